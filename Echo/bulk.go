@@ -0,0 +1,168 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/mail"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// maxBulkItems caps the number of items accepted by a single bulk request
+const maxBulkItems = 200
+
+// BulkItemResult reports the outcome of one item in a bulk request
+type BulkItemResult struct {
+	Index  int               `json:"index"`
+	Status string            `json:"status"`
+	ID     int               `json:"id,omitempty"`
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// BulkSummary aggregates the per-item results
+type BulkSummary struct {
+	Total     int  `json:"total"`
+	Succeeded int  `json:"succeeded"`
+	Failed    int  `json:"failed"`
+	Atomic    bool `json:"atomic"`
+}
+
+// Bulk item statuses
+const (
+	bulkStatusCreated  = "created"
+	bulkStatusInvalid  = "invalid"
+	bulkStatusSkipped  = "skipped"
+	bulkStatusConflict = "conflict"
+)
+
+// isAtomic reports whether the request asked for all-or-nothing semantics
+func isAtomic(c echo.Context) bool {
+	v := c.QueryParam("atomic")
+	return v == "1" || v == "true"
+}
+
+// bulkStatusCode picks the response status for a bulk request:
+// 201/200 when every item succeeded, 207 for partial success and 422 when nothing was applied
+func bulkStatusCode(summary BulkSummary, allOK int) int {
+	switch {
+	case summary.Failed == 0:
+		return allOK
+	case summary.Succeeded == 0:
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusMultiStatus
+	}
+}
+
+// validateUser returns per-field validation errors for a user payload
+func validateUser(u User) map[string]string {
+	errs := map[string]string{}
+	if strings.TrimSpace(u.Name) == "" {
+		errs["name"] = "is required"
+	}
+	if strings.TrimSpace(u.Email) == "" {
+		errs["email"] = "is required"
+	} else if _, err := mail.ParseAddress(u.Email); err != nil {
+		errs["email"] = "must be a valid email address"
+	}
+	return errs
+}
+
+// bulkCreate creates up to maxBulkItems users.
+// By default valid items are created and invalid ones reported; with ?atomic=1
+// the whole batch is rejected if any item is invalid.
+func (api userAPI) bulkCreate(c echo.Context) error {
+	var batch []User
+	if err := c.Bind(&batch); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "Request body must be a JSON array of users"),
+		})
+	}
+	if len(batch) == 0 || len(batch) > maxBulkItems {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "Batch must contain between 1 and 200 users"),
+		})
+	}
+
+	atomic := isAtomic(c)
+	results := make([]BulkItemResult, len(batch))
+	var valid []User
+	var validIdx []int
+	for i, u := range batch {
+		results[i] = BulkItemResult{Index: i}
+		if errs := validateUser(u); len(errs) > 0 {
+			results[i].Status = bulkStatusInvalid
+			results[i].Errors = translateFieldErrors(c, errs)
+			continue
+		}
+		valid = append(valid, u)
+		validIdx = append(validIdx, i)
+	}
+
+	summary := BulkSummary{Total: len(batch), Atomic: atomic}
+	if atomic && len(valid) < len(batch) {
+		// Nothing is written; valid items are reported as skipped
+		for _, i := range validIdx {
+			results[i].Status = bulkStatusSkipped
+		}
+	} else if len(valid) > 0 {
+		created, errs := api.store.CreateMany(valid, atomic)
+		for n, i := range validIdx {
+			var conflict *ErrConflict
+			switch {
+			case errs[n] == nil:
+				results[i].Status = bulkStatusCreated
+				results[i].ID = created[n].ID
+				summary.Succeeded++
+			case errors.As(errs[n], &conflict):
+				results[i].Status = bulkStatusConflict
+				results[i].Errors = map[string]string{conflict.Field: tr(c, "already exists")}
+			default:
+				// Atomic batches reject every item once one conflicts
+				results[i].Status = bulkStatusSkipped
+			}
+		}
+	}
+	summary.Failed = summary.Total - summary.Succeeded
+
+	return c.JSON(bulkStatusCode(summary, http.StatusCreated), map[string]interface{}{
+		"results": results,
+		"summary": summary,
+	})
+}
+
+// bulkDeleteProducts deletes the products whose IDs are given as a JSON array.
+// With ?atomic=1 nothing is deleted unless every ID exists.
+func bulkDeleteProducts(c echo.Context) error {
+	var ids []int
+	if err := c.Bind(&ids); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "Request body must be a JSON array of product IDs"),
+		})
+	}
+	if len(ids) == 0 || len(ids) > maxBulkItems {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "Batch must contain between 1 and 200 IDs"),
+		})
+	}
+
+	atomic := isAtomic(c)
+	deleted, missing := products.DeleteMany(ids, atomic)
+
+	summary := BulkSummary{
+		Total:     len(deleted) + len(missing),
+		Succeeded: len(deleted),
+		Failed:    len(missing),
+		Atomic:    atomic,
+	}
+	if atomic && len(missing) > 0 {
+		summary.Failed = summary.Total
+	}
+
+	return c.JSON(bulkStatusCode(summary, http.StatusOK), map[string]interface{}{
+		"deleted": deleted,
+		"missing": missing,
+		"summary": summary,
+	})
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+type bulkUsersResponse struct {
+	Results []BulkItemResult `json:"results"`
+	Summary BulkSummary      `json:"summary"`
+}
+
+type bulkDeleteResponse struct {
+	Deleted []int       `json:"deleted"`
+	Missing []int       `json:"missing"`
+	Summary BulkSummary `json:"summary"`
+}
+
+const mixedUserBatch = `[
+	{"name":"Alice","email":"alice@example.com"},
+	{"name":"","email":"nobody@example.com"},
+	{"name":"Carol","email":"not-an-email"},
+	{"name":"Dave","email":"dave@example.com"}
+]`
+
+// TestBulkCreateUsersBestEffort creates the valid items and reports the invalid ones
+func TestBulkCreateUsersBestEffort(t *testing.T) {
+	withFreshUsers(t)
+	e := newServer()
+
+	rec := doRequest(e, http.MethodPost, "/api/users/bulk", mixedUserBatch)
+	if rec.Code != http.StatusMultiStatus {
+		t.Fatalf("status = %d, want 207: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp bulkUsersResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Summary.Succeeded != 2 || resp.Summary.Failed != 2 || resp.Summary.Atomic {
+		t.Errorf("summary = %+v", resp.Summary)
+	}
+
+	wantStatus := []string{bulkStatusCreated, bulkStatusInvalid, bulkStatusInvalid, bulkStatusCreated}
+	for i, want := range wantStatus {
+		if resp.Results[i].Status != want {
+			t.Errorf("result %d status = %q, want %q", i, resp.Results[i].Status, want)
+		}
+	}
+	if resp.Results[1].Errors["name"] == "" || resp.Results[2].Errors["email"] == "" {
+		t.Errorf("missing field errors: %+v", resp.Results)
+	}
+	if resp.Results[0].ID != 4 || resp.Results[3].ID != 5 {
+		t.Errorf("unexpected IDs: %+v", resp.Results)
+	}
+	if n := len(users.List()); n != 5 {
+		t.Errorf("store has %d users, want 5", n)
+	}
+}
+
+// TestBulkCreateUsersAtomic rejects the whole batch when any item is invalid
+func TestBulkCreateUsersAtomic(t *testing.T) {
+	withFreshUsers(t)
+	e := newServer()
+
+	rec := doRequest(e, http.MethodPost, "/api/users/bulk?atomic=1", mixedUserBatch)
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want 422", rec.Code)
+	}
+	var resp bulkUsersResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Results[0].Status != bulkStatusSkipped || resp.Results[1].Status != bulkStatusInvalid {
+		t.Errorf("unexpected results: %+v", resp.Results)
+	}
+	if n := len(users.List()); n != 3 {
+		t.Errorf("store has %d users, want 3 (nothing created)", n)
+	}
+
+	rec = doRequest(e, http.MethodPost, "/api/users/bulk?atomic=1",
+		`[{"name":"Alice","email":"alice@example.com"},{"name":"Dave","email":"dave@example.com"}]`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("valid atomic batch status = %d, want 201", rec.Code)
+	}
+	if n := len(users.List()); n != 5 {
+		t.Errorf("store has %d users, want 5", n)
+	}
+}
+
+// TestBulkCreateUsersLimits rejects empty and oversized batches
+func TestBulkCreateUsersLimits(t *testing.T) {
+	withFreshUsers(t)
+	e := newServer()
+
+	batch := make([]User, maxBulkItems+1)
+	body, _ := json.Marshal(batch)
+	for _, payload := range []string{"[]", string(body), `{"name":"not an array"}`} {
+		if rec := doRequest(e, http.MethodPost, "/api/users/bulk", payload); rec.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want 400", rec.Code)
+		}
+	}
+}
+
+// TestBulkDeleteProducts covers best-effort and atomic deletion with missing IDs
+func TestBulkDeleteProducts(t *testing.T) {
+	original := products
+	t.Cleanup(func() { products = original })
+
+	tests := []struct {
+		name        string
+		query       string
+		wantCode    int
+		wantDeleted []int
+		wantMissing []int
+		wantLeft    int
+	}{
+		{"best effort", "", http.StatusMultiStatus, []int{1, 3}, []int{42}, 1},
+		{"atomic", "?atomic=1", http.StatusUnprocessableEntity, []int{}, []int{42}, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			products = NewProductStore(seedProducts())
+			e := newServer()
+
+			rec := doRequest(e, http.MethodDelete, "/api/products/bulk"+tt.query, `[1, 42, 3]`)
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantCode, rec.Body.String())
+			}
+			var resp bulkDeleteResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if !equalInts(resp.Deleted, tt.wantDeleted) || !equalInts(resp.Missing, tt.wantMissing) {
+				t.Errorf("deleted=%v missing=%v", resp.Deleted, resp.Missing)
+			}
+			if n := len(products.List()); n != tt.wantLeft {
+				t.Errorf("%d products left, want %d", n, tt.wantLeft)
+			}
+		})
+	}
+
+	products = NewProductStore(seedProducts())
+	e := newServer()
+	if rec := doRequest(e, http.MethodDelete, "/api/products/bulk?atomic=1", `[1, 2]`); rec.Code != http.StatusOK {
+		t.Errorf("all-present atomic delete status = %d, want 200", rec.Code)
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+// TestCreateUserConflicts rejects duplicate emails (any case) and usernames with 409
+func TestCreateUserConflicts(t *testing.T) {
+	withFreshUsers(t)
+	e := newServer()
+
+	rec := doRequest(e, http.MethodPost, "/api/users", `{"name":"Ann","email":"ann@example.com","username":"ann"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("first create = %d", rec.Code)
+	}
+
+	tests := []struct {
+		name      string
+		body      string
+		wantField string
+	}{
+		{"same email", `{"name":"Ann 2","email":"ann@example.com"}`, "email"},
+		{"email different case", `{"name":"Ann 3","email":"  ANN@Example.com"}`, "email"},
+		{"seeded email", `{"name":"Johnny","email":"John@example.com"}`, "email"},
+		{"same username", `{"name":"Other","email":"other@example.com","username":"ann"}`, "username"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := doRequest(e, http.MethodPost, "/api/users", tt.body)
+			if rec.Code != http.StatusConflict {
+				t.Fatalf("status = %d, want 409: %s", rec.Code, rec.Body.String())
+			}
+			if field := decodeBody(t, rec)["field"]; field != tt.wantField {
+				t.Errorf("field = %v, want %s", field, tt.wantField)
+			}
+		})
+	}
+
+	if n := len(users.List()); n != 4 {
+		t.Errorf("store has %d users, want 4", n)
+	}
+}
+
+// TestUpdateUserConflicts rejects taking another user's email but allows keeping your own
+func TestUpdateUserConflicts(t *testing.T) {
+	withFreshUsers(t)
+	e := newServer()
+
+	rec := doRequest(e, http.MethodPut, "/api/users/1", `{"name":"John","email":"JANE@example.com"}`)
+	if rec.Code != http.StatusConflict || decodeBody(t, rec)["field"] != "email" {
+		t.Fatalf("update to taken email = %d: %s", rec.Code, rec.Body.String())
+	}
+	if u, _ := users.Get(1); u.Email != "john@example.com" {
+		t.Errorf("conflicting update was applied: %+v", u)
+	}
+
+	// Re-saving your own email with different casing is not a conflict
+	if rec := doRequest(e, http.MethodPut, "/api/users/1", `{"name":"John","email":"John@Example.com"}`); rec.Code != http.StatusOK {
+		t.Errorf("update own email = %d", rec.Code)
+	}
+
+	// The old email is released when it changes
+	if rec := doRequest(e, http.MethodPut, "/api/users/1", `{"name":"John","email":"johnny@example.com"}`); rec.Code != http.StatusOK {
+		t.Fatalf("change email = %d", rec.Code)
+	}
+	if rec := doRequest(e, http.MethodPost, "/api/users", `{"name":"New John","email":"john@example.com"}`); rec.Code != http.StatusCreated {
+		t.Errorf("reuse of released email = %d", rec.Code)
+	}
+}
+
+// TestDeleteUserFreesEmail checks a deleted user's email and username can be reused
+func TestDeleteUserFreesEmail(t *testing.T) {
+	withFreshUsers(t)
+	e := newServer()
+
+	doRequest(e, http.MethodPost, "/api/users", `{"name":"Temp","email":"temp@example.com","username":"temp"}`)
+	if rec := doRequest(e, http.MethodDelete, "/api/users/4", ""); rec.Code != http.StatusOK {
+		t.Fatalf("delete = %d", rec.Code)
+	}
+	if rec := doRequest(e, http.MethodPost, "/api/users", `{"name":"Temp 2","email":"TEMP@example.com","username":"temp"}`); rec.Code != http.StatusCreated {
+		t.Errorf("recreate after delete = %d: %s", rec.Code, rec.Body.String())
+	}
+	if _, err := users.FindByEmail("temp@example.com"); err != nil {
+		t.Errorf("FindByEmail after recreate: %v", err)
+	}
+}
+
+// TestBulkCreateConflicts reports duplicates against the store and within the batch
+func TestBulkCreateConflicts(t *testing.T) {
+	withFreshUsers(t)
+	e := newServer()
+
+	batch := `[
+		{"name":"Jane Again","email":"jane@example.com"},
+		{"name":"Zed","email":"zed@example.com"},
+		{"name":"Zed Twin","email":"ZED@example.com"}
+	]`
+
+	rec := doRequest(e, http.MethodPost, "/api/users/bulk?atomic=1", batch)
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("atomic status = %d", rec.Code)
+	}
+	if n := len(users.List()); n != 3 {
+		t.Fatalf("atomic batch stored users: %d", n)
+	}
+
+	rec = doRequest(e, http.MethodPost, "/api/users/bulk", batch)
+	var resp bulkUsersResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{bulkStatusConflict, bulkStatusCreated, bulkStatusConflict}
+	for i, status := range want {
+		if resp.Results[i].Status != status {
+			t.Errorf("result %d = %q, want %q", i, resp.Results[i].Status, status)
+		}
+	}
+	if resp.Results[0].Errors["email"] == "" {
+		t.Errorf("conflict should name the field: %+v", resp.Results[0])
+	}
+}
+
+// TestErrConflictIsTyped checks callers can inspect the conflicting field
+func TestErrConflictIsTyped(t *testing.T) {
+	store := NewUserStore(seedUsers())
+	_, err := store.Create(User{Name: "Dup", Email: "bob@example.com"})
+
+	var conflict *ErrConflict
+	if !errors.As(err, &conflict) || conflict.Field != "email" {
+		t.Fatalf("err = %v, want *ErrConflict on email", err)
+	}
+}
+
+// TestUpdateUserRequiresNameAndEmail rejects an update that would blank either field
+func TestUpdateUserRequiresNameAndEmail(t *testing.T) {
+	withFreshUsers(t)
+	e := newServer()
+
+	for _, body := range []string{`{"name":"John","email":""}`, `{"name":"","email":"john@example.com"}`} {
+		if rec := doRequest(e, http.MethodPut, "/api/v1/users/1", body); rec.Code != http.StatusBadRequest {
+			t.Errorf("update with %s = %d, want 400", body, rec.Code)
+		}
+	}
+	if u, _ := users.Get(1); u.Email != "john@example.com" || u.Name == "" {
+		t.Errorf("invalid update was applied: %+v", u)
+	}
+}
+
+// TestEmptyEmailNeverConflicts checks users stored without an email don't claim ""
+func TestEmptyEmailNeverConflicts(t *testing.T) {
+	store := NewUserStore(seedUsers())
+	if _, err := store.Create(User{Name: "No Email"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Create(User{Name: "Also No Email", Email: "  "}); err != nil {
+		t.Errorf("second user without an email: %v", err)
+	}
+	if _, err := store.FindByEmail(""); !errors.Is(err, ErrNotFound) {
+		t.Errorf("FindByEmail(\"\") = %v, want ErrNotFound", err)
+	}
+
+	_, errs := store.CreateMany([]User{{Name: "A"}, {Name: "B"}}, true)
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("batch item %d: %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// defaultMaxBodyLog is the default number of body bytes captured per direction
+const defaultMaxBodyLog = 4096
+
+// redactedValue replaces sensitive values in logged bodies
+const redactedValue = "[REDACTED]"
+
+// BodyLogConfig configures the debug body logging middleware
+type BodyLogConfig struct {
+	// MaxBodyBytes caps how much of each request and response body is captured
+	MaxBodyBytes int
+	// SensitiveKeys are matched case-insensitively as substrings of JSON and form field names
+	SensitiveKeys []string
+	// Output receives one JSON line per request; defaults to the Echo logger output
+	Output io.Writer
+}
+
+// bodyLogConfigFromEnv enables body logging when DEBUG_HTTP=1;
+// DEBUG_HTTP_MAX_BODY overrides the capture size
+func bodyLogConfigFromEnv() (BodyLogConfig, bool) {
+	if os.Getenv("DEBUG_HTTP") != "1" {
+		return BodyLogConfig{}, false
+	}
+	cfg := BodyLogConfig{}
+	if n, err := strconv.Atoi(os.Getenv("DEBUG_HTTP_MAX_BODY")); err == nil && n > 0 {
+		cfg.MaxBodyBytes = n
+	}
+	return cfg, true
+}
+
+// bodyLogEntry is the structured record written for every request
+type bodyLogEntry struct {
+	Time         string      `json:"time"`
+	RequestID    string      `json:"request_id"`
+	Method       string      `json:"method"`
+	URI          string      `json:"uri"`
+	Status       int         `json:"status"`
+	Latency      int64       `json:"latency"`
+	LatencyHuman string      `json:"latency_human"`
+	Request      bodySummary `json:"request"`
+	Response     bodySummary `json:"response"`
+}
+
+type bodySummary struct {
+	ContentType string `json:"content_type,omitempty"`
+	Body        string `json:"body,omitempty"`
+	Truncated   bool   `json:"truncated,omitempty"`
+	Omitted     string `json:"omitted,omitempty"`
+}
+
+// bodyLogger captures request and response bodies for troubleshooting.
+// The bytes seen by the handler and the client are never modified.
+func bodyLogger(cfg BodyLogConfig) echo.MiddlewareFunc {
+	if cfg.MaxBodyBytes <= 0 {
+		cfg.MaxBodyBytes = defaultMaxBodyLog
+	}
+	if len(cfg.SensitiveKeys) == 0 {
+		cfg.SensitiveKeys = []string{"password", "token", "secret"}
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			req := c.Request()
+
+			// Read at most MaxBodyBytes+1 up front and stitch them back in front of
+			// the unread remainder so the handler sees the original stream
+			var reqHead []byte
+			if req.Body != nil {
+				reqHead, _ = io.ReadAll(io.LimitReader(req.Body, int64(cfg.MaxBodyBytes)+1))
+				req.Body = readCloser{io.MultiReader(bytes.NewReader(reqHead), req.Body), req.Body}
+			}
+
+			capture := &cappedBuffer{limit: cfg.MaxBodyBytes}
+			res := c.Response()
+			res.Writer = &captureWriter{ResponseWriter: res.Writer, capture: capture}
+
+			err := next(c)
+			if err != nil {
+				// Let the central error handler write the response now so it is
+				// captured too; outer middleware still sees the error
+				c.Error(err)
+			}
+
+			latency := time.Since(start)
+			reqTruncated := len(reqHead) > cfg.MaxBodyBytes
+			if reqTruncated {
+				reqHead = reqHead[:cfg.MaxBodyBytes]
+			}
+
+			requestID := res.Header().Get(echo.HeaderXRequestID)
+			if requestID == "" {
+				requestID = req.Header.Get(echo.HeaderXRequestID)
+			}
+
+			entry := bodyLogEntry{
+				Time:         start.Format(time.RFC3339Nano),
+				RequestID:    requestID,
+				Method:       req.Method,
+				URI:          req.RequestURI,
+				Status:       res.Status,
+				Latency:      latency.Nanoseconds(),
+				LatencyHuman: latency.String(),
+				Request:      summarizeBody(req.Header.Get(echo.HeaderContentType), reqHead, reqTruncated, cfg.SensitiveKeys),
+				Response:     summarizeBody(res.Header().Get(echo.HeaderContentType), capture.buf.Bytes(), capture.Truncated(), cfg.SensitiveKeys),
+			}
+
+			out := cfg.Output
+			if out == nil {
+				out = c.Echo().Logger.Output()
+			}
+			if line, mErr := json.Marshal(entry); mErr == nil {
+				out.Write(append(line, '\n'))
+			}
+			return err
+		}
+	}
+}
+
+// summarizeBody renders a captured body for logging, redacting sensitive fields
+// and omitting binary payloads
+func summarizeBody(contentType string, body []byte, truncated bool, sensitive []string) bodySummary {
+	summary := bodySummary{ContentType: contentType, Truncated: truncated}
+	if len(body) == 0 {
+		return summary
+	}
+	if isBinaryContentType(contentType) {
+		summary.Omitted = "binary content"
+		return summary
+	}
+	summary.Body = redactBody(contentType, body, sensitive)
+	return summary
+}
+
+func isBinaryContentType(contentType string) bool {
+	ct := strings.ToLower(contentType)
+	for _, prefix := range []string{"image/", "audio/", "video/", "font/", "multipart/",
+		"application/octet-stream", "application/pdf", "application/zip", "application/gzip"} {
+		if strings.HasPrefix(ct, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func isSensitiveKey(key string, sensitive []string) bool {
+	k := strings.ToLower(key)
+	for _, s := range sensitive {
+		if strings.Contains(k, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonStringField matches "key": "value" pairs for redacting bodies that are not valid JSON (e.g. truncated)
+var jsonStringField = regexp.MustCompile(`"([^"\\]+)"\s*:\s*("(?:[^"\\]|\\.)*"?|[^,}\]\s]+)`)
+
+func redactBody(contentType string, body []byte, sensitive []string) string {
+	ct := strings.ToLower(contentType)
+	switch {
+	case strings.HasPrefix(ct, echo.MIMEApplicationForm):
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			break
+		}
+		for key := range values {
+			if isSensitiveKey(key, sensitive) {
+				values[key] = []string{redactedValue}
+			}
+		}
+		return values.Encode()
+
+	case strings.Contains(ct, "json"):
+		var doc interface{}
+		if err := json.Unmarshal(body, &doc); err == nil {
+			if out, err := json.Marshal(redactJSON(doc, sensitive)); err == nil {
+				return string(out)
+			}
+		}
+		return jsonStringField.ReplaceAllStringFunc(string(body), func(pair string) string {
+			key := jsonStringField.FindStringSubmatch(pair)[1]
+			if !isSensitiveKey(key, sensitive) {
+				return pair
+			}
+			return `"` + key + `":"` + redactedValue + `"`
+		})
+	}
+	return string(body)
+}
+
+// redactJSON walks decoded JSON replacing values of sensitive keys
+func redactJSON(v interface{}, sensitive []string) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for key, value := range t {
+			if isSensitiveKey(key, sensitive) {
+				t[key] = redactedValue
+			} else {
+				t[key] = redactJSON(value, sensitive)
+			}
+		}
+	case []interface{}:
+		for i := range t {
+			t[i] = redactJSON(t[i], sensitive)
+		}
+	}
+	return v
+}
+
+// cappedBuffer keeps the first limit bytes written and counts the rest
+type cappedBuffer struct {
+	buf   bytes.Buffer
+	limit int
+	total int
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	b.total += len(p)
+	if room := b.limit - b.buf.Len(); room > 0 {
+		if len(p) > room {
+			b.buf.Write(p[:room])
+		} else {
+			b.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+// Truncated reports whether more bytes were written than captured
+func (b *cappedBuffer) Truncated() bool {
+	return b.total > b.buf.Len()
+}
+
+// captureWriter copies response bytes into a capped buffer while writing them unchanged
+type captureWriter struct {
+	http.ResponseWriter
+	capture *cappedBuffer
+}
+
+func (w *captureWriter) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	w.capture.Write(p[:n])
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach Flush/Hijack on the original writer
+func (w *captureWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// readCloser pairs a replacement reader with the original body's Close
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+// newDebugServer builds the server with DEBUG_HTTP enabled and captures its log lines
+func newDebugServer(t *testing.T, maxBody string) (*echo.Echo, *bytes.Buffer) {
+	t.Helper()
+	t.Setenv("DEBUG_HTTP", "1")
+	t.Setenv("DEBUG_HTTP_MAX_BODY", maxBody)
+	e := newServer()
+	logs := &bytes.Buffer{}
+	e.Logger.SetOutput(logs)
+	return e, logs
+}
+
+// lastBodyLogEntry returns the most recent body log line; the access log
+// middleware writes to the same output, so its lines are skipped
+func lastBodyLogEntry(t *testing.T, logs *bytes.Buffer) bodyLogEntry {
+	t.Helper()
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if !strings.Contains(lines[i], `"request":`) {
+			continue
+		}
+		var entry bodyLogEntry
+		if err := json.Unmarshal([]byte(lines[i]), &entry); err != nil {
+			t.Fatalf("log line is not JSON: %q", lines[i])
+		}
+		return entry
+	}
+	t.Fatalf("no body log entry in %q", logs.String())
+	return bodyLogEntry{}
+}
+
+// TestBodyLoggerRedactsSecrets checks JSON and form fields named like secrets are masked
+func TestBodyLoggerRedactsSecrets(t *testing.T) {
+	withFreshUsers(t)
+	e, logs := newDebugServer(t, "")
+
+	doRequest(e, http.MethodPost, "/api/users",
+		`{"name":"Eve","email":"eve@example.com","password":"hunter2","auth":{"api_token":"abc123"},"keys":[{"client_secret":"s3"}]}`)
+
+	entry := lastBodyLogEntry(t, logs)
+	for _, leaked := range []string{"hunter2", "abc123", "s3\""} {
+		if strings.Contains(entry.Request.Body, leaked) {
+			t.Errorf("request log leaked %q: %s", leaked, entry.Request.Body)
+		}
+	}
+	if strings.Count(entry.Request.Body, redactedValue) != 3 || !strings.Contains(entry.Request.Body, "eve@example.com") {
+		t.Errorf("unexpected redacted body: %s", entry.Request.Body)
+	}
+	if entry.Status != http.StatusCreated || entry.RequestID == "" || entry.Method != http.MethodPost || entry.LatencyHuman == "" {
+		t.Errorf("missing request metadata: %+v", entry)
+	}
+	if !strings.Contains(entry.Response.Body, `"id":4`) {
+		t.Errorf("response body not captured: %s", entry.Response.Body)
+	}
+
+	// Truncated JSON falls back to pattern-based redaction
+	if got := redactBody(echo.MIMEApplicationJSON, []byte(`{"user":"x","password":"hunt`), []string{"password"}); strings.Contains(got, "hunt") {
+		t.Errorf("truncated JSON leaked secret: %s", got)
+	}
+
+	// Form posts such as the login page are redacted too
+	form := redactBody(echo.MIMEApplicationForm, []byte(url.Values{"email": {"a@b.c"}, "password": {"demo"}}.Encode()), []string{"password"})
+	if strings.Contains(form, "demo") || !strings.Contains(form, "a%40b.c") {
+		t.Errorf("unexpected form redaction: %s", form)
+	}
+}
+
+// TestBodyLoggerTruncatesAtCap checks bodies are cut at the configured size
+func TestBodyLoggerTruncatesAtCap(t *testing.T) {
+	withFreshUsers(t)
+	e, logs := newDebugServer(t, "16")
+
+	rec := doRequest(e, http.MethodPost, "/api/users", `{"name":"Truncated User","email":"trunc@example.com"}`)
+	entry := lastBodyLogEntry(t, logs)
+
+	if len(entry.Request.Body) > 16 || !entry.Request.Truncated {
+		t.Errorf("request not truncated: %+v", entry.Request)
+	}
+	if !entry.Response.Truncated || !strings.HasPrefix(rec.Body.String(), `{"id":4,"name":"`) {
+		t.Errorf("response not truncated: %+v", entry.Response)
+	}
+	// The handler still saw the whole request body
+	if rec.Code != http.StatusCreated || !strings.Contains(rec.Body.String(), "trunc@example.com") {
+		t.Errorf("handler output changed: %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+// TestBodyLoggerSkipsBinary checks multipart uploads are not dumped
+func TestBodyLoggerSkipsBinary(t *testing.T) {
+	e, logs := newDebugServer(t, "")
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, _ := mw.CreateFormFile("file", "photo.png")
+	part.Write([]byte("\x89PNG\r\n\x1a\nbinary"))
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/upload", &body)
+	req.Header.Set(echo.HeaderContentType, mw.FormDataContentType())
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	entry := lastBodyLogEntry(t, logs)
+	if entry.Request.Body != "" || entry.Request.Omitted == "" {
+		t.Errorf("binary request body logged: %+v", entry.Request)
+	}
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "photo.png") {
+		t.Errorf("upload failed through logger: %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+// TestBodyLoggerDoesNotChangeResponses compares handler output with and without the middleware
+func TestBodyLoggerDoesNotChangeResponses(t *testing.T) {
+	requests := []struct{ method, path, body string }{
+		{http.MethodGet, "/api/users", ""},
+		{http.MethodGet, "/api/users/999", ""},
+		{http.MethodPost, "/api/users", `{"name":"Same","email":"same@example.com"}`},
+		{http.MethodPost, "/api/users", `{"name":`},
+		{http.MethodGet, "/api/products/export?format=csv", ""},
+		{http.MethodGet, "/api/error", ""},
+	}
+
+	// Each run gets its own seeded store, swapped in before the server binds to it
+	run := func(build func() *echo.Echo) []*httptest.ResponseRecorder {
+		withFreshUsers(t)
+		e := build()
+		var out []*httptest.ResponseRecorder
+		for _, r := range requests {
+			out = append(out, doRequest(e, r.method, r.path, r.body))
+		}
+		return out
+	}
+
+	plain := run(newServer)
+	debug := run(func() *echo.Echo {
+		e, _ := newDebugServer(t, "8")
+		return e
+	})
+
+	for i, r := range requests {
+		p, d := plain[i], debug[i]
+		if p.Code != d.Code || !bytes.Equal(p.Body.Bytes(), d.Body.Bytes()) {
+			t.Errorf("%s %s: plain %d %q vs debug %d %q", r.method, r.path, p.Code, p.Body, d.Code, d.Body)
+		}
+		for _, h := range []string{echo.HeaderContentType, echo.HeaderContentDisposition} {
+			if p.Header().Get(h) != d.Header().Get(h) {
+				t.Errorf("%s %s: header %s differs", r.method, r.path, h)
+			}
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// exportFormat describes one of the supported download formats
+type exportFormat struct {
+	delimiter   rune
+	contentType string
+	extension   string
+}
+
+var exportFormats = map[string]exportFormat{
+	"csv": {delimiter: ',', contentType: "text/csv; charset=utf-8", extension: "csv"},
+	"tsv": {delimiter: '\t', contentType: "text/tab-separated-values; charset=utf-8", extension: "tsv"},
+}
+
+// utf8BOM lets Excel detect UTF-8 encoding when opening the downloaded file
+const utf8BOM = "\uFEFF"
+
+var productExportHeader = []string{"id", "name", "price", "category", "description"}
+
+// exportProducts streams products as CSV or TSV.
+// Query parameters: format (csv|tsv, default csv), category (optional filter)
+// and bom=1 to prepend a UTF-8 byte order mark for Excel.
+func exportProducts(c echo.Context) error {
+	formatName := c.QueryParam("format")
+	if formatName == "" {
+		formatName = "csv"
+	}
+
+	format, ok := exportFormats[formatName]
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "Unsupported export format, use csv or tsv"),
+		})
+	}
+
+	rows := products.Filter(c.QueryParam("category"))
+	withBOM := c.QueryParam("bom") == "1"
+
+	// Write rows through a pipe so the response is streamed rather than
+	// buffered in memory for large datasets
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(writeProductsDelimited(pw, rows, format.delimiter, withBOM))
+	}()
+	defer pr.Close()
+
+	c.Response().Header().Set(echo.HeaderContentDisposition,
+		fmt.Sprintf(`attachment; filename="products.%s"`, format.extension))
+	return c.Stream(http.StatusOK, format.contentType, pr)
+}
+
+// writeProductsDelimited writes a header row followed by one row per product.
+// encoding/csv takes care of quoting fields containing delimiters, quotes or newlines.
+func writeProductsDelimited(w io.Writer, rows []Product, delimiter rune, withBOM bool) error {
+	if withBOM {
+		if _, err := io.WriteString(w, utf8BOM); err != nil {
+			return err
+		}
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Comma = delimiter
+
+	if err := cw.Write(productExportHeader); err != nil {
+		return err
+	}
+
+	for _, product := range rows {
+		record := []string{
+			strconv.Itoa(product.ID),
+			product.Name,
+			strconv.FormatFloat(product.Price, 'f', 2, 64),
+			product.Category,
+			product.Description,
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
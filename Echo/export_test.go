@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+// withTrickyProduct swaps in a fresh product store that also holds a product
+// whose name and description need quoting
+func withTrickyProduct(t *testing.T) {
+	t.Helper()
+	original := products
+	products = NewProductStore(seedProducts())
+	products.Create(Product{
+		Name:        `Poster "Deluxe"`,
+		Price:       12.5,
+		Category:    "Decor",
+		Description: "Large, glossy print\nwith \"quoted\" text,\tand a tab",
+	})
+	t.Cleanup(func() { products = original })
+}
+
+func performExport(t *testing.T, query string) *httptest.ResponseRecorder {
+	t.Helper()
+	e := newServer()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/products/export"+query, nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func expectedRecords(rows []Product) [][]string {
+	records := [][]string{productExportHeader}
+	for _, p := range rows {
+		records = append(records, []string{
+			strconv.Itoa(p.ID), p.Name, strconv.FormatFloat(p.Price, 'f', 2, 64), p.Category, p.Description,
+		})
+	}
+	return records
+}
+
+func assertRecords(t *testing.T, got, want [][]string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d records, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if strings.Join(got[i], "|") != strings.Join(want[i], "|") {
+			t.Errorf("record %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+// TestExportProductsCSV round-trips the CSV export through encoding/csv
+func TestExportProductsCSV(t *testing.T) {
+	withTrickyProduct(t)
+
+	rec := performExport(t, "?format=csv")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if ct := rec.Header().Get(echo.HeaderContentType); !strings.HasPrefix(ct, "text/csv") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if cd := rec.Header().Get(echo.HeaderContentDisposition); cd != `attachment; filename="products.csv"` {
+		t.Errorf("Content-Disposition = %q", cd)
+	}
+
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("parse CSV: %v", err)
+	}
+	assertRecords(t, records, expectedRecords(products.List()))
+}
+
+// TestExportProductsTSVWithCategory checks the TSV format and category filter
+func TestExportProductsTSVWithCategory(t *testing.T) {
+	withTrickyProduct(t)
+
+	rec := performExport(t, "?format=tsv&category=Decor")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+
+	reader := csv.NewReader(rec.Body)
+	reader.Comma = '\t'
+	records, err := reader.ReadAll()
+	if err != nil {
+		t.Fatalf("parse TSV: %v", err)
+	}
+	assertRecords(t, records, expectedRecords(products.Filter("Decor")))
+}
+
+// TestExportProductsOptions covers the BOM option and unknown formats
+func TestExportProductsOptions(t *testing.T) {
+	rec := performExport(t, "?bom=1")
+	if !strings.HasPrefix(rec.Body.String(), utf8BOM+"id,name") {
+		t.Errorf("expected BOM-prefixed CSV, got %q", rec.Body.String()[:20])
+	}
+
+	rec = performExport(t, "?format=xml")
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", rec.Code)
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultLocale    = "en"
+	localeContextKey = "locale"
+)
+
+// translations maps a locale to translations of the canonical (English) error
+// messages. Missing entries fall back to the English text.
+var translations = map[string]map[string]string{
+	"es": {
+		"Invalid user ID":                                  "ID de usuario no válido",
+		"Invalid product ID":                               "ID de producto no válido",
+		"Invalid request body":                             "Cuerpo de la solicitud no válido",
+		"User not found":                                   "Usuario no encontrado",
+		"Product not found":                                "Producto no encontrado",
+		"Name and email are required":                      "El nombre y el correo electrónico son obligatorios",
+		"Name and valid price are required":                "El nombre y un precio válido son obligatorios",
+		"Query parameter 'q' is required":                  "El parámetro de consulta 'q' es obligatorio",
+		"A user with this %s already exists":               "Ya existe un usuario con este %s",
+		"No file uploaded":                                 "No se ha subido ningún archivo",
+		"Unsupported export format, use csv or tsv":        "Formato de exportación no compatible, use csv o tsv",
+		"Request body must be a JSON array of users":       "El cuerpo debe ser un array JSON de usuarios",
+		"Batch must contain between 1 and 200 users":       "El lote debe contener entre 1 y 200 usuarios",
+		"Request body must be a JSON array of product IDs": "El cuerpo debe ser un array JSON de IDs de producto",
+		"Batch must contain between 1 and 200 IDs":         "El lote debe contener entre 1 y 200 IDs",
+		"is required":                                      "es obligatorio",
+		"must be a valid email address":                    "debe ser una dirección de correo válida",
+		"already exists":                                   "ya existe",
+		"This is a demo error":                             "Este es un error de demostración",
+		"Not Found":                                        "No encontrado",
+		"Method Not Allowed":                               "Método no permitido",
+		"Internal Server Error":                            "Error interno del servidor",
+	},
+	"de": {
+		"Invalid user ID":                                  "Ungültige Benutzer-ID",
+		"Invalid product ID":                               "Ungültige Produkt-ID",
+		"Invalid request body":                             "Ungültiger Anfragetext",
+		"User not found":                                   "Benutzer nicht gefunden",
+		"Product not found":                                "Produkt nicht gefunden",
+		"Name and email are required":                      "Name und E-Mail sind erforderlich",
+		"Name and valid price are required":                "Name und gültiger Preis sind erforderlich",
+		"Query parameter 'q' is required":                  "Der Abfrageparameter 'q' ist erforderlich",
+		"A user with this %s already exists":               "Ein Benutzer mit diesem Feld (%s) existiert bereits",
+		"No file uploaded":                                 "Keine Datei hochgeladen",
+		"Unsupported export format, use csv or tsv":        "Nicht unterstütztes Exportformat, verwenden Sie csv oder tsv",
+		"Request body must be a JSON array of users":       "Der Anfragetext muss ein JSON-Array von Benutzern sein",
+		"Batch must contain between 1 and 200 users":       "Der Stapel muss zwischen 1 und 200 Benutzer enthalten",
+		"Request body must be a JSON array of product IDs": "Der Anfragetext muss ein JSON-Array von Produkt-IDs sein",
+		"Batch must contain between 1 and 200 IDs":         "Der Stapel muss zwischen 1 und 200 IDs enthalten",
+		"is required":                                      "ist erforderlich",
+		"must be a valid email address":                    "muss eine gültige E-Mail-Adresse sein",
+		"already exists":                                   "existiert bereits",
+		"This is a demo error":                             "Dies ist ein Demo-Fehler",
+		"Not Found":                                        "Nicht gefunden",
+		"Method Not Allowed":                               "Methode nicht erlaubt",
+		"Internal Server Error":                            "Interner Serverfehler",
+	},
+}
+
+// supportedLocale reports whether messages are available for the locale
+func supportedLocale(locale string) bool {
+	_, ok := translations[locale]
+	return ok || locale == defaultLocale
+}
+
+// localeMiddleware picks the response locale from ?lang= (for testing) or the
+// Accept-Language header and stores it in the context
+func localeMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			locale := defaultLocale
+			if lang := strings.ToLower(c.QueryParam("lang")); supportedLocale(lang) {
+				locale = lang
+			} else {
+				locale = negotiateLocale(c.Request().Header.Get("Accept-Language"))
+			}
+			c.Set(localeContextKey, locale)
+			c.Response().Header().Set("Content-Language", locale)
+			return next(c)
+		}
+	}
+}
+
+// negotiateLocale returns the supported locale with the highest quality value,
+// matching region tags such as es-MX by their primary language
+func negotiateLocale(header string) string {
+	type candidate struct {
+		tag string
+		q   float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{tag: strings.ToLower(tag), q: q})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+
+	for _, cand := range candidates {
+		primary, _, _ := strings.Cut(cand.tag, "-")
+		if supportedLocale(primary) {
+			return primary
+		}
+	}
+	return defaultLocale
+}
+
+// requestLocale returns the locale chosen by localeMiddleware
+func requestLocale(c echo.Context) string {
+	if locale, ok := c.Get(localeContextKey).(string); ok {
+		return locale
+	}
+	return defaultLocale
+}
+
+// tr translates a canonical message into the request's locale
+func tr(c echo.Context, message string) string {
+	if translated, ok := translations[requestLocale(c)][message]; ok {
+		return translated
+	}
+	return message
+}
+
+// trf translates a canonical format string and then applies the arguments
+func trf(c echo.Context, format string, args ...interface{}) string {
+	return fmt.Sprintf(tr(c, format), args...)
+}
+
+// translateFieldErrors localizes validation messages keyed by field name
+func translateFieldErrors(c echo.Context, errs map[string]string) map[string]string {
+	if len(errs) == 0 {
+		return errs
+	}
+	out := make(map[string]string, len(errs))
+	for field, message := range errs {
+		out[field] = tr(c, message)
+	}
+	return out
+}
+
+// localizedErrorHandler translates echo.HTTPError messages before delegating to
+// Echo's default JSON error handler
+func localizedErrorHandler(e *echo.Echo) echo.HTTPErrorHandler {
+	return func(err error, c echo.Context) {
+		var he *echo.HTTPError
+		if errors.As(err, &he) {
+			if message, ok := he.Message.(string); ok {
+				localized := *he
+				localized.Message = tr(c, message)
+				err = &localized
+			}
+		} else if !e.Debug {
+			err = echo.NewHTTPError(http.StatusInternalServerError, tr(c, http.StatusText(http.StatusInternalServerError)))
+		}
+		e.DefaultHTTPErrorHandler(err, c)
+	}
+}
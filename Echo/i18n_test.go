@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func localizedRequest(t *testing.T, method, path, body, acceptLanguage string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if acceptLanguage != "" {
+		req.Header.Set("Accept-Language", acceptLanguage)
+	}
+	rec := httptest.NewRecorder()
+	newServer().ServeHTTP(rec, req)
+	return rec
+}
+
+// TestLocalizedNotFound requests the same missing user in three languages
+func TestLocalizedNotFound(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		acceptLanguage string
+		wantLocale     string
+		wantError      string
+	}{
+		{"en-US,en;q=0.9", "en", "User not found"},
+		{"es-MX,es;q=0.9,en;q=0.5", "es", "Usuario no encontrado"},
+		{"fr;q=0.9, de;q=0.8, en;q=0.1", "de", "Benutzer nicht gefunden"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.wantLocale, func(t *testing.T) {
+			t.Parallel()
+			rec := localizedRequest(t, http.MethodGet, "/api/users/999", "", tt.acceptLanguage)
+			if rec.Code != http.StatusNotFound {
+				t.Fatalf("status = %d", rec.Code)
+			}
+			if got := decodeBody(t, rec)["error"]; got != tt.wantError {
+				t.Errorf("error = %v, want %q", got, tt.wantError)
+			}
+			if got := rec.Header().Get("Content-Language"); got != tt.wantLocale {
+				t.Errorf("Content-Language = %q, want %q", got, tt.wantLocale)
+			}
+		})
+	}
+}
+
+// TestLocalizedValidationErrors checks bulk field errors are translated
+func TestLocalizedValidationErrors(t *testing.T) {
+	withFreshUsers(t)
+
+	want := map[string][2]string{
+		"en": {"is required", "must be a valid email address"},
+		"es": {"es obligatorio", "debe ser una dirección de correo válida"},
+		"de": {"ist erforderlich", "muss eine gültige E-Mail-Adresse sein"},
+	}
+	for locale, messages := range want {
+		rec := localizedRequest(t, http.MethodPost, "/api/users/bulk", `[{"name":"","email":"nope"}]`, locale)
+		var resp bulkUsersResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		errs := resp.Results[0].Errors
+		if errs["name"] != messages[0] || errs["email"] != messages[1] {
+			t.Errorf("%s: errors = %v", locale, errs)
+		}
+	}
+
+	rec := localizedRequest(t, http.MethodPost, "/api/users", `{"name":"Solo"}`, "de")
+	if got := decodeBody(t, rec)["error"]; got != "Name und E-Mail sind erforderlich" {
+		t.Errorf("single create error = %v", got)
+	}
+	rec = localizedRequest(t, http.MethodPost, "/api/users", `{"name":"J","email":"john@example.com"}`, "es")
+	if got := decodeBody(t, rec)["error"]; got != "Ya existe un usuario con este email" {
+		t.Errorf("conflict error = %v", got)
+	}
+}
+
+// TestLocaleOverrideAndFallback covers ?lang=, unknown locales and the central error handler
+func TestLocaleOverrideAndFallback(t *testing.T) {
+	t.Parallel()
+
+	rec := localizedRequest(t, http.MethodGet, "/api/users/abc?lang=es", "", "de")
+	if got := decodeBody(t, rec)["error"]; got != "ID de usuario no válido" {
+		t.Errorf("?lang override = %v", got)
+	}
+
+	rec = localizedRequest(t, http.MethodGet, "/api/users/abc?lang=xx", "ja, fr;q=0.8", "")
+	if got := decodeBody(t, rec)["error"]; got != "Invalid user ID" {
+		t.Errorf("unknown locale fallback = %v", got)
+	}
+
+	rec = localizedRequest(t, http.MethodGet, "/api/no-such-route", "", "es")
+	if rec.Code != http.StatusNotFound || decodeBody(t, rec)["message"] != "No encontrado" {
+		t.Errorf("router 404 = %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = localizedRequest(t, http.MethodGet, "/api/error", "", "de")
+	if got := decodeBody(t, rec)["message"]; got != "Dies ist ein Demo-Fehler" {
+		t.Errorf("HTTPError message = %v", got)
+	}
+}
+
+// TestNegotiateLocale covers quality ordering and malformed entries
+func TestNegotiateLocale(t *testing.T) {
+	tests := map[string]string{
+		"":                            "en",
+		"*":                           "en",
+		"de-AT":                       "de",
+		"en;q=0.2, es;q=0.8":          "es",
+		"es;q=0, de":                  "de",
+		"de;q=abc, es;q=0.3":          "es",
+		"pt-BR, ES-es;q=0.5, de;q=.4": "es",
+	}
+	for header, want := range tests {
+		if got := negotiateLocale(header); got != want {
+			t.Errorf("negotiateLocale(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
@@ -1,7 +1,10 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"os"
 	"strconv"
 	"strings"
 	"time"
@@ -13,9 +16,10 @@ import (
 
 // User represents a user in our system
 type User struct {
-	ID    int    `json:"id"`
-	Name  string `json:"name"`
-	Email string `json:"email"`
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Username string `json:"username,omitempty"`
 }
 
 // Product represents a product in our system
@@ -28,35 +32,67 @@ type Product struct {
 }
 
 // In-memory storage (in production, use a database)
-var users = []User{
-	{ID: 1, Name: "John Doe", Email: "john@example.com"},
-	{ID: 2, Name: "Jane Smith", Email: "jane@example.com"},
-	{ID: 3, Name: "Bob Johnson", Email: "bob@example.com"},
+var users = NewUserStore(seedUsers())
+
+func seedUsers() []User {
+	return []User{
+		{ID: 1, Name: "John Doe", Email: "john@example.com"},
+		{ID: 2, Name: "Jane Smith", Email: "jane@example.com"},
+		{ID: 3, Name: "Bob Johnson", Email: "bob@example.com"},
+	}
 }
 
-var products = []Product{
-	{ID: 1, Name: "Laptop", Price: 999.99, Category: "Electronics", Description: "High-performance laptop"},
-	{ID: 2, Name: "Coffee Mug", Price: 15.50, Category: "Kitchen", Description: "Ceramic coffee mug"},
-	{ID: 3, Name: "Desk Chair", Price: 199.99, Category: "Furniture", Description: "Ergonomic office chair"},
+var products = NewProductStore(seedProducts())
+
+func seedProducts() []Product {
+	return []Product{
+		{ID: 1, Name: "Laptop", Price: 999.99, Category: "Electronics", Description: "High-performance laptop"},
+		{ID: 2, Name: "Coffee Mug", Price: 15.50, Category: "Kitchen", Description: "Ceramic coffee mug"},
+		{ID: 3, Name: "Desk Chair", Price: 199.99, Category: "Furniture", Description: "Ergonomic office chair"},
+	}
 }
 
 func main() {
+	e := newServer()
+
+	// Optional background price changes so the SSE stream has activity
+	if os.Getenv("STREAM_DEMO") == "1" {
+		go jitterPrices(context.Background(), products, 3*time.Second)
+	}
+
+	// Start server
+	e.Logger.Info("Starting Echo server on :8080")
+	e.Logger.Fatal(e.Start(":8080"))
+}
+
+// newServer builds the Echo instance with its middleware chain and routes
+func newServer() *echo.Echo {
 	// Create Echo instance
 	e := echo.New()
+	e.HTTPErrorHandler = localizedErrorHandler(e)
 
 	// Middleware
 	e.Use(middleware.Logger())
 	e.Use(middleware.Recover())
+	e.Use(middleware.RequestID())
 	e.Use(middleware.CORS())
+	e.Use(localeMiddleware())
 
-	// Custom middleware for request timing
+	// Full request/response body logging for troubleshooting clients
+	if cfg, enabled := bodyLogConfigFromEnv(); enabled {
+		e.Use(bodyLogger(cfg))
+	}
+
+	// Custom middleware for request timing. The header is set just before
+	// the status line is written, as headers set after that never reach
+	// the client.
 	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			start := time.Now()
-			err := next(c)
-			duration := time.Since(start)
-			c.Response().Header().Set("X-Response-Time", duration.String())
-			return err
+			c.Response().Before(func() {
+				c.Response().Header().Set("X-Response-Time", time.Since(start).String())
+			})
+			return next(c)
 		}
 	})
 
@@ -66,9 +102,7 @@ func main() {
 	// Routes
 	setupRoutes(e)
 
-	// Start server
-	e.Logger.Info("Starting Echo server on :8080")
-	e.Logger.Fatal(e.Start(":8080"))
+	return e
 }
 
 func setupRoutes(e *echo.Echo) {
@@ -76,50 +110,129 @@ func setupRoutes(e *echo.Echo) {
 	e.GET("/", homeHandler)
 	e.GET("/health", healthCheckHandler)
 
-	// API group
-	api := e.Group("/api")
+	// Versioned API groups; the unversioned /api paths are kept as
+	// deprecated aliases of v1 for backward compatibility. Routes added
+	// through the registry are documented in /openapi.json.
+	routes := &RouteRegistry{}
+	registerV1Routes(routes.Group(e, "/api", true, apiVersion("v1", true)))
+	registerV1Routes(routes.Group(e, "/api/v1", false, apiVersion("v1", false)))
+	registerV2Routes(routes.Group(e, "/api/v2", false, apiVersion("v2", false)))
+
+	// API documentation
+	e.GET("/openapi.json", openAPIHandler(routes))
+	e.GET("/docs", swaggerUIHandler)
+
+	// Cookie session login and CSRF-protected forms
+	registerSessionPages(e)
+
+	// Template rendering example (using built-in HTML renderer)
+	e.GET("/template", templateHandler)
+}
+
+// registerV1Routes registers the original API surface on the given group
+func registerV1Routes(api *APIGroup) {
+	v1 := userAPIv1.withStore(users)
 
 	// User routes
-	users := api.Group("/users")
-	users.GET("", getAllUsers)
-	users.GET("/:id", getUserByID)
-	users.POST("", createUser)
-	users.PUT("/:id", updateUser)
-	users.DELETE("/:id", deleteUser)
+	api.Route(RouteSpec{Method: http.MethodGet, Path: "/users", Handler: v1.getAll, Tag: "users",
+		Summary: "List users", Response: userListResponse{}})
+	api.Route(RouteSpec{Method: http.MethodGet, Path: "/users/:id", Handler: v1.getByID, Tag: "users",
+		Summary: "Get a user by ID", Response: User{}})
+	api.Route(RouteSpec{Method: http.MethodPost, Path: "/users", Handler: v1.create, Tag: "users",
+		Summary: "Create a user", Request: User{}, Response: User{}, SuccessStatus: http.StatusCreated})
+	api.Route(RouteSpec{Method: http.MethodPost, Path: "/users/bulk", Handler: v1.bulkCreate, Tag: "users",
+		Summary: "Create up to 200 users", Request: []User{}, Response: bulkUsersResult{}, SuccessStatus: http.StatusCreated,
+		Query: []QueryParam{{Name: "atomic", Description: "1 to reject the whole batch if any item fails"}}})
+	api.Route(RouteSpec{Method: http.MethodPut, Path: "/users/:id", Handler: v1.update, Tag: "users",
+		Summary: "Update a user", Request: User{}, Response: User{}})
+	api.Route(RouteSpec{Method: http.MethodDelete, Path: "/users/:id", Handler: v1.delete, Tag: "users",
+		Summary: "Delete a user", Response: MessageResponse{}})
 
 	// Product routes
-	products := api.Group("/products")
-	products.GET("", getAllProducts)
-	products.GET("/:id", getProductByID)
-	products.GET("/category/:category", getProductsByCategory)
-	products.POST("", createProduct)
-	products.PUT("/:id", updateProduct)
-	products.DELETE("/:id", deleteProduct)
+	api.Route(RouteSpec{Method: http.MethodGet, Path: "/products", Handler: getAllProducts, Tag: "products",
+		Summary: "List products", Response: productListResponse{}})
+	api.Route(RouteSpec{Method: http.MethodGet, Path: "/products/export", Handler: exportProducts, Tag: "products",
+		Summary: "Download products as CSV or TSV",
+		Query: []QueryParam{
+			{Name: "format", Description: "csv (default) or tsv"},
+			{Name: "category", Description: "Only export this category"},
+			{Name: "bom", Description: "1 to prepend a UTF-8 byte order mark"},
+		}})
+	api.Route(RouteSpec{Method: http.MethodDelete, Path: "/products/bulk", Handler: bulkDeleteProducts, Tag: "products",
+		Summary: "Delete products by ID", Request: []int{}, Response: bulkDeleteResult{},
+		Query: []QueryParam{{Name: "atomic", Description: "1 to delete nothing unless every ID exists"}}})
+	api.Route(RouteSpec{Method: http.MethodGet, Path: "/products/:id", Handler: getProductByID, Tag: "products",
+		Summary: "Get a product by ID", Response: Product{}})
+	api.Route(RouteSpec{Method: http.MethodGet, Path: "/products/category/:category", Handler: getProductsByCategory, Tag: "products",
+		Summary: "List products in a category", Response: categoryListResponse{}})
+	api.Route(RouteSpec{Method: http.MethodPost, Path: "/products", Handler: createProduct, Tag: "products",
+		Summary: "Create a product", Request: Product{}, Response: Product{}, SuccessStatus: http.StatusCreated})
+	api.Route(RouteSpec{Method: http.MethodPut, Path: "/products/:id", Handler: updateProduct, Tag: "products",
+		Summary: "Update a product", Request: Product{}, Response: Product{}})
+	api.Route(RouteSpec{Method: http.MethodDelete, Path: "/products/:id", Handler: deleteProduct, Tag: "products",
+		Summary: "Delete a product", Response: MessageResponse{}})
+
+	// Server-sent events for product changes
+	api.GET("/stream/products", streamProducts)
 
 	// Search routes
-	e.GET("/api/search/users", searchUsers)
-	e.GET("/api/search/products", searchProducts)
+	api.Route(RouteSpec{Method: http.MethodGet, Path: "/search/users", Handler: v1.search, Tag: "search",
+		Summary: "Search users by name or email", Response: userSearchResponse{},
+		Query: []QueryParam{{Name: "q", Description: "Search text", Required: true}}})
+	api.Route(RouteSpec{Method: http.MethodGet, Path: "/search/products", Handler: searchProducts, Tag: "search",
+		Summary: "Search products by name, category or description", Response: productSearchResponse{},
+		Query: []QueryParam{{Name: "q", Description: "Search text", Required: true}}})
 
 	// File upload example
-	e.POST("/api/upload", uploadFile)
+	api.POST("/upload", uploadFile)
 
 	// Custom error handling example
-	e.GET("/api/error", errorHandler)
-
-	// Template rendering example (using built-in HTML renderer)
-	e.GET("/template", templateHandler)
+	api.GET("/error", errorHandler)
 
 	// JSON response examples
-	e.GET("/api/examples/json", jsonExampleHandler)
-	e.GET("/api/examples/status", statusExampleHandler)
+	api.GET("/examples/json", jsonExampleHandler)
+	api.GET("/examples/status", statusExampleHandler)
 
 	// Parameter and query examples
-	e.GET("/api/examples/params/:name/:age", paramExampleHandler)
-	e.GET("/api/examples/query", queryExampleHandler)
+	api.GET("/examples/params/:name/:age", paramExampleHandler)
+	api.GET("/examples/query", queryExampleHandler)
 
 	// Cookie and header examples
-	e.GET("/api/examples/cookie", cookieExampleHandler)
-	e.GET("/api/examples/headers", headerExampleHandler)
+	api.GET("/examples/cookie", cookieExampleHandler)
+	api.GET("/examples/headers", headerExampleHandler)
+}
+
+// registerV2Routes registers the v2 API: users carry timestamps and
+// list endpoints return the pagination envelope
+func registerV2Routes(api *APIGroup) {
+	v2 := userAPIv2.withStore(users)
+	pageParams := []QueryParam{
+		{Name: "page", Description: "Page number, starting at 1"},
+		{Name: "per_page", Description: "Items per page (max 100)"},
+	}
+
+	api.Route(RouteSpec{Method: http.MethodGet, Path: "/users", Handler: v2.getAll, Tag: "users",
+		Summary: "List users (paginated)", Response: userPageV2{}, Query: pageParams})
+	api.Route(RouteSpec{Method: http.MethodGet, Path: "/users/:id", Handler: v2.getByID, Tag: "users",
+		Summary: "Get a user by ID", Response: UserV2{}})
+	api.Route(RouteSpec{Method: http.MethodPost, Path: "/users", Handler: v2.create, Tag: "users",
+		Summary: "Create a user", Request: User{}, Response: UserV2{}, SuccessStatus: http.StatusCreated})
+	api.Route(RouteSpec{Method: http.MethodPost, Path: "/users/bulk", Handler: v2.bulkCreate, Tag: "users",
+		Summary: "Create up to 200 users", Request: []User{}, Response: bulkUsersResult{}, SuccessStatus: http.StatusCreated})
+	api.Route(RouteSpec{Method: http.MethodPut, Path: "/users/:id", Handler: v2.update, Tag: "users",
+		Summary: "Update a user", Request: User{}, Response: UserV2{}})
+	api.Route(RouteSpec{Method: http.MethodDelete, Path: "/users/:id", Handler: v2.delete, Tag: "users",
+		Summary: "Delete a user", Response: MessageResponse{}})
+
+	api.Route(RouteSpec{Method: http.MethodGet, Path: "/products", Handler: getAllProductsV2, Tag: "products",
+		Summary: "List products (paginated)", Response: productPageV2{},
+		Query: append([]QueryParam{{Name: "category", Description: "Only list this category"}}, pageParams...)})
+	api.Route(RouteSpec{Method: http.MethodGet, Path: "/products/:id", Handler: getProductByID, Tag: "products",
+		Summary: "Get a product by ID", Response: Product{}})
+
+	api.Route(RouteSpec{Method: http.MethodGet, Path: "/search/users", Handler: v2.search, Tag: "search",
+		Summary: "Search users by name or email", Response: userSearchResponseV2{},
+		Query: []QueryParam{{Name: "q", Description: "Search text", Required: true}}})
 }
 
 // Handlers
@@ -171,6 +284,9 @@ func homeHandler(c echo.Context) error {
 				<div class="endpoint">
 					<span class="method">DELETE</span> <span class="url">/api/users/:id</span> - Delete user
 				</div>
+				<div class="endpoint">
+					<span class="method">POST</span> <span class="url">/api/users/bulk</span> - Create up to 200 users (<code>?atomic=1</code> for all-or-nothing)
+				</div>
 
 				<h3>📦 Product Management</h3>
 				<div class="endpoint">
@@ -182,6 +298,27 @@ func homeHandler(c echo.Context) error {
 				<div class="endpoint">
 					<span class="method">GET</span> <span class="url"><a href="/api/products/category/Electronics">/api/products/category/Electronics</a></span> - Get products by category
 				</div>
+				<div class="endpoint">
+					<span class="method">DELETE</span> <span class="url">/api/products/bulk</span> - Delete products by a JSON array of IDs
+				</div>
+				<div class="endpoint">
+					<span class="method">GET</span> <span class="url"><a href="/api/products/export?format=csv">/api/products/export?format=csv</a></span> - Export products as CSV/TSV
+				</div>
+
+				<div class="endpoint">
+					<span class="method">GET</span> <span class="url"><a href="/api/stream/products">/api/stream/products</a></span> - Server-sent events for product changes
+				</div>
+
+				<h3>🔢 API Versions</h3>
+				<div class="endpoint">
+					<span class="method">GET</span> <span class="url"><a href="/api/v1/users">/api/v1/users</a></span> - v1 users (<code>/api/...</code> is a deprecated alias of v1)
+				</div>
+				<div class="endpoint">
+					<span class="method">GET</span> <span class="url"><a href="/api/v2/users?page=1&per_page=2">/api/v2/users?page=1&per_page=2</a></span> - v2 users with timestamps and pagination envelope
+				</div>
+				<div class="endpoint">
+					<span class="method">GET</span> <span class="url"><a href="/api/v2/products">/api/v2/products</a></span> - v2 products with pagination envelope
+				</div>
 
 				<h3>🔍 Search & Examples</h3>
 				<div class="endpoint">
@@ -200,6 +337,25 @@ func homeHandler(c echo.Context) error {
 					<span class="method">GET</span> <span class="url"><a href="/api/examples/query?name=John&age=25">/api/examples/query?name=John&age=25</a></span> - Query parameters example
 				</div>
 
+				<h3>🔐 Sessions</h3>
+				<div class="endpoint">
+					<span class="method">GET</span> <span class="url"><a href="/login">/login</a></span> - Log in with a signed session cookie (password: <code>demo</code>)
+				</div>
+				<div class="endpoint">
+					<span class="method">GET</span> <span class="url"><a href="/account">/account</a></span> - Session-protected page with a CSRF-protected contact form
+				</div>
+				<div class="endpoint">
+					<span class="method">GET</span> <span class="url"><a href="/logout">/logout</a></span> - Clear the session
+				</div>
+
+				<h3>📖 API Documentation</h3>
+				<div class="endpoint">
+					<span class="method">GET</span> <span class="url"><a href="/docs">/docs</a></span> - Swagger UI
+				</div>
+				<div class="endpoint">
+					<span class="method">GET</span> <span class="url"><a href="/openapi.json">/openapi.json</a></span> - Generated OpenAPI 3 document
+				</div>
+
 				<h2>🧪 Testing the API</h2>
 				<p>Use tools like curl, Postman, or your browser to test the endpoints:</p>
 				<pre>
@@ -238,110 +394,12 @@ func healthCheckHandler(c echo.Context) error {
 	})
 }
 
-// User handlers
-func getAllUsers(c echo.Context) error {
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"users": users,
-		"total": len(users),
-	})
-}
-
-func getUserByID(c echo.Context) error {
-	id, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid user ID",
-		})
-	}
-
-	for _, user := range users {
-		if user.ID == id {
-			return c.JSON(http.StatusOK, user)
-		}
-	}
-
-	return c.JSON(http.StatusNotFound, map[string]string{
-		"error": "User not found",
-	})
-}
-
-func createUser(c echo.Context) error {
-	var newUser User
-	if err := c.Bind(&newUser); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request body",
-		})
-	}
-
-	// Simple validation
-	if newUser.Name == "" || newUser.Email == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Name and email are required",
-		})
-	}
-
-	// Assign new ID (in production, use proper ID generation)
-	newUser.ID = len(users) + 1
-	users = append(users, newUser)
-
-	return c.JSON(http.StatusCreated, newUser)
-}
-
-func updateUser(c echo.Context) error {
-	id, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid user ID",
-		})
-	}
-
-	var updatedUser User
-	if err := c.Bind(&updatedUser); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request body",
-		})
-	}
-
-	for i, user := range users {
-		if user.ID == id {
-			updatedUser.ID = id
-			users[i] = updatedUser
-			return c.JSON(http.StatusOK, updatedUser)
-		}
-	}
-
-	return c.JSON(http.StatusNotFound, map[string]string{
-		"error": "User not found",
-	})
-}
-
-func deleteUser(c echo.Context) error {
-	id, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid user ID",
-		})
-	}
-
-	for i, user := range users {
-		if user.ID == id {
-			users = append(users[:i], users[i+1:]...)
-			return c.JSON(http.StatusOK, map[string]string{
-				"message": "User deleted successfully",
-			})
-		}
-	}
-
-	return c.JSON(http.StatusNotFound, map[string]string{
-		"error": "User not found",
-	})
-}
-
 // Product handlers
 func getAllProducts(c echo.Context) error {
+	all := products.List()
 	return c.JSON(http.StatusOK, map[string]interface{}{
-		"products": products,
-		"total":    len(products),
+		"products": all,
+		"total":    len(all),
 	})
 }
 
@@ -349,30 +407,20 @@ func getProductByID(c echo.Context) error {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid product ID",
+			"error": tr(c, "Invalid product ID"),
 		})
 	}
 
-	for _, product := range products {
-		if product.ID == id {
-			return c.JSON(http.StatusOK, product)
-		}
+	product, err := products.Get(id)
+	if err != nil {
+		return productStoreError(c, err)
 	}
-
-	return c.JSON(http.StatusNotFound, map[string]string{
-		"error": "Product not found",
-	})
+	return c.JSON(http.StatusOK, product)
 }
 
 func getProductsByCategory(c echo.Context) error {
 	category := c.Param("category")
-	var categoryProducts []Product
-
-	for _, product := range products {
-		if product.Category == category {
-			categoryProducts = append(categoryProducts, product)
-		}
-	}
+	categoryProducts := products.Filter(category)
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
 		"products": categoryProducts,
@@ -385,113 +433,82 @@ func createProduct(c echo.Context) error {
 	var newProduct Product
 	if err := c.Bind(&newProduct); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request body",
+			"error": tr(c, "Invalid request body"),
 		})
 	}
 
 	// Simple validation
 	if newProduct.Name == "" || newProduct.Price <= 0 {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Name and valid price are required",
+			"error": tr(c, "Name and valid price are required"),
 		})
 	}
 
-	newProduct.ID = len(products) + 1
-	products = append(products, newProduct)
-
-	return c.JSON(http.StatusCreated, newProduct)
+	created, err := products.Create(newProduct)
+	if err != nil {
+		return productStoreError(c, err)
+	}
+	return c.JSON(http.StatusCreated, created)
 }
 
 func updateProduct(c echo.Context) error {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid product ID",
+			"error": tr(c, "Invalid product ID"),
 		})
 	}
 
 	var updatedProduct Product
 	if err := c.Bind(&updatedProduct); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request body",
+			"error": tr(c, "Invalid request body"),
 		})
 	}
 
-	for i, product := range products {
-		if product.ID == id {
-			updatedProduct.ID = id
-			products[i] = updatedProduct
-			return c.JSON(http.StatusOK, updatedProduct)
-		}
+	updated, err := products.Update(id, updatedProduct)
+	if err != nil {
+		return productStoreError(c, err)
 	}
-
-	return c.JSON(http.StatusNotFound, map[string]string{
-		"error": "Product not found",
-	})
+	return c.JSON(http.StatusOK, updated)
 }
 
 func deleteProduct(c echo.Context) error {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid product ID",
+			"error": tr(c, "Invalid product ID"),
 		})
 	}
 
-	for i, product := range products {
-		if product.ID == id {
-			products = append(products[:i], products[i+1:]...)
-			return c.JSON(http.StatusOK, map[string]string{
-				"message": "Product deleted successfully",
-			})
-		}
+	if err := products.Delete(id); err != nil {
+		return productStoreError(c, err)
 	}
-
-	return c.JSON(http.StatusNotFound, map[string]string{
-		"error": "Product not found",
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Product deleted successfully",
 	})
 }
 
-// Search handlers
-func searchUsers(c echo.Context) error {
-	query := c.QueryParam("q")
-	if query == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Query parameter 'q' is required",
+// productStoreError maps store errors to HTTP responses
+func productStoreError(c echo.Context, err error) error {
+	if errors.Is(err, ErrNotFound) {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "Product not found"),
 		})
 	}
-
-	var results []User
-	for _, user := range users {
-		if containsIgnoreCase(user.Name, query) || containsIgnoreCase(user.Email, query) {
-			results = append(results, user)
-		}
-	}
-
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"query":   query,
-		"results": results,
-		"total":   len(results),
-	})
+	return err
 }
 
+// Search handlers
 func searchProducts(c echo.Context) error {
 	query := c.QueryParam("q")
 	if query == "" {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Query parameter 'q' is required",
+			"error": tr(c, "Query parameter 'q' is required"),
 		})
 	}
 
-	var results []Product
-	for _, product := range products {
-		if containsIgnoreCase(product.Name, query) ||
-			containsIgnoreCase(product.Category, query) ||
-			containsIgnoreCase(product.Description, query) {
-			results = append(results, product)
-		}
-	}
-
+	results := products.Search(query)
 	return c.JSON(http.StatusOK, map[string]interface{}{
 		"query":   query,
 		"results": results,
@@ -504,7 +521,7 @@ func uploadFile(c echo.Context) error {
 	file, err := c.FormFile("file")
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "No file uploaded",
+			"error": tr(c, "No file uploaded"),
 		})
 	}
 
@@ -0,0 +1,351 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RouteSpec describes an API route for both the router and the OpenAPI document
+type RouteSpec struct {
+	Method  string
+	Path    string // Echo path relative to the group, e.g. /users/:id
+	Summary string
+	Tag     string
+	Handler echo.HandlerFunc
+	// Request and Response are zero values of the body types, reflected into schemas
+	Request  interface{}
+	Response interface{}
+	// SuccessStatus defaults to 200
+	SuccessStatus int
+	Query         []QueryParam
+}
+
+// QueryParam documents a query string parameter
+type QueryParam struct {
+	Name        string
+	Description string
+	Required    bool
+}
+
+// registeredRoute is a RouteSpec resolved to its full path
+type registeredRoute struct {
+	RouteSpec
+	fullPath   string
+	deprecated bool
+}
+
+// RouteRegistry records every route added through an APIGroup so the
+// OpenAPI document is always generated from what is actually served
+type RouteRegistry struct {
+	routes []registeredRoute
+}
+
+// APIGroup is an Echo group whose documented routes go through the registry
+type APIGroup struct {
+	*echo.Group
+	prefix     string
+	deprecated bool
+	registry   *RouteRegistry
+}
+
+// Group creates a registry-backed group under prefix
+func (r *RouteRegistry) Group(e *echo.Echo, prefix string, deprecated bool, m ...echo.MiddlewareFunc) *APIGroup {
+	return &APIGroup{Group: e.Group(prefix, m...), prefix: prefix, deprecated: deprecated, registry: r}
+}
+
+// Route registers the handler with Echo and records it for the OpenAPI document
+func (g *APIGroup) Route(spec RouteSpec) {
+	g.Group.Add(spec.Method, spec.Path, spec.Handler)
+	g.registry.routes = append(g.registry.routes, registeredRoute{
+		RouteSpec:  spec,
+		fullPath:   g.prefix + spec.Path,
+		deprecated: g.deprecated,
+	})
+}
+
+// ErrorResponse is the JSON body returned by handlers on failure
+type ErrorResponse struct {
+	Error string `json:"error"`
+	Field string `json:"field,omitempty"`
+}
+
+// MessageResponse is returned by delete endpoints
+type MessageResponse struct {
+	Message string `json:"message"`
+}
+
+// Documentation-only shapes of the map-based list responses
+type (
+	userListResponse struct {
+		Users []User `json:"users"`
+		Total int    `json:"total"`
+	}
+	productListResponse struct {
+		Products []Product `json:"products"`
+		Total    int       `json:"total"`
+	}
+	categoryListResponse struct {
+		Products []Product `json:"products"`
+		Category string    `json:"category"`
+		Total    int       `json:"total"`
+	}
+	userSearchResponse struct {
+		Query   string `json:"query"`
+		Results []User `json:"results"`
+		Total   int    `json:"total"`
+	}
+	userSearchResponseV2 struct {
+		Query   string   `json:"query"`
+		Results []UserV2 `json:"results"`
+		Total   int      `json:"total"`
+	}
+	productSearchResponse struct {
+		Query   string    `json:"query"`
+		Results []Product `json:"results"`
+		Total   int       `json:"total"`
+	}
+	userPageV2 struct {
+		Data       []UserV2   `json:"data"`
+		Pagination Pagination `json:"pagination"`
+	}
+	productPageV2 struct {
+		Data       []Product  `json:"data"`
+		Pagination Pagination `json:"pagination"`
+	}
+	bulkUsersResult struct {
+		Results []BulkItemResult `json:"results"`
+		Summary BulkSummary      `json:"summary"`
+	}
+	bulkDeleteResult struct {
+		Deleted []int       `json:"deleted"`
+		Missing []int       `json:"missing"`
+		Summary BulkSummary `json:"summary"`
+	}
+)
+
+var pathParamPattern = regexp.MustCompile(`:([A-Za-z_]+)`)
+
+// OpenAPI builds an OpenAPI 3.0 document from the registered routes
+func (r *RouteRegistry) OpenAPI() map[string]interface{} {
+	schemas := map[string]interface{}{}
+	paths := map[string]map[string]interface{}{}
+
+	for _, route := range r.routes {
+		path := pathParamPattern.ReplaceAllString(route.fullPath, "{$1}")
+		if paths[path] == nil {
+			paths[path] = map[string]interface{}{}
+		}
+
+		var params []map[string]interface{}
+		for _, m := range pathParamPattern.FindAllStringSubmatch(route.fullPath, -1) {
+			schema := map[string]interface{}{"type": "string"}
+			if m[1] == "id" {
+				schema = map[string]interface{}{"type": "integer"}
+			}
+			params = append(params, map[string]interface{}{
+				"name": m[1], "in": "path", "required": true, "schema": schema,
+			})
+		}
+		for _, q := range route.Query {
+			params = append(params, map[string]interface{}{
+				"name": q.Name, "in": "query", "required": q.Required,
+				"description": q.Description, "schema": map[string]interface{}{"type": "string"},
+			})
+		}
+
+		success := route.SuccessStatus
+		if success == 0 {
+			success = http.StatusOK
+		}
+		responses := map[string]interface{}{
+			strconv.Itoa(success): response(http.StatusText(success), route.Response, schemas),
+		}
+		if len(params) > 0 || route.Request != nil {
+			responses[strconv.Itoa(http.StatusBadRequest)] = response("Invalid input", ErrorResponse{}, schemas)
+		}
+		if strings.Contains(route.fullPath, ":id") {
+			responses[strconv.Itoa(http.StatusNotFound)] = response("Not found", ErrorResponse{}, schemas)
+		}
+
+		op := map[string]interface{}{
+			"summary":     route.Summary,
+			"operationId": operationID(route.Method, route.fullPath),
+			"responses":   responses,
+		}
+		if route.Tag != "" {
+			op["tags"] = []string{route.Tag}
+		}
+		if len(params) > 0 {
+			op["parameters"] = params
+		}
+		if route.deprecated {
+			op["deprecated"] = true
+		}
+		if route.Request != nil {
+			op["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content": map[string]interface{}{
+					echo.MIMEApplicationJSON: map[string]interface{}{"schema": schemaFor(reflect.TypeOf(route.Request), schemas)},
+				},
+			}
+		}
+		paths[path][strings.ToLower(route.Method)] = op
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "Echo Demo API",
+			"version":     "1.0.0",
+			"description": "User, product and search endpoints of the Echo demo. Generated from the route registry.",
+		},
+		"paths":      paths,
+		"components": map[string]interface{}{"schemas": schemas},
+	}
+}
+
+func response(description string, body interface{}, schemas map[string]interface{}) map[string]interface{} {
+	resp := map[string]interface{}{"description": description}
+	if body != nil {
+		resp["content"] = map[string]interface{}{
+			echo.MIMEApplicationJSON: map[string]interface{}{"schema": schemaFor(reflect.TypeOf(body), schemas)},
+		}
+	}
+	return resp
+}
+
+// operationID turns "GET /api/v1/users/:id" into "getApiV1UsersById"
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, part := range strings.Split(path, "/") {
+		if part == "" {
+			continue
+		}
+		if strings.HasPrefix(part, ":") {
+			b.WriteString("By")
+			part = part[1:]
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaFor reflects a Go type into a JSON schema. Named structs are added to
+// components/schemas and referenced.
+func schemaFor(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaFor(t.Elem(), schemas)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaFor(t.Elem(), schemas)}
+	case reflect.Struct:
+		name := t.Name()
+		if name == "" || !isExported(name) {
+			return structSchema(t, schemas)
+		}
+		if _, ok := schemas[name]; !ok {
+			schemas[name] = map[string]interface{}{} // placeholder for recursive types
+			schemas[name] = structSchema(t, schemas)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	}
+	return map[string]interface{}{}
+}
+
+func isExported(name string) bool {
+	return strings.ToUpper(name[:1]) == name[:1]
+}
+
+// structSchema builds an object schema from exported fields and their json tags;
+// embedded structs are flattened like encoding/json does
+func structSchema(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	properties := map[string]interface{}{}
+	var required []string
+
+	var walk func(reflect.Type)
+	walk = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag := f.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			name, opts, _ := strings.Cut(tag, ",")
+			if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+				walk(f.Type)
+				continue
+			}
+			if !f.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			properties[name] = schemaFor(f.Type, schemas)
+			if !strings.Contains(opts, "omitempty") {
+				required = append(required, name)
+			}
+		}
+	}
+	walk(t)
+
+	sort.Strings(required)
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// openAPIHandler serves the generated document
+func openAPIHandler(registry *RouteRegistry) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return c.JSON(http.StatusOK, registry.OpenAPI())
+	}
+}
+
+// swaggerUIHandler serves a Swagger UI page that loads /openapi.json
+func swaggerUIHandler(c echo.Context) error {
+	return c.HTML(http.StatusOK, `<!DOCTYPE html>
+<html>
+<head>
+	<title>Echo Demo API Docs</title>
+	<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+	<div id="swagger-ui"></div>
+	<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+	<script>
+		window.onload = () => {
+			window.ui = SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui" });
+		};
+	</script>
+</body>
+</html>`)
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type openAPIDoc struct {
+	OpenAPI string `json:"openapi"`
+	Paths   map[string]map[string]struct {
+		Deprecated bool `json:"deprecated"`
+		Parameters []struct {
+			Name     string                 `json:"name"`
+			In       string                 `json:"in"`
+			Required bool                   `json:"required"`
+			Schema   map[string]interface{} `json:"schema"`
+		} `json:"parameters"`
+		RequestBody map[string]interface{}            `json:"requestBody"`
+		Responses   map[string]map[string]interface{} `json:"responses"`
+	} `json:"paths"`
+	Components struct {
+		Schemas map[string]struct {
+			Properties map[string]map[string]interface{} `json:"properties"`
+			Required   []string                          `json:"required"`
+		} `json:"schemas"`
+	} `json:"components"`
+}
+
+func fetchOpenAPI(t *testing.T) openAPIDoc {
+	t.Helper()
+	rec := doRequest(newServer(), http.MethodGet, "/openapi.json", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	var doc openAPIDoc
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid document: %v", err)
+	}
+	return doc
+}
+
+// TestOpenAPIUserByID checks the user path, its path parameter and the User schema
+func TestOpenAPIUserByID(t *testing.T) {
+	t.Parallel()
+	doc := fetchOpenAPI(t)
+
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		t.Errorf("openapi = %q", doc.OpenAPI)
+	}
+
+	item, ok := doc.Paths["/api/users/{id}"]
+	if !ok {
+		t.Fatal("missing /api/users/{id}")
+	}
+	get, ok := item["get"]
+	if !ok {
+		t.Fatal("missing GET /api/users/{id}")
+	}
+	if len(get.Parameters) != 1 || get.Parameters[0].Name != "id" || get.Parameters[0].In != "path" ||
+		!get.Parameters[0].Required || get.Parameters[0].Schema["type"] != "integer" {
+		t.Errorf("unexpected parameters: %+v", get.Parameters)
+	}
+	if !get.Deprecated {
+		t.Error("unversioned alias should be deprecated")
+	}
+	for _, code := range []string{"200", "400", "404"} {
+		if _, ok := get.Responses[code]; !ok {
+			t.Errorf("missing %s response", code)
+		}
+	}
+	if _, ok := item["put"]; !ok {
+		t.Error("missing PUT /api/users/{id}")
+	}
+	if _, ok := doc.Paths["/api/v1/users/{id}"]["delete"]; !ok {
+		t.Error("missing DELETE /api/v1/users/{id}")
+	}
+
+	user, ok := doc.Components.Schemas["User"]
+	if !ok {
+		t.Fatal("missing User schema")
+	}
+	for name, typ := range map[string]string{"id": "integer", "name": "string", "email": "string", "username": "string"} {
+		if user.Properties[name]["type"] != typ {
+			t.Errorf("User.%s = %v, want %s", name, user.Properties[name], typ)
+		}
+	}
+	if strings.Join(user.Required, ",") != "email,id,name" {
+		t.Errorf("User required = %v", user.Required)
+	}
+}
+
+// TestOpenAPIReflectsTypes checks embedded structs, timestamps and query parameters
+func TestOpenAPIReflectsTypes(t *testing.T) {
+	t.Parallel()
+	doc := fetchOpenAPI(t)
+
+	v2 := doc.Components.Schemas["UserV2"]
+	if v2.Properties["email"]["type"] != "string" || v2.Properties["created_at"]["format"] != "date-time" {
+		t.Errorf("UserV2 schema not flattened: %+v", v2.Properties)
+	}
+
+	search := doc.Paths["/api/v1/search/products"]["get"]
+	if len(search.Parameters) != 1 || search.Parameters[0].Name != "q" || search.Parameters[0].In != "query" || !search.Parameters[0].Required {
+		t.Errorf("search parameters = %+v", search.Parameters)
+	}
+	if doc.Paths["/api/v1/search/products"]["get"].Deprecated {
+		t.Error("v1 routes should not be deprecated")
+	}
+
+	create := doc.Paths["/api/v2/users"]["post"]
+	if create.RequestBody == nil {
+		t.Error("POST /api/v2/users has no request body")
+	}
+	if _, ok := create.Responses["201"]; !ok {
+		t.Errorf("POST /api/v2/users responses = %v", create.Responses)
+	}
+	if _, ok := doc.Paths["/api/v1/examples/json"]; ok {
+		t.Error("routes added outside the registry should not be documented")
+	}
+}
+
+// TestSwaggerUIPage checks the docs page loads the generated document
+func TestSwaggerUIPage(t *testing.T) {
+	t.Parallel()
+	rec := doRequest(newServer(), http.MethodGet, "/docs", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `url: "/openapi.json"`) {
+		t.Errorf("docs page = %d: %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"html/template"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// demoPassword is accepted for every seeded user; the demo has no credential store
+const demoPassword = "demo"
+
+// csrfFormField is the hidden form field carrying the CSRF token
+const csrfFormField = "_csrf"
+
+// pageTemplates holds the HTML pages rendered through Echo's Renderer
+var pageTemplates = template.Must(template.New("pages").Parse(`
+{{define "header"}}<html>
+<head>
+	<title>{{.Title}} - Echo Demo</title>
+	<style>
+		body { font-family: Arial, sans-serif; margin: 40px; }
+		.container { max-width: 600px; margin: 0 auto; }
+		.error { color: #d73027; }
+		.notice { color: #2c7a2c; }
+		label { display: block; margin-top: 10px; }
+	</style>
+</head>
+<body><div class="container">{{end}}
+
+{{define "footer"}}</div></body></html>{{end}}
+
+{{define "login"}}{{template "header" .}}
+	<h1>🔐 Log in</h1>
+	{{with .Error}}<p class="error">{{.}}</p>{{end}}
+	<form method="POST" action="/login">
+		<input type="hidden" name="_csrf" value="{{.CSRF}}">
+		<label>Email <input type="email" name="email" value="{{.Email}}"></label>
+		<label>Password <input type="password" name="password"></label>
+		<p><button type="submit">Log in</button></p>
+	</form>
+	<p>Use any seeded user's email (e.g. john@example.com) with the password <code>demo</code>.</p>
+{{template "footer" .}}{{end}}
+
+{{define "account"}}{{template "header" .}}
+	<h1>👋 Welcome, {{.User.Name}}</h1>
+	<p>Signed in as {{.User.Email}}. <a href="/logout">Log out</a></p>
+	{{with .Notice}}<p class="notice">{{.}}</p>{{end}}
+	{{with .Error}}<p class="error">{{.}}</p>{{end}}
+	<h2>✉️ Contact us</h2>
+	<form method="POST" action="/contact">
+		<input type="hidden" name="_csrf" value="{{.CSRF}}">
+		<label>Message <textarea name="message" rows="4" cols="50"></textarea></label>
+		<p><button type="submit">Send</button></p>
+	</form>
+{{template "footer" .}}{{end}}
+`))
+
+// templateRenderer adapts html/template to echo.Renderer
+type templateRenderer struct {
+	templates *template.Template
+}
+
+func (r *templateRenderer) Render(w io.Writer, name string, data interface{}, c echo.Context) error {
+	return r.templates.ExecuteTemplate(w, name, data)
+}
+
+// pageData is shared by all session pages
+type pageData struct {
+	Title  string
+	CSRF   string
+	User   User
+	Email  string
+	Error  string
+	Notice string
+}
+
+func newPageData(c echo.Context, title string) pageData {
+	data := pageData{Title: title}
+	data.CSRF, _ = c.Get(middleware.DefaultCSRFConfig.ContextKey).(string)
+	data.User, _ = sessionUser(c)
+	return data
+}
+
+// csrfProtection validates the hidden form token on every mutating request and
+// makes the current token available to templates
+func csrfProtection() echo.MiddlewareFunc {
+	return middleware.CSRFWithConfig(middleware.CSRFConfig{
+		TokenLookup:    "form:" + csrfFormField,
+		CookiePath:     "/",
+		CookieHTTPOnly: true,
+		CookieSecure:   sessions.secure,
+		CookieSameSite: http.SameSiteStrictMode,
+	})
+}
+
+// registerSessionPages wires the HTML login flow and forms
+func registerSessionPages(e *echo.Echo) {
+	e.Renderer = &templateRenderer{templates: pageTemplates}
+
+	pages := []echo.MiddlewareFunc{csrfProtection(), sessions.Middleware()}
+	e.GET("/login", showLoginPage, pages...)
+	e.POST("/login", loginHandler, pages...)
+	e.GET("/logout", logoutHandler, pages...)
+	e.GET("/account", accountPage, append(pages, requireSession)...)
+	e.POST("/contact", contactHandler, append(pages, requireSession)...)
+}
+
+func showLoginPage(c echo.Context) error {
+	if _, ok := sessionUser(c); ok {
+		return c.Redirect(http.StatusSeeOther, "/account")
+	}
+	return c.Render(http.StatusOK, "login", newPageData(c, "Log in"))
+}
+
+func loginHandler(c echo.Context) error {
+	email := strings.TrimSpace(c.FormValue("email"))
+	password := c.FormValue("password")
+
+	rec, err := users.FindByEmail(email)
+	if err != nil || password != demoPassword {
+		data := newPageData(c, "Log in")
+		data.Email = email
+		data.Error = "Invalid email or password"
+		return c.Render(http.StatusUnauthorized, "login", data)
+	}
+
+	sessions.Login(c, rec.ID)
+	return c.Redirect(http.StatusSeeOther, "/account")
+}
+
+func logoutHandler(c echo.Context) error {
+	sessions.Logout(c)
+	return c.Redirect(http.StatusSeeOther, "/login")
+}
+
+func accountPage(c echo.Context) error {
+	return c.Render(http.StatusOK, "account", newPageData(c, "Account"))
+}
+
+func contactHandler(c echo.Context) error {
+	data := newPageData(c, "Account")
+	if strings.TrimSpace(c.FormValue("message")) == "" {
+		data.Error = "Message is required"
+		return c.Render(http.StatusBadRequest, "account", data)
+	}
+	data.Notice = "Thanks, your message has been received"
+	return c.Render(http.StatusOK, "account", data)
+}
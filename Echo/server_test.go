@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata/")
+
+// withFreshUsers swaps in a newly seeded user store for the duration of a test
+func withFreshUsers(t *testing.T) {
+	t.Helper()
+	original := users
+	users = NewUserStore(seedUsers())
+	t.Cleanup(func() { users = original })
+}
+
+// withFreshProducts swaps in a newly seeded product store for the duration of a test
+func withFreshProducts(t *testing.T) {
+	t.Helper()
+	original := products
+	products = NewProductStore(seedProducts())
+	t.Cleanup(func() { products = original })
+}
+
+func doRequest(e *echo.Echo, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func decodeBody(t *testing.T, rec *httptest.ResponseRecorder) map[string]interface{} {
+	t.Helper()
+	var body map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON %q: %v", rec.Body.String(), err)
+	}
+	return body
+}
+
+// assertGolden compares a JSON body against testdata/<name>.golden.json.
+// Run `go test -update` to regenerate the files after an intentional change.
+func assertGolden(t *testing.T, name string, body []byte) {
+	t.Helper()
+
+	var normalized bytes.Buffer
+	if err := json.Indent(&normalized, bytes.TrimSpace(body), "", "  "); err != nil {
+		t.Fatalf("golden %s: body is not JSON: %v", name, err)
+	}
+	normalized.WriteByte('\n')
+
+	path := filepath.Join("testdata", name+".golden.json")
+	if *update {
+		if err := os.WriteFile(path, normalized.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read golden file (run go test -update to create it): %v", err)
+	}
+	if !bytes.Equal(normalized.Bytes(), want) {
+		t.Errorf("golden %s mismatch\n got: %s\nwant: %s", name, normalized.String(), want)
+	}
+}
+
+// routeCase is a single request against the server and its expected outcome
+type routeCase struct {
+	name     string
+	method   string
+	path     string
+	body     string
+	wantCode int
+	golden   string
+	contains string
+}
+
+func runRouteCase(t *testing.T, e *echo.Echo, tc routeCase) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := doRequest(e, tc.method, tc.path, tc.body)
+
+	if rec.Code != tc.wantCode {
+		t.Fatalf("%s %s: status = %d, want %d: %s", tc.method, tc.path, rec.Code, tc.wantCode, rec.Body.String())
+	}
+	// The timing middleware runs for every route, including errors
+	if rec.Result().Header.Get("X-Response-Time") == "" {
+		t.Errorf("%s %s: missing X-Response-Time header", tc.method, tc.path)
+	}
+	if tc.golden != "" {
+		assertGolden(t, tc.golden, rec.Body.Bytes())
+	}
+	if tc.contains != "" && !strings.Contains(rec.Body.String(), tc.contains) {
+		t.Errorf("%s %s: body does not contain %q: %s", tc.method, tc.path, tc.contains, rec.Body.String())
+	}
+	return rec
+}
+
+// TestReadOnlyRoutes covers every non-mutating route; it only reads the seeded
+// stores so the cases run in parallel
+func TestReadOnlyRoutes(t *testing.T) {
+	t.Parallel()
+	e := newServer()
+
+	tests := []routeCase{
+		{name: "home", method: http.MethodGet, path: "/", wantCode: http.StatusOK, contains: "Echo Web Framework Demo"},
+		{name: "health", method: http.MethodGet, path: "/health", wantCode: http.StatusOK, contains: `"status":"healthy"`},
+		{name: "template", method: http.MethodGet, path: "/template", wantCode: http.StatusOK, contains: "Template Example"},
+		{name: "unknown route", method: http.MethodGet, path: "/api/nope", wantCode: http.StatusNotFound},
+
+		{name: "list users", method: http.MethodGet, path: "/api/users", wantCode: http.StatusOK, golden: "users_list"},
+		{name: "get user", method: http.MethodGet, path: "/api/users/1", wantCode: http.StatusOK, golden: "user_1"},
+		{name: "get user bad id", method: http.MethodGet, path: "/api/users/abc", wantCode: http.StatusBadRequest, golden: "user_bad_id"},
+		{name: "get user missing", method: http.MethodGet, path: "/api/users/999", wantCode: http.StatusNotFound, golden: "user_not_found"},
+
+		{name: "list products", method: http.MethodGet, path: "/api/products", wantCode: http.StatusOK, golden: "products_list"},
+		{name: "get product", method: http.MethodGet, path: "/api/products/1", wantCode: http.StatusOK, golden: "product_1"},
+		{name: "get product bad id", method: http.MethodGet, path: "/api/products/xyz", wantCode: http.StatusBadRequest, golden: "product_bad_id"},
+		{name: "get product missing", method: http.MethodGet, path: "/api/products/999", wantCode: http.StatusNotFound, golden: "product_not_found"},
+		{name: "products by category", method: http.MethodGet, path: "/api/products/category/Kitchen", wantCode: http.StatusOK, golden: "products_kitchen"},
+
+		{name: "search users", method: http.MethodGet, path: "/api/search/users?q=JANE", wantCode: http.StatusOK, golden: "search_users_jane"},
+		{name: "search users without q", method: http.MethodGet, path: "/api/search/users", wantCode: http.StatusBadRequest, golden: "search_missing_q"},
+		{name: "search products", method: http.MethodGet, path: "/api/search/products?q=chair", wantCode: http.StatusOK, golden: "search_products_chair"},
+		{name: "search products without q", method: http.MethodGet, path: "/api/search/products", wantCode: http.StatusBadRequest, golden: "search_missing_q"},
+		{name: "search no matches", method: http.MethodGet, path: "/api/search/products?q=zzz", wantCode: http.StatusOK, contains: `"total":0`},
+
+		{name: "json example", method: http.MethodGet, path: "/api/examples/json", wantCode: http.StatusOK, contains: `"Hello, Echo!"`},
+		{name: "status example", method: http.MethodGet, path: "/api/examples/status?status=418", wantCode: http.StatusTeapot, contains: "I'm a teapot"},
+		{name: "params example", method: http.MethodGet, path: "/api/examples/params/John/25", wantCode: http.StatusOK, golden: "params_example"},
+		{name: "query example", method: http.MethodGet, path: "/api/examples/query?name=John&age=25&hobby=chess", wantCode: http.StatusOK, golden: "query_example"},
+		{name: "demo error", method: http.MethodGet, path: "/api/error", wantCode: http.StatusInternalServerError, contains: "This is a demo error"},
+		{name: "upload without file", method: http.MethodPost, path: "/api/upload", wantCode: http.StatusBadRequest, contains: "No file uploaded"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			runRouteCase(t, e, tc)
+		})
+	}
+}
+
+// TestCookieExample checks the cookie is set and incoming cookies are echoed back
+func TestCookieExample(t *testing.T) {
+	t.Parallel()
+	e := newServer()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/examples/cookie", nil)
+	req.AddCookie(&http.Cookie{Name: "flavour", Value: "oatmeal"})
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	var set *http.Cookie
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == "demo_cookie" {
+			set = c
+		}
+	}
+	if set == nil || set.Value != "echo_framework_demo" || !set.HttpOnly || set.MaxAge != 3600 {
+		t.Errorf("unexpected demo cookie: %+v", set)
+	}
+
+	body := decodeBody(t, rec)
+	if body["all_cookies"].(map[string]interface{})["flavour"] != "oatmeal" || body["cookie_count"] != float64(1) {
+		t.Errorf("request cookies not reported: %v", body)
+	}
+}
+
+// TestHeaderExample checks custom response headers and echoed request headers
+func TestHeaderExample(t *testing.T) {
+	t.Parallel()
+	e := newServer()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/examples/headers", nil)
+	req.Header.Set("User-Agent", "echo-test/1.0")
+	req.Header.Set("Accept", "application/json")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("X-Custom-Header"); got != "Echo-Demo-Value" {
+		t.Errorf("X-Custom-Header = %q", got)
+	}
+	if got := rec.Header().Get("X-API-Version"); got != "1.0.0" {
+		t.Errorf("X-API-Version = %q", got)
+	}
+	headers := decodeBody(t, rec)["request_headers"].(map[string]interface{})
+	if headers["User-Agent"] != "echo-test/1.0" || headers["Accept"] != "application/json" {
+		t.Errorf("request headers not echoed: %v", headers)
+	}
+}
+
+// TestMiddlewareSideEffects checks CORS and response timing headers
+func TestMiddlewareSideEffects(t *testing.T) {
+	t.Parallel()
+	e := newServer()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/users", nil)
+	req.Header.Set(echo.HeaderOrigin, "http://example.org")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get(echo.HeaderAccessControlAllowOrigin); got != "*" {
+		t.Errorf("Access-Control-Allow-Origin = %q", got)
+	}
+	if _, err := time.ParseDuration(rec.Result().Header.Get("X-Response-Time")); err != nil {
+		t.Errorf("X-Response-Time is not a duration: %v", err)
+	}
+}
+
+// TestUserCRUD walks a user through its full lifecycle; it mutates the store
+// so it does not run in parallel
+func TestUserCRUD(t *testing.T) {
+	withFreshUsers(t)
+	e := newServer()
+
+	steps := []routeCase{
+		{name: "create", method: http.MethodPost, path: "/api/users", body: `{"name":"Alice","email":"alice@example.com"}`, wantCode: http.StatusCreated, golden: "user_created"},
+		{name: "read", method: http.MethodGet, path: "/api/users/4", wantCode: http.StatusOK, golden: "user_created"},
+		{name: "update", method: http.MethodPut, path: "/api/users/4", body: `{"name":"Alice Updated","email":"alice@example.com"}`, wantCode: http.StatusOK, golden: "user_updated"},
+		{name: "delete", method: http.MethodDelete, path: "/api/users/4", wantCode: http.StatusOK, golden: "user_deleted"},
+		{name: "read deleted", method: http.MethodGet, path: "/api/users/4", wantCode: http.StatusNotFound, golden: "user_not_found"},
+
+		{name: "create missing email", method: http.MethodPost, path: "/api/users", body: `{"name":"NoEmail"}`, wantCode: http.StatusBadRequest, golden: "user_validation"},
+		{name: "create malformed body", method: http.MethodPost, path: "/api/users", body: `{"name":`, wantCode: http.StatusBadRequest, golden: "invalid_body"},
+		{name: "update bad id", method: http.MethodPut, path: "/api/users/abc", body: `{}`, wantCode: http.StatusBadRequest, golden: "user_bad_id"},
+		{name: "update missing", method: http.MethodPut, path: "/api/users/999", body: `{"name":"X","email":"x@example.com"}`, wantCode: http.StatusNotFound, golden: "user_not_found"},
+		{name: "delete bad id", method: http.MethodDelete, path: "/api/users/abc", wantCode: http.StatusBadRequest, golden: "user_bad_id"},
+		{name: "delete missing", method: http.MethodDelete, path: "/api/users/999", wantCode: http.StatusNotFound, golden: "user_not_found"},
+	}
+
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			runRouteCase(t, e, step)
+		})
+	}
+}
+
+// TestProductCRUD walks a product through its full lifecycle
+func TestProductCRUD(t *testing.T) {
+	withFreshProducts(t)
+	e := newServer()
+
+	steps := []routeCase{
+		{name: "create", method: http.MethodPost, path: "/api/products", body: `{"name":"Gaming Mouse","price":59.99,"category":"Electronics","description":"High-precision gaming mouse"}`, wantCode: http.StatusCreated, golden: "product_created"},
+		{name: "read", method: http.MethodGet, path: "/api/products/4", wantCode: http.StatusOK, golden: "product_created"},
+		{name: "in category", method: http.MethodGet, path: "/api/products/category/Electronics", wantCode: http.StatusOK, contains: `"total":2`},
+		{name: "update", method: http.MethodPut, path: "/api/products/4", body: `{"name":"Gaming Mouse","price":49.99,"category":"Electronics","description":"On sale"}`, wantCode: http.StatusOK, golden: "product_updated"},
+		{name: "delete", method: http.MethodDelete, path: "/api/products/4", wantCode: http.StatusOK, golden: "product_deleted"},
+		{name: "read deleted", method: http.MethodGet, path: "/api/products/4", wantCode: http.StatusNotFound, golden: "product_not_found"},
+
+		{name: "create invalid price", method: http.MethodPost, path: "/api/products", body: `{"name":"Free","price":0}`, wantCode: http.StatusBadRequest, golden: "product_validation"},
+		{name: "create malformed body", method: http.MethodPost, path: "/api/products", body: `[`, wantCode: http.StatusBadRequest, golden: "invalid_body"},
+		{name: "update bad id", method: http.MethodPut, path: "/api/products/abc", body: `{}`, wantCode: http.StatusBadRequest, golden: "product_bad_id"},
+		{name: "update missing", method: http.MethodPut, path: "/api/products/999", body: `{"name":"X","price":1}`, wantCode: http.StatusNotFound, golden: "product_not_found"},
+		{name: "delete missing", method: http.MethodDelete, path: "/api/products/999", wantCode: http.StatusNotFound, golden: "product_not_found"},
+	}
+
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			runRouteCase(t, e, step)
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	sessionCookieName = "echo_session"
+	sessionContextKey = "session_user"
+	sessionTTL        = 2 * time.Hour
+)
+
+// Session errors
+var (
+	ErrSessionMalformed = errors.New("malformed session cookie")
+	ErrSessionTampered  = errors.New("session signature mismatch")
+	ErrSessionExpired   = errors.New("session expired")
+)
+
+// SessionManager issues and verifies signed session cookies.
+// The cookie value is "<base64(userID:expiryUnix)>.<base64(HMAC-SHA256)>" so the
+// server needs no session storage, yet any modification is detected.
+type SessionManager struct {
+	secret []byte
+	ttl    time.Duration
+	now    func() time.Time
+	// secure forces the Secure attribute even for plain HTTP requests
+	secure bool
+}
+
+// NewSessionManager creates a manager signing with the given secret
+func NewSessionManager(secret []byte, ttl time.Duration) *SessionManager {
+	return &SessionManager{secret: secret, ttl: ttl, now: time.Now}
+}
+
+var sessions = newSessionManagerFromEnv()
+
+// newSessionManagerFromEnv reads SESSION_SECRET and COOKIE_SECURE. Without a secret a
+// random one is generated, so sessions do not survive a restart.
+func newSessionManagerFromEnv() *SessionManager {
+	secret := []byte(os.Getenv("SESSION_SECRET"))
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			panic(err)
+		}
+	}
+	m := NewSessionManager(secret, sessionTTL)
+	m.secure = os.Getenv("COOKIE_SECURE") == "1"
+	return m
+}
+
+// Sign returns a cookie value for the user that expires after the manager's TTL
+func (m *SessionManager) Sign(userID int) string {
+	payload := fmt.Sprintf("%d:%d", userID, m.now().Add(m.ttl).Unix())
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(m.mac([]byte(payload)))
+}
+
+// Verify checks the signature and expiry of a cookie value and returns the user ID
+func (m *SessionManager) Verify(value string) (int, error) {
+	encPayload, encMAC, ok := strings.Cut(value, ".")
+	if !ok {
+		return 0, ErrSessionMalformed
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encPayload)
+	if err != nil {
+		return 0, ErrSessionMalformed
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(encMAC)
+	if err != nil {
+		return 0, ErrSessionMalformed
+	}
+	if !hmac.Equal(mac, m.mac(payload)) {
+		return 0, ErrSessionTampered
+	}
+
+	idPart, expPart, ok := strings.Cut(string(payload), ":")
+	if !ok {
+		return 0, ErrSessionMalformed
+	}
+	userID, err := strconv.Atoi(idPart)
+	if err != nil {
+		return 0, ErrSessionMalformed
+	}
+	expiry, err := strconv.ParseInt(expPart, 10, 64)
+	if err != nil {
+		return 0, ErrSessionMalformed
+	}
+	if !m.now().Before(time.Unix(expiry, 0)) {
+		return 0, ErrSessionExpired
+	}
+	return userID, nil
+}
+
+func (m *SessionManager) mac(payload []byte) []byte {
+	h := hmac.New(sha256.New, m.secret)
+	h.Write(payload)
+	return h.Sum(nil)
+}
+
+// cookie builds the session cookie; maxAge < 0 deletes it
+func (m *SessionManager) cookie(c echo.Context, value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   m.secure || c.Scheme() == "https",
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// Login sets a fresh session cookie for the user
+func (m *SessionManager) Login(c echo.Context, userID int) {
+	c.SetCookie(m.cookie(c, m.Sign(userID), int(m.ttl.Seconds())))
+}
+
+// Logout clears the session cookie
+func (m *SessionManager) Logout(c echo.Context) {
+	c.SetCookie(m.cookie(c, "", -1))
+}
+
+// Middleware loads the session user into the context. Invalid, tampered or
+// expired cookies are cleared and the request continues unauthenticated.
+func (m *SessionManager) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			cookie, err := c.Cookie(sessionCookieName)
+			if err != nil || cookie.Value == "" {
+				return next(c)
+			}
+
+			userID, err := m.Verify(cookie.Value)
+			if err == nil {
+				var rec UserRecord
+				if rec, err = users.Get(userID); err == nil {
+					c.Set(sessionContextKey, rec.User)
+					return next(c)
+				}
+			}
+
+			c.Logger().Warnf("rejecting session cookie: %v", err)
+			m.Logout(c)
+			return next(c)
+		}
+	}
+}
+
+// sessionUser returns the logged in user, if any
+func sessionUser(c echo.Context) (User, bool) {
+	u, ok := c.Get(sessionContextKey).(User)
+	return u, ok
+}
+
+// requireSession redirects anonymous visitors to the login page
+func requireSession(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if _, ok := sessionUser(c); !ok {
+			return c.Redirect(http.StatusSeeOther, "/login")
+		}
+		return next(c)
+	}
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+var csrfInputPattern = regexp.MustCompile(`name="_csrf" value="([^"]+)"`)
+
+// browser is a minimal cookie-carrying client for driving the HTML pages
+type browser struct {
+	t       *testing.T
+	e       *echo.Echo
+	cookies map[string]*http.Cookie
+	headers map[string]string
+}
+
+func newBrowser(t *testing.T, e *echo.Echo) *browser {
+	return &browser{t: t, e: e, cookies: map[string]*http.Cookie{}, headers: map[string]string{}}
+}
+
+func (b *browser) do(method, path string, form url.Values) *httptest.ResponseRecorder {
+	b.t.Helper()
+	var body *strings.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	} else {
+		body = strings.NewReader("")
+	}
+	req := httptest.NewRequest(method, path, body)
+	if form != nil {
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	}
+	for k, v := range b.headers {
+		req.Header.Set(k, v)
+	}
+	for _, c := range b.cookies {
+		req.AddCookie(c)
+	}
+
+	rec := httptest.NewRecorder()
+	b.e.ServeHTTP(rec, req)
+	for _, c := range rec.Result().Cookies() {
+		if c.MaxAge < 0 {
+			delete(b.cookies, c.Name)
+		} else {
+			b.cookies[c.Name] = c
+		}
+	}
+	return rec
+}
+
+// csrfToken loads a page and extracts the token injected into its form
+func (b *browser) csrfToken(path string) string {
+	b.t.Helper()
+	rec := b.do(http.MethodGet, path, nil)
+	m := csrfInputPattern.FindStringSubmatch(rec.Body.String())
+	if m == nil {
+		b.t.Fatalf("no CSRF token in %s: %s", path, rec.Body.String())
+	}
+	return m[1]
+}
+
+func (b *browser) login(email, password string) *httptest.ResponseRecorder {
+	b.t.Helper()
+	token := b.csrfToken("/login")
+	return b.do(http.MethodPost, "/login", url.Values{
+		"email": {email}, "password": {password}, csrfFormField: {token},
+	})
+}
+
+func findCookie(rec *httptest.ResponseRecorder, name string) *http.Cookie {
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// TestLoginSetsSecureSessionCookie logs in and visits the protected page
+func TestLoginSetsSecureSessionCookie(t *testing.T) {
+	withFreshUsers(t)
+	b := newBrowser(t, newServer())
+	b.headers[echo.HeaderXForwardedProto] = "https"
+
+	rec := b.login("JOHN@example.com", demoPassword)
+	if rec.Code != http.StatusSeeOther || rec.Header().Get(echo.HeaderLocation) != "/account" {
+		t.Fatalf("login = %d %s", rec.Code, rec.Header().Get(echo.HeaderLocation))
+	}
+	cookie := findCookie(rec, sessionCookieName)
+	if cookie == nil {
+		t.Fatal("no session cookie set")
+	}
+	if !cookie.HttpOnly || !cookie.Secure || cookie.SameSite != http.SameSiteLaxMode || cookie.MaxAge != int(sessionTTL.Seconds()) {
+		t.Errorf("unexpected cookie attributes: %+v", cookie)
+	}
+
+	rec = b.do(http.MethodGet, "/account", nil)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Welcome, John Doe") {
+		t.Fatalf("account page = %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = b.do(http.MethodGet, "/logout", nil)
+	if c := findCookie(rec, sessionCookieName); c == nil || c.MaxAge >= 0 {
+		t.Errorf("logout did not clear the cookie: %+v", c)
+	}
+	if rec = b.do(http.MethodGet, "/account", nil); rec.Code != http.StatusSeeOther {
+		t.Errorf("account after logout = %d, want redirect", rec.Code)
+	}
+}
+
+// TestLoginRejectsBadCredentials re-renders the form with an error
+func TestLoginRejectsBadCredentials(t *testing.T) {
+	withFreshUsers(t)
+	b := newBrowser(t, newServer())
+
+	rec := b.login("john@example.com", "wrong")
+	if rec.Code != http.StatusUnauthorized || !strings.Contains(rec.Body.String(), "Invalid email or password") {
+		t.Errorf("bad login = %d: %s", rec.Code, rec.Body.String())
+	}
+	if findCookie(rec, sessionCookieName) != nil {
+		t.Error("session cookie set for failed login")
+	}
+}
+
+// TestAnonymousAccountRedirects checks the protected page requires a session
+func TestAnonymousAccountRedirects(t *testing.T) {
+	rec := doRequest(newServer(), http.MethodGet, "/account", "")
+	if rec.Code != http.StatusSeeOther || rec.Header().Get(echo.HeaderLocation) != "/login" {
+		t.Errorf("anonymous account = %d %s", rec.Code, rec.Header().Get(echo.HeaderLocation))
+	}
+}
+
+// TestTamperedSessionRejected changes the signed user ID and expects the cookie to be dropped
+func TestTamperedSessionRejected(t *testing.T) {
+	withFreshUsers(t)
+	b := newBrowser(t, newServer())
+	b.login("john@example.com", demoPassword)
+
+	valid := b.cookies[sessionCookieName].Value
+	_, mac, _ := strings.Cut(valid, ".")
+	forged := base64URL("2:9999999999") + "." + mac
+	b.cookies[sessionCookieName] = &http.Cookie{Name: sessionCookieName, Value: forged}
+
+	rec := b.do(http.MethodGet, "/account", nil)
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("tampered session = %d, want redirect to login", rec.Code)
+	}
+	if _, ok := b.cookies[sessionCookieName]; ok {
+		t.Error("tampered cookie was not cleared")
+	}
+}
+
+// TestSessionVerify covers expiry and malformed values at the manager level
+func TestSessionVerify(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	m := NewSessionManager([]byte("test-secret"), time.Hour)
+	m.now = func() time.Time { return now }
+
+	value := m.Sign(7)
+	if id, err := m.Verify(value); err != nil || id != 7 {
+		t.Fatalf("Verify = %d, %v", id, err)
+	}
+
+	now = now.Add(time.Hour)
+	if _, err := m.Verify(value); !errors.Is(err, ErrSessionExpired) {
+		t.Errorf("expired session err = %v", err)
+	}
+
+	other := NewSessionManager([]byte("other-secret"), time.Hour)
+	if _, err := other.Verify(value); !errors.Is(err, ErrSessionTampered) {
+		t.Errorf("wrong secret err = %v", err)
+	}
+	for _, bad := range []string{"", "abc", "!!!.###", base64URL("nocolon") + "." + base64URL("x")} {
+		if _, err := m.Verify(bad); err == nil {
+			t.Errorf("Verify(%q) succeeded", bad)
+		}
+	}
+}
+
+// TestContactFormCSRF checks the form is rejected without a valid token
+func TestContactFormCSRF(t *testing.T) {
+	withFreshUsers(t)
+	b := newBrowser(t, newServer())
+	b.login("jane@example.com", demoPassword)
+
+	if rec := b.do(http.MethodPost, "/contact", url.Values{"message": {"hi"}}); rec.Code != http.StatusBadRequest {
+		t.Errorf("missing token = %d, want 400", rec.Code)
+	}
+	if rec := b.do(http.MethodPost, "/contact", url.Values{"message": {"hi"}, csrfFormField: {"forged"}}); rec.Code != http.StatusForbidden {
+		t.Errorf("invalid token = %d, want 403", rec.Code)
+	}
+
+	token := b.csrfToken("/account")
+	rec := b.do(http.MethodPost, "/contact", url.Values{"message": {"hi"}, csrfFormField: {token}})
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "message has been received") {
+		t.Errorf("valid token = %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func base64URL(s string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(s))
+}
@@ -0,0 +1,472 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Store errors
+var (
+	// ErrNotFound is returned by the stores when a record does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrBatchAborted marks items of an atomic batch that were not stored because another item failed
+	ErrBatchAborted = errors.New("batch aborted")
+)
+
+// ErrConflict is returned when a write would duplicate a unique user field
+type ErrConflict struct {
+	Field string
+	Value string
+}
+
+func (e *ErrConflict) Error() string {
+	return fmt.Sprintf("%s %q is already in use", e.Field, e.Value)
+}
+
+// UserRecord is a stored user together with its bookkeeping timestamps
+type UserRecord struct {
+	User
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// UserStore is a concurrency-safe in-memory user repository shared by all API versions.
+// Emails (case-insensitive) and usernames are unique and indexed for O(1) lookups.
+type UserStore struct {
+	mu      sync.RWMutex
+	records []UserRecord
+	nextID  int
+	now     func() time.Time
+
+	byEmail    map[string]int
+	byUsername map[string]int
+}
+
+// NewUserStore creates a store seeded with the given users
+func NewUserStore(seed []User) *UserStore {
+	s := &UserStore{
+		nextID:     1,
+		now:        time.Now,
+		byEmail:    make(map[string]int),
+		byUsername: make(map[string]int),
+	}
+	for _, u := range seed {
+		ts := s.now()
+		s.records = append(s.records, UserRecord{User: u, CreatedAt: ts, UpdatedAt: ts})
+		s.index(u)
+		if u.ID >= s.nextID {
+			s.nextID = u.ID + 1
+		}
+	}
+	return s
+}
+
+func emailKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// index and unindex maintain the unique lookups; both must be called with the lock held
+func (s *UserStore) index(u User) {
+	if emailKey(u.Email) != "" {
+		s.byEmail[emailKey(u.Email)] = u.ID
+	}
+	if u.Username != "" {
+		s.byUsername[u.Username] = u.ID
+	}
+}
+
+func (s *UserStore) unindex(u User) {
+	if emailKey(u.Email) != "" {
+		delete(s.byEmail, emailKey(u.Email))
+	}
+	if u.Username != "" {
+		delete(s.byUsername, u.Username)
+	}
+}
+
+// checkUnique reports a conflict if another user (not selfID) owns u's email or username.
+// Empty fields are never indexed, so they never conflict. Must be called with the lock held.
+func (s *UserStore) checkUnique(u User, selfID int) error {
+	if emailKey(u.Email) != "" {
+		if owner, ok := s.byEmail[emailKey(u.Email)]; ok && owner != selfID {
+			return &ErrConflict{Field: "email", Value: u.Email}
+		}
+	}
+	if u.Username != "" {
+		if owner, ok := s.byUsername[u.Username]; ok && owner != selfID {
+			return &ErrConflict{Field: "username", Value: u.Username}
+		}
+	}
+	return nil
+}
+
+// List returns a snapshot of all users ordered by ID
+func (s *UserStore) List() []UserRecord {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]UserRecord(nil), s.records...)
+}
+
+// Get returns the user with the given ID
+func (s *UserStore) Get(id int) (UserRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if i := s.indexOf(id); i >= 0 {
+		return s.records[i], nil
+	}
+	return UserRecord{}, ErrNotFound
+}
+
+// Create assigns a new ID to the user and stores it
+func (s *UserStore) Create(u User) (UserRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkUnique(u, 0); err != nil {
+		return UserRecord{}, err
+	}
+	return s.insert(u, s.now()), nil
+}
+
+// insert must be called with the lock held after uniqueness was checked
+func (s *UserStore) insert(u User, ts time.Time) UserRecord {
+	u.ID = s.nextID
+	s.nextID++
+	rec := UserRecord{User: u, CreatedAt: ts, UpdatedAt: ts}
+	s.records = append(s.records, rec)
+	s.index(u)
+	return rec
+}
+
+// CreateMany stores a batch under a single lock. created and errs are aligned
+// with batch: errs[i] explains why item i was not stored. In atomic mode nothing
+// is stored unless every item can be, including duplicates within the batch.
+func (s *UserStore) CreateMany(batch []User, atomic bool) (created []UserRecord, errs []error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	created = make([]UserRecord, len(batch))
+	errs = make([]error, len(batch))
+
+	// Detect conflicts with existing users and earlier items of the same batch
+	pendingEmail := make(map[string]bool)
+	pendingUsername := make(map[string]bool)
+	failed := false
+	for i, u := range batch {
+		err := s.checkUnique(u, 0)
+		if err == nil && emailKey(u.Email) != "" && pendingEmail[emailKey(u.Email)] {
+			err = &ErrConflict{Field: "email", Value: u.Email}
+		}
+		if err == nil && u.Username != "" && pendingUsername[u.Username] {
+			err = &ErrConflict{Field: "username", Value: u.Username}
+		}
+		if err != nil {
+			errs[i] = err
+			failed = true
+			continue
+		}
+		if emailKey(u.Email) != "" {
+			pendingEmail[emailKey(u.Email)] = true
+		}
+		if u.Username != "" {
+			pendingUsername[u.Username] = true
+		}
+	}
+	if atomic && failed {
+		for i := range errs {
+			if errs[i] == nil {
+				errs[i] = ErrBatchAborted
+			}
+		}
+		return created, errs
+	}
+
+	ts := s.now()
+	for i, u := range batch {
+		if errs[i] == nil {
+			created[i] = s.insert(u, ts)
+		}
+	}
+	return created, errs
+}
+
+// Update replaces the user's fields while keeping its ID and creation time
+func (s *UserStore) Update(id int, u User) (UserRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.indexOf(id)
+	if i < 0 {
+		return UserRecord{}, ErrNotFound
+	}
+	if err := s.checkUnique(u, id); err != nil {
+		return UserRecord{}, err
+	}
+
+	u.ID = id
+	s.unindex(s.records[i].User)
+	s.records[i].User = u
+	s.records[i].UpdatedAt = s.now()
+	s.index(u)
+	return s.records[i], nil
+}
+
+// Delete removes the user with the given ID, freeing its email and username
+func (s *UserStore) Delete(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.indexOf(id)
+	if i < 0 {
+		return ErrNotFound
+	}
+	s.unindex(s.records[i].User)
+	s.records = append(s.records[:i], s.records[i+1:]...)
+	return nil
+}
+
+// FindByEmail returns the user with the given email (case-insensitive)
+func (s *UserStore) FindByEmail(email string) (UserRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if id, ok := s.byEmail[emailKey(email)]; ok {
+		return s.records[s.indexOf(id)], nil
+	}
+	return UserRecord{}, ErrNotFound
+}
+
+// Search returns users whose name or email contains the query (case-insensitive)
+func (s *UserStore) Search(query string) []UserRecord {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var results []UserRecord
+	for _, rec := range s.records {
+		if containsIgnoreCase(rec.Name, query) || containsIgnoreCase(rec.Email, query) {
+			results = append(results, rec)
+		}
+	}
+	return results
+}
+
+// indexOf must be called with the lock held
+func (s *UserStore) indexOf(id int) int {
+	for i, rec := range s.records {
+		if rec.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// ProductEvent describes a change to a product, delivered to store subscribers
+type ProductEvent struct {
+	Seq     uint64  `json:"seq"`
+	Type    string  `json:"type"`
+	Product Product `json:"product"`
+}
+
+// Product event types
+const (
+	ProductCreated = "product.created"
+	ProductUpdated = "product.updated"
+)
+
+// subscriberBuffer is how many events a slow subscriber may lag behind before events are dropped
+const subscriberBuffer = 16
+
+// ProductStore is a concurrency-safe in-memory product repository
+type ProductStore struct {
+	mu       sync.RWMutex
+	products []Product
+	nextID   int
+
+	subMu       sync.Mutex
+	subscribers map[chan ProductEvent]struct{}
+	seq         uint64
+}
+
+// NewProductStore creates a store seeded with the given products
+func NewProductStore(seed []Product) *ProductStore {
+	s := &ProductStore{nextID: 1, subscribers: make(map[chan ProductEvent]struct{})}
+	for _, p := range seed {
+		s.products = append(s.products, p)
+		if p.ID >= s.nextID {
+			s.nextID = p.ID + 1
+		}
+	}
+	return s
+}
+
+// List returns a snapshot of all products ordered by ID
+func (s *ProductStore) List() []Product {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]Product(nil), s.products...)
+}
+
+// Filter returns the products in the given category, or all products when category is empty
+func (s *ProductStore) Filter(category string) []Product {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var filtered []Product
+	for _, p := range s.products {
+		if category == "" || p.Category == category {
+			filtered = append(filtered, p)
+		}
+	}
+	return filtered
+}
+
+// Search returns products whose name, category or description contains the query
+func (s *ProductStore) Search(query string) []Product {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var results []Product
+	for _, p := range s.products {
+		if containsIgnoreCase(p.Name, query) ||
+			containsIgnoreCase(p.Category, query) ||
+			containsIgnoreCase(p.Description, query) {
+			results = append(results, p)
+		}
+	}
+	return results
+}
+
+// Get returns the product with the given ID
+func (s *ProductStore) Get(id int) (Product, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if i := s.indexOf(id); i >= 0 {
+		return s.products[i], nil
+	}
+	return Product{}, ErrNotFound
+}
+
+// Create assigns a new ID to the product and stores it
+func (s *ProductStore) Create(p Product) (Product, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p.ID = s.nextID
+	s.nextID++
+	s.products = append(s.products, p)
+	s.publish(ProductCreated, p)
+	return p, nil
+}
+
+// Update replaces the product with the given ID
+func (s *ProductStore) Update(id int, p Product) (Product, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.indexOf(id)
+	if i < 0 {
+		return Product{}, ErrNotFound
+	}
+	p.ID = id
+	s.products[i] = p
+	s.publish(ProductUpdated, p)
+	return p, nil
+}
+
+// Delete removes the product with the given ID
+func (s *ProductStore) Delete(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.indexOf(id)
+	if i < 0 {
+		return ErrNotFound
+	}
+	s.products = append(s.products[:i], s.products[i+1:]...)
+	return nil
+}
+
+// DeleteMany removes the given IDs and reports which were deleted and which were missing.
+// In atomic mode nothing is deleted unless every ID exists.
+func (s *ProductStore) DeleteMany(ids []int, atomic bool) (deleted, missing []int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deleted, missing = []int{}, []int{}
+	seen := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if s.indexOf(id) >= 0 {
+			deleted = append(deleted, id)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+
+	if atomic && len(missing) > 0 {
+		return []int{}, missing
+	}
+
+	remove := make(map[int]bool, len(deleted))
+	for _, id := range deleted {
+		remove[id] = true
+	}
+	kept := s.products[:0]
+	for _, p := range s.products {
+		if !remove[p.ID] {
+			kept = append(kept, p)
+		}
+	}
+	s.products = kept
+	return deleted, missing
+}
+
+// Subscribe registers for product change events. The returned function
+// unsubscribes and must be called once the caller stops reading.
+func (s *ProductStore) Subscribe() (<-chan ProductEvent, func()) {
+	ch := make(chan ProductEvent, subscriberBuffer)
+
+	s.subMu.Lock()
+	s.subscribers[ch] = struct{}{}
+	s.subMu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			s.subMu.Lock()
+			delete(s.subscribers, ch)
+			s.subMu.Unlock()
+		})
+	}
+}
+
+// publish fans an event out to all subscribers without blocking writers;
+// subscribers that have fallen behind miss the event
+func (s *ProductStore) publish(eventType string, p Product) {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+
+	s.seq++
+	event := ProductEvent{Seq: s.seq, Type: eventType, Product: p}
+	for ch := range s.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// indexOf must be called with the lock held
+func (s *ProductStore) indexOf(id int) int {
+	for i, p := range s.products {
+		if p.ID == id {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// sseKeepAlive is how often a comment line is sent so proxies keep the connection open
+var sseKeepAlive = 15 * time.Second
+
+// streamProducts streams product changes as server-sent events until the client disconnects
+func streamProducts(c echo.Context) error {
+	events, unsubscribe := products.Subscribe()
+	defer unsubscribe()
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+
+	// An initial comment tells the client the subscription is live
+	if _, err := fmt.Fprint(res, ": connected\n\n"); err != nil {
+		return nil
+	}
+	res.Flush()
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+
+	ctx := c.Request().Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(res, ": keep-alive\n\n"); err != nil {
+				return nil
+			}
+			res.Flush()
+		case event := <-events:
+			if err := writeSSE(res, event); err != nil {
+				return nil
+			}
+			res.Flush()
+		}
+	}
+}
+
+// writeSSE writes one event in text/event-stream framing
+func writeSSE(w http.ResponseWriter, event ProductEvent) error {
+	data, err := json.Marshal(event.Product)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Seq, event.Type, data)
+	return err
+}
+
+// jitterPrices nudges a random product's price by up to ±5% on every tick so
+// the stream has visible activity. Enabled with STREAM_DEMO=1.
+func jitterPrices(ctx context.Context, store *ProductStore, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			all := store.List()
+			if len(all) == 0 {
+				continue
+			}
+			p := all[rand.Intn(len(all))]
+			factor := 1 + (rand.Float64()-0.5)/10
+			p.Price = math.Round(p.Price*factor*100) / 100
+			store.Update(p.ID, p)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readSSEEvent reads lines until a complete event (terminated by a blank line)
+// with an event: field arrives. Comment-only blocks are returned as comments.
+func readSSEEvent(t *testing.T, r *bufio.Reader) (event, data string, comments []string) {
+	t.Helper()
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read stream: %v", err)
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "":
+			if event != "" {
+				return event, data, comments
+			}
+		case strings.HasPrefix(line, ":"):
+			comments = append(comments, strings.TrimSpace(line[1:]))
+			if event == "" {
+				return "", "", comments
+			}
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func openStream(t *testing.T, url string) (*bufio.Reader, *http.Response, context.CancelFunc) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		cancel()
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return bufio.NewReader(resp.Body), resp, cancel
+}
+
+// TestStreamProductsEmitsMutations asserts created and updated products are streamed
+func TestStreamProductsEmitsMutations(t *testing.T) {
+	withFreshProducts(t)
+	srv := httptest.NewServer(newServer())
+	defer srv.Close()
+
+	reader, resp, cancel := openStream(t, srv.URL+"/api/stream/products")
+	defer cancel()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if cc := resp.Header.Get("Cache-Control"); cc != "no-cache" {
+		t.Errorf("Cache-Control = %q", cc)
+	}
+
+	// Wait for the subscription before mutating
+	if _, _, comments := readSSEEvent(t, reader); len(comments) == 0 || comments[0] != "connected" {
+		t.Fatalf("expected connected comment, got %v", comments)
+	}
+
+	createResp, err := http.Post(srv.URL+"/api/products", "application/json",
+		strings.NewReader(`{"name":"Lamp","price":25,"category":"Furniture"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	createResp.Body.Close()
+
+	event, data, _ := readSSEEvent(t, reader)
+	if event != ProductCreated {
+		t.Fatalf("event = %q, want %q", event, ProductCreated)
+	}
+	var created Product
+	if err := json.Unmarshal([]byte(data), &created); err != nil {
+		t.Fatalf("data is not a product: %q", data)
+	}
+	if created.Name != "Lamp" || created.ID != 4 {
+		t.Errorf("unexpected product: %+v", created)
+	}
+
+	created.Price = 30
+	products.Update(created.ID, created)
+	if event, data, _ = readSSEEvent(t, reader); event != ProductUpdated || !strings.Contains(data, `"price":30`) {
+		t.Errorf("unexpected update event %q: %s", event, data)
+	}
+}
+
+// TestStreamProductsKeepAliveAndDisconnect checks keep-alive comments and that the
+// handler unsubscribes once the client goes away
+func TestStreamProductsKeepAliveAndDisconnect(t *testing.T) {
+	withFreshProducts(t)
+	original := sseKeepAlive
+	sseKeepAlive = 20 * time.Millisecond
+	t.Cleanup(func() { sseKeepAlive = original })
+
+	srv := httptest.NewServer(newServer())
+	defer srv.Close()
+
+	reader, _, cancel := openStream(t, srv.URL+"/api/stream/products")
+	readSSEEvent(t, reader) // connected
+	if _, _, comments := readSSEEvent(t, reader); len(comments) == 0 || comments[0] != "keep-alive" {
+		t.Errorf("expected keep-alive comment, got %v", comments)
+	}
+
+	cancel()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		products.subMu.Lock()
+		remaining := len(products.subscribers)
+		products.subMu.Unlock()
+		if remaining == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d subscribers still registered after disconnect", remaining)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestJitterPrices checks the demo goroutine publishes updates and stops with its context
+func TestJitterPrices(t *testing.T) {
+	store := NewProductStore(seedProducts())
+	events, unsubscribe := store.Subscribe()
+	defer unsubscribe()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go jitterPrices(ctx, store, 5*time.Millisecond)
+
+	select {
+	case event := <-events:
+		if event.Type != ProductUpdated {
+			t.Errorf("event type = %q", event.Type)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no price update published")
+	}
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// UserV2 is the v2 representation of a user, adding audit timestamps
+type UserV2 struct {
+	User
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Pagination describes the page returned inside a v2 list envelope
+type Pagination struct {
+	Page       int `json:"page"`
+	PerPage    int `json:"per_page"`
+	Total      int `json:"total"`
+	TotalPages int `json:"total_pages"`
+}
+
+// PageEnvelope is the v2 list response shape
+type PageEnvelope struct {
+	Data       interface{} `json:"data"`
+	Pagination Pagination  `json:"pagination"`
+}
+
+const (
+	defaultPerPage = 10
+	maxPerPage     = 100
+)
+
+// userAPI binds the shared user handler logic to a version-specific
+// presentation. Each API version is a thin adapter over the same store.
+type userAPI struct {
+	store *UserStore
+	// view converts a stored record into the version's JSON representation
+	view func(UserRecord) interface{}
+	// list renders a collection of already converted items
+	list func(c echo.Context, items []interface{}) error
+}
+
+var userAPIv1 = userAPI{
+	view: func(rec UserRecord) interface{} { return rec.User },
+	list: func(c echo.Context, items []interface{}) error {
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"users": items,
+			"total": len(items),
+		})
+	},
+}
+
+var userAPIv2 = userAPI{
+	view: func(rec UserRecord) interface{} {
+		return UserV2{User: rec.User, CreatedAt: rec.CreatedAt, UpdatedAt: rec.UpdatedAt}
+	},
+	list: paginatedList,
+}
+
+// withStore returns a copy of the adapter bound to the given store
+func (api userAPI) withStore(store *UserStore) userAPI {
+	api.store = store
+	return api
+}
+
+func (api userAPI) views(records []UserRecord) []interface{} {
+	items := make([]interface{}, 0, len(records))
+	for _, rec := range records {
+		items = append(items, api.view(rec))
+	}
+	return items
+}
+
+func (api userAPI) getAll(c echo.Context) error {
+	return api.list(c, api.views(api.store.List()))
+}
+
+func (api userAPI) getByID(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "Invalid user ID"),
+		})
+	}
+
+	rec, err := api.store.Get(id)
+	if err != nil {
+		return userStoreError(c, err)
+	}
+	return c.JSON(http.StatusOK, api.view(rec))
+}
+
+func (api userAPI) create(c echo.Context) error {
+	var newUser User
+	if err := c.Bind(&newUser); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "Invalid request body"),
+		})
+	}
+
+	// Simple validation
+	if newUser.Name == "" || newUser.Email == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "Name and email are required"),
+		})
+	}
+
+	rec, err := api.store.Create(newUser)
+	if err != nil {
+		return userStoreError(c, err)
+	}
+	return c.JSON(http.StatusCreated, api.view(rec))
+}
+
+func (api userAPI) update(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "Invalid user ID"),
+		})
+	}
+
+	var updatedUser User
+	if err := c.Bind(&updatedUser); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "Invalid request body"),
+		})
+	}
+
+	// Simple validation, as for create
+	if updatedUser.Name == "" || updatedUser.Email == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "Name and email are required"),
+		})
+	}
+
+	rec, err := api.store.Update(id, updatedUser)
+	if err != nil {
+		return userStoreError(c, err)
+	}
+	return c.JSON(http.StatusOK, api.view(rec))
+}
+
+func (api userAPI) delete(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "Invalid user ID"),
+		})
+	}
+
+	if err := api.store.Delete(id); err != nil {
+		return userStoreError(c, err)
+	}
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "User deleted successfully",
+	})
+}
+
+func (api userAPI) search(c echo.Context) error {
+	query := c.QueryParam("q")
+	if query == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "Query parameter 'q' is required"),
+		})
+	}
+
+	results := api.views(api.store.Search(query))
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"query":   query,
+		"results": results,
+		"total":   len(results),
+	})
+}
+
+// userStoreError maps store errors to HTTP responses
+func userStoreError(c echo.Context, err error) error {
+	var conflict *ErrConflict
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "User not found"),
+		})
+	case errors.As(err, &conflict):
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": trf(c, "A user with this %s already exists", conflict.Field),
+			"field": conflict.Field,
+		})
+	}
+	return err
+}
+
+// paginatedList renders items in the v2 envelope using ?page and ?per_page
+func paginatedList(c echo.Context, items []interface{}) error {
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	if page < 1 {
+		page = 1
+	}
+	perPage, _ := strconv.Atoi(c.QueryParam("per_page"))
+	if perPage < 1 {
+		perPage = defaultPerPage
+	}
+	if perPage > maxPerPage {
+		perPage = maxPerPage
+	}
+
+	// Past the end is the last page, which also keeps a huge ?page from
+	// overflowing the offset
+	total := len(items)
+	totalPages := (total + perPage - 1) / perPage
+	page = min(page, max(1, totalPages))
+	start := (page - 1) * perPage
+	end := start + perPage
+	if end > total {
+		end = total
+	}
+
+	return c.JSON(http.StatusOK, PageEnvelope{
+		Data: items[start:end],
+		Pagination: Pagination{
+			Page:       page,
+			PerPage:    perPage,
+			Total:      total,
+			TotalPages: totalPages,
+		},
+	})
+}
+
+// getAllProductsV2 lists products (optionally by ?category) in the v2 envelope
+func getAllProductsV2(c echo.Context) error {
+	rows := products.Filter(c.QueryParam("category"))
+	items := make([]interface{}, 0, len(rows))
+	for _, p := range rows {
+		items = append(items, p)
+	}
+	return paginatedList(c, items)
+}
+
+// apiVersion tags responses with the API version that served them.
+// Deprecated aliases additionally advertise their successor path.
+func apiVersion(version string, deprecated bool) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Response().Header().Set("X-API-Version", version)
+			if deprecated {
+				c.Response().Header().Set("Deprecation", "true")
+				c.Response().Header().Set("Link", `</api/v1>; rel="successor-version"`)
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+// TestUserShapeByVersion asserts v2 adds timestamps that v1 does not expose
+func TestUserShapeByVersion(t *testing.T) {
+	withFreshUsers(t)
+	e := newServer()
+
+	v1 := decodeBody(t, doRequest(e, http.MethodGet, "/api/v1/users/1", ""))
+	if _, ok := v1["created_at"]; ok {
+		t.Errorf("v1 user should not expose created_at: %v", v1)
+	}
+	if v1["email"] != "john@example.com" {
+		t.Errorf("v1 email = %v", v1["email"])
+	}
+
+	v2 := decodeBody(t, doRequest(e, http.MethodGet, "/api/v2/users/1", ""))
+	for _, key := range []string{"id", "name", "email", "created_at", "updated_at"} {
+		if _, ok := v2[key]; !ok {
+			t.Errorf("v2 user missing %q: %v", key, v2)
+		}
+	}
+}
+
+// TestListShapeByVersion asserts the v1 list body and the v2 pagination envelope
+func TestListShapeByVersion(t *testing.T) {
+	withFreshUsers(t)
+	e := newServer()
+
+	v1 := decodeBody(t, doRequest(e, http.MethodGet, "/api/v1/users", ""))
+	if v1["total"] != float64(3) || len(v1["users"].([]interface{})) != 3 {
+		t.Errorf("unexpected v1 list: %v", v1)
+	}
+
+	v2 := decodeBody(t, doRequest(e, http.MethodGet, "/api/v2/users?page=2&per_page=2", ""))
+	data := v2["data"].([]interface{})
+	if len(data) != 1 || data[0].(map[string]interface{})["id"] != float64(3) {
+		t.Errorf("unexpected v2 page data: %v", data)
+	}
+	pagination := v2["pagination"].(map[string]interface{})
+	want := map[string]float64{"page": 2, "per_page": 2, "total": 3, "total_pages": 2}
+	for key, value := range want {
+		if pagination[key] != value {
+			t.Errorf("pagination[%s] = %v, want %v", key, pagination[key], value)
+		}
+	}
+}
+
+// TestListPagePastTheEnd checks a page past the end, however large, is the last page
+func TestListPagePastTheEnd(t *testing.T) {
+	withFreshUsers(t)
+	e := newServer()
+
+	for _, page := range []string{"3", "9223372036854775807"} {
+		rec := doRequest(e, http.MethodGet, "/api/v2/users?per_page=2&page="+page, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("page=%s: status %d", page, rec.Code)
+		}
+		v2 := decodeBody(t, rec)
+		data := v2["data"].([]interface{})
+		pagination := v2["pagination"].(map[string]interface{})
+		if len(data) != 1 || pagination["page"] != float64(2) {
+			t.Errorf("page=%s: data %v, pagination %v", page, data, pagination)
+		}
+	}
+}
+
+// TestV2CreateTracksUpdates checks updated_at moves while created_at stays put
+func TestV2CreateTracksUpdates(t *testing.T) {
+	withFreshUsers(t)
+	e := newServer()
+
+	created := decodeBody(t, doRequest(e, http.MethodPost, "/api/v2/users", `{"name":"Alice","email":"alice@example.com"}`))
+	if created["created_at"] != created["updated_at"] {
+		t.Errorf("new user timestamps differ: %v", created)
+	}
+
+	updated := decodeBody(t, doRequest(e, http.MethodPut, "/api/v2/users/4", `{"name":"Alice B","email":"alice@example.com"}`))
+	if updated["created_at"] != created["created_at"] {
+		t.Errorf("created_at changed on update: %v -> %v", created["created_at"], updated["created_at"])
+	}
+	if updated["name"] != "Alice B" {
+		t.Errorf("name = %v", updated["name"])
+	}
+
+	// The same record is visible through v1 without timestamps
+	v1 := decodeBody(t, doRequest(e, http.MethodGet, "/api/v1/users/4", ""))
+	if v1["name"] != "Alice B" || v1["updated_at"] != nil {
+		t.Errorf("unexpected v1 view: %v", v1)
+	}
+}
+
+// TestUnversionedAlias checks /api serves v1 bodies and flags itself as deprecated
+func TestUnversionedAlias(t *testing.T) {
+	withFreshUsers(t)
+	e := newServer()
+
+	for _, path := range []string{"/users", "/users/2", "/products", "/search/users?q=jane"} {
+		alias := doRequest(e, http.MethodGet, "/api"+path, "")
+		v1 := doRequest(e, http.MethodGet, "/api/v1"+path, "")
+
+		if alias.Code != http.StatusOK || alias.Body.String() != v1.Body.String() {
+			t.Errorf("%s: alias (%d) and v1 (%d) responses differ", path, alias.Code, v1.Code)
+		}
+		if alias.Header().Get("Deprecation") != "true" {
+			t.Errorf("%s: alias missing Deprecation header", path)
+		}
+		if v1.Header().Get("Deprecation") != "" {
+			t.Errorf("%s: v1 should not be deprecated", path)
+		}
+		if v1.Header().Get("X-API-Version") != "v1" {
+			t.Errorf("%s: X-API-Version = %q", path, v1.Header().Get("X-API-Version"))
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// severity is how much an audit finding matters
+type severity int
+
+const (
+	severityWarning severity = iota
+	severityError
+)
+
+func (s severity) String() string {
+	if s == severityError {
+		return "error"
+	}
+	return "warning"
+}
+
+// Finding is one problem Audit found with the configuration
+type Finding struct {
+	Severity severity
+	// Setting is the variable the finding is about
+	Setting string
+	Message string
+}
+
+// minJWTSecretLength is the shortest JWT secret Audit accepts, 32 bytes
+// being the size of an HS256 key
+const minJWTSecretLength = 32
+
+// knownJWTSecrets are defaults from examples and tutorials that are
+// public and so no secret at all
+var knownJWTSecrets = []string{
+	"your-jwt-secret-key-here",
+	"secret",
+	"jwt-secret",
+	"jwtsecret",
+	"my-secret",
+	"mysecret",
+	"supersecret",
+	"your-256-bit-secret",
+	"changeme",
+}
+
+// apiKeyFeatures are the FEATURE_FLAGS that call an API needing API_KEY
+var apiKeyFeatures = []string{"beta_api", "webhooks"}
+
+// Audit checks config for weak or unsafe values, returning the findings
+// with errors first
+func Audit(config Config) []Finding {
+	var findings []Finding
+	add := func(sev severity, setting, format string, args ...any) {
+		findings = append(findings, Finding{sev, setting, fmt.Sprintf(format, args...)})
+	}
+
+	switch secret := config.JWTSecret; {
+	case secret == "":
+		add(severityError, "JWT_SECRET", "is not set")
+	case slices.Contains(knownJWTSecrets, strings.ToLower(secret)) || isPlaceholder(secret):
+		add(severityError, "JWT_SECRET", "is a known default or placeholder value")
+	case len(secret) < minJWTSecretLength:
+		add(severityWarning, "JWT_SECRET", "is %d characters; use at least %d", len(secret), minJWTSecretLength)
+	}
+
+	if config.DebugMode && config.AppEnv == "production" {
+		add(severityError, "DEBUG_MODE", "is on while APP_ENV=production")
+	}
+
+	if config.Database.SSLMode == "disable" && !isLocalHost(config.Database.Host) {
+		add(severityWarning, "DB_SSL_MODE", "is disable for %s, so credentials and data cross the network unencrypted", config.Database.Host)
+	}
+
+	if config.SMTPHost != "" && config.EmailFrom == "" {
+		add(severityWarning, "EMAIL_FROM", "is not set, but SMTP_HOST is")
+	}
+
+	if config.APIKey == "" {
+		for _, feature := range apiKeyFeatures {
+			if featureEnabled(config.FeatureFlags[feature]) {
+				add(severityError, "API_KEY", "is not set, but feature %s needs it", feature)
+			}
+		}
+	}
+
+	slices.SortStableFunc(findings, func(a, b Finding) int {
+		return cmp.Compare(b.Severity, a.Severity)
+	})
+	return findings
+}
+
+// isLocalHost reports whether host is this machine
+func isLocalHost(host string) bool {
+	if host == "" || host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(strings.Trim(host, "[]"))
+	return ip != nil && ip.IsLoopback()
+}
+
+// featureEnabled reports whether a FEATURE_FLAGS value turns it on
+func featureEnabled(value string) bool {
+	switch strings.ToLower(value) {
+	case "on", "true", "1", "yes", "enabled":
+		return true
+	}
+	return false
+}
+
+// printFindings shows findings as a table
+func printFindings(out io.Writer, findings []Finding) {
+	if len(findings) == 0 {
+		fmt.Fprintln(out, "✅ No problems found")
+		return
+	}
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SEVERITY\tSETTING\tFINDING")
+	for _, f := range findings {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", f.Severity, f.Setting, f.Message)
+	}
+	tw.Flush()
+}
+
+// auditFailed is the audit command's exit status when a finding is at or
+// above -fail-on; 1 is left for not being able to run the audit
+const auditFailed = 2
+
+// newAuditCommand is the audit command: it loads the configuration as
+// print does, prints Audit's findings, and fails on those at the
+// --fail-on level
+func newAuditCommand(opts *rootOptions) *cobra.Command {
+	var failOn string
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Report weak or unsafe configuration values",
+		Long: fmt.Sprintf(`audit prints the configuration's weak or unsafe values and exits %d when
+any is at the --fail-on severity or worse. check runs the same audit along
+with validation and drift detection.`, auditFailed),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			threshold := map[string]severity{"warning": severityWarning, "error": severityError, "none": severityError + 1}
+			level, ok := threshold[failOn]
+			if !ok {
+				return fmt.Errorf("--fail-on is %q: want error, warning or none", failOn)
+			}
+			if _, err := opts.load(); err != nil {
+				return err
+			}
+			config, err := loadConfig()
+			if err != nil {
+				return fmt.Errorf("invalid configuration:\n%w", err)
+			}
+
+			findings := Audit(config)
+			printFindings(cmd.OutOrStdout(), findings)
+			if slices.ContainsFunc(findings, func(f Finding) bool { return f.Severity >= level }) {
+				return exitStatus(auditFailed)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&failOn, "fail-on", "error", "exit non-zero on findings of this severity or worse: error, warning or none")
+	return cmd
+}
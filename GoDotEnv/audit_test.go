@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// safeConfig is a Config Audit has nothing to say about
+func safeConfig() Config {
+	return Config{
+		Database:  DatabaseConfig{Host: "db.internal", SSLMode: "require"},
+		JWTSecret: "k8Jq2vN5xR7tL0pW3zY6cB9mF4hD1sG8",
+		AppEnv:    "production",
+		SMTPHost:  "smtp.example.com",
+		EmailFrom: "noreply@example.com",
+		APIKey:    "api-key",
+		FeatureFlags: map[string]string{
+			"beta_api": "on",
+		},
+	}
+}
+
+func TestAuditRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(*Config)
+		setting string // "" when no rule should fire
+		sev     severity
+	}{
+		{"safe", func(c *Config) {}, "", 0},
+
+		{"jwt secret unset", func(c *Config) { c.JWTSecret = "" }, "JWT_SECRET", severityError},
+		{"jwt secret known default", func(c *Config) { c.JWTSecret = "your-jwt-secret-key-here" }, "JWT_SECRET", severityError},
+		{"jwt secret known default any case", func(c *Config) { c.JWTSecret = "SuperSecret" }, "JWT_SECRET", severityError},
+		{"jwt secret placeholder", func(c *Config) { c.JWTSecret = "changeme-before-deploying-this-app-ok" }, "JWT_SECRET", severityError},
+		{"jwt secret short", func(c *Config) { c.JWTSecret = "k8Jq2vN5xR7tL0pW3zY6cB9mF4hD1sG" }, "JWT_SECRET", severityWarning},
+		{"jwt secret exactly long enough", func(c *Config) { c.JWTSecret = strings.Repeat("a1", 16) }, "", 0},
+
+		{"debug in production", func(c *Config) { c.DebugMode = true }, "DEBUG_MODE", severityError},
+		{"debug in development", func(c *Config) { c.DebugMode = true; c.AppEnv = "development" }, "", 0},
+		{"no debug in production", func(c *Config) { c.DebugMode = false }, "", 0},
+
+		{"ssl disabled remotely", func(c *Config) { c.Database.SSLMode = "disable" }, "DB_SSL_MODE", severityWarning},
+		{"ssl disabled on localhost", func(c *Config) { c.Database.SSLMode = "disable"; c.Database.Host = "localhost" }, "", 0},
+		{"ssl disabled on loopback", func(c *Config) { c.Database.SSLMode = "disable"; c.Database.Host = "127.0.0.1" }, "", 0},
+		{"ssl disabled on ipv6 loopback", func(c *Config) { c.Database.SSLMode = "disable"; c.Database.Host = "::1" }, "", 0},
+		{"ssl preferred remotely", func(c *Config) { c.Database.SSLMode = "prefer" }, "", 0},
+
+		{"smtp without from", func(c *Config) { c.EmailFrom = "" }, "EMAIL_FROM", severityWarning},
+		{"no smtp and no from", func(c *Config) { c.EmailFrom = ""; c.SMTPHost = "" }, "", 0},
+
+		{"api key missing for a feature", func(c *Config) { c.APIKey = "" }, "API_KEY", severityError},
+		{"api key missing with the feature off", func(c *Config) { c.APIKey = ""; c.FeatureFlags["beta_api"] = "off" }, "", 0},
+		{"api key missing with no features", func(c *Config) { c.APIKey = ""; c.FeatureFlags = nil }, "", 0},
+		{"api key missing for another feature", func(c *Config) {
+			c.APIKey = ""
+			c.FeatureFlags = map[string]string{"webhooks": "true", "new_ui": "on"}
+		}, "API_KEY", severityError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := safeConfig()
+			tt.change(&config)
+			findings := Audit(config)
+			if tt.setting == "" {
+				if len(findings) != 0 {
+					t.Errorf("findings = %+v, want none", findings)
+				}
+				return
+			}
+			if len(findings) != 1 || findings[0].Setting != tt.setting || findings[0].Severity != tt.sev {
+				t.Errorf("findings = %+v, want one %s about %s", findings, tt.sev, tt.setting)
+			}
+		})
+	}
+}
+
+func TestAuditOrdersErrorsFirst(t *testing.T) {
+	config := safeConfig()
+	config.EmailFrom = ""
+	config.Database.SSLMode = "disable"
+	config.DebugMode = true
+	findings := Audit(config)
+	var got []string
+	for _, f := range findings {
+		got = append(got, f.Severity.String()+" "+f.Setting)
+	}
+	if want := "error DEBUG_MODE|warning DB_SSL_MODE|warning EMAIL_FROM"; strings.Join(got, "|") != want {
+		t.Errorf("findings = %q, want %s", got, want)
+	}
+
+	var out bytes.Buffer
+	printFindings(&out, findings)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "SEVERITY  SETTING") || !strings.HasPrefix(lines[1], "error     DEBUG_MODE ") {
+		t.Errorf("table:\n%s", out.String())
+	}
+}
+
+func TestAuditCommandFailOn(t *testing.T) {
+	unsetEnv(t, "APP_ENV", "JWT_SECRET", "DB_HOST", "DB_SSL_MODE", "SMTP_HOST", "EMAIL_FROM", "API_KEY", "FEATURE_FLAGS", "DEBUG_MODE")
+	// A short secret is a warning and nothing else
+	writeEnvFiles(t, map[string]string{".env": "JWT_SECRET=short-but-not-a-default\n"})
+
+	tests := []struct {
+		failOn string
+		fails  bool
+	}{
+		{"warning", true},
+		{"error", false},
+		{"none", false},
+	}
+	for _, tt := range tests {
+		out, err := execute(t, "audit", "--fail-on", tt.failOn)
+		var status exitStatus
+		if failed := errors.As(err, &status) && int(status) == auditFailed; failed != tt.fails || (!tt.fails && err != nil) {
+			t.Errorf("-fail-on %s: %v, want failed %t", tt.failOn, err, tt.fails)
+		}
+		if !strings.Contains(out, "warning   JWT_SECRET") {
+			t.Errorf("-fail-on %s printed:\n%s", tt.failOn, out)
+		}
+	}
+
+	// An error fails the default level
+	t.Setenv("JWT_SECRET", "")
+	var status exitStatus
+	if _, err := execute(t, "audit"); !errors.As(err, &status) || int(status) != auditFailed {
+		t.Errorf("unset secret: %v", err)
+	}
+
+	if _, err := execute(t, "audit", "--fail-on", "info"); err == nil || errors.As(err, &status) {
+		t.Errorf("-fail-on info: %v", err)
+	}
+}
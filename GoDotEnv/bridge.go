@@ -0,0 +1,212 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// PathMapping maps env key prefixes to dotted Viper paths: with "DB_"
+// mapped to "database", DB_HOST is database.host
+type PathMapping map[string]string
+
+// defaultPathMapping follows the Config's groups
+var defaultPathMapping = PathMapping{
+	"DB_":     "database",
+	"SERVER_": "server",
+	"REDIS_":  "redis",
+	"SMTP_":   "smtp",
+	"APP_":    "app",
+}
+
+// extraKey is the group NestedMap puts the keys no prefix matches in
+const extraKey = "extra"
+
+// CollisionError is variables that NestedMap would put at the same path,
+// or a variable whose path is also a group other variables are in
+type CollisionError struct {
+	Path string
+	// Keys are the variables, sorted; NestedMap kept the first
+	Keys []string
+}
+
+func (e *CollisionError) Error() string {
+	return fmt.Sprintf("%s collide at %s; keeping %s", strings.Join(e.Keys, ", "), e.Path, e.Keys[0])
+}
+
+// NestedMap nests vars into the map viper.MergeConfigMap and
+// mapstructure.Decode take. Each key goes under the path of the longest
+// prefix in mapping it starts with, with the rest of it lowercased, so
+// REDIS_POOL_SIZE is redis.pool_size; a key no prefix matches goes under
+// extra. Values stay strings, which Viper and a weakly typed mapstructure
+// decoder convert. Every collision is reported, each as a
+// *CollisionError, along with the map.
+func NestedMap(vars map[string]string, mapping PathMapping) (map[string]any, error) {
+	root := make(map[string]any)
+	// owners are the variables that set each leaf, or first made each
+	// group, by path
+	owners := make(map[string]string)
+	collisions := make(map[string]*CollisionError)
+	collide := func(path, owner, key string) {
+		if c, ok := collisions[path]; ok {
+			c.Keys = append(c.Keys, key)
+			return
+		}
+		collisions[path] = &CollisionError{Path: path, Keys: []string{owner, key}}
+	}
+
+	// Sorted, so the same variable wins every time
+	for _, key := range slices.Sorted(maps.Keys(vars)) {
+		segments := mapping.path(key)
+		m := root
+		for i, segment := range segments[:len(segments)-1] {
+			path := strings.Join(segments[:i+1], ".")
+			existing, ok := m[segment]
+			if !ok {
+				child := make(map[string]any)
+				m[segment] = child
+				owners[path] = key
+				m = child
+				continue
+			}
+			child, isGroup := existing.(map[string]any)
+			if !isGroup {
+				collide(path, owners[path], key)
+				m = nil
+				break
+			}
+			m = child
+		}
+		if m == nil {
+			continue
+		}
+
+		path := strings.Join(segments, ".")
+		leaf := segments[len(segments)-1]
+		if _, ok := m[leaf]; ok {
+			collide(path, owners[path], key)
+			continue
+		}
+		m[leaf] = vars[key]
+		owners[path] = key
+	}
+
+	var errs []error
+	for _, path := range slices.Sorted(maps.Keys(collisions)) {
+		errs = append(errs, collisions[path])
+	}
+	return root, errors.Join(errs...)
+}
+
+// path is key's path segments under m
+func (m PathMapping) path(key string) []string {
+	var prefix string
+	for p := range m {
+		if strings.HasPrefix(key, p) && len(key) > len(p) && len(p) > len(prefix) {
+			prefix = p
+		}
+	}
+	if prefix == "" {
+		return []string{extraKey, strings.ToLower(key)}
+	}
+	var segments []string
+	for segment := range strings.SplitSeq(strings.ToLower(m[prefix]), ".") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return append(segments, strings.ToLower(key[len(prefix):]))
+}
+
+// newNestedCommand is the nested command, which prints the loaded
+// variables as the nested map NestedMap makes of them
+func newNestedCommand(opts *rootOptions) *cobra.Command {
+	var format string
+	var extra map[string]string
+	var showSecrets bool
+	cmd := &cobra.Command{
+		Use:   "nested",
+		Short: "Print the loaded variables nested by dotted keys, as Viper takes them",
+		Long: `nested loads the env files and prints the variables they set, and those
+the Config reads, nested by prefix the way viper.MergeConfigMap takes them:
+DB_HOST is database.host and REDIS_POOL_SIZE is redis.pool_size. Variables
+no prefix matches go under extra.
+
+The prefixes are DB_, SERVER_, REDIS_, SMTP_ and APP_; --map adds more or
+replaces them, and the longest prefix a variable starts with wins.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			report, err := opts.load()
+			if err != nil {
+				return err
+			}
+			mapping := maps.Clone(defaultPathMapping)
+			maps.Copy(mapping, extra)
+			return runNested(cmd.OutOrStdout(), cmd.ErrOrStderr(), loadedVariables(report, showSecrets), mapping, format)
+		},
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&format, "format", "json", "json or yaml")
+	flags.StringToStringVar(&extra, "map", nil, "map a prefix to a dotted path, like DB_REPLICA_=database.replica; can be repeated")
+	flags.BoolVar(&showSecrets, "show-secrets", false, "print secrets unmasked")
+	return cmd
+}
+
+// runNested writes vars nested by mapping to out in format, warning on
+// errOut about the variables that collide
+func runNested(out, errOut io.Writer, vars map[string]string, mapping PathMapping, format string) error {
+	nested, err := NestedMap(vars, mapping)
+	if err != nil {
+		// The map is still usable, with the first of each colliding set
+		for collision := range strings.SplitSeq(err.Error(), "\n") {
+			fmt.Fprintf(errOut, "⚠️  %s\n", collision)
+		}
+	}
+
+	var data []byte
+	switch format {
+	case "json":
+		data, err = json.MarshalIndent(nested, "", "  ")
+		data = append(data, '\n')
+	case "yaml":
+		data, err = encodeYAML(nested)
+	default:
+		return fmt.Errorf("unknown format %q: want json or yaml", format)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(data)
+	return err
+}
+
+// loadedVariables are the variables the env files set and those the
+// Config reads that are set, with the Config's secrets masked unless
+// showSecrets
+func loadedVariables(report *LoadReport, showSecrets bool) map[string]string {
+	vars := make(map[string]string)
+	for key, source := range report.Sources {
+		if source != processEnv {
+			vars[key] = os.Getenv(key)
+		}
+	}
+	for _, f := range exportFields(reflect.ValueOf(Config{}), "", nil) {
+		value, ok := os.LookupEnv(f.Env)
+		if !ok {
+			continue
+		}
+		if f.Secret && !showSecrets && value != "" {
+			value = maskSecret(value)
+		}
+		vars[f.Env] = value
+	}
+	return vars
+}
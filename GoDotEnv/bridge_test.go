@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNestedMapGroups(t *testing.T) {
+	got, err := NestedMap(map[string]string{
+		"DB_HOST":         "db",
+		"DB_SSL_MODE":     "require",
+		"DB_REPLICA_HOST": "replica",
+		"SERVER_PORT":     "8080",
+		"REDIS_POOL_SIZE": "10",
+		"SMTP_HOST":       "mail",
+	}, PathMapping{
+		"DB_":         "database",
+		"DB_REPLICA_": "database.replica",
+		"SERVER_":     "server",
+		"REDIS_":      "Redis",
+		"SMTP_":       "mail.smtp",
+	})
+	if err != nil {
+		t.Fatalf("NestedMap: %v", err)
+	}
+	want := map[string]any{
+		"database": map[string]any{
+			"host":     "db",
+			"ssl_mode": "require",
+			// The longest prefix wins
+			"replica": map[string]any{"host": "replica"},
+		},
+		"server": map[string]any{"port": "8080"},
+		// Paths are lowercased, as Viper's keys are
+		"redis": map[string]any{"pool_size": "10"},
+		"mail":  map[string]any{"smtp": map[string]any{"host": "mail"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NestedMap =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestNestedMapExtra(t *testing.T) {
+	got, err := NestedMap(map[string]string{
+		"DB_HOST": "db",
+		"FOO":     "bar",
+		"DB_":     "nothing after the prefix",
+		"DBHOST":  "no underscore",
+	}, defaultPathMapping)
+	if err != nil {
+		t.Fatalf("NestedMap: %v", err)
+	}
+	want := map[string]any{
+		"database": map[string]any{"host": "db"},
+		"extra": map[string]any{
+			"foo":    "bar",
+			"db_":    "nothing after the prefix",
+			"dbhost": "no underscore",
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NestedMap =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestNestedMapCollisions(t *testing.T) {
+	got, err := NestedMap(map[string]string{
+		// Two prefixes for the same group
+		"DB_HOST":       "db",
+		"DATABASE_HOST": "database",
+		// A leaf where another prefix makes a group, both ways round
+		"DB_REPLICA":      "yes",
+		"DB_REPLICA_HOST": "replica",
+		"CACHE_TTL":       "60s",
+		"CACHE":           "on",
+		// And one that collides with nothing
+		"DB_PORT": "5432",
+	}, PathMapping{
+		"DB_":         "database",
+		"DATABASE_":   "database",
+		"DB_REPLICA_": "database.replica",
+		"CACHE_":      "extra.cache",
+	})
+
+	if err == nil {
+		t.Fatal("NestedMap reported no collisions")
+	}
+	var collisions []*CollisionError
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var c *CollisionError
+		if !errors.As(e, &c) {
+			t.Fatalf("error %v is not a *CollisionError", e)
+		}
+		collisions = append(collisions, c)
+	}
+	want := []CollisionError{
+		{Path: "database.host", Keys: []string{"DATABASE_HOST", "DB_HOST"}},
+		{Path: "database.replica", Keys: []string{"DB_REPLICA", "DB_REPLICA_HOST"}},
+		{Path: "extra.cache", Keys: []string{"CACHE", "CACHE_TTL"}},
+	}
+	if len(collisions) != len(want) {
+		t.Fatalf("got %d collisions, want %d: %v", len(collisions), len(want), err)
+	}
+	for i, c := range collisions {
+		if c.Path != want[i].Path || !slices.Equal(c.Keys, want[i].Keys) {
+			t.Errorf("collision %d = %+v, want %+v", i, *c, want[i])
+		}
+	}
+	if !strings.Contains(err.Error(), "DATABASE_HOST, DB_HOST collide at database.host; keeping DATABASE_HOST") {
+		t.Errorf("error = %v", err)
+	}
+
+	// The first of each set, by name, is kept and the rest still mapped
+	database := got["database"].(map[string]any)
+	if database["host"] != "database" || database["replica"] != "yes" || database["port"] != "5432" {
+		t.Errorf("database = %v", database)
+	}
+	if extra := got["extra"].(map[string]any); extra["cache"] != "on" {
+		t.Errorf("extra = %v", extra)
+	}
+}
+
+func TestNestedCommand(t *testing.T) {
+	unsetEnv(t, configKeys...)
+	unsetEnv(t, "SERVER_PORT", "REDIS_POOL_SIZE", "BRIDGE_EXTRA", "DB_REPLICA_HOST")
+	writeEnvFiles(t, map[string]string{".env": strings.Join([]string{
+		"DB_HOST=db",
+		"DB_REPLICA_HOST=replica",
+		"SERVER_PORT=9090",
+		"REDIS_POOL_SIZE=10",
+		"JWT_SECRET=jwt-secret-value-123",
+		"BRIDGE_EXTRA=${DB_HOST}-extra",
+	}, "\n")})
+
+	out, err := execute(t, "nested", "--map", "DB_REPLICA_=database.replica")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Database struct {
+			Host    string
+			Replica struct{ Host string }
+		}
+		Server struct{ Port string }
+		Redis  struct {
+			PoolSize string `json:"pool_size"`
+		}
+		Extra map[string]string
+	}
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out)
+	}
+	if got.Database.Host != "db" || got.Database.Replica.Host != "replica" || got.Server.Port != "9090" || got.Redis.PoolSize != "10" {
+		t.Errorf("nested:\n%s", out)
+	}
+	// Expanded, and the secret masked
+	if got.Extra["bridge_extra"] != "db-extra" || got.Extra["jwt_secret"] != maskSecret("jwt-secret-value-123") {
+		t.Errorf("extra = %v", got.Extra)
+	}
+	// Variables from the process environment that the Config doesn't read
+	// are left out
+	if _, ok := got.Extra["path"]; ok {
+		t.Errorf("PATH was included:\n%s", out)
+	}
+
+	out, err = execute(t, "nested", "--format", "yaml", "--show-secrets")
+	if err != nil || !strings.Contains(out, "database:\n  host: db\n") || !strings.Contains(out, "jwt_secret: jwt-secret-value-123\n") {
+		t.Errorf("nested --format yaml: %v\n%s", err, out)
+	}
+}
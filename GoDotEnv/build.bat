@@ -4,19 +4,19 @@ REM Build script for GoDotEnv demo (Windows)
 echo Building GoDotEnv Demo...
 
 REM Build for Windows
-go build -o godotenv-demo.exe main.go
+go build -o godotenv-demo.exe .
 
 REM Build for Linux
 echo Building for Linux...
 set GOOS=linux
 set GOARCH=amd64
-go build -o godotenv-demo-linux main.go
+go build -o godotenv-demo-linux .
 
 REM Build for macOS  
 echo Building for macOS...
 set GOOS=darwin
 set GOARCH=amd64
-go build -o godotenv-demo-macos main.go
+go build -o godotenv-demo-macos .
 
 REM Reset environment
 set GOOS=
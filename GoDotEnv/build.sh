@@ -4,21 +4,21 @@
 echo "Building GoDotEnv Demo..."
 
 # Build for current platform
-go build -o godotenv-demo main.go
+go build -o godotenv-demo .
 
 # Build for Windows (if not on Windows)
 if [[ "$OSTYPE" != "msys" && "$OSTYPE" != "win32" ]]; then
     echo "Building for Windows..."
-    GOOS=windows GOARCH=amd64 go build -o godotenv-demo.exe main.go
+    GOOS=windows GOARCH=amd64 go build -o godotenv-demo.exe .
 fi
 
 # Build for Linux
 echo "Building for Linux..."
-GOOS=linux GOARCH=amd64 go build -o godotenv-demo-linux main.go
+GOOS=linux GOARCH=amd64 go build -o godotenv-demo-linux .
 
 # Build for macOS
 echo "Building for macOS..."
-GOOS=darwin GOARCH=amd64 go build -o godotenv-demo-macos main.go
+GOOS=darwin GOARCH=amd64 go build -o godotenv-demo-macos .
 
 echo "Build complete!"
 echo "Run with: ./godotenv-demo"
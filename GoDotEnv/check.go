@@ -0,0 +1,203 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// The check command's exit statuses; 1 is left for not being able to run
+// the check at all, as with the other commands
+const (
+	checkClean = 0
+	// checkWarnings is for undocumented keys, placeholders and audit
+	// warnings only
+	checkWarnings = 2
+	// checkErrors is for missing keys, an invalid configuration or audit
+	// errors
+	checkErrors = 3
+)
+
+// exitStatus is an error that only sets the exit status, for a command
+// that has already reported what it found
+type exitStatus int
+
+func (s exitStatus) Error() string { return fmt.Sprintf("exit status %d", int(s)) }
+
+// driftReport is how an env file differs from its example
+type driftReport struct {
+	// Missing keys are in the example but not the env file
+	Missing []string
+	// Undocumented keys are in the env file but not the example
+	Undocumented []string
+	// Placeholders are keys whose value is still the example's
+	// placeholder, like "changeme" or "your-api-key-here"
+	Placeholders []string
+}
+
+// Status is the exit status for the report: missing keys are worse than
+// anything else, which is only a warning
+func (r driftReport) Status() int {
+	switch {
+	case len(r.Missing) > 0:
+		return checkErrors
+	case len(r.Undocumented) > 0 || len(r.Placeholders) > 0:
+		return checkWarnings
+	}
+	return checkClean
+}
+
+// compareEnv compares the parsed env file with its parsed example, so
+// comments and order don't matter; each list is sorted
+func compareEnv(env, example map[string]string) driftReport {
+	var r driftReport
+	for key, exampleValue := range example {
+		value, ok := env[key]
+		switch {
+		case !ok:
+			r.Missing = append(r.Missing, key)
+		case value == exampleValue && isPlaceholder(exampleValue):
+			r.Placeholders = append(r.Placeholders, key)
+		}
+	}
+	for key := range env {
+		if _, ok := example[key]; !ok {
+			r.Undocumented = append(r.Undocumented, key)
+		}
+	}
+	slices.Sort(r.Missing)
+	slices.Sort(r.Undocumented)
+	slices.Sort(r.Placeholders)
+	return r
+}
+
+// placeholderWords mark an example value as something to replace rather
+// than a usable default such as "localhost" or "5432"
+var placeholderWords = []string{"changeme", "change-me", "change_me", "replace", "todo", "fixme", "xxx", "example-secret"}
+
+// isPlaceholder reports whether an example value is only a stand-in
+func isPlaceholder(value string) bool {
+	v := strings.ToLower(strings.TrimSpace(value))
+	if strings.HasPrefix(v, "your") || (strings.HasPrefix(v, "<") && strings.HasSuffix(v, ">")) {
+		return true
+	}
+	return slices.ContainsFunc(placeholderWords, func(word string) bool {
+		return strings.Contains(v, word)
+	})
+}
+
+// newCheckCommand is the check command, which validates the
+// configuration, compares the env files with their example and audits
+// the configuration, all in one report
+func newCheckCommand(opts *rootOptions) *cobra.Command {
+	var examplePath string
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Validate the configuration, compare it with .env.example, and audit it",
+		Long: fmt.Sprintf(`check runs three checks and reports on all of them:
+
+  drift       the env files set every key in the example, and no others
+  validation  the Config loads, with every value in its validate format
+  audit       no value is weak or unsafe, as the audit command reports
+
+It exits %d when everything passes, %d for warnings only, and %d for
+missing keys, an invalid configuration or audit errors.`, checkClean, checkWarnings, checkErrors),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			status, err := runCheck(cmd.OutOrStdout(), opts, examplePath)
+			if err != nil {
+				return err
+			}
+			if status != checkClean {
+				return exitStatus(status)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&examplePath, "example", ".env.example", "the example the env files should match")
+	return cmd
+}
+
+// runCheck prints the check command's report and returns its exit status
+func runCheck(out io.Writer, opts *rootOptions, examplePath string) (int, error) {
+	example, err := readEnvFile(examplePath)
+	if err != nil {
+		return 0, fmt.Errorf("reading %s: %w", examplePath, err)
+	}
+
+	// The keys the files set between them, as they are merged on loading
+	env := make(map[string]string)
+	var found []string
+	for _, path := range opts.files() {
+		values, err := readEnvFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("reading %s: %w", path, err)
+		}
+		found = append(found, path)
+		maps.Copy(env, values)
+	}
+	name := strings.Join(found, " + ")
+	fmt.Fprintf(out, "🔎 Checking %s against %s\n", cmp.Or(name, "the env files"), examplePath)
+	// Without an env file everything is missing
+	if len(found) == 0 {
+		name = strings.Join(opts.files(), ", ")
+		fmt.Fprintf(out, "⚠️  None of %s exist\n", name)
+	}
+
+	report := compareEnv(env, example)
+	printKeys(out, "❌ Missing from "+name, report.Missing)
+	printKeys(out, "⚠️  Not in "+examplePath, report.Undocumented)
+	printKeys(out, "⚠️  Still the example's placeholder", report.Placeholders)
+	if report.Status() == checkClean {
+		fmt.Fprintf(out, "✅ %s matches %s\n", name, examplePath)
+	}
+	status := report.Status()
+
+	fmt.Fprintln(out, "\n📋 Validation:")
+	_, err = opts.load()
+	var config Config
+	if err == nil {
+		config, err = loadConfig()
+	}
+	if err != nil {
+		fmt.Fprintln(out, "❌ Invalid configuration:")
+		for line := range strings.SplitSeq(err.Error(), "\n") {
+			fmt.Fprintf(out, "   %s\n", line)
+		}
+		fmt.Fprintln(out, "\n🛡️  Audit: skipped until the configuration is valid")
+		return checkErrors, nil
+	}
+	fmt.Fprintln(out, "✅ The configuration is valid")
+
+	fmt.Fprintln(out, "\n🛡️  Audit:")
+	findings := Audit(config)
+	printFindings(out, findings)
+	for _, f := range findings {
+		if f.Severity == severityError {
+			return checkErrors, nil
+		}
+		status = max(status, checkWarnings)
+	}
+	return status, nil
+}
+
+// printKeys lists keys under a heading, if there are any
+func printKeys(out io.Writer, heading string, keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+	fmt.Fprintf(out, "%s (%d):\n", heading, len(keys))
+	for _, key := range keys {
+		fmt.Fprintf(out, "   %s\n", key)
+	}
+}
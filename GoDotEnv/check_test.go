@@ -0,0 +1,157 @@
+package main
+
+import (
+	"errors"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCheckFixtures(t *testing.T) {
+	tests := []struct {
+		dir    string
+		want   driftReport
+		status int
+	}{
+		{"clean", driftReport{}, checkClean},
+		{"missing", driftReport{Missing: []string{"DB_PASSWORD", "JWT_SECRET"}}, checkErrors},
+		{"undocumented", driftReport{Undocumented: []string{"FEATURE_X"}}, checkWarnings},
+		{"placeholder", driftReport{Placeholders: []string{"API_KEY", "JWT_SECRET"}}, checkWarnings},
+		{"mixed", driftReport{Missing: []string{"DB_HOST", "DB_PASSWORD"}, Undocumented: []string{"EXTRA"}, Placeholders: []string{"API_KEY"}}, checkErrors},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			dir := filepath.Join("testdata", "check", tt.dir)
+			env, err := readEnvFile(filepath.Join(dir, "app.env"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			example, err := readEnvFile(filepath.Join(dir, "app.env.example"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := compareEnv(env, example)
+			if !slices.Equal(got.Missing, tt.want.Missing) || !slices.Equal(got.Undocumented, tt.want.Undocumented) || !slices.Equal(got.Placeholders, tt.want.Placeholders) {
+				t.Errorf("compareEnv = %+v, want %+v", got, tt.want)
+			}
+			if got.Status() != tt.status {
+				t.Errorf("Status = %d, want %d", got.Status(), tt.status)
+			}
+
+			// The command exits with the same status, given a configuration
+			// that is otherwise valid and safe
+			unsetEnv(t, slices.Collect(maps.Keys(env))...)
+			unsetEnv(t, "APP_ENV", "DB_HOST", "API_KEY", "FEATURE_FLAGS", "SMTP_HOST")
+			t.Setenv("JWT_SECRET", strongSecret)
+			out, err := execute(t, "check", "--env-file", filepath.Join(dir, "app.env"), "--example", filepath.Join(dir, "app.env.example"))
+			var status exitStatus
+			switch {
+			case tt.status == checkClean && err != nil:
+				t.Errorf("check = %v, want success\n%s", err, out)
+			case tt.status != checkClean && (!errors.As(err, &status) || int(status) != tt.status):
+				t.Errorf("check = %v, want exit status %d\n%s", err, tt.status, out)
+			}
+			for _, key := range slices.Concat(tt.want.Missing, tt.want.Undocumented, tt.want.Placeholders) {
+				if !strings.Contains(out, "   "+key+"\n") {
+					t.Errorf("output does not list %s:\n%s", key, out)
+				}
+			}
+		})
+	}
+}
+
+func TestIsPlaceholder(t *testing.T) {
+	for value, want := range map[string]bool{
+		"changeme":                 true,
+		"CHANGE_ME":                true,
+		"your-jwt-secret-key-here": true,
+		"your_username":            true,
+		"<generate one>":           true,
+		"replace-with-real-key":    true,
+		"xxxx":                     true,
+		"localhost":                false,
+		"5432":                     false,
+		"info":                     false,
+		"":                         false,
+		"disable":                  false,
+	} {
+		if got := isPlaceholder(value); got != want {
+			t.Errorf("isPlaceholder(%q) = %t, want %t", value, got, want)
+		}
+	}
+}
+
+// strongSecret is a JWT secret the audit accepts
+const strongSecret = "kR8vN2qLx5TzW9mJc4HbY7pF3sD6gA1e"
+
+func TestCheckCommandFiles(t *testing.T) {
+	dir := filepath.Join("testdata", "check", "clean")
+	unsetEnv(t, "APP_ENV", "DB_HOST", "DB_PASSWORD")
+	t.Setenv("JWT_SECRET", strongSecret)
+
+	// No env file at all: every key is missing
+	out, err := execute(t, "check", "--env-file", filepath.Join(dir, "absent.env"), "--example", filepath.Join(dir, "app.env.example"))
+	var status exitStatus
+	if !errors.As(err, &status) || int(status) != checkErrors || !strings.Contains(out, "None of") {
+		t.Errorf("missing env file: %v\n%s", err, out)
+	}
+
+	// No example is an error, not a result
+	_, err = execute(t, "check", "--env-file", filepath.Join(dir, "app.env"), "--example", filepath.Join(dir, "absent.example"))
+	if err == nil || errors.As(err, &status) {
+		t.Errorf("missing example: %v", err)
+	}
+}
+
+func TestCheckCommandLayeredFiles(t *testing.T) {
+	unsetEnv(t, "APP_ENV", "DB_HOST", "DB_PASSWORD")
+	t.Setenv("JWT_SECRET", strongSecret)
+	writeEnvFiles(t, map[string]string{
+		".env.example": "DB_HOST=localhost\nDB_PASSWORD=changeme\n",
+		".env":         "DB_HOST=localhost\n",
+		".env.local":   "DB_PASSWORD=local-password\n",
+	})
+	// The keys can come from any of the files
+	out, err := execute(t, "check")
+	if err != nil || !strings.Contains(out, "✅ .env + .env.local matches .env.example") {
+		t.Errorf("check: %v\n%s", err, out)
+	}
+}
+
+func TestCheckCommandValidationAndAudit(t *testing.T) {
+	tests := []struct {
+		name   string
+		env    string
+		status int
+		want   string
+	}{
+		{"clean", "DB_HOST=localhost\nJWT_SECRET=" + strongSecret + "\n", checkClean, "✅ The configuration is valid"},
+		{"invalid", "DB_HOST=localhost\nJWT_SECRET=" + strongSecret + "\nDB_PORT=65536\n", checkErrors, `field Database.Port (env DB_PORT): bad value "65536"`},
+		{"cycle", "DB_HOST=${JWT_SECRET}\nJWT_SECRET=${DB_HOST}\n", checkErrors, "❌ Invalid configuration:\n   loading env files: circular reference"},
+		{"audit error", "DB_HOST=localhost\nJWT_SECRET=changeme\n", checkErrors, "error     JWT_SECRET"},
+		{"audit warning", "DB_HOST=localhost\nJWT_SECRET=short-but-not-a-default\n", checkWarnings, "warning   JWT_SECRET"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unsetEnv(t, "APP_ENV", "DB_HOST", "DB_PORT", "JWT_SECRET", "DB_SSL_MODE", "DEBUG_MODE", "API_KEY", "FEATURE_FLAGS", "SMTP_HOST")
+			writeEnvFiles(t, map[string]string{
+				"app.env":         tt.env,
+				"app.env.example": "DB_HOST=localhost\nJWT_SECRET=<generate one>\n",
+			})
+			out, err := execute(t, "check", "--env-file", "app.env", "--example", "app.env.example")
+			var status exitStatus
+			switch {
+			case tt.status == checkClean && err != nil:
+				t.Errorf("check = %v, want success\n%s", err, out)
+			case tt.status != checkClean && (!errors.As(err, &status) || int(status) != tt.status):
+				t.Errorf("check = %v, want exit status %d\n%s", err, tt.status, out)
+			}
+			if !strings.Contains(out, tt.want) {
+				t.Errorf("output is missing %q:\n%s", tt.want, out)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config struct to hold our configuration. NewConfig fills it from the
+// env tags, using the defaults when a variable is unset and checking the
+// validate formats; errors and the export command mask the fields tagged
+// secret.
+type Config struct {
+	Database DatabaseConfig `envPrefix:"DB_"`
+	// DatabaseURL is usually built from the DB_ variables with ${VAR}
+	// references
+	DatabaseURL string `env:"DATABASE_URL" validate:"url" secret:"true"`
+
+	// Server
+	ServerPort     int           `env:"SERVER_PORT" default:"8080" validate:"port"`
+	ServerHost     string        `env:"SERVER_HOST" default:"localhost" validate:"hostname"`
+	DebugMode      bool          `env:"DEBUG_MODE" default:"false"`
+	ReadTimeout    time.Duration `env:"SERVER_READ_TIMEOUT" default:"15s"`
+	WriteTimeout   time.Duration `env:"SERVER_WRITE_TIMEOUT" default:"15s"`
+	PublicURL      *url.URL      `env:"PUBLIC_URL" default:"http://localhost:8080" validate:"url"`
+	AllowedOrigins []string      `env:"CORS_ALLOWED_ORIGINS"`
+
+	// Security; each can also come from a file named by its _FILE variable
+	JWTSecret string `env:"JWT_SECRET" file:"true" secret:"true"`
+	APIKey    string `env:"API_KEY" file:"true" secret:"true"`
+
+	// Email
+	SMTPHost  string `env:"SMTP_HOST" validate:"hostname"`
+	SMTPPort  int    `env:"SMTP_PORT" default:"587" validate:"port"`
+	EmailFrom string `env:"EMAIL_FROM" validate:"email"`
+
+	Redis RedisConfig `envPrefix:"REDIS_"`
+
+	// App
+	AppEnv       string            `env:"APP_ENV" default:"development"`
+	AppName      string            `env:"APP_NAME" default:"GoDotEnv Demo"`
+	AppVersion   string            `env:"APP_VERSION" default:"1.0.0"`
+	LogLevel     string            `env:"LOG_LEVEL" default:"info" validate:"oneof=debug info warn error"`
+	FeatureFlags map[string]string `env:"FEATURE_FLAGS"`
+}
+
+// DatabaseConfig is read from the DB_ variables
+type DatabaseConfig struct {
+	Host     string `env:"HOST" default:"localhost" validate:"hostname"`
+	Port     int    `env:"PORT" default:"5432" validate:"port"`
+	User     string `env:"USER" default:"postgres"`
+	Password string `env:"PASSWORD" file:"true" secret:"true"`
+	Name     string `env:"NAME" default:"testdb"`
+	SSLMode  string `env:"SSL_MODE" default:"disable"`
+}
+
+// RedisConfig is read from the REDIS_ variables
+type RedisConfig struct {
+	Host     string `env:"HOST" default:"localhost" validate:"hostname"`
+	Port     int    `env:"PORT" default:"6379" validate:"port"`
+	Password string `env:"PASSWORD" file:"true" secret:"true"`
+}
+
+// configOptions are what the Options given to NewConfig set
+type configOptions struct {
+	files    []string
+	lookup   Env
+	defaults map[string]string
+	validate bool
+}
+
+// Option configures NewConfig
+type Option func(*configOptions)
+
+// WithEnvFiles reads files, least specific first as LoadEnvFiles takes
+// them, for the variables the lookup doesn't set. Missing files are
+// skipped. Unlike LoadEnvFiles, nothing is put in the process
+// environment.
+func WithEnvFiles(files ...string) Option {
+	return func(o *configOptions) { o.files = append(o.files, files...) }
+}
+
+// WithLookupFunc reads the variables through lookup instead of
+// os.LookupEnv, such as from a map in tests. The lookup wins over the env
+// files, as the process environment does, and also supplies DOTENV_KEY
+// and ${VAR} references.
+func WithLookupFunc(lookup func(string) (string, bool)) Option {
+	return func(o *configOptions) { o.lookup = lookup }
+}
+
+// WithDefaults supplies values for the variables that neither the lookup
+// nor the env files set, ahead of the default tags
+func WithDefaults(defaults map[string]string) Option {
+	return func(o *configOptions) { o.defaults = defaults }
+}
+
+// WithValidation turns the checks of the validate tags on or off; they
+// are on unless it is given false
+func WithValidation(enabled bool) Option {
+	return func(o *configOptions) { o.validate = enabled }
+}
+
+// NewConfig is the Config read from the process environment, or what the
+// options give it instead. Every field that is invalid is reported, each
+// as a *FieldError.
+func NewConfig(opts ...Option) (*Config, error) {
+	o := configOptions{lookup: os.LookupEnv, validate: true}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	env := o.lookup
+	if len(o.files) > 0 {
+		report := &LoadReport{Sources: make(map[string]string)}
+		values, err := resolveEnvFiles(report, o.lookup, o.files)
+		if err != nil {
+			return nil, fmt.Errorf("loading env files: %w", err)
+		}
+		env = env.orElse(mapEnv(values))
+	}
+	if o.defaults != nil {
+		env = env.orElse(mapEnv(o.defaults))
+	}
+
+	config := &Config{}
+	if err := (structLoader{env: env, validate: o.validate}).load(config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// PostgresURL is a postgres:// URL for the database, with the user name
+// and password escaped
+func (c Config) PostgresURL() string {
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(c.Database.User, c.Database.Password),
+		Host:     net.JoinHostPort(c.Database.Host, strconv.Itoa(c.Database.Port)),
+		Path:     "/" + c.Database.Name,
+		RawQuery: url.Values{"sslmode": {c.Database.SSLMode}}.Encode(),
+	}
+	return u.String()
+}
+
+// GormDSN is a key=value DSN for the database, as GORM's postgres driver
+// takes it
+func (c Config) GormDSN() string {
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=%s",
+		dsnValue(c.Database.Host),
+		dsnValue(c.Database.User),
+		dsnValue(c.Database.Password),
+		dsnValue(c.Database.Name),
+		c.Database.Port,
+		dsnValue(c.Database.SSLMode),
+	)
+}
+
+// dsnValue quotes a DSN value that is empty or holds a space, quote or
+// backslash, the way libpq reads it
+func dsnValue(s string) string {
+	if s != "" && !strings.ContainsAny(s, ` '\`) {
+		return s
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
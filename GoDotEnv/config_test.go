@@ -0,0 +1,183 @@
+package main
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeTempEnv writes content to name in a new temporary directory and
+// returns its path, leaving the working directory alone
+func writeTempEnv(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestNewConfigFromLookup(t *testing.T) {
+	// The process environment is ignored once a lookup is given
+	t.Setenv("SERVER_PORT", "1111")
+	t.Setenv("APP_NAME", "from the process")
+
+	config, err := NewConfig(WithLookupFunc(mapEnv(map[string]string{
+		"DB_HOST":              "db.internal",
+		"DB_PASSWORD":          "map-password",
+		"SERVER_READ_TIMEOUT":  "3s",
+		"CORS_ALLOWED_ORIGINS": "https://a.example, https://b.example",
+		"FEATURE_FLAGS":        "beta=on",
+	})))
+	if err != nil {
+		t.Fatalf("NewConfig: %v", err)
+	}
+	if config.Database.Host != "db.internal" || config.Database.Password != "map-password" || config.ReadTimeout != 3*time.Second {
+		t.Errorf("config = %+v", config)
+	}
+	if len(config.AllowedOrigins) != 2 || config.FeatureFlags["beta"] != "on" {
+		t.Errorf("origins %q, flags %v", config.AllowedOrigins, config.FeatureFlags)
+	}
+	// And the rest come from the default tags
+	if config.ServerPort != 8080 || config.AppName != "GoDotEnv Demo" || config.Database.Port != 5432 || config.LogLevel != "info" {
+		t.Errorf("defaults: port %d, name %q, db port %d, log level %q", config.ServerPort, config.AppName, config.Database.Port, config.LogLevel)
+	}
+}
+
+func TestNewConfigEnvFiles(t *testing.T) {
+	base := writeTempEnv(t, ".env", strings.Join([]string{
+		"DB_HOST=db.base",
+		"DB_USER=app",
+		"DB_PASSWORD=base-password",
+		"SERVER_PORT=9000",
+		"DATABASE_URL=postgres://${DB_USER}@${DB_HOST}/${DB_NAME:-shop}",
+	}, "\n"))
+	override := writeTempEnv(t, ".env.production", encryptFile(t, "DB_HOST=db.prod\nJWT_SECRET=x", map[string]string{"JWT_SECRET": "encrypted-jwt-secret"}))
+	unsetEnv(t, "DB_HOST", "DB_USER", "DB_PASSWORD", "SERVER_PORT", "DATABASE_URL", "JWT_SECRET")
+
+	config, err := NewConfig(
+		WithEnvFiles(base, override, filepath.Join(t.TempDir(), "missing.env")),
+		WithLookupFunc(mapEnv(map[string]string{
+			// The lookup wins over the files, as the process environment does
+			"SERVER_PORT": "7000",
+			keyVariable:   testKey,
+		})),
+	)
+	if err != nil {
+		t.Fatalf("NewConfig: %v", err)
+	}
+	checks := []struct {
+		name      string
+		got, want any
+	}{
+		{"DB_HOST", config.Database.Host, "db.prod"},
+		{"DB_PASSWORD", config.Database.Password, "base-password"},
+		{"SERVER_PORT", config.ServerPort, 7000},
+		{"JWT_SECRET", config.JWTSecret, "encrypted-jwt-secret"},
+		{"DATABASE_URL", config.DatabaseURL, "postgres://app@db.prod/shop"},
+	}
+	for _, c := range checks {
+		if c.got != c.want {
+			t.Errorf("%s = %v, want %v", c.name, c.got, c.want)
+		}
+	}
+
+	// Nothing was put in the process environment
+	for _, key := range []string{"DB_HOST", "DB_PASSWORD", "JWT_SECRET"} {
+		if value, ok := os.LookupEnv(key); ok {
+			t.Errorf("%s=%q was set in the process environment", key, value)
+		}
+	}
+}
+
+func TestNewConfigEnvFileErrors(t *testing.T) {
+	cycle := writeTempEnv(t, ".env", "A=${B}\nB=${A}\n")
+	_, err := NewConfig(WithEnvFiles(cycle), WithLookupFunc(mapEnv(nil)))
+	var cycleErr *CycleError
+	if !errors.As(err, &cycleErr) || !strings.Contains(err.Error(), "loading env files") {
+		t.Errorf("NewConfig = %v, want a CycleError", err)
+	}
+
+	encrypted := writeTempEnv(t, ".env", encryptFile(t, "API_KEY=x", map[string]string{"API_KEY": "secret"}))
+	if _, err := NewConfig(WithEnvFiles(encrypted), WithLookupFunc(mapEnv(nil))); err == nil || !strings.Contains(err.Error(), "decrypting API_KEY") {
+		t.Errorf("NewConfig without a key = %v", err)
+	}
+}
+
+func TestNewConfigDefaults(t *testing.T) {
+	file := writeTempEnv(t, ".env", "DB_NAME=from-file\n")
+	config, err := NewConfig(
+		WithEnvFiles(file),
+		WithLookupFunc(mapEnv(map[string]string{"DB_USER": "from-lookup"})),
+		WithDefaults(map[string]string{
+			"DB_USER":   "from-defaults",
+			"DB_NAME":   "from-defaults",
+			"DB_HOST":   "from-defaults",
+			"LOG_LEVEL": "debug",
+		}),
+	)
+	if err != nil {
+		t.Fatalf("NewConfig: %v", err)
+	}
+	// The lookup, then the files, then WithDefaults, then the tags
+	if config.Database.User != "from-lookup" || config.Database.Name != "from-file" || config.Database.Host != "from-defaults" || config.LogLevel != "debug" || config.Database.SSLMode != "disable" {
+		t.Errorf("database %+v, log level %q", config.Database, config.LogLevel)
+	}
+}
+
+func TestNewConfigValidation(t *testing.T) {
+	lookup := WithLookupFunc(mapEnv(map[string]string{"SERVER_PORT": "70000", "LOG_LEVEL": "loud"}))
+
+	_, err := NewConfig(lookup)
+	var fieldErr *FieldError
+	if !errors.As(err, &fieldErr) || !strings.Contains(err.Error(), "SERVER_PORT") || !strings.Contains(err.Error(), "LOG_LEVEL") {
+		t.Errorf("NewConfig = %v, want both fields reported", err)
+	}
+
+	config, err := NewConfig(lookup, WithValidation(false))
+	if err != nil || config.ServerPort != 70000 || config.LogLevel != "loud" {
+		t.Errorf("NewConfig without validation = %+v, %v", config, err)
+	}
+
+	// A value that doesn't parse at all is still an error
+	_, err = NewConfig(WithLookupFunc(mapEnv(map[string]string{"SERVER_PORT": "eighty"})), WithValidation(false))
+	if !errors.As(err, &fieldErr) || fieldErr.Env != "SERVER_PORT" {
+		t.Errorf("NewConfig = %v, want SERVER_PORT reported", err)
+	}
+}
+
+func TestConfigConnectionStrings(t *testing.T) {
+	var config Config
+	config.Database = DatabaseConfig{Host: "db", Port: 5432, User: "app", Password: "p@ss/word's", Name: "shop", SSLMode: "require"}
+
+	u, err := url.Parse(config.PostgresURL())
+	if err != nil {
+		t.Fatalf("PostgresURL %q: %v", config.PostgresURL(), err)
+	}
+	password, _ := u.User.Password()
+	if u.Scheme != "postgres" || u.User.Username() != "app" || password != "p@ss/word's" || u.Host != "db:5432" || u.Path != "/shop" || u.Query().Get("sslmode") != "require" {
+		t.Errorf("PostgresURL = %q", config.PostgresURL())
+	}
+	if want := `host=db user=app password='p@ss/word\'s' dbname=shop port=5432 sslmode=require`; config.GormDSN() != want {
+		t.Errorf("GormDSN = %q, want %q", config.GormDSN(), want)
+	}
+
+	// Neither shows the password once masked
+	for _, s := range []string{config.PostgresURL(), config.GormDSN()} {
+		if masked := maskConnectionString(s); strings.Contains(masked, "ss/word") || strings.Contains(masked, "ss%2Fword") {
+			t.Errorf("maskConnectionString(%q) = %q", s, masked)
+		}
+	}
+
+	config.Database = DatabaseConfig{Host: "::1", Port: 5432, User: "app", Name: "shop", SSLMode: "disable"}
+	if got := config.PostgresURL(); !strings.Contains(got, "@[::1]:5432/") {
+		t.Errorf("PostgresURL with an IPv6 host = %q", got)
+	}
+	if got := config.GormDSN(); !strings.Contains(got, "password='' ") {
+		t.Errorf("GormDSN with no password = %q", got)
+	}
+}
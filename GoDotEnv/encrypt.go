@@ -0,0 +1,180 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// keyVariable holds the base64 AES key that ENC() values are decrypted
+// with; DOTENV_KEY_FILE, which the --key-file flag sets, can name a file
+// holding it instead
+const keyVariable = "DOTENV_KEY"
+
+// isEncrypted reports whether value is a whole ENC(base64-ciphertext)
+func isEncrypted(value string) bool {
+	return strings.HasPrefix(value, "ENC(") && strings.HasSuffix(value, ")")
+}
+
+// loadKey reads the key from env's DOTENV_KEY, or else the file
+// DOTENV_KEY_FILE names, and checks it is a base64 AES-128, -192 or -256
+// key
+func loadKey(env Env) ([]byte, error) {
+	encoded := env.Secret(keyVariable, "")
+	if encoded == "" {
+		return nil, fmt.Errorf("%s is not set; set it or pass --key-file", keyVariable)
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not base64: %w", keyVariable, err)
+	}
+	switch len(key) {
+	case 16, 24, 32:
+		return key, nil
+	}
+	return nil, fmt.Errorf("%s is %d bytes, want 16, 24 or 32", keyVariable, len(key))
+}
+
+// newGCM is AES-GCM with key
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptValue seals value for the variable name as ENC(...), where the
+// base64 holds a random nonce followed by the ciphertext. The name is
+// authenticated too, so a value pasted under another variable won't
+// decrypt.
+func encryptValue(key []byte, name, value string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(value), []byte(name))
+	return "ENC(" + base64.StdEncoding.EncodeToString(sealed) + ")", nil
+}
+
+// decryptValue opens an ENC(...) value that encryptValue sealed for name
+func decryptValue(key []byte, name, value string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimSuffix(strings.TrimPrefix(value, "ENC("), ")"))
+	if err != nil {
+		return "", fmt.Errorf("not base64: %w", err)
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("too short")
+	}
+	plain, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], []byte(name))
+	if err != nil {
+		return "", errors.New("wrong key, or the value was changed or belongs to another variable")
+	}
+	return string(plain), nil
+}
+
+// decryptValues replaces the ENC(...) values in values with their
+// plaintext, with each $ doubled so expandValues keeps it literal, and
+// returns the decrypted keys in order. The key is only loaded when there
+// is something to decrypt, from env.
+func decryptValues(values map[string]string, env Env) ([]string, error) {
+	var names []string
+	for name, value := range values {
+		if isEncrypted(value) {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+	slices.Sort(names)
+	key, err := loadKey(env)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting %s: %w", names[0], err)
+	}
+	for _, name := range names {
+		plain, err := decryptValue(key, name, values[name])
+		if err != nil {
+			return nil, fmt.Errorf("decrypting %s: %w", name, err)
+		}
+		values[name] = strings.ReplaceAll(plain, "$", "$$")
+	}
+	return names, nil
+}
+
+// newEncryptCommand is the encrypt command: encrypt KEY=VALUE... prints
+// each as a KEY=ENC(...) line to paste into an env file, and encrypt
+// --generate-key prints a new random key for DOTENV_KEY
+func newEncryptCommand() *cobra.Command {
+	var generate bool
+	cmd := &cobra.Command{
+		Use:   "encrypt KEY=VALUE...",
+		Short: "Encrypt values as ENC(...) lines for a committed env file",
+		Long: `encrypt prints each KEY=VALUE as KEY=ENC(...), encrypted with AES-GCM
+using DOTENV_KEY or --key-file, to paste into an env file. The env files
+are decrypted with the same key when they are loaded.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runEncrypt(cmd.OutOrStdout(), args, generate)
+		},
+	}
+	cmd.Flags().BoolVar(&generate, "generate-key", false, "print a new AES-256 key instead")
+	return cmd
+}
+
+// runEncrypt prints the ENC(...) line for each KEY=VALUE in args, or a new
+// key when generate is set
+func runEncrypt(out io.Writer, args []string, generate bool) error {
+	if generate {
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return err
+		}
+		fmt.Fprintln(out, base64.StdEncoding.EncodeToString(key))
+		return nil
+	}
+	if len(args) == 0 {
+		return errors.New("nothing to encrypt; give KEY=VALUE arguments")
+	}
+
+	key, err := loadKey(osEnv)
+	if err != nil {
+		return err
+	}
+	for _, arg := range args {
+		name, value, ok := strings.Cut(arg, "=")
+		if !ok || !envKeyPattern.MatchString(name) {
+			return fmt.Errorf("%q is not KEY=VALUE", arg)
+		}
+		sealed, err := encryptValue(key, name, value)
+		if err != nil {
+			return fmt.Errorf("encrypting %s: %w", name, err)
+		}
+		fmt.Fprintf(out, "%s=%s\n", name, sealed)
+	}
+	return nil
+}
+
+// decrypted marks a masked value that was stored encrypted, for
+// displayConfig
+func (r *LoadReport) decrypted(key string) string {
+	if r != nil && slices.Contains(r.Decrypted, key) {
+		return " 🔓 decrypted"
+	}
+	return ""
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Env reads variables through a lookup function, such as os.LookupEnv
+// or, in tests, a map. Its typed methods treat an unset or empty
+// variable as missing and use the default; a value that is set but does
+// not parse also falls back to the default, with a warning rather than
+// silently.
+type Env func(key string) (string, bool)
+
+// osEnv is the process environment, which the getEnv helpers read
+var osEnv Env = os.LookupEnv
+
+// mapEnv is an Env holding just the variables in m
+func mapEnv(m map[string]string) Env {
+	return func(key string) (string, bool) {
+		value, ok := m[key]
+		return value, ok
+	}
+}
+
+// orElse is an Env with e's variables and, for those e doesn't set,
+// fallback's
+func (e Env) orElse(fallback Env) Env {
+	return func(key string) (string, bool) {
+		if value, ok := e(key); ok {
+			return value, true
+		}
+		return fallback(key)
+	}
+}
+
+// Get is key's value, or "" when it is unset, like os.Getenv
+func (e Env) Get(key string) string {
+	value, _ := e(key)
+	return value
+}
+
+// String is key's value, or defaultVal when it is unset; unlike the
+// typed methods, a variable set to "" is kept
+func (e Env) String(key, defaultVal string) string {
+	if value, exists := e(key); exists {
+		return value
+	}
+	return defaultVal
+}
+
+// envAs parses name's value from e, or returns defaultVal when it is
+// missing or invalid
+func envAs[T any](e Env, name string, defaultVal T, parse func(string) (T, error)) T {
+	value := e.Get(name)
+	if value == "" {
+		return defaultVal
+	}
+	v, err := parse(value)
+	if err != nil {
+		log.Printf("Warning: %s=%q is invalid, using the default: %v", name, value, err)
+		return defaultVal
+	}
+	return v
+}
+
+func (e Env) Int(name string, defaultVal int) int {
+	return envAs(e, name, defaultVal, strconv.Atoi)
+}
+
+func (e Env) Bool(name string, defaultVal bool) bool {
+	return envAs(e, name, defaultVal, strconv.ParseBool)
+}
+
+func (e Env) Duration(name string, defaultVal time.Duration) time.Duration {
+	return envAs(e, name, defaultVal, time.ParseDuration)
+}
+
+func (e Env) Float(name string, defaultVal float64) float64 {
+	return envAs(e, name, defaultVal, func(s string) (float64, error) {
+		return strconv.ParseFloat(s, 64)
+	})
+}
+
+// Slice splits name's value on sep, like "a,b,c"
+func (e Env) Slice(name, sep string, defaultVal []string) []string {
+	return envAs(e, name, defaultVal, func(s string) ([]string, error) {
+		return parseSlice(s, sep), nil
+	})
+}
+
+// Map reads pairs like "k1=v1,k2=v2"
+func (e Env) Map(name string, defaultVal map[string]string) map[string]string {
+	return envAs(e, name, defaultVal, parseMap)
+}
+
+// URL reads an absolute URL, with a scheme and host
+func (e Env) URL(name string, defaultVal *url.URL) *url.URL {
+	return envAs(e, name, defaultVal, parseURL)
+}
+
+// Secret reads name, or else the file name_FILE points to, the way
+// Docker and Kubernetes mount secrets. A file that can't be read is
+// warned about without showing what it holds.
+func (e Env) Secret(name, defaultVal string) string {
+	if value := e.Get(name); value != "" {
+		return value
+	}
+	path := e.Get(name + "_FILE")
+	if path == "" {
+		return defaultVal
+	}
+	secret, err := readSecretFile(path)
+	if err != nil {
+		log.Printf("Warning: %s_FILE is invalid, using the default: %v", name, err)
+		return defaultVal
+	}
+	return secret
+}
+
+// The getEnv helpers read the process environment through osEnv
+
+func getEnv(key, defaultVal string) string {
+	return osEnv.String(key, defaultVal)
+}
+
+func getEnvAsInt(name string, defaultVal int) int {
+	return osEnv.Int(name, defaultVal)
+}
+
+func getEnvAsBool(name string, defaultVal bool) bool {
+	return osEnv.Bool(name, defaultVal)
+}
+
+func getEnvAsDuration(name string, defaultVal time.Duration) time.Duration {
+	return osEnv.Duration(name, defaultVal)
+}
+
+func getEnvAsFloat(name string, defaultVal float64) float64 {
+	return osEnv.Float(name, defaultVal)
+}
+
+func getEnvAsSlice(name, sep string, defaultVal []string) []string {
+	return osEnv.Slice(name, sep, defaultVal)
+}
+
+func getEnvAsMap(name string, defaultVal map[string]string) map[string]string {
+	return osEnv.Map(name, defaultVal)
+}
+
+func getEnvAsURL(name string, defaultVal *url.URL) *url.URL {
+	return osEnv.URL(name, defaultVal)
+}
+
+func getEnvSecret(name, defaultVal string) string {
+	return osEnv.Secret(name, defaultVal)
+}
+
+// readSecretFile is the contents of the file at path without the
+// surrounding whitespace, such as the trailing newline editors add
+func readSecretFile(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	secret := strings.TrimSpace(string(b))
+	if secret == "" {
+		return "", fmt.Errorf("%s is empty", path)
+	}
+	return secret, nil
+}
+
+// parseSlice splits s on sep, trimming the items and dropping empty ones
+func parseSlice(s, sep string) []string {
+	var items []string
+	for item := range strings.SplitSeq(s, sep) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseMap reads comma-separated key=value pairs; a later key wins
+func parseMap(s string) (map[string]string, error) {
+	m := make(map[string]string)
+	for _, pair := range parseSlice(s, ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%q is not key=value", pair)
+		}
+		m[key] = strings.TrimSpace(value)
+	}
+	return m, nil
+}
+
+// parseURL parses s as an absolute URL
+func parseURL(s string) (*url.URL, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, errors.New("needs a scheme and host")
+	}
+	return u, nil
+}
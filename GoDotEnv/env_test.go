@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// captureWarnings collects what the helpers log for the rest of the test
+func captureWarnings(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	return &buf
+}
+
+// helperCase is one value for a typed helper: set is false for an unset
+// variable, and warn is whether the helper should complain about it
+type helperCase[T any] struct {
+	value string
+	set   bool
+	want  T
+	warn  bool
+}
+
+// checkHelper runs get against each case with TEST_VALUE set to its value
+func checkHelper[T any](t *testing.T, get func(name string) T, cases []helperCase[T]) {
+	t.Helper()
+	for _, tc := range cases {
+		unsetEnv(t, "TEST_VALUE")
+		if tc.set {
+			t.Setenv("TEST_VALUE", tc.value)
+		}
+		warnings := captureWarnings(t)
+		if got := get("TEST_VALUE"); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("TEST_VALUE=%q (set %t) = %v, want %v", tc.value, tc.set, got, tc.want)
+		}
+		if warned := strings.Contains(warnings.String(), "TEST_VALUE"); warned != tc.warn {
+			t.Errorf("TEST_VALUE=%q: warned %t, want %t: %s", tc.value, warned, tc.warn, warnings)
+		}
+	}
+}
+
+func TestGetEnvAsInt(t *testing.T) {
+	checkHelper(t, func(name string) int { return getEnvAsInt(name, 7) }, []helperCase[int]{
+		{set: false, want: 7},
+		{value: "", set: true, want: 7},
+		{value: "42", set: true, want: 42},
+		{value: "-3", set: true, want: -3},
+		{value: "4.5", set: true, want: 7, warn: true},
+		{value: "seven", set: true, want: 7, warn: true},
+	})
+}
+
+func TestGetEnvAsBool(t *testing.T) {
+	checkHelper(t, func(name string) bool { return getEnvAsBool(name, true) }, []helperCase[bool]{
+		{set: false, want: true},
+		{value: "false", set: true, want: false},
+		{value: "0", set: true, want: false},
+		{value: "TRUE", set: true, want: true},
+		{value: "nope", set: true, want: true, warn: true},
+	})
+}
+
+func TestGetEnvAsDuration(t *testing.T) {
+	checkHelper(t, func(name string) time.Duration { return getEnvAsDuration(name, time.Minute) }, []helperCase[time.Duration]{
+		{set: false, want: time.Minute},
+		{value: "1h30m", set: true, want: 90 * time.Minute},
+		{value: "250ms", set: true, want: 250 * time.Millisecond},
+		{value: "30", set: true, want: time.Minute, warn: true},
+		{value: "soon", set: true, want: time.Minute, warn: true},
+	})
+}
+
+func TestGetEnvAsFloat(t *testing.T) {
+	checkHelper(t, func(name string) float64 { return getEnvAsFloat(name, 0.5) }, []helperCase[float64]{
+		{set: false, want: 0.5},
+		{value: "0.25", set: true, want: 0.25},
+		{value: "1e3", set: true, want: 1000},
+		{value: "-2", set: true, want: -2},
+		{value: "half", set: true, want: 0.5, warn: true},
+	})
+}
+
+func TestGetEnvAsSlice(t *testing.T) {
+	def := []string{"default"}
+	checkHelper(t, func(name string) []string { return getEnvAsSlice(name, ",", def) }, []helperCase[[]string]{
+		{set: false, want: def},
+		{value: "a,b,c", set: true, want: []string{"a", "b", "c"}},
+		{value: " a , b ,, ", set: true, want: []string{"a", "b"}},
+		{value: "only", set: true, want: []string{"only"}},
+	})
+	checkHelper(t, func(name string) []string { return getEnvAsSlice(name, ";", def) }, []helperCase[[]string]{
+		{value: "a,b;c", set: true, want: []string{"a,b", "c"}},
+	})
+}
+
+func TestGetEnvAsMap(t *testing.T) {
+	def := map[string]string{"default": "yes"}
+	checkHelper(t, func(name string) map[string]string { return getEnvAsMap(name, def) }, []helperCase[map[string]string]{
+		{set: false, want: def},
+		{value: "k1=v1,k2=v2", set: true, want: map[string]string{"k1": "v1", "k2": "v2"}},
+		{value: " k1 = v1 , k2= ", set: true, want: map[string]string{"k1": "v1", "k2": ""}},
+		{value: "url=a=b", set: true, want: map[string]string{"url": "a=b"}},
+		{value: "k=1,k=2", set: true, want: map[string]string{"k": "2"}},
+		{value: "k1=v1,broken", set: true, want: def, warn: true},
+		{value: "=v", set: true, want: def, warn: true},
+	})
+}
+
+func TestGetEnvAsURL(t *testing.T) {
+	def := &url.URL{Scheme: "http", Host: "localhost"}
+	mustParse := func(s string) *url.URL {
+		u, err := url.Parse(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return u
+	}
+	checkHelper(t, func(name string) *url.URL { return getEnvAsURL(name, def) }, []helperCase[*url.URL]{
+		{set: false, want: def},
+		{value: "https://example.com:8443/api?x=1", set: true, want: mustParse("https://example.com:8443/api?x=1")},
+		{value: "redis://:pw@cache:6379/0", set: true, want: mustParse("redis://:pw@cache:6379/0")},
+		{value: "example.com", set: true, want: def, warn: true},
+		{value: "/just/a/path", set: true, want: def, warn: true},
+		{value: "http://bad host", set: true, want: def, warn: true},
+	})
+}
+
+func TestGetEnvSecret(t *testing.T) {
+	dir := t.TempDir()
+	secretFile := filepath.Join(dir, "secret")
+	if err := os.WriteFile(secretFile, []byte("  from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	emptyFile := filepath.Join(dir, "empty")
+	if err := os.WriteFile(emptyFile, []byte("\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		value string
+		file  string
+		want  string
+		warn  bool
+	}{
+		{"neither", "", "", "default", false},
+		{"variable", "from-env", "", "from-env", false},
+		{"file", "", secretFile, "from-file", false},
+		{"variable beats file", "from-env", secretFile, "from-env", false},
+		{"missing file", "", filepath.Join(dir, "missing"), "default", true},
+		{"empty file", "", emptyFile, "default", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unsetEnv(t, "TEST_SECRET", "TEST_SECRET_FILE")
+			if tt.value != "" {
+				t.Setenv("TEST_SECRET", tt.value)
+			}
+			if tt.file != "" {
+				t.Setenv("TEST_SECRET_FILE", tt.file)
+			}
+			warnings := captureWarnings(t)
+			if got := getEnvSecret("TEST_SECRET", "default"); got != tt.want {
+				t.Errorf("getEnvSecret = %q, want %q", got, tt.want)
+			}
+			if warned := strings.Contains(warnings.String(), "TEST_SECRET_FILE"); warned != tt.warn {
+				t.Errorf("warned %t, want %t: %s", warned, tt.warn, warnings)
+			}
+			if strings.Contains(warnings.String(), "from-file") {
+				t.Errorf("warning shows the secret: %s", warnings)
+			}
+		})
+	}
+}
+
+func TestEnvFromMap(t *testing.T) {
+	env := mapEnv(map[string]string{
+		"PORT":    "9090",
+		"DEBUG":   "yes",
+		"EMPTY":   "",
+		"ORIGINS": "a.com, b.com",
+		"TIMEOUT": "2s",
+	})
+	warnings := captureWarnings(t)
+
+	if got := env.String("EMPTY", "default"); got != "" {
+		t.Errorf("String(EMPTY) = %q, want the empty value kept", got)
+	}
+	if got := env.String("UNSET", "default"); got != "default" {
+		t.Errorf("String(UNSET) = %q", got)
+	}
+	if got := env.Int("PORT", 1); got != 9090 {
+		t.Errorf("Int(PORT) = %d", got)
+	}
+	if got := env.Int("EMPTY", 1); got != 1 {
+		t.Errorf("Int(EMPTY) = %d, want the default", got)
+	}
+	// "yes" isn't a bool strconv knows, so it is warned about
+	if got := env.Bool("DEBUG", true); got != true || !strings.Contains(warnings.String(), "DEBUG") {
+		t.Errorf("Bool(DEBUG) = %t, warnings %q", got, warnings)
+	}
+	if got := env.Duration("TIMEOUT", time.Minute); got != 2*time.Second {
+		t.Errorf("Duration(TIMEOUT) = %s", got)
+	}
+	if got := env.Slice("ORIGINS", ",", nil); !reflect.DeepEqual(got, []string{"a.com", "b.com"}) {
+		t.Errorf("Slice(ORIGINS) = %q", got)
+	}
+}
+
+func TestEnvOrElse(t *testing.T) {
+	env := mapEnv(map[string]string{"A": "first", "EMPTY": ""}).orElse(mapEnv(map[string]string{"A": "second", "B": "second", "EMPTY": "second"}))
+	for key, want := range map[string]string{"A": "first", "B": "second", "EMPTY": "", "C": "unset"} {
+		if got := env.String(key, "unset"); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestEnvSecretFromMap(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(secretFile, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	env := mapEnv(map[string]string{"TOKEN_FILE": secretFile})
+	if got := env.Secret("TOKEN", "default"); got != "from-file" {
+		t.Errorf("Secret(TOKEN) = %q, want the file's contents", got)
+	}
+	if got := env.Secret("OTHER", "default"); got != "default" {
+		t.Errorf("Secret(OTHER) = %q", got)
+	}
+}
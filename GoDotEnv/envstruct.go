@@ -0,0 +1,195 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// FieldError is a struct field LoadStruct could not set
+type FieldError struct {
+	// Field is the field's path from the top struct, like "Database.Port"
+	Field string
+	Env   string
+	// Value is what the variable or default held, masked when the field
+	// is tagged secret; empty when missing
+	Value string
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	if e.Value == "" {
+		return fmt.Sprintf("field %s (env %s): %v", e.Field, e.Env, e.Err)
+	}
+	return fmt.Sprintf("field %s (env %s): bad value %q: %v", e.Field, e.Env, e.Value, e.Err)
+}
+
+func (e *FieldError) Unwrap() error { return e.Err }
+
+// ErrRequired is a FieldError's Err when a required variable is not set
+var ErrRequired = errors.New("required but not set")
+
+var (
+	durationType = reflect.TypeFor[time.Duration]()
+	urlType      = reflect.TypeFor[*url.URL]()
+)
+
+// LoadStruct fills the struct cfg points to from the environment, using
+// its fields' tags:
+//
+//	env:"DB_PORT"        the variable to read
+//	default:"5432"       the value when the variable is unset or empty
+//	required:"true"      an error when it is unset and there is no default
+//	file:"true"          when the variable is unset, reads the file that
+//	                     DB_PORT_FILE names, as getEnvSecret does
+//	sep:";"              what separates a []string's items, "," by default
+//	envPrefix:"SMTP_"    on a nested struct, prefixes its fields' variables
+//	validate:"port"      the format the value must be in; see checkFormat
+//	secret:"true"        masks the value in errors
+//
+// Fields can be string, int, int64, float64, bool, time.Duration,
+// []string, map[string]string written "k1=v1,k2=v2", or an absolute
+// *url.URL. Fields without an env tag are left alone unless they are
+// structs, which are filled in turn. Every field that fails to parse or
+// validate is reported, each as a *FieldError.
+func LoadStruct(cfg any) error {
+	return structLoader{env: osEnv, validate: true}.load(cfg)
+}
+
+// structLoader is LoadStruct reading env instead of the process
+// environment, and checking the validate tags only when validate is set
+type structLoader struct {
+	env      Env
+	validate bool
+}
+
+// load fills the struct cfg points to
+func (l structLoader) load(cfg any) error {
+	v := reflect.ValueOf(cfg)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("LoadStruct needs a pointer to a struct, got %T", cfg)
+	}
+	return errors.Join(l.loadStruct(v.Elem(), "", "")...)
+}
+
+// loadStruct fills v's fields, with prefix before their variables and
+// path before their names
+func (l structLoader) loadStruct(v reflect.Value, prefix, path string) []error {
+	var errs []error
+	t := v.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := path + field.Name
+		key, ok := field.Tag.Lookup("env")
+		if !ok {
+			if field.Type.Kind() == reflect.Struct {
+				errs = append(errs, l.loadStruct(v.Field(i), prefix+field.Tag.Get("envPrefix"), name+".")...)
+			}
+			continue
+		}
+
+		key = prefix + key
+		value := l.env.Get(key)
+		if value == "" && field.Tag.Get("file") == "true" {
+			if path := l.env.Get(key + "_FILE"); path != "" {
+				secret, err := readSecretFile(path)
+				if err != nil {
+					errs = append(errs, &FieldError{Field: name, Env: key + "_FILE", Value: path, Err: err})
+					continue
+				}
+				value = secret
+			}
+		}
+		if value == "" {
+			value = field.Tag.Get("default")
+		}
+		if value == "" {
+			if field.Tag.Get("required") == "true" {
+				errs = append(errs, &FieldError{Field: name, Env: key, Err: ErrRequired})
+			}
+			continue
+		}
+		err := setField(v.Field(i), value, field.Tag.Get("sep"))
+		if rule, ok := field.Tag.Lookup("validate"); ok && l.validate && err == nil {
+			err = checkFormat(rule, value)
+		}
+		if err != nil {
+			if field.Tag.Get("secret") == "true" {
+				value = maskSecret(value)
+			}
+			errs = append(errs, &FieldError{Field: name, Env: key, Value: value, Err: err})
+		}
+	}
+	return errs
+}
+
+// setField parses value into f according to f's type, splitting a
+// []string on sep
+func setField(f reflect.Value, value, sep string) error {
+	switch f.Type() {
+	// Duration is an int64, so it has to be checked for first
+	case durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		f.SetInt(int64(d))
+		return nil
+	case urlType:
+		u, err := parseURL(value)
+		if err != nil {
+			return err
+		}
+		f.Set(reflect.ValueOf(u))
+		return nil
+	}
+
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(value)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetInt(n)
+	case reflect.Float64:
+		x, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		f.SetFloat(x)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		f.SetBool(b)
+	case reflect.Slice:
+		if f.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", f.Type())
+		}
+		if sep == "" {
+			sep = ","
+		}
+		f.Set(reflect.ValueOf(parseSlice(value, sep)).Convert(f.Type()))
+	case reflect.Map:
+		if f.Type().Key().Kind() != reflect.String || f.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", f.Type())
+		}
+		m, err := parseMap(value)
+		if err != nil {
+			return err
+		}
+		f.Set(reflect.ValueOf(m).Convert(f.Type()))
+	default:
+		return fmt.Errorf("unsupported type %s", f.Type())
+	}
+	return nil
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadStructTypes(t *testing.T) {
+	type types struct {
+		String   string        `env:"TEST_STRING"`
+		Int      int           `env:"TEST_INT"`
+		Int64    int64         `env:"TEST_INT64"`
+		Float64  float64       `env:"TEST_FLOAT64"`
+		Bool     bool          `env:"TEST_BOOL"`
+		Duration time.Duration `env:"TEST_DURATION"`
+		Strings  []string      `env:"TEST_STRINGS"`
+	}
+	tests := []struct {
+		key   string
+		value string
+		want  types
+	}{
+		{"TEST_STRING", "hello world", types{String: "hello world"}},
+		{"TEST_INT", "-42", types{Int: -42}},
+		{"TEST_INT64", "9007199254740993", types{Int64: 9007199254740993}},
+		{"TEST_FLOAT64", "2.5", types{Float64: 2.5}},
+		{"TEST_BOOL", "true", types{Bool: true}},
+		{"TEST_BOOL", "0", types{Bool: false}},
+		{"TEST_DURATION", "1m30s", types{Duration: 90 * time.Second}},
+		{"TEST_STRINGS", "a, b ,,c", types{Strings: []string{"a", "b", "c"}}},
+		{"TEST_STRINGS", "one", types{Strings: []string{"one"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key+"="+tt.value, func(t *testing.T) {
+			unsetEnv(t, "TEST_STRING", "TEST_INT", "TEST_INT64", "TEST_FLOAT64", "TEST_BOOL", "TEST_DURATION", "TEST_STRINGS")
+			t.Setenv(tt.key, tt.value)
+			var got types
+			if err := LoadStruct(&got); err != nil {
+				t.Fatalf("LoadStruct: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadStructBadValues(t *testing.T) {
+	type types struct {
+		Int      int           `env:"TEST_INT"`
+		Int64    int64         `env:"TEST_INT64"`
+		Float64  float64       `env:"TEST_FLOAT64"`
+		Bool     bool          `env:"TEST_BOOL"`
+		Duration time.Duration `env:"TEST_DURATION"`
+	}
+	tests := []struct {
+		key   string
+		value string
+		field string
+	}{
+		{"TEST_INT", "forty", "Int"},
+		{"TEST_INT", "1.5", "Int"},
+		{"TEST_INT64", "99999999999999999999", "Int64"},
+		{"TEST_FLOAT64", "two", "Float64"},
+		{"TEST_BOOL", "yes", "Bool"},
+		{"TEST_DURATION", "90", "Duration"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key+"="+tt.value, func(t *testing.T) {
+			unsetEnv(t, "TEST_INT", "TEST_INT64", "TEST_FLOAT64", "TEST_BOOL", "TEST_DURATION")
+			t.Setenv(tt.key, tt.value)
+			var cfg types
+			err := LoadStruct(&cfg)
+			var fieldErr *FieldError
+			if !errors.As(err, &fieldErr) {
+				t.Fatalf("LoadStruct = %v, want a *FieldError", err)
+			}
+			if fieldErr.Field != tt.field || fieldErr.Env != tt.key || fieldErr.Value != tt.value {
+				t.Errorf("error names %s, %s, %q", fieldErr.Field, fieldErr.Env, fieldErr.Value)
+			}
+			for _, want := range []string{tt.field, tt.key, `"` + tt.value + `"`} {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("%q does not mention %s", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadStructDefaultsAndRequired(t *testing.T) {
+	type config struct {
+		Name     string        `env:"TEST_NAME" default:"demo"`
+		Port     int           `env:"TEST_PORT" default:"8080"`
+		Timeout  time.Duration `env:"TEST_TIMEOUT" default:"5s"`
+		Tags     []string      `env:"TEST_TAGS" default:"a,b"`
+		Secret   string        `env:"TEST_SECRET" required:"true"`
+		Fallback string        `env:"TEST_FALLBACK" default:"x" required:"true"`
+		Optional string        `env:"TEST_OPTIONAL"`
+		Ignored  string
+		internal string `env:"TEST_INTERNAL"`
+	}
+	keys := []string{"TEST_NAME", "TEST_PORT", "TEST_TIMEOUT", "TEST_TAGS", "TEST_SECRET", "TEST_FALLBACK", "TEST_OPTIONAL", "TEST_INTERNAL"}
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    config
+		missing string // the required variable the error should name
+	}{
+		{
+			name: "defaults",
+			env:  map[string]string{"TEST_SECRET": "s3cret"},
+			want: config{Name: "demo", Port: 8080, Timeout: 5 * time.Second, Tags: []string{"a", "b"}, Secret: "s3cret", Fallback: "x"},
+		},
+		{
+			name: "variables beat defaults",
+			env:  map[string]string{"TEST_NAME": "live", "TEST_PORT": "9090", "TEST_TIMEOUT": "1s", "TEST_TAGS": "c", "TEST_SECRET": "s", "TEST_FALLBACK": "y", "TEST_OPTIONAL": "o", "TEST_INTERNAL": "i"},
+			want: config{Name: "live", Port: 9090, Timeout: time.Second, Tags: []string{"c"}, Secret: "s", Fallback: "y", Optional: "o"},
+		},
+		{
+			name: "empty counts as unset",
+			env:  map[string]string{"TEST_PORT": "", "TEST_SECRET": "s"},
+			want: config{Name: "demo", Port: 8080, Timeout: 5 * time.Second, Tags: []string{"a", "b"}, Secret: "s", Fallback: "x"},
+		},
+		{
+			name:    "required missing",
+			env:     map[string]string{},
+			missing: "TEST_SECRET",
+		},
+		{
+			name:    "required empty",
+			env:     map[string]string{"TEST_SECRET": ""},
+			missing: "TEST_SECRET",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unsetEnv(t, keys...)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			got := config{Ignored: "kept"}
+			tt.want.Ignored = "kept"
+			err := LoadStruct(&got)
+			if tt.missing != "" {
+				var fieldErr *FieldError
+				if !errors.Is(err, ErrRequired) || !errors.As(err, &fieldErr) || fieldErr.Env != tt.missing || fieldErr.Field != "Secret" {
+					t.Fatalf("LoadStruct = %v, want %s required", err, tt.missing)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadStruct: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadStructNestedPrefixes(t *testing.T) {
+	type server struct {
+		Host string `env:"HOST" default:"localhost"`
+		Port int    `env:"PORT" required:"true"`
+	}
+	type tls struct {
+		Cert string `env:"CERT"`
+	}
+	type smtp struct {
+		Host string `env:"HOST"`
+		TLS  tls    `envPrefix:"TLS_"`
+	}
+	type config struct {
+		Primary server `envPrefix:"PRIMARY_"`
+		Replica server `envPrefix:"REPLICA_"`
+		SMTP    smtp   `envPrefix:"SMTP_"`
+		// No prefix: its fields read their own names
+		Plain tls
+	}
+	unsetEnv(t, "PRIMARY_HOST", "PRIMARY_PORT", "REPLICA_HOST", "REPLICA_PORT", "SMTP_HOST", "SMTP_TLS_CERT", "CERT", "HOST", "PORT")
+	t.Setenv("PRIMARY_HOST", "db1")
+	t.Setenv("PRIMARY_PORT", "5432")
+	t.Setenv("REPLICA_PORT", "5433")
+	t.Setenv("SMTP_HOST", "mail")
+	t.Setenv("SMTP_TLS_CERT", "cert.pem")
+	t.Setenv("CERT", "plain.pem")
+	t.Setenv("HOST", "not used")
+
+	var got config
+	if err := LoadStruct(&got); err != nil {
+		t.Fatalf("LoadStruct: %v", err)
+	}
+	want := config{
+		Primary: server{Host: "db1", Port: 5432},
+		Replica: server{Host: "localhost", Port: 5433},
+		SMTP:    smtp{Host: "mail", TLS: tls{Cert: "cert.pem"}},
+		Plain:   tls{Cert: "plain.pem"},
+	}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	// Errors name the nested field and the prefixed variable, and every
+	// failing field is reported
+	t.Setenv("PRIMARY_PORT", "x")
+	unsetEnv(t, "REPLICA_PORT")
+	err := LoadStruct(&got)
+	for _, want := range []string{`field Primary.Port (env PRIMARY_PORT): bad value "x"`, "field Replica.Port (env REPLICA_PORT): required but not set"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("LoadStruct = %v, want it to contain %q", err, want)
+		}
+	}
+}
+
+func TestLoadStructMisuse(t *testing.T) {
+	var notStruct int
+	var nilConfig *Config
+	for _, cfg := range []any{Config{}, &notStruct, nilConfig, nil} {
+		if err := LoadStruct(cfg); err == nil {
+			t.Errorf("LoadStruct(%T) succeeded", cfg)
+		}
+	}
+
+	type unsupported struct {
+		Ratio float32 `env:"TEST_RATIO"`
+	}
+	t.Setenv("TEST_RATIO", "0.5")
+	if err := LoadStruct(&unsupported{}); err == nil || !strings.Contains(err.Error(), "unsupported type float32") {
+		t.Errorf("float32 field: %v", err)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	unsetEnv(t, "DB_HOST", "DB_PORT", "DB_USER", "SERVER_PORT", "SMTP_PORT", "REDIS_HOST", "REDIS_PORT", "APP_NAME")
+	t.Setenv("DB_HOST", "db.internal")
+	t.Setenv("REDIS_PORT", "6380")
+
+	config, err := loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+	if config.Database.Host != "db.internal" || config.Database.Port != 5432 || config.Database.User != "postgres" {
+		t.Errorf("Database = %+v", config.Database)
+	}
+	if config.Redis.Host != "localhost" || config.Redis.Port != 6380 {
+		t.Errorf("Redis = %+v", config.Redis)
+	}
+	if config.ServerPort != 8080 || config.SMTPPort != 587 || config.AppName != "GoDotEnv Demo" {
+		t.Errorf("config = %+v", config)
+	}
+}
+
+func TestLoadStructMapsURLsAndFiles(t *testing.T) {
+	dir := t.TempDir()
+	secretFile := filepath.Join(dir, "secret")
+	if err := os.WriteFile(secretFile, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	type config struct {
+		Flags    map[string]string `env:"TEST_FLAGS"`
+		Endpoint *url.URL          `env:"TEST_ENDPOINT" default:"http://localhost:8080"`
+		Hosts    []string          `env:"TEST_HOSTS" sep:";"`
+		Secret   string            `env:"TEST_SECRET" file:"true" required:"true"`
+		NotFile  string            `env:"TEST_PLAIN"`
+	}
+	keys := []string{"TEST_FLAGS", "TEST_ENDPOINT", "TEST_HOSTS", "TEST_SECRET", "TEST_SECRET_FILE", "TEST_PLAIN", "TEST_PLAIN_FILE"}
+
+	unsetEnv(t, keys...)
+	t.Setenv("TEST_FLAGS", "a=1,b=2")
+	t.Setenv("TEST_HOSTS", "one;two, three")
+	t.Setenv("TEST_SECRET_FILE", secretFile)
+	t.Setenv("TEST_PLAIN_FILE", secretFile)
+	var got config
+	if err := LoadStruct(&got); err != nil {
+		t.Fatalf("LoadStruct: %v", err)
+	}
+	if !reflect.DeepEqual(got.Flags, map[string]string{"a": "1", "b": "2"}) {
+		t.Errorf("Flags = %v", got.Flags)
+	}
+	if got.Endpoint == nil || got.Endpoint.Host != "localhost:8080" {
+		t.Errorf("Endpoint = %v", got.Endpoint)
+	}
+	if !reflect.DeepEqual(got.Hosts, []string{"one", "two, three"}) {
+		t.Errorf("Hosts = %q", got.Hosts)
+	}
+	if got.Secret != "from-file" || got.NotFile != "" {
+		t.Errorf("Secret = %q, NotFile = %q; only file-tagged fields read _FILE", got.Secret, got.NotFile)
+	}
+
+	// The variable beats its file
+	t.Setenv("TEST_SECRET", "from-env")
+	if err := LoadStruct(&got); err != nil || got.Secret != "from-env" {
+		t.Errorf("Secret = %q, %v", got.Secret, err)
+	}
+
+	// Bad values name the variable that held them
+	unsetEnv(t, "TEST_SECRET")
+	t.Setenv("TEST_SECRET_FILE", filepath.Join(dir, "missing"))
+	t.Setenv("TEST_FLAGS", "broken")
+	t.Setenv("TEST_ENDPOINT", "not a url")
+	err := LoadStruct(&got)
+	for _, want := range []string{
+		"field Secret (env TEST_SECRET_FILE)",
+		`field Flags (env TEST_FLAGS): bad value "broken"`,
+		`field Endpoint (env TEST_ENDPOINT): bad value "not a url"`,
+	} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("LoadStruct = %v, want it to contain %q", err, want)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/joho/godotenv"
+)
+
+// CycleError is a variable whose value refers back to itself
+type CycleError struct {
+	// Chain is the references in order, starting and ending with the
+	// same variable, like A B A
+	Chain []string
+}
+
+func (e *CycleError) Error() string {
+	return "circular reference: " + strings.Join(e.Chain, " -> ")
+}
+
+// dollar stands in for "$" while godotenv parses a file. godotenv expands
+// ${VAR} itself, but only against the same file and without defaults or
+// escapes, so the references are hidden from it and expanded afterwards.
+const dollar = "\uE000"
+
+// readEnvFile parses an env file, leaving ${VAR} references as written
+func readEnvFile(path string) (map[string]string, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	// godotenv.Marshal, and so SaveEnv, writes a literal $ as \$
+	hidden := strings.ReplaceAll(strings.ReplaceAll(string(src), `\$`, "$$"), "$", dollar)
+	values, err := godotenv.UnmarshalBytes([]byte(hidden))
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range values {
+		values[key] = strings.ReplaceAll(value, dollar, "$")
+	}
+	return values, nil
+}
+
+// expandValues resolves the references in values, which are the loaded
+// variables, returning the result. ${VAR} is VAR's value, from values or
+// through lookup, which is the process environment; ${VAR:-default} is
+// default when VAR is unset or empty; $$ is a literal $, so $${VAR} is
+// the text ${VAR}. A reference to a variable that is not set is empty.
+func expandValues(values map[string]string, lookup func(string) (string, bool)) (map[string]string, error) {
+	e := &expander{raw: values, lookup: lookup, done: make(map[string]string)}
+	// Sorted, so a cycle is always reported from the same variable
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		if _, _, err := e.value(key); err != nil {
+			return nil, err
+		}
+	}
+	return e.done, nil
+}
+
+// expander expands each variable once, remembering the chain of
+// references it is in the middle of to catch cycles
+type expander struct {
+	raw      map[string]string
+	lookup   func(string) (string, bool)
+	done     map[string]string
+	visiting []string
+}
+
+// value is key's expanded value and whether it is set at all
+func (e *expander) value(key string) (string, bool, error) {
+	if v, ok := e.done[key]; ok {
+		return v, true, nil
+	}
+	raw, ok := e.raw[key]
+	if !ok {
+		v, ok := e.lookup(key)
+		return v, ok, nil
+	}
+	if i := slices.Index(e.visiting, key); i >= 0 {
+		return "", false, &CycleError{Chain: append(slices.Clone(e.visiting[i:]), key)}
+	}
+
+	e.visiting = append(e.visiting, key)
+	v, err := e.expand(raw)
+	e.visiting = e.visiting[:len(e.visiting)-1]
+	if err != nil {
+		return "", false, err
+	}
+	e.done[key] = v
+	return v, true, nil
+}
+
+// expand replaces the references in s
+func (e *expander) expand(s string) (string, error) {
+	var b strings.Builder
+	for {
+		i := strings.IndexByte(s, '$')
+		if i < 0 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+		b.WriteString(s[:i])
+		s = s[i:]
+
+		end := closingBrace(s)
+		switch {
+		case strings.HasPrefix(s, "$$"):
+			b.WriteByte('$')
+			s = s[2:]
+		case strings.HasPrefix(s, "${") && end > 2:
+			name, def, hasDef := strings.Cut(s[2:end], ":-")
+			s = s[end+1:]
+			v, _, err := e.value(name)
+			if err != nil {
+				return "", err
+			}
+			if hasDef && v == "" {
+				if v, err = e.expand(def); err != nil {
+					return "", err
+				}
+			}
+			b.WriteString(v)
+		default:
+			// Not a reference, like a lone $ or an unterminated ${
+			b.WriteByte('$')
+			s = s[1:]
+		}
+	}
+}
+
+// closingBrace is the index of the } that ends the ${ at the start of s,
+// allowing for references nested in a default, or -1
+func closingBrace(s string) int {
+	if !strings.HasPrefix(s, "${") {
+		return -1
+	}
+	depth := 0
+	for i := 1; i < len(s); i++ {
+		switch {
+		case strings.HasPrefix(s[i-1:], "${"):
+			depth++
+		case s[i] == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"testing"
+)
+
+// processLookup is a stand-in process environment for expandValues
+func processLookup(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+}
+
+func TestExpandValues(t *testing.T) {
+	process := processLookup(map[string]string{"HOME": "/home/demo", "EMPTY": ""})
+	tests := []struct {
+		name   string
+		values map[string]string
+		key    string
+		want   string
+	}{
+		{"plain", map[string]string{"A": "value"}, "A", "value"},
+		{"reference", map[string]string{"A": "x", "B": "${A}-y"}, "B", "x-y"},
+		{"chained", map[string]string{"A": "${B}/a", "B": "${C}/b", "C": "c"}, "A", "c/b/a"},
+		{"process env", map[string]string{"A": "${HOME}/app"}, "A", "/home/demo/app"},
+		{"unset is empty", map[string]string{"A": "[${MISSING}]"}, "A", "[]"},
+		{"default when unset", map[string]string{"A": "${MISSING:-5432}"}, "A", "5432"},
+		{"default when empty", map[string]string{"A": "${EMPTY:-fallback}"}, "A", "fallback"},
+		{"default unused when set", map[string]string{"A": "set", "B": "${A:-fallback}"}, "B", "set"},
+		{"default with a reference", map[string]string{"A": "${MISSING:-${HOME}/x}"}, "A", "/home/demo/x"},
+		{"default with a colon", map[string]string{"A": "${MISSING:-http://localhost:80}"}, "A", "http://localhost:80"},
+		{"escaped reference", map[string]string{"A": "x", "B": "$${A}"}, "B", "${A}"},
+		{"escaped dollar", map[string]string{"A": "cost: $$5"}, "A", "cost: $5"},
+		{"escape then reference", map[string]string{"A": "x", "B": "$$$${A} ${A}"}, "B", "$${A} x"},
+		{"lone dollar", map[string]string{"A": "a$b $"}, "A", "a$b $"},
+		{"unterminated", map[string]string{"A": "${HOME"}, "A", "${HOME"},
+		{"empty name", map[string]string{"A": "${}"}, "A", "${}"},
+		{
+			"database url",
+			map[string]string{"DB_USER": "app", "DB_PASSWORD": "pw", "DB_HOST": "db", "DB_PORT": "5432", "DB_NAME": "demo",
+				"DATABASE_URL": "postgres://${DB_USER}:${DB_PASSWORD}@${DB_HOST}:${DB_PORT}/${DB_NAME}?sslmode=${DB_SSL_MODE:-disable}"},
+			"DATABASE_URL",
+			"postgres://app:pw@db:5432/demo?sslmode=disable",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandValues(tt.values, process)
+			if err != nil {
+				t.Fatalf("expandValues: %v", err)
+			}
+			if got[tt.key] != tt.want {
+				t.Errorf("%s = %q, want %q", tt.key, got[tt.key], tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandValuesCycles(t *testing.T) {
+	tests := []struct {
+		name   string
+		values map[string]string
+		chain  []string
+	}{
+		{"self", map[string]string{"A": "${A}"}, []string{"A", "A"}},
+		{"two", map[string]string{"A": "${B}", "B": "${A}"}, []string{"A", "B", "A"}},
+		{"three", map[string]string{"A": "x${B}", "B": "${C}", "C": "${A}y"}, []string{"A", "B", "C", "A"}},
+		{"entered from outside", map[string]string{"A": "${B}", "B": "${C}", "C": "${B}"}, []string{"B", "C", "B"}},
+		{"in a default", map[string]string{"A": "${UNSET:-${B}}", "B": "${A}"}, []string{"A", "B", "A"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := expandValues(tt.values, processLookup(nil))
+			var cycle *CycleError
+			if !errors.As(err, &cycle) {
+				t.Fatalf("expandValues = %v, want a *CycleError", err)
+			}
+			if !slices.Equal(cycle.Chain, tt.chain) {
+				t.Errorf("Chain = %v, want %v", cycle.Chain, tt.chain)
+			}
+		})
+	}
+
+	// A default that is never used can't form a cycle
+	got, err := expandValues(map[string]string{"A": "set", "B": "${A:-${B}}"}, processLookup(nil))
+	if err != nil || got["B"] != "set" {
+		t.Errorf("B = %q, %v", got["B"], err)
+	}
+}
+
+func TestLoadLayeredExpands(t *testing.T) {
+	unsetEnv(t, "DB_USER", "DB_HOST", "DB_NAME", "DATABASE_URL", "DOUBLE", "SINGLE", "PRICE")
+	t.Setenv("DB_HOST", "db.process")
+	writeEnvFiles(t, map[string]string{
+		// A reference to a variable from a more specific file and one
+		// from the process environment, which godotenv alone leaves empty
+		".env": "DB_HOST=localhost\nDB_NAME=demo\nDATABASE_URL=postgres://${DB_USER}@${DB_HOST}/${DB_NAME}\n" +
+			"DOUBLE=\"${DB_NAME:-none}\"\nSINGLE='${DB_NAME}'\nPRICE=$$10\n",
+		".env.local": "DB_USER=local\n",
+	})
+
+	if _, err := LoadLayered("development"); err != nil {
+		t.Fatalf("LoadLayered: %v", err)
+	}
+	for key, want := range map[string]string{
+		"DATABASE_URL": "postgres://local@db.process/demo",
+		"DOUBLE":       "demo",
+		"SINGLE":       "demo",
+		"PRICE":        "$10",
+	} {
+		if got := os.Getenv(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestLoadLayeredCycle(t *testing.T) {
+	unsetEnv(t, "A", "B")
+	writeEnvFiles(t, map[string]string{".env": "A=${B}\n", ".env.local": "B=${A}\n"})
+	_, err := LoadLayered("development")
+	if err == nil || err.Error() != "circular reference: A -> B -> A" {
+		t.Errorf("LoadLayered = %v", err)
+	}
+	if _, ok := os.LookupEnv("A"); ok {
+		t.Error("a failed load set variables")
+	}
+}
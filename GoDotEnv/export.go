@@ -0,0 +1,311 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// exportOptions choose what exportConfig writes
+type exportOptions struct {
+	// Format is "json", "yaml", "shell", "compose", "env-file" or "k8s"
+	Format string
+	// ShowSecrets writes fields tagged secret:"true" as they are rather
+	// than through maskSecret
+	ShowSecrets bool
+	// Only keeps the variables whose names start with it, like "DB_"
+	Only string
+	// Name names the k8s format's ConfigMap and Secret, with -config and
+	// -secret after it
+	Name string
+}
+
+// newExportCommand is the export command: it loads the env files and the
+// Config as print does and writes them in another format, to stdout or a
+// file
+func newExportCommand(opts *rootOptions) *cobra.Command {
+	var export exportOptions
+	var output string
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Write the resolved configuration as JSON, YAML, shell exports, or for Compose or Kubernetes",
+		Long: `export writes the configuration as it resolves after loading the env files:
+
+  json, yaml   the Config's fields, nested as in the struct
+  shell        export KEY=value lines for the variables that are set
+  compose      a docker-compose environment: block of those variables
+  env-file     a docker-compose env_file of them, without comments or references
+  k8s          a Kubernetes ConfigMap, and a Secret for the variables tagged secret
+
+Secrets are masked unless --show-secrets is given, so pass it for a file
+to deploy. compose, env-file and k8s list the variables sorted by name.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := opts.load(); err != nil {
+				return err
+			}
+			return runExport(cmd.OutOrStdout(), export, output)
+		},
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&export.Format, "format", "json", "json, yaml, shell, compose, env-file or k8s")
+	flags.BoolVar(&export.ShowSecrets, "show-secrets", false, "write secrets unmasked")
+	flags.StringVar(&export.Only, "only", "", "only variables starting with this prefix, like DB_ or DB_*")
+	flags.StringVarP(&output, "output", "o", "", "write to this file instead of stdout")
+	flags.StringVar(&export.Name, "name", "godotenv-demo", "the k8s format's manifest name")
+	return cmd
+}
+
+// runExport writes the Config from the loaded environment to out, or to
+// the file output when it is set
+func runExport(out io.Writer, opts exportOptions, output string) error {
+	config, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	data, err := exportConfig(config, opts)
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		_, err := out.Write(data)
+		return err
+	}
+	// The file can hold secrets, so only its owner can read it
+	return os.WriteFile(output, data, 0o600)
+}
+
+// exportConfig writes config in opts.Format. JSON and YAML use the
+// struct's field names and nesting, with durations and URLs as strings;
+// the other formats list the raw variables the Config reads that are set,
+// as setVariables does.
+func exportConfig(config Config, opts exportOptions) ([]byte, error) {
+	fields := exportFields(reflect.ValueOf(config), "", nil)
+	prefix := strings.TrimSuffix(opts.Only, "*")
+	kept := fields[:0]
+	for _, f := range fields {
+		if strings.HasPrefix(f.Env, prefix) {
+			kept = append(kept, f)
+		}
+	}
+	fields = kept
+
+	switch opts.Format {
+	case "json":
+		data, err := json.MarshalIndent(fieldTree(fields, opts.ShowSecrets), "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	case "yaml":
+		return encodeYAML(fieldTree(fields, opts.ShowSecrets))
+	case "shell":
+		var buf bytes.Buffer
+		for _, v := range setVariables(fields, opts.ShowSecrets) {
+			fmt.Fprintf(&buf, "export %s=%s\n", v.Key, shellQuote(v.Value))
+		}
+		return buf.Bytes(), nil
+	case "compose":
+		return composeEnvironment(sortedVariables(fields, opts.ShowSecrets))
+	case "env-file":
+		return composeEnvFile(sortedVariables(fields, opts.ShowSecrets)), nil
+	case "k8s":
+		return kubernetesManifests(cmp.Or(opts.Name, "godotenv-demo"), sortedVariables(fields, opts.ShowSecrets))
+	}
+	return nil, fmt.Errorf("unknown format %q: want json, yaml, shell, compose, env-file or k8s", opts.Format)
+}
+
+// variable is a variable the Config reads, as it is set in the
+// environment
+type variable struct {
+	Key   string
+	Value string
+	// Secret is whether its field is tagged secret, which masks it
+	Secret bool
+}
+
+// setVariables are the variables of fields that are set, in field order,
+// with secrets masked unless showSecrets
+func setVariables(fields []exportField, showSecrets bool) []variable {
+	var vars []variable
+	for _, f := range fields {
+		value, ok := os.LookupEnv(f.Env)
+		if !ok {
+			continue
+		}
+		if f.Secret && !showSecrets && value != "" {
+			value = maskSecret(value)
+		}
+		vars = append(vars, variable{Key: f.Env, Value: value, Secret: f.Secret})
+	}
+	return vars
+}
+
+// sortedVariables is setVariables sorted by name, so files written from
+// it diff cleanly
+func sortedVariables(fields []exportField, showSecrets bool) []variable {
+	vars := setVariables(fields, showSecrets)
+	slices.SortFunc(vars, func(a, b variable) int { return strings.Compare(a.Key, b.Key) })
+	return vars
+}
+
+// exportField is one field LoadStruct sets, with the path of field
+// names that leads to it
+type exportField struct {
+	Path   []string
+	Env    string
+	Secret bool
+	Value  reflect.Value
+}
+
+// exportFields lists the fields of v that have env tags, in order,
+// following nested structs the way LoadStruct does
+func exportFields(v reflect.Value, prefix string, path []string) []exportField {
+	var fields []exportField
+	t := v.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fieldPath := append(path[:len(path):len(path)], field.Name)
+		key, ok := field.Tag.Lookup("env")
+		if !ok {
+			if field.Type.Kind() == reflect.Struct {
+				fields = append(fields, exportFields(v.Field(i), prefix+field.Tag.Get("envPrefix"), fieldPath)...)
+			}
+			continue
+		}
+		fields = append(fields, exportField{
+			Path:   fieldPath,
+			Env:    prefix + key,
+			Secret: field.Tag.Get("secret") == "true",
+			Value:  v.Field(i),
+		})
+	}
+	return fields
+}
+
+// fieldTree nests fields by their paths, keeping their order
+func fieldTree(fields []exportField, showSecrets bool) orderedMap {
+	var root orderedMap
+	for _, f := range fields {
+		m := &root
+		for _, name := range f.Path[:len(f.Path)-1] {
+			m = m.child(name)
+		}
+		*m = append(*m, keyValue{f.Path[len(f.Path)-1], exportValue(f, showSecrets)})
+	}
+	return root
+}
+
+// exportValue is f's value in a form JSON and YAML show readably
+func exportValue(f exportField, showSecrets bool) any {
+	switch v := f.Value.Interface().(type) {
+	case string:
+		if f.Secret && !showSecrets && v != "" {
+			return maskSecret(v)
+		}
+		return v
+	case time.Duration:
+		return v.String()
+	case *url.URL:
+		if v == nil {
+			return ""
+		}
+		return v.String()
+	case []string:
+		if v == nil {
+			return []string{}
+		}
+		return v
+	case map[string]string:
+		if v == nil {
+			return map[string]string{}
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// keyValue is an entry in an orderedMap
+type keyValue struct {
+	Key   string
+	Value any
+}
+
+// orderedMap is a JSON object or YAML mapping that keeps its keys in the
+// order they were added, rather than sorting them as a map would
+type orderedMap []keyValue
+
+// child is the orderedMap under key, added if it isn't there yet
+func (m *orderedMap) child(key string) *orderedMap {
+	for i := range *m {
+		if (*m)[i].Key == key {
+			if child, ok := (*m)[i].Value.(*orderedMap); ok {
+				return child
+			}
+		}
+	}
+	child := &orderedMap{}
+	*m = append(*m, keyValue{key, child})
+	return child
+}
+
+func (m orderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, kv := range m {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(kv.Key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(kv.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (m orderedMap) MarshalYAML() (any, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for _, kv := range m {
+		var value yaml.Node
+		if err := value.Encode(kv.Value); err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: kv.Key}, &value)
+	}
+	return node, nil
+}
+
+// plainValueChars are the characters a value can be written with unquoted
+const plainValueChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:@,+="
+
+// shellQuote quotes s for a POSIX shell, leaving simple words bare
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, plainValueChars) == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// exportSample is a Config with every kind of field set
+func exportSample() Config {
+	return Config{
+		Database:       DatabaseConfig{Host: "db", Port: 5432, User: "app", Password: "database-password", Name: "demo", SSLMode: "disable"},
+		DatabaseURL:    "postgres://app:database-password@db:5432/demo",
+		ServerPort:     8080,
+		ServerHost:     "localhost",
+		DebugMode:      true,
+		ReadTimeout:    90 * time.Second,
+		WriteTimeout:   1500 * time.Millisecond,
+		PublicURL:      &url.URL{Scheme: "https", Host: "example.com", Path: "/app"},
+		AllowedOrigins: []string{"https://a.example", "https://b.example"},
+		JWTSecret:      "jwt-secret-value-123",
+		Redis:          RedisConfig{Host: "cache", Port: 6379},
+		AppName:        "Demo",
+		FeatureFlags:   map[string]string{"new_ui": "on"},
+	}
+}
+
+func TestExportJSON(t *testing.T) {
+	data, err := exportConfig(exportSample(), exportOptions{Format: "json"})
+	if err != nil {
+		t.Fatalf("exportConfig: %v", err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, data)
+	}
+	db := got["Database"].(map[string]any)
+	checks := map[string]struct{ got, want any }{
+		"Database.Host":  {db["Host"], "db"},
+		"Database.Port":  {db["Port"], 5432.0},
+		"DebugMode":      {got["DebugMode"], true},
+		"ReadTimeout":    {got["ReadTimeout"], "1m30s"},
+		"WriteTimeout":   {got["WriteTimeout"], "1.5s"},
+		"PublicURL":      {got["PublicURL"], "https://example.com/app"},
+		"AllowedOrigins": {len(got["AllowedOrigins"].([]any)), 2},
+		"FeatureFlags":   {got["FeatureFlags"].(map[string]any)["new_ui"], "on"},
+		"APIKey":         {got["APIKey"], ""},
+		"SMTPPort":       {got["SMTPPort"], 0.0},
+	}
+	for name, c := range checks {
+		if c.got != c.want {
+			t.Errorf("%s = %#v, want %#v", name, c.got, c.want)
+		}
+	}
+
+	// Field order follows the struct, not the alphabet
+	s := string(data)
+	if !(strings.Index(s, `"Database"`) < strings.Index(s, `"ServerPort"`) && strings.Index(s, `"ServerPort"`) < strings.Index(s, `"AppName"`)) {
+		t.Errorf("fields out of order:\n%s", s)
+	}
+}
+
+func TestExportYAML(t *testing.T) {
+	data, err := exportConfig(exportSample(), exportOptions{Format: "yaml"})
+	if err != nil {
+		t.Fatalf("exportConfig: %v", err)
+	}
+	var got struct {
+		Database struct {
+			Host string `yaml:"Host"`
+			Port int    `yaml:"Port"`
+		} `yaml:"Database"`
+		DebugMode      bool              `yaml:"DebugMode"`
+		ReadTimeout    string            `yaml:"ReadTimeout"`
+		PublicURL      string            `yaml:"PublicURL"`
+		AllowedOrigins []string          `yaml:"AllowedOrigins"`
+		FeatureFlags   map[string]string `yaml:"FeatureFlags"`
+	}
+	if err := yaml.Unmarshal(data, &got); err != nil {
+		t.Fatalf("output is not YAML: %v\n%s", err, data)
+	}
+	if got.Database.Host != "db" || got.Database.Port != 5432 || !got.DebugMode || got.ReadTimeout != "1m30s" ||
+		got.PublicURL != "https://example.com/app" || len(got.AllowedOrigins) != 2 || got.FeatureFlags["new_ui"] != "on" {
+		t.Errorf("decoded %+v from:\n%s", got, data)
+	}
+	for _, want := range []string{"Database:\n  Host: db\n", "DebugMode: true\n", "ReadTimeout: 1m30s\n", "  - https://a.example\n", "  new_ui: \"on\"\n"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("YAML does not contain %q:\n%s", want, data)
+		}
+	}
+}
+
+func TestExportShell(t *testing.T) {
+	unsetEnv(t, "DB_HOST", "DB_PORT", "DB_USER", "DB_PASSWORD", "DB_NAME", "DB_SSL_MODE", "APP_NAME", "LOG_LEVEL")
+	t.Setenv("DB_HOST", "db")
+	t.Setenv("DB_PASSWORD", "database-password")
+	t.Setenv("APP_NAME", "It's a demo")
+	t.Setenv("LOG_LEVEL", "")
+
+	data, err := exportConfig(exportSample(), exportOptions{Format: "shell"})
+	if err != nil {
+		t.Fatalf("exportConfig: %v", err)
+	}
+	got := string(data)
+	for _, want := range []string{
+		"export DB_HOST=db\n",
+		"export DB_PASSWORD=" + shellQuote(maskSecret("database-password")) + "\n",
+		`export APP_NAME='It'\''s a demo'` + "\n",
+		"export LOG_LEVEL=''\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("shell output does not contain %q:\n%s", want, got)
+		}
+	}
+	// Unset variables are left out rather than exported empty
+	if strings.Contains(got, "DB_PORT") || strings.Contains(got, "DB_USER") {
+		t.Errorf("shell output has unset variables:\n%s", got)
+	}
+	if strings.Index(got, "DB_HOST") > strings.Index(got, "DB_PASSWORD") {
+		t.Errorf("variables out of order:\n%s", got)
+	}
+}
+
+func TestExportMasksSecrets(t *testing.T) {
+	for _, format := range []string{"json", "yaml", "shell"} {
+		unsetEnv(t, "JWT_SECRET", "DB_PASSWORD")
+		t.Setenv("JWT_SECRET", "jwt-secret-value-123")
+		t.Setenv("DB_PASSWORD", "database-password")
+
+		masked, err := exportConfig(exportSample(), exportOptions{Format: format})
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		for _, secret := range []string{"jwt-secret-value-123", "database-password"} {
+			if bytes.Contains(masked, []byte(secret)) {
+				t.Errorf("%s output shows %q:\n%s", format, secret, masked)
+			}
+		}
+		if !bytes.Contains(masked, []byte(maskSecret("jwt-secret-value-123"))) {
+			t.Errorf("%s output does not have the masked secret:\n%s", format, masked)
+		}
+
+		shown, err := exportConfig(exportSample(), exportOptions{Format: format, ShowSecrets: true})
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if !bytes.Contains(shown, []byte("jwt-secret-value-123")) || !bytes.Contains(shown, []byte("database-password")) {
+			t.Errorf("%s output with secrets shown:\n%s", format, shown)
+		}
+	}
+}
+
+func TestExportOnly(t *testing.T) {
+	for _, only := range []string{"DB_", "DB_*"} {
+		data, err := exportConfig(exportSample(), exportOptions{Format: "json", Only: only})
+		if err != nil {
+			t.Fatalf("exportConfig: %v", err)
+		}
+		var got map[string]map[string]any
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatalf("only %s: %v\n%s", only, err, data)
+		}
+		if len(got) != 1 || len(got["Database"]) != 6 {
+			t.Errorf("only %s exported:\n%s", only, data)
+		}
+	}
+
+	data, err := exportConfig(exportSample(), exportOptions{Format: "json", Only: "NOTHING_"})
+	if err != nil || strings.TrimSpace(string(data)) != "{}" {
+		t.Errorf("nothing matching = %s, %v", data, err)
+	}
+}
+
+func TestExportUnknownFormat(t *testing.T) {
+	if _, err := exportConfig(exportSample(), exportOptions{Format: "toml"}); err == nil {
+		t.Error("format toml succeeded")
+	}
+}
+
+func TestExportCommand(t *testing.T) {
+	unsetEnv(t, "APP_ENV", "DB_HOST", "DB_PASSWORD", "REDIS_HOST")
+	writeEnvFiles(t, map[string]string{".env": "DB_HOST=from-file\nDB_PASSWORD=database-password\nREDIS_HOST=cache\n"})
+
+	out, err := execute(t, "export", "--format", "shell", "--only", "DB_", "--output", "config.sh")
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if out != "" {
+		t.Errorf("wrote to stdout as well: %s", out)
+	}
+	got := readFile(t, "config.sh")
+	if !strings.Contains(got, "export DB_HOST=from-file\n") || strings.Contains(got, "REDIS") || strings.Contains(got, "database-password") {
+		t.Errorf("config.sh = %s", got)
+	}
+	if info, err := os.Stat(filepath.Join(".", "config.sh")); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("config.sh mode: %v, %v", info.Mode(), err)
+	}
+
+	if _, err := execute(t, "export", "--format", "xml"); err == nil {
+		t.Error("export with an unknown format succeeded")
+	}
+
+	// To stdout, as YAML
+	out, err = execute(t, "export", "-o", "", "--format", "yaml", "--only", "REDIS_*")
+	if err != nil || out != "Redis:\n  Host: cache\n  Port: 6379\n  Password: \"\"\n" {
+		t.Errorf("yaml export: %v\n%s", err, out)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// newGenerateExampleCommand is the generate-example command, which writes
+// an example env file listing every variable Config reads
+func newGenerateExampleCommand() *cobra.Command {
+	var output string
+	cmd := &cobra.Command{
+		Use:   "generate-example",
+		Short: "Write a .env.example listing every variable the Config reads",
+		Long: `generate-example writes an example env file from Config's tags: each
+variable with its default, and a placeholder for each secret that check
+reports until it is replaced.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := generateExample(reflect.TypeFor[Config]())
+			if err != nil {
+				return err
+			}
+			if output == "" {
+				_, err := cmd.OutOrStdout().Write(data)
+				return err
+			}
+			if err := writeFileAtomic(output, data, 0o644); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "✅ Wrote %s\n", output)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", "", "write to this file, such as .env.example, instead of stdout")
+	return cmd
+}
+
+// generateExample is an example env file for the struct type t, read the
+// way LoadStruct reads its tags
+func generateExample(t reflect.Type) ([]byte, error) {
+	var b strings.Builder
+	b.WriteString("# Generated by godotenv-demo generate-example\n")
+	b.WriteString("# Copy this to .env and replace the placeholders\n")
+	if err := writeExample(&b, t, ""); err != nil {
+		return nil, err
+	}
+	return []byte(b.String()), nil
+}
+
+// writeExample writes t's variables, with prefix before their names. Each
+// nested struct is a group headed by its field name.
+func writeExample(b *strings.Builder, t reflect.Type, prefix string) error {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		key, ok := field.Tag.Lookup("env")
+		if !ok {
+			if field.Type.Kind() == reflect.Struct {
+				fmt.Fprintf(b, "\n# %s\n", field.Name)
+				if err := writeExample(b, field.Type, prefix+field.Tag.Get("envPrefix")); err != nil {
+					return err
+				}
+				b.WriteString("\n")
+			}
+			continue
+		}
+
+		key = prefix + key
+		value := field.Tag.Get("default")
+		if field.Tag.Get("secret") == "true" {
+			value = "your-" + strings.ToLower(strings.ReplaceAll(key, "_", "-")) + "-here"
+		}
+		if field.Tag.Get("file") == "true" {
+			fmt.Fprintf(b, "# Or %s_FILE, naming a file that holds it\n", key)
+		}
+		entry, err := marshalEntry(key, value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		b.WriteString(entry + "\n")
+	}
+	return nil
+}
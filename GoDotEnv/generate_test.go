@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestGenerateExampleCommand(t *testing.T) {
+	out, err := execute(t, "generate-example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"\n# Database\nDB_HOST=\"localhost\"\nDB_PORT=5432\n",
+		"# Or DB_PASSWORD_FILE, naming a file that holds it\nDB_PASSWORD=\"your-db-password-here\"\n",
+		"\n# Redis\nREDIS_HOST=\"localhost\"\n",
+		"JWT_SECRET=\"your-jwt-secret-here\"\n",
+		"APP_NAME=\"GoDotEnv Demo\"\n",
+		"LOG_LEVEL=\"info\"\n",
+		"SMTP_HOST=\"\"\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output is missing %q:\n%s", want, out)
+		}
+	}
+
+	// Every variable the Config reads is listed, once
+	t.Chdir(t.TempDir())
+	if out, err := execute(t, "generate-example", "-o", "app.env.example"); err != nil || out != "✅ Wrote app.env.example\n" {
+		t.Fatalf("generate-example -o: %v, %s", err, out)
+	}
+	example, err := readEnvFile("app.env.example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, f := range exportFields(reflect.ValueOf(Config{}), "", nil) {
+		keys = append(keys, f.Env)
+	}
+	got := slices.Sorted(maps.Keys(example))
+	slices.Sort(keys)
+	if !slices.Equal(got, keys) {
+		t.Errorf("example keys = %v, want %v", got, keys)
+	}
+
+	// A copy passes check's drift test except for the placeholders
+	unsetEnv(t, keys...)
+	if err := writeFileAtomic("app.env", []byte(readFile(t, "app.env.example")), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	out, err = execute(t, "check", "--env-file", "app.env", "--example", "app.env.example")
+	var status exitStatus
+	if !errors.As(err, &status) || !strings.Contains(out, "⚠️  Still the example's placeholder (5):") || strings.Contains(out, "Missing") {
+		t.Errorf("check against the generated example: %v\n%s", err, out)
+	}
+}
@@ -2,4 +2,15 @@ module example.com/godotenv-demo
 
 go 1.25.0
 
-require github.com/joho/godotenv v1.5.1 // indirect
+require (
+	github.com/fsnotify/fsnotify v1.7.0
+	github.com/joho/godotenv v1.5.1
+	github.com/spf13/cobra v1.9.1
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/inconshreveable/mousetrap v1.1.0 // indirect
+	github.com/spf13/pflag v1.0.6 // indirect
+	golang.org/x/sys v0.15.0 // indirect
+)
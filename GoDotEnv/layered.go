@@ -0,0 +1,172 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+)
+
+// processEnv is the source of variables that were already set when the
+// env files were loaded
+const processEnv = "process environment"
+
+// FileSummary is what one env file contributed
+type FileSummary struct {
+	Path string
+	// Found is false when the file does not exist, which is not an error
+	Found bool
+	// Loaded counts keys no less specific file had set, Overridden keys it
+	// replaced, and Shadowed keys the process environment kept
+	Loaded     int
+	Overridden int
+	Shadowed   int
+}
+
+// LoadReport records which files LoadLayered read and where each
+// variable came from
+type LoadReport struct {
+	Env   string
+	Files []FileSummary
+	// Sources maps each variable to the file that supplied it, or to
+	// processEnv when it was set before loading
+	Sources map[string]string
+	// Decrypted lists, in order, the variables whose files held them as
+	// ENC(...)
+	Decrypted []string
+}
+
+// envFiles are the files for env from least to most specific. As in
+// godotenv's own recommendation, .env.local is skipped in the test
+// environment so tests behave the same on every machine.
+func envFiles(env string) []string {
+	files := []string{".env", ".env." + env}
+	if env != "test" {
+		files = append(files, ".env.local")
+	}
+	return append(files, ".env."+env+".local")
+}
+
+// LoadLayered loads .env, .env.{env}, .env.local and .env.{env}.local
+// from the working directory into the environment. A more specific file
+// wins over a less specific one, and anything already in the process
+// environment wins over all of them. Once the files are merged, ENC(...)
+// values are decrypted with DOTENV_KEY, then ${VAR} references are
+// expanded, as expandValues describes. Missing files are skipped; a file
+// that cannot be read or parsed, a value that won't decrypt, or a
+// circular reference is an error and nothing is set.
+func LoadLayered(env string) (*LoadReport, error) {
+	return LoadEnvFiles(env, envFiles(env)...)
+}
+
+// LoadEnvFiles is LoadLayered with the files given from least to most
+// specific, such as from the --env-file flag; env only labels the report
+func LoadEnvFiles(env string, files ...string) (*LoadReport, error) {
+	report := &LoadReport{Env: env, Sources: make(map[string]string)}
+	for _, kv := range os.Environ() {
+		if key, _, ok := strings.Cut(kv, "="); ok {
+			report.Sources[key] = processEnv
+		}
+	}
+	values, err := resolveEnvFiles(report, osEnv, files)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range values {
+		if err := os.Setenv(key, value); err != nil {
+			return nil, fmt.Errorf("setting %s: %w", key, err)
+		}
+	}
+	return report, nil
+}
+
+// resolveEnvFiles merges files, least specific first, under the
+// variables lookup already has, then decrypts and expands them, returning
+// the variables the files set without setting them anywhere. It records
+// each file and where each variable came from in report.
+func resolveEnvFiles(report *LoadReport, lookup Env, files []string) (map[string]string, error) {
+	values := make(map[string]string)
+	for _, path := range files {
+		summary := FileSummary{Path: path}
+		fileValues, err := readEnvFile(path)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			report.Files = append(report.Files, summary)
+			continue
+		case err != nil:
+			return nil, fmt.Errorf("loading %s: %w", path, err)
+		}
+		summary.Found = true
+		for key, value := range fileValues {
+			if _, ok := lookup(key); ok {
+				report.Sources[key] = processEnv
+				summary.Shadowed++
+				continue
+			}
+			if report.Sources[key] == "" {
+				summary.Loaded++
+			} else {
+				summary.Overridden++
+			}
+			report.Sources[key] = path
+			values[key] = value
+		}
+		report.Files = append(report.Files, summary)
+	}
+
+	decrypted, err := decryptValues(values, lookup)
+	if err != nil {
+		return nil, err
+	}
+	report.Decrypted = decrypted
+	return expandValues(values, lookup)
+}
+
+// Source is the file that supplied key, processEnv, or "" when nothing
+// set it and the default applies
+func (r *LoadReport) Source(key string) string {
+	if r == nil {
+		return ""
+	}
+	return r.Sources[key]
+}
+
+// annotate names where keys' values came from, for displayConfig
+func (r *LoadReport) annotate(keys ...string) string {
+	var sources []string
+	for _, key := range keys {
+		source := r.Source(key)
+		if source == "" {
+			source = "default"
+		}
+		if !slices.Contains(sources, source) {
+			sources = append(sources, source)
+		}
+	}
+	return "  [" + strings.Join(sources, ", ") + "]"
+}
+
+// FoundAny reports whether any of the files existed
+func (r *LoadReport) FoundAny() bool {
+	return slices.ContainsFunc(r.Files, func(f FileSummary) bool { return f.Found })
+}
+
+// printLoadSummary shows what each file contributed, in the order they
+// were loaded
+func printLoadSummary(out io.Writer, report *LoadReport) {
+	fmt.Fprintf(out, "\n📂 Environment files (APP_ENV=%s):\n", report.Env)
+	for _, f := range report.Files {
+		if !f.Found {
+			fmt.Fprintf(out, "   %-24s not found\n", f.Path)
+			continue
+		}
+		line := fmt.Sprintf("   %-24s %d loaded, %d overridden", f.Path, f.Loaded, f.Overridden)
+		if f.Shadowed > 0 {
+			line += fmt.Sprintf(", %d kept from the %s", f.Shadowed, processEnv)
+		}
+		fmt.Fprintln(out, line)
+	}
+}
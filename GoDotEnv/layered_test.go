@@ -0,0 +1,145 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeEnvFiles writes each file into a temporary directory and makes it
+// the working directory for the rest of the test
+func writeEnvFiles(t *testing.T, files map[string]string) {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(dir)
+}
+
+// unsetEnv clears keys for the test, restoring them when it ends
+func unsetEnv(t *testing.T, keys ...string) {
+	t.Helper()
+	for _, key := range keys {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+}
+
+func TestLoadLayeredPrecedence(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   []string // the files that set LAYERED_KEY, each to its own name
+		process bool     // whether LAYERED_KEY is also in the process env
+		want    string
+		source  string
+	}{
+		{"only .env", []string{".env"}, false, ".env", ".env"},
+		{"env file beats .env", []string{".env", ".env.production"}, false, ".env.production", ".env.production"},
+		{".env.local beats env file", []string{".env", ".env.production", ".env.local"}, false, ".env.local", ".env.local"},
+		{"env local beats .env.local", []string{".env.local", ".env.production.local"}, false, ".env.production.local", ".env.production.local"},
+		{"all four files", []string{".env", ".env.production", ".env.local", ".env.production.local"}, false, ".env.production.local", ".env.production.local"},
+		{"process env beats all four files", []string{".env", ".env.production", ".env.local", ".env.production.local"}, true, "process", processEnv},
+		{"process env alone", nil, true, "process", processEnv},
+		{"nothing sets it", nil, false, "", ""},
+		{"other environments' files are ignored", []string{".env.staging", ".env.staging.local"}, false, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unsetEnv(t, "LAYERED_KEY")
+			files := make(map[string]string)
+			for _, name := range tt.files {
+				files[name] = "LAYERED_KEY=" + name + "\n"
+			}
+			writeEnvFiles(t, files)
+			if tt.process {
+				t.Setenv("LAYERED_KEY", "process")
+			}
+
+			report, err := LoadLayered("production")
+			if err != nil {
+				t.Fatalf("LoadLayered: %v", err)
+			}
+			if got := os.Getenv("LAYERED_KEY"); got != tt.want {
+				t.Errorf("LAYERED_KEY = %q, want %q", got, tt.want)
+			}
+			if got := report.Source("LAYERED_KEY"); got != tt.source {
+				t.Errorf("Source(LAYERED_KEY) = %q, want %q", got, tt.source)
+			}
+		})
+	}
+}
+
+func TestLoadLayeredSummary(t *testing.T) {
+	unsetEnv(t, "A", "B", "C", "D")
+	t.Setenv("D", "process")
+	writeEnvFiles(t, map[string]string{
+		".env":                  "A=1\nB=1\nD=1\n",
+		".env.production":       "B=2\nC=2\n",
+		".env.production.local": "A=4\nD=4\n",
+	})
+
+	report, err := LoadLayered("production")
+	if err != nil {
+		t.Fatalf("LoadLayered: %v", err)
+	}
+	want := []FileSummary{
+		{Path: ".env", Found: true, Loaded: 2, Shadowed: 1},
+		{Path: ".env.production", Found: true, Loaded: 1, Overridden: 1},
+		{Path: ".env.local"},
+		{Path: ".env.production.local", Found: true, Overridden: 1, Shadowed: 1},
+	}
+	if len(report.Files) != len(want) {
+		t.Fatalf("Files = %+v", report.Files)
+	}
+	for i, f := range report.Files {
+		if f != want[i] {
+			t.Errorf("Files[%d] = %+v, want %+v", i, f, want[i])
+		}
+	}
+	for key, want := range map[string]string{"A": "4", "B": "2", "C": "2", "D": "process"} {
+		if got := os.Getenv(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+	if got := report.annotate("A", "B", "C", "D", "MISSING"); got != "  [.env.production.local, .env.production, process environment, default]" {
+		t.Errorf("annotate = %q", got)
+	}
+}
+
+func TestLoadLayeredSkipsLocalInTest(t *testing.T) {
+	unsetEnv(t, "LAYERED_KEY")
+	writeEnvFiles(t, map[string]string{
+		".env":       "LAYERED_KEY=.env\n",
+		".env.local": "LAYERED_KEY=.env.local\n",
+	})
+
+	report, err := LoadLayered("test")
+	if err != nil {
+		t.Fatalf("LoadLayered: %v", err)
+	}
+	if got := os.Getenv("LAYERED_KEY"); got != ".env" {
+		t.Errorf("LAYERED_KEY = %q, want .env's value", got)
+	}
+	for _, f := range report.Files {
+		if f.Path == ".env.local" {
+			t.Errorf("test environment read .env.local")
+		}
+	}
+}
+
+func TestLoadLayeredParseError(t *testing.T) {
+	unsetEnv(t, "LAYERED_KEY")
+	writeEnvFiles(t, map[string]string{
+		".env":            "LAYERED_KEY=.env\n",
+		".env.production": "LAYERED_KEY='unterminated\n",
+	})
+	if _, err := LoadLayered("production"); err == nil {
+		t.Fatal("LoadLayered with a broken file succeeded")
+	}
+	if _, ok := os.LookupEnv("LAYERED_KEY"); ok {
+		t.Error("a failed load set variables")
+	}
+}
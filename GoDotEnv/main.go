@@ -2,280 +2,281 @@ package main
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"net/url"
 	"os"
 	"runtime"
-	"strconv"
 	"strings"
+	"time"
 
-	"github.com/joho/godotenv"
+	"github.com/spf13/cobra"
 )
 
-// Config struct to hold our configuration
-type Config struct {
-	// Database
-	DBHost     string
-	DBPort     int
-	DBUser     string
-	DBPassword string
-	DBName     string
-	DBSSLMode  string
+// rootOptions are the persistent flags every command shares
+type rootOptions struct {
+	// env is APP_ENV, which picks the layered files
+	env string
+	// envFiles replace the layered files when set, least specific first
+	envFiles []string
+	keyFile  string
+}
 
-	// Server
-	ServerPort int
-	ServerHost string
-	DebugMode  bool
+// files are the env files the commands load, least specific first
+func (o *rootOptions) files() []string {
+	if len(o.envFiles) > 0 {
+		return o.envFiles
+	}
+	return envFiles(o.env)
+}
 
-	// Security
-	JWTSecret string
-	APIKey    string
+// load loads the env files into the environment
+func (o *rootOptions) load() (*LoadReport, error) {
+	report, err := LoadEnvFiles(o.env, o.files()...)
+	if err != nil {
+		return nil, fmt.Errorf("loading env files: %w", err)
+	}
+	return report, nil
+}
 
-	// Email
-	SMTPHost  string
-	SMTPPort  int
-	EmailFrom string
+func main() {
+	err := newRootCommand().Execute()
+	// check and audit have printed what they found and only set the status
+	var status exitStatus
+	if errors.As(err, &status) {
+		os.Exit(int(status))
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
 
-	// Redis
-	RedisHost     string
-	RedisPort     int
-	RedisPassword string
+// newRootCommand is the CLI. Run without a command, it prints everything
+// print --verbose does.
+func newRootCommand() *cobra.Command {
+	opts := &rootOptions{}
+	root := &cobra.Command{
+		Use:   "godotenv-demo",
+		Short: "Load, check and manage configuration from .env files",
+		Long: `godotenv-demo loads .env, .env.{env}, .env.local and .env.{env}.local,
+or the files given with --env-file, into a Config, and checks, exports
+and edits them. Without a command it prints everything print --verbose does.`,
+		Args:          cobra.NoArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			if opts.keyFile != "" {
+				os.Setenv(keyVariable+"_FILE", opts.keyFile)
+			}
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := runPrint(opts, cmd.OutOrStdout(), true); err != nil {
+				return err
+			}
+			// Prevent terminal window from closing on Windows
+			if runtime.GOOS == "windows" {
+				fmt.Fprintln(cmd.OutOrStdout(), "\nPress Enter to exit...")
+				bufio.NewScanner(cmd.InOrStdin()).Scan()
+			}
+			return nil
+		},
+	}
+	root.CompletionOptions.DisableDefaultCmd = true
+	root.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return fmt.Errorf("%w\nRun '%s --help' for usage", err, cmd.CommandPath())
+	})
+
+	flags := root.PersistentFlags()
+	flags.StringVar(&opts.env, "env", getEnv("APP_ENV", "development"), "the environment, which picks the layered env files")
+	flags.StringSliceVar(&opts.envFiles, "env-file", nil, "load these files instead of the layered ones, least specific first")
+	flags.StringVar(&opts.keyFile, "key-file", "", "a file holding the key for ENC() values, instead of DOTENV_KEY")
+
+	root.AddCommand(
+		newPrintCommand(opts),
+		newCheckCommand(opts),
+		newGenerateExampleCommand(),
+		newExportCommand(opts),
+		newSetCommand(opts),
+		newWatchCommand(opts),
+		newAuditCommand(opts),
+		newEncryptCommand(),
+		newNestedCommand(opts),
+	)
+	return root
+}
 
-	// App
-	AppName    string
-	AppVersion string
-	LogLevel   string
+// newPrintCommand is the print command, which shows the configuration
+// and where each value came from
+func newPrintCommand(opts *rootOptions) *cobra.Command {
+	var verbose bool
+	cmd := &cobra.Command{
+		Use:   "print",
+		Short: "Show the configuration and which file set each value",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPrint(opts, cmd.OutOrStdout(), verbose)
+		},
+	}
+	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "also show the ways to read variables and the connection strings")
+	return cmd
 }
 
-func main() {
-	fmt.Println("🚀 GoDotEnv Demo Application")
-	fmt.Println("============================")
+// runPrint loads the env files and the Config and shows them, with the
+// access methods and connection strings when verbose
+func runPrint(opts *rootOptions, out io.Writer, verbose bool) error {
+	fmt.Fprintln(out, "🚀 GoDotEnv Demo Application")
+	fmt.Fprintln(out, "============================")
 
-	// Load .env file
-	err := godotenv.Load()
+	report, err := opts.load()
 	if err != nil {
-		log.Printf("Warning: Error loading .env file: %v", err)
-		fmt.Println("⚠️  No .env file found, using system environment variables")
+		return err
+	}
+	if report.FoundAny() {
+		fmt.Fprintln(out, "✅ Successfully loaded env files")
 	} else {
-		fmt.Println("✅ Successfully loaded .env file")
+		fmt.Fprintln(out, "⚠️  No env files found, using system environment variables")
 	}
+	printLoadSummary(out, report)
 
-	// Load configuration
-	config := loadConfig()
-
-	// Display configuration
-	displayConfig(config)
+	config, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("invalid configuration:\n%w", err)
+	}
 
-	// Demonstrate different ways to access env vars
-	demonstrateUsage()
+	// Display configuration, with where each value came from
+	displayConfig(out, config, report)
 
-	// Create database connection string example
-	createDatabaseURL(config)
+	if verbose {
+		// Demonstrate different ways to access env vars
+		demonstrateUsage(out)
 
-	// Prevent terminal window from closing on Windows
-	if runtime.GOOS == "windows" {
-		fmt.Println("\nPress Enter to exit...")
-		bufio.NewScanner(os.Stdin).Scan()
+		// Create database connection string example
+		createDatabaseURL(out, config)
 	}
+	return nil
 }
 
-func loadConfig() Config {
-	return Config{
-		// Database configuration with defaults
-		DBHost:     getEnv("DB_HOST", "localhost"),
-		DBPort:     getEnvAsInt("DB_PORT", 5432),
-		DBUser:     getEnv("DB_USER", "postgres"),
-		DBPassword: getEnv("DB_PASSWORD", ""),
-		DBName:     getEnv("DB_NAME", "testdb"),
-		DBSSLMode:  getEnv("DB_SSL_MODE", "disable"),
-
-		// Server configuration
-		ServerPort: getEnvAsInt("SERVER_PORT", 8080),
-		ServerHost: getEnv("SERVER_HOST", "localhost"),
-		DebugMode:  getEnvAsBool("DEBUG_MODE", false),
-
-		// Security
-		JWTSecret: getEnv("JWT_SECRET", ""),
-		APIKey:    getEnv("API_KEY", ""),
-
-		// Email
-		SMTPHost:  getEnv("SMTP_HOST", ""),
-		SMTPPort:  getEnvAsInt("SMTP_PORT", 587),
-		EmailFrom: getEnv("EMAIL_FROM", ""),
-
-		// Redis
-		RedisHost:     getEnv("REDIS_HOST", "localhost"),
-		RedisPort:     getEnvAsInt("REDIS_PORT", 6379),
-		RedisPassword: getEnv("REDIS_PASSWORD", ""),
-
-		// Application
-		AppName:    getEnv("APP_NAME", "GoDotEnv Demo"),
-		AppVersion: getEnv("APP_VERSION", "1.0.0"),
-		LogLevel:   getEnv("LOG_LEVEL", "info"),
+// loadConfig is the Config from the process environment, which the
+// commands load the env files into first
+func loadConfig() (Config, error) {
+	config, err := NewConfig(WithLookupFunc(os.LookupEnv))
+	if err != nil {
+		return Config{}, err
 	}
+	return *config, nil
 }
 
-func displayConfig(config Config) {
-	fmt.Println("\n📋 Loaded Configuration:")
-	fmt.Println("------------------------")
+func displayConfig(out io.Writer, config Config, report *LoadReport) {
+	fmt.Fprintln(out, "\n📋 Loaded Configuration:")
+	fmt.Fprintln(out, "------------------------")
 
 	// Database
-	fmt.Printf("🗄️  Database:\n")
-	fmt.Printf("   Host: %s:%d\n", config.DBHost, config.DBPort)
-	fmt.Printf("   User: %s\n", config.DBUser)
-	fmt.Printf("   Password: %s\n", maskPassword(config.DBPassword))
-	fmt.Printf("   Database: %s\n", config.DBName)
-	fmt.Printf("   SSL Mode: %s\n", config.DBSSLMode)
+	fmt.Fprintf(out, "🗄️  Database:\n")
+	fmt.Fprintf(out, "   Host: %s:%d%s\n", config.Database.Host, config.Database.Port, report.annotate("DB_HOST", "DB_PORT"))
+	fmt.Fprintf(out, "   User: %s%s\n", config.Database.User, report.annotate("DB_USER"))
+	fmt.Fprintf(out, "   Password: %s%s\n", maskPassword(config.Database.Password)+report.decrypted("DB_PASSWORD"), report.annotate("DB_PASSWORD"))
+	fmt.Fprintf(out, "   Database: %s%s\n", config.Database.Name, report.annotate("DB_NAME"))
+	fmt.Fprintf(out, "   SSL Mode: %s%s\n", config.Database.SSLMode, report.annotate("DB_SSL_MODE"))
 
 	// Server
-	fmt.Printf("\n🌐 Server:\n")
-	fmt.Printf("   Address: %s:%d\n", config.ServerHost, config.ServerPort)
-	fmt.Printf("   Debug Mode: %t\n", config.DebugMode)
+	fmt.Fprintf(out, "\n🌐 Server:\n")
+	fmt.Fprintf(out, "   Address: %s:%d%s\n", config.ServerHost, config.ServerPort, report.annotate("SERVER_HOST", "SERVER_PORT"))
+	fmt.Fprintf(out, "   Debug Mode: %t%s\n", config.DebugMode, report.annotate("DEBUG_MODE"))
+	fmt.Fprintf(out, "   Timeouts: read %s, write %s%s\n", config.ReadTimeout, config.WriteTimeout, report.annotate("SERVER_READ_TIMEOUT", "SERVER_WRITE_TIMEOUT"))
+	fmt.Fprintf(out, "   Public URL: %s%s\n", config.PublicURL, report.annotate("PUBLIC_URL"))
+	fmt.Fprintf(out, "   Allowed Origins: %s%s\n", strings.Join(config.AllowedOrigins, ", "), report.annotate("CORS_ALLOWED_ORIGINS"))
 
 	// Security
-	fmt.Printf("\n🔐 Security:\n")
-	fmt.Printf("   JWT Secret: %s\n", maskSecret(config.JWTSecret))
-	fmt.Printf("   API Key: %s\n", maskSecret(config.APIKey))
+	fmt.Fprintf(out, "\n🔐 Security:\n")
+	fmt.Fprintf(out, "   JWT Secret: %s%s\n", maskSecret(config.JWTSecret)+report.decrypted("JWT_SECRET"), report.annotate("JWT_SECRET"))
+	fmt.Fprintf(out, "   API Key: %s%s\n", maskSecret(config.APIKey)+report.decrypted("API_KEY"), report.annotate("API_KEY"))
 
 	// Email
 	if config.SMTPHost != "" {
-		fmt.Printf("\n📧 Email:\n")
-		fmt.Printf("   SMTP: %s:%d\n", config.SMTPHost, config.SMTPPort)
-		fmt.Printf("   From: %s\n", config.EmailFrom)
+		fmt.Fprintf(out, "\n📧 Email:\n")
+		fmt.Fprintf(out, "   SMTP: %s:%d%s\n", config.SMTPHost, config.SMTPPort, report.annotate("SMTP_HOST", "SMTP_PORT"))
+		fmt.Fprintf(out, "   From: %s%s\n", config.EmailFrom, report.annotate("EMAIL_FROM"))
 	}
 
 	// Redis
-	fmt.Printf("\n🔴 Redis:\n")
-	fmt.Printf("   Address: %s:%d\n", config.RedisHost, config.RedisPort)
-	fmt.Printf("   Password: %s\n", maskPassword(config.RedisPassword))
+	fmt.Fprintf(out, "\n🔴 Redis:\n")
+	fmt.Fprintf(out, "   Address: %s:%d%s\n", config.Redis.Host, config.Redis.Port, report.annotate("REDIS_HOST", "REDIS_PORT"))
+	fmt.Fprintf(out, "   Password: %s%s\n", maskPassword(config.Redis.Password)+report.decrypted("REDIS_PASSWORD"), report.annotate("REDIS_PASSWORD"))
 
 	// App
-	fmt.Printf("\n📱 Application:\n")
-	fmt.Printf("   Name: %s\n", config.AppName)
-	fmt.Printf("   Version: %s\n", config.AppVersion)
-	fmt.Printf("   Log Level: %s\n", config.LogLevel)
+	fmt.Fprintf(out, "\n📱 Application:\n")
+	fmt.Fprintf(out, "   Environment: %s%s\n", config.AppEnv, report.annotate("APP_ENV"))
+	fmt.Fprintf(out, "   Name: %s%s\n", config.AppName, report.annotate("APP_NAME"))
+	fmt.Fprintf(out, "   Version: %s%s\n", config.AppVersion, report.annotate("APP_VERSION"))
+	fmt.Fprintf(out, "   Log Level: %s%s\n", config.LogLevel, report.annotate("LOG_LEVEL"))
+	fmt.Fprintf(out, "   Feature Flags: %v%s\n", config.FeatureFlags, report.annotate("FEATURE_FLAGS"))
 }
 
-func demonstrateUsage() {
-	fmt.Println("\n🔍 Environment Variable Access Methods:")
-	fmt.Println("---------------------------------------")
+func demonstrateUsage(out io.Writer) {
+	fmt.Fprintln(out, "\n🔍 Environment Variable Access Methods:")
+	fmt.Fprintln(out, "---------------------------------------")
 
 	// Method 1: Direct os.Getenv
 	dbHost := os.Getenv("DB_HOST")
-	fmt.Printf("1. os.Getenv(\"DB_HOST\"): '%s'\n", dbHost)
+	fmt.Fprintf(out, "1. os.Getenv(\"DB_HOST\"): '%s'\n", dbHost)
 
 	// Method 2: With default value
 	port := getEnv("SERVER_PORT", "3000")
-	fmt.Printf("2. With default: SERVER_PORT = '%s'\n", port)
+	fmt.Fprintf(out, "2. With default: SERVER_PORT = '%s'\n", port)
 
 	// Method 3: As integer
 	dbPort := getEnvAsInt("DB_PORT", 5432)
-	fmt.Printf("3. As integer: DB_PORT = %d\n", dbPort)
+	fmt.Fprintf(out, "3. As integer: DB_PORT = %d\n", dbPort)
 
 	// Method 4: As boolean
 	debug := getEnvAsBool("DEBUG_MODE", false)
-	fmt.Printf("4. As boolean: DEBUG_MODE = %t\n", debug)
+	fmt.Fprintf(out, "4. As boolean: DEBUG_MODE = %t\n", debug)
 
 	// Method 5: Check if variable exists
 	if value, exists := os.LookupEnv("API_KEY"); exists {
-		fmt.Printf("5. API_KEY exists: '%s'\n", maskSecret(value))
+		fmt.Fprintf(out, "5. API_KEY exists: '%s'\n", maskSecret(value))
 	} else {
-		fmt.Printf("5. API_KEY not set\n")
+		fmt.Fprintf(out, "5. API_KEY not set\n")
 	}
-}
 
-func createDatabaseURL(config Config) {
-	fmt.Println("\n🔗 Database Connection Examples:")
-	fmt.Println("---------------------------------")
-
-	// PostgreSQL connection string
-	postgresURL := fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=%s",
-		config.DBUser,
-		config.DBPassword,
-		config.DBHost,
-		config.DBPort,
-		config.DBName,
-		config.DBSSLMode,
-	)
+	// Method 6: As duration
+	timeout := getEnvAsDuration("SERVER_READ_TIMEOUT", 15*time.Second)
+	fmt.Fprintf(out, "6. As duration: SERVER_READ_TIMEOUT = %s\n", timeout)
 
-	// GORM DSN
-	gormDSN := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=%s",
-		config.DBHost,
-		config.DBUser,
-		config.DBPassword,
-		config.DBName,
-		config.DBPort,
-		config.DBSSLMode,
-	)
+	// Method 7: As float
+	sampleRate := getEnvAsFloat("SAMPLE_RATE", 0.1)
+	fmt.Fprintf(out, "7. As float: SAMPLE_RATE = %g\n", sampleRate)
 
-	fmt.Printf("PostgreSQL URL: %s\n", maskConnectionString(postgresURL))
-	fmt.Printf("GORM DSN: %s\n", maskConnectionString(gormDSN))
-}
+	// Method 8: As slice
+	origins := getEnvAsSlice("CORS_ALLOWED_ORIGINS", ",", nil)
+	fmt.Fprintf(out, "8. As slice: CORS_ALLOWED_ORIGINS = %q\n", origins)
 
-// Helper functions
-func getEnv(key, defaultVal string) string {
-	if value, exists := os.LookupEnv(key); exists {
-		return value
-	}
-	return defaultVal
-}
+	// Method 9: As map
+	flags := getEnvAsMap("FEATURE_FLAGS", map[string]string{})
+	fmt.Fprintf(out, "9. As map: FEATURE_FLAGS = %v\n", flags)
 
-func getEnvAsInt(name string, defaultVal int) int {
-	valueStr := getEnv(name, "")
-	if value, err := strconv.Atoi(valueStr); err == nil {
-		return value
-	}
-	return defaultVal
-}
+	// Method 10: As validated URL
+	publicURL := getEnvAsURL("PUBLIC_URL", &url.URL{Scheme: "http", Host: "localhost:8080"})
+	fmt.Fprintf(out, "10. As URL: PUBLIC_URL = %s (host %s)\n", publicURL, publicURL.Host)
 
-func getEnvAsBool(name string, defaultVal bool) bool {
-	valStr := getEnv(name, "")
-	if val, err := strconv.ParseBool(valStr); err == nil {
-		return val
-	}
-	return defaultVal
-}
-
-func maskPassword(password string) string {
-	if password == "" {
-		return "<empty>"
-	}
-	if len(password) <= 4 {
-		return strings.Repeat("*", len(password))
-	}
-	return password[:2] + strings.Repeat("*", len(password)-4) + password[len(password)-2:]
+	// Method 11: Secret from the variable or a mounted file
+	jwtSecret := getEnvSecret("JWT_SECRET", "")
+	fmt.Fprintf(out, "11. Secret (JWT_SECRET or JWT_SECRET_FILE): '%s'\n", maskSecret(jwtSecret))
 }
 
-func maskSecret(secret string) string {
-	if secret == "" {
-		return "<not set>"
-	}
-	if len(secret) <= 8 {
-		return strings.Repeat("*", len(secret))
-	}
-	return secret[:4] + strings.Repeat("*", len(secret)-8) + secret[len(secret)-4:]
-}
+func createDatabaseURL(out io.Writer, config Config) {
+	fmt.Fprintln(out, "\n🔗 Database Connection Examples:")
+	fmt.Fprintln(out, "---------------------------------")
 
-func maskConnectionString(connStr string) string {
-	// Simple password masking in connection strings
-	if strings.Contains(connStr, "password=") {
-		parts := strings.Split(connStr, " ")
-		for i, part := range parts {
-			if strings.HasPrefix(part, "password=") {
-				passwordPart := strings.Split(part, "=")
-				if len(passwordPart) == 2 {
-					parts[i] = "password=" + maskPassword(passwordPart[1])
-				}
-			}
-		}
-		return strings.Join(parts, " ")
-	}
+	fmt.Fprintf(out, "PostgreSQL URL: %s\n", maskConnectionString(config.PostgresURL()))
+	fmt.Fprintf(out, "GORM DSN: %s\n", maskConnectionString(config.GormDSN()))
 
-	// For postgres:// URLs
-	if strings.Contains(connStr, "://") && strings.Contains(connStr, ":") {
-		// This is a basic implementation - in production use proper URL parsing
-		return strings.ReplaceAll(connStr, strings.Split(strings.Split(connStr, "://")[1], "@")[0], "***:***")
+	// Or one variable whose ${VAR} references were expanded at load time
+	if config.DatabaseURL != "" {
+		fmt.Fprintf(out, "DATABASE_URL (expanded): %s\n", maskConnectionString(config.DatabaseURL))
+	} else {
+		fmt.Fprintln(out, "DATABASE_URL: <not set>")
 	}
-
-	return connStr
 }
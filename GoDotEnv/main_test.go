@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// execute runs the CLI with args as main does, returning what it wrote to
+// stdout and stderr
+func execute(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	root := newRootCommand()
+	var out bytes.Buffer
+	root.SetOut(&out)
+	root.SetErr(&out)
+	root.SetIn(strings.NewReader("\n"))
+	root.SetArgs(args)
+	err := root.Execute()
+	return out.String(), err
+}
+
+func TestHelpListsCommands(t *testing.T) {
+	out, err := execute(t, "--help")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"print", "check", "generate-example", "export", "set", "watch", "audit", "encrypt", "--env ", "--env-file", "--key-file"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("--help does not mention %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "completion") {
+		t.Errorf("--help lists the completion command:\n%s", out)
+	}
+
+	// Each command documents its own flags
+	for cmd, flags := range map[string][]string{
+		"print":            {"--verbose"},
+		"check":            {"--example", "exits 0"},
+		"generate-example": {"--output"},
+		"export":           {"--format", "--show-secrets", "--only", "--output"},
+		"set":              {"KEY=VALUE"},
+		"watch":            {"last good"},
+		"audit":            {"--fail-on"},
+		"encrypt":          {"--generate-key"},
+	} {
+		out, err := execute(t, cmd, "--help")
+		if err != nil {
+			t.Fatalf("%s --help: %v", cmd, err)
+		}
+		for _, want := range append(flags, "--env-file") {
+			if !strings.Contains(out, want) {
+				t.Errorf("%s --help does not mention %q:\n%s", cmd, want, out)
+			}
+		}
+	}
+}
+
+func TestUsageErrors(t *testing.T) {
+	for _, args := range [][]string{{"--no-such-flag"}, {"print", "extra"}, {"frobnicate"}, {"export", "--format"}} {
+		if _, err := execute(t, args...); err == nil {
+			t.Errorf("%q succeeded", args)
+		}
+	}
+	_, err := execute(t, "print", "--no-such-flag")
+	if err == nil || !strings.Contains(err.Error(), "Run 'godotenv-demo print --help' for usage") {
+		t.Errorf("bad flag: %v", err)
+	}
+}
+
+func TestPrintCommand(t *testing.T) {
+	unsetEnv(t, "APP_ENV", "DB_HOST", "DB_PORT", "DB_PASSWORD", "LOG_LEVEL")
+	writeEnvFiles(t, map[string]string{
+		".env":             "DB_HOST=from-env\nDB_PASSWORD=database-password\n",
+		".env.development": "LOG_LEVEL=debug\n",
+		"other.env":        "DB_HOST=from-other\n",
+	})
+
+	out, err := execute(t, "print")
+	if err != nil {
+		t.Fatalf("print: %v", err)
+	}
+	for _, want := range []string{"✅ Successfully loaded env files", ".env.development         1 loaded", "Host: from-env:5432  [.env, default]", "Log Level: debug  [.env.development]", "Password: da*************rd"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("print output is missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "database-password") || strings.Contains(out, "Access Methods") {
+		t.Errorf("print output:\n%s", out)
+	}
+}
+
+func TestPrintVerboseAndRoot(t *testing.T) {
+	for _, args := range [][]string{{"print", "--verbose"}, {"print", "-v"}, nil} {
+		unsetEnv(t, "APP_ENV", "DB_HOST", "DB_PASSWORD")
+		writeEnvFiles(t, map[string]string{".env": "DB_HOST=db\nDB_PASSWORD=database-password\n"})
+		out, err := execute(t, args...)
+		if err != nil {
+			t.Fatalf("%q: %v", args, err)
+		}
+		for _, want := range []string{"📋 Loaded Configuration", "1. os.Getenv(\"DB_HOST\"): 'db'", "PostgreSQL URL: postgres://postgres:da*************rd@db:5432/testdb"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("%q output is missing %q:\n%s", args, want, out)
+			}
+		}
+	}
+}
+
+func TestSharedEnvFlags(t *testing.T) {
+	unsetEnv(t, "APP_ENV", "DB_HOST", "LOG_LEVEL")
+	writeEnvFiles(t, map[string]string{
+		".env":            "DB_HOST=from-env\n",
+		".env.production": "DB_HOST=from-production\n",
+		"a.env":           "DB_HOST=from-a\nLOG_LEVEL=warn\n",
+		"b.env":           "DB_HOST=from-b\n",
+	})
+
+	// --env picks the layered files
+	out, err := execute(t, "print", "--env", "production")
+	if err != nil || !strings.Contains(out, "Host: from-production:5432  [.env.production, default]") || !strings.Contains(out, "APP_ENV=production") {
+		t.Errorf("--env production: %v\n%s", err, out)
+	}
+
+	// --env-file replaces them, the last given winning
+	unsetEnv(t, "DB_HOST")
+	out, err = execute(t, "--env-file", "a.env", "--env-file", "b.env", "print")
+	if err != nil || !strings.Contains(out, "Host: from-b:5432  [b.env, default]") || !strings.Contains(out, "Log Level: warn  [a.env]") || strings.Contains(out, ".env.local") {
+		t.Errorf("--env-file: %v\n%s", err, out)
+	}
+
+	// Invalid values fail with every problem listed
+	unsetEnv(t, "DB_HOST", "LOG_LEVEL")
+	writeEnvFiles(t, map[string]string{".env": "DB_HOST=bad_host\nLOG_LEVEL=loud\n"})
+	_, err = execute(t, "print")
+	if err == nil || !strings.Contains(err.Error(), "DB_HOST") || !strings.Contains(err.Error(), "LOG_LEVEL") {
+		t.Errorf("invalid values: %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// composeEnvironment is a docker-compose service's environment: block.
+// Compose expands $ in it, so each literal $ is doubled, and every value
+// is a string, as Compose wants.
+func composeEnvironment(vars []variable) ([]byte, error) {
+	env := &yaml.Node{Kind: yaml.MappingNode}
+	for _, v := range vars {
+		env.Content = append(env.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: v.Key},
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: strings.ReplaceAll(v.Value, "$", "$$")},
+		)
+	}
+	root := &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+		{Kind: yaml.ScalarNode, Value: "environment"},
+		env,
+	}}
+	return encodeYAML(root)
+}
+
+// composeEnvFile is a docker-compose env_file: a KEY=value line for each
+// variable, with nothing for Compose to expand
+func composeEnvFile(vars []variable) []byte {
+	var buf bytes.Buffer
+	for _, v := range vars {
+		fmt.Fprintf(&buf, "%s=%s\n", v.Key, composeQuote(v.Value))
+	}
+	return buf.Bytes()
+}
+
+// composeQuote writes a value for an env_file: bare when it is plain, in
+// single quotes, which Compose takes literally, when it can be, and
+// otherwise in double quotes with escapes and each $ doubled
+func composeQuote(s string) string {
+	if strings.Trim(s, plainValueChars) == "" {
+		return s
+	}
+	if !strings.ContainsAny(s, "'\n") {
+		return "'" + s + "'"
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "$", "$$").Replace(s) + `"`
+}
+
+// kubernetesName is what Kubernetes accepts as a ConfigMap or Secret name
+var kubernetesName = regexp.MustCompile(`^[a-z0-9]([-a-z0-9.]*[a-z0-9])?$`)
+
+// kubernetesObject is the part of a ConfigMap or Secret manifest export
+// writes
+type kubernetesObject struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Metadata   struct {
+		Name string `yaml:"name"`
+	} `yaml:"metadata"`
+	Type string `yaml:"type,omitempty"`
+	// Data is written with its keys sorted
+	Data map[string]string `yaml:"data"`
+}
+
+// kubernetesManifests are a ConfigMap named name-config for the variables
+// that aren't secret and a Secret named name-secret, with base64 values,
+// for those that are, as two YAML documents
+func kubernetesManifests(name string, vars []variable) ([]byte, error) {
+	if !kubernetesName.MatchString(name) || len(name) > 246 {
+		return nil, fmt.Errorf("%q is not a Kubernetes name: use lowercase letters, digits and hyphens", name)
+	}
+	config := kubernetesObject{APIVersion: "v1", Kind: "ConfigMap", Data: map[string]string{}}
+	config.Metadata.Name = name + "-config"
+	secret := kubernetesObject{APIVersion: "v1", Kind: "Secret", Type: "Opaque", Data: map[string]string{}}
+	secret.Metadata.Name = name + "-secret"
+	for _, v := range vars {
+		if v.Secret {
+			secret.Data[v.Key] = base64.StdEncoding.EncodeToString([]byte(v.Value))
+		} else {
+			config.Data[v.Key] = v.Value
+		}
+	}
+	return encodeYAML(config, secret)
+}
+
+// encodeYAML writes docs as YAML documents, indented by two spaces
+func encodeYAML(docs ...any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	for _, doc := range docs {
+		if err := enc.Encode(doc); err != nil {
+			return nil, err
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
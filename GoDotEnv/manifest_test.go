@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// manifestKeys are the variables the manifest tests set
+var manifestKeys = []string{"DB_HOST", "DB_PORT", "DB_PASSWORD", "SERVER_PORT", "DEBUG_MODE", "JWT_SECRET", "API_KEY", "APP_NAME", "LOG_LEVEL"}
+
+// setManifestEnv sets a mix of plain, secret and awkward values
+func setManifestEnv(t *testing.T) {
+	t.Helper()
+	unsetEnv(t, configKeys...)
+	unsetEnv(t, manifestKeys...)
+	t.Setenv("SERVER_PORT", "8080")
+	t.Setenv("DEBUG_MODE", "true")
+	t.Setenv("DB_HOST", "db")
+	t.Setenv("DB_PASSWORD", "pa$$ word")
+	t.Setenv("JWT_SECRET", "jwt-secret-value-123")
+	t.Setenv("APP_NAME", "It's a \"demo\"")
+	t.Setenv("LOG_LEVEL", "")
+}
+
+func TestExportCompose(t *testing.T) {
+	setManifestEnv(t)
+	data, err := exportConfig(exportSample(), exportOptions{Format: "compose", ShowSecrets: true})
+	if err != nil {
+		t.Fatalf("exportConfig: %v", err)
+	}
+	var got struct {
+		Environment yaml.Node `yaml:"environment"`
+	}
+	if err := yaml.Unmarshal(data, &got); err != nil {
+		t.Fatalf("output is not YAML: %v\n%s", err, data)
+	}
+	var keys []string
+	env := make(map[string]string)
+	for i := 0; i < len(got.Environment.Content); i += 2 {
+		key, value := got.Environment.Content[i], got.Environment.Content[i+1]
+		if value.Tag != "!!str" {
+			t.Errorf("%s is a %s, want a string", key.Value, value.Tag)
+		}
+		keys = append(keys, key.Value)
+		env[key.Value] = value.Value
+	}
+	if want := []string{"APP_NAME", "DB_HOST", "DB_PASSWORD", "DEBUG_MODE", "JWT_SECRET", "LOG_LEVEL", "SERVER_PORT"}; !slices.Equal(keys, want) {
+		t.Errorf("keys = %v, want %v", keys, want)
+	}
+	for key, want := range map[string]string{
+		"SERVER_PORT": "8080",
+		"DEBUG_MODE":  "true",
+		// Compose would expand a single $
+		"DB_PASSWORD": "pa$$$$ word",
+		"APP_NAME":    "It's a \"demo\"",
+		"LOG_LEVEL":   "",
+	} {
+		if env[key] != want {
+			t.Errorf("%s = %q, want %q", key, env[key], want)
+		}
+	}
+}
+
+func TestExportComposeEnvFile(t *testing.T) {
+	setManifestEnv(t)
+	t.Setenv("API_KEY", "line one\nit's line two")
+	data, err := exportConfig(exportSample(), exportOptions{Format: "env-file", ShowSecrets: true})
+	if err != nil {
+		t.Fatalf("exportConfig: %v", err)
+	}
+	want := strings.Join([]string{
+		`API_KEY="line one\nit's line two"`,
+		`APP_NAME="It's a \"demo\""`,
+		`DB_HOST=db`,
+		`DB_PASSWORD='pa$$ word'`,
+		`DEBUG_MODE=true`,
+		`JWT_SECRET=jwt-secret-value-123`,
+		`LOG_LEVEL=`,
+		`SERVER_PORT=8080`,
+	}, "\n") + "\n"
+	if string(data) != want {
+		t.Errorf("env-file output:\n%s\nwant:\n%s", data, want)
+	}
+
+	// Without --show-secrets, the secrets are masked like everywhere else
+	data, err = exportConfig(exportSample(), exportOptions{Format: "env-file"})
+	if err != nil || strings.Contains(string(data), "jwt-secret-value") || !strings.Contains(string(data), "JWT_SECRET='"+maskSecret("jwt-secret-value-123")+"'") {
+		t.Errorf("masked env-file output: %v\n%s", err, data)
+	}
+}
+
+func TestExportKubernetes(t *testing.T) {
+	setManifestEnv(t)
+	data, err := exportConfig(exportSample(), exportOptions{Format: "k8s", ShowSecrets: true, Name: "demo-app"})
+	if err != nil {
+		t.Fatalf("exportConfig: %v", err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	var docs []kubernetesObject
+	for {
+		var doc kubernetesObject
+		if err := dec.Decode(&doc); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			t.Fatalf("output is not YAML: %v\n%s", err, data)
+		}
+		docs = append(docs, doc)
+	}
+	if len(docs) != 2 {
+		t.Fatalf("got %d documents, want 2:\n%s", len(docs), data)
+	}
+	config, secret := docs[0], docs[1]
+	if config.APIVersion != "v1" || config.Kind != "ConfigMap" || config.Metadata.Name != "demo-app-config" {
+		t.Errorf("ConfigMap header = %+v", config)
+	}
+	if secret.Kind != "Secret" || secret.Type != "Opaque" || secret.Metadata.Name != "demo-app-secret" {
+		t.Errorf("Secret header = %+v", secret)
+	}
+
+	// Secret fields go to the Secret, base64 encoded, and the rest to the
+	// ConfigMap as they are
+	if config.Data["SERVER_PORT"] != "8080" || config.Data["DB_HOST"] != "db" || config.Data["APP_NAME"] != "It's a \"demo\"" {
+		t.Errorf("ConfigMap data = %v", config.Data)
+	}
+	if _, ok := config.Data["JWT_SECRET"]; ok {
+		t.Error("JWT_SECRET is in the ConfigMap")
+	}
+	if _, ok := secret.Data["SERVER_PORT"]; ok {
+		t.Error("SERVER_PORT is in the Secret")
+	}
+	for key, want := range map[string]string{"JWT_SECRET": "jwt-secret-value-123", "DB_PASSWORD": "pa$$ word"} {
+		got, err := base64.StdEncoding.DecodeString(secret.Data[key])
+		if err != nil || string(got) != want {
+			t.Errorf("Secret %s = %q (%v), want %q", key, got, err, want)
+		}
+	}
+
+	// The keys are sorted, so the same environment gives the same file
+	if !strings.Contains(string(data), "data:\n  APP_NAME:") || strings.Index(string(data), "  DB_HOST:") > strings.Index(string(data), "  SERVER_PORT:") {
+		t.Errorf("keys out of order:\n%s", data)
+	}
+	again, _ := exportConfig(exportSample(), exportOptions{Format: "k8s", ShowSecrets: true, Name: "demo-app"})
+	if !bytes.Equal(data, again) {
+		t.Error("two exports differ")
+	}
+
+	for _, name := range []string{"Demo", "demo_app", "-demo", ""} {
+		if _, err := kubernetesManifests(name, nil); err == nil {
+			t.Errorf("name %q accepted", name)
+		}
+	}
+}
+
+func TestExportManifestCommand(t *testing.T) {
+	unsetEnv(t, configKeys...)
+	unsetEnv(t, manifestKeys...)
+	writeEnvFiles(t, map[string]string{".env": "SERVER_PORT=9090\nJWT_SECRET=jwt-secret-value-123\n"})
+	out, err := execute(t, "export", "--format", "k8s", "--name", "shop", "--show-secrets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded := base64.StdEncoding.EncodeToString([]byte("jwt-secret-value-123"))
+	if !strings.Contains(out, "name: shop-config\ndata:\n  SERVER_PORT: \"9090\"\n") || !strings.Contains(out, "  JWT_SECRET: "+encoded+"\n") {
+		t.Errorf("export --format k8s:\n%s", out)
+	}
+	if _, err := execute(t, "export", "--format", "k8s", "--name", "Not_Valid"); err == nil {
+		t.Error("an invalid --name succeeded")
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import "testing"
+
+func TestMaskPasswordAndSecret(t *testing.T) {
+	tests := []struct {
+		value, password, secret string
+	}{
+		{"", "<empty>", "<not set>"},
+		{"a", "*", "*"},
+		{"abcd", "****", "****"},
+		{"abcde", "ab*de", "*****"},
+		{"abcdefgh", "ab****gh", "********"},
+		{"abcdefghi", "ab*****hi", "abcd*fghi"},
+		// Multibyte characters count once and are never cut in half
+		{"pässwörd", "pä****rd", "********"},
+		{"🔐🔐🔐🔐🔐", "🔐🔐*🔐🔐", "*****"},
+		{"ünïcödé-sécret", "ün**********et", "ünïc******cret"},
+	}
+	for _, tt := range tests {
+		if got := maskPassword(tt.value); got != tt.password {
+			t.Errorf("maskPassword(%q) = %q, want %q", tt.value, got, tt.password)
+		}
+		if got := maskSecret(tt.value); got != tt.secret {
+			t.Errorf("maskSecret(%q) = %q, want %q", tt.value, got, tt.secret)
+		}
+	}
+}
+
+func TestMaskConnectionString(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"url", "postgres://app:secret99@db:5432/shop?sslmode=disable", "postgres://app:se****99@db:5432/shop?sslmode=disable"},
+		// These used to be mangled: the whole address replaced, or the
+		// user and password replaced wherever else they appeared
+		{"url without credentials", "postgres://localhost:5432/shop", "postgres://localhost:5432/shop"},
+		{"url with only a user", "postgres://app@db/shop", "postgres://app@db/shop"},
+		{"url with no path", "redis://:cachepass@cache:6379", "redis://:ca*****ss@cache:6379"},
+		{"credentials repeated", "postgres://db:db@db/db", "postgres://db:**@db/db"},
+		{"@ in the password", "postgres://app:p@ss@word@db/shop", "postgres://app:p@*****rd@db/shop"},
+		{"escaped password", "postgres://app:p%40ss%2Fword@db/shop", "postgres://app:p%*********rd@db/shop"},
+		{"@ after the host", "https://example.com/users/@me", "https://example.com/users/@me"},
+		{"password in the query", "postgres://db/shop?user=app&password=secret99&sslmode=require", "postgres://db/shop?user=app&password=se****99&sslmode=require"},
+		{"dsn", "host=db user=app password=secret99 dbname=shop", "host=db user=app password=se****99 dbname=shop"},
+		{"dsn with = in the password", "host=db password=a=b=c=d port=5432", "host=db password=a=***=d port=5432"},
+		{"dsn at the start", "password=secret99", "password=se****99"},
+		{"dsn with a quoted password", `host=db password='it\'s a secret' port=5432`, "host=db password=it*********et port=5432"},
+		{"dsn keeping the quotes", "password='a secret value' port=5432", "password='a **********ue' port=5432"},
+		{"dsn with an empty password", "host=db password= port=5432", "host=db password=<empty> port=5432"},
+		{"dsn with an unclosed quote", "host=db password='opened", "host=db password=op**ed"},
+		{"not a password parameter", "host=db db_password=x", "host=db db_password=x"},
+		{"nothing to mask", "just some text", "just some text"},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := maskConnectionString(tt.in); got != tt.want {
+				t.Errorf("maskConnectionString(%q)\n = %q\nwant %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/joho/godotenv"
+)
+
+// SaveOptions control how SaveEnv writes
+type SaveOptions struct {
+	// Merge updates the keys in an existing file and appends new ones,
+	// leaving every other line as it was, rather than replacing the file
+	Merge bool
+	// Atomic writes a temporary file beside path and renames it into
+	// place, so readers see the old file or the new one, never half
+	Atomic bool
+	// Perm is the mode of a new file; 0 means 0600, since env files hold
+	// secrets. An existing file keeps its mode.
+	Perm fs.FileMode
+}
+
+// SaveEnv writes values to the env file at path, formatted by
+// godotenv.Marshal so values with spaces, quotes or newlines are quoted
+// and escaped. Without Merge the file holds just values, sorted by key.
+// With Merge, comments, blank lines, key order and entries whose value
+// doesn't change are kept byte for byte; an updated entry keeps its
+// export prefix but loses any comment at the end of its line.
+func SaveEnv(path string, values map[string]string, opts SaveOptions) error {
+	perm := opts.Perm
+	if perm == 0 {
+		perm = 0o600
+	}
+	existing, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if info, err := os.Stat(path); err == nil {
+			perm = info.Mode().Perm()
+		}
+	case errors.Is(err, fs.ErrNotExist):
+		existing = nil
+	default:
+		return err
+	}
+
+	var content string
+	if opts.Merge && existing != nil {
+		content, err = mergeEnv(string(existing), values)
+	} else {
+		content, err = godotenv.Marshal(values)
+		if content != "" {
+			content += "\n"
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if !opts.Atomic {
+		return os.WriteFile(path, []byte(content), perm)
+	}
+	return writeFileAtomic(path, []byte(content), perm)
+}
+
+// marshalEntry is key=value as godotenv.Marshal writes it
+func marshalEntry(key, value string) (string, error) {
+	return godotenv.Marshal(map[string]string{key: value})
+}
+
+// mergeEnv rewrites the entries in src that values sets and appends the
+// rest of values, in key order
+func mergeEnv(src string, values map[string]string) (string, error) {
+	var b strings.Builder
+	seen := make(map[string]bool)
+	for _, entry := range splitEnvEntries(src) {
+		value, ok := values[entry.key]
+		if ok {
+			seen[entry.key] = true
+		}
+		// An entry that already holds the value is left as it was written
+		if current, err := godotenv.Unmarshal(entry.text); !ok || (err == nil && current[entry.key] == value) {
+			b.WriteString(entry.text)
+			continue
+		}
+		line, err := marshalEntry(entry.key, value)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(entry.export + line + entry.newline)
+	}
+
+	var added []string
+	for key := range values {
+		if !seen[key] {
+			added = append(added, key)
+		}
+	}
+	slices.Sort(added)
+	newline := "\n"
+	if strings.Contains(src, "\r\n") {
+		newline = "\r\n"
+	}
+	if len(added) > 0 && src != "" && !strings.HasSuffix(src, "\n") {
+		b.WriteString(newline)
+	}
+	for _, key := range added {
+		line, err := marshalEntry(key, values[key])
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(line + newline)
+	}
+	return b.String(), nil
+}
+
+// envEntry is a piece of an env file: either one variable, which can run
+// over several lines when its value is quoted, or a line with no
+// variable, such as a comment, which has an empty key
+type envEntry struct {
+	text    string
+	key     string
+	export  string
+	newline string
+}
+
+// splitEnvEntries cuts src into entries that join back into src exactly.
+// It reads keys the way godotenv does, as far as KEY=, KEY: or
+// export KEY=, and follows a quoted value to its closing quote.
+func splitEnvEntries(src string) []envEntry {
+	var entries []envEntry
+	for src != "" {
+		line, rest := cutLine(src)
+		entry := envEntry{text: line}
+		body := strings.TrimLeft(line, " \t")
+		if body != "" && !strings.HasPrefix(body, "#") {
+			if after, ok := strings.CutPrefix(body, "export "); ok {
+				entry.export = "export "
+				body = strings.TrimLeft(after, " \t")
+			}
+			if i := strings.IndexAny(body, "=:"); i > 0 {
+				entry.key = strings.TrimSpace(body[:i])
+				value := strings.TrimLeft(body[i+1:], " \t")
+				if value != "" && (value[0] == '"' || value[0] == '\'') {
+					// Take lines until the value's closing quote
+					for !hasClosingQuote(value[1:], value[0]) && rest != "" {
+						var next string
+						next, rest = cutLine(rest)
+						entry.text += next
+						value += next
+					}
+				}
+			}
+		}
+		entry.newline = trailingNewline(entry.text)
+		entries = append(entries, entry)
+		src = rest
+	}
+	return entries
+}
+
+// cutLine splits off src's first line, with its line ending
+func cutLine(src string) (line, rest string) {
+	if i := strings.IndexByte(src, '\n'); i >= 0 {
+		return src[:i+1], src[i+1:]
+	}
+	return src, ""
+}
+
+// trailingNewline is the line ending text finishes with, if any
+func trailingNewline(text string) string {
+	switch {
+	case strings.HasSuffix(text, "\r\n"):
+		return "\r\n"
+	case strings.HasSuffix(text, "\n"):
+		return "\n"
+	}
+	return ""
+}
+
+// hasClosingQuote reports whether s contains quote without a backslash
+// before it, which is how godotenv finds the end of a quoted value
+func hasClosingQuote(s string, quote byte) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] == quote && (i == 0 || s[i-1] != '\\') {
+			return true
+		}
+	}
+	return false
+}
+
+// writeFileAtomic writes data to a temporary file in path's directory and
+// renames it over path
+func writeFileAtomic(path string, data []byte, perm fs.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // a no-op once it has been renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("replacing %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/joho/godotenv"
+)
+
+// sampleEnv has comments, blank lines, export prefixes, quoting styles
+// and a value over several lines, with keys out of order
+const sampleEnv = `# Database
+export DB_HOST=localhost # the local one
+DB_PORT=5432
+
+# A certificate over several lines
+CERT="-----BEGIN-----
+abc=def
+-----END-----"
+  SINGLE='single: quoted'
+export   PASSWORD="p\"w"
+APP_NAME: demo
+# trailing comment`
+
+func writeSample(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte(content), 0o640); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestSplitEnvEntries(t *testing.T) {
+	entries := splitEnvEntries(sampleEnv)
+	var joined strings.Builder
+	var keys []string
+	for _, e := range entries {
+		joined.WriteString(e.text)
+		if e.key != "" {
+			keys = append(keys, e.export+e.key)
+		}
+	}
+	if joined.String() != sampleEnv {
+		t.Errorf("entries don't join back into the file:\n%s", joined.String())
+	}
+	want := "export DB_HOST|DB_PORT|CERT|SINGLE|export PASSWORD|APP_NAME"
+	if got := strings.Join(keys, "|"); got != want {
+		t.Errorf("keys = %s, want %s", got, want)
+	}
+}
+
+func TestSaveEnvMerge(t *testing.T) {
+	for _, newline := range []string{"\n", "\r\n"} {
+		src := strings.ReplaceAll(sampleEnv, "\n", newline) + newline
+		path := writeSample(t, src)
+		values := map[string]string{
+			"DB_PORT":  "6543",
+			"CERT":     "new\nmultiline \"cert\" value",
+			"PASSWORD": "with spaces and $dollar",
+			"APP_NAME": "demo", // unchanged, so left as written
+			"ZED":      "last",
+			"ADDED":    "first new",
+		}
+		if err := SaveEnv(path, values, SaveOptions{Merge: true}); err != nil {
+			t.Fatalf("SaveEnv: %v", err)
+		}
+
+		got := readFile(t, path)
+		want := strings.Join([]string{
+			"# Database",
+			"export DB_HOST=localhost # the local one",
+			"DB_PORT=6543",
+			"",
+			"# A certificate over several lines",
+			`CERT="new\nmultiline \"cert\" value"`,
+			"  SINGLE='single: quoted'",
+			`export PASSWORD="with spaces and \$dollar"`,
+			"APP_NAME: demo",
+			"# trailing comment",
+			`ADDED="first new"`,
+			`ZED="last"`,
+			"",
+		}, newline)
+		if got != want {
+			t.Errorf("merged file with %q endings:\n%s\nwant:\n%s", newline, got, want)
+		}
+
+		// godotenv reads back every value, old and new
+		read, err := godotenv.Read(path)
+		if err != nil {
+			t.Fatalf("reading the merged file: %v", err)
+		}
+		for key, value := range values {
+			if read[key] != value {
+				t.Errorf("%s read back as %q, want %q", key, read[key], value)
+			}
+		}
+		if read["DB_HOST"] != "localhost" || read["SINGLE"] != "single: quoted" {
+			t.Errorf("untouched values read back as %q, %q", read["DB_HOST"], read["SINGLE"])
+		}
+	}
+}
+
+func TestSaveEnvMergeKeepsUntouchedLines(t *testing.T) {
+	path := writeSample(t, sampleEnv)
+	if err := SaveEnv(path, map[string]string{"DB_PORT": "1"}, SaveOptions{Merge: true}); err != nil {
+		t.Fatal(err)
+	}
+	got := readFile(t, path)
+	if want := strings.Replace(sampleEnv, "DB_PORT=5432", "DB_PORT=1", 1); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Setting a value the file already has changes nothing at all
+	before := got
+	if err := SaveEnv(path, map[string]string{"DB_HOST": "localhost", "CERT": "-----BEGIN-----\nabc=def\n-----END-----"}, SaveOptions{Merge: true}); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, path); got != before {
+		t.Errorf("unchanged values rewrote the file:\n%s", got)
+	}
+}
+
+func TestSaveEnvRoundTripsThroughLoadLayered(t *testing.T) {
+	values := map[string]string{
+		"PLAIN":     "value",
+		"SPACES":    "hello world",
+		"MULTILINE": "one\ntwo",
+		"QUOTES":    `say "hi" and 'bye'`,
+		"DOLLAR":    "cost $5 ${NOT_A_REF}",
+		"NUMBER":    "42",
+	}
+	unsetEnv(t, "PLAIN", "SPACES", "MULTILINE", "QUOTES", "DOLLAR", "NUMBER")
+	writeEnvFiles(t, nil)
+	if err := SaveEnv(".env", values, SaveOptions{}); err != nil {
+		t.Fatalf("SaveEnv: %v", err)
+	}
+	if _, err := LoadLayered("development"); err != nil {
+		t.Fatalf("LoadLayered: %v", err)
+	}
+	for key, want := range values {
+		if got := os.Getenv(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestSaveEnvReplace(t *testing.T) {
+	path := writeSample(t, sampleEnv)
+	if err := SaveEnv(path, map[string]string{"B": "2 3", "A": "1"}, SaveOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, path); got != "A=1\nB=\"2 3\"\n" {
+		t.Errorf("replaced file = %q", got)
+	}
+}
+
+func TestSaveEnvAtomic(t *testing.T) {
+	path := writeSample(t, "A=1\n")
+	if err := SaveEnv(path, map[string]string{"B": "2"}, SaveOptions{Merge: true, Atomic: true}); err != nil {
+		t.Fatalf("SaveEnv: %v", err)
+	}
+	if got := readFile(t, path); got != "A=1\nB=2\n" {
+		t.Errorf("file = %q", got)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o640 {
+		t.Errorf("mode = %v, want the file's own 0640", info.Mode().Perm())
+	}
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("directory has %d entries, want just the env file", len(entries))
+	}
+
+	// A new file is private unless asked otherwise
+	newPath := filepath.Join(filepath.Dir(path), ".env.local")
+	if err := SaveEnv(newPath, map[string]string{"A": "1"}, SaveOptions{Merge: true, Atomic: true}); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(newPath); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("new file: %v, %v", info.Mode(), err)
+	}
+}
+
+func TestLineDiff(t *testing.T) {
+	got := lineDiff("a\nb\nc\n", "a\nB\nc\nd\n")
+	want := []string{"- b", "+ B", "+ d"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("lineDiff = %q, want %q", got, want)
+	}
+	if got := lineDiff("same\n", "same\n"); len(got) != 0 {
+		t.Errorf("identical files differ: %q", got)
+	}
+	if got := lineDiff("", "new\n"); strings.Join(got, "|") != "+ new" {
+		t.Errorf("new file diff = %q", got)
+	}
+}
+
+func TestSetCommand(t *testing.T) {
+	path := writeSample(t, "# keep me\nDB_HOST=localhost\nDB_NAME=demo\n")
+	out, err := execute(t, "set", "--env-file", path, "DB_HOST=newhost", "EXTRA=a b")
+	if err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if got := readFile(t, path); got != "# keep me\nDB_HOST=\"newhost\"\nDB_NAME=demo\nEXTRA=\"a b\"\n" {
+		t.Errorf("file = %q", got)
+	}
+	for _, want := range []string{"- DB_HOST=localhost\n", "+ DB_HOST=\"newhost\"\n", "+ EXTRA=\"a b\"\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "keep me") || strings.Contains(out, "DB_NAME") {
+		t.Errorf("output shows unchanged lines:\n%s", out)
+	}
+
+	out, err = execute(t, "set", "--env-file", path, "DB_NAME=demo")
+	if err != nil || !strings.Contains(out, "unchanged") {
+		t.Errorf("setting the same value: %v, %s", err, out)
+	}
+
+	// Without --env-file it is .env in the working directory
+	t.Chdir(t.TempDir())
+	if _, err := execute(t, "set", "NEW=1"); err != nil || readFile(t, ".env") != "NEW=1\n" {
+		t.Errorf("set without --env-file: %v", err)
+	}
+
+	for _, args := range [][]string{
+		{"set"},
+		{"set", "--env-file", path, "NOVALUE"},
+		{"set", "--env-file", path, "1BAD=x"},
+		{"set", "--env-file", path, "=x"},
+		{"set", "--env-file", path, "--env-file", path, "A=b"},
+	} {
+		if _, err := execute(t, args...); err == nil {
+			t.Errorf("%q succeeded", args)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var envKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+// newSetCommand is the set command: set KEY=VALUE... updates .env, or the
+// one --env-file, in place with SaveEnv and shows what changed
+func newSetCommand(opts *rootOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "set KEY=VALUE...",
+		Short: "Set variables in an env file and show the diff",
+		Long: `set updates .env, or the file given with --env-file, in place. Lines for
+keys already in the file are changed where they are, keeping comments and
+order, and new keys are appended.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := ".env"
+			switch len(opts.envFiles) {
+			case 0:
+			case 1:
+				path = opts.envFiles[0]
+			default:
+				return errors.New("set updates one file; give --env-file once")
+			}
+			return runSet(path, args, cmd.OutOrStdout())
+		},
+	}
+}
+
+// runSet saves the KEY=VALUE args into the env file at path and prints the
+// lines that changed
+func runSet(path string, args []string, out io.Writer) error {
+	values := make(map[string]string)
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok || !envKeyPattern.MatchString(key) {
+			return fmt.Errorf("%q is not KEY=VALUE", arg)
+		}
+		values[key] = value
+	}
+
+	before, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err := SaveEnv(path, values, SaveOptions{Merge: true, Atomic: true}); err != nil {
+		return fmt.Errorf("saving %s: %w", path, err)
+	}
+	after, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	diff := lineDiff(string(before), string(after))
+	if len(diff) == 0 {
+		fmt.Fprintf(out, "✅ %s is unchanged\n", path)
+		return nil
+	}
+	fmt.Fprintf(out, "✅ Updated %s\n--- before\n+++ after\n", path)
+	for _, line := range diff {
+		fmt.Fprintln(out, line)
+	}
+	return nil
+}
+
+// lineDiff lists the lines removed from before, marked "- ", and added in
+// after, marked "+ ", in file order, leaving out the lines they share
+func lineDiff(before, after string) []string {
+	a, b := splitLines(before), splitLines(after)
+	// lcs[i][j] is the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var diff []string
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			diff = append(diff, "- "+a[i])
+			i++
+		default:
+			diff = append(diff, "+ "+b[j])
+			j++
+		}
+	}
+	return diff
+}
+
+// splitLines is s's lines without their endings
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(strings.ReplaceAll(s, "\r\n", "\n"), "\n"), "\n")
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/mail"
+	"strconv"
+	"strings"
+)
+
+// FormatError is a FieldError's Err when a value loads but isn't in the
+// format its validate tag asks for
+type FormatError struct {
+	// Rule is the validate tag, like "port" or "oneof=debug info"
+	Rule string
+	// Expected describes the format, like "a port from 1 to 65535"
+	Expected string
+}
+
+func (e *FormatError) Error() string { return "want " + e.Expected }
+
+// checkFormat checks value against a validate tag's rule:
+//
+//	port                   a whole number from 1 to 65535
+//	hostname               an RFC 1123 host name or an IP address
+//	email                  a bare address like name@example.com
+//	url                    an absolute URL with a scheme and host
+//	oneof=debug info ...   one of the space-separated words
+//
+// It returns a *FormatError when value doesn't match.
+func checkFormat(rule, value string) error {
+	name, arg, _ := strings.Cut(rule, "=")
+	var ok bool
+	var expected string
+	switch name {
+	case "port":
+		n, err := strconv.Atoi(value)
+		ok = err == nil && n >= 1 && n <= 65535
+		expected = "a port from 1 to 65535"
+	case "hostname":
+		ok = isHostname(value) || net.ParseIP(value) != nil
+		expected = "a host name like db.example.com or an IP address"
+	case "email":
+		addr, err := mail.ParseAddress(value)
+		ok = err == nil && addr.Name == "" && addr.Address == value
+		expected = "an email address like name@example.com"
+	case "url":
+		_, err := parseURL(value)
+		ok = err == nil
+		expected = "an absolute URL like https://example.com"
+	case "oneof":
+		words := strings.Fields(arg)
+		if len(words) == 0 {
+			return fmt.Errorf("validate rule %q has no values", rule)
+		}
+		for _, w := range words {
+			ok = ok || value == w
+		}
+		expected = "one of " + strings.Join(words, ", ")
+	default:
+		return fmt.Errorf("unknown validate rule %q", rule)
+	}
+	if !ok {
+		return &FormatError{Rule: rule, Expected: expected}
+	}
+	return nil
+}
+
+// isHostname reports whether s is a host name of dot-separated labels,
+// each 1 to 63 letters, digits or hyphens that don't start or end with a
+// hyphen
+func isHostname(s string) bool {
+	if s == "" || len(s) > 253 {
+		return false
+	}
+	for label := range strings.SplitSeq(s, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
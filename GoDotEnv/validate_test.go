@@ -0,0 +1,139 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCheckFormat(t *testing.T) {
+	tests := []struct {
+		rule  string
+		value string
+		ok    bool
+	}{
+		{"port", "0", false},
+		{"port", "1", true},
+		{"port", "8080", true},
+		{"port", "65535", true},
+		{"port", "65536", false},
+		{"port", "99999", false},
+		{"port", "-1", false},
+		{"port", "http", false},
+
+		{"hostname", "localhost", true},
+		{"hostname", "db.example.com", true},
+		{"hostname", "my-db-1.internal", true},
+		{"hostname", "127.0.0.1", true},
+		{"hostname", "::1", true},
+		{"hostname", strings.Repeat("a", 63) + ".com", true},
+		{"hostname", strings.Repeat("a", 64) + ".com", false},
+		{"hostname", strings.Repeat("a.", 126) + "a", true},
+		{"hostname", strings.Repeat("a.", 127) + "a", false},
+		{"hostname", "-db.example.com", false},
+		{"hostname", "db-.example.com", false},
+		{"hostname", "db..example.com", false},
+		{"hostname", "db_host", false},
+		{"hostname", "db.example.com:5432", false},
+		{"hostname", "http://db.example.com", false},
+
+		{"email", "noreply@example.com", true},
+		{"email", "first.last+tag@mail.example.com", true},
+		{"email", "noreply", false},
+		{"email", "noreply@", false},
+		{"email", "@example.com", false},
+		{"email", "App <noreply@example.com>", false},
+		{"email", " noreply@example.com", false},
+
+		{"url", "https://example.com", true},
+		{"url", "postgres://user:pass@db:5432/app?sslmode=disable", true},
+		{"url", "example.com", false},
+		{"url", "/relative/path", false},
+		{"url", "https://", false},
+		{"url", "://example.com", false},
+
+		{"oneof=debug info warn error", "debug", true},
+		{"oneof=debug info warn error", "error", true},
+		{"oneof=debug info warn error", "INFO", false},
+		{"oneof=debug info warn error", "verbose", false},
+		{"oneof=debug info warn error", "info warn", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule+"/"+tt.value, func(t *testing.T) {
+			err := checkFormat(tt.rule, tt.value)
+			if tt.ok {
+				if err != nil {
+					t.Errorf("checkFormat(%q, %q) = %v", tt.rule, tt.value, err)
+				}
+				return
+			}
+			var format *FormatError
+			if !errors.As(err, &format) || format.Rule != tt.rule {
+				t.Errorf("checkFormat(%q, %q) = %v, want a *FormatError", tt.rule, tt.value, err)
+			}
+		})
+	}
+}
+
+func TestCheckFormatBadRules(t *testing.T) {
+	for _, rule := range []string{"postcode", "oneof=", "oneof"} {
+		err := checkFormat(rule, "value")
+		var format *FormatError
+		if err == nil || errors.As(err, &format) {
+			t.Errorf("checkFormat(%q) = %v, want a rule error", rule, err)
+		}
+	}
+}
+
+func TestLoadStructValidates(t *testing.T) {
+	keys := []string{"SERVER_PORT", "SERVER_HOST", "EMAIL_FROM", "LOG_LEVEL", "DATABASE_URL", "DB_PORT", "REDIS_HOST"}
+	unsetEnv(t, keys...)
+	t.Setenv("SERVER_PORT", "99999")
+	t.Setenv("SERVER_HOST", "bad_host")
+	t.Setenv("EMAIL_FROM", "noreply")
+	t.Setenv("LOG_LEVEL", "verbose")
+	t.Setenv("DATABASE_URL", "db-password-in-plain-sight")
+	t.Setenv("DB_PORT", "0")
+	t.Setenv("REDIS_HOST", "cache.internal")
+
+	_, err := loadConfig()
+	if err == nil {
+		t.Fatal("loadConfig succeeded")
+	}
+	msg := err.Error()
+	for _, want := range []string{
+		`field Database.Port (env DB_PORT): bad value "0": want a port from 1 to 65535`,
+		`field DatabaseURL (env DATABASE_URL): bad value "` + maskSecret("db-password-in-plain-sight") + `": want an absolute URL`,
+		`field ServerPort (env SERVER_PORT): bad value "99999": want a port from 1 to 65535`,
+		`field ServerHost (env SERVER_HOST): bad value "bad_host": want a host name`,
+		`field EmailFrom (env EMAIL_FROM): bad value "noreply": want an email address`,
+		`field LogLevel (env LOG_LEVEL): bad value "verbose": want one of debug, info, warn, error`,
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("error is missing %q:\n%s", want, msg)
+		}
+	}
+	if strings.Count(msg, "\n") != 5 || strings.Contains(msg, "plain-sight") || strings.Contains(msg, "REDIS_HOST") {
+		t.Errorf("error:\n%s", msg)
+	}
+
+	// Each failure is a *FieldError wrapping a *FormatError
+	var format *FormatError
+	if !errors.As(err, &format) {
+		t.Errorf("errors.As(*FormatError) failed for %v", err)
+	}
+
+	// Edge values and defaults pass
+	t.Setenv("SERVER_PORT", "65535")
+	t.Setenv("DB_PORT", "1")
+	for _, key := range []string{"SERVER_HOST", "EMAIL_FROM", "LOG_LEVEL", "DATABASE_URL"} {
+		t.Setenv(key, "")
+	}
+	config, err := loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+	if config.ServerPort != 65535 || config.Database.Port != 1 || config.LogLevel != "info" {
+		t.Errorf("config = %d, %d, %q", config.ServerPort, config.Database.Port, config.LogLevel)
+	}
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/cobra"
+)
+
+// defaultDebounce is how long the watcher waits for the events from one
+// save to settle, since editors often write a file twice or replace it
+const defaultDebounce = 100 * time.Millisecond
+
+// Change is a Config variable whose value changed in a reload, with
+// secrets masked
+type Change struct {
+	Key string
+	Old string
+	New string
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s → %s", c.Key, c.Old, c.New)
+}
+
+// ConfigWatcher keeps a Config loaded from the env files and reloads it
+// when they change
+type ConfigWatcher struct {
+	env   string
+	files []string
+	out   io.Writer
+	// Debounce is how long to wait after a change before reloading
+	Debounce time.Duration
+
+	// current is swapped for a whole new Config on each reload, so readers
+	// never see one half built
+	current atomic.Pointer[Config]
+
+	mu        sync.Mutex
+	report    *LoadReport
+	callbacks []func(Config, []Change)
+}
+
+// NewConfigWatcher loads files, least specific first as LoadEnvFiles
+// takes them, and the Config, reporting reloads to out
+func NewConfigWatcher(env string, files []string, out io.Writer) (*ConfigWatcher, error) {
+	w := &ConfigWatcher{env: env, files: files, out: out, Debounce: defaultDebounce}
+	report, err := LoadEnvFiles(env, files...)
+	if err != nil {
+		return nil, err
+	}
+	config, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	w.report = report
+	w.current.Store(&config)
+	return w, nil
+}
+
+// Config is the current configuration. It is shared, so don't modify it.
+func (w *ConfigWatcher) Config() *Config {
+	return w.current.Load()
+}
+
+// OnChange registers fn to be called with the new Config and what changed
+// after each reload that changes something
+func (w *ConfigWatcher) OnChange(fn func(Config, []Change)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.callbacks = append(w.callbacks, fn)
+}
+
+// Reload loads the env files and the Config again. On an error, such as
+// a file saved half edited, the environment and Config stay as they were.
+func (w *ConfigWatcher) Reload() ([]Change, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// LoadEnvFiles never overrides the process environment, so take out
+	// what the last load put there first, keeping it to put back
+	previous := make(map[string]string)
+	for key, source := range w.report.Sources {
+		if source != processEnv {
+			previous[key] = os.Getenv(key)
+			os.Unsetenv(key)
+		}
+	}
+	restore := func(loaded *LoadReport) {
+		if loaded != nil {
+			for key, source := range loaded.Sources {
+				if source != processEnv {
+					os.Unsetenv(key)
+				}
+			}
+		}
+		for key, value := range previous {
+			os.Setenv(key, value)
+		}
+	}
+
+	report, err := LoadEnvFiles(w.env, w.files...)
+	if err != nil {
+		restore(nil)
+		return nil, err
+	}
+	config, err := loadConfig()
+	if err != nil {
+		restore(report)
+		return nil, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	w.report = report
+
+	old := w.current.Swap(&config)
+	changes := diffConfig(*old, config)
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	fmt.Fprintf(w.out, "🔄 Reloaded, %d changed:\n", len(changes))
+	for _, c := range changes {
+		fmt.Fprintf(w.out, "   %s\n", c)
+	}
+	for _, fn := range w.callbacks {
+		fn(config, changes)
+	}
+	return changes, nil
+}
+
+// Watch reloads whenever one of the env files is written, created,
+// removed or renamed, until ctx is done. It watches the files' directories
+// rather than the files so it sees a file atomically replaced.
+func (w *ConfigWatcher) Watch(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+	var files, dirs []string
+	for _, path := range w.files {
+		path = filepath.Clean(path)
+		files = append(files, path)
+		if dir := filepath.Dir(path); !slices.Contains(dirs, dir) {
+			if err := watcher.Add(dir); err != nil {
+				return err
+			}
+			dirs = append(dirs, dir)
+		}
+	}
+
+	timer := time.NewTimer(0)
+	<-timer.C
+	for {
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if !slices.Contains(files, filepath.Clean(event.Name)) || event.Has(fsnotify.Chmod) && !event.Has(fsnotify.Write) {
+				continue
+			}
+			// Wait for the rest of the save before reloading
+			timer.Reset(w.Debounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			fmt.Fprintf(w.out, "⚠️  Watching: %v\n", err)
+		case <-timer.C:
+			if _, err := w.Reload(); err != nil {
+				fmt.Fprintf(w.out, "⚠️  Not reloaded, keeping the last good config: %v\n", err)
+			}
+		}
+	}
+}
+
+// diffConfig lists the variables whose values differ between old and
+// updated, in Config's field order, masking secrets
+func diffConfig(old, updated Config) []Change {
+	oldFields := exportFields(reflect.ValueOf(old), "", nil)
+	newFields := exportFields(reflect.ValueOf(updated), "", nil)
+	var changes []Change
+	for i, f := range newFields {
+		before, after := exportValue(oldFields[i], false), exportValue(f, false)
+		if reflect.DeepEqual(before, after) {
+			continue
+		}
+		changes = append(changes, Change{Key: f.Env, Old: displayValue(before), New: displayValue(after)})
+	}
+	return changes
+}
+
+// displayValue shows an exported value in a diff, marking empty ones
+func displayValue(v any) string {
+	if s := fmt.Sprint(v); s != "" && s != "[]" && s != "map[]" {
+		return s
+	}
+	return "<empty>"
+}
+
+// newWatchCommand is the watch command: it loads the configuration and
+// reloads it as the env files change, until interrupted
+func newWatchCommand(opts *rootOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "watch",
+		Short: "Reload the configuration whenever the env files change",
+		Long: `watch loads the configuration, then reloads it whenever one of the env
+files is saved, printing what changed with secrets masked and auditing the
+new configuration. A file that doesn't load keeps the last good one.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runWatch(cmd.Context(), cmd.OutOrStdout(), opts)
+		},
+	}
+}
+
+// runWatch watches until ctx is done or the process is interrupted
+func runWatch(ctx context.Context, out io.Writer, opts *rootOptions) error {
+	w, err := NewConfigWatcher(opts.env, opts.files(), out)
+	if err != nil {
+		return err
+	}
+	w.OnChange(func(config Config, changes []Change) {
+		printFindings(out, Audit(config))
+	})
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+	fmt.Fprintf(out, "👀 Watching %v for APP_ENV=%s; press Ctrl+C to stop\n", opts.files(), opts.env)
+	return w.Watch(ctx)
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// watchKeys are the variables the watch tests' files set
+var watchKeys = []string{"APP_ENV", "DB_HOST", "JWT_SECRET", "LOG_LEVEL", "DB_PORT"}
+
+// lockedBuffer is a bytes.Buffer the watcher can write to while the test
+// reads it
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func writeEnv(t *testing.T, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(name, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConfigWatcherReload(t *testing.T) {
+	unsetEnv(t, watchKeys...)
+	t.Setenv("DB_PORT", "6000")
+	writeEnvFiles(t, map[string]string{".env": "DB_HOST=localhost\nJWT_SECRET=first-secret-value-here\nDB_PORT=5432\n"})
+
+	var out bytes.Buffer
+	w, err := NewConfigWatcher("development", envFiles("development"), &out)
+	if err != nil {
+		t.Fatalf("NewConfigWatcher: %v", err)
+	}
+	first := w.Config()
+	if first.Database.Host != "localhost" || first.Database.Port != 6000 {
+		t.Fatalf("initial config = %+v", first.Database)
+	}
+
+	var got []Change
+	w.OnChange(func(config Config, changes []Change) { got = changes })
+
+	writeEnv(t, ".env", "DB_HOST=db.internal\nJWT_SECRET=second-secret-value-here\nLOG_LEVEL=debug\nDB_PORT=5433\n")
+	changes, err := w.Reload()
+	if err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	want := []string{
+		"DB_HOST: localhost → db.internal",
+		"JWT_SECRET: " + maskSecret("first-secret-value-here") + " → " + maskSecret("second-secret-value-here"),
+		"LOG_LEVEL: info → debug",
+	}
+	var lines []string
+	for _, c := range changes {
+		lines = append(lines, c.String())
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("changes:\n%s\nwant:\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+	if len(got) != len(changes) {
+		t.Errorf("callback got %d changes, want %d", len(got), len(changes))
+	}
+	if strings.Contains(out.String(), "second-secret") || !strings.Contains(out.String(), "DB_HOST: localhost → db.internal") {
+		t.Errorf("printed diff:\n%s", out.String())
+	}
+
+	// The old Config is untouched, and the process env still wins
+	if first.Database.Host != "localhost" || w.Config().Database.Host != "db.internal" || w.Config().Database.Port != 6000 {
+		t.Errorf("configs: old %+v, new %+v", first.Database, w.Config().Database)
+	}
+
+	// A removed variable goes back to its default
+	writeEnv(t, ".env", "DB_HOST=db.internal\nJWT_SECRET=second-secret-value-here\n")
+	if changes, err := w.Reload(); err != nil || len(changes) != 1 || changes[0].String() != "LOG_LEVEL: debug → info" {
+		t.Errorf("removing LOG_LEVEL: %v, %v", changes, err)
+	}
+	if _, ok := os.LookupEnv("LOG_LEVEL"); ok {
+		t.Error("LOG_LEVEL is still set")
+	}
+
+	// Nothing changed, nothing reported
+	got = nil
+	if changes, err := w.Reload(); err != nil || changes != nil || got != nil {
+		t.Errorf("reload without changes: %v, %v, callback %v", changes, err, got)
+	}
+}
+
+func TestConfigWatcherKeepsLastGoodConfig(t *testing.T) {
+	unsetEnv(t, watchKeys...)
+	writeEnvFiles(t, map[string]string{".env": "DB_HOST=localhost\nDB_PORT=5432\n"})
+	w, err := NewConfigWatcher("development", envFiles("development"), new(bytes.Buffer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := w.Config()
+
+	for _, content := range []string{
+		"DB_HOST=half-saved\nDB_PORT=\"5432\n",     // doesn't parse
+		"DB_HOST=bad-port\nDB_PORT=not-a-number\n", // parses, but isn't a valid Config
+	} {
+		writeEnv(t, ".env", content)
+		if _, err := w.Reload(); err == nil {
+			t.Errorf("Reload of %q succeeded", content)
+		}
+		if w.Config() != before || os.Getenv("DB_HOST") != "localhost" || os.Getenv("DB_PORT") != "5432" {
+			t.Errorf("after %q: config %+v, DB_HOST=%s DB_PORT=%s", content, w.Config().Database, os.Getenv("DB_HOST"), os.Getenv("DB_PORT"))
+		}
+	}
+
+	// Fixing the file reloads it
+	writeEnv(t, ".env", "DB_HOST=fixed\nDB_PORT=5432\n")
+	if _, err := w.Reload(); err != nil || w.Config().Database.Host != "fixed" {
+		t.Errorf("after fixing: %v, %+v", err, w.Config().Database)
+	}
+}
+
+func TestConfigWatcherWatch(t *testing.T) {
+	unsetEnv(t, watchKeys...)
+	writeEnvFiles(t, map[string]string{".env": "DB_HOST=localhost\n"})
+	var out lockedBuffer
+	w, err := NewConfigWatcher("development", envFiles("development"), &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Debounce = 50 * time.Millisecond
+	reloads := make(chan []Change, 10)
+	w.OnChange(func(config Config, changes []Change) { reloads <- changes })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- w.Watch(ctx) }()
+	defer func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Watch: %v", err)
+		}
+	}()
+	// Give the watcher time to start
+	time.Sleep(50 * time.Millisecond)
+
+	wait := func(what string) []Change {
+		t.Helper()
+		select {
+		case changes := <-reloads:
+			return changes
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no reload after %s; output:\n%s", what, out.String())
+			return nil
+		}
+	}
+
+	// An editor's double write is one reload
+	writeEnv(t, ".env", "DB_HOST=half\n")
+	writeEnv(t, ".env", "DB_HOST=written\n")
+	if changes := wait("writing"); len(changes) != 1 || changes[0].New != "written" {
+		t.Errorf("changes = %v", changes)
+	}
+	select {
+	case changes := <-reloads:
+		t.Errorf("a second reload for one save: %v", changes)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	// Replacing the file atomically, as many editors do
+	writeEnv(t, ".env.tmp", "DB_HOST=renamed\n")
+	if err := os.Rename(".env.tmp", ".env"); err != nil {
+		t.Fatal(err)
+	}
+	if changes := wait("renaming"); len(changes) != 1 || changes[0].New != "renamed" {
+		t.Errorf("changes = %v", changes)
+	}
+
+	// A more specific file appearing
+	writeEnv(t, ".env.local", "DB_HOST=local\n")
+	if changes := wait("creating .env.local"); len(changes) != 1 || changes[0].String() != "DB_HOST: renamed → local" {
+		t.Errorf("changes = %v", changes)
+	}
+
+	// Other files don't count
+	writeEnv(t, "notes.txt", "DB_HOST=ignored\n")
+	select {
+	case changes := <-reloads:
+		t.Errorf("reloaded for another file: %v", changes)
+	case <-time.After(200 * time.Millisecond):
+	}
+	if !strings.Contains(out.String(), "DB_HOST: written → renamed") {
+		t.Errorf("output:\n%s", out.String())
+	}
+}
+
+func TestWatchCommand(t *testing.T) {
+	unsetEnv(t, watchKeys...)
+	t.Chdir(t.TempDir())
+	if err := os.Mkdir("conf", 0o700); err != nil {
+		t.Fatal(err)
+	}
+	writeEnv(t, "conf/app.env", "DB_HOST=localhost\n")
+
+	root := newRootCommand()
+	var out lockedBuffer
+	root.SetOut(&out)
+	root.SetErr(&out)
+	root.SetArgs([]string{"--env-file", "conf/app.env", "watch"})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- root.ExecuteContext(ctx) }()
+
+	waitFor := func(want string) {
+		t.Helper()
+		for deadline := time.Now().Add(5 * time.Second); !strings.Contains(out.String(), want); {
+			if time.Now().After(deadline) {
+				t.Fatalf("output is missing %q:\n%s", want, out.String())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	waitFor("👀 Watching [conf/app.env]")
+	// Give the watcher time to start
+	time.Sleep(50 * time.Millisecond)
+
+	writeEnv(t, "conf/app.env", "DB_HOST=db.internal\n")
+	waitFor("DB_HOST: localhost → db.internal")
+	// The new configuration is audited
+	waitFor("error     JWT_SECRET")
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("watch: %v", err)
+	}
+}
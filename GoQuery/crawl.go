@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/publicsuffix"
+
+	"example.com/goquery-demo/robots"
+)
+
+// Crawler walks the pages of one site breadth first from a seed URL,
+// following links on the seed's registered domain only
+type Crawler struct {
+	Client *http.Client
+	// MaxDepth is how many links away from the seed to go; 0 is just the seed
+	MaxDepth int
+	// MaxPages stops the crawl after this many pages; 0 means no limit
+	MaxPages int
+	// Robots is consulted before every fetch; nil fetches everything
+	Robots *robots.Checker
+}
+
+// CrawledPage is one URL the crawler fetched
+type CrawledPage struct {
+	URL    string
+	Depth  int
+	Title  string
+	Status int
+	// Links is how many distinct pages it links to, on any domain
+	Links int
+	// Err is why the page couldn't be fetched or wasn't parsed
+	Err error
+}
+
+// Edge is a link from one page to another
+type Edge struct {
+	From, To string
+}
+
+// CrawlResult is the pages in the order they were fetched, and the links
+// found on them
+type CrawlResult struct {
+	Pages []CrawledPage
+	Edges []Edge
+}
+
+// errNotHTML marks a page skipped for its content type
+var errNotHTML = errors.New("not HTML")
+
+// Crawl fetches seed and the same-domain pages it leads to, up to
+// MaxDepth links away and MaxPages pages in all. Each URL, less any
+// #fragment, is fetched once however many pages link to it.
+func (c *Crawler) Crawl(ctx context.Context, seed string) (*CrawlResult, error) {
+	start, err := url.Parse(seed)
+	if err != nil {
+		return nil, fmt.Errorf("parsing seed URL: %w", err)
+	}
+	if start.Scheme != "http" && start.Scheme != "https" {
+		return nil, fmt.Errorf("seed URL %q is not http or https", seed)
+	}
+	normalizeURL(start)
+
+	type queued struct {
+		u     *url.URL
+		depth int
+	}
+	result := &CrawlResult{}
+	visited := map[string]bool{start.String(): true}
+	queue := []queued{{start, 0}}
+	for len(queue) > 0 && (c.MaxPages <= 0 || len(result.Pages) < c.MaxPages) {
+		next := queue[0]
+		queue = queue[1:]
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		page, links := c.visit(ctx, next.u)
+		page.Depth = next.depth
+		page.Links = len(links)
+		result.Pages = append(result.Pages, page)
+		for _, link := range links {
+			result.Edges = append(result.Edges, Edge{From: page.URL, To: link.String()})
+			if next.depth >= c.MaxDepth || visited[link.String()] || !sameSite(start, link) {
+				continue
+			}
+			visited[link.String()] = true
+			queue = append(queue, queued{link, next.depth + 1})
+		}
+	}
+	return result, nil
+}
+
+// visit fetches u and returns what it learned and the distinct links on
+// the page, resolved against the URL it was finally served from
+func (c *Crawler) visit(ctx context.Context, u *url.URL) (CrawledPage, []*url.URL) {
+	page := CrawledPage{URL: u.String()}
+	req, err := http.NewRequestWithContext(ctx, "GET", page.URL, nil)
+	if err != nil {
+		page.Err = err
+		return page, nil
+	}
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9")
+
+	resp, err := fetchPage(c.Client, c.Robots, req)
+	if err != nil {
+		var fetchErr *FetchError
+		if errors.As(err, &fetchErr) {
+			page.Status = fetchErr.StatusCode
+		}
+		page.Err = err
+		return page, nil
+	}
+	defer resp.Body.Close()
+	page.Status = resp.StatusCode
+
+	// Images, PDFs and the like aren't read at all
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		page.Err = fmt.Errorf("%w: %s", errNotHTML, resp.Header.Get("Content-Type"))
+		return page, nil
+	}
+
+	doc, err := goquery.NewDocumentFromReader(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		page.Err = fmt.Errorf("parsing HTML: %w", err)
+		return page, nil
+	}
+	page.Title = strings.TrimSpace(doc.Find("title").First().Text())
+	return page, extractLinks(doc, resp.Request.URL)
+}
+
+// extractLinks is the distinct http and https links in doc, resolved
+// against base and without fragments. Links to a fragment of the page
+// itself, and mailto: and javascript: links, are left out.
+func extractLinks(doc *goquery.Document, base *url.URL) []*url.URL {
+	var links []*url.URL
+	seen := make(map[string]bool)
+	doc.Find("a[href]").Each(func(i int, s *goquery.Selection) {
+		href, _ := s.Attr("href")
+		href = strings.TrimSpace(href)
+		if href == "" || strings.HasPrefix(href, "#") {
+			return
+		}
+		ref, err := url.Parse(href)
+		if err != nil {
+			return
+		}
+		link := base.ResolveReference(ref)
+		normalizeURL(link)
+		if link.Scheme != "http" && link.Scheme != "https" || seen[link.String()] {
+			return
+		}
+		seen[link.String()] = true
+		links = append(links, link)
+	})
+	return links
+}
+
+// normalizeURL drops u's fragment and lowercases its host, and gives it a
+// path of / if it has none, so one page has one URL
+func normalizeURL(u *url.URL) {
+	u.Fragment, u.RawFragment = "", ""
+	u.Host = strings.ToLower(u.Host)
+	if u.Path == "" && u.Opaque == "" {
+		u.Path = "/"
+	}
+}
+
+// sameSite reports whether a and b share a registered domain, so that
+// www.example.com and blog.example.com are one site but example.co.uk
+// and other.co.uk are not. IP addresses and single-label hosts like
+// localhost have to match exactly.
+func sameSite(a, b *url.URL) bool {
+	return registeredDomain(a.Hostname()) == registeredDomain(b.Hostname())
+}
+
+// registeredDomain is host's public suffix plus one label, or host itself
+// when it has none
+func registeredDomain(host string) string {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if net.ParseIP(host) != nil {
+		return host
+	}
+	domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return host
+	}
+	return domain
+}
+
+// writeCrawlResults prints a table of the crawled pages
+func writeCrawlResults(w io.Writer, pages []CrawledPage) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "DEPTH\tSTATUS\tLINKS\tURL\tTITLE")
+	for _, p := range pages {
+		status := "-"
+		if p.Status != 0 {
+			status = fmt.Sprint(p.Status)
+		}
+		title := truncateText(p.Title, 50)
+		if p.Err != nil {
+			title = "(" + p.Err.Error() + ")"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%s\t%s\n", p.Depth, status, p.Links, p.URL, title)
+	}
+	return tw.Flush()
+}
+
+// writeEdges prints edges one per line as source and target separated by
+// a tab, which Gephi, networkx and most graph tools read as an edge list
+func writeEdges(w io.Writer, edges []Edge) error {
+	for _, e := range edges {
+		if _, err := fmt.Fprintf(w, "%s\t%s\n", e.From, e.To); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testSite is a small site whose pages link in cycles:
+//
+//	/        -> /a /b, plus a fragment, a mailto: and another domain
+//	/a       -> / /b /a/deep
+//	/b       -> /a /image.png /missing
+//	/a/deep  -> /a/deep/deeper
+var testSite = map[string]string{
+	"/": `<title>Home</title>
+<a href="/a">A</a> <a href="b">B</a> <a href="#top">Top</a> <a href="/a#section">A again</a>
+<a href="mailto:someone@example.com">Mail</a> <a href="https://other.example/">Elsewhere</a>`,
+	"/a":             `<title>Page A</title><a href="/">Home</a> <a href="/b">B</a> <a href="/a/deep">Deep</a>`,
+	"/b":             `<title>Page B</title><a href="./a">A</a> <a href="/image.png">Image</a> <a href="/missing">Gone</a>`,
+	"/a/deep":        `<title>Deep</title><a href="deep/deeper">Deeper</a>`,
+	"/a/deep/deeper": `<title>Deeper</title>`,
+}
+
+// siteServer serves testSite, counting the requests for each path
+func siteServer(t *testing.T) (*httptest.Server, func() map[string]int) {
+	t.Helper()
+	var mu sync.Mutex
+	hits := make(map[string]int)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits[r.URL.Path]++
+		mu.Unlock()
+		if r.URL.Path == "/image.png" {
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte("\x89PNG"))
+			return
+		}
+		body, ok := testSite[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() map[string]int {
+		mu.Lock()
+		defer mu.Unlock()
+		return hits
+	}
+}
+
+// crawl crawls srv from / with the given limits
+func crawl(t *testing.T, srv *httptest.Server, maxDepth, maxPages int) *CrawlResult {
+	t.Helper()
+	crawler := &Crawler{Client: &http.Client{Timeout: 5 * time.Second}, MaxDepth: maxDepth, MaxPages: maxPages}
+	result, err := crawler.Crawl(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+// paths is the path of each crawled page, in crawl order
+func paths(t *testing.T, pages []CrawledPage) []string {
+	t.Helper()
+	var out []string
+	for _, p := range pages {
+		u, err := url.Parse(p.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, u.Path)
+	}
+	return out
+}
+
+func TestCrawlDeduplicatesAndLimitsDepth(t *testing.T) {
+	srv, hits := siteServer(t)
+	result := crawl(t, srv, 2, 0)
+
+	// Breadth first, each page once despite the cycles, and nothing three
+	// links away
+	want := []string{"/", "/a", "/b", "/a/deep", "/image.png", "/missing"}
+	if got := paths(t, result.Pages); !slices.Equal(got, want) {
+		t.Fatalf("crawled %v, want %v", got, want)
+	}
+	for path, n := range hits() {
+		if n != 1 {
+			t.Errorf("%s fetched %d times", path, n)
+		}
+	}
+
+	byPath := make(map[string]CrawledPage)
+	for i, p := range result.Pages {
+		byPath[want[i]] = p
+	}
+	if home := byPath["/"]; home.Title != "Home" || home.Status != 200 || home.Depth != 0 || home.Links != 3 || home.Err != nil {
+		t.Errorf("home page %+v", home)
+	}
+	if deep := byPath["/a/deep"]; deep.Depth != 2 || deep.Links != 1 {
+		t.Errorf("/a/deep %+v, want depth 2 and 1 link", deep)
+	}
+	if image := byPath["/image.png"]; !errors.Is(image.Err, errNotHTML) || image.Links != 0 {
+		t.Errorf("/image.png %+v, want skipped as not HTML", image)
+	}
+	var fetchErr *FetchError
+	if missing := byPath["/missing"]; missing.Status != 404 || !errors.As(missing.Err, &fetchErr) {
+		t.Errorf("/missing %+v, want a 404", missing)
+	}
+}
+
+func TestCrawlEdges(t *testing.T) {
+	srv, _ := siteServer(t)
+	result := crawl(t, srv, 2, 0)
+
+	var got []string
+	for _, e := range result.Edges {
+		got = append(got, strings.ReplaceAll(e.From+" -> "+e.To, srv.URL, ""))
+	}
+	// Fragments are dropped or folded into their page, mailto: is left out,
+	// and links off the site or past the depth limit are edges but not
+	// crawled
+	want := []string{
+		"/ -> /a",
+		"/ -> /b",
+		"/ -> https://other.example/",
+		"/a -> /",
+		"/a -> /b",
+		"/a -> /a/deep",
+		"/b -> /a",
+		"/b -> /image.png",
+		"/b -> /missing",
+		"/a/deep -> /a/deep/deeper",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("edges:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	var out strings.Builder
+	if err := writeEdges(&out, result.Edges[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if want := srv.URL + "/\t" + srv.URL + "/a\n"; out.String() != want {
+		t.Errorf("writeEdges wrote %q, want %q", out.String(), want)
+	}
+}
+
+func TestCrawlLimits(t *testing.T) {
+	tests := []struct {
+		maxDepth, maxPages int
+		want               []string
+	}{
+		{0, 0, []string{"/"}},
+		{1, 0, []string{"/", "/a", "/b"}},
+		{5, 2, []string{"/", "/a"}},
+		{5, 0, []string{"/", "/a", "/b", "/a/deep", "/image.png", "/missing", "/a/deep/deeper"}},
+	}
+	for _, tt := range tests {
+		srv, _ := siteServer(t)
+		if got := paths(t, crawl(t, srv, tt.maxDepth, tt.maxPages).Pages); !slices.Equal(got, tt.want) {
+			t.Errorf("depth %d, pages %d: crawled %v, want %v", tt.maxDepth, tt.maxPages, got, tt.want)
+		}
+	}
+}
+
+func TestCrawlBadSeed(t *testing.T) {
+	for _, seed := range []string{"ftp://example.com/", "example.com", "http://[::1"} {
+		if _, err := (&Crawler{}).Crawl(context.Background(), seed); err == nil {
+			t.Errorf("Crawl(%q) succeeded", seed)
+		}
+	}
+}
+
+func TestSameSite(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"https://example.com/", "https://www.example.com/page", true},
+		{"https://blog.example.com/", "http://shop.EXAMPLE.com./", true},
+		{"https://example.co.uk/", "https://other.co.uk/", false},
+		{"https://www.example.co.uk/", "https://example.co.uk/", true},
+		{"https://example.com/", "https://example.org/", false},
+		{"http://127.0.0.1:8080/", "http://127.0.0.1:9090/", true},
+		{"http://127.0.0.1/", "http://127.0.0.2/", false},
+		{"http://localhost:8080/", "http://localhost/", true},
+	}
+	for _, tt := range tests {
+		a, _ := url.Parse(tt.a)
+		b, _ := url.Parse(tt.b)
+		if got := sameSite(a, b); got != tt.want {
+			t.Errorf("sameSite(%s, %s) = %t, want %t", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -2,9 +2,8 @@ module example.com/goquery-demo
 
 go 1.25.0
 
-require github.com/PuerkitoBio/goquery v1.10.3
-
 require (
-	github.com/andybalholm/cascadia v1.3.3 // indirect
-	golang.org/x/net v0.39.0 // indirect
+	github.com/PuerkitoBio/goquery v1.10.3
+	github.com/andybalholm/cascadia v1.3.3
+	golang.org/x/net v0.39.0
 )
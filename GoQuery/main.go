@@ -2,32 +2,113 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"embed"
+	"errors"
+	"flag"
 	"fmt"
+	"io/fs"
 	"log"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"runtime"
 	"strings"
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
+
+	"example.com/goquery-demo/robots"
 )
 
+// userAgent identifies the demo to the sites it scrapes and to their
+// robots.txt
+const userAgent = "GoQuery-Demo/1.0 (Educational Purpose)"
+
 func main() {
+	// goquery-demo select ... is a command of its own
+	if len(os.Args) > 1 && os.Args[1] == "select" {
+		os.Exit(runSelect(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
+	ignoreRobots := flag.Bool("ignore-robots", false, "don't fetch or obey robots.txt (for local test servers)")
+	seed := flag.String("crawl", "", "crawl the site from this URL instead of running the examples")
+	maxDepth := flag.Int("max-depth", 2, "with --crawl, how many links away from the seed to go")
+	maxPages := flag.Int("max-pages", 20, "the most pages to fetch, crawling or following pagination")
+	edgesFile := flag.String("edges", "", "with --crawl, write the link graph's edge list to this file")
+	output := flag.String("output", "", "also save the scraped pages to this .json, .csv or .jsonl file")
+	flag.Parse()
+
+	// checker is consulted before every fetch; nil with --ignore-robots
+	var checker *robots.Checker
+	if !*ignoreRobots {
+		checker = robots.NewChecker(&http.Client{Timeout: 10 * time.Second}, userAgent)
+	}
+
+	if *seed != "" {
+		crawler := &Crawler{
+			Client:   &http.Client{Timeout: 10 * time.Second},
+			MaxDepth: *maxDepth,
+			MaxPages: *maxPages,
+			Robots:   checker,
+		}
+		if err := runCrawl(crawler, *seed, *edgesFile); err != nil {
+			log.Fatalf("Error crawling: %v", err)
+		}
+		return
+	}
+
+	// Each page is saved as it is scraped, so JSONL output streams
+	var results resultWriter
+	saved := 0
+	if *output != "" {
+		var err error
+		if results, err = newResultWriter(*output); err != nil {
+			log.Fatalf("Error creating output file: %v", err)
+		}
+	}
+	save := func(result *PageResult) {
+		if result == nil || results == nil {
+			return
+		}
+		if err := results.Write(*result); err != nil {
+			log.Printf("Error saving %s: %v", result.URL, err)
+			return
+		}
+		saved++
+	}
+
 	fmt.Println("🔍 GoQuery Web Scraping Demo")
 	fmt.Println("=============================")
 
 	// Example 1: Simple web scraping
 	fmt.Println("\n1. 📄 Scraping example.com...")
-	scrapeExample()
+	save(scrapeExample(checker))
 
 	// Example 2: Scraping news headlines (example with BBC)
 	fmt.Println("\n2. 📰 Scraping news headlines...")
-	scrapeNews()
+	save(scrapeNews(checker))
 
 	// Example 3: Scraping with custom user agent
 	fmt.Println("\n3. 🕵️ Scraping with custom headers...")
-	scrapeWithHeaders()
+	save(scrapeWithHeaders(checker))
+
+	// Example 4: Extracting a table into structs, from a local page
+	fmt.Println("\n4. 📊 Extracting a product table...")
+	extractProducts()
+
+	// Example 5: Following "next" links through a paginated listing
+	fmt.Println("\n5. 📚 Following pagination...")
+	scrapeBookListing(*maxPages)
+
+	if results != nil {
+		if err := results.Close(); err != nil {
+			log.Printf("Error writing %s: %v", *output, err)
+		} else {
+			fmt.Printf("\n💾 Saved %d pages to %s\n", saved, *output)
+		}
+	}
 
 	// Prevent terminal window from closing on Windows
 	if runtime.GOOS == "windows" {
@@ -36,7 +117,7 @@ func main() {
 	}
 }
 
-func scrapeExample() {
+func scrapeExample(checker *robots.Checker) *PageResult {
 	// Create HTTP client with timeout
 	client := &http.Client{
 		Timeout: 10 * time.Second,
@@ -46,28 +127,22 @@ func scrapeExample() {
 	req, err := http.NewRequest("GET", "https://example.com", nil)
 	if err != nil {
 		log.Printf("Error creating request: %v", err)
-		return
+		return nil
 	}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		log.Printf("Error making request: %v", err)
-		return
+	resp, fetchedAt, ok := fetchOrSkip(client, checker, req)
+	if !ok {
+		return nil
 	}
 	defer resp.Body.Close()
 
-	// Check status code
-	if resp.StatusCode != 200 {
-		log.Printf("Status code error: %d %s", resp.StatusCode, resp.Status)
-		return
-	}
-
 	// Parse HTML document
 	doc, err := goquery.NewDocumentFromReader(resp.Body)
 	if err != nil {
 		log.Printf("Error parsing HTML: %v", err)
-		return
+		return nil
 	}
+	result := newPageResult(doc, resp, fetchedAt)
 
 	// Extract various elements
 	fmt.Printf("   📋 Title: %s\n", doc.Find("title").Text())
@@ -105,11 +180,13 @@ func scrapeExample() {
 			linkCount++
 		}
 	})
+
+	return &result
 }
 
-func scrapeNews() {
-	// Note: This is an example - always check robots.txt and terms of service
-	// before scraping real websites
+func scrapeNews(checker *robots.Checker) *PageResult {
+	// Note: This is an example - fetchPage checks robots.txt, but always
+	// read a site's terms of service before scraping it too
 
 	client := &http.Client{
 		Timeout: 10 * time.Second,
@@ -119,26 +196,21 @@ func scrapeNews() {
 	req, err := http.NewRequest("GET", "https://httpbin.org/html", nil)
 	if err != nil {
 		log.Printf("Error creating request: %v", err)
-		return
+		return nil
 	}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		log.Printf("Error making request: %v", err)
-		return
+	resp, fetchedAt, ok := fetchOrSkip(client, checker, req)
+	if !ok {
+		return nil
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		log.Printf("Status code error: %d %s", resp.StatusCode, resp.Status)
-		return
-	}
-
 	doc, err := goquery.NewDocumentFromReader(resp.Body)
 	if err != nil {
 		log.Printf("Error parsing HTML: %v", err)
-		return
+		return nil
 	}
+	result := newPageResult(doc, resp, fetchedAt)
 
 	fmt.Printf("   📋 Page title: %s\n", doc.Find("title").Text())
 
@@ -154,9 +226,11 @@ func scrapeNews() {
 			fmt.Printf("   • %s\n", text)
 		}
 	})
+
+	return &result
 }
 
-func scrapeWithHeaders() {
+func scrapeWithHeaders(checker *robots.Checker) *PageResult {
 	client := &http.Client{
 		Timeout: 10 * time.Second,
 	}
@@ -165,18 +239,17 @@ func scrapeWithHeaders() {
 	req, err := http.NewRequest("GET", "https://httpbin.org/headers", nil)
 	if err != nil {
 		log.Printf("Error creating request: %v", err)
-		return
+		return nil
 	}
 
 	// Add custom headers
-	req.Header.Set("User-Agent", "GoQuery-Demo/1.0 (Educational Purpose)")
+	req.Header.Set("User-Agent", userAgent)
 	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
 	req.Header.Set("Accept-Language", "en-US,en;q=0.5")
 
-	resp, err := client.Do(req)
-	if err != nil {
-		log.Printf("Error making request: %v", err)
-		return
+	resp, fetchedAt, ok := fetchOrSkip(client, checker, req)
+	if !ok {
+		return nil
 	}
 	defer resp.Body.Close()
 
@@ -188,8 +261,9 @@ func scrapeWithHeaders() {
 	doc, err := goquery.NewDocumentFromReader(resp.Body)
 	if err != nil {
 		log.Printf("Error parsing response: %v", err)
-		return
+		return nil
 	}
+	result := newPageResult(doc, resp, fetchedAt)
 
 	// Find pre tags (JSON content)
 	doc.Find("pre").Each(func(i int, s *goquery.Selection) {
@@ -204,6 +278,166 @@ func scrapeWithHeaders() {
 			}
 		}
 	})
+
+	return &result
+}
+
+// productsPage is a store's product listing, with a two-row header, a
+// <br> and a nested table in its cells
+//
+//go:embed fixtures/products.html
+var productsPage string
+
+// Product is a row of the table in productsPage
+type Product struct {
+	Name   string  `col:"Product"`
+	SKU    string  `col:"SKU"`
+	Price  float64 `col:"Price"`
+	Stock  int     `col:"In Stock"`
+	OnSale bool    `col:"On Sale"`
+}
+
+func extractProducts() {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(productsPage))
+	if err != nil {
+		log.Printf("Error parsing HTML: %v", err)
+		return
+	}
+
+	tables := ExtractTables(doc)
+	fmt.Printf("   📋 Found %d tables (one nested in a cell)\n", len(tables))
+	fmt.Printf("   🏷️ Headers: %s\n", strings.Join(tables[0].Headers, ", "))
+
+	products, err := ExtractTableAs[Product](tables[0])
+	if err != nil {
+		log.Printf("Error converting cells: %v", err)
+	}
+	for _, p := range products {
+		sale := ""
+		if p.OnSale {
+			sale = " (on sale)"
+		}
+		fmt.Printf("   🛒 %-20s %-7s $%8.2f  %3d in stock%s\n", p.Name, p.SKU, p.Price, p.Stock, sale)
+	}
+}
+
+// listingSite is a book listing split over three pages, linked by
+// rel="next"
+//
+//go:embed fixtures/listing
+var listingSite embed.FS
+
+// Book is an item of the listing in listingSite
+type Book struct {
+	Title string
+	Price string
+}
+
+func scrapeBookListing(maxPages int) {
+	// Serve the listing locally, so the example works offline
+	site, err := fs.Sub(listingSite, "fixtures/listing")
+	if err != nil {
+		log.Printf("Error opening the listing: %v", err)
+		return
+	}
+	srv := httptest.NewServer(http.FileServerFS(site))
+	defer srv.Close()
+
+	result, err := ScrapePaginated(context.Background(), srv.URL+"/page1.html", PaginateOptions[Book]{
+		Next: NextRel(),
+		Extract: func(doc *goquery.Document, page *url.URL) []Book {
+			var books []Book
+			doc.Find("li.book").Each(func(i int, s *goquery.Selection) {
+				books = append(books, Book{
+					Title: strings.TrimSpace(s.Find(".title").Text()),
+					Price: strings.TrimSpace(s.Find(".price").Text()),
+				})
+			})
+			return books
+		},
+		MaxPages: maxPages,
+		Delay:    200 * time.Millisecond,
+	})
+	if err != nil {
+		log.Printf("Error following pagination: %v", err)
+		if result == nil {
+			return
+		}
+	}
+
+	for i, page := range result.Pages {
+		fmt.Printf("   📄 Page %d: %s\n", i+1, strings.TrimPrefix(page, srv.URL))
+	}
+	for _, b := range result.Items {
+		fmt.Printf("   📖 %-40s %s\n", b.Title, b.Price)
+	}
+	fmt.Printf("   🛑 Stopped: %s, with %d books from %d pages\n", result.Stop, len(result.Items), len(result.Pages))
+}
+
+// runCrawl crawls from seed, prints the pages found, and writes the edge
+// list to edgesFile, or after the pages when it is empty
+func runCrawl(crawler *Crawler, seed, edgesFile string) error {
+	fmt.Printf("🕸️ Crawling %s (depth %d, up to %d pages)...\n\n", seed, crawler.MaxDepth, crawler.MaxPages)
+	result, err := crawler.Crawl(context.Background(), seed)
+	if err != nil {
+		return err
+	}
+	if err := writeCrawlResults(os.Stdout, result.Pages); err != nil {
+		return err
+	}
+
+	if edgesFile == "" {
+		fmt.Printf("\n🔗 %d edges:\n", len(result.Edges))
+		return writeEdges(os.Stdout, result.Edges)
+	}
+	f, err := os.Create(edgesFile)
+	if err != nil {
+		return err
+	}
+	if err := writeEdges(f, result.Edges); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Printf("\n🔗 Wrote %d edges to %s\n", len(result.Edges), edgesFile)
+	return nil
+}
+
+// fetchPage fetches req as the demo's user agent once checker's
+// robots.txt rules allow it, waiting out any Crawl-delay first. A
+// disallowed URL returns a *robots.DisallowedError without being fetched;
+// a nil checker allows everything.
+func fetchPage(client *http.Client, checker *robots.Checker, req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", userAgent)
+	}
+	if checker != nil {
+		if err := checker.Wait(req.Context(), req.URL); err != nil {
+			return nil, err
+		}
+	}
+	return fetchWithRetry(client, req, defaultRetryPolicy)
+}
+
+// fetchOrSkip fetches req through fetchPage, so robots.txt is checked and
+// transient failures, like a 503 or a dropped connection, are retried. It
+// returns when the fetch started, or logs why the page was skipped and
+// reports false.
+func fetchOrSkip(client *http.Client, checker *robots.Checker, req *http.Request) (*http.Response, time.Time, bool) {
+	fetchedAt := time.Now()
+	resp, err := fetchPage(client, checker, req)
+	var disallowed *robots.DisallowedError
+	if errors.As(err, &disallowed) {
+		log.Printf("Skipping %s: %s", disallowed.URL, disallowed.Reason)
+		return nil, fetchedAt, false
+	}
+	if err != nil {
+		log.Printf("Error making request: %v", err)
+		return nil, fetchedAt, false
+	}
+	return resp, fetchedAt, true
 }
 
 // Helper function to truncate text
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+
+	"example.com/goquery-demo/robots"
+)
+
+// NextFunc finds the URL of the page after page, whose document is doc,
+// or reports that there isn't one
+type NextFunc func(doc *goquery.Document, page *url.URL) (*url.URL, bool)
+
+// NextRel follows the rel="next" link, from a <link> in the head or an
+// <a> in the body, which is how most listings mark their next page
+func NextRel() NextFunc {
+	return func(doc *goquery.Document, page *url.URL) (*url.URL, bool) {
+		href, ok := doc.Find(`link[rel~="next"][href], a[rel~="next"][href]`).First().Attr("href")
+		if !ok {
+			return nil, false
+		}
+		return resolveNext(page, href)
+	}
+}
+
+// NextSelector follows the href of the first element selector matches,
+// or of the first link inside it, for a "Next" button marked some other
+// way. The last page is the one it matches nothing on.
+func NextSelector(selector string) (NextFunc, error) {
+	matcher, err := cascadia.Compile(selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid next-page selector %q: %w", selector, err)
+	}
+	return func(doc *goquery.Document, page *url.URL) (*url.URL, bool) {
+		next := doc.FindMatcher(matcher).First()
+		href, ok := next.Attr("href")
+		if !ok {
+			href, ok = next.Find("a[href]").First().Attr("href")
+		}
+		if !ok {
+			return nil, false
+		}
+		return resolveNext(page, href)
+	}, nil
+}
+
+// NextPageParam counts up the query parameter param, so ?page=2 follows
+// ?page=1, and a page without it is page 1. It always has a next page;
+// the run ends on a page with no items or one that isn't found.
+func NextPageParam(param string) NextFunc {
+	return func(doc *goquery.Document, page *url.URL) (*url.URL, bool) {
+		query := page.Query()
+		n, err := strconv.Atoi(query.Get(param))
+		if err != nil || n < 1 {
+			n = 1
+		}
+		query.Set(param, strconv.Itoa(n+1))
+		next := *page
+		next.RawQuery = query.Encode()
+		return &next, true
+	}
+}
+
+// resolveNext is href resolved against page
+func resolveNext(page *url.URL, href string) (*url.URL, bool) {
+	ref, err := url.Parse(href)
+	if err != nil {
+		return nil, false
+	}
+	return page.ResolveReference(ref), true
+}
+
+// PaginateOptions configures ScrapePaginated
+type PaginateOptions[T any] struct {
+	// Client defaults to one with a 10-second timeout
+	Client *http.Client
+	// Robots is consulted before every page request; nil fetches
+	// everything
+	Robots *robots.Checker
+	// Next finds each page's next page; nil is NextRel
+	Next NextFunc
+	// Extract returns the items on a page
+	Extract func(doc *goquery.Document, page *url.URL) []T
+	// MaxPages stops after this many pages; 0 means no limit
+	MaxPages int
+	// Delay is the least time between page requests, on top of any
+	// robots.txt Crawl-delay Robots asks for
+	Delay time.Duration
+	// Sleep waits for d or until ctx is done; nil uses a timer. Tests
+	// replace it to record the waits instead.
+	Sleep func(ctx context.Context, d time.Duration) error
+}
+
+// StopReason is why ScrapePaginated stopped following pages
+type StopReason int
+
+const (
+	// StopLastPage is a page with no next page, or a next page that
+	// wasn't found
+	StopLastPage StopReason = iota
+	// StopEmptyPage is a page with no items
+	StopEmptyPage
+	// StopLoop is a next page that was already scraped
+	StopLoop
+	// StopMaxPages is reaching MaxPages
+	StopMaxPages
+)
+
+func (r StopReason) String() string {
+	switch r {
+	case StopEmptyPage:
+		return "a page had no items"
+	case StopLoop:
+		return "the next page was already scraped"
+	case StopMaxPages:
+		return "reached the page limit"
+	}
+	return "reached the last page"
+}
+
+// Paginated is what ScrapePaginated found
+type Paginated[T any] struct {
+	// Items are from every page, in order
+	Items []T
+	// Pages are the URLs scraped, in order
+	Pages []string
+	Stop  StopReason
+	// LoopURL is the page that was seen twice, when Stop is StopLoop
+	LoopURL string
+}
+
+// ScrapePaginated scrapes startURL and each next page after it, fetching
+// through fetchPage, and collects the items Extract finds on them. It
+// stops at the last page, an empty page, a page it has already scraped,
+// or MaxPages. An error fetching a page after the first is returned with
+// the items from the pages before it.
+func ScrapePaginated[T any](ctx context.Context, startURL string, opts PaginateOptions[T]) (*Paginated[T], error) {
+	page, err := url.Parse(startURL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing start URL: %w", err)
+	}
+	if opts.Extract == nil {
+		return nil, errors.New("ScrapePaginated needs an Extract func")
+	}
+	next := opts.Next
+	if next == nil {
+		next = NextRel()
+	}
+	sleep := opts.Sleep
+	if sleep == nil {
+		sleep = sleepContext
+	}
+	client := opts.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	result := &Paginated[T]{}
+	seen := make(map[string]bool)
+	for {
+		normalizeURL(page)
+		seen[page.String()] = true
+		if len(result.Pages) > 0 && opts.Delay > 0 {
+			if err := sleep(ctx, opts.Delay); err != nil {
+				return result, err
+			}
+		}
+
+		doc, final, err := fetchListingPage(ctx, client, opts.Robots, page)
+		var fetchErr *FetchError
+		if len(result.Pages) > 0 && errors.As(err, &fetchErr) && fetchErr.StatusCode == http.StatusNotFound {
+			result.Stop = StopLastPage
+			return result, nil
+		}
+		if err != nil {
+			return result, fmt.Errorf("page %d (%s): %w", len(result.Pages)+1, page, err)
+		}
+		result.Pages = append(result.Pages, page.String())
+
+		items := opts.Extract(doc, final)
+		result.Items = append(result.Items, items...)
+		if len(items) == 0 {
+			result.Stop = StopEmptyPage
+			return result, nil
+		}
+		if opts.MaxPages > 0 && len(result.Pages) >= opts.MaxPages {
+			result.Stop = StopMaxPages
+			return result, nil
+		}
+
+		nextPage, ok := next(doc, final)
+		if !ok {
+			result.Stop = StopLastPage
+			return result, nil
+		}
+		normalizeURL(nextPage)
+		if seen[nextPage.String()] {
+			result.Stop, result.LoopURL = StopLoop, nextPage.String()
+			return result, nil
+		}
+		page = nextPage
+	}
+}
+
+// fetchListingPage fetches and parses page, if checker allows, returning
+// the URL it was finally served from after any redirects
+func fetchListingPage(ctx context.Context, client *http.Client, checker *robots.Checker, page *url.URL) (*goquery.Document, *url.URL, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", page.String(), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := fetchPage(client, checker, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parsing HTML: %w", err)
+	}
+	return doc, resp.Request.URL, nil
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// listingServer serves pages by path and query, counting the requests
+// for each
+func listingServer(t *testing.T, pages map[string]string) (*httptest.Server, func() map[string]int) {
+	t.Helper()
+	var mu sync.Mutex
+	hits := make(map[string]int)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits[r.URL.RequestURI()]++
+		mu.Unlock()
+		body, ok := pages[r.URL.RequestURI()]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() map[string]int {
+		mu.Lock()
+		defer mu.Unlock()
+		return hits
+	}
+}
+
+// items is an Extract func returning each li's text
+func items(doc *goquery.Document, page *url.URL) []string {
+	return doc.Find("li").Map(func(i int, s *goquery.Selection) string { return s.Text() })
+}
+
+// paginate runs ScrapePaginated from path on srv with items, recording
+// any waits rather than waiting
+func paginate(t *testing.T, srv *httptest.Server, path string, opts PaginateOptions[string]) *Paginated[string] {
+	t.Helper()
+	opts.Client = srv.Client()
+	opts.Extract = items
+	if opts.Sleep == nil {
+		opts.Sleep = recordSleeps(new([]time.Duration))
+	}
+	result, err := ScrapePaginated(context.Background(), srv.URL+path, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+// checkPaginated checks the items, the pages, without the server's
+// address, and why it stopped
+func checkPaginated(t *testing.T, srv *httptest.Server, got *Paginated[string], items, pages []string, stop StopReason) {
+	t.Helper()
+	var gotPages []string
+	for _, p := range got.Pages {
+		gotPages = append(gotPages, strings.TrimPrefix(p, srv.URL))
+	}
+	if !slices.Equal(got.Items, items) || !slices.Equal(gotPages, pages) || got.Stop != stop {
+		t.Errorf("got items %q, pages %q, stopped because %s\nwant items %q, pages %q, stopped because %s",
+			got.Items, gotPages, got.Stop, items, pages, stop)
+	}
+}
+
+func TestPaginateRelNext(t *testing.T) {
+	srv, hits := listingServer(t, map[string]string{
+		"/list":   `<head><link rel="next" href="/list/2"></head><li>a</li><li>b</li>`,
+		"/list/2": `<li>c</li><a rel="prev" href="/list">Back</a> <a rel="noopener next" href="3#results">More</a>`,
+		"/list/3": `<li>d</li><a href="/list/4">Not marked next</a>`,
+		"/list/4": `<li>never fetched</li>`,
+	})
+	got := paginate(t, srv, "/list", PaginateOptions[string]{})
+
+	// The fragment is dropped, so /list/3#results is fetched as /list/3
+	checkPaginated(t, srv, got, []string{"a", "b", "c", "d"}, []string{"/list", "/list/2", "/list/3"}, StopLastPage)
+	if hits()["/list/4"] != 0 {
+		t.Error("followed a link that isn't rel=next")
+	}
+}
+
+func TestPaginateSelector(t *testing.T) {
+	srv, _ := listingServer(t, map[string]string{
+		"/a": `<li>1</li><div class="pager"><span class="next"><a href="/b">»</a></span></div>`,
+		"/b": `<li>2</li><a class="next" href="/c">»</a>`,
+		// The selector matches nothing here, so this is the last page
+		"/c": `<li>3</li><span class="next disabled">»</span><a href="/d">4</a>`,
+	})
+	next, err := NextSelector(".next")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := paginate(t, srv, "/a", PaginateOptions[string]{Next: next})
+	checkPaginated(t, srv, got, []string{"1", "2", "3"}, []string{"/a", "/b", "/c"}, StopLastPage)
+
+	if _, err := NextSelector("a[href"); err == nil {
+		t.Error("NextSelector accepted an invalid selector")
+	}
+}
+
+func TestPaginatePageParam(t *testing.T) {
+	pages := map[string]string{"/search?q=go": "<li>result 1</li>"}
+	for n := 2; n <= 3; n++ {
+		pages[fmt.Sprintf("/search?page=%d&q=go", n)] = fmt.Sprintf("<li>result %d</li>", n)
+	}
+	want := []string{"result 1", "result 2", "result 3"}
+
+	// Past the last page, a 404 ends the run
+	srv, _ := listingServer(t, pages)
+	got := paginate(t, srv, "/search?q=go", PaginateOptions[string]{Next: NextPageParam("page")})
+	checkPaginated(t, srv, got, want, []string{"/search?q=go", "/search?page=2&q=go", "/search?page=3&q=go"}, StopLastPage)
+
+	// So does a page with no items
+	pages["/search?page=4&q=go"] = `<p>No more results</p>`
+	srv, _ = listingServer(t, pages)
+	got = paginate(t, srv, "/search?q=go", PaginateOptions[string]{Next: NextPageParam("page")})
+	checkPaginated(t, srv, got, want, []string{"/search?q=go", "/search?page=2&q=go", "/search?page=3&q=go", "/search?page=4&q=go"}, StopEmptyPage)
+
+	// A page that isn't found at the start is an error, though
+	if _, err := ScrapePaginated(context.Background(), srv.URL+"/nothing", PaginateOptions[string]{Client: srv.Client(), Extract: items}); err == nil || !strings.Contains(err.Error(), "page 1") {
+		t.Errorf("err = %v, want page 1 not found", err)
+	}
+}
+
+func TestPaginateLoopGuard(t *testing.T) {
+	srv, hits := listingServer(t, map[string]string{
+		"/1": `<li>a</li><a rel="next" href="/2">next</a>`,
+		"/2": `<li>b</li><a rel="next" href="/3">next</a>`,
+		// Back to the start, with a fragment that doesn't make it new
+		"/3": `<li>c</li><a rel="next" href="/1#top">next</a>`,
+	})
+	got := paginate(t, srv, "/1", PaginateOptions[string]{})
+	checkPaginated(t, srv, got, []string{"a", "b", "c"}, []string{"/1", "/2", "/3"}, StopLoop)
+	if got.LoopURL != srv.URL+"/1" {
+		t.Errorf("LoopURL = %q", got.LoopURL)
+	}
+	for path, n := range hits() {
+		if n != 1 {
+			t.Errorf("%s fetched %d times", path, n)
+		}
+	}
+
+	// A last page linking to itself is a loop too
+	srv, _ = listingServer(t, map[string]string{"/only": `<li>x</li><link rel="next" href="/only">`})
+	got = paginate(t, srv, "/only", PaginateOptions[string]{})
+	checkPaginated(t, srv, got, []string{"x"}, []string{"/only"}, StopLoop)
+}
+
+func TestPaginateMaxPagesAndDelay(t *testing.T) {
+	pages := make(map[string]string)
+	for n := 1; n <= 10; n++ {
+		pages[fmt.Sprintf("/p/%d", n)] = fmt.Sprintf(`<li>%d</li><a rel="next" href="/p/%d">next</a>`, n, n+1)
+	}
+	srv, hits := listingServer(t, pages)
+	var waits []time.Duration
+	got := paginate(t, srv, "/p/1", PaginateOptions[string]{MaxPages: 3, Delay: time.Second, Sleep: recordSleeps(&waits)})
+
+	checkPaginated(t, srv, got, []string{"1", "2", "3"}, []string{"/p/1", "/p/2", "/p/3"}, StopMaxPages)
+	if hits()["/p/4"] != 0 {
+		t.Error("fetched past MaxPages")
+	}
+	// The delay comes between pages, not before the first
+	if want := []time.Duration{time.Second, time.Second}; !slices.Equal(waits, want) {
+		t.Errorf("waits %v, want %v", waits, want)
+	}
+}
+
+func TestPaginateFixtureSite(t *testing.T) {
+	// The demo's three-page listing: what the fifth example prints
+	site, err := fs.Sub(listingSite, "fixtures/listing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.FileServerFS(site))
+	defer srv.Close()
+
+	got, err := ScrapePaginated(context.Background(), srv.URL+"/page1.html", PaginateOptions[string]{
+		Client: srv.Client(),
+		Extract: func(doc *goquery.Document, page *url.URL) []string {
+			return doc.Find("li.book .title").Map(func(i int, s *goquery.Selection) string { return s.Text() })
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkPaginated(t, srv, got, []string{
+		"The Go Programming Language", "Learning Go", "Concurrency in Go", "Go in Action", "100 Go Mistakes and How to Avoid Them",
+	}, []string{"/page1.html", "/page2.html", "/page3.html"}, StopLastPage)
+}
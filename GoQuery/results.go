@@ -0,0 +1,215 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// PageResult is what the demo scraped from one page
+type PageResult struct {
+	URL         string    `json:"url"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Status      int       `json:"status"`
+	FetchedAt   time.Time `json:"fetched_at"`
+	Headings    []Heading `json:"headings"`
+	Links       []Link    `json:"links"`
+	Images      []Image   `json:"images"`
+}
+
+// Heading is an h1 to h6 element
+type Heading struct {
+	Level string `json:"level"`
+	Text  string `json:"text"`
+}
+
+// Link is an a[href] element, with the href resolved against the page
+type Link struct {
+	URL  string `json:"url"`
+	Text string `json:"text"`
+}
+
+// Image is an img element, with the src resolved against the page
+type Image struct {
+	Src string `json:"src"`
+	Alt string `json:"alt"`
+}
+
+// newPageResult collects a PageResult from doc, parsed from resp, which
+// was requested at fetchedAt
+func newPageResult(doc *goquery.Document, resp *http.Response, fetchedAt time.Time) PageResult {
+	page := resp.Request.URL
+	result := PageResult{
+		URL:       page.String(),
+		Title:     strings.TrimSpace(doc.Find("title").First().Text()),
+		Status:    resp.StatusCode,
+		FetchedAt: fetchedAt.UTC(),
+		Headings:  []Heading{},
+		Links:     []Link{},
+		Images:    []Image{},
+	}
+
+	meta := ExtractMetaTags(doc)
+	result.Description = meta["description"]
+	if result.Description == "" {
+		result.Description = meta["og:description"]
+	}
+
+	doc.Find("h1, h2, h3, h4, h5, h6").Each(func(i int, s *goquery.Selection) {
+		if text := strings.TrimSpace(s.Text()); text != "" {
+			result.Headings = append(result.Headings, Heading{Level: goquery.NodeName(s), Text: text})
+		}
+	})
+	doc.Find("a[href]").Each(func(i int, s *goquery.Selection) {
+		href, _ := s.Attr("href")
+		result.Links = append(result.Links, Link{URL: resolveURL(page, href), Text: strings.TrimSpace(s.Text())})
+	})
+	for _, img := range ExtractImages(doc) {
+		result.Images = append(result.Images, Image{Src: resolveURL(page, img["src"]), Alt: img["alt"]})
+	}
+	return result
+}
+
+// resolveURL is ref resolved against base, or ref as it is if it doesn't
+// parse
+func resolveURL(base *url.URL, ref string) string {
+	u, err := url.Parse(strings.TrimSpace(ref))
+	if err != nil {
+		return ref
+	}
+	return base.ResolveReference(u).String()
+}
+
+// resultWriter saves PageResults as they are scraped
+type resultWriter interface {
+	Write(PageResult) error
+	// Close finishes the file; nothing written may be complete before it
+	Close() error
+}
+
+// newResultWriter creates path and writes results to it in the format
+// its extension names: .json, .csv or .jsonl
+func newResultWriter(path string) (resultWriter, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != ".json" && ext != ".csv" && ext != ".jsonl" {
+		return nil, fmt.Errorf("unknown output format %q: use .json, .csv or .jsonl", ext)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	switch ext {
+	case ".csv":
+		w, err := newCSVWriter(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return w, nil
+	case ".jsonl":
+		return &jsonlWriter{f: f, enc: json.NewEncoder(f)}, nil
+	}
+	return &jsonWriter{f: f, results: []PageResult{}}, nil
+}
+
+// jsonWriter writes all the results as one JSON array when it is closed
+type jsonWriter struct {
+	f       io.WriteCloser
+	results []PageResult
+}
+
+func (w *jsonWriter) Write(r PageResult) error {
+	w.results = append(w.results, r)
+	return nil
+}
+
+func (w *jsonWriter) Close() error {
+	enc := json.NewEncoder(w.f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(w.results); err != nil {
+		w.f.Close()
+		return err
+	}
+	return w.f.Close()
+}
+
+// jsonlWriter writes each result as a line of JSON as soon as it has it,
+// so a crash keeps the pages already scraped
+type jsonlWriter struct {
+	f   io.WriteCloser
+	enc *json.Encoder
+}
+
+func (w *jsonlWriter) Write(r PageResult) error { return w.enc.Encode(r) }
+
+func (w *jsonlWriter) Close() error { return w.f.Close() }
+
+// csvHeader is the columns of the CSV output
+var csvHeader = []string{"url", "title", "description", "status", "fetched_at", "headings", "links", "images"}
+
+// listSeparator joins the items of a list column in the CSV output
+const listSeparator = " | "
+
+// csvWriter writes a row per result, flushed as each is written. List
+// columns are flattened: headings to "h1: text", links to their URLs and
+// images to their srcs, joined by listSeparator. The JSON formats keep
+// everything.
+type csvWriter struct {
+	f   io.WriteCloser
+	csv *csv.Writer
+}
+
+func newCSVWriter(f io.WriteCloser) (*csvWriter, error) {
+	w := &csvWriter{f: f, csv: csv.NewWriter(f)}
+	if err := w.csv.Write(csvHeader); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *csvWriter) Write(r PageResult) error {
+	headings := make([]string, len(r.Headings))
+	for i, h := range r.Headings {
+		headings[i] = h.Level + ": " + h.Text
+	}
+	links := make([]string, len(r.Links))
+	for i, l := range r.Links {
+		links[i] = l.URL
+	}
+	images := make([]string, len(r.Images))
+	for i, img := range r.Images {
+		images[i] = img.Src
+	}
+	w.csv.Write([]string{
+		r.URL,
+		r.Title,
+		r.Description,
+		strconv.Itoa(r.Status),
+		r.FetchedAt.Format(time.RFC3339),
+		strings.Join(headings, listSeparator),
+		strings.Join(links, listSeparator),
+		strings.Join(images, listSeparator),
+	})
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+func (w *csvWriter) Close() error {
+	w.csv.Flush()
+	if err := w.csv.Error(); err != nil {
+		w.f.Close()
+		return err
+	}
+	return w.f.Close()
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// fixturePages are served by fixtureServer
+var fixturePages = map[string]string{
+	"/cafe": `<!DOCTYPE html>
+<html><head>
+<title> Café Ünïcödé — 日本語 🍜 </title>
+<meta name="description" content="Crème brûlée, «quoted», and a comma, here">
+</head><body>
+<h1>Menü</h1>
+<h2>Soups &amp; "Noodles"</h2>
+<h3>   </h3>
+<a href="/menu?lang=fr&amp;page=2">Carte</a>
+<a href="https://example.org/été">Été</a>
+<a href="#top">Top</a>
+<img src="img/ramen.png" alt="ラーメン">
+<img src="/logo.svg">
+</body></html>`,
+	"/bare": `<html><head><meta property="og:description" content="From Open Graph"></head><body>No title</body></html>`,
+}
+
+// fixtureServer serves fixturePages
+func fixtureServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := fixturePages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// fixtureTime stands in for the fetch time, whole seconds as CSV keeps
+var fixtureTime = time.Date(2025, 6, 1, 12, 30, 0, 0, time.UTC)
+
+// scrapeFixtures scrapes each path from srv the way the examples do
+func scrapeFixtures(t *testing.T, srv *httptest.Server, paths ...string) []PageResult {
+	t.Helper()
+	var results []PageResult
+	for _, path := range paths {
+		req, err := http.NewRequest("GET", srv.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := fetchPage(srv.Client(), nil, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		doc, err := goquery.NewDocumentFromReader(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		result := newPageResult(doc, resp, time.Now())
+		result.FetchedAt = fixtureTime
+		results = append(results, result)
+	}
+	return results
+}
+
+// writeResults writes results to a file named name in a temporary
+// directory, and returns its path
+func writeResults(t *testing.T, name string, results []PageResult) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	w, err := newResultWriter(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range results {
+		if err := w.Write(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// readFile reads path, checking that it is valid UTF-8
+func readFile(t *testing.T, path string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !utf8.Valid(data) {
+		t.Errorf("%s is not valid UTF-8", filepath.Base(path))
+	}
+	return data
+}
+
+func TestNewPageResult(t *testing.T) {
+	srv := fixtureServer(t)
+	results := scrapeFixtures(t, srv, "/cafe", "/bare")
+
+	want := PageResult{
+		URL:         srv.URL + "/cafe",
+		Title:       "Café Ünïcödé — 日本語 🍜",
+		Description: "Crème brûlée, «quoted», and a comma, here",
+		Status:      200,
+		FetchedAt:   fixtureTime,
+		Headings:    []Heading{{"h1", "Menü"}, {"h2", `Soups & "Noodles"`}},
+		Links: []Link{
+			{srv.URL + "/menu?lang=fr&page=2", "Carte"},
+			{"https://example.org/%C3%A9t%C3%A9", "Été"},
+			{srv.URL + "/cafe#top", "Top"},
+		},
+		Images: []Image{
+			{srv.URL + "/img/ramen.png", "ラーメン"},
+			{srv.URL + "/logo.svg", "No alt text"},
+		},
+	}
+	if !reflect.DeepEqual(results[0], want) {
+		t.Errorf("got  %+v\nwant %+v", results[0], want)
+	}
+
+	// Empty lists rather than nil, so JSON has [] not null
+	bare := results[1]
+	if bare.Title != "" || bare.Description != "From Open Graph" || bare.Headings == nil || bare.Links == nil || bare.Images == nil {
+		t.Errorf("bare page %+v", bare)
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	results := scrapeFixtures(t, fixtureServer(t), "/cafe", "/bare")
+	data := readFile(t, writeResults(t, "results.json", results))
+
+	var got []PageResult
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, results) {
+		t.Errorf("round trip changed the results:\n%s", data)
+	}
+	// Text is written as UTF-8, not \u escapes
+	if !bytes.Contains(data, []byte("日本語 🍜")) || !bytes.Contains(data, []byte(`"headings": []`)) {
+		t.Errorf("unexpected JSON:\n%s", data)
+	}
+}
+
+func TestJSONLStreamsAndRoundTrips(t *testing.T) {
+	results := scrapeFixtures(t, fixtureServer(t), "/cafe", "/bare")
+	path := filepath.Join(t.TempDir(), "results.JSONL")
+	w, err := newResultWriter(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each record is on disk as soon as it is written
+	for i, r := range results {
+		if err := w.Write(r); err != nil {
+			t.Fatal(err)
+		}
+		if lines := bytes.Count(readFile(t, path), []byte("\n")); lines != i+1 {
+			t.Errorf("after %d writes the file has %d lines", i+1, lines)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []PageResult
+	scanner := bufio.NewScanner(bytes.NewReader(readFile(t, path)))
+	for scanner.Scan() {
+		var r PageResult
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			t.Fatalf("line %d: %v", len(got)+1, err)
+		}
+		got = append(got, r)
+	}
+	if !reflect.DeepEqual(got, results) {
+		t.Errorf("round trip changed the results: %+v", got)
+	}
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	srv := fixtureServer(t)
+	results := scrapeFixtures(t, srv, "/cafe", "/bare")
+	data := readFile(t, writeResults(t, "results.csv", results))
+
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 || !slices.Equal(records[0], csvHeader) {
+		t.Fatalf("got %d records, header %v", len(records), records[0])
+	}
+
+	cafe := make(map[string]string)
+	for i, column := range csvHeader {
+		cafe[column] = records[1][i]
+	}
+	// Quotes and commas in values survive the CSV quoting
+	if cafe["title"] != "Café Ünïcödé — 日本語 🍜" || cafe["description"] != "Crème brûlée, «quoted», and a comma, here" {
+		t.Errorf("title %q, description %q", cafe["title"], cafe["description"])
+	}
+	if cafe["status"] != "200" || cafe["fetched_at"] != "2025-06-01T12:30:00Z" {
+		t.Errorf("status %q, fetched_at %q", cafe["status"], cafe["fetched_at"])
+	}
+
+	// List columns flatten to one cell, split back by the separator
+	lists := map[string][]string{
+		"headings": {"h1: Menü", `h2: Soups & "Noodles"`},
+		"links":    {srv.URL + "/menu?lang=fr&page=2", "https://example.org/%C3%A9t%C3%A9", srv.URL + "/cafe#top"},
+		"images":   {srv.URL + "/img/ramen.png", srv.URL + "/logo.svg"},
+	}
+	for column, want := range lists {
+		if got := strings.Split(cafe[column], listSeparator); !slices.Equal(got, want) {
+			t.Errorf("%s = %q, want %q", column, got, want)
+		}
+	}
+
+	// Empty lists are empty cells
+	if bare := records[2]; bare[5] != "" || bare[6] != "" || bare[7] != "" {
+		t.Errorf("bare page lists %q", bare[5:])
+	}
+}
+
+func TestNewResultWriterRejectsUnknownFormats(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.xml")
+	if _, err := newResultWriter(path); err == nil || !strings.Contains(err.Error(), ".xml") {
+		t.Errorf("err = %v, want one naming .xml", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("results.xml was created")
+	}
+}
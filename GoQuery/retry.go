@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy controls how fetchWithRetry retries a request
+type RetryPolicy struct {
+	// Attempts is how many times to try in all, counting the first
+	Attempts int
+	// BaseDelay is the wait after the first failure; it doubles after
+	// each one after that, up to MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Jitter is the fraction of each wait, from 0 to 1, that is taken off
+	// at random, so many scrapers that failed together don't all retry at
+	// once
+	Jitter float64
+	// Sleep waits for d or until ctx is done; nil uses a timer. Tests
+	// replace it to record the waits instead.
+	Sleep func(ctx context.Context, d time.Duration) error
+}
+
+// defaultRetryPolicy tries four times over about four seconds
+var defaultRetryPolicy = RetryPolicy{
+	Attempts:  4,
+	BaseDelay: 500 * time.Millisecond,
+	MaxDelay:  10 * time.Second,
+	Jitter:    0.2,
+}
+
+// ErrorClass is whether a failed request is worth trying again
+type ErrorClass int
+
+const (
+	// Permanent failures, like a 404 or a bad URL, fail the same way
+	// every time
+	Permanent ErrorClass = iota
+	// Retryable failures, like a 503, a timeout or a reset connection,
+	// may succeed on another try
+	Retryable
+)
+
+func (c ErrorClass) String() string {
+	if c == Retryable {
+		return "retryable"
+	}
+	return "permanent"
+}
+
+// FetchError is why fetchWithRetry gave up on a request
+type FetchError struct {
+	URL      string
+	Class    ErrorClass
+	Attempts int
+	// StatusCode is the last response's status, or 0 when there was no
+	// response
+	StatusCode int
+	Err        error
+}
+
+func (e *FetchError) Error() string {
+	return fmt.Sprintf("fetching %s: %v (%s, after %d attempts)", e.URL, e.Err, e.Class, e.Attempts)
+}
+
+func (e *FetchError) Unwrap() error { return e.Err }
+
+// fetchWithRetry sends req with client, trying again with exponential
+// backoff while the failure is retryable: a 5xx or 429 status, a timeout,
+// or a refused or reset connection. A retryable response with a
+// Retry-After header, as a 429 or 503 often has, waits as long as it asks,
+// giving up if that is longer than policy.MaxDelay. Any other 4xx, or
+// another error, fails at once. The response is returned when its status
+// is below 400; otherwise the error is a *FetchError with the
+// classification. req must have no body, as a GET doesn't, so it can be
+// sent again.
+func fetchWithRetry(client *http.Client, req *http.Request, policy RetryPolicy) (*http.Response, error) {
+	ctx := req.Context()
+	sleep := policy.Sleep
+	if sleep == nil {
+		sleep = sleepContext
+	}
+
+	delay := policy.BaseDelay
+	for attempt := 1; ; attempt++ {
+		resp, err := client.Do(req.Clone(ctx))
+		if err == nil && resp.StatusCode < 400 {
+			return resp, nil
+		}
+
+		fetchErr := &FetchError{URL: req.URL.String(), Attempts: attempt}
+		wait := jittered(delay, policy.Jitter)
+		if err != nil {
+			fetchErr.Class, fetchErr.Err = classifyError(err), err
+		} else {
+			fetchErr.StatusCode = resp.StatusCode
+			fetchErr.Class = classifyStatus(resp.StatusCode)
+			fetchErr.Err = errors.New(resp.Status)
+			if after, ok := retryAfter(resp.Header.Get("Retry-After"), time.Now()); ok && fetchErr.Class == Retryable {
+				if after > policy.MaxDelay {
+					fetchErr.Class = Permanent
+					fetchErr.Err = fmt.Errorf("%s, and Retry-After asks for %s, more than %s", resp.Status, after, policy.MaxDelay)
+				}
+				wait = after
+			}
+			// Read what is left so the connection can be reused
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+
+		if fetchErr.Class == Permanent || attempt >= policy.Attempts {
+			return nil, fetchErr
+		}
+		if err := sleep(ctx, wait); err != nil {
+			fetchErr.Err = fmt.Errorf("%w; stopped waiting to retry: %w", fetchErr.Err, err)
+			return nil, fetchErr
+		}
+		delay = min(delay*2, policy.MaxDelay)
+	}
+}
+
+// classifyStatus sorts a failed response's status: server errors and 429
+// Too Many Requests may pass, other client errors won't
+func classifyStatus(code int) ErrorClass {
+	if code >= 500 || code == http.StatusTooManyRequests {
+		return Retryable
+	}
+	return Permanent
+}
+
+// classifyError sorts an error from sending a request: timeouts, refused
+// or reset connections and connections closed mid-response may pass;
+// anything else, like a bad URL, a TLS failure or a cancelled request,
+// won't
+func classifyError(err error) ErrorClass {
+	if errors.Is(err, context.Canceled) {
+		return Permanent
+	}
+	var netErr net.Error
+	switch {
+	case errors.As(err, &netErr) && netErr.Timeout(),
+		errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, io.EOF):
+		return Retryable
+	}
+	return Permanent
+}
+
+// retryAfter reads a Retry-After header, either seconds or an HTTP date,
+// as how long to wait from now
+func retryAfter(header string, now time.Time) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(header); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// jittered takes up to fraction of d off at random
+func jittered(d time.Duration, fraction float64) time.Duration {
+	if fraction <= 0 || d <= 0 {
+		return d
+	}
+	return d - time.Duration(rand.Float64()*fraction*float64(d))
+}
+
+// sleepContext waits for d, or returns ctx's error if it is done first
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
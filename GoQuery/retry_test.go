@@ -0,0 +1,280 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyServer fails the first failures requests with status, then
+// succeeds, counting every request in hits
+func flakyServer(t *testing.T, failures int, status int, header http.Header) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if int(hits.Add(1)) <= failures {
+			for key, values := range header {
+				w.Header()[key] = values
+			}
+			w.WriteHeader(status)
+			return
+		}
+		w.Write([]byte("<title>ok</title>"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+// recordSleeps is a policy's Sleep that records each wait without waiting
+func recordSleeps(waits *[]time.Duration) func(context.Context, time.Duration) error {
+	return func(ctx context.Context, d time.Duration) error {
+		*waits = append(*waits, d)
+		return nil
+	}
+}
+
+// fetch sends a GET for url through fetchWithRetry with policy
+func fetch(t *testing.T, url string, policy RetryPolicy) (*http.Response, error) {
+	t.Helper()
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := fetchWithRetry(&http.Client{Timeout: 5 * time.Second}, req, policy)
+	if resp != nil {
+		t.Cleanup(func() { resp.Body.Close() })
+	}
+	return resp, err
+}
+
+func TestFetchWithRetryRecovers(t *testing.T) {
+	for _, status := range []int{http.StatusServiceUnavailable, http.StatusInternalServerError, http.StatusBadGateway} {
+		srv, hits := flakyServer(t, 2, status, nil)
+		var waits []time.Duration
+		resp, err := fetch(t, srv.URL, RetryPolicy{Attempts: 4, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second, Sleep: recordSleeps(&waits)})
+		if err != nil || resp.StatusCode != http.StatusOK {
+			t.Fatalf("%d twice, then 200: %v", status, err)
+		}
+		if hits.Load() != 3 {
+			t.Errorf("%d: %d attempts, want 3", status, hits.Load())
+		}
+		if want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond}; !slices.Equal(waits, want) {
+			t.Errorf("%d: waits %v, want %v", status, waits, want)
+		}
+	}
+}
+
+func TestFetchWithRetryBackoffGrowth(t *testing.T) {
+	srv, hits := flakyServer(t, 100, http.StatusServiceUnavailable, nil)
+	var waits []time.Duration
+	_, err := fetch(t, srv.URL, RetryPolicy{Attempts: 6, BaseDelay: 100 * time.Millisecond, MaxDelay: 500 * time.Millisecond, Sleep: recordSleeps(&waits)})
+
+	// Doubling, up to MaxDelay, with no wait after the last attempt
+	want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 500 * time.Millisecond, 500 * time.Millisecond}
+	if !slices.Equal(waits, want) {
+		t.Errorf("waits %v, want %v", waits, want)
+	}
+	if hits.Load() != 6 {
+		t.Errorf("%d attempts, want 6", hits.Load())
+	}
+	var fetchErr *FetchError
+	if !errors.As(err, &fetchErr) || fetchErr.Class != Retryable || fetchErr.Attempts != 6 || fetchErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("err = %#v, want a retryable FetchError after 6 attempts", err)
+	}
+	if !strings.Contains(err.Error(), "503 Service Unavailable (retryable, after 6 attempts)") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestFetchWithRetryJitter(t *testing.T) {
+	srv, _ := flakyServer(t, 100, http.StatusServiceUnavailable, nil)
+	var waits []time.Duration
+	fetch(t, srv.URL, RetryPolicy{Attempts: 20, BaseDelay: time.Second, MaxDelay: time.Second, Jitter: 0.5, Sleep: recordSleeps(&waits)})
+	for _, wait := range waits {
+		if wait < 500*time.Millisecond || wait > time.Second {
+			t.Errorf("wait %s is outside [500ms, 1s]", wait)
+		}
+	}
+	if slices.Min(waits) == slices.Max(waits) {
+		t.Errorf("19 waits were all %s; want them spread out", waits[0])
+	}
+}
+
+func TestFetchWithRetryPermanent(t *testing.T) {
+	for _, status := range []int{http.StatusNotFound, http.StatusForbidden, http.StatusBadRequest, http.StatusUnauthorized} {
+		srv, hits := flakyServer(t, 100, status, nil)
+		var waits []time.Duration
+		_, err := fetch(t, srv.URL, RetryPolicy{Attempts: 4, BaseDelay: time.Millisecond, MaxDelay: time.Second, Sleep: recordSleeps(&waits)})
+		var fetchErr *FetchError
+		if !errors.As(err, &fetchErr) || fetchErr.Class != Permanent || fetchErr.StatusCode != status {
+			t.Errorf("%d: err = %v, want a permanent FetchError", status, err)
+		}
+		if hits.Load() != 1 || len(waits) != 0 {
+			t.Errorf("%d: %d attempts and %d waits, want 1 and none", status, hits.Load(), len(waits))
+		}
+	}
+}
+
+func TestFetchWithRetryAfter(t *testing.T) {
+	policy := func(waits *[]time.Duration) RetryPolicy {
+		return RetryPolicy{Attempts: 3, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Minute, Sleep: recordSleeps(waits)}
+	}
+
+	// 429 is the one 4xx worth retrying, and Retry-After replaces the backoff
+	srv, hits := flakyServer(t, 1, http.StatusTooManyRequests, http.Header{"Retry-After": {"7"}})
+	var waits []time.Duration
+	if _, err := fetch(t, srv.URL, policy(&waits)); err != nil {
+		t.Fatalf("429 then 200: %v", err)
+	}
+	if hits.Load() != 2 || !slices.Equal(waits, []time.Duration{7 * time.Second}) {
+		t.Errorf("%d attempts, waits %v; want 2 and [7s]", hits.Load(), waits)
+	}
+
+	// An HTTP date
+	at := time.Now().Add(30 * time.Second).UTC().Format(http.TimeFormat)
+	srv, _ = flakyServer(t, 1, http.StatusServiceUnavailable, http.Header{"Retry-After": {at}})
+	waits = nil
+	if _, err := fetch(t, srv.URL, policy(&waits)); err != nil {
+		t.Fatalf("503 then 200: %v", err)
+	}
+	if len(waits) != 1 || waits[0] < 28*time.Second || waits[0] > 30*time.Second {
+		t.Errorf("waits %v, want about 30s", waits)
+	}
+
+	// Without the header, 429 backs off as usual
+	srv, _ = flakyServer(t, 1, http.StatusTooManyRequests, nil)
+	waits = nil
+	if _, err := fetch(t, srv.URL, policy(&waits)); err != nil || !slices.Equal(waits, []time.Duration{100 * time.Millisecond}) {
+		t.Errorf("429 without Retry-After: %v, waits %v", err, waits)
+	}
+
+	// Longer than MaxDelay is not worth waiting for
+	srv, hits = flakyServer(t, 1, http.StatusTooManyRequests, http.Header{"Retry-After": {"3600"}})
+	waits = nil
+	_, err := fetch(t, srv.URL, policy(&waits))
+	var fetchErr *FetchError
+	if !errors.As(err, &fetchErr) || fetchErr.Class != Permanent || !strings.Contains(err.Error(), "Retry-After asks for 1h0m0s") {
+		t.Errorf("err = %v", err)
+	}
+	if hits.Load() != 1 || len(waits) != 0 {
+		t.Errorf("%d attempts and waits %v, want 1 and none", hits.Load(), waits)
+	}
+}
+
+func TestFetchWithRetryConnectionErrors(t *testing.T) {
+	// The connection is dropped without a response twice
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) <= 2 {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				conn.Close()
+			}
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+	var waits []time.Duration
+	if _, err := fetch(t, srv.URL, RetryPolicy{Attempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Second, Sleep: recordSleeps(&waits)}); err != nil {
+		t.Fatalf("dropped twice, then 200: %v", err)
+	}
+	if hits.Load() != 3 {
+		t.Errorf("%d attempts, want 3", hits.Load())
+	}
+
+	// Nothing listening
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	waits = nil
+	_, err = fetch(t, "http://"+addr, RetryPolicy{Attempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Second, Sleep: recordSleeps(&waits)})
+	var fetchErr *FetchError
+	if !errors.As(err, &fetchErr) || fetchErr.Class != Retryable || fetchErr.Attempts != 3 || fetchErr.StatusCode != 0 || len(waits) != 2 {
+		t.Errorf("refused: err = %v, waits %v", err, waits)
+	}
+}
+
+func TestFetchWithRetryTimeout(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) == 1 {
+			select {
+			case <-time.After(time.Second):
+			case <-r.Context().Done():
+			}
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	req, _ := http.NewRequest("GET", srv.URL, nil)
+	var waits []time.Duration
+	resp, err := fetchWithRetry(&http.Client{Timeout: 50 * time.Millisecond}, req, RetryPolicy{Attempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Second, Sleep: recordSleeps(&waits)})
+	if err != nil {
+		t.Fatalf("timed out, then 200: %v", err)
+	}
+	resp.Body.Close()
+	if hits.Load() != 2 {
+		t.Errorf("%d attempts, want 2", hits.Load())
+	}
+}
+
+func TestFetchWithRetryCancelled(t *testing.T) {
+	srv, hits := flakyServer(t, 100, http.StatusServiceUnavailable, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, "GET", srv.URL, nil)
+
+	// Cancelled while waiting to retry, with the real sleeper
+	policy := RetryPolicy{Attempts: 5, BaseDelay: time.Minute, MaxDelay: time.Minute}
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	_, err := fetchWithRetry(http.DefaultClient, req, policy)
+	if !errors.Is(err, context.Canceled) || time.Since(start) > 10*time.Second {
+		t.Errorf("err = %v after %s, want it cancelled promptly", err, time.Since(start))
+	}
+	if hits.Load() != 1 {
+		t.Errorf("%d attempts, want 1", hits.Load())
+	}
+
+	// And cancelled before sending is permanent
+	_, err = fetchWithRetry(http.DefaultClient, req, policy)
+	var fetchErr *FetchError
+	if !errors.As(err, &fetchErr) || fetchErr.Class != Permanent {
+		t.Errorf("err = %v, want a permanent FetchError", err)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		header string
+		want   time.Duration
+		ok     bool
+	}{
+		{"", 0, false},
+		{"0", 0, true},
+		{"120", 2 * time.Minute, true},
+		{"-5", 0, false},
+		{"soon", 0, false},
+		{now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second, true},
+		// A date already past means now
+		{now.Add(-time.Hour).Format(http.TimeFormat), 0, true},
+	}
+	for _, tt := range tests {
+		got, ok := retryAfter(tt.header, now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("retryAfter(%q) = %s, %t; want %s, %t", tt.header, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package robots
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// DisallowedError is a URL a Checker won't let the crawler fetch
+type DisallowedError struct {
+	URL string
+	// Reason is the rule that disallowed it, or why robots.txt couldn't
+	// be read
+	Reason string
+}
+
+func (e *DisallowedError) Error() string {
+	return fmt.Sprintf("%s is disallowed by robots.txt (%s)", e.URL, e.Reason)
+}
+
+// Checker fetches each host's robots.txt once, caches it, and enforces it
+// for one user agent. Its methods are safe to call from many goroutines.
+type Checker struct {
+	Client    *http.Client
+	UserAgent string
+	// Sleep waits for d or until ctx is done; nil uses a timer
+	Sleep func(ctx context.Context, d time.Duration) error
+
+	mu    sync.Mutex
+	sites map[string]*site
+}
+
+// site is what a Checker knows about a scheme and host
+type site struct {
+	// ready is closed once robots and err are set
+	ready chan struct{}
+	// cancelled is a fetch its caller cancelled, which says nothing about
+	// the site, so the next caller fetches again
+	cancelled bool
+
+	robots *Robots
+	// err is why robots.txt couldn't be read, which disallows everything
+	err error
+	// next is the earliest the next request may start, for Crawl-delay
+	next time.Time
+}
+
+// NewChecker is a Checker fetching with client as userAgent
+func NewChecker(client *http.Client, userAgent string) *Checker {
+	return &Checker{Client: client, UserAgent: userAgent, sites: make(map[string]*site)}
+}
+
+// Robots is the robots.txt for u's scheme and host, fetched the first
+// time it is asked for. A 4xx, such as a missing robots.txt, allows
+// everything; a 5xx or a network error disallows everything, as the RFC
+// asks, and is returned as the error.
+func (c *Checker) Robots(ctx context.Context, u *url.URL) (*Robots, error) {
+	s := c.site(ctx, u)
+	return s.robots, s.err
+}
+
+// Wait returns a *DisallowedError when u's robots.txt disallows it, and
+// otherwise waits until the host's Crawl-delay has passed since the last
+// request this Checker let through to it
+func (c *Checker) Wait(ctx context.Context, u *url.URL) error {
+	s := c.site(ctx, u)
+	if s.err != nil {
+		return &DisallowedError{URL: u.String(), Reason: "robots.txt could not be read: " + s.err.Error()}
+	}
+	if rule, allowed := s.robots.Check(c.UserAgent, u.String()); !allowed {
+		return &DisallowedError{URL: u.String(), Reason: rule.String()}
+	}
+
+	// Take the next slot now, so requests waiting together are spaced too
+	c.mu.Lock()
+	now := time.Now()
+	start := now
+	if s.next.After(now) {
+		start = s.next
+	}
+	s.next = start.Add(s.robots.CrawlDelay(c.UserAgent))
+	c.mu.Unlock()
+
+	wait := start.Sub(now)
+	if wait <= 0 {
+		return nil
+	}
+	sleep := c.Sleep
+	if sleep == nil {
+		sleep = sleepContext
+	}
+	return sleep(ctx, wait)
+}
+
+// site is the cached site for u, fetching its robots.txt the first time.
+// The fetch runs without c.mu held, so a slow host doesn't hold up the
+// others; callers wanting the same host wait for it instead.
+func (c *Checker) site(ctx context.Context, u *url.URL) *site {
+	key := u.Scheme + "://" + u.Host
+	for {
+		c.mu.Lock()
+		s, ok := c.sites[key]
+		if !ok {
+			s = &site{ready: make(chan struct{})}
+			c.sites[key] = s
+		}
+		c.mu.Unlock()
+
+		if !ok {
+			s.robots, s.err = c.fetch(ctx, key+"/robots.txt")
+			if ctx.Err() != nil {
+				s.cancelled = true
+				c.mu.Lock()
+				delete(c.sites, key)
+				c.mu.Unlock()
+			}
+			close(s.ready)
+			return s
+		}
+
+		select {
+		case <-s.ready:
+		case <-ctx.Done():
+			return &site{err: ctx.Err()}
+		}
+		if !s.cancelled {
+			return s
+		}
+	}
+}
+
+// fetch reads the robots.txt at robotsURL
+func (c *Checker) fetch(ctx context.Context, robotsURL string) (*Robots, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", robotsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.UserAgent)
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return Parse(resp.Body)
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		return &Robots{}, nil
+	}
+	return nil, fmt.Errorf("%s returned %s", robotsURL, resp.Status)
+}
+
+// sleepContext waits for d, or returns ctx's error if it is done first
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
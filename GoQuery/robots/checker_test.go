@@ -0,0 +1,206 @@
+package robots
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// robotsServer serves body as /robots.txt with status, and counts the
+// requests for it
+func robotsServer(t *testing.T, status int, body string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var fetches atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/robots.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		fetches.Add(1)
+		if got := r.Header.Get("User-Agent"); got != "TestBot/1.0" {
+			t.Errorf("robots.txt fetched as %q", got)
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &fetches
+}
+
+// parseURL parses s, failing the test if it can't
+func parseURL(t *testing.T, s string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func TestCheckerDisallows(t *testing.T) {
+	srv, fetches := robotsServer(t, http.StatusOK, "User-agent: testbot\nDisallow: /private\n")
+	c := NewChecker(srv.Client(), "TestBot/1.0")
+	ctx := context.Background()
+
+	if err := c.Wait(ctx, parseURL(t, srv.URL+"/public")); err != nil {
+		t.Errorf("Wait(/public) = %v", err)
+	}
+	err := c.Wait(ctx, parseURL(t, srv.URL+"/private/page"))
+	var disallowed *DisallowedError
+	if !errors.As(err, &disallowed) || disallowed.Reason != "Disallow: /private" {
+		t.Errorf("Wait(/private/page) = %v, want a DisallowedError naming the rule", err)
+	}
+
+	// Fetched once for the host, however many URLs are checked
+	if fetches.Load() != 1 {
+		t.Errorf("robots.txt fetched %d times, want 1", fetches.Load())
+	}
+}
+
+func TestCheckerMissingRobotsAllowsAll(t *testing.T) {
+	for _, status := range []int{http.StatusNotFound, http.StatusForbidden, http.StatusGone} {
+		srv, _ := robotsServer(t, status, "User-agent: *\nDisallow: /\n")
+		c := NewChecker(srv.Client(), "TestBot/1.0")
+		if err := c.Wait(context.Background(), parseURL(t, srv.URL+"/anything")); err != nil {
+			t.Errorf("robots.txt %d: Wait = %v, want allowed", status, err)
+		}
+	}
+}
+
+func TestCheckerUnreachableDisallowsAll(t *testing.T) {
+	srv, fetches := robotsServer(t, http.StatusServiceUnavailable, "")
+	c := NewChecker(srv.Client(), "TestBot/1.0")
+	u := parseURL(t, srv.URL+"/page")
+
+	err := c.Wait(context.Background(), u)
+	var disallowed *DisallowedError
+	if !errors.As(err, &disallowed) || !strings.Contains(disallowed.Reason, "503 Service Unavailable") {
+		t.Errorf("Wait = %v, want disallowed because of the 503", err)
+	}
+	if _, err := c.Robots(context.Background(), u); err == nil {
+		t.Error("Robots returned no error")
+	}
+	if fetches.Load() != 1 {
+		t.Errorf("robots.txt fetched %d times, want 1", fetches.Load())
+	}
+}
+
+func TestCheckerCrawlDelay(t *testing.T) {
+	srv, _ := robotsServer(t, http.StatusOK, "User-agent: *\nCrawl-delay: 10\n")
+	var waits []time.Duration
+	c := NewChecker(srv.Client(), "TestBot/1.0")
+	c.Sleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+
+	for range 3 {
+		if err := c.Wait(context.Background(), parseURL(t, srv.URL+"/page")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The first request goes at once and each after it takes the next
+	// 10-second slot, as Sleep here doesn't really wait
+	if len(waits) != 2 || waits[0] < 9*time.Second || waits[0] > 10*time.Second || waits[1] < 19*time.Second || waits[1] > 20*time.Second {
+		t.Errorf("waits %v, want about [10s 20s]", waits)
+	}
+
+	// Another host has its own spacing
+	other, _ := robotsServer(t, http.StatusOK, "")
+	if err := c.Wait(context.Background(), parseURL(t, other.URL+"/")); err != nil || len(waits) != 2 {
+		t.Errorf("another host: %v, waits %v", err, waits)
+	}
+}
+
+func TestCheckerCrawlDelayCancelled(t *testing.T) {
+	srv, _ := robotsServer(t, http.StatusOK, "User-agent: *\nCrawl-delay: 60\n")
+	c := NewChecker(srv.Client(), "TestBot/1.0")
+	u := parseURL(t, srv.URL+"/page")
+	if err := c.Wait(context.Background(), u); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := c.Wait(ctx, u); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wait = %v, want the deadline", err)
+	}
+}
+
+func TestCheckerSchemesAreSeparate(t *testing.T) {
+	var hosts []string
+	c := NewChecker(&http.Client{Transport: roundTripper(func(r *http.Request) (*http.Response, error) {
+		hosts = append(hosts, r.URL.Scheme+"://"+r.URL.Host+r.URL.Path)
+		return &http.Response{StatusCode: http.StatusNotFound, Body: http.NoBody, Request: r}, nil
+	})}, "TestBot/1.0")
+	for _, s := range []string{"https://example.com/a", "http://example.com/b", "https://example.com/c"} {
+		c.Wait(context.Background(), parseURL(t, s))
+	}
+	if want := []string{"https://example.com/robots.txt", "http://example.com/robots.txt"}; !slices.Equal(hosts, want) {
+		t.Errorf("fetched %v, want %v", hosts, want)
+	}
+}
+
+// roundTripper is an http.RoundTripper from a function
+type roundTripper func(*http.Request) (*http.Response, error)
+
+func (f roundTripper) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestCheckerSlowHostDoesNotBlockOthers(t *testing.T) {
+	entered, release := make(chan struct{}), make(chan struct{})
+	var fetches atomic.Int32
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fetches.Add(1) == 1 {
+			close(entered)
+		}
+		<-release
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer slow.Close()
+	fast, _ := robotsServer(t, http.StatusNotFound, "")
+	c := NewChecker(&http.Client{}, "TestBot/1.0")
+
+	// Two callers for the slow host share its one fetch
+	errs := make(chan error, 2)
+	for range 2 {
+		go func() { errs <- c.Wait(context.Background(), parseURL(t, slow.URL+"/page")) }()
+	}
+	<-entered
+
+	done := make(chan error, 1)
+	go func() { done <- c.Wait(context.Background(), parseURL(t, fast.URL+"/page")) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("fast host: Wait = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the fast host waited for the slow host's robots.txt")
+	}
+
+	// A caller giving up on the slow host doesn't wait for it either
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := c.Wait(ctx, parseURL(t, slow.URL+"/other"))
+	var disallowed *DisallowedError
+	if !errors.As(err, &disallowed) || !strings.Contains(disallowed.Reason, "deadline exceeded") {
+		t.Errorf("cancelled caller: Wait = %v, want disallowed because of the deadline", err)
+	}
+
+	close(release)
+	for range 2 {
+		if err := <-errs; err != nil {
+			t.Errorf("slow host: Wait = %v", err)
+		}
+	}
+	if fetches.Load() != 1 {
+		t.Errorf("slow robots.txt fetched %d times, want 1", fetches.Load())
+	}
+}
@@ -0,0 +1,207 @@
+// Package robots reads robots.txt files, as RFC 9309 describes them, and
+// answers whether a crawler may fetch a URL and how long it should wait
+// between requests.
+package robots
+
+import (
+	"bufio"
+	"io"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxSize is how much of a robots.txt is read; RFC 9309 asks crawlers to
+// read at least 500 KiB
+const MaxSize = 500 << 10
+
+// Rule is an Allow or Disallow line. Pattern is matched against the start
+// of a URL's path and query; * matches any run of characters and a $ at
+// the end matches the end of the URL.
+type Rule struct {
+	Pattern string
+	Allow   bool
+}
+
+func (r Rule) String() string {
+	if r.Allow {
+		return "Allow: " + r.Pattern
+	}
+	return "Disallow: " + r.Pattern
+}
+
+// Group is the rules for the user agents named on the User-agent lines
+// before them
+type Group struct {
+	// Agents are lowercased; * is every crawler without a group of its own
+	Agents     []string
+	Rules      []Rule
+	CrawlDelay time.Duration
+}
+
+// Robots is a parsed robots.txt. The zero Robots, like a missing
+// robots.txt, allows everything.
+type Robots struct {
+	Groups []Group
+}
+
+// Parse reads a robots.txt. Lines it doesn't understand, and rules before
+// the first User-agent line, are ignored, as the RFC asks, so the only
+// error is one reading r.
+func Parse(r io.Reader) (*Robots, error) {
+	robots := &Robots{}
+	var group *Group
+	// Consecutive User-agent lines share a group
+	agentLines := false
+	scanner := bufio.NewScanner(io.LimitReader(r, MaxSize))
+	for first := true; scanner.Scan(); first = false {
+		line := scanner.Text()
+		if first {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		line, _, _ = strings.Cut(line, "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "user-agent":
+			if !agentLines {
+				robots.Groups = append(robots.Groups, Group{})
+				group = &robots.Groups[len(robots.Groups)-1]
+			}
+			group.Agents = append(group.Agents, strings.ToLower(value))
+			agentLines = true
+		case "allow", "disallow":
+			agentLines = false
+			// An empty Disallow allows everything, which no rule does too
+			if group != nil && value != "" {
+				group.Rules = append(group.Rules, Rule{Pattern: value, Allow: strings.EqualFold(strings.TrimSpace(key), "allow")})
+			}
+		case "crawl-delay":
+			agentLines = false
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds >= 0 && group != nil {
+				group.CrawlDelay = time.Duration(seconds * float64(time.Second))
+			}
+		}
+	}
+	return robots, scanner.Err()
+}
+
+// Allowed reports whether userAgent may fetch rawURL, which can be a full
+// URL or just its path. The longest matching pattern decides, and Allow
+// wins a tie; with no matching rule, or for /robots.txt itself, it is
+// allowed.
+func (r *Robots) Allowed(userAgent, rawURL string) bool {
+	_, allowed := r.Check(userAgent, rawURL)
+	return allowed
+}
+
+// Check is Allowed, with the rule that decided, which is the zero Rule
+// when none matched
+func (r *Robots) Check(userAgent, rawURL string) (Rule, bool) {
+	path := requestPath(rawURL)
+	if path == "/robots.txt" {
+		return Rule{}, true
+	}
+	var best Rule
+	found := false
+	for _, g := range r.groups(userAgent) {
+		for _, rule := range g.Rules {
+			if !match(rule.Pattern, path) {
+				continue
+			}
+			longer := len(rule.Pattern) > len(best.Pattern)
+			if !found || longer || len(rule.Pattern) == len(best.Pattern) && rule.Allow {
+				best, found = rule, true
+			}
+		}
+	}
+	return best, !found || best.Allow
+}
+
+// CrawlDelay is how long userAgent should wait between requests, or 0
+func (r *Robots) CrawlDelay(userAgent string) time.Duration {
+	var delay time.Duration
+	for _, g := range r.groups(userAgent) {
+		delay = max(delay, g.CrawlDelay)
+	}
+	return delay
+}
+
+// groups are the groups for userAgent: those naming its product token,
+// like goquery-demo for "GoQuery-Demo/1.0 (Educational Purpose)", or else
+// those for *
+func (r *Robots) groups(userAgent string) []*Group {
+	if r == nil {
+		return nil
+	}
+	token := strings.ToLower(userAgent)
+	if i := strings.IndexAny(token, "/ ("); i >= 0 {
+		token = token[:i]
+	}
+	var named, wildcard []*Group
+	for i := range r.Groups {
+		g := &r.Groups[i]
+		switch {
+		case slices.Contains(g.Agents, token):
+			named = append(named, g)
+		case slices.Contains(g.Agents, "*"):
+			wildcard = append(wildcard, g)
+		}
+	}
+	if len(named) > 0 {
+		return named
+	}
+	return wildcard
+}
+
+// requestPath is the path and query of rawURL, as rules are matched
+// against them
+func requestPath(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return path
+}
+
+// match reports whether pattern matches the start of path, or all of it
+// when the pattern ends in $
+func match(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	if len(parts) == 1 {
+		return !anchored || rest == ""
+	}
+	// Taking each middle part as early as it appears leaves the most for
+	// the rest
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(rest, part)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i+len(part):]
+	}
+	last := parts[len(parts)-1]
+	if anchored {
+		return strings.HasSuffix(rest, last)
+	}
+	return strings.Contains(rest, last)
+}
@@ -0,0 +1,161 @@
+package robots
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// mustParse parses a robots.txt held in a string
+func mustParse(t *testing.T, src string) *Robots {
+	t.Helper()
+	r, err := Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// allowedCase is a URL and whether the robots.txt under test allows it
+type allowedCase struct {
+	url  string
+	want bool
+}
+
+// checkAllowed checks each case for userAgent
+func checkAllowed(t *testing.T, r *Robots, userAgent string, cases []allowedCase) {
+	t.Helper()
+	for _, c := range cases {
+		if got := r.Allowed(userAgent, c.url); got != c.want {
+			rule, _ := r.Check(userAgent, c.url)
+			t.Errorf("Allowed(%q, %q) = %t, want %t (decided by %q)", userAgent, c.url, got, c.want, rule)
+		}
+	}
+}
+
+func TestParseGroups(t *testing.T) {
+	r := mustParse(t, "\ufeff"+`# A comment line
+User-agent: *
+Disallow: /private/   # trailing comment
+Crawl-delay: 2
+
+User-agent: GoQuery-Demo
+User-agent: OtherBot
+Disallow: /demo-only/
+Allow: /private/
+Crawl-delay: 0.5
+
+Sitemap: https://example.com/sitemap.xml
+user-AGENT: strictbot
+DISALLOW: /
+`)
+	if len(r.Groups) != 3 {
+		t.Fatalf("got %d groups, want 3: %+v", len(r.Groups), r.Groups)
+	}
+
+	// A named group replaces the * group entirely
+	checkAllowed(t, r, "GoQuery-Demo/1.0 (Educational Purpose)", []allowedCase{
+		{"https://example.com/private/page", true},
+		{"/demo-only/x", false},
+		{"/anything", true},
+	})
+	checkAllowed(t, r, "otherbot", []allowedCase{{"/demo-only/", false}})
+	// Everyone else gets the * group
+	checkAllowed(t, r, "Go-http-client/1.1", []allowedCase{
+		{"/private/page", false},
+		{"/private", true},
+		{"/demo-only/x", true},
+	})
+	// Keys are case-insensitive
+	checkAllowed(t, r, "StrictBot", []allowedCase{{"/", false}, {"/robots.txt", true}})
+
+	if got := r.CrawlDelay("GoQuery-Demo/1.0"); got != 500*time.Millisecond {
+		t.Errorf("CrawlDelay(GoQuery-Demo) = %s, want 500ms", got)
+	}
+	if got := r.CrawlDelay("somebot"); got != 2*time.Second {
+		t.Errorf("CrawlDelay(somebot) = %s, want 2s", got)
+	}
+	if got := r.CrawlDelay("strictbot"); got != 0 {
+		t.Errorf("CrawlDelay(strictbot) = %s, want 0", got)
+	}
+}
+
+func TestWildcards(t *testing.T) {
+	r := mustParse(t, `User-agent: *
+Disallow: /*.pdf$
+Disallow: /search*q=
+Disallow: /tmp*/cache/*.json
+Disallow: /exact$
+Disallow: *session=
+`)
+	checkAllowed(t, r, "bot", []allowedCase{
+		{"/files/report.pdf", false},
+		{"/report.pdf", false},
+		// $ anchors the end, so these don't match
+		{"/report.pdf?download=1", true},
+		{"/report.pdf.html", true},
+		{"/search?q=go", false},
+		{"/search/advanced?lang=en&q=go", false},
+		{"/search?lang=en", true},
+		{"/tmp/a/cache/b.json", false},
+		{"/tmp-old/cache/x/y.json", false},
+		{"/tmp/cache.json", true},
+		{"/exact", false},
+		{"/exact/more", true},
+		{"/exactly", true},
+		{"/cart?id=1&session=abc", false},
+		{"/cart?id=1", true},
+	})
+}
+
+func TestLongestMatchWins(t *testing.T) {
+	r := mustParse(t, `User-agent: *
+Disallow: /shop
+Allow: /shop/public
+Disallow: /shop/public/drafts
+Allow: /page
+Disallow: /page
+Disallow: /*.gif$
+Allow: /img/
+`)
+	checkAllowed(t, r, "bot", []allowedCase{
+		{"/shop", false},
+		{"/shop/cart", false},
+		{"/shop/public/item", true},
+		{"/shop/public/drafts/1", false},
+		// Equally long, so the Allow wins
+		{"/page", true},
+		// /*.gif$ is longer than /img/, counting the * and $
+		{"/img/logo.gif", false},
+		{"/img/logo.png", true},
+	})
+}
+
+func TestAllowEverything(t *testing.T) {
+	for name, r := range map[string]*Robots{
+		"missing":        {},
+		"nil":            nil,
+		"empty file":     mustParse(t, ""),
+		"empty Disallow": mustParse(t, "User-agent: *\nDisallow:\n"),
+		"other agents":   mustParse(t, "User-agent: otherbot\nDisallow: /\n"),
+		// Rules before any User-agent line belong to no group
+		"no group": mustParse(t, "Disallow: /\n"),
+	} {
+		if !r.Allowed("GoQuery-Demo/1.0", "https://example.com/any/page?x=1") || r.CrawlDelay("GoQuery-Demo/1.0") != 0 {
+			t.Errorf("%s: disallowed, or a crawl delay", name)
+		}
+	}
+}
+
+func TestParseIgnoresBadLines(t *testing.T) {
+	r := mustParse(t, `User-agent: *
+this line has no colon
+Crawl-delay: soon
+Crawl-delay: -1
+Disallow: /a
+`)
+	checkAllowed(t, r, "bot", []allowedCase{{"/a", false}, {"/b", true}})
+	if got := r.CrawlDelay("bot"); got != 0 {
+		t.Errorf("CrawlDelay = %s, want 0", got)
+	}
+}
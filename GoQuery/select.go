@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+
+	"example.com/goquery-demo/robots"
+)
+
+// The select command's exit codes. Nothing matching isn't an error, but
+// scripts need to tell it from a match.
+const (
+	exitMatched   = 0
+	exitError     = 1
+	exitNoMatches = 2
+)
+
+// Match is an element the select command found
+type Match struct {
+	Tag  string `json:"tag"`
+	Text string `json:"text"`
+	// Attrs are the --attr attributes the element has
+	Attrs map[string]string `json:"attrs,omitempty"`
+}
+
+// attrList is a flag taking attribute names, repeated or comma-separated
+type attrList []string
+
+func (a *attrList) String() string { return strings.Join(*a, ",") }
+
+func (a *attrList) Set(value string) error {
+	for name := range strings.SplitSeq(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			*a = append(*a, name)
+		}
+	}
+	return nil
+}
+
+// runSelect is the select command: it fetches a page and prints the text
+// or attributes of the elements a CSS selector matches, returning the
+// exit code
+func runSelect(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("select", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	pageURL := fs.String("url", "", "the page to fetch (required)")
+	selector := fs.String("selector", "", "the CSS selector to match (required)")
+	var attrs attrList
+	fs.Var(&attrs, "attr", "print this attribute instead of the text; repeat or comma-separate for several")
+	format := fs.String("format", "text", "text, one match per line, or json")
+	first := fs.Bool("first", false, "only the first match, like --limit 1")
+	limit := fs.Int("limit", 0, "at most this many matches; 0 is all")
+	ignoreRobots := fs.Bool("ignore-robots", false, "don't fetch or obey robots.txt (for local test servers)")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: goquery-demo select --url URL --selector SELECTOR [flags]")
+		fmt.Fprintln(stderr, "\nExits 0 when something matched, 2 when nothing did, and 1 on errors.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitMatched
+		}
+		return exitError
+	}
+
+	usageError := func(msg string) int {
+		fmt.Fprintln(stderr, "select:", msg)
+		fs.Usage()
+		return exitError
+	}
+	switch {
+	case fs.NArg() > 0:
+		return usageError(fmt.Sprintf("unexpected arguments %q", fs.Args()))
+	case *pageURL == "" || *selector == "":
+		return usageError("--url and --selector are required")
+	case *format != "text" && *format != "json":
+		return usageError(fmt.Sprintf("--format must be text or json, not %q", *format))
+	case *limit < 0:
+		return usageError("--limit can't be negative")
+	}
+	if *first {
+		*limit = 1
+	}
+
+	// goquery quietly matches nothing for a bad selector, so compile it
+	// first to report the mistake
+	matcher, err := cascadia.Compile(*selector)
+	if err != nil {
+		fmt.Fprintf(stderr, "select: invalid selector %q: %v\n", *selector, err)
+		return exitError
+	}
+
+	var checker *robots.Checker
+	if !*ignoreRobots {
+		checker = robots.NewChecker(&http.Client{Timeout: 10 * time.Second}, userAgent)
+	}
+	doc, err := fetchDocument(checker, *pageURL)
+	if err != nil {
+		fmt.Fprintln(stderr, "select:", err)
+		return exitError
+	}
+
+	matches := selectMatches(doc.FindMatcher(matcher), attrs, *limit)
+	if len(matches) == 0 {
+		fmt.Fprintf(stderr, "select: nothing matched %q\n", *selector)
+		return exitNoMatches
+	}
+	if err := writeMatches(stdout, matches, attrs, *format); err != nil {
+		fmt.Fprintln(stderr, "select:", err)
+		return exitError
+	}
+	return exitMatched
+}
+
+// fetchDocument fetches and parses the page at pageURL, if checker allows
+func fetchDocument(checker *robots.Checker, pageURL string) (*goquery.Document, error) {
+	req, err := http.NewRequest("GET", pageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := fetchPage(&http.Client{Timeout: 10 * time.Second}, checker, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return goquery.NewDocumentFromReader(resp.Body)
+}
+
+// selectMatches is the first limit elements of sel, or all of them when
+// limit is 0, with their text and the attrs they have
+func selectMatches(sel *goquery.Selection, attrs []string, limit int) []Match {
+	if limit > 0 && sel.Length() > limit {
+		sel = sel.Slice(0, limit)
+	}
+	matches := make([]Match, 0, sel.Length())
+	sel.Each(func(i int, s *goquery.Selection) {
+		m := Match{Tag: goquery.NodeName(s), Text: strings.Join(strings.Fields(s.Text()), " ")}
+		for _, name := range attrs {
+			if value, ok := s.Attr(name); ok {
+				if m.Attrs == nil {
+					m.Attrs = make(map[string]string)
+				}
+				m.Attrs[name] = value
+			}
+		}
+		matches = append(matches, m)
+	})
+	return matches
+}
+
+// writeMatches prints matches as a JSON array, or as text: each match's
+// text, or with attrs its values of them separated by tabs. In text, a
+// match without the one attribute asked for is left out.
+func writeMatches(w io.Writer, matches []Match, attrs []string, format string) error {
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(matches)
+	}
+	for _, m := range matches {
+		line := m.Text
+		if len(attrs) > 0 {
+			if _, ok := m.Attrs[attrs[0]]; len(attrs) == 1 && !ok {
+				continue
+			}
+			values := make([]string, len(attrs))
+			for i, name := range attrs {
+				values[i] = m.Attrs[name]
+			}
+			line = strings.Join(values, "\t")
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
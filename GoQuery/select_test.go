@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// selectFixture is the page the select tests run against
+const selectFixture = `<!DOCTYPE html>
+<html><head><title>Articles</title></head><body>
+<div class="article">
+  <h2><a href="/one" title="First">First
+    post</a></h2>
+  <p>Intro to <b>goquery</b></p>
+</div>
+<div class="article">
+  <h2><a href="/two">Second post</a></h2>
+</div>
+<div class="article">
+  <h2>No link here</h2>
+</div>
+<h2>Not in an article</h2>
+</body></html>`
+
+// runSelectOn runs the select command against the fixture with args,
+// returning its exit code and output
+func runSelectOn(t *testing.T, args ...string) (int, string, string) {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(selectFixture))
+	}))
+	t.Cleanup(srv.Close)
+
+	var stdout, stderr strings.Builder
+	args = append([]string{"--url", srv.URL, "--ignore-robots"}, args...)
+	code := runSelect(args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestSelectText(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--selector", "div.article h2"}, "First post\nSecond post\nNo link here\n"},
+		{[]string{"--selector", "h2", "--limit", "2"}, "First post\nSecond post\n"},
+		{[]string{"--selector", "h2", "--first", "--limit", "3"}, "First post\n"},
+		{[]string{"--selector", "h2", "--limit", "10"}, "First post\nSecond post\nNo link here\nNot in an article\n"},
+		{[]string{"--selector", "p"}, "Intro to goquery\n"},
+		// A match without the attribute is left out
+		{[]string{"--selector", "div.article h2 a, div.article h2", "--attr", "href"}, "/one\n/two\n"},
+		{[]string{"--selector", "a", "--attr", "href,title"}, "/one\tFirst\n/two\t\n"},
+		{[]string{"--selector", "a", "--attr", "title", "--attr", "href"}, "First\t/one\n\t/two\n"},
+	}
+	for _, tt := range tests {
+		code, stdout, stderr := runSelectOn(t, tt.args...)
+		if code != exitMatched || stdout != tt.want {
+			t.Errorf("select %s: exit %d, stdout %q, stderr %q; want %q", strings.Join(tt.args, " "), code, stdout, stderr, tt.want)
+		}
+	}
+}
+
+func TestSelectJSON(t *testing.T) {
+	code, stdout, stderr := runSelectOn(t, "--selector", "h2 > a, p", "--attr", "href", "--format", "json")
+	if code != exitMatched {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	var got []Match
+	if err := json.Unmarshal([]byte(stdout), &got); err != nil {
+		t.Fatalf("%v in %s", err, stdout)
+	}
+	want := []Match{
+		{Tag: "a", Text: "First post", Attrs: map[string]string{"href": "/one"}},
+		{Tag: "p", Text: "Intro to goquery"},
+		{Tag: "a", Text: "Second post", Attrs: map[string]string{"href": "/two"}},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i].Tag != want[i].Tag || got[i].Text != want[i].Text || len(got[i].Attrs) != len(want[i].Attrs) || got[i].Attrs["href"] != want[i].Attrs["href"] {
+			t.Errorf("match %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	// Without --attr there is no attrs key at all
+	if _, stdout, _ := runSelectOn(t, "--selector", "title", "--format", "json"); !strings.Contains(stdout, `"tag": "title"`) || strings.Contains(stdout, "attrs") {
+		t.Errorf("unexpected JSON %s", stdout)
+	}
+}
+
+func TestSelectExitCodes(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantStderr string
+	}{
+		{"nothing matched", []string{"--selector", "table td"}, exitNoMatches, `nothing matched "table td"`},
+		{"nothing matched in JSON", []string{"--selector", "ul", "--format", "json"}, exitNoMatches, "nothing matched"},
+		{"invalid selector", []string{"--selector", "div[("}, exitError, "invalid selector"},
+		{"missing selector", nil, exitError, "--url and --selector are required"},
+		{"bad format", []string{"--selector", "h2", "--format", "xml"}, exitError, `not "xml"`},
+		{"negative limit", []string{"--selector", "h2", "--limit", "-1"}, exitError, "can't be negative"},
+		{"unknown flag", []string{"--selector", "h2", "--color"}, exitError, "flag provided but not defined"},
+	}
+	for _, tt := range tests {
+		code, stdout, stderr := runSelectOn(t, tt.args...)
+		if code != tt.wantCode || stdout != "" || !strings.Contains(stderr, tt.wantStderr) {
+			t.Errorf("%s: exit %d, stdout %q, stderr %q; want exit %d mentioning %q", tt.name, code, stdout, stderr, tt.wantCode, tt.wantStderr)
+		}
+	}
+
+	// A page that can't be fetched is an error, not nothing matched
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+	var stdout, stderr strings.Builder
+	if code := runSelect([]string{"--url", srv.URL, "--selector", "h2", "--ignore-robots"}, &stdout, &stderr); code != exitError || !strings.Contains(stderr.String(), "404") {
+		t.Errorf("missing page: exit %d, stderr %q", code, stderr.String())
+	}
+}
@@ -0,0 +1,311 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Table is an HTML table's text. Every row, and Headers when there are
+// any, is as wide as the widest row, padded with empty cells.
+type Table struct {
+	// Headers are nil when the table has none
+	Headers []string
+	Rows    [][]string
+}
+
+// ExtractTables extracts every table in doc, in document order. The
+// headers are the last row of the thead or, without one, a first row of
+// only th cells. A cell spanning several columns or rows is repeated in
+// each. Cell text has its whitespace collapsed, with <br> and block
+// elements as spaces. A table nested in a cell is extracted as a table of
+// its own and left out of that cell's text.
+func ExtractTables(doc *goquery.Document) []Table {
+	var tables []Table
+	doc.Find("table").Each(func(i int, s *goquery.Selection) {
+		tables = append(tables, extractTable(s))
+	})
+	return tables
+}
+
+// tableRow is a row's cells with its spans filled in
+type tableRow struct {
+	cells []string
+	// inHead is a row of the thead, allHeaders one of only th cells
+	inHead, allHeaders bool
+}
+
+// extractTable extracts the table s
+func extractTable(s *goquery.Selection) Table {
+	// The parser puts rows in a tbody when the HTML doesn't, so every row
+	// of this table, and none of a nested one, is a child of a section
+	var rows []tableRow
+	// spans are the cells of earlier rows still spanning down, by column
+	spans := make(map[int]rowSpan)
+	s.ChildrenFiltered("thead, tbody, tfoot").Each(func(i int, section *goquery.Selection) {
+		inHead := goquery.NodeName(section) == "thead"
+		section.ChildrenFiltered("tr").Each(func(i int, tr *goquery.Selection) {
+			if row := readRow(tr, spans); len(row.cells) > 0 {
+				row.inHead = inHead
+				rows = append(rows, row)
+			}
+		})
+	})
+
+	var t Table
+	switch {
+	case len(rows) > 0 && rows[0].inHead:
+		for len(rows) > 0 && rows[0].inHead {
+			t.Headers, rows = rows[0].cells, rows[1:]
+		}
+	case len(rows) > 0 && rows[0].allHeaders:
+		t.Headers, rows = rows[0].cells, rows[1:]
+	}
+
+	width := len(t.Headers)
+	for _, row := range rows {
+		width = max(width, len(row.cells))
+	}
+	if t.Headers != nil {
+		t.Headers = padRow(t.Headers, width)
+	}
+	t.Rows = make([][]string, len(rows))
+	for i, row := range rows {
+		t.Rows[i] = padRow(row.cells, width)
+	}
+	return t
+}
+
+// rowSpan is a cell's text and how many more rows it spans
+type rowSpan struct {
+	text string
+	rows int
+}
+
+// readRow reads tr's cells, taking the cells spanning down into it from
+// spans and adding its own
+func readRow(tr *goquery.Selection, spans map[int]rowSpan) tableRow {
+	row := tableRow{allHeaders: true}
+	column := 0
+	// fromAbove takes the cells spanning down into the columns from here on
+	fromAbove := func() {
+		for span, ok := spans[column]; ok; span, ok = spans[column] {
+			row.cells = append(row.cells, span.text)
+			if span.rows--; span.rows == 0 {
+				delete(spans, column)
+			} else {
+				spans[column] = span
+			}
+			column++
+		}
+	}
+
+	tr.ChildrenFiltered("td, th").Each(func(i int, cell *goquery.Selection) {
+		fromAbove()
+		if goquery.NodeName(cell) != "th" {
+			row.allHeaders = false
+		}
+		text := cellText(cell.Get(0))
+		// The HTML spec's limits, so a typo can't make a huge table
+		colspan := spanAttr(cell, "colspan", 1000)
+		rowspan := spanAttr(cell, "rowspan", 65534)
+		for range colspan {
+			row.cells = append(row.cells, text)
+			if rowspan > 1 {
+				spans[column] = rowSpan{text, rowspan - 1}
+			}
+			column++
+		}
+	})
+	if len(row.cells) == 0 {
+		row.allHeaders = false
+	}
+
+	// Cells spanning down past the end of a short row, with gaps filled
+	if len(spans) > 0 {
+		last := slices.Max(slices.Collect(maps.Keys(spans)))
+		for column <= last {
+			if _, ok := spans[column]; ok {
+				fromAbove()
+			} else {
+				row.cells = append(row.cells, "")
+				column++
+			}
+		}
+	}
+	return row
+}
+
+// spanAttr is cell's colspan or rowspan, from 1 to limit
+func spanAttr(cell *goquery.Selection, name string, limit int) int {
+	n, err := strconv.Atoi(strings.TrimSpace(cell.AttrOr(name, "1")))
+	if err != nil || n < 1 {
+		return 1
+	}
+	return min(n, limit)
+}
+
+// cellText is n's text with whitespace collapsed, leaving out nested
+// tables, scripts and styles
+func cellText(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			switch {
+			case c.Type == html.TextNode:
+				b.WriteString(c.Data)
+			case c.Type != html.ElementNode:
+			case c.DataAtom == atom.Table, c.DataAtom == atom.Script, c.DataAtom == atom.Style:
+			case c.DataAtom == atom.Br, c.DataAtom == atom.P, c.DataAtom == atom.Div, c.DataAtom == atom.Li:
+				b.WriteByte(' ')
+				walk(c)
+				b.WriteByte(' ')
+			default:
+				walk(c)
+			}
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// padRow is cells with empty cells added to make it width wide
+func padRow(cells []string, width int) []string {
+	for len(cells) < width {
+		cells = append(cells, "")
+	}
+	return cells
+}
+
+// CellError is a cell ExtractTableAs couldn't convert to its field's type
+type CellError struct {
+	// Row counts from 1, not counting the headers
+	Row    int
+	Column string
+	Value  string
+	Err    error
+}
+
+func (e *CellError) Error() string {
+	return fmt.Sprintf("row %d, column %q: %v", e.Row, e.Column, e.Err)
+}
+
+func (e *CellError) Unwrap() error { return e.Err }
+
+// ExtractTableAs maps t's rows to a slice of the struct T. A field tagged
+// `col:"Header Name"` is set from the column with that header, matched
+// case-insensitively; untagged fields are left alone. Cells convert to
+// strings, ints, uints, floats and bools; numbers may have thousands
+// commas and a leading currency sign, bools may be yes or no, and an
+// empty cell is the zero value. Every cell that doesn't convert is
+// reported, each as a *CellError, along with the rows, which have those
+// fields left zero.
+func ExtractTableAs[T any](t Table) ([]T, error) {
+	typ := reflect.TypeFor[T]()
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("ExtractTableAs needs a struct type, not %s", typ)
+	}
+
+	// The column for each tagged field, in field order
+	type fieldColumn struct{ field, column int }
+	var columns []fieldColumn
+	for i := range typ.NumField() {
+		header, ok := typ.Field(i).Tag.Lookup("col")
+		if !ok || !typ.Field(i).IsExported() {
+			continue
+		}
+		if !cellKind(typ.Field(i).Type.Kind()) {
+			return nil, fmt.Errorf("field %s is a %s, which cells can't convert to", typ.Field(i).Name, typ.Field(i).Type)
+		}
+		column := slices.IndexFunc(t.Headers, func(h string) bool { return strings.EqualFold(h, header) })
+		if column < 0 {
+			return nil, fmt.Errorf("table has no %q column for field %s", header, typ.Field(i).Name)
+		}
+		columns = append(columns, fieldColumn{i, column})
+	}
+
+	var errs []error
+	out := make([]T, len(t.Rows))
+	for r, row := range t.Rows {
+		v := reflect.ValueOf(&out[r]).Elem()
+		for _, fc := range columns {
+			if err := setCell(v.Field(fc.field), row[fc.column]); err != nil {
+				errs = append(errs, &CellError{Row: r + 1, Column: t.Headers[fc.column], Value: row[fc.column], Err: err})
+			}
+		}
+	}
+	return out, errors.Join(errs...)
+}
+
+// setCell sets field from a cell's text
+func setCell(field reflect.Value, text string) error {
+	if field.Kind() == reflect.String {
+		field.SetString(text)
+		return nil
+	}
+	if text == "" {
+		return nil
+	}
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(cleanNumber(text), 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", text)
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(cleanNumber(text), 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not a whole number", text)
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(cleanNumber(text), field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not a number", text)
+		}
+		field.SetFloat(f)
+	case reflect.Bool:
+		switch strings.ToLower(text) {
+		case "yes", "y":
+			field.SetBool(true)
+		case "no", "n":
+			field.SetBool(false)
+		default:
+			b, err := strconv.ParseBool(text)
+			if err != nil {
+				return fmt.Errorf("%q is not true or false", text)
+			}
+			field.SetBool(b)
+		}
+	}
+	return nil
+}
+
+// cellKind reports whether setCell can set a field of kind k
+func cellKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.String, reflect.Bool, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// cleanNumber drops a leading currency sign and thousands commas, so
+// "$1,299.00" is "1299.00"
+func cleanNumber(text string) string {
+	text = strings.TrimLeftFunc(text, func(r rune) bool { return unicode.Is(unicode.Sc, r) })
+	return strings.ReplaceAll(strings.TrimSpace(text), ",", "")
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// parseTables extracts the tables from an HTML fragment
+func parseTables(t *testing.T, src string) []Table {
+	t.Helper()
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ExtractTables(doc)
+}
+
+func TestExtractTables(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want Table
+	}{
+		{
+			name: "thead and tbody",
+			html: `<table><thead><tr><th>Name</th><th>Age</th></tr></thead>
+				<tbody><tr><td>Ada</td><td>36</td></tr><tr><td>Alan</td><td>41</td></tr></tbody></table>`,
+			want: Table{Headers: []string{"Name", "Age"}, Rows: [][]string{{"Ada", "36"}, {"Alan", "41"}}},
+		},
+		{
+			name: "th in the first row",
+			html: `<table><tr><th>Name</th><th>Age</th></tr><tr><td>Ada</td><td>36</td></tr></table>`,
+			want: Table{Headers: []string{"Name", "Age"}, Rows: [][]string{{"Ada", "36"}}},
+		},
+		{
+			name: "missing headers",
+			html: `<table><tr><td>Ada</td><td>36</td></tr><tr><th>Alan</th><td>41</td></tr></table>`,
+			want: Table{Rows: [][]string{{"Ada", "36"}, {"Alan", "41"}}},
+		},
+		{
+			name: "a first row of th and td isn't headers",
+			html: `<table><tr><th>Ada</th><td>36</td></tr></table>`,
+			want: Table{Rows: [][]string{{"Ada", "36"}}},
+		},
+		{
+			name: "colspan repeats the cell",
+			html: `<table><thead><tr><th>Name</th><th colspan="2">Scores</th></tr></thead>
+				<tr><td colspan=" 2 ">Both</td><td>3</td></tr><tr><td>a</td><td>b</td><td>c</td></tr></table>`,
+			want: Table{Headers: []string{"Name", "Scores", "Scores"}, Rows: [][]string{{"Both", "Both", "3"}, {"a", "b", "c"}}},
+		},
+		{
+			name: "the last thead row is the headers",
+			html: `<table><thead><tr><th colspan="2">Person</th></tr><tr><th>Name</th><th>Age</th></tr></thead>
+				<tr><td>Ada</td><td>36</td></tr></table>`,
+			want: Table{Headers: []string{"Name", "Age"}, Rows: [][]string{{"Ada", "36"}}},
+		},
+		{
+			name: "rowspan repeats down",
+			html: `<table><tr><th>Team</th><th>Name</th></tr>
+				<tr><td rowspan="2">Core</td><td>Ada</td></tr><tr><td>Alan</td></tr><tr><td>Docs</td><td>Grace</td></tr></table>`,
+			want: Table{Headers: []string{"Team", "Name"}, Rows: [][]string{{"Core", "Ada"}, {"Core", "Alan"}, {"Docs", "Grace"}}},
+		},
+		{
+			name: "rowspan past a short row",
+			html: `<table><tr><td>a</td><td>b</td><td rowspan="2">c</td></tr><tr><td>d</td></tr></table>`,
+			want: Table{Rows: [][]string{{"a", "b", "c"}, {"d", "", "c"}}},
+		},
+		{
+			name: "ragged rows are padded",
+			html: `<table><tr><th>A</th><th>B</th></tr><tr><td>1</td></tr><tr><td>1</td><td>2</td><td>3</td></tr><tr></tr></table>`,
+			want: Table{Headers: []string{"A", "B", ""}, Rows: [][]string{{"1", "", ""}, {"1", "2", "3"}}},
+		},
+		{
+			name: "bad spans count as 1",
+			html: `<table><tr><td colspan="x">a</td><td colspan="0">b</td><td colspan="-2">c</td></tr></table>`,
+			want: Table{Rows: [][]string{{"a", "b", "c"}}},
+		},
+		{
+			name: "whitespace",
+			html: "<table><tr><td>  Line\n\t one<br>two&nbsp;&nbsp;three <p>four</p><span>five</span></td><td><script>x()</script> </td></tr></table>",
+			want: Table{Rows: [][]string{{"Line one two three four five", ""}}},
+		},
+		{
+			name: "empty",
+			html: `<table></table>`,
+			want: Table{Rows: [][]string{}},
+		},
+	}
+	for _, tt := range tests {
+		tables := parseTables(t, tt.html)
+		if len(tables) != 1 {
+			t.Errorf("%s: %d tables", tt.name, len(tables))
+			continue
+		}
+		if !reflect.DeepEqual(tables[0], tt.want) {
+			t.Errorf("%s:\ngot  %q\nwant %q", tt.name, tables[0], tt.want)
+		}
+	}
+}
+
+func TestExtractTablesNested(t *testing.T) {
+	tables := parseTables(t, `<table>
+		<tr><th>Name</th><th>Sizes</th></tr>
+		<tr><td>Shirt</td><td>see <table><tr><td>S</td><td>M</td></tr></table> above</td></tr>
+	</table>`)
+
+	// Each table on its own, the outer one first, and the nested one's
+	// text left out of the cell it is in
+	want := []Table{
+		{Headers: []string{"Name", "Sizes"}, Rows: [][]string{{"Shirt", "see above"}}},
+		{Rows: [][]string{{"S", "M"}}},
+	}
+	if !reflect.DeepEqual(tables, want) {
+		t.Errorf("got  %q\nwant %q", tables, want)
+	}
+}
+
+func TestExtractTableAs(t *testing.T) {
+	type row struct {
+		Name    string  `col:"name"`
+		Age     int     `col:"Age"`
+		Score   float64 `col:"Score"`
+		Member  bool    `col:"Member"`
+		Visits  uint16  `col:"Visits"`
+		Comment string
+		ignored int `col:"Age"`
+	}
+	table := Table{
+		Headers: []string{"Name", "Age", "Score", "Member", "Visits", "Comment"},
+		Rows: [][]string{
+			{"Ada", "36", "€1,234.5", "yes", "12", "not mapped"},
+			{"Alan", "forty", "", "maybe", "70000", ""},
+			{"Grace", "", "-2.5e3", "true", "", ""},
+		},
+	}
+	got, err := ExtractTableAs[row](table)
+
+	want := []row{
+		{Name: "Ada", Age: 36, Score: 1234.5, Member: true, Visits: 12},
+		// Cells that don't convert are left zero
+		{Name: "Alan"},
+		{Name: "Grace", Score: -2500, Member: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %+v\nwant %+v", got, want)
+	}
+
+	// Every bad cell is reported, in order
+	wantErrs := []string{
+		`row 2, column "Age": "forty" is not an integer`,
+		`row 2, column "Member": "maybe" is not true or false`,
+		`row 2, column "Visits": "70000" is not a whole number`,
+	}
+	if err == nil || err.Error() != strings.Join(wantErrs, "\n") {
+		t.Errorf("err = %v, want\n%s", err, strings.Join(wantErrs, "\n"))
+	}
+	var cellErr *CellError
+	if !errors.As(err, &cellErr) || cellErr.Row != 2 || cellErr.Column != "Age" || cellErr.Value != "forty" {
+		t.Errorf("first CellError %+v", cellErr)
+	}
+}
+
+func TestExtractTableAsRejects(t *testing.T) {
+	table := Table{Headers: []string{"Name"}, Rows: [][]string{{"Ada"}}}
+
+	type missing struct {
+		Name string `col:"Name"`
+		Age  int    `col:"Age"`
+	}
+	if _, err := ExtractTableAs[missing](table); err == nil || !strings.Contains(err.Error(), `no "Age" column`) {
+		t.Errorf("missing column: err = %v", err)
+	}
+	if _, err := ExtractTableAs[missing](Table{Rows: table.Rows}); err == nil {
+		t.Error("a table without headers mapped")
+	}
+
+	type unsupported struct {
+		Name []string `col:"Name"`
+	}
+	if _, err := ExtractTableAs[unsupported](table); err == nil || !strings.Contains(err.Error(), "[]string") {
+		t.Errorf("unsupported field: err = %v", err)
+	}
+	if _, err := ExtractTableAs[string](table); err == nil {
+		t.Error("a non-struct type mapped")
+	}
+}
+
+func TestExtractProductsFixture(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(productsPage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tables := ExtractTables(doc)
+	if len(tables) != 2 {
+		t.Fatalf("%d tables, want the products and the nested variants", len(tables))
+	}
+
+	products, err := ExtractTableAs[Product](tables[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Product{
+		{Name: "Mechanical Keyboard", SKU: "KB-101", Price: 1299, Stock: 42, OnSale: true},
+		{Name: "Wireless Mouse", SKU: "MS-220", Price: 39.99, Stock: 0},
+		{Name: "USB-C Hub", SKU: "HB-007", Price: 24.5, Stock: 118},
+		{Name: "Monitor Arm", SKU: "MA-330", Price: 89, Stock: 7, OnSale: true},
+	}
+	if !reflect.DeepEqual(products, want) {
+		t.Errorf("got  %+v\nwant %+v", products, want)
+	}
+}
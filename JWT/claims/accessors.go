@@ -0,0 +1,151 @@
+package claims
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Errors returned by the Get accessors, which read custom claims from
+// jwt.MapClaims without type assertions that could panic. Numbers decode as
+// float64, or as json.Number when the token was parsed with
+// jwt.WithJSONNumber; values set in Go before signing (int, int64,
+// []string, ...) are accepted too.
+var (
+	ErrClaimMissing = errors.New("claims: claim is missing")
+	ErrClaimType    = errors.New("claims: wrong claim type")
+)
+
+// GetString reads a string claim
+func GetString(m jwt.MapClaims, key string) (string, error) {
+	raw, err := get(m, key)
+	if err != nil {
+		return "", err
+	}
+	s, ok := raw.(string)
+	if !ok {
+		return "", typeError(key, "a string", raw)
+	}
+	return s, nil
+}
+
+// GetInt64 reads a whole-number claim
+func GetInt64(m jwt.MapClaims, key string) (int64, error) {
+	raw, err := get(m, key)
+	if err != nil {
+		return 0, err
+	}
+	switch v := raw.(type) {
+	case float64:
+		if v != math.Trunc(v) || v < math.MinInt64 || v >= math.MaxInt64 {
+			return 0, fmt.Errorf("%w: %s must be a whole number, got %v", ErrClaimType, key, v)
+		}
+		return int64(v), nil
+	case json.Number:
+		i, err := v.Int64()
+		if err != nil {
+			return 0, fmt.Errorf("%w: %s must be a whole number, got %v", ErrClaimType, key, v)
+		}
+		return i, nil
+	case int:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	default:
+		return 0, typeError(key, "a number", raw)
+	}
+}
+
+// GetTime reads a time claim given as seconds since the epoch, like exp,
+// or as an RFC 3339 string
+func GetTime(m jwt.MapClaims, key string) (time.Time, error) {
+	raw, err := get(m, key)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var seconds float64
+	switch v := raw.(type) {
+	case float64:
+		seconds = v
+	case json.Number:
+		if seconds, err = v.Float64(); err != nil {
+			return time.Time{}, fmt.Errorf("%w: %s must be a number of seconds, got %v", ErrClaimType, key, v)
+		}
+	case int64:
+		return time.Unix(v, 0), nil
+	case int:
+		return time.Unix(int64(v), 0), nil
+	case string:
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%w: %s must be an RFC 3339 time, got %q", ErrClaimType, key, v)
+		}
+		return t, nil
+	default:
+		return time.Time{}, typeError(key, "a time", raw)
+	}
+
+	whole, frac := math.Modf(seconds)
+	return time.Unix(int64(whole), int64(frac*1e9)), nil
+}
+
+// GetStringSlice reads a list of strings; like aud, a single string is
+// a list of one
+func GetStringSlice(m jwt.MapClaims, key string) ([]string, error) {
+	raw, err := get(m, key)
+	if err != nil {
+		return nil, err
+	}
+	switch v := raw.(type) {
+	case []string:
+		return v, nil
+	case string:
+		return []string{v}, nil
+	case []interface{}:
+		list := make([]string, len(v))
+		for i, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, typeError(fmt.Sprintf("%s[%d]", key, i), "a string", item)
+			}
+			list[i] = s
+		}
+		return list, nil
+	default:
+		return nil, typeError(key, "a list of strings", raw)
+	}
+}
+
+// GetMap reads a nested object, which can be read with the accessors in turn
+func GetMap(m jwt.MapClaims, key string) (jwt.MapClaims, error) {
+	raw, err := get(m, key)
+	if err != nil {
+		return nil, err
+	}
+	switch v := raw.(type) {
+	case map[string]interface{}:
+		return v, nil
+	case jwt.MapClaims:
+		return v, nil
+	default:
+		return nil, typeError(key, "an object", raw)
+	}
+}
+
+// get returns ErrClaimMissing for absent and null claims
+func get(m jwt.MapClaims, key string) (interface{}, error) {
+	raw, ok := m[key]
+	if !ok || raw == nil {
+		return nil, fmt.Errorf("%w: %s", ErrClaimMissing, key)
+	}
+	return raw, nil
+}
+
+func typeError(key, want string, got interface{}) error {
+	return fmt.Errorf("%w: %s must be %s, got %T", ErrClaimType, key, want, got)
+}
@@ -0,0 +1,185 @@
+package claims
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// decoded returns claims as the jwt parser would decode them, with numbers
+// as float64 or, with useNumber, as json.Number
+func decoded(t *testing.T, document string, useNumber bool) jwt.MapClaims {
+	t.Helper()
+
+	dec := json.NewDecoder(strings.NewReader(document))
+	if useNumber {
+		dec.UseNumber()
+	}
+	m := jwt.MapClaims{}
+	if err := dec.Decode(&m); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+const accessorDocument = `{
+	"name": "John Doe",
+	"count": 42,
+	"big": 9007199254740993,
+	"ratio": 1.5,
+	"iat": 1735128000,
+	"half": 1735128000.5,
+	"when": "2024-12-25T12:00:00Z",
+	"perms": ["read", "write"],
+	"single": "admin",
+	"mixed": ["read", 7],
+	"meta": {"department": "eng"},
+	"nothing": null
+}`
+
+func TestGetString(t *testing.T) {
+	m := decoded(t, accessorDocument, false)
+
+	tests := []struct {
+		key     string
+		want    string
+		wantErr error
+	}{
+		{"name", "John Doe", nil},
+		{"absent", "", ErrClaimMissing},
+		{"nothing", "", ErrClaimMissing},
+		{"count", "", ErrClaimType},
+		{"perms", "", ErrClaimType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, err := GetString(m, tt.key)
+			if got != tt.want || !errors.Is(err, tt.wantErr) {
+				t.Errorf("GetString(%q) = %q, %v; want %q, %v", tt.key, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetInt64(t *testing.T) {
+	tests := []struct {
+		name      string
+		key       string
+		useNumber bool
+		want      int64
+		wantErr   error
+	}{
+		{"float64", "count", false, 42, nil},
+		{"json.Number", "count", true, 42, nil},
+		{"json.Number keeps precision", "big", true, 9007199254740993, nil},
+		{"fraction", "ratio", false, 0, ErrClaimType},
+		{"json.Number fraction", "ratio", true, 0, ErrClaimType},
+		{"string", "name", false, 0, ErrClaimType},
+		{"missing", "absent", false, 0, ErrClaimMissing},
+		{"null", "nothing", true, 0, ErrClaimMissing},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetInt64(decoded(t, accessorDocument, tt.useNumber), tt.key)
+			if got != tt.want || !errors.Is(err, tt.wantErr) {
+				t.Errorf("GetInt64(%q) = %d, %v; want %d, %v", tt.key, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("values set in Go", func(t *testing.T) {
+		m := jwt.MapClaims{"int": 7, "int64": int64(8)}
+		if got, err := GetInt64(m, "int"); got != 7 || err != nil {
+			t.Errorf("int: %d, %v", got, err)
+		}
+		if got, err := GetInt64(m, "int64"); got != 8 || err != nil {
+			t.Errorf("int64: %d, %v", got, err)
+		}
+	})
+}
+
+func TestGetTime(t *testing.T) {
+	issued := time.Date(2024, 12, 25, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		key       string
+		useNumber bool
+		want      time.Time
+		wantErr   error
+	}{
+		{"seconds as float64", "iat", false, issued, nil},
+		{"seconds as json.Number", "iat", true, issued, nil},
+		{"fractional seconds", "half", false, issued.Add(500 * time.Millisecond), nil},
+		{"RFC 3339 string", "when", false, issued, nil},
+		{"unparseable string", "name", false, time.Time{}, ErrClaimType},
+		{"list", "perms", false, time.Time{}, ErrClaimType},
+		{"missing", "absent", false, time.Time{}, ErrClaimMissing},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetTime(decoded(t, accessorDocument, tt.useNumber), tt.key)
+			if !got.Equal(tt.want) || !errors.Is(err, tt.wantErr) {
+				t.Errorf("GetTime(%q) = %v, %v; want %v, %v", tt.key, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetStringSlice(t *testing.T) {
+	m := decoded(t, accessorDocument, false)
+	m["go"] = []string{"set", "in", "go"}
+
+	tests := []struct {
+		key     string
+		want    []string
+		wantErr error
+	}{
+		{"perms", []string{"read", "write"}, nil},
+		{"single", []string{"admin"}, nil},
+		{"go", []string{"set", "in", "go"}, nil},
+		{"mixed", nil, ErrClaimType},
+		{"count", nil, ErrClaimType},
+		{"absent", nil, ErrClaimMissing},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, err := GetStringSlice(m, tt.key)
+			if !reflect.DeepEqual(got, tt.want) || !errors.Is(err, tt.wantErr) {
+				t.Errorf("GetStringSlice(%q) = %q, %v; want %q, %v", tt.key, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("element index in error", func(t *testing.T) {
+		if _, err := GetStringSlice(m, "mixed"); err == nil || !strings.Contains(err.Error(), "mixed[1] must be a string, got float64") {
+			t.Errorf("err = %v", err)
+		}
+	})
+}
+
+func TestGetMap(t *testing.T) {
+	m := decoded(t, accessorDocument, false)
+
+	meta, err := GetMap(m, "meta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if department, err := GetString(meta, "department"); department != "eng" || err != nil {
+		t.Errorf("department = %q, %v", department, err)
+	}
+	if _, err := GetString(meta, "team"); !errors.Is(err, ErrClaimMissing) {
+		t.Errorf("nested missing claim: %v", err)
+	}
+
+	for key, want := range map[string]error{"name": ErrClaimType, "absent": ErrClaimMissing} {
+		if _, err := GetMap(m, key); !errors.Is(err, want) {
+			t.Errorf("GetMap(%q) err = %v, want %v", key, err, want)
+		}
+	}
+}
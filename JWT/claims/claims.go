@@ -0,0 +1,232 @@
+// Package claims defines the user claims carried by the demo's access and
+// refresh tokens, with builders that fill in the registered claims and
+// validation of the custom ones.
+package claims
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Token types stored in the "type" claim
+const (
+	TypeAccess  = "access"
+	TypeRefresh = "refresh"
+)
+
+// DefaultIssuer is the iss claim set by the builders
+const DefaultIssuer = "jwt-demo-app"
+
+// AllowedRoles lists the roles Validate accepts
+var AllowedRoles = []string{"admin", "user"}
+
+// Validation errors, wrapped by Validate and FromToken
+var (
+	ErrMissingUserID = errors.New("user_id claim is required")
+	ErrInvalidRole   = errors.New("role claim is not an allowed role")
+	ErrMissingType   = errors.New("type claim must be \"access\" or \"refresh\"")
+)
+
+// Claims are the user claims of an access or refresh token
+type Claims struct {
+	UserID   int    `json:"user_id"`
+	Username string `json:"username"`
+	Role     string `json:"role"`
+	Type     string `json:"type,omitempty"` // TypeAccess or TypeRefresh
+	Family   string `json:"fam,omitempty"`  // refresh token family
+	jwt.RegisteredClaims
+}
+
+// Option adjusts the claims built by NewAccessClaims and NewRefreshClaims
+type Option func(*Claims)
+
+// WithIssuer replaces DefaultIssuer
+func WithIssuer(issuer string) Option {
+	return func(c *Claims) { c.Issuer = issuer }
+}
+
+// WithAudience sets the aud claim
+func WithAudience(audience ...string) Option {
+	return func(c *Claims) { c.Audience = audience }
+}
+
+// WithFamily sets the refresh token family ("fam" claim)
+func WithFamily(family string) Option {
+	return func(c *Claims) { c.Family = family }
+}
+
+// WithIssuedAt issues the token at t instead of now; nbf and exp move with it
+func WithIssuedAt(t time.Time) Option {
+	return func(c *Claims) {
+		ttl := c.ExpiresAt.Sub(c.IssuedAt.Time)
+		c.IssuedAt = jwt.NewNumericDate(t)
+		c.NotBefore = jwt.NewNumericDate(t)
+		c.ExpiresAt = jwt.NewNumericDate(t.Add(ttl))
+	}
+}
+
+// NewAccessClaims builds access token claims valid for ttl from now, with
+// a fresh jti, sub set to the user ID and iss set to DefaultIssuer
+func NewAccessClaims(userID int, username, role string, ttl time.Duration, opts ...Option) *Claims {
+	return newClaims(TypeAccess, userID, username, role, ttl, opts)
+}
+
+// NewRefreshClaims builds refresh token claims like NewAccessClaims
+func NewRefreshClaims(userID int, username, role string, ttl time.Duration, opts ...Option) *Claims {
+	return newClaims(TypeRefresh, userID, username, role, ttl, opts)
+}
+
+func newClaims(tokenType string, userID int, username, role string, ttl time.Duration, opts []Option) *Claims {
+	now := time.Now()
+	c := &Claims{
+		UserID:   userID,
+		Username: username,
+		Role:     role,
+		Type:     tokenType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    DefaultIssuer,
+			Subject:   strconv.Itoa(userID),
+			ID:        NewID(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			NotBefore: jwt.NewNumericDate(now),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Validate checks the custom claims. The jwt parser calls it after the
+// registered claims have been validated, so tokens with missing or bad user
+// claims fail to parse with jwt.ErrTokenInvalidClaims.
+func (c *Claims) Validate() error {
+	var errs []error
+	if c.UserID == 0 {
+		errs = append(errs, ErrMissingUserID)
+	}
+	if !allowedRole(c.Role) {
+		errs = append(errs, fmt.Errorf("%w: %q", ErrInvalidRole, c.Role))
+	}
+	if c.Type != TypeAccess && c.Type != TypeRefresh {
+		errs = append(errs, ErrMissingType)
+	}
+	return errors.Join(errs...)
+}
+
+func allowedRole(role string) bool {
+	for _, allowed := range AllowedRoles {
+		if role == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// FromToken returns the validated Claims of a verified token, whether it was
+// parsed into *Claims or into jwt.MapClaims
+func FromToken(token *jwt.Token) (*Claims, error) {
+	if token == nil {
+		return nil, errors.New("claims: nil token")
+	}
+	if !token.Valid {
+		return nil, errors.New("claims: token has not been verified")
+	}
+
+	var c *Claims
+	switch tc := token.Claims.(type) {
+	case *Claims:
+		c = tc
+	case jwt.MapClaims:
+		var err error
+		if c, err = fromMap(tc); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("claims: unsupported claims type %T", token.Claims)
+	}
+
+	if err := c.Validate(); err != nil {
+		return nil, fmt.Errorf("claims: %w", err)
+	}
+	return c, nil
+}
+
+// fromMap converts MapClaims, checking the type of every custom claim
+func fromMap(m jwt.MapClaims) (*Claims, error) {
+	c := &Claims{}
+	var err error
+
+	if c.UserID, err = intClaim(m, "user_id"); err != nil {
+		return nil, err
+	}
+	for _, field := range []struct {
+		name string
+		dst  *string
+	}{
+		{"username", &c.Username},
+		{"role", &c.Role},
+		{"type", &c.Type},
+		{"fam", &c.Family},
+		{"jti", &c.ID},
+	} {
+		if *field.dst, err = stringClaim(m, field.name); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.Issuer, err = m.GetIssuer(); err != nil {
+		return nil, fmt.Errorf("claims: %w", err)
+	}
+	if c.Subject, err = m.GetSubject(); err != nil {
+		return nil, fmt.Errorf("claims: %w", err)
+	}
+	if c.Audience, err = m.GetAudience(); err != nil {
+		return nil, fmt.Errorf("claims: %w", err)
+	}
+	if c.ExpiresAt, err = m.GetExpirationTime(); err != nil {
+		return nil, fmt.Errorf("claims: %w", err)
+	}
+	if c.NotBefore, err = m.GetNotBefore(); err != nil {
+		return nil, fmt.Errorf("claims: %w", err)
+	}
+	if c.IssuedAt, err = m.GetIssuedAt(); err != nil {
+		return nil, fmt.Errorf("claims: %w", err)
+	}
+	return c, nil
+}
+
+// intClaim reads a whole number; a missing claim is 0
+func intClaim(m jwt.MapClaims, name string) (int, error) {
+	i, err := GetInt64(m, name)
+	if errors.Is(err, ErrClaimMissing) {
+		return 0, nil
+	}
+	return int(i), err
+}
+
+// stringClaim reads a string; a missing claim is ""
+func stringClaim(m jwt.MapClaims, name string) (string, error) {
+	s, err := GetString(m, name)
+	if errors.Is(err, ErrClaimMissing) {
+		return "", nil
+	}
+	return s, err
+}
+
+// NewID returns a random (version 4) UUID for the jti claim
+func NewID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("jti: %v", err))
+	}
+	b[6] = b[6]&0x0f | 0x40 // version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
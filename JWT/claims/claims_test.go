@@ -0,0 +1,234 @@
+package claims
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var secret = []byte("test-secret")
+
+func keyfunc(*jwt.Token) (interface{}, error) { return secret, nil }
+
+func sign(t *testing.T, c jwt.Claims) string {
+	t.Helper()
+
+	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, c).SignedString(secret)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return tokenString
+}
+
+func TestBuilderDefaults(t *testing.T) {
+	before := time.Now().Truncate(time.Second)
+	access := NewAccessClaims(123, "john_doe", "admin", 15*time.Minute)
+	refresh := NewRefreshClaims(123, "john_doe", "admin", 7*24*time.Hour)
+
+	if access.Type != TypeAccess || refresh.Type != TypeRefresh {
+		t.Errorf("types = %q, %q", access.Type, refresh.Type)
+	}
+	if access.Issuer != DefaultIssuer || access.Subject != "123" {
+		t.Errorf("iss = %q, sub = %q", access.Issuer, access.Subject)
+	}
+	if access.ID == "" || access.ID == refresh.ID {
+		t.Errorf("jti not unique: %q, %q", access.ID, refresh.ID)
+	}
+	if access.IssuedAt.Before(before) || !access.NotBefore.Equal(access.IssuedAt.Time) {
+		t.Errorf("iat = %v, nbf = %v", access.IssuedAt, access.NotBefore)
+	}
+	if got := access.ExpiresAt.Sub(access.IssuedAt.Time); got != 15*time.Minute {
+		t.Errorf("access TTL = %s", got)
+	}
+	if got := refresh.ExpiresAt.Sub(refresh.IssuedAt.Time); got != 7*24*time.Hour {
+		t.Errorf("refresh TTL = %s", got)
+	}
+	if access.Audience != nil || access.Family != "" {
+		t.Errorf("unexpected aud %v / fam %q", access.Audience, access.Family)
+	}
+	if err := access.Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+}
+
+func TestBuilderOptions(t *testing.T) {
+	issued := time.Date(2024, 12, 25, 12, 0, 0, 0, time.UTC)
+	c := NewRefreshClaims(456, "jane_doe", "user", time.Hour,
+		WithIssuer("auth.example.com"),
+		WithAudience("web-app", "mobile-app"),
+		WithFamily("fam-1"),
+		WithIssuedAt(issued),
+	)
+
+	if c.Issuer != "auth.example.com" || c.Family != "fam-1" {
+		t.Errorf("iss = %q, fam = %q", c.Issuer, c.Family)
+	}
+	if len(c.Audience) != 2 || c.Audience[1] != "mobile-app" {
+		t.Errorf("aud = %v", c.Audience)
+	}
+	if !c.IssuedAt.Equal(issued) || !c.NotBefore.Equal(issued) || !c.ExpiresAt.Equal(issued.Add(time.Hour)) {
+		t.Errorf("iat = %v, nbf = %v, exp = %v", c.IssuedAt, c.NotBefore, c.ExpiresAt)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Claims)
+		want   []error
+	}{
+		{"missing user ID", func(c *Claims) { c.UserID = 0 }, []error{ErrMissingUserID}},
+		{"unknown role", func(c *Claims) { c.Role = "superuser" }, []error{ErrInvalidRole}},
+		{"empty role", func(c *Claims) { c.Role = "" }, []error{ErrInvalidRole}},
+		{"missing type", func(c *Claims) { c.Type = "" }, []error{ErrMissingType}},
+		{"unknown type", func(c *Claims) { c.Type = "id" }, []error{ErrMissingType}},
+		{"everything", func(c *Claims) { *c = Claims{} }, []error{ErrMissingUserID, ErrInvalidRole, ErrMissingType}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewAccessClaims(123, "john_doe", "admin", time.Hour)
+			tt.modify(c)
+			err := c.Validate()
+			for _, want := range tt.want {
+				if !errors.Is(err, want) {
+					t.Errorf("Validate() = %v, want %v", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParserRunsValidate(t *testing.T) {
+	bad := NewAccessClaims(123, "john_doe", "superuser", time.Hour)
+
+	_, err := jwt.ParseWithClaims(sign(t, bad), &Claims{}, keyfunc)
+	if !errors.Is(err, jwt.ErrTokenInvalidClaims) || !errors.Is(err, ErrInvalidRole) {
+		t.Errorf("err = %v, want %v wrapping %v", err, jwt.ErrTokenInvalidClaims, ErrInvalidRole)
+	}
+}
+
+func TestFromToken(t *testing.T) {
+	want := NewAccessClaims(123, "john_doe", "admin", time.Hour, WithFamily("fam-1"), WithAudience("web-app"))
+	tokenString := sign(t, want)
+
+	t.Run("struct claims", func(t *testing.T) {
+		token, err := jwt.ParseWithClaims(tokenString, &Claims{}, keyfunc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := FromToken(token)
+		if err != nil {
+			t.Fatalf("FromToken: %v", err)
+		}
+		if got.UserID != 123 || got.Family != "fam-1" {
+			t.Errorf("got %+v", got)
+		}
+	})
+
+	t.Run("map claims", func(t *testing.T) {
+		token, err := jwt.Parse(tokenString, keyfunc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := FromToken(token)
+		if err != nil {
+			t.Fatalf("FromToken: %v", err)
+		}
+		if got.UserID != 123 || got.Username != "john_doe" || got.Role != "admin" || got.Type != TypeAccess ||
+			got.Family != "fam-1" || got.ID != want.ID || got.Subject != "123" || got.Issuer != DefaultIssuer ||
+			len(got.Audience) != 1 || !got.ExpiresAt.Equal(want.ExpiresAt.Time) {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("json numbers", func(t *testing.T) {
+		token, err := jwt.Parse(tokenString, keyfunc, jwt.WithJSONNumber())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, err := FromToken(token); err != nil || got.UserID != 123 {
+			t.Errorf("FromToken = %+v, %v", got, err)
+		}
+	})
+}
+
+func TestFromTokenErrors(t *testing.T) {
+	exp := time.Now().Add(time.Hour).Unix()
+	complete := func() jwt.MapClaims {
+		return jwt.MapClaims{"user_id": 123, "role": "admin", "type": "access", "exp": exp}
+	}
+
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		want   string
+	}{
+		{"missing user_id", without(complete(), "user_id"), ErrMissingUserID.Error()},
+		{"missing role", without(complete(), "role"), ErrInvalidRole.Error()},
+		{"missing type", without(complete(), "type"), ErrMissingType.Error()},
+		{"user_id as string", with(complete(), "user_id", "123"), "user_id must be a number, got string"},
+		{"fractional user_id", with(complete(), "user_id", 1.5), "user_id must be a whole number"},
+		{"role as number", with(complete(), "role", 1), "role must be a string, got float64"},
+		{"bad audience", with(complete(), "aud", []interface{}{"web-app", 7}), "aud is invalid"},
+		{"bad issuer", with(complete(), "iss", 7), "iss is invalid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := jwt.Parse(sign(t, tt.claims), keyfunc)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+
+			_, err = FromToken(token)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("FromToken error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	t.Run("unverified token", func(t *testing.T) {
+		token, _, err := jwt.NewParser().ParseUnverified(sign(t, complete()), jwt.MapClaims{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := FromToken(token); err == nil || !strings.Contains(err.Error(), "not been verified") {
+			t.Errorf("err = %v", err)
+		}
+	})
+
+	t.Run("nil token", func(t *testing.T) {
+		if _, err := FromToken(nil); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestNewID(t *testing.T) {
+	uuidV4 := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		id := NewID()
+		if !uuidV4.MatchString(id) {
+			t.Fatalf("NewID() = %q, not a v4 UUID", id)
+		}
+		if seen[id] {
+			t.Fatalf("duplicate ID %q", id)
+		}
+		seen[id] = true
+	}
+}
+
+func with(c jwt.MapClaims, key string, value interface{}) jwt.MapClaims {
+	c[key] = value
+	return c
+}
+
+func without(c jwt.MapClaims, key string) jwt.MapClaims {
+	delete(c, key)
+	return c
+}
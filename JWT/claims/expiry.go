@@ -0,0 +1,44 @@
+package claims
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Errors returned by TimeToExpiry
+var (
+	ErrNoExpiry = errors.New("claims: token has no exp claim")
+	ErrExpired  = errors.New("claims: token has already expired")
+)
+
+// TimeToExpiry returns how long token stays valid after now. A token
+// without exp fails with ErrNoExpiry, one whose exp is not after now with
+// ErrExpired.
+func TimeToExpiry(token *jwt.Token, now time.Time) (time.Duration, error) {
+	if token == nil || token.Claims == nil {
+		return 0, ErrNoExpiry
+	}
+	exp, err := token.Claims.GetExpirationTime()
+	if err != nil {
+		return 0, err
+	}
+	if exp == nil {
+		return 0, ErrNoExpiry
+	}
+
+	remaining := exp.Sub(now)
+	if remaining <= 0 {
+		return 0, ErrExpired
+	}
+	return remaining, nil
+}
+
+// ShouldRefresh reports whether token is still valid but expires within
+// threshold, so a sliding session should replace it now. Tokens without exp
+// or already expired never qualify.
+func ShouldRefresh(token *jwt.Token, threshold time.Duration, now time.Time) bool {
+	remaining, err := TimeToExpiry(token, now)
+	return err == nil && remaining <= threshold
+}
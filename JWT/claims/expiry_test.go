@@ -0,0 +1,74 @@
+package claims
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestTimeToExpiry(t *testing.T) {
+	now := time.Date(2024, 12, 25, 12, 0, 0, 0, time.UTC)
+	withExp := func(exp time.Time) *jwt.Token {
+		return &jwt.Token{Claims: jwt.MapClaims{"exp": float64(exp.Unix())}}
+	}
+
+	tests := []struct {
+		name    string
+		token   *jwt.Token
+		want    time.Duration
+		wantErr error
+	}{
+		{"valid", withExp(now.Add(10 * time.Minute)), 10 * time.Minute, nil},
+		{"one second left", withExp(now.Add(time.Second)), time.Second, nil},
+		{"expires now", withExp(now), 0, ErrExpired},
+		{"expired", withExp(now.Add(-time.Hour)), 0, ErrExpired},
+		{"no exp", &jwt.Token{Claims: jwt.MapClaims{"sub": "123"}}, 0, ErrNoExpiry},
+		{"registered claims without exp", &jwt.Token{Claims: &jwt.RegisteredClaims{}}, 0, ErrNoExpiry},
+		{"nil token", nil, 0, ErrNoExpiry},
+		{"typed claims", &jwt.Token{Claims: NewAccessClaims(1, "a", "user", time.Hour, WithIssuedAt(now))}, time.Hour, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := TimeToExpiry(tt.token, now)
+			if got != tt.want || !errors.Is(err, tt.wantErr) {
+				t.Errorf("TimeToExpiry = %s, %v; want %s, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("malformed exp", func(t *testing.T) {
+		token := &jwt.Token{Claims: jwt.MapClaims{"exp": "tomorrow"}}
+		if _, err := TimeToExpiry(token, now); !errors.Is(err, jwt.ErrInvalidType) {
+			t.Errorf("err = %v, want %v", err, jwt.ErrInvalidType)
+		}
+	})
+}
+
+func TestShouldRefresh(t *testing.T) {
+	issued := time.Date(2024, 12, 25, 12, 0, 0, 0, time.UTC)
+	token := &jwt.Token{Claims: NewAccessClaims(1, "a", "user", 15*time.Minute, WithIssuedAt(issued))}
+	threshold := 5 * time.Minute
+
+	tests := []struct {
+		elapsed time.Duration
+		want    bool
+	}{
+		{0, false},
+		{10*time.Minute - time.Second, false},
+		{10 * time.Minute, true}, // exactly threshold left
+		{14 * time.Minute, true},
+		{15 * time.Minute, false}, // expired: too late to slide
+		{time.Hour, false},
+	}
+	for _, tt := range tests {
+		if got := ShouldRefresh(token, threshold, issued.Add(tt.elapsed)); got != tt.want {
+			t.Errorf("after %s: ShouldRefresh = %t, want %t", tt.elapsed, got, tt.want)
+		}
+	}
+
+	if ShouldRefresh(&jwt.Token{Claims: jwt.MapClaims{}}, time.Hour, issued) {
+		t.Error("token without exp should not be refreshed")
+	}
+}
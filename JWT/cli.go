@@ -0,0 +1,432 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/spf13/cobra"
+	"jwt-demo/tokenerr"
+)
+
+// Exit codes of the CLI
+const (
+	exitOK               = 0
+	exitError            = 1 // usage errors, unreadable keys, ...
+	exitInvalidSignature = 2 // bad signature or disallowed algorithm
+	exitExpired          = 3 // exp in the past
+	exitMalformed        = 4 // not a JWT at all
+	exitInvalidClaims    = 5 // wrong aud/iss, not valid yet, ...
+)
+
+// exitCodeError carries the process exit code for a failed command
+type exitCodeError struct {
+	code int
+	err  error
+}
+
+func (e *exitCodeError) Error() string { return e.err.Error() }
+func (e *exitCodeError) Unwrap() error { return e.err }
+
+// exitCode maps an error returned by a command to the process exit code
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+	var coded *exitCodeError
+	if errors.As(err, &coded) {
+		return coded.code
+	}
+	return exitError
+}
+
+// tokenError classifies a jwt parse error and attaches the matching exit
+// code
+func tokenError(err error) error {
+	code := exitInvalidClaims
+	switch tokenerr.Classify(err) {
+	case tokenerr.ErrMalformed:
+		code = exitMalformed
+	case tokenerr.ErrBadSignature, tokenerr.ErrWrongAlgorithm:
+		code = exitInvalidSignature
+	case tokenerr.ErrExpired:
+		code = exitExpired
+	}
+	return &exitCodeError{code: code, err: tokenerr.Wrap(err)}
+}
+
+// cli holds what the commands read from the environment, so tests can
+// replace it
+type cli struct {
+	now       func() time.Time
+	lookupEnv func(string) (string, bool)
+}
+
+// newRootCmd builds the jwt-demo command tree. Without a subcommand the
+// demos run (or the auth server with --serve).
+func newRootCmd() *cobra.Command {
+	c := &cli{now: time.Now, lookupEnv: os.LookupEnv}
+	return c.rootCmd()
+}
+
+func (c *cli) rootCmd() *cobra.Command {
+	var serveAddr, keyDir string
+
+	cmd := &cobra.Command{
+		Use:   "jwt-demo",
+		Short: "JWT demos and a small token tool",
+		Long: "Runs the JWT demos, or with a subcommand signs, verifies and decodes tokens.\n\n" +
+			"Exit codes: 0 ok, 1 error, 2 invalid signature, 3 expired, 4 malformed, 5 invalid claims.",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// The server has no demo defaults: fail before doing anything
+			// else when its configuration is incomplete
+			var cfg *Config
+			if serveAddr != "" {
+				var err error
+				if cfg, err = LoadConfig(c.lookupEnv); err != nil {
+					return err
+				}
+			}
+			return runDemos(serveAddr, keyDir, cfg)
+		},
+	}
+	cmd.Flags().StringVar(&serveAddr, "serve", "", "run the HTTP auth server on this address (e.g. :8080) instead of the demos")
+	cmd.Flags().StringVar(&keyDir, "keys", "", "load signing keys from this directory, creating them if missing (default: fresh keys every run)")
+
+	cmd.AddCommand(c.signCmd(), c.verifyCmd(), c.decodeCmd())
+	return cmd
+}
+
+func (c *cli) signCmd() *cobra.Command {
+	var (
+		alg, keyFile, secret, subject, issuer string
+		audience, claimArgs                   []string
+		ttl                                   time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "sign",
+		Short: "Sign a new token and print it",
+		Example: "  JWT_SECRET=$(openssl rand -hex 32) jwt-demo sign --sub 123 --claim role=admin --exp 2h\n" +
+			"  jwt-demo sign --alg RS256 --key-file keys/rsa.pem --aud web-app",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			method := jwt.GetSigningMethod(alg)
+			if method == nil || method.Alg() == "none" {
+				return fmt.Errorf("unsupported --alg %q (use HS256, RS256, ES256 or EdDSA)", alg)
+			}
+			if ttl <= 0 {
+				return fmt.Errorf("--exp must be positive, got %s", ttl)
+			}
+
+			now := c.now()
+			claims := jwt.MapClaims{
+				"iat": now.Unix(),
+				"exp": now.Add(ttl).Unix(),
+			}
+			for _, arg := range claimArgs {
+				key, value, ok := strings.Cut(arg, "=")
+				if !ok || key == "" {
+					return fmt.Errorf("--claim %q: expected key=value", arg)
+				}
+				switch key {
+				case "exp", "iat", "nbf":
+					return fmt.Errorf("--claim %q: %s is set from --exp", arg, key)
+				}
+				claims[key] = value
+			}
+			if subject != "" {
+				claims["sub"] = subject
+			}
+			if issuer != "" {
+				claims["iss"] = issuer
+			}
+			if len(audience) == 1 {
+				claims["aud"] = audience[0]
+			} else if len(audience) > 1 {
+				claims["aud"] = audience
+			}
+
+			key, err := c.signingKey(method, keyFile, secret)
+			if err != nil {
+				return err
+			}
+			tokenString, err := jwt.NewWithClaims(method, claims).SignedString(key)
+			if err != nil {
+				return fmt.Errorf("sign: %w", err)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), tokenString)
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&alg, "alg", "HS256", "signing algorithm: HS256, RS256, ES256 or EdDSA")
+	flags.StringVar(&keyFile, "key-file", "", "PEM private key for RS256, ES256 and EdDSA")
+	flags.StringVar(&secret, "secret", "", "HMAC secret (default $"+SecretEnv+")")
+	flags.StringVar(&subject, "sub", "", "subject claim")
+	flags.StringVar(&issuer, "iss", "", "issuer claim")
+	flags.StringSliceVar(&audience, "aud", nil, "audience claim (repeatable)")
+	flags.StringArrayVar(&claimArgs, "claim", nil, "extra claim as key=value (repeatable)")
+	flags.DurationVar(&ttl, "exp", time.Hour, "time until the token expires")
+	return cmd
+}
+
+func (c *cli) verifyCmd() *cobra.Command {
+	var (
+		keyFile, secret, issuer, audience string
+		leeway                            time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "verify <token>",
+		Short: "Verify a token's signature and claims",
+		Example: "  jwt-demo verify $TOKEN --key-file pub.pem --aud web-app\n" +
+			"  JWT_SECRET_FILE=/run/secrets/jwt jwt-demo verify $TOKEN",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, methods, err := c.verificationKey(keyFile, secret)
+			if err != nil {
+				return err
+			}
+
+			opts := []jwt.ParserOption{
+				jwt.WithValidMethods(methods),
+				jwt.WithLeeway(leeway),
+				jwt.WithTimeFunc(c.now),
+				jwt.WithExpirationRequired(),
+			}
+			if audience != "" {
+				opts = append(opts, jwt.WithAudience(audience))
+			}
+			if issuer != "" {
+				opts = append(opts, jwt.WithIssuer(issuer))
+			}
+
+			claims := jwt.MapClaims{}
+			token, err := jwt.NewParser(opts...).ParseWithClaims(args[0], claims, func(*jwt.Token) (interface{}, error) {
+				return key, nil
+			})
+			if err != nil {
+				return tokenError(tokenerr.Parsed(token, err, methods))
+			}
+
+			out := cmd.OutOrStdout()
+			fmt.Fprintf(out, "✅ Token is valid (%s)\n", token.Method.Alg())
+			return writeIndentedJSON(out, claims)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&keyFile, "key-file", "", "PEM public (or private) key; HMAC secret is used when omitted")
+	flags.StringVar(&secret, "secret", "", "HMAC secret (default $"+SecretEnv+")")
+	flags.StringVar(&audience, "aud", "", "required audience")
+	flags.StringVar(&issuer, "iss", "", "required issuer")
+	flags.DurationVar(&leeway, "leeway", 0, "allowed clock skew for exp, nbf and iat")
+	return cmd
+}
+
+func (c *cli) decodeCmd() *cobra.Command {
+	var keyFile, secret string
+
+	cmd := &cobra.Command{
+		Use:   "decode <token>",
+		Short: "Print a token's header, claims and diagnostics",
+		Long: "Print a token's header and claims with times as RFC3339. The signature\n" +
+			"is checked when a key is available but a bad one is only reported, not an error.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			report, err := inspect(args[0], c.decodeKeyfunc(keyFile, secret), c.now())
+			if err != nil {
+				return tokenError(err)
+			}
+			return writeReport(cmd.OutOrStdout(), report)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&keyFile, "key-file", "", "PEM public (or private) key to check the signature with")
+	flags.StringVar(&secret, "secret", "", "HMAC secret (default $"+SecretEnv+")")
+	return cmd
+}
+
+// decodeKeyfunc finds a key for decode without insisting on one: HMAC
+// tokens use the secret when set, others need --key-file
+func (c *cli) decodeKeyfunc(keyFile, secret string) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		if keyFile == "" {
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, nil
+			}
+			// Short secrets are accepted here so Inspect can warn about them
+			if secret != "" {
+				return []byte(secret), nil
+			}
+			if key, err := c.hmacSecret(""); err == nil {
+				return key, nil
+			}
+			return nil, nil
+		}
+
+		key, methods, err := c.verificationKey(keyFile, secret)
+		if err != nil {
+			return nil, err
+		}
+		if !containsString(methods, token.Method.Alg()) {
+			return nil, fmt.Errorf("%w %q for %s", ErrAlgorithmNotAllowed, token.Method.Alg(), keyFile)
+		}
+		return key, nil
+	}
+}
+
+// writeReport prints an Inspect report
+func writeReport(w io.Writer, report *TokenReport) error {
+	switch report.Signature {
+	case SignatureValid:
+		fmt.Fprintln(w, "✅ Signature valid")
+	case SignatureInvalid:
+		fmt.Fprintf(w, "❌ Signature invalid: %v\n", report.SignatureError)
+	default:
+		fmt.Fprintln(w, "⚠️  Signature NOT verified (no key)")
+	}
+
+	fmt.Fprintln(w, "Header:")
+	if err := writeIndentedJSON(w, report.Header); err != nil {
+		return err
+	}
+	fmt.Fprintln(w, "Claims:")
+	if err := writeIndentedJSON(w, report.Claims); err != nil {
+		return err
+	}
+
+	switch ttl := report.TTL; {
+	case ttl == nil:
+		fmt.Fprintln(w, "Remaining TTL: unknown (no valid exp claim)")
+	case *ttl > 0:
+		fmt.Fprintf(w, "Remaining TTL: %s\n", *ttl)
+	default:
+		fmt.Fprintf(w, "Remaining TTL: expired %s ago\n", -*ttl)
+	}
+
+	for _, warning := range report.Warnings {
+		fmt.Fprintf(w, "⚠️  %s\n", warning)
+	}
+	return nil
+}
+
+// signingKey returns the key for method from keyFile or the HMAC secret
+func (c *cli) signingKey(method jwt.SigningMethod, keyFile, secret string) (interface{}, error) {
+	if _, ok := method.(*jwt.SigningMethodHMAC); ok {
+		return c.hmacSecret(secret)
+	}
+	if keyFile == "" {
+		return nil, fmt.Errorf("--key-file is required for %s", method.Alg())
+	}
+
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	key, err := parsePrivateKeyPEM(data, []byte(c.getenv(KeyPassphraseEnv)))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", keyFile, err)
+	}
+
+	if !containsString(methodsForKey(key.Public()), method.Alg()) {
+		return nil, fmt.Errorf("%s holds a %T, which cannot sign %s", keyFile, key, method.Alg())
+	}
+	return key, nil
+}
+
+// verificationKey returns the public key in keyFile, or the HMAC secret,
+// together with the algorithms that key may verify
+func (c *cli) verificationKey(keyFile, secret string) (interface{}, []string, error) {
+	if keyFile == "" {
+		key, err := c.hmacSecret(secret)
+		return key, []string{"HS256", "HS384", "HS512"}, err
+	}
+
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := parsePublicKeyPEM(data, []byte(c.getenv(KeyPassphraseEnv)))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", keyFile, err)
+	}
+	methods := methodsForKey(key)
+	if methods == nil {
+		return nil, nil, fmt.Errorf("%s: unsupported key type %T", keyFile, key)
+	}
+	return key, methods, nil
+}
+
+// hmacSecret returns the --secret flag or, without it, the secret of the
+// environment configuration; the whole configuration must be valid then
+func (c *cli) hmacSecret(secret string) ([]byte, error) {
+	if secret != "" {
+		return []byte(secret), checkSecret("--secret", []byte(secret))
+	}
+	cfg, err := LoadConfig(c.lookupEnv)
+	if err != nil {
+		return nil, fmt.Errorf("no usable HMAC secret: pass --secret or fix the environment: %w", err)
+	}
+	return cfg.Secret, nil
+}
+
+func (c *cli) getenv(key string) string {
+	value, _ := c.lookupEnv(key)
+	return value
+}
+
+// methodsForKey lists the algorithms a public key can verify, so an RSA key
+// never accepts an HMAC token and vice versa
+func methodsForKey(key crypto.PublicKey) []string {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512"}
+	case *ecdsa.PublicKey:
+		switch k.Curve {
+		case elliptic.P256():
+			return []string{"ES256"}
+		case elliptic.P384():
+			return []string{"ES384"}
+		case elliptic.P521():
+			return []string{"ES512"}
+		}
+	case ed25519.PublicKey:
+		return []string{"EdDSA"}
+	}
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// writeIndentedJSON prints v as indented JSON with sorted keys
+func writeIndentedJSON(w io.Writer, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bytes"
+	"crypto"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testSecret is the JWT_SECRET of testCLI
+const testSecret = "s3cret-s3cret-s3cret-s3cret-s3cret"
+
+// testCLI returns a cli with a fixed clock and JWT_SECRET set to testSecret
+func testCLI(now time.Time) *cli {
+	return &cli{now: func() time.Time { return now }, lookupEnv: envLookup(map[string]string{SecretEnv: testSecret})}
+}
+
+// envLookup returns a lookupEnv reading env
+func envLookup(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+}
+
+// runCLI executes the command tree with args and returns stdout, stderr and
+// the exit code main would use
+func runCLI(t *testing.T, c *cli, args ...string) (string, string, int) {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+	cmd := c.rootCmd()
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&stderr)
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return stdout.String(), stderr.String(), exitCode(err)
+}
+
+func signCLI(t *testing.T, c *cli, args ...string) string {
+	t.Helper()
+
+	stdout, stderr, code := runCLI(t, c, append([]string{"sign"}, args...)...)
+	if code != exitOK {
+		t.Fatalf("sign %v: exit %d: %s", args, code, stderr)
+	}
+	return strings.TrimSpace(stdout)
+}
+
+func TestCLISignVerifyHMAC(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	c := testCLI(now)
+	token := signCLI(t, c, "--sub", "123", "--claim", "role=admin", "--aud", "web-app", "--exp", "2h")
+
+	stdout, stderr, code := runCLI(t, c, "verify", token, "--aud", "web-app")
+	if code != exitOK {
+		t.Fatalf("verify: exit %d: %s", code, stderr)
+	}
+	for _, want := range []string{"Token is valid (HS256)", `"role": "admin"`, `"sub": "123"`, `"exp": 1700007200`} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("verify output missing %q:\n%s", want, stdout)
+		}
+	}
+
+	// --secret overrides the environment
+	if _, _, code := runCLI(t, c, "verify", token, "--secret", "another-secret-another-secret-1234"); code != exitInvalidSignature {
+		t.Errorf("wrong secret: exit %d, want %d", code, exitInvalidSignature)
+	}
+}
+
+func TestCLIVerifyExitCodes(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	c := testCLI(now)
+	token := signCLI(t, c, "--aud", "web-app", "--iss", "jwt-demo-app", "--exp", "1h")
+
+	later := testCLI(now.Add(2 * time.Hour))
+	rsToken := signCLI(t, c, "--alg", "RS256", "--key-file", writeKeyFile(t, privateKey))
+
+	tests := []struct {
+		name string
+		cli  *cli
+		args []string
+		want int
+	}{
+		{"valid", c, []string{token, "--aud", "web-app", "--iss", "jwt-demo-app"}, exitOK},
+		{"tampered signature", c, []string{token[:len(token)-4] + "AAAA"}, exitInvalidSignature},
+		{"algorithm not allowed for key", c, []string{rsToken}, exitInvalidSignature},
+		{"expired", later, []string{token}, exitExpired},
+		{"expired within leeway", later, []string{token, "--leeway", "2h"}, exitOK},
+		{"malformed", c, []string{"not-a-token"}, exitMalformed},
+		{"bad base64", c, []string{"a.b.c"}, exitMalformed},
+		{"wrong audience", c, []string{token, "--aud", "billing"}, exitInvalidClaims},
+		{"wrong issuer", c, []string{token, "--iss", "evil"}, exitInvalidClaims},
+		{"missing argument", c, nil, exitError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, stderr, code := runCLI(t, tt.cli, append([]string{"verify"}, tt.args...)...)
+			if code != tt.want {
+				t.Errorf("exit %d, want %d; stderr: %s", code, tt.want, stderr)
+			}
+		})
+	}
+}
+
+func TestCLIAsymmetricKeyFiles(t *testing.T) {
+	c := testCLI(time.Now())
+
+	tests := []struct {
+		alg     string
+		private crypto.Signer
+		public  interface{}
+	}{
+		{"RS256", privateKey, publicKey},
+		{"ES256", ecdsaPrivateKey, ecdsaPublicKey},
+		{"EdDSA", ed25519PrivateKey, ed25519PublicKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.alg, func(t *testing.T) {
+			token := signCLI(t, c, "--alg", tt.alg, "--key-file", writeKeyFile(t, tt.private), "--sub", "123")
+
+			pubFile := filepath.Join(t.TempDir(), "pub.pem")
+			if err := os.WriteFile(pubFile, []byte(exportPublicKeyAsPEMStr(tt.public)), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			stdout, stderr, code := runCLI(t, c, "verify", token, "--key-file", pubFile)
+			if code != exitOK || !strings.Contains(stdout, "("+tt.alg+")") {
+				t.Errorf("verify: exit %d, stdout %q, stderr %q", code, stdout, stderr)
+			}
+		})
+	}
+
+	t.Run("key does not match algorithm", func(t *testing.T) {
+		_, stderr, code := runCLI(t, c, "sign", "--alg", "ES256", "--key-file", writeKeyFile(t, privateKey))
+		if code != exitError || !strings.Contains(stderr, "cannot sign ES256") {
+			t.Errorf("exit %d, stderr %q", code, stderr)
+		}
+	})
+}
+
+func TestCLISignErrors(t *testing.T) {
+	noSecret := &cli{now: time.Now, lookupEnv: envLookup(nil)}
+	badEnv := &cli{now: time.Now, lookupEnv: envLookup(map[string]string{SecretEnv: testSecret, AccessTTLEnv: "soon"})}
+
+	tests := []struct {
+		name string
+		cli  *cli
+		args []string
+		want string
+	}{
+		{"no secret", noSecret, nil, "JWT_SECRET or JWT_SECRET_FILE is required"},
+		{"short secret flag", testCLI(time.Now()), []string{"--secret", "s3cret"}, "--secret must be at least 32 bytes, got 6"},
+		{"invalid environment", badEnv, nil, `ACCESS_TTL: time: invalid duration "soon"`},
+		{"alg none", testCLI(time.Now()), []string{"--alg", "none"}, `unsupported --alg "none"`},
+		{"unknown alg", testCLI(time.Now()), []string{"--alg", "XX1"}, `unsupported --alg "XX1"`},
+		{"bad claim", testCLI(time.Now()), []string{"--claim", "role"}, "expected key=value"},
+		{"reserved claim", testCLI(time.Now()), []string{"--claim", "exp=1"}, "set from --exp"},
+		{"missing key file", testCLI(time.Now()), []string{"--alg", "RS256"}, "--key-file is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, stderr, code := runCLI(t, tt.cli, append([]string{"sign"}, tt.args...)...)
+			if code != exitError || !strings.Contains(stderr, tt.want) {
+				t.Errorf("exit %d, stderr %q, want %q", code, stderr, tt.want)
+			}
+		})
+	}
+}
+
+func TestCLIDecode(t *testing.T) {
+	issued := time.Date(2024, 12, 25, 12, 0, 0, 0, time.UTC)
+	token := signCLI(t, testCLI(issued), "--sub", "123", "--exp", "2h")
+
+	// Decoding needs no secret and reports times relative to now
+	c := &cli{now: func() time.Time { return issued.Add(30 * time.Minute) }, lookupEnv: envLookup(nil)}
+	stdout, stderr, code := runCLI(t, c, "decode", token)
+	if code != exitOK {
+		t.Fatalf("decode: exit %d: %s", code, stderr)
+	}
+
+	want := strings.Join([]string{
+		"⚠️  Signature NOT verified (no key)",
+		"Header:",
+		"{",
+		`  "alg": "HS256",`,
+		`  "typ": "JWT"`,
+		"}",
+		"Claims:",
+		"{",
+		`  "exp": "2024-12-25T14:00:00Z",`,
+		`  "iat": "2024-12-25T12:00:00Z",`,
+		`  "sub": "123"`,
+		"}",
+		"Remaining TTL: 1h30m0s",
+		"",
+	}, "\n")
+	if stdout != want {
+		t.Errorf("decode output:\n%s\nwant:\n%s", stdout, want)
+	}
+
+	t.Run("signature status", func(t *testing.T) {
+		rsToken := signCLI(t, testCLI(issued), "--alg", "RS256", "--key-file", writeKeyFile(t, privateKey), "--exp", "2h")
+		tests := []struct {
+			name  string
+			token string
+			args  []string
+			want  string
+		}{
+			{"secret from env", token, nil, "✅ Signature valid\n"},
+			{"wrong secret", token, []string{"--secret", "other"}, "❌ Signature invalid: token signature is invalid"},
+			{"short secret", token, []string{"--secret", "other"}, "⚠️  HS256 secret shorter than 32 bytes\n"},
+			{"RSA token without key file", rsToken, nil, "⚠️  Signature NOT verified (no key)\n"},
+			{"RSA token with key file", rsToken, []string{"--key-file", writeKeyFile(t, privateKey)}, "✅ Signature valid\n"},
+			{"key file for another algorithm", rsToken, []string{"--key-file", writeKeyFile(t, ecdsaPrivateKey)}, `algorithm not allowed "RS256"`},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				stdout, stderr, code := runCLI(t, testCLI(issued), append([]string{"decode", tt.token}, tt.args...)...)
+				if code != exitOK || !strings.Contains(stdout, tt.want) {
+					t.Errorf("exit %d, stderr %q, stdout:\n%s\nwant %q", code, stderr, stdout, tt.want)
+				}
+			})
+		}
+	})
+
+	t.Run("no exp claim", func(t *testing.T) {
+		stdout, _, code := runCLI(t, c, "decode", jwtIOToken)
+		if code != exitOK || !strings.HasSuffix(stdout, "Remaining TTL: unknown (no valid exp claim)\n⚠️  no exp claim\n") {
+			t.Errorf("exit %d, stdout:\n%s", code, stdout)
+		}
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		c := testCLI(issued.Add(3 * time.Hour))
+		stdout, _, code := runCLI(t, c, "decode", token)
+		if code != exitOK || !strings.Contains(stdout, "Remaining TTL: expired 1h0m0s ago") {
+			t.Errorf("exit %d, stdout:\n%s", code, stdout)
+		}
+	})
+
+	t.Run("malformed token", func(t *testing.T) {
+		if _, _, code := runCLI(t, testCLI(issued), "decode", "abc.def"); code != exitMalformed {
+			t.Errorf("exit %d, want %d", code, exitMalformed)
+		}
+	})
+}
+
+// writeKeyFile writes a private key as PEM into a temporary file
+func writeKeyFile(t *testing.T, key crypto.Signer) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "key.pem")
+	if err := writePrivateKeyPEM(path, key, nil); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
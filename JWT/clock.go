@@ -0,0 +1,77 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"jwt-demo/tokenerr"
+)
+
+// Clock tells the time. Token creation and validation take a Clock (or its
+// Now method as a func() time.Time) so tests can control time instead of
+// sleeping.
+type Clock interface {
+	Now() time.Time
+}
+
+// RealClock is the system clock
+type RealClock struct{}
+
+// Now returns time.Now()
+func (RealClock) Now() time.Time { return time.Now() }
+
+// FakeClock is a Clock that only moves when told to
+type FakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewFakeClock creates a FakeClock set to now
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now returns the clock's current time
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Set moves the clock to now
+func (c *FakeClock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
+
+// signTimedToken signs an HS256 token issued at clock.Now() that becomes
+// valid after notBefore and expires ttl after issue
+func signTimedToken(clock Clock, notBefore, ttl time.Duration) (string, error) {
+	now := clock.Now()
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": "1234567890",
+		"iat": now.Unix(),
+		"nbf": now.Add(notBefore).Unix(),
+		"exp": now.Add(ttl).Unix(),
+	}).SignedString(hmacSecret)
+}
+
+// verifyTimedToken validates tokenString at the time shown by clock,
+// tolerating leeway of clock skew; errors are classified by tokenerr
+func verifyTimedToken(tokenString string, clock Clock, leeway time.Duration) error {
+	_, err := jwt.Parse(tokenString, hmacKeyfunc,
+		jwt.WithTimeFunc(clock.Now),
+		jwt.WithLeeway(leeway),
+		jwt.WithExpirationRequired(),
+	)
+	return tokenerr.Wrap(err)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2024, 12, 25, 12, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+
+	if !clock.Now().Equal(start) {
+		t.Errorf("Now() = %v, want %v", clock.Now(), start)
+	}
+	clock.Advance(90 * time.Second)
+	if want := start.Add(90 * time.Second); !clock.Now().Equal(want) {
+		t.Errorf("after Advance: %v, want %v", clock.Now(), want)
+	}
+	clock.Set(start)
+	if !clock.Now().Equal(start) {
+		t.Errorf("after Set: %v, want %v", clock.Now(), start)
+	}
+}
+
+func TestTokenTimeBoundaries(t *testing.T) {
+	issued := time.Date(2024, 12, 25, 12, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(issued)
+
+	expiring, err := signTimedToken(clock, 0, 10*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notYet, err := signTimedToken(clock, time.Minute, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		at     time.Duration // offset from issue
+		leeway time.Duration
+		want   error
+	}{
+		{"exp: just before", expiring, 10*time.Second - time.Nanosecond, 0, nil},
+		{"exp: exactly at", expiring, 10 * time.Second, 0, jwt.ErrTokenExpired},
+		{"exp: after", expiring, 11 * time.Second, 0, jwt.ErrTokenExpired},
+		{"exp: inside leeway", expiring, 15*time.Second - time.Nanosecond, 5 * time.Second, nil},
+		{"exp: at leeway edge", expiring, 15 * time.Second, 5 * time.Second, jwt.ErrTokenExpired},
+		{"nbf: in the future", notYet, 0, 0, jwt.ErrTokenNotValidYet},
+		{"nbf: just before", notYet, time.Minute - time.Nanosecond, 0, jwt.ErrTokenNotValidYet},
+		{"nbf: exactly at", notYet, time.Minute, 0, nil},
+		{"nbf: inside leeway", notYet, 30 * time.Second, 30 * time.Second, nil},
+		{"nbf: outside leeway", notYet, 30*time.Second - time.Nanosecond, 30 * time.Second, jwt.ErrTokenNotValidYet},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock.Set(issued.Add(tt.at))
+			err := verifyTimedToken(tt.token, clock, tt.leeway)
+			if tt.want == nil && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthServerUsesClock(t *testing.T) {
+	s, clock := testAuthServer(t)
+	issued := clock.Now()
+	access, _ := login(t, s.Handler(), "jane_doe", "password456")
+
+	token, _, err := jwt.NewParser().ParseUnverified(access, jwt.MapClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp, _ := token.Claims.GetExpirationTime()
+	if want := issued.Add(s.accessTTL).Unix(); exp.Unix() != want {
+		t.Errorf("exp = %d, want %d", exp.Unix(), want)
+	}
+}
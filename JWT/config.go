@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"jwt-demo/claims"
+)
+
+// Environment variables read by LoadConfig
+const (
+	SecretEnv     = "JWT_SECRET"
+	SecretFileEnv = "JWT_SECRET_FILE" // path to a file holding the secret; wins over JWT_SECRET
+	AccessTTLEnv  = "ACCESS_TTL"
+	RefreshTTLEnv = "REFRESH_TTL"
+	IssuerEnv     = "ISSUER"
+	AudienceEnv   = "AUDIENCE"
+)
+
+// MinSecretLength is the shortest accepted HMAC secret, the output size of
+// SHA-256
+const MinSecretLength = 32
+
+// Config holds the secret and token settings of the auth server and CLI
+type Config struct {
+	Secret     []byte
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+	Issuer     string
+	Audience   string // optional; tokens carry and require it when set
+}
+
+// DefaultConfig returns the settings used for variables that are not set.
+// There is no default secret.
+func DefaultConfig() Config {
+	return Config{
+		AccessTTL:  15 * time.Minute,
+		RefreshTTL: 7 * 24 * time.Hour,
+		Issuer:     claims.DefaultIssuer,
+	}
+}
+
+// LoadConfig reads the configuration from the environment through
+// lookupEnv (os.LookupEnv outside tests) and validates it. Every problem is
+// listed in the error, not just the first one found.
+func LoadConfig(lookupEnv func(string) (string, bool)) (*Config, error) {
+	cfg := DefaultConfig()
+	var problems []error
+
+	secret, err := loadSecret(lookupEnv)
+	cfg.Secret = secret
+	problems = append(problems, err)
+
+	var accessErr, refreshErr error
+	cfg.AccessTTL, accessErr = durationEnv(lookupEnv, AccessTTLEnv, cfg.AccessTTL)
+	cfg.RefreshTTL, refreshErr = durationEnv(lookupEnv, RefreshTTLEnv, cfg.RefreshTTL)
+	problems = append(problems, accessErr, refreshErr)
+	if accessErr == nil && refreshErr == nil && cfg.AccessTTL >= cfg.RefreshTTL {
+		problems = append(problems, fmt.Errorf("%s (%s) must be shorter than %s (%s)",
+			AccessTTLEnv, cfg.AccessTTL, RefreshTTLEnv, cfg.RefreshTTL))
+	}
+
+	if issuer, ok := lookupEnv(IssuerEnv); ok {
+		cfg.Issuer = strings.TrimSpace(issuer)
+		if cfg.Issuer == "" {
+			problems = append(problems, fmt.Errorf("%s must not be empty", IssuerEnv))
+		}
+	}
+	if audience, ok := lookupEnv(AudienceEnv); ok {
+		cfg.Audience = strings.TrimSpace(audience)
+	}
+
+	if err := errors.Join(problems...); err != nil {
+		return nil, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	return &cfg, nil
+}
+
+// loadSecret reads JWT_SECRET_FILE if set, JWT_SECRET otherwise
+func loadSecret(lookupEnv func(string) (string, bool)) ([]byte, error) {
+	if path, ok := lookupEnv(SecretFileEnv); ok && path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", SecretFileEnv, err)
+		}
+		// Mounted secrets usually end with a newline that is not part of
+		// the secret
+		secret := bytes.TrimRight(data, "\r\n")
+		return secret, checkSecret(SecretFileEnv, secret)
+	}
+
+	secret, _ := lookupEnv(SecretEnv)
+	if secret == "" {
+		return nil, fmt.Errorf("%s or %s is required", SecretEnv, SecretFileEnv)
+	}
+	return []byte(secret), checkSecret(SecretEnv, []byte(secret))
+}
+
+// checkSecret rejects HMAC secrets shorter than MinSecretLength; source
+// names where the secret came from
+func checkSecret(source string, secret []byte) error {
+	if len(secret) < MinSecretLength {
+		return fmt.Errorf("%s must be at least %d bytes, got %d", source, MinSecretLength, len(secret))
+	}
+	return nil
+}
+
+// durationEnv parses a positive duration such as "15m", or returns def
+// when name is not set
+func durationEnv(lookupEnv func(string) (string, bool), name string, def time.Duration) (time.Duration, error) {
+	value, ok := lookupEnv(name)
+	if !ok {
+		return def, nil
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", name, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("%s must be positive, got %s", name, d)
+	}
+	return d, nil
+}
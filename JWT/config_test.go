@@ -0,0 +1,123 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfigDefaults(t *testing.T) {
+	cfg, err := LoadConfig(envLookup(map[string]string{SecretEnv: testSecret}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := DefaultConfig()
+	if string(cfg.Secret) != testSecret || cfg.AccessTTL != want.AccessTTL || cfg.RefreshTTL != want.RefreshTTL ||
+		cfg.Issuer != "jwt-demo-app" || cfg.Audience != "" {
+		t.Errorf("config = %+v", cfg)
+	}
+}
+
+func TestLoadConfigFromEnv(t *testing.T) {
+	cfg, err := LoadConfig(envLookup(map[string]string{
+		SecretEnv:     testSecret,
+		AccessTTLEnv:  "5m",
+		RefreshTTLEnv: " 24h ",
+		IssuerEnv:     "auth.example.com",
+		AudienceEnv:   "web-app",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.AccessTTL != 5*time.Minute || cfg.RefreshTTL != 24*time.Hour || cfg.Issuer != "auth.example.com" || cfg.Audience != "web-app" {
+		t.Errorf("config = %+v", cfg)
+	}
+}
+
+func TestLoadConfigValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want []string
+	}{
+		{"no secret", map[string]string{}, []string{"JWT_SECRET or JWT_SECRET_FILE is required"}},
+		{"short secret", map[string]string{SecretEnv: "your-256-bit-secret"}, []string{"JWT_SECRET must be at least 32 bytes, got 19"}},
+		{"unparseable access TTL", map[string]string{SecretEnv: testSecret, AccessTTLEnv: "15 minutes"}, []string{`ACCESS_TTL: time: unknown unit`}},
+		{"unparseable refresh TTL", map[string]string{SecretEnv: testSecret, RefreshTTLEnv: "7d"}, []string{`REFRESH_TTL: time: unknown unit "d"`}},
+		{"negative TTL", map[string]string{SecretEnv: testSecret, AccessTTLEnv: "-1m"}, []string{"ACCESS_TTL must be positive, got -1m0s"}},
+		{"zero TTL", map[string]string{SecretEnv: testSecret, RefreshTTLEnv: "0s"}, []string{"REFRESH_TTL must be positive, got 0s"}},
+		{"access not shorter than refresh", map[string]string{SecretEnv: testSecret, AccessTTLEnv: "2h", RefreshTTLEnv: "1h"},
+			[]string{"ACCESS_TTL (2h0m0s) must be shorter than REFRESH_TTL (1h0m0s)"}},
+		{"equal TTLs", map[string]string{SecretEnv: testSecret, AccessTTLEnv: "1h", RefreshTTLEnv: "60m"},
+			[]string{"ACCESS_TTL (1h0m0s) must be shorter than REFRESH_TTL (1h0m0s)"}},
+		{"empty issuer", map[string]string{SecretEnv: testSecret, IssuerEnv: " "}, []string{"ISSUER must not be empty"}},
+		{"missing secret file", map[string]string{SecretFileEnv: filepath.Join(t.TempDir(), "nope")}, []string{"JWT_SECRET_FILE: open "}},
+		{"every problem at once", map[string]string{SecretEnv: "short", AccessTTLEnv: "soon", RefreshTTLEnv: "-1h", IssuerEnv: ""}, []string{
+			"JWT_SECRET must be at least 32 bytes, got 5",
+			`ACCESS_TTL: time: invalid duration "soon"`,
+			"REFRESH_TTL must be positive, got -1h0m0s",
+			"ISSUER must not be empty",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := LoadConfig(envLookup(tt.env))
+			if err == nil {
+				t.Fatalf("LoadConfig succeeded: %+v", cfg)
+			}
+			lines := strings.Split(err.Error(), "\n")
+			if lines[0] != "invalid configuration:" || len(lines)-1 != len(tt.want) {
+				t.Fatalf("error:\n%v\nwant %d problem(s)", err, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(lines[i+1], want) {
+					t.Errorf("problem %d = %q, want %q", i+1, lines[i+1], want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadConfigSecretFile(t *testing.T) {
+	dir := t.TempDir()
+	writeSecret := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	fileSecret := "mounted-secret-mounted-secret-0123"
+	path := writeSecret("secret", fileSecret+"\n")
+
+	t.Run("file wins over the plain variable", func(t *testing.T) {
+		cfg, err := LoadConfig(envLookup(map[string]string{SecretEnv: testSecret, SecretFileEnv: path}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(cfg.Secret) != fileSecret {
+			t.Errorf("secret = %q, want the file's %q", cfg.Secret, fileSecret)
+		}
+	})
+
+	t.Run("short file is rejected even with a good plain variable", func(t *testing.T) {
+		short := writeSecret("short", "tiny\n")
+		_, err := LoadConfig(envLookup(map[string]string{SecretEnv: testSecret, SecretFileEnv: short}))
+		if err == nil || !strings.Contains(err.Error(), "JWT_SECRET_FILE must be at least 32 bytes, got 4") {
+			t.Errorf("err = %v", err)
+		}
+	})
+
+	t.Run("empty file variable falls back", func(t *testing.T) {
+		cfg, err := LoadConfig(envLookup(map[string]string{SecretEnv: testSecret, SecretFileEnv: ""}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(cfg.Secret) != testSecret {
+			t.Errorf("secret = %q, want %q", cfg.Secret, testSecret)
+		}
+	})
+}
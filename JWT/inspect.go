@@ -0,0 +1,149 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// SignatureStatus is the outcome of checking a token's signature
+type SignatureStatus string
+
+const (
+	SignatureValid        SignatureStatus = "valid"
+	SignatureInvalid      SignatureStatus = "invalid"
+	SignatureUnverifiable SignatureStatus = "unverifiable-no-key"
+)
+
+// maxTokenLifetime is how far in the future exp may lie before Inspect warns
+const maxTokenLifetime = 30 * 24 * time.Hour
+
+// errNoKey is returned by the Inspect keyfunc when there is no key
+var errNoKey = errors.New("no key to verify the signature")
+
+// TokenReport is what Inspect learned about a token
+type TokenReport struct {
+	Header map[string]interface{}
+	// Claims holds every claim; exp, iat and nbf are RFC3339 strings
+	Claims map[string]interface{}
+	// TTL is the time left until exp, negative once expired and nil
+	// without an exp claim
+	TTL            *time.Duration
+	Signature      SignatureStatus
+	SignatureError error
+	Warnings       []string
+}
+
+// Inspect decodes tokenString, checks its signature with the key from
+// keyResolver and lists anything suspicious about it. Only a token that
+// cannot be decoded at all is an error; expired tokens and bad signatures
+// are reported, not rejected. A nil keyResolver, or one returning a nil key
+// and no error, leaves the signature unverifiable rather than invalid.
+func Inspect(tokenString string, keyResolver jwt.Keyfunc) (*TokenReport, error) {
+	return inspect(tokenString, keyResolver, time.Now())
+}
+
+func inspect(tokenString string, keyResolver jwt.Keyfunc, now time.Time) (*TokenReport, error) {
+	mapClaims := jwt.MapClaims{}
+	token, _, err := jwt.NewParser().ParseUnverified(tokenString, mapClaims)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &TokenReport{
+		Header: token.Header,
+		Claims: make(map[string]interface{}, len(mapClaims)),
+	}
+	for name, value := range mapClaims {
+		report.Claims[name] = value
+	}
+
+	alg := token.Method.Alg()
+	if alg == jwt.SigningMethodNone.Alg() {
+		report.Warnings = append(report.Warnings, "alg is none")
+		report.Signature = SignatureInvalid
+		report.SignatureError = fmt.Errorf("%w: alg none carries no signature", jwt.ErrTokenSignatureInvalid)
+	} else {
+		report.checkSignature(tokenString, keyResolver)
+	}
+
+	for _, name := range []string{"iat", "nbf", "exp"} {
+		if _, ok := mapClaims[name]; !ok {
+			continue
+		}
+		date, err := claimTime(mapClaims, name)
+		if err != nil {
+			report.Warnings = append(report.Warnings, name+" is not a NumericDate")
+			continue
+		}
+		report.Claims[name] = date.UTC().Format(time.RFC3339)
+	}
+
+	exp, err := claimTime(mapClaims, "exp")
+	switch {
+	case err != nil:
+	case exp.IsZero():
+		report.Warnings = append(report.Warnings, "no exp claim")
+	default:
+		ttl := exp.Sub(now.Truncate(time.Second))
+		report.TTL = &ttl
+		if ttl > maxTokenLifetime {
+			report.Warnings = append(report.Warnings, "exp > 30 days")
+		}
+	}
+	return report, nil
+}
+
+// checkSignature verifies the signature only; expired or not yet valid
+// tokens still have a valid signature
+func (r *TokenReport) checkSignature(tokenString string, keyResolver jwt.Keyfunc) {
+	parser := jwt.NewParser(jwt.WithoutClaimsValidation())
+	_, err := parser.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if keyResolver == nil {
+			return nil, errNoKey
+		}
+		key, err := keyResolver(token)
+		if err != nil {
+			return nil, err
+		}
+		if key == nil {
+			return nil, errNoKey
+		}
+		if secret, ok := key.([]byte); ok {
+			if method, ok := token.Method.(*jwt.SigningMethodHMAC); ok && len(secret) < method.Hash.Size() {
+				r.Warnings = append(r.Warnings, fmt.Sprintf("%s secret shorter than %d bytes", method.Alg(), method.Hash.Size()))
+			}
+		}
+		return key, nil
+	})
+
+	switch {
+	case err == nil:
+		r.Signature = SignatureValid
+	case errors.Is(err, errNoKey):
+		r.Signature = SignatureUnverifiable
+	default:
+		r.Signature = SignatureInvalid
+		r.SignatureError = err
+	}
+}
+
+// claimTime reads a NumericDate claim; a missing claim is the zero time
+func claimTime(claims jwt.MapClaims, name string) (time.Time, error) {
+	var date *jwt.NumericDate
+	var err error
+	switch name {
+	case "exp":
+		date, err = claims.GetExpirationTime()
+	case "iat":
+		date, err = claims.GetIssuedAt()
+	case "nbf":
+		date, err = claims.GetNotBefore()
+	}
+	if err != nil || date == nil {
+		return time.Time{}, err
+	}
+	return date.Time, nil
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"jwt-demo/tokenerr"
+)
+
+// JWKSPath is where services conventionally publish their JWK Set
+const JWKSPath = "/.well-known/jwks.json"
+
+// JWK is a single public key in JSON Web Key format (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// EC and OKP
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// NewJWK describes an RSA (RS256), P-256 (ES256) or Ed25519 (EdDSA) public key
+func NewJWK(kid string, pub crypto.PublicKey) (JWK, error) {
+	b64 := base64.RawURLEncoding.EncodeToString
+
+	switch key := pub.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			Kty: "RSA", Kid: kid, Alg: jwt.SigningMethodRS256.Alg(), Use: "sig",
+			N: b64(key.N.Bytes()),
+			E: b64(big.NewInt(int64(key.E)).Bytes()),
+		}, nil
+	case *ecdsa.PublicKey:
+		if key.Curve != elliptic.P256() {
+			return JWK{}, fmt.Errorf("jwk: unsupported curve %s", key.Curve.Params().Name)
+		}
+		return JWK{
+			Kty: "EC", Kid: kid, Alg: jwt.SigningMethodES256.Alg(), Use: "sig",
+			Crv: "P-256",
+			X:   b64(key.X.FillBytes(make([]byte, 32))),
+			Y:   b64(key.Y.FillBytes(make([]byte, 32))),
+		}, nil
+	case ed25519.PublicKey:
+		return JWK{
+			Kty: "OKP", Kid: kid, Alg: jwt.SigningMethodEdDSA.Alg(), Use: "sig",
+			Crv: "Ed25519",
+			X:   b64(key),
+		}, nil
+	default:
+		return JWK{}, fmt.Errorf("jwk: unsupported key type %T", pub)
+	}
+}
+
+// PublicKey decodes the JWK back into a Go public key
+func (k JWK) PublicKey() (crypto.PublicKey, error) {
+	decode := func(field, value string) ([]byte, error) {
+		if value == "" {
+			return nil, fmt.Errorf("jwk %q: missing %q", k.Kid, field)
+		}
+		b, err := base64.RawURLEncoding.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("jwk %q: invalid %q: %w", k.Kid, field, err)
+		}
+		return b, nil
+	}
+
+	switch k.Kty {
+	case "RSA":
+		n, err := decode("n", k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode("e", k.E)
+		if err != nil {
+			return nil, err
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("jwk %q: invalid RSA exponent", k.Kid)
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("jwk %q: unsupported curve %q", k.Kid, k.Crv)
+		}
+		x, err := decode("x", k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode("y", k.Y)
+		if err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			return nil, fmt.Errorf("jwk %q: point is not on P-256", k.Kid)
+		}
+		return key, nil
+
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("jwk %q: unsupported curve %q", k.Kid, k.Crv)
+		}
+		x, err := decode("x", k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("jwk %q: invalid Ed25519 key length %d", k.Kid, len(x))
+		}
+		return ed25519.PublicKey(x), nil
+
+	default:
+		return nil, fmt.Errorf("jwk %q: unsupported key type %q", k.Kid, k.Kty)
+	}
+}
+
+// JWKSHandler serves a JWK Set as JSON
+func JWKSHandler(set JWKS) http.Handler {
+	body, err := json.Marshal(set)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		w.Write(body)
+	})
+}
+
+// jwksKey is a cached public key together with the algorithm it is published for
+type jwksKey struct {
+	alg string
+	key crypto.PublicKey
+}
+
+// JWKSVerifier fetches a JWK Set over HTTP, caches it for TTL and resolves
+// verification keys by the token's "kid" header. A kid missing from the cache
+// triggers one refetch, so keys rotated in since the last fetch are picked up.
+type JWKSVerifier struct {
+	URL    string
+	TTL    time.Duration
+	Client *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]jwksKey
+	fetchedAt time.Time
+	now       func() time.Time
+}
+
+// NewJWKSVerifier creates a verifier for the JWK Set at url
+func NewJWKSVerifier(url string, ttl time.Duration) *JWKSVerifier {
+	return &JWKSVerifier{URL: url, TTL: ttl, Client: http.DefaultClient, now: time.Now}
+}
+
+// ErrUnknownKID is returned when no published key matches the token's kid
+var ErrUnknownKID = errors.New("unknown kid")
+
+// Keyfunc is a jwt.Keyfunc selecting the key by kid and checking that the
+// token's algorithm matches the one the key is published for
+func (v *JWKSVerifier) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, ok := token.Header["kid"].(string)
+	if !ok || kid == "" {
+		return nil, errors.New("token has no kid header")
+	}
+
+	entry, err := v.lookup(kid)
+	if err != nil {
+		return nil, err
+	}
+	if token.Method.Alg() != entry.alg {
+		return nil, fmt.Errorf("%w: unexpected signing method %v (kid %q is for %s)", tokenerr.ErrWrongAlgorithm, token.Header["alg"], kid, entry.alg)
+	}
+	return entry.key, nil
+}
+
+func (v *JWKSVerifier) lookup(kid string) (jwksKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	stale := v.keys == nil || v.now().Sub(v.fetchedAt) > v.TTL
+	if entry, ok := v.keys[kid]; ok && !stale {
+		return entry, nil
+	}
+
+	if err := v.refresh(); err != nil {
+		return jwksKey{}, err
+	}
+	if entry, ok := v.keys[kid]; ok {
+		return entry, nil
+	}
+	return jwksKey{}, fmt.Errorf("%w %q", ErrUnknownKID, kid)
+}
+
+// refresh replaces the cache with the currently published keys; v.mu must be held
+func (v *JWKSVerifier) refresh() error {
+	resp, err := v.Client.Get(v.URL)
+	if err != nil {
+		return fmt.Errorf("fetch jwks: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetch jwks: unexpected status %s", resp.Status)
+	}
+
+	var set JWKS
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return fmt.Errorf("decode jwks: %w", err)
+	}
+
+	keys := make(map[string]jwksKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Kid == "" || jwk.Alg == "" {
+			return errors.New("decode jwks: every key needs a kid and an alg")
+		}
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.PublicKey()
+		if err != nil {
+			return fmt.Errorf("decode jwks: %w", err)
+		}
+		keys[jwk.Kid] = jwksKey{alg: jwk.Alg, key: key}
+	}
+
+	v.keys = keys
+	v.fetchedAt = v.now()
+	return nil
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// jwksServer serves whatever set currently holds and counts fetches.
+type jwksServer struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	body    string
+	fetches int
+}
+
+func newJWKSServer(t *testing.T, keys ...JWK) *jwksServer {
+	t.Helper()
+
+	s := &jwksServer{}
+	s.publish(t, keys...)
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.fetches++
+		w.Write([]byte(s.body))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) publish(t *testing.T, keys ...JWK) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	JWKSHandler(JWKS{Keys: keys}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, JWKSPath, nil))
+	s.setBody(rec.Body.String())
+}
+
+func (s *jwksServer) setBody(body string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.body = body
+}
+
+func (s *jwksServer) fetchCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fetches
+}
+
+func mustJWK(t *testing.T, kid string, pub interface{}) JWK {
+	t.Helper()
+
+	jwk, err := NewJWK(kid, pub)
+	if err != nil {
+		t.Fatalf("NewJWK(%s): %v", kid, err)
+	}
+	return jwk
+}
+
+func signWithKID(t *testing.T, method jwt.SigningMethod, key interface{}, kid string) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, testClaims())
+	token.Header["kid"] = kid
+	tokenString, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return tokenString
+}
+
+func TestJWKRoundTrip(t *testing.T) {
+	for _, pub := range []interface{}{publicKey, ecdsaPublicKey, ed25519PublicKey} {
+		jwk := mustJWK(t, "k", pub)
+		got, err := jwk.PublicKey()
+		if err != nil {
+			t.Fatalf("%T: %v", pub, err)
+		}
+
+		var equal bool
+		switch want := pub.(type) {
+		case *rsa.PublicKey:
+			equal = want.Equal(got)
+		case *ecdsa.PublicKey:
+			equal = want.Equal(got)
+		case ed25519.PublicKey:
+			equal = want.Equal(got)
+		}
+		if !equal {
+			t.Errorf("%s key did not round-trip", jwk.Kty)
+		}
+	}
+}
+
+func TestJWKSVerifierSelectsKeyByKID(t *testing.T) {
+	server := newJWKSServer(t, mustJWK(t, "key-1", publicKey), mustJWK(t, "key-2", ecdsaPublicKey))
+	verifier := NewJWKSVerifier(server.URL, time.Minute)
+
+	for _, tokenString := range []string{
+		signWithKID(t, jwt.SigningMethodRS256, privateKey, "key-1"),
+		signWithKID(t, jwt.SigningMethodES256, ecdsaPrivateKey, "key-2"),
+	} {
+		if _, err := jwt.Parse(tokenString, verifier.Keyfunc); err != nil {
+			t.Errorf("parse: %v", err)
+		}
+	}
+	if got := server.fetchCount(); got != 1 {
+		t.Errorf("fetches = %d, want 1 (cached)", got)
+	}
+
+	// A token claiming key-1 but signed with ES256 must not be accepted
+	_, err := jwt.Parse(signWithKID(t, jwt.SigningMethodES256, ecdsaPrivateKey, "key-1"), verifier.Keyfunc)
+	if err == nil || !strings.Contains(err.Error(), "unexpected signing method") {
+		t.Errorf("err = %v, want an algorithm mismatch", err)
+	}
+
+	_, err = jwt.Parse(signWithKID(t, jwt.SigningMethodRS256, privateKey, ""), verifier.Keyfunc)
+	if err == nil || !strings.Contains(err.Error(), "no kid") {
+		t.Errorf("err = %v, want a missing kid error", err)
+	}
+}
+
+func TestJWKSVerifierRefreshesOnUnknownKID(t *testing.T) {
+	server := newJWKSServer(t, mustJWK(t, "key-1", publicKey))
+	verifier := NewJWKSVerifier(server.URL, time.Hour)
+
+	if _, err := jwt.Parse(signWithKID(t, jwt.SigningMethodRS256, privateKey, "key-1"), verifier.Keyfunc); err != nil {
+		t.Fatalf("parse key-1: %v", err)
+	}
+
+	// key-2 is rotated in after the first fetch; the cache is still fresh
+	server.publish(t, mustJWK(t, "key-1", publicKey), mustJWK(t, "key-2", ecdsaPublicKey))
+	if _, err := jwt.Parse(signWithKID(t, jwt.SigningMethodES256, ecdsaPrivateKey, "key-2"), verifier.Keyfunc); err != nil {
+		t.Fatalf("parse key-2: %v", err)
+	}
+	if got := server.fetchCount(); got != 2 {
+		t.Errorf("fetches = %d, want 2", got)
+	}
+
+	// A kid that is still unknown after the refetch is rejected
+	_, err := jwt.Parse(signWithKID(t, jwt.SigningMethodRS256, privateKey, "key-9"), verifier.Keyfunc)
+	if !errors.Is(err, ErrUnknownKID) {
+		t.Errorf("err = %v, want %v", err, ErrUnknownKID)
+	}
+}
+
+func TestJWKSVerifierRefreshesAfterTTL(t *testing.T) {
+	server := newJWKSServer(t, mustJWK(t, "key-1", publicKey))
+	verifier := NewJWKSVerifier(server.URL, time.Minute)
+
+	now := time.Now()
+	verifier.now = func() time.Time { return now }
+
+	tokenString := signWithKID(t, jwt.SigningMethodRS256, privateKey, "key-1")
+	for _, advance := range []time.Duration{0, 30 * time.Second, 2 * time.Minute} {
+		now = now.Add(advance)
+		if _, err := jwt.Parse(tokenString, verifier.Keyfunc); err != nil {
+			t.Fatalf("parse: %v", err)
+		}
+	}
+	if got := server.fetchCount(); got != 2 {
+		t.Errorf("fetches = %d, want 2 (initial + after TTL)", got)
+	}
+
+	// Removing the key is noticed once the cache expires
+	server.publish(t)
+	now = now.Add(2 * time.Minute)
+	if _, err := jwt.Parse(tokenString, verifier.Keyfunc); !errors.Is(err, ErrUnknownKID) {
+		t.Errorf("err = %v, want %v", err, ErrUnknownKID)
+	}
+}
+
+func TestJWKSVerifierMalformedJWKS(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		detail string
+	}{
+		{"not json", "<html>oops</html>", "decode jwks"},
+		{"missing kid", `{"keys":[{"kty":"RSA","alg":"RS256","n":"AQAB","e":"AQAB"}]}`, "kid and an alg"},
+		{"missing modulus", `{"keys":[{"kty":"RSA","kid":"key-1","alg":"RS256","e":"AQAB"}]}`, `missing "n"`},
+		{"bad base64", `{"keys":[{"kty":"RSA","kid":"key-1","alg":"RS256","n":"!!","e":"AQAB"}]}`, `invalid "n"`},
+		{"point off curve", `{"keys":[{"kty":"EC","kid":"key-1","alg":"ES256","crv":"P-256","x":"AQ","y":"AQ"}]}`, "not on P-256"},
+		{"unsupported kty", `{"keys":[{"kty":"oct","kid":"key-1","alg":"HS256","k":"c2VjcmV0"}]}`, "unsupported key type"},
+	}
+
+	tokenString := signWithKID(t, jwt.SigningMethodRS256, privateKey, "key-1")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newJWKSServer(t)
+			server.setBody(tt.body)
+
+			_, err := jwt.Parse(tokenString, NewJWKSVerifier(server.URL, time.Minute).Keyfunc)
+			if err == nil || !strings.Contains(err.Error(), tt.detail) {
+				t.Errorf("err = %v, want it to mention %q", err, tt.detail)
+			}
+		})
+	}
+
+	t.Run("error status", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		defer server.Close()
+
+		_, err := jwt.Parse(tokenString, NewJWKSVerifier(server.URL, time.Minute).Keyfunc)
+		if err == nil || !strings.Contains(err.Error(), "404") {
+			t.Errorf("err = %v, want a status error", err)
+		}
+	})
+}
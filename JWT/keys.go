@@ -0,0 +1,222 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// Key file names used by LoadOrCreateKeys
+const (
+	rsaKeyFile     = "rsa.pem"
+	ecdsaKeyFile   = "ecdsa.pem"
+	ed25519KeyFile = "ed25519.pem"
+)
+
+// KeyPassphraseEnv names the environment variable holding the passphrase
+// for encrypted key files
+const KeyPassphraseEnv = "JWT_KEY_PASSPHRASE"
+
+// KeySet holds the private keys used by the demos
+type KeySet struct {
+	RSA     *rsa.PrivateKey
+	ECDSA   *ecdsa.PrivateKey
+	Ed25519 ed25519.PrivateKey
+
+	// Warnings lists non-fatal problems such as key files readable by others
+	Warnings []string
+}
+
+// LoadOrCreateKeys reads the RSA, ECDSA (P-256) and Ed25519 private keys
+// from PEM files in dir, generating and writing (mode 0600) any that are
+// missing, so tokens signed in one run verify in the next. PKCS#1
+// ("RSA PRIVATE KEY"), SEC 1 ("EC PRIVATE KEY") and PKCS#8 ("PRIVATE KEY")
+// blocks are accepted. If JWT_KEY_PASSPHRASE is set, encrypted PEM blocks
+// are decrypted with it and new keys are written encrypted.
+func LoadOrCreateKeys(dir string) (*KeySet, error) {
+	return loadOrCreateKeys(dir, []byte(os.Getenv(KeyPassphraseEnv)))
+}
+
+func loadOrCreateKeys(dir string, passphrase []byte) (*KeySet, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create key directory: %w", err)
+	}
+
+	ks := &KeySet{}
+
+	key, err := ks.loadOrCreate(filepath.Join(dir, rsaKeyFile), passphrase, func() (crypto.Signer, error) {
+		return rsa.GenerateKey(rand.Reader, 2048)
+	})
+	if err != nil {
+		return nil, err
+	}
+	var ok bool
+	if ks.RSA, ok = key.(*rsa.PrivateKey); !ok {
+		return nil, fmt.Errorf("%s: expected an RSA key, got %T", filepath.Join(dir, rsaKeyFile), key)
+	}
+
+	key, err = ks.loadOrCreate(filepath.Join(dir, ecdsaKeyFile), passphrase, func() (crypto.Signer, error) {
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if ks.ECDSA, ok = key.(*ecdsa.PrivateKey); !ok || ks.ECDSA.Curve != elliptic.P256() {
+		return nil, fmt.Errorf("%s: expected a P-256 ECDSA key, got %T", filepath.Join(dir, ecdsaKeyFile), key)
+	}
+
+	key, err = ks.loadOrCreate(filepath.Join(dir, ed25519KeyFile), passphrase, func() (crypto.Signer, error) {
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		return priv, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if ks.Ed25519, ok = key.(ed25519.PrivateKey); !ok {
+		return nil, fmt.Errorf("%s: expected an Ed25519 key, got %T", filepath.Join(dir, ed25519KeyFile), key)
+	}
+
+	return ks, nil
+}
+
+// loadOrCreate reads the key at path or, if the file does not exist,
+// generates one and writes it
+func (ks *KeySet) loadOrCreate(path string, passphrase []byte, generate func() (crypto.Signer, error)) (crypto.Signer, error) {
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		key, err := generate()
+		if err != nil {
+			return nil, fmt.Errorf("generate %s: %w", path, err)
+		}
+		if err := writePrivateKeyPEM(path, key, passphrase); err != nil {
+			return nil, err
+		}
+		return key, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0o077 != 0 {
+		ks.Warnings = append(ks.Warnings, fmt.Sprintf(
+			"%s is accessible by group or others (mode %04o); run chmod 600 %s", path, info.Mode().Perm(), path))
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := parsePrivateKeyPEM(data, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return key, nil
+}
+
+// parsePrivateKeyPEM decodes the first PEM block of data as a PKCS#1,
+// SEC 1 or PKCS#8 private key, decrypting legacy encrypted blocks with
+// passphrase
+func parsePrivateKeyPEM(data, passphrase []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	// Legacy PEM encryption (Proc-Type: 4,ENCRYPTED) is deprecated in
+	// crypto/x509 but is still what "openssl rsa -aes256" writes
+	der := block.Bytes
+	if x509.IsEncryptedPEMBlock(block) {
+		if len(passphrase) == 0 {
+			return nil, fmt.Errorf("key is encrypted; set %s", KeyPassphraseEnv)
+		}
+		var err error
+		if der, err = x509.DecryptPEMBlock(block, passphrase); err != nil {
+			return nil, fmt.Errorf("decrypt key: %w", err)
+		}
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(der)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(der)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(der)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported key type %T", key)
+		}
+		return signer, nil
+	case "ENCRYPTED PRIVATE KEY":
+		return nil, errors.New("encrypted PKCS#8 keys are not supported; convert with openssl pkcs8 -traditional")
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+}
+
+// parsePublicKeyPEM decodes a PKIX ("PUBLIC KEY") or PKCS#1
+// ("RSA PUBLIC KEY") public key. Private key files are accepted too, in
+// which case their public half is returned.
+func parsePublicKeyPEM(data, passphrase []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	switch block.Type {
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		key, err := parsePrivateKeyPEM(data, passphrase)
+		if err != nil {
+			return nil, err
+		}
+		return key.Public(), nil
+	}
+}
+
+// writePrivateKeyPEM writes key to path as PKCS#8 with mode 0600, encrypted
+// with AES-256 when passphrase is set
+func writePrivateKeyPEM(path string, key crypto.Signer, passphrase []byte) error {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return fmt.Errorf("marshal %s: %w", path, err)
+	}
+
+	block := &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	if len(passphrase) > 0 {
+		block, err = x509.EncryptPEMBlock(rand.Reader, "PRIVATE KEY", der, passphrase, x509.PEMCipherAES256)
+		if err != nil {
+			return fmt.Errorf("encrypt %s: %w", path, err)
+		}
+	}
+
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o600); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	return nil
+}
+
+// useKeys replaces the demo keys generated in init with ks
+func useKeys(ks *KeySet) {
+	privateKey, publicKey = ks.RSA, &ks.RSA.PublicKey
+	ecdsaPrivateKey, ecdsaPublicKey = ks.ECDSA, &ks.ECDSA.PublicKey
+	ed25519PrivateKey = ks.Ed25519
+	ed25519PublicKey = ks.Ed25519.Public().(ed25519.PublicKey)
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func writePEM(t *testing.T, path string, block *pem.Block, mode os.FileMode) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), mode); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadOrCreateKeysCreates(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "keys")
+
+	ks, err := loadOrCreateKeys(dir, nil)
+	if err != nil {
+		t.Fatalf("loadOrCreateKeys: %v", err)
+	}
+	if len(ks.Warnings) != 0 {
+		t.Errorf("unexpected warnings: %q", ks.Warnings)
+	}
+
+	for _, name := range []string{rsaKeyFile, ecdsaKeyFile, ed25519KeyFile} {
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("%s not written: %v", name, err)
+		}
+		if runtime.GOOS != "windows" && info.Mode().Perm() != 0o600 {
+			t.Errorf("%s mode = %04o, want 0600", name, info.Mode().Perm())
+		}
+	}
+
+	// A second call must load the same keys rather than generate new ones
+	again, err := loadOrCreateKeys(dir, nil)
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if !ks.RSA.Equal(again.RSA) || !ks.ECDSA.Equal(again.ECDSA) || !ks.Ed25519.Equal(again.Ed25519) {
+		t.Error("reloaded keys differ from the generated ones")
+	}
+}
+
+func TestLoadOrCreateKeysLoadsFormats(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecDER, err := x509.MarshalECPrivateKey(ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaPKCS8, err := x509.MarshalPKCS8PrivateKey(rsaKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		block *pem.Block
+	}{
+		{"PKCS#1", &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}},
+		{"PKCS#8", &pem.Block{Type: "PRIVATE KEY", Bytes: rsaPKCS8}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writePEM(t, filepath.Join(dir, rsaKeyFile), tt.block, 0o600)
+			writePEM(t, filepath.Join(dir, ecdsaKeyFile), &pem.Block{Type: "EC PRIVATE KEY", Bytes: ecDER}, 0o600)
+
+			ks, err := loadOrCreateKeys(dir, nil)
+			if err != nil {
+				t.Fatalf("loadOrCreateKeys: %v", err)
+			}
+			if !ks.RSA.Equal(rsaKey) {
+				t.Error("RSA key was not loaded from disk")
+			}
+			if !ks.ECDSA.Equal(ecKey) {
+				t.Error("EC key was not loaded from disk")
+			}
+		})
+	}
+}
+
+func TestLoadOrCreateKeysPassphrase(t *testing.T) {
+	dir := t.TempDir()
+	passphrase := []byte("correct horse")
+
+	ks, err := loadOrCreateKeys(dir, passphrase)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, rsaKeyFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "ENCRYPTED") {
+		t.Errorf("key written without encryption:\n%s", data)
+	}
+
+	again, err := loadOrCreateKeys(dir, passphrase)
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if !ks.RSA.Equal(again.RSA) {
+		t.Error("encrypted RSA key did not round-trip")
+	}
+
+	if _, err := loadOrCreateKeys(dir, nil); err == nil || !strings.Contains(err.Error(), KeyPassphraseEnv) {
+		t.Errorf("missing passphrase: err = %v", err)
+	}
+	if _, err := loadOrCreateKeys(dir, []byte("wrong")); err == nil || !strings.Contains(err.Error(), "decrypt key") {
+		t.Errorf("wrong passphrase: err = %v", err)
+	}
+}
+
+func TestLoadOrCreateKeysWarnsOnPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are not enforced on Windows")
+	}
+
+	dir := t.TempDir()
+	if _, err := loadOrCreateKeys(dir, nil); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, ecdsaKeyFile)
+	if err := os.Chmod(path, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ks, err := loadOrCreateKeys(dir, nil)
+	if err != nil {
+		t.Fatalf("loadOrCreateKeys: %v", err)
+	}
+	if len(ks.Warnings) != 1 {
+		t.Fatalf("warnings = %q, want one", ks.Warnings)
+	}
+	if !strings.Contains(ks.Warnings[0], path) || !strings.Contains(ks.Warnings[0], "0644") {
+		t.Errorf("warning %q does not name the file and mode", ks.Warnings[0])
+	}
+}
+
+func TestLoadOrCreateKeysCorruptFiles(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecDER, err := x509.MarshalECPrivateKey(ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		content []byte
+		want    string
+	}{
+		{"not PEM", []byte("hello"), "no PEM block found"},
+		{"garbage DER", pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: []byte{1, 2, 3}}), "asn1"},
+		{"unknown block", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte{1}}), `unsupported PEM block "CERTIFICATE"`},
+		{"wrong key type", pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: ecDER}), "expected an RSA key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, rsaKeyFile)
+			if err := os.WriteFile(path, tt.content, 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := loadOrCreateKeys(dir, nil)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(err.Error(), path) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %q, want file path and %q", err, tt.want)
+			}
+		})
+	}
+}
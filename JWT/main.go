@@ -1,32 +1,46 @@
 package main
 
 import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
 	"fmt"
 	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"jwt-demo/claims"
+	"jwt-demo/tokenerr"
 )
 
-// Custom claims struct
-type CustomClaims struct {
-	UserID   int    `json:"user_id"`
-	Username string `json:"username"`
-	Role     string `json:"role"`
-	jwt.RegisteredClaims
-}
-
 var (
 	// HMAC Secret key (in production, use environment variable)
 	hmacSecret = []byte("your-256-bit-secret")
 
+	// Revoked token IDs shared by the demos
+	revocationStore = NewMemoryRevocationStore()
+
+	// Refresh token families for rotation with reuse detection
+	refreshFamilies = NewRefreshFamilyStore()
+
 	// RSA keys for RSA256 signing
 	privateKey *rsa.PrivateKey
 	publicKey  *rsa.PublicKey
+
+	// P-256 keys for ES256 signing
+	ecdsaPrivateKey *ecdsa.PrivateKey
+	ecdsaPublicKey  *ecdsa.PublicKey
+
+	// Ed25519 keys for EdDSA signing
+	ed25519PrivateKey ed25519.PrivateKey
+	ed25519PublicKey  ed25519.PublicKey
 )
 
 func init() {
@@ -37,11 +51,48 @@ func init() {
 		log.Fatal("Failed to generate RSA key:", err)
 	}
 	publicKey = &privateKey.PublicKey
+
+	ecdsaPrivateKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		log.Fatal("Failed to generate ECDSA key:", err)
+	}
+	ecdsaPublicKey = &ecdsaPrivateKey.PublicKey
+
+	ed25519PublicKey, ed25519PrivateKey, err = ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		log.Fatal("Failed to generate Ed25519 key:", err)
+	}
 }
 
 func main() {
+	if err := newRootCmd().Execute(); err != nil {
+		os.Exit(exitCode(err))
+	}
+}
+
+// runDemos runs every demo in order, or the auth server configured by cfg
+// when serveAddr is set
+func runDemos(serveAddr, keyDir string, cfg *Config) error {
+	if keyDir != "" {
+		keys, err := LoadOrCreateKeys(keyDir)
+		if err != nil {
+			return fmt.Errorf("load keys: %w", err)
+		}
+		for _, warning := range keys.Warnings {
+			log.Printf("⚠️ %s", warning)
+		}
+		useKeys(keys)
+	}
+
+	if serveAddr != "" {
+		fmt.Printf("🔐 JWT auth server listening on %s\n", serveAddr)
+		fmt.Println("POST /login, POST /refresh, GET /me, GET /admin")
+		return http.ListenAndServe(serveAddr, NewAuthServer(cfg).Handler())
+	}
+
 	fmt.Println("🔐 JWT (JSON Web Token) Demo")
-	fmt.Println("============================\n")
+	fmt.Println("============================")
+	fmt.Println()
 
 	// Demo 1: Basic HMAC Token
 	fmt.Println("1. Basic HMAC Token Creation and Validation")
@@ -59,9 +110,9 @@ func main() {
 	rsaSigningDemo()
 
 	// Demo 4: Token Expiration
-	fmt.Println("\n4. Token Expiration Demo")
-	fmt.Println("------------------------")
-	expirationDemo()
+	fmt.Println("\n4. Token Expiration and Not-Before Demo")
+	fmt.Println("---------------------------------------")
+	expirationDemo(RealClock{})
 
 	// Demo 5: Invalid Token Handling
 	fmt.Println("\n5. Invalid Token Handling")
@@ -71,7 +122,43 @@ func main() {
 	// Demo 6: Refresh Token Pattern
 	fmt.Println("\n6. Refresh Token Pattern")
 	fmt.Println("------------------------")
-	refreshTokenDemo()
+	refreshTokenDemo(RealClock{})
+
+	// Demo 7: ECDSA Signing
+	fmt.Println("\n7. ES256 (ECDSA P-256) Signing Example")
+	fmt.Println("---------------------------------------")
+	ecdsaSigningDemo()
+
+	// Demo 8: Ed25519 Signing
+	fmt.Println("\n8. EdDSA (Ed25519) Signing Example")
+	fmt.Println("----------------------------------")
+	eddsaSigningDemo()
+
+	// Demo 9: JWKS Endpoint
+	fmt.Println("\n9. JWKS Publication and Verification")
+	fmt.Println("------------------------------------")
+	jwksDemo()
+
+	// Demo 10: Parser Options
+	fmt.Println("\n10. Audience, Issuer, Leeway and Algorithm Enforcement")
+	fmt.Println("------------------------------------------------------")
+	parserOptionsDemo()
+
+	// Demo 11: RSA-PSS Signing
+	fmt.Println("\n11. PS256 (RSA-PSS) Signing and Algorithm Confusion")
+	fmt.Println("---------------------------------------------------")
+	pssSigningDemo()
+
+	// Demo 12: Nested and Custom Claim Types
+	fmt.Println("\n12. Nested Claims and Typed Accessors")
+	fmt.Println("-------------------------------------")
+	nestedClaimsDemo()
+
+	// Demo 13: Throughput
+	fmt.Println("\n13. Signing and Verification Throughput")
+	fmt.Println("---------------------------------------")
+	throughputDemo(200)
+	return nil
 }
 
 // Demo 1: Basic HMAC token creation and validation
@@ -93,47 +180,42 @@ func basicHMACDemo() {
 	fmt.Printf("Generated Token: %s\n", tokenString)
 
 	// Parse and validate the token
-	parsedToken, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		// Verify signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return hmacSecret, nil
-	})
+	parsedToken, err := jwt.Parse(tokenString, hmacKeyfunc)
 
 	if err != nil {
-		log.Printf("Error parsing token: %v", err)
+		fmt.Printf("❌ Token rejected: %v\n", tokenerr.Wrap(err))
 		return
 	}
 
-	if claims, ok := parsedToken.Claims.(jwt.MapClaims); ok && parsedToken.Valid {
+	if mapClaims, ok := parsedToken.Claims.(jwt.MapClaims); ok && parsedToken.Valid {
 		fmt.Printf("✅ Token is valid!\n")
-		fmt.Printf("Subject: %s\n", claims["sub"])
-		fmt.Printf("Name: %s\n", claims["name"])
-		fmt.Printf("Issued At: %v\n", time.Unix(int64(claims["iat"].(float64)), 0))
-		fmt.Printf("Expires At: %v\n", time.Unix(int64(claims["exp"].(float64)), 0))
+		subject, err := claims.GetString(mapClaims, "sub")
+		fmt.Printf("Subject: %s%s\n", subject, claimErr(err))
+		name, err := claims.GetString(mapClaims, "name")
+		fmt.Printf("Name: %s%s\n", name, claimErr(err))
+		issuedAt, err := claims.GetTime(mapClaims, "iat")
+		fmt.Printf("Issued At: %v%s\n", issuedAt, claimErr(err))
+		expiresAt, err := claims.GetTime(mapClaims, "exp")
+		fmt.Printf("Expires At: %v%s\n", expiresAt, claimErr(err))
+	}
+}
+
+// claimErr formats an accessor error for the demo output
+func claimErr(err error) string {
+	if err != nil {
+		return fmt.Sprintf(" (❌ %v)", err)
 	}
+	return ""
 }
 
 // Demo 2: Custom claims with structured data
 func customClaimsDemo() {
-	// Create custom claims
-	claims := CustomClaims{
-		UserID:   123,
-		Username: "john_doe",
-		Role:     "admin",
-		RegisteredClaims: jwt.RegisteredClaims{
-			Issuer:    "jwt-demo-app",
-			Subject:   "user-auth",
-			Audience:  []string{"web-app", "mobile-app"},
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * 2)),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-		},
-	}
+	// Create custom claims; the builder fills in iss, sub, jti, iat, nbf and exp
+	userClaims := claims.NewAccessClaims(123, "john_doe", "admin", time.Hour*2,
+		claims.WithAudience("web-app", "mobile-app"))
 
 	// Create token with custom claims
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, userClaims)
 	tokenString, err := token.SignedString(hmacSecret)
 	if err != nil {
 		log.Fatal("Error signing token:", err)
@@ -142,22 +224,32 @@ func customClaimsDemo() {
 	fmt.Printf("Generated Token with Custom Claims: %s\n", tokenString)
 
 	// Parse with custom claims
-	parsedToken, err := jwt.ParseWithClaims(tokenString, &CustomClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return hmacSecret, nil
-	})
-
+	parsedToken, err := jwt.ParseWithClaims(tokenString, &claims.Claims{}, hmacKeyfunc)
 	if err != nil {
-		log.Printf("Error parsing token: %v", err)
+		fmt.Printf("❌ Token rejected: %v\n", tokenerr.Wrap(err))
 		return
 	}
 
-	if claims, ok := parsedToken.Claims.(*CustomClaims); ok && parsedToken.Valid {
-		fmt.Printf("✅ Custom claims token is valid!\n")
-		fmt.Printf("User ID: %d\n", claims.UserID)
-		fmt.Printf("Username: %s\n", claims.Username)
-		fmt.Printf("Role: %s\n", claims.Role)
-		fmt.Printf("Issuer: %s\n", claims.Issuer)
-		fmt.Printf("Expires: %v\n", claims.ExpiresAt.Time)
+	parsed, err := claims.FromToken(parsedToken)
+	if err != nil {
+		fmt.Printf("❌ Claims rejected: %v\n", err)
+		return
+	}
+	fmt.Printf("✅ Custom claims token is valid!\n")
+	fmt.Printf("User ID: %d\n", parsed.UserID)
+	fmt.Printf("Username: %s\n", parsed.Username)
+	fmt.Printf("Role: %s\n", parsed.Role)
+	fmt.Printf("Issuer: %s\n", parsed.Issuer)
+	fmt.Printf("Expires: %v\n", parsed.ExpiresAt.Time)
+
+	// Validate runs during parsing, so a token with an unknown role is rejected
+	bad := claims.NewAccessClaims(123, "john_doe", "superuser", time.Hour)
+	badString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, bad).SignedString(hmacSecret)
+	if err != nil {
+		log.Fatal("Error signing token:", err)
+	}
+	if _, err := jwt.ParseWithClaims(badString, &claims.Claims{}, hmacKeyfunc); err != nil {
+		fmt.Printf("❌ Token with role %q rejected: %v\n", bad.Role, tokenerr.Wrap(err))
 	}
 }
 
@@ -180,17 +272,12 @@ func rsaSigningDemo() {
 
 	fmt.Printf("RSA256 Signed Token: %s\n", tokenString)
 
-	// Validate with RSA public key
-	parsedToken, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		// Verify signing method is RSA
-		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return publicKey, nil
-	})
+	// Validate with RSA public key; only RS256 is accepted, not RS384/RS512
+	// or PS256, which share the key type
+	parsedToken, err := jwt.Parse(tokenString, rsaKeyfunc)
 
 	if err != nil {
-		log.Printf("Error parsing RSA token: %v", err)
+		fmt.Printf("❌ RSA token rejected: %v\n", tokenerr.Wrap(err))
 		return
 	}
 
@@ -202,50 +289,61 @@ func rsaSigningDemo() {
 	}
 
 	// Display public key for verification (in production, this would be shared)
-	publicKeyPEM := exportRSAPublicKeyAsPEMStr(publicKey)
+	publicKeyPEM := exportPublicKeyAsPEMStr(publicKey)
 	fmt.Printf("Public Key (PEM):\n%s\n", publicKeyPEM)
 }
 
-// Demo 4: Token expiration handling
-func expirationDemo() {
+// Demo 4: Token expiration and not-before handling. Instead of sleeping,
+// the token is checked as seen by a clock moved forward from clock.Now().
+func expirationDemo(clock Clock) {
 	// Create a token that expires in 1 second
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub": "1234567890",
-		"exp": time.Now().Add(time.Second * 1).Unix(),
-		"iat": time.Now().Unix(),
-	})
-
-	tokenString, err := token.SignedString(hmacSecret)
+	tokenString, err := signTimedToken(clock, 0, time.Second)
 	if err != nil {
 		log.Fatal("Error signing token:", err)
 	}
 
 	fmt.Printf("Token expires in 1 second...\n")
+	view := NewFakeClock(clock.Now())
 
 	// Validate immediately (should be valid)
-	parsedToken, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return hmacSecret, nil
-	})
-
-	if err != nil {
+	if err := verifyTimedToken(tokenString, view, 0); err != nil {
 		fmt.Printf("❌ Immediate validation failed: %v\n", err)
-	} else if parsedToken.Valid {
+	} else {
 		fmt.Printf("✅ Token is currently valid\n")
 	}
 
-	// Wait for expiration
-	fmt.Println("Waiting 2 seconds for token to expire...")
-	time.Sleep(2 * time.Second)
+	// Validate 2 seconds later (should fail)
+	fmt.Println("Moving the clock forward 2 seconds...")
+	view.Advance(2 * time.Second)
+	if err := verifyTimedToken(tokenString, view, 0); err != nil {
+		fmt.Printf("❌ Expected expiration error: %v\n", err)
+	} else {
+		fmt.Printf("Unexpected: Token should have expired\n")
+	}
 
-	// Validate after expiration (should fail)
-	parsedToken, err = jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return hmacSecret, nil
-	})
+	// A little leeway absorbs clock skew between issuer and verifier
+	if err := verifyTimedToken(tokenString, view, 5*time.Second); err == nil {
+		fmt.Printf("✅ Still accepted with 5s leeway\n")
+	}
 
+	// Create a token that only becomes valid in 1 minute
+	fmt.Println("\nToken not valid before 1 minute from now...")
+	nbfTokenString, err := signTimedToken(clock, time.Minute, time.Hour)
 	if err != nil {
-		fmt.Printf("❌ Expected expiration error: %v\n", err)
+		log.Fatal("Error signing token:", err)
+	}
+
+	view.Set(clock.Now())
+	if err := verifyTimedToken(nbfTokenString, view, 0); err != nil {
+		fmt.Printf("❌ Expected not-valid-yet error: %v\n", err)
+	}
+
+	fmt.Println("Moving the clock forward 1 minute...")
+	view.Advance(time.Minute)
+	if err := verifyTimedToken(nbfTokenString, view, 0); err != nil {
+		fmt.Printf("❌ Unexpected error: %v\n", err)
 	} else {
-		fmt.Printf("Unexpected: Token should have expired\n")
+		fmt.Printf("✅ Token is now valid\n")
 	}
 }
 
@@ -259,35 +357,37 @@ func invalidTokenDemo() {
 		name  string
 		token string
 	}{
-		{"Valid Token Format (but wrong secret)", validToken},
+		{"jwt.io Example Token", validToken},
 		{"Invalid Token Format", invalidToken},
 		{"Tampered Signature", tamperedToken},
 	}
 
 	for _, tc := range testCases {
 		fmt.Printf("Testing: %s\n", tc.name)
-		_, err := jwt.Parse(tc.token, func(token *jwt.Token) (interface{}, error) {
-			return hmacSecret, nil
-		})
-
+		report, err := Inspect(tc.token, hmacKeyfunc)
 		if err != nil {
-			fmt.Printf("❌ %s: %v\n", tc.name, err)
+			fmt.Printf("❌ %s: %v\n", tc.name, tokenerr.Wrap(err))
+			continue
+		}
+
+		if report.Signature == SignatureValid {
+			fmt.Printf("✅ Signature: %s\n", report.Signature)
 		} else {
-			fmt.Printf("✅ %s: Token is valid (unexpected)\n", tc.name)
+			fmt.Printf("❌ Signature: %s (%v)\n", report.Signature, report.SignatureError)
+		}
+		fmt.Printf("   Header: %v\n", report.Header)
+		fmt.Printf("   Claims: %v\n", report.Claims)
+		for _, warning := range report.Warnings {
+			fmt.Printf("   ⚠️  %s\n", warning)
 		}
 	}
 }
 
 // Demo 6: Refresh token pattern
-func refreshTokenDemo() {
+func refreshTokenDemo(clock Clock) {
 	// Create access token (short lived)
-	accessClaims := jwt.MapClaims{
-		"sub":  "1234567890",
-		"name": "John Doe",
-		"type": "access",
-		"iat":  time.Now().Unix(),
-		"exp":  time.Now().Add(time.Minute * 15).Unix(), // 15 minutes
-	}
+	accessClaims := claims.NewAccessClaims(123, "john_doe", "admin", time.Minute*15, // 15 minutes
+		claims.WithIssuedAt(clock.Now()))
 
 	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
 	accessTokenString, err := accessToken.SignedString(hmacSecret)
@@ -295,16 +395,8 @@ func refreshTokenDemo() {
 		log.Fatal("Error creating access token:", err)
 	}
 
-	// Create refresh token (long lived)
-	refreshClaims := jwt.MapClaims{
-		"sub":  "1234567890",
-		"type": "refresh",
-		"iat":  time.Now().Unix(),
-		"exp":  time.Now().Add(time.Hour * 24 * 7).Unix(), // 7 days
-	}
-
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
-	refreshTokenString, err := refreshToken.SignedString(hmacSecret)
+	// Create refresh token (long lived); logging in starts a new family
+	refreshTokenString, err := startRefreshFamily(123, "john_doe", "admin")
 	if err != nil {
 		log.Fatal("Error creating refresh token:", err)
 	}
@@ -312,64 +404,494 @@ func refreshTokenDemo() {
 	fmt.Printf("Access Token (15 min): %s\n", accessTokenString)
 	fmt.Printf("Refresh Token (7 days): %s\n", refreshTokenString)
 
-	// Simulate token refresh
+	slidingSessionDemo(clock, accessTokenString)
+
+	// Every refresh returns a new refresh token and consumes the old one
 	fmt.Println("\nSimulating token refresh...")
-	newAccessToken := refreshAccessToken(refreshTokenString)
+	newAccessToken, secondRefreshToken := refreshAccessToken(refreshTokenString)
 	if newAccessToken != "" {
 		fmt.Printf("✅ New Access Token: %s\n", newAccessToken)
+		fmt.Printf("✅ New Refresh Token: %s\n", secondRefreshToken)
 	}
+
+	fmt.Println("\nRefreshing again with the new refresh token...")
+	_, thirdRefreshToken := refreshAccessToken(secondRefreshToken)
+	if thirdRefreshToken != "" {
+		fmt.Println("✅ Rotated: the second refresh token is now consumed")
+	}
+
+	// An attacker who stole the first refresh token replays it; the family
+	// is revoked because a consumed token came back
+	fmt.Println("\nAttacker replays the first (consumed) refresh token...")
+	refreshAccessToken(refreshTokenString)
+
+	// The legitimate client's current refresh token is now rejected as well,
+	// forcing a fresh login
+	fmt.Println("\nLegitimate client uses its current refresh token...")
+	refreshAccessToken(thirdRefreshToken)
+
+	// Access tokens can be revoked before they expire too (e.g. on logout)
+	fmt.Println("\nRevoking the first new access token...")
+	if err := Revoke(revocationStore, newAccessToken, hmacKeyfunc); err != nil {
+		fmt.Printf("❌ Revoke failed: %v\n", tokenerr.Wrap(err))
+		return
+	}
+	token, err := jwt.Parse(newAccessToken, hmacKeyfunc)
+	if err == nil {
+		err = checkNotRevoked(revocationStore, token.Claims)
+	}
+	if err != nil {
+		fmt.Printf("❌ Revoked access token rejected: %v\n", tokenerr.Wrap(err))
+	}
+	fmt.Printf("Revoked token IDs held until expiry: %d\n", revocationStore.Len())
 }
 
-// Helper function to refresh access token
-func refreshAccessToken(refreshTokenString string) string {
-	// Parse refresh token
-	token, err := jwt.Parse(refreshTokenString, func(token *jwt.Token) (interface{}, error) {
-		return hmacSecret, nil
+// slidingSessionDemo checks an access token's remaining lifetime as time
+// passes and replaces it before it expires, as RequireAuth does
+func slidingSessionDemo(clock Clock, accessTokenString string) {
+	const threshold = 5 * time.Minute
+	fmt.Printf("\nSliding session: refresh when %s or less remain...\n", threshold)
+
+	token, err := jwt.ParseWithClaims(accessTokenString, &claims.Claims{}, hmacKeyfunc, jwt.WithTimeFunc(clock.Now))
+	if err != nil {
+		fmt.Printf("❌ Access token rejected: %v\n", tokenerr.Wrap(err))
+		return
+	}
+
+	// Watch the token from the second it was issued
+	c := token.Claims.(*claims.Claims)
+	view := NewFakeClock(c.IssuedAt.Time)
+	for _, elapsed := range []time.Duration{9 * time.Minute, 12 * time.Minute, 16 * time.Minute} {
+		view.Set(c.IssuedAt.Add(elapsed))
+		remaining, err := claims.TimeToExpiry(token, view.Now())
+		switch {
+		case err != nil:
+			fmt.Printf("❌ After %s: %v, use the refresh token\n", elapsed, tokenerr.Wrap(err))
+		case claims.ShouldRefresh(token, threshold, view.Now()):
+			refreshed := claims.NewAccessClaims(c.UserID, c.Username, c.Role, time.Minute*15,
+				claims.WithIssuedAt(view.Now()), claims.WithFamily(c.Family))
+			fmt.Printf("🔄 After %s: %s left, issuing a new access token valid until %s\n",
+				elapsed, remaining, refreshed.ExpiresAt.Time.Format(time.Kitchen))
+		default:
+			fmt.Printf("✅ After %s: %s left, no refresh needed\n", elapsed, remaining)
+		}
+	}
+}
+
+// Demo 7: ES256 signing with a P-256 key
+func ecdsaSigningDemo() {
+	// Create token with ES256 signing
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"sub":  "1234567890",
+		"name": "Jane Doe",
+		"role": "user",
+		"iat":  time.Now().Unix(),
+		"exp":  time.Now().Add(time.Hour).Unix(),
 	})
 
+	// Sign with ECDSA private key
+	tokenString, err := token.SignedString(ecdsaPrivateKey)
 	if err != nil {
-		fmt.Printf("❌ Invalid refresh token: %v\n", err)
-		return ""
+		log.Fatal("Error signing ES256 token:", err)
+	}
+
+	fmt.Printf("ES256 Signed Token: %s\n", tokenString)
+
+	// Validate with ECDSA public key
+	parsedToken, err := jwt.Parse(tokenString, ecdsaKeyfunc)
+	if err != nil {
+		fmt.Printf("❌ ES256 token rejected: %v\n", tokenerr.Wrap(err))
+		return
+	}
+
+	if claims, ok := parsedToken.Claims.(jwt.MapClaims); ok && parsedToken.Valid {
+		fmt.Printf("✅ ES256 token is valid!\n")
+		fmt.Printf("Subject: %s\n", claims["sub"])
+		fmt.Printf("Name: %s\n", claims["name"])
+	}
+
+	// An RS256 token must not be accepted by the ES256 verifier
+	rsaToken, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"sub": "1234567890",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString(privateKey)
+	if err != nil {
+		log.Fatal("Error signing RSA token:", err)
+	}
+
+	if _, err := jwt.Parse(rsaToken, ecdsaKeyfunc); err != nil {
+		fmt.Printf("❌ RS256 token rejected by ES256 verifier: %v\n", tokenerr.Wrap(err))
+	} else {
+		fmt.Printf("Unexpected: RS256 token accepted by ES256 verifier\n")
+	}
+
+	fmt.Printf("Public Key (PEM):\n%s\n", exportPublicKeyAsPEMStr(ecdsaPublicKey))
+}
+
+// Demo 8: EdDSA signing with an Ed25519 key
+func eddsaSigningDemo() {
+	// Create token with EdDSA signing
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, jwt.MapClaims{
+		"sub":  "1234567890",
+		"name": "Jane Doe",
+		"role": "user",
+		"iat":  time.Now().Unix(),
+		"exp":  time.Now().Add(time.Hour).Unix(),
+	})
+
+	// Sign with Ed25519 private key
+	tokenString, err := token.SignedString(ed25519PrivateKey)
+	if err != nil {
+		log.Fatal("Error signing EdDSA token:", err)
+	}
+
+	fmt.Printf("EdDSA Signed Token: %s\n", tokenString)
+
+	// Validate with Ed25519 public key
+	parsedToken, err := jwt.Parse(tokenString, ed25519Keyfunc)
+	if err != nil {
+		fmt.Printf("❌ EdDSA token rejected: %v\n", tokenerr.Wrap(err))
+		return
+	}
+
+	if claims, ok := parsedToken.Claims.(jwt.MapClaims); ok && parsedToken.Valid {
+		fmt.Printf("✅ EdDSA token is valid!\n")
+		fmt.Printf("Subject: %s\n", claims["sub"])
+		fmt.Printf("Name: %s\n", claims["name"])
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		// Verify it's a refresh token
-		if tokenType, exists := claims["type"]; !exists || tokenType != "refresh" {
-			fmt.Printf("❌ Not a refresh token\n")
-			return ""
+	fmt.Printf("Public Key (PEM):\n%s\n", exportPublicKeyAsPEMStr(ed25519PublicKey))
+}
+
+// Demo 9: publish public keys as a JWK Set and verify tokens by kid
+func jwksDemo() {
+	rsaJWK, err := NewJWK("key-1", publicKey)
+	if err != nil {
+		log.Fatal("Error creating RSA JWK:", err)
+	}
+	ecJWK, err := NewJWK("key-2", ecdsaPublicKey)
+	if err != nil {
+		log.Fatal("Error creating ECDSA JWK:", err)
+	}
+
+	// Serve the JWK Set the way an auth service would
+	mux := http.NewServeMux()
+	mux.Handle(JWKSPath, JWKSHandler(JWKS{Keys: []JWK{rsaJWK, ecJWK}}))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	fmt.Printf("JWKS served at %s%s\n", server.URL, JWKSPath)
+
+	verifier := NewJWKSVerifier(server.URL+JWKSPath, 5*time.Minute)
+
+	testCases := []struct {
+		name   string
+		method jwt.SigningMethod
+		key    interface{}
+		kid    string
+	}{
+		{"RS256 token with kid key-1", jwt.SigningMethodRS256, privateKey, "key-1"},
+		{"ES256 token with kid key-2", jwt.SigningMethodES256, ecdsaPrivateKey, "key-2"},
+		{"RS256 token with unknown kid key-9", jwt.SigningMethodRS256, privateKey, "key-9"},
+	}
+
+	for _, tc := range testCases {
+		token := jwt.NewWithClaims(tc.method, jwt.MapClaims{
+			"sub": "1234567890",
+			"exp": time.Now().Add(time.Hour).Unix(),
+		})
+		token.Header["kid"] = tc.kid
+
+		tokenString, err := token.SignedString(tc.key)
+		if err != nil {
+			log.Fatal("Error signing token:", err)
 		}
 
-		// Create new access token
-		newClaims := jwt.MapClaims{
-			"sub":  claims["sub"],
-			"name": "John Doe", // In practice, fetch from database
-			"type": "access",
-			"iat":  time.Now().Unix(),
-			"exp":  time.Now().Add(time.Minute * 15).Unix(),
+		if _, err := jwt.Parse(tokenString, verifier.Keyfunc); err != nil {
+			fmt.Printf("❌ %s: %v\n", tc.name, tokenerr.Wrap(err))
+		} else {
+			fmt.Printf("✅ %s: verified via JWKS\n", tc.name)
 		}
+	}
+}
 
-		newToken := jwt.NewWithClaims(jwt.SigningMethodHS256, newClaims)
-		tokenString, err := newToken.SignedString(hmacSecret)
+// Demo 10: enforce iss, aud, clock-skew leeway and allowed algorithms
+func parserOptionsDemo() {
+	strict, err := NewVerifier(VerifierOptions{
+		Issuer:       "jwt-demo-app",
+		Audience:     "jwt-demo-api",
+		ValidMethods: []string{jwt.SigningMethodHS256.Alg()},
+		Keyfunc:      hmacKeyfunc,
+	})
+	if err != nil {
+		log.Fatal("Error creating verifier:", err)
+	}
+	lenient, err := NewVerifier(VerifierOptions{
+		Issuer:       "jwt-demo-app",
+		Audience:     "jwt-demo-api",
+		ValidMethods: []string{jwt.SigningMethodHS256.Alg()},
+		Leeway:       time.Minute,
+		Keyfunc:      hmacKeyfunc,
+	})
+	if err != nil {
+		log.Fatal("Error creating verifier:", err)
+	}
+
+	now := time.Now()
+	claims := func(issuer, audience string, notBefore time.Time) jwt.RegisteredClaims {
+		return jwt.RegisteredClaims{
+			Subject:   "1234567890",
+			Issuer:    issuer,
+			Audience:  jwt.ClaimStrings{audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(notBefore),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		}
+	}
+	sign := func(method jwt.SigningMethod, key interface{}, c jwt.RegisteredClaims) string {
+		tokenString, err := jwt.NewWithClaims(method, c).SignedString(key)
 		if err != nil {
-			fmt.Printf("❌ Error creating new access token: %v\n", err)
-			return ""
+			log.Fatal("Error signing token:", err)
 		}
+		return tokenString
+	}
+
+	early := claims("jwt-demo-app", "jwt-demo-api", now.Add(30*time.Second))
+	early.IssuedAt = early.NotBefore // issued by a server whose clock runs 30s fast
 
+	testCases := []struct {
+		name     string
+		verifier *Verifier
+		token    string
+	}{
+		{"Valid token", strict, sign(jwt.SigningMethodHS256, hmacSecret, claims("jwt-demo-app", "jwt-demo-api", now))},
+		{"Wrong audience", strict, sign(jwt.SigningMethodHS256, hmacSecret, claims("jwt-demo-app", "billing-api", now))},
+		{"Wrong issuer", strict, sign(jwt.SigningMethodHS256, hmacSecret, claims("evil-issuer", "jwt-demo-api", now))},
+		{`alg "none"`, strict, sign(jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, claims("jwt-demo-app", "jwt-demo-api", now))},
+		{"Token 30s early, no leeway", strict, sign(jwt.SigningMethodHS256, hmacSecret, early)},
+		{"Token 30s early, 1m leeway", lenient, sign(jwt.SigningMethodHS256, hmacSecret, early)},
+	}
+
+	for _, tc := range testCases {
+		if _, err := tc.verifier.Verify(tc.token, &jwt.RegisteredClaims{}); err != nil {
+			fmt.Printf("❌ %s: %v\n", tc.name, err)
+		} else {
+			fmt.Printf("✅ %s: accepted\n", tc.name)
+		}
+	}
+}
+
+// Demo 11: PS256 with the RSA key, and why keyfuncs must check the exact alg
+func pssSigningDemo() {
+	sign := func(method jwt.SigningMethod, key interface{}) string {
+		tokenString, err := jwt.NewWithClaims(method, jwt.MapClaims{
+			"sub": "1234567890",
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}).SignedString(key)
+		if err != nil {
+			log.Fatal("Error signing token:", err)
+		}
 		return tokenString
 	}
 
-	return ""
+	pssToken := sign(jwt.SigningMethodPS256, privateKey)
+	fmt.Printf("PS256 Signed Token: %s\n", pssToken)
+
+	// The classic confusion attack: sign HS256 with the public key as the
+	// HMAC secret and hope the verifier hands the same key to HMAC
+	publicKeyPEM := []byte(exportPublicKeyAsPEMStr(publicKey))
+	confusedToken := sign(jwt.SigningMethodHS256, publicKeyPEM)
+
+	testCases := []struct {
+		name    string
+		token   string
+		keyfunc jwt.Keyfunc
+	}{
+		{"PS256 token, PS256 keyfunc", pssToken, pssKeyfunc},
+		{"PS256 token, RS256 keyfunc", pssToken, rsaKeyfunc},
+		{"RS256 token, PS256 keyfunc", sign(jwt.SigningMethodRS256, privateKey), pssKeyfunc},
+		{"RS384 token, RS256 keyfunc", sign(jwt.SigningMethodRS384, privateKey), rsaKeyfunc},
+		{"HS256 token signed with the RSA public key, RS256 keyfunc", confusedToken, rsaKeyfunc},
+	}
+
+	for _, tc := range testCases {
+		if _, err := jwt.Parse(tc.token, tc.keyfunc); err != nil {
+			fmt.Printf("❌ %s: %v\n", tc.name, tokenerr.Wrap(err))
+		} else {
+			fmt.Printf("✅ %s: valid\n", tc.name)
+		}
+	}
+}
+
+// Demo 12: Lists and nested objects in claims, read back without panics
+func nestedClaimsDemo() {
+	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":         "1234567890",
+		"permissions": []string{"orders:read", "orders:write"},
+		"metadata": map[string]interface{}{
+			"department":  "engineering",
+			"login_count": 42,
+			"last_login":  time.Now().Add(-time.Hour).Unix(),
+		},
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString(hmacSecret)
+	if err != nil {
+		log.Fatal("Error signing token:", err)
+	}
+
+	// WithJSONNumber decodes numbers as json.Number; the accessors handle
+	// both that and the default float64
+	for _, opts := range [][]jwt.ParserOption{nil, {jwt.WithJSONNumber()}} {
+		token, err := jwt.Parse(tokenString, hmacKeyfunc, opts...)
+		if err != nil {
+			fmt.Printf("❌ Token is invalid: %v\n", err)
+			return
+		}
+		mapClaims := token.Claims.(jwt.MapClaims)
+		fmt.Printf("Numbers decoded as %T\n", mapClaims["exp"])
+
+		permissions, err := claims.GetStringSlice(mapClaims, "permissions")
+		fmt.Printf("   permissions: %q%s\n", permissions, claimErr(err))
+
+		metadata, err := claims.GetMap(mapClaims, "metadata")
+		if err != nil {
+			fmt.Printf("❌ metadata: %v\n", err)
+			continue
+		}
+		department, err := claims.GetString(metadata, "department")
+		fmt.Printf("   metadata.department: %s%s\n", department, claimErr(err))
+		logins, err := claims.GetInt64(metadata, "login_count")
+		fmt.Printf("   metadata.login_count: %d%s\n", logins, claimErr(err))
+		lastLogin, err := claims.GetTime(metadata, "last_login")
+		fmt.Printf("   metadata.last_login: %s%s\n", lastLogin.Format(time.RFC3339), claimErr(err))
+	}
+
+	// Missing and mistyped claims are errors, not panics
+	token, _ := jwt.Parse(tokenString, hmacKeyfunc)
+	mapClaims := token.Claims.(jwt.MapClaims)
+	if _, err := claims.GetString(mapClaims, "email"); err != nil {
+		fmt.Printf("❌ email: %v\n", err)
+	}
+	if _, err := claims.GetInt64(mapClaims, "sub"); err != nil {
+		fmt.Printf("❌ sub as number: %v\n", err)
+	}
+	if _, err := claims.GetStringSlice(mapClaims, "metadata"); err != nil {
+		fmt.Printf("❌ metadata as list: %v\n", err)
+	}
+}
+
+// strictKeyfunc returns a Keyfunc that hands out key only for tokens whose
+// alg is exactly method's. Checking the Go type of token.Method is not
+// enough: RS256, RS384 and RS512 share *jwt.SigningMethodRSA.
+func strictKeyfunc(method jwt.SigningMethod, key interface{}) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		if token.Method == nil || token.Method.Alg() != method.Alg() {
+			return nil, fmt.Errorf("%w: unexpected signing method %v (want %s)", tokenerr.ErrWrongAlgorithm, token.Header["alg"], method.Alg())
+		}
+		return key, nil
+	}
+}
+
+// rsaKeyfunc only accepts RS256-signed tokens
+func rsaKeyfunc(token *jwt.Token) (interface{}, error) {
+	return strictKeyfunc(jwt.SigningMethodRS256, publicKey)(token)
+}
+
+// pssKeyfunc only accepts PS256-signed tokens
+func pssKeyfunc(token *jwt.Token) (interface{}, error) {
+	return strictKeyfunc(jwt.SigningMethodPS256, publicKey)(token)
+}
+
+// ecdsaKeyfunc only accepts ES256-signed tokens
+func ecdsaKeyfunc(token *jwt.Token) (interface{}, error) {
+	return strictKeyfunc(jwt.SigningMethodES256, ecdsaPublicKey)(token)
+}
+
+// ed25519Keyfunc only accepts EdDSA-signed tokens
+func ed25519Keyfunc(token *jwt.Token) (interface{}, error) {
+	return strictKeyfunc(jwt.SigningMethodEdDSA, ed25519PublicKey)(token)
+}
+
+// Helper function to start a refresh token family, as a login would
+func startRefreshFamily(userID int, username, role string) (string, error) {
+	refreshClaims := claims.NewRefreshClaims(userID, username, role, time.Hour*24*7, // 7 days
+		claims.WithFamily(claims.NewID()))
+
+	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims).SignedString(hmacSecret)
+	if err != nil {
+		return "", err
+	}
+	if err := refreshFamilies.Start(refreshClaims.Family, refreshClaims.ID, refreshClaims.ExpiresAt.Time); err != nil {
+		return "", err
+	}
+	return tokenString, nil
+}
+
+// Helper function to refresh access token; the refresh token is rotated:
+// a new one is issued and the old one is consumed, so presenting it again
+// revokes the whole family
+func refreshAccessToken(refreshTokenString string) (string, string) {
+	// Parse refresh token
+	token, err := jwt.ParseWithClaims(refreshTokenString, &claims.Claims{}, hmacKeyfunc)
+	if err == nil {
+		err = checkNotRevoked(revocationStore, token.Claims)
+	}
+	var presented *claims.Claims
+	if err == nil {
+		presented, err = claims.FromToken(token)
+	}
+
+	if err != nil {
+		fmt.Printf("❌ Invalid refresh token: %v\n", tokenerr.Wrap(err))
+		return "", ""
+	}
+
+	// Verify it's a refresh token
+	if presented.Type != claims.TypeRefresh {
+		fmt.Printf("❌ Not a refresh token\n")
+		return "", ""
+	}
+
+	// Create new access token; in practice, re-read the user from the database
+	accessClaims := claims.NewAccessClaims(presented.UserID, presented.Username, presented.Role, time.Minute*15,
+		claims.WithFamily(presented.Family))
+	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims).SignedString(hmacSecret)
+	if err != nil {
+		fmt.Printf("❌ Error creating new access token: %v\n", err)
+		return "", ""
+	}
+
+	refreshClaims := claims.NewRefreshClaims(presented.UserID, presented.Username, presented.Role, time.Hour*24*7,
+		claims.WithFamily(presented.Family))
+	refreshString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims).SignedString(hmacSecret)
+	if err != nil {
+		fmt.Printf("❌ Error creating new refresh token: %v\n", err)
+		return "", ""
+	}
+
+	// The old refresh token must not be usable again
+	if err := refreshFamilies.Rotate(presented.Family, presented.ID, refreshClaims.ID, refreshClaims.ExpiresAt.Time); err != nil {
+		fmt.Printf("❌ Refresh rejected: %v\n", err)
+		return "", ""
+	}
+
+	return tokenString, refreshString
+}
+
+// hmacKeyfunc only accepts HS256-signed tokens
+func hmacKeyfunc(token *jwt.Token) (interface{}, error) {
+	return strictKeyfunc(jwt.SigningMethodHS256, hmacSecret)(token)
 }
 
-// Helper function to export RSA public key as PEM string
-func exportRSAPublicKeyAsPEMStr(pubkey *rsa.PublicKey) string {
+// Helper function to export any public key (ECDSA, Ed25519, RSA) as a PKIX PEM string
+func exportPublicKeyAsPEMStr(pubkey interface{}) string {
 	pubkeyBytes, err := x509.MarshalPKIXPublicKey(pubkey)
 	if err != nil {
 		return ""
 	}
 	pubkeyPem := pem.EncodeToMemory(
 		&pem.Block{
-			Type:  "RSA PUBLIC KEY",
+			Type:  "PUBLIC KEY",
 			Bytes: pubkeyBytes,
 		},
 	)
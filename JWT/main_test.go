@@ -0,0 +1,116 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func testClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub": "1234567890",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func signToken(t *testing.T, method jwt.SigningMethod, key interface{}) string {
+	t.Helper()
+
+	tokenString, err := jwt.NewWithClaims(method, testClaims()).SignedString(key)
+	if err != nil {
+		t.Fatalf("sign %s: %v", method.Alg(), err)
+	}
+	return tokenString
+}
+
+func TestAsymmetricRoundTrips(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  jwt.SigningMethod
+		key     interface{}
+		keyfunc jwt.Keyfunc
+	}{
+		{"RS256", jwt.SigningMethodRS256, privateKey, rsaKeyfunc},
+		{"PS256", jwt.SigningMethodPS256, privateKey, pssKeyfunc},
+		{"ES256", jwt.SigningMethodES256, ecdsaPrivateKey, ecdsaKeyfunc},
+		{"EdDSA", jwt.SigningMethodEdDSA, ed25519PrivateKey, ed25519Keyfunc},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := jwt.Parse(signToken(t, tt.method, tt.key), tt.keyfunc)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			if !token.Valid || token.Header["alg"] != tt.method.Alg() {
+				t.Errorf("token valid=%t alg=%v", token.Valid, token.Header["alg"])
+			}
+			if sub, _ := token.Claims.GetSubject(); sub != "1234567890" {
+				t.Errorf("sub = %q", sub)
+			}
+		})
+	}
+}
+
+func TestKeyfuncsRejectOtherAlgorithms(t *testing.T) {
+	tests := []struct {
+		name    string
+		token   string
+		keyfunc jwt.Keyfunc
+	}{
+		{"RS256 to ES256 verifier", signToken(t, jwt.SigningMethodRS256, privateKey), ecdsaKeyfunc},
+		{"HS256 to ES256 verifier", signToken(t, jwt.SigningMethodHS256, hmacSecret), ecdsaKeyfunc},
+		{"ES256 to EdDSA verifier", signToken(t, jwt.SigningMethodES256, ecdsaPrivateKey), ed25519Keyfunc},
+		{"RS256 to EdDSA verifier", signToken(t, jwt.SigningMethodRS256, privateKey), ed25519Keyfunc},
+		{"PS256 to RS256 verifier", signToken(t, jwt.SigningMethodPS256, privateKey), rsaKeyfunc},
+		{"RS256 to PS256 verifier", signToken(t, jwt.SigningMethodRS256, privateKey), pssKeyfunc},
+		{"RS384 to RS256 verifier", signToken(t, jwt.SigningMethodRS384, privateKey), rsaKeyfunc},
+		{"HS512 to HS256 verifier", signToken(t, jwt.SigningMethodHS512, hmacSecret), hmacKeyfunc},
+		// The classic confusion attack: HMAC keyed with the RSA public key
+		{"HS256 with public key to RS256 verifier", signToken(t, jwt.SigningMethodHS256, []byte(exportPublicKeyAsPEMStr(publicKey))), rsaKeyfunc},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := jwt.Parse(tt.token, tt.keyfunc)
+			if !errors.Is(err, jwt.ErrTokenUnverifiable) {
+				t.Errorf("err = %v, want %v", err, jwt.ErrTokenUnverifiable)
+			}
+		})
+	}
+}
+
+func TestExportPublicKeyAsPEMStr(t *testing.T) {
+	for _, pub := range []interface{}{publicKey, ecdsaPublicKey, ed25519PublicKey} {
+		block, _ := pem.Decode([]byte(exportPublicKeyAsPEMStr(pub)))
+		if block == nil || block.Type != "PUBLIC KEY" {
+			t.Fatalf("%T: unexpected PEM block %v", pub, block)
+		}
+
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			t.Fatalf("%T: %v", pub, err)
+		}
+		switch want := pub.(type) {
+		case *rsa.PublicKey:
+			if !want.Equal(parsed) {
+				t.Errorf("RSA key did not round-trip")
+			}
+		case *ecdsa.PublicKey:
+			if !want.Equal(parsed) {
+				t.Errorf("ECDSA key did not round-trip")
+			}
+		case ed25519.PublicKey:
+			if !want.Equal(parsed) {
+				t.Errorf("Ed25519 key did not round-trip")
+			}
+		}
+	}
+}
@@ -0,0 +1,264 @@
+// Package middleware validates bearer tokens for net/http, httprouter and
+// Echo handlers. All three adapters share one Options struct and the same
+// accept/reject rules, and store the verified claims in the request context.
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/julienschmidt/httprouter"
+	"github.com/labstack/echo/v4"
+	"jwt-demo/claims"
+	"jwt-demo/tokenerr"
+)
+
+// Response headers set on authenticated requests
+const (
+	// ExpiresInHeader carries the whole seconds left on the presented token
+	ExpiresInHeader = "X-Token-Expires-In"
+	// RefreshedTokenHeader carries a new access token issued because the
+	// presented one was about to expire
+	RefreshedTokenHeader = "X-Refreshed-Token"
+)
+
+// Request errors passed to the ErrorRenderer
+var (
+	ErrMissingToken        = errors.New("missing bearer token")
+	ErrMalformedAuthHeader = errors.New("malformed Authorization header")
+	ErrRoleNotAllowed      = errors.New("role not allowed")
+)
+
+// ErrorRenderer writes the response for a rejected request. status is 400
+// for a malformed Authorization header or a token that is not a JWT, 401
+// for a missing or invalid token and 403 for a role that is not allowed.
+// Token errors are *tokenerr.Error values; tokenerr.Classify tells them
+// apart.
+type ErrorRenderer func(w http.ResponseWriter, r *http.Request, status int, err error)
+
+// Options configures a Middleware
+type Options struct {
+	// Keyfunc returns the verification key; it is required
+	Keyfunc jwt.Keyfunc
+	// ValidMethods restricts the accepted alg values when set
+	ValidMethods []string
+	// Audience and Issuer, when set, must be present in the token
+	Audience string
+	Issuer   string
+	// NewClaims returns the value tokens are decoded into; the default is
+	// jwt.MapClaims
+	NewClaims func() jwt.Claims
+	// Validate runs after the signature and registered claims are
+	// verified, e.g. to check a token type or revocation. An error
+	// rejects the request with 401.
+	Validate func(claims jwt.Claims) error
+	// Role extracts the caller's role; the default reads the "role" key
+	// of jwt.MapClaims
+	Role func(claims jwt.Claims) string
+	// Roles lists the roles allowed through; empty allows any role
+	Roles []string
+	// ErrorRenderer writes rejections; the default writes
+	// {"error": "..."} as JSON
+	ErrorRenderer ErrorRenderer
+	// Refresh issues a new access token for claims. When set, tokens
+	// with RefreshThreshold or less left are replaced proactively and the
+	// new token is sent in RefreshedTokenHeader (a sliding session). A
+	// Refresh error leaves the request unaffected.
+	Refresh          func(claims jwt.Claims) (string, error)
+	RefreshThreshold time.Duration
+	// Now is the clock used for exp, nbf and iat; the default is time.Now
+	Now func() time.Time
+}
+
+// Middleware validates bearer tokens according to its Options
+type Middleware struct {
+	opts   Options
+	parser *jwt.Parser
+}
+
+// New checks opts and fills in the defaults
+func New(opts Options) (*Middleware, error) {
+	if opts.Keyfunc == nil {
+		return nil, errors.New("middleware: Keyfunc is required")
+	}
+	if opts.NewClaims == nil {
+		opts.NewClaims = func() jwt.Claims { return jwt.MapClaims{} }
+	}
+	if opts.Role == nil {
+		opts.Role = mapClaimsRole
+	}
+	if opts.ErrorRenderer == nil {
+		opts.ErrorRenderer = RenderJSONError
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+
+	parserOpts := []jwt.ParserOption{
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(func() time.Time { return opts.Now() }),
+	}
+	if len(opts.ValidMethods) > 0 {
+		parserOpts = append(parserOpts, jwt.WithValidMethods(opts.ValidMethods))
+	}
+	if opts.Audience != "" {
+		parserOpts = append(parserOpts, jwt.WithAudience(opts.Audience))
+	}
+	if opts.Issuer != "" {
+		parserOpts = append(parserOpts, jwt.WithIssuer(opts.Issuer))
+	}
+	return &Middleware{opts: opts, parser: jwt.NewParser(parserOpts...)}, nil
+}
+
+// Handler is net/http middleware, i.e. a func(http.Handler) http.Handler
+func (m *Middleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r, ok := m.authenticate(w, r); ok {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// Handle wraps an httprouter.Handle
+func (m *Middleware) Handle(next httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		if r, ok := m.authenticate(w, r); ok {
+			next(w, r, ps)
+		}
+	}
+}
+
+// Echo is an echo.MiddlewareFunc; the claims are in c.Request().Context()
+func (m *Middleware) Echo(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		r, ok := m.authenticate(c.Response(), c.Request())
+		if !ok {
+			return nil
+		}
+		c.SetRequest(r)
+		return next(c)
+	}
+}
+
+// authenticate returns r with the verified claims in its context, or
+// renders the rejection and returns false
+func (m *Middleware) authenticate(w http.ResponseWriter, r *http.Request) (*http.Request, bool) {
+	tokenString, err := BearerToken(r)
+	if err != nil {
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_request"`)
+		m.opts.ErrorRenderer(w, r, http.StatusBadRequest, err)
+		return nil, false
+	}
+	if tokenString == "" {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		m.opts.ErrorRenderer(w, r, http.StatusUnauthorized, ErrMissingToken)
+		return nil, false
+	}
+
+	tokenClaims := m.opts.NewClaims()
+	token, err := m.parser.ParseWithClaims(tokenString, tokenClaims, m.opts.Keyfunc)
+	if err != nil {
+		m.rejectToken(w, r, tokenerr.Parsed(token, err, m.opts.ValidMethods))
+		return nil, false
+	}
+	if m.opts.Validate != nil {
+		if err := m.opts.Validate(tokenClaims); err != nil {
+			m.rejectToken(w, r, tokenerr.Wrap(err))
+			return nil, false
+		}
+	}
+	if len(m.opts.Roles) > 0 && !contains(m.opts.Roles, m.opts.Role(tokenClaims)) {
+		err := fmt.Errorf("%w: one of %q required", ErrRoleNotAllowed, m.opts.Roles)
+		m.opts.ErrorRenderer(w, r, http.StatusForbidden, err)
+		return nil, false
+	}
+
+	m.setExpiryHeaders(w, token)
+	return r.WithContext(context.WithValue(r.Context(), claimsContextKey{}, tokenClaims)), true
+}
+
+// rejectToken renders err with the status of its tokenerr class
+func (m *Middleware) rejectToken(w http.ResponseWriter, r *http.Request, err error) {
+	status := tokenerr.HTTPStatus(err)
+	if status == http.StatusBadRequest {
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_request"`)
+	} else {
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+	}
+	m.opts.ErrorRenderer(w, r, status, err)
+}
+
+// setExpiryHeaders reports the remaining lifetime of an accepted token and
+// refreshes it when it is close to expiring
+func (m *Middleware) setExpiryHeaders(w http.ResponseWriter, token *jwt.Token) {
+	now := m.opts.Now()
+	remaining, err := claims.TimeToExpiry(token, now)
+	if err != nil {
+		return
+	}
+	w.Header().Set(ExpiresInHeader, strconv.FormatInt(int64(remaining/time.Second), 10))
+
+	if m.opts.Refresh != nil && claims.ShouldRefresh(token, m.opts.RefreshThreshold, now) {
+		if refreshed, err := m.opts.Refresh(token.Claims); err == nil {
+			w.Header().Set(RefreshedTokenHeader, refreshed)
+		}
+	}
+}
+
+// BearerToken extracts the token from an "Authorization: Bearer <token>"
+// header. A missing header returns "" and no error.
+func BearerToken(r *http.Request) (string, error) {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return "", nil
+	}
+
+	scheme, token, ok := strings.Cut(header, " ")
+	token = strings.TrimSpace(token)
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" || strings.Contains(token, " ") {
+		return "", ErrMalformedAuthHeader
+	}
+	return token, nil
+}
+
+// claimsContextKey is the context key for the verified claims
+type claimsContextKey struct{}
+
+// ClaimsFromContext returns the claims stored by the middleware, in the
+// type returned by Options.NewClaims
+func ClaimsFromContext(ctx context.Context) (jwt.Claims, bool) {
+	claims, ok := ctx.Value(claimsContextKey{}).(jwt.Claims)
+	return claims, ok
+}
+
+// RenderJSONError is the default ErrorRenderer
+func RenderJSONError(w http.ResponseWriter, _ *http.Request, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+func mapClaimsRole(claims jwt.Claims) string {
+	mapClaims, ok := claims.(jwt.MapClaims)
+	if !ok {
+		return ""
+	}
+	role, _ := mapClaims["role"].(string)
+	return role
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
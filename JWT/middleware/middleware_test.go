@@ -0,0 +1,333 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/julienschmidt/httprouter"
+	"github.com/labstack/echo/v4"
+	"jwt-demo/tokenerr"
+)
+
+var (
+	testSecret = []byte("0123456789abcdef0123456789abcdef")
+	testNow    = time.Date(2024, 12, 25, 12, 0, 0, 0, time.UTC)
+)
+
+func testKeyfunc(token *jwt.Token) (interface{}, error) {
+	if token.Method.Alg() != jwt.SigningMethodHS256.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return testSecret, nil
+}
+
+func testOptions() Options {
+	return Options{
+		Keyfunc:      testKeyfunc,
+		ValidMethods: []string{"HS256"},
+		Audience:     "web-app",
+		Issuer:       "jwt-demo-app",
+		Now:          func() time.Time { return testNow },
+	}
+}
+
+// sign returns an HS256 token for a valid admin, changed by edit
+func sign(t *testing.T, edit func(jwt.MapClaims)) string {
+	t.Helper()
+
+	claims := jwt.MapClaims{
+		"sub":  "123",
+		"role": "admin",
+		"aud":  "web-app",
+		"iss":  "jwt-demo-app",
+		"exp":  testNow.Add(time.Hour).Unix(),
+	}
+	if edit != nil {
+		edit(claims)
+	}
+	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tokenString
+}
+
+// whoami answers with the subject found by ClaimsFromContext
+func whoami(w http.ResponseWriter, r *http.Request) {
+	claims, ok := ClaimsFromContext(r.Context())
+	if !ok {
+		RenderJSONError(w, r, http.StatusInternalServerError, errors.New("no claims in context"))
+		return
+	}
+	sub, _ := claims.GetSubject()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"sub": sub})
+}
+
+// servers wires m into a minimal instance of each framework at GET /me
+func servers(m *Middleware) map[string]http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/me", m.Handler(http.HandlerFunc(whoami)))
+
+	router := httprouter.New()
+	router.GET("/me", m.Handle(func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		whoami(w, r)
+	}))
+
+	e := echo.New()
+	e.GET("/me", echo.WrapHandler(http.HandlerFunc(whoami)), m.Echo)
+
+	return map[string]http.Handler{"net/http": mux, "httprouter": router, "echo": e}
+}
+
+type response struct {
+	code            int
+	body            string
+	wwwAuthenticate string
+	expiresIn       string
+	refreshed       string
+}
+
+func get(h http.Handler, authorization string) response {
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return response{
+		code:            rec.Code,
+		body:            rec.Body.String(),
+		wwwAuthenticate: rec.Header().Get("WWW-Authenticate"),
+		expiresIn:       rec.Header().Get(ExpiresInHeader),
+		refreshed:       rec.Header().Get(RefreshedTokenHeader),
+	}
+}
+
+// assertSameResponses sends authorization to every framework and checks
+// they all answer like the net/http one, with status want
+func assertSameResponses(t *testing.T, handlers map[string]http.Handler, authorization string, want int) {
+	t.Helper()
+
+	reference := get(handlers["net/http"], authorization)
+	if reference.code != want {
+		t.Errorf("net/http: status %d, want %d (body %s)", reference.code, want, reference.body)
+	}
+	for name, h := range handlers {
+		if got := get(h, authorization); got != reference {
+			t.Errorf("%s: got %+v, net/http got %+v", name, got, reference)
+		}
+	}
+}
+
+func TestFrameworksAgree(t *testing.T) {
+	opts := testOptions()
+	opts.Roles = []string{"admin"}
+	m, err := New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handlers := servers(m)
+
+	tests := []struct {
+		name          string
+		authorization string
+		want          int
+	}{
+		{"valid", "Bearer " + sign(t, nil), http.StatusOK},
+		{"lowercase scheme", "bearer " + sign(t, nil), http.StatusOK},
+		{"missing", "", http.StatusUnauthorized},
+		{"wrong scheme", "Basic am9objpwYXNz", http.StatusBadRequest},
+		{"empty token", "Bearer   ", http.StatusBadRequest},
+		{"garbage token", "Bearer not.a.jwt", http.StatusBadRequest},
+		{"bad signature", "Bearer " + sign(t, nil) + "x", http.StatusUnauthorized},
+		{"expired", "Bearer " + sign(t, func(c jwt.MapClaims) { c["exp"] = testNow.Add(-time.Minute).Unix() }), http.StatusUnauthorized},
+		{"no exp", "Bearer " + sign(t, func(c jwt.MapClaims) { delete(c, "exp") }), http.StatusUnauthorized},
+		{"wrong audience", "Bearer " + sign(t, func(c jwt.MapClaims) { c["aud"] = "billing" }), http.StatusUnauthorized},
+		{"wrong issuer", "Bearer " + sign(t, func(c jwt.MapClaims) { c["iss"] = "evil" }), http.StatusUnauthorized},
+		{"role not allowed", "Bearer " + sign(t, func(c jwt.MapClaims) { c["role"] = "user" }), http.StatusForbidden},
+		{"no role", "Bearer " + sign(t, func(c jwt.MapClaims) { delete(c, "role") }), http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertSameResponses(t, handlers, tt.authorization, tt.want)
+		})
+	}
+
+	t.Run("claims reach the handler", func(t *testing.T) {
+		for name, h := range handlers {
+			if got := get(h, "Bearer "+sign(t, nil)); got.body != `{"sub":"123"}`+"\n" {
+				t.Errorf("%s: body %q", name, got.body)
+			}
+		}
+	})
+}
+
+func TestValidateAndErrorRenderer(t *testing.T) {
+	revoked := errors.New("token revoked")
+	opts := testOptions()
+	opts.Validate = func(claims jwt.Claims) error {
+		if sub, _ := claims.GetSubject(); sub == "666" {
+			return revoked
+		}
+		return nil
+	}
+	var rendered []error
+	opts.ErrorRenderer = func(w http.ResponseWriter, r *http.Request, status int, err error) {
+		rendered = append(rendered, err)
+		w.WriteHeader(status)
+		fmt.Fprintf(w, "denied: %v", err)
+	}
+	m, err := New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handlers := servers(m)
+
+	assertSameResponses(t, handlers, "Bearer "+sign(t, func(c jwt.MapClaims) { c["sub"] = "666" }), http.StatusUnauthorized)
+	assertSameResponses(t, handlers, "", http.StatusUnauthorized)
+	assertSameResponses(t, handlers, "Bearer "+sign(t, nil), http.StatusOK)
+
+	if len(rendered) == 0 {
+		t.Fatal("ErrorRenderer was not called")
+	}
+	if !errors.Is(rendered[0], revoked) {
+		t.Errorf("first rendered error = %v, want %v", rendered[0], revoked)
+	}
+	if got := get(handlers["echo"], ""); got.body != "denied: "+ErrMissingToken.Error() {
+		t.Errorf("echo body = %q", got.body)
+	}
+
+	// Token errors arrive classified
+	rendered = nil
+	expired := sign(t, func(c jwt.MapClaims) { c["exp"] = testNow.Add(-time.Minute).Unix() })
+	for _, authorization := range []string{"Bearer " + expired, "Bearer " + sign(t, nil) + "x", "Bearer not.a.jwt"} {
+		get(handlers["httprouter"], authorization)
+	}
+	want := []error{tokenerr.ErrExpired, tokenerr.ErrBadSignature, tokenerr.ErrMalformed}
+	for i, class := range want {
+		if i >= len(rendered) || tokenerr.Classify(rendered[i]) != class {
+			t.Errorf("rendered %v, want classes %v", rendered, want)
+			break
+		}
+	}
+}
+
+func TestCustomClaims(t *testing.T) {
+	type userClaims struct {
+		Role string `json:"role"`
+		jwt.RegisteredClaims
+	}
+
+	opts := testOptions()
+	opts.NewClaims = func() jwt.Claims { return &userClaims{} }
+	opts.Role = func(claims jwt.Claims) string { return claims.(*userClaims).Role }
+	opts.Roles = []string{"admin", "user"}
+	m, err := New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, _ := ClaimsFromContext(r.Context())
+		if c, ok := claims.(*userClaims); !ok || c.Role != "user" {
+			t.Errorf("claims = %#v", claims)
+		}
+	}))
+	if got := get(handler, "Bearer "+sign(t, func(c jwt.MapClaims) { c["role"] = "user" })); got.code != http.StatusOK {
+		t.Errorf("status %d: %s", got.code, got.body)
+	}
+	if got := get(handler, "Bearer "+sign(t, func(c jwt.MapClaims) { c["role"] = "guest" })); got.code != http.StatusForbidden {
+		t.Errorf("guest: status %d: %s", got.code, got.body)
+	}
+}
+
+func TestNewRequiresKeyfunc(t *testing.T) {
+	if _, err := New(Options{}); err == nil {
+		t.Error("New without Keyfunc succeeded")
+	}
+}
+
+func TestExpiryHeaders(t *testing.T) {
+	m, err := New(testOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	handlers := servers(m)
+
+	// Every framework reports the same lifetime; assertSameResponses
+	// compares the headers too
+	assertSameResponses(t, handlers, "Bearer "+sign(t, nil), http.StatusOK)
+	if got := get(handlers["net/http"], "Bearer "+sign(t, nil)); got.expiresIn != "3600" || got.refreshed != "" {
+		t.Errorf("headers = %q, %q; want 3600 and no refresh", got.expiresIn, got.refreshed)
+	}
+	if got := get(handlers["echo"], "Bearer "+sign(t, nil)+"x"); got.expiresIn != "" {
+		t.Errorf("rejected request carries %s: %q", ExpiresInHeader, got.expiresIn)
+	}
+}
+
+func TestProactiveRefresh(t *testing.T) {
+	now := testNow
+	refreshErr := error(nil)
+	var refreshedFor []string
+
+	opts := testOptions()
+	opts.Now = func() time.Time { return now }
+	opts.RefreshThreshold = 10 * time.Minute
+	opts.Refresh = func(c jwt.Claims) (string, error) {
+		sub, _ := c.GetSubject()
+		refreshedFor = append(refreshedFor, sub)
+		return "refreshed-" + sub, refreshErr
+	}
+	m, err := New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := servers(m)["httprouter"]
+	authorization := "Bearer " + sign(t, nil)
+
+	tests := []struct {
+		elapsed       time.Duration
+		wantExpiresIn string
+		wantRefreshed string
+	}{
+		{0, "3600", ""},
+		{50*time.Minute - time.Second, "601", ""},
+		{50 * time.Minute, "600", "refreshed-123"}, // exactly the threshold left
+		{59*time.Minute + 30*time.Second, "30", "refreshed-123"},
+	}
+	for _, tt := range tests {
+		now = testNow.Add(tt.elapsed)
+		got := get(handler, authorization)
+		if got.code != http.StatusOK || got.expiresIn != tt.wantExpiresIn || got.refreshed != tt.wantRefreshed {
+			t.Errorf("after %s: %d, %s=%q, %s=%q; want 200, %q, %q", tt.elapsed, got.code,
+				ExpiresInHeader, got.expiresIn, RefreshedTokenHeader, got.refreshed, tt.wantExpiresIn, tt.wantRefreshed)
+		}
+	}
+	if len(refreshedFor) != 2 {
+		t.Errorf("Refresh called %d times, want 2", len(refreshedFor))
+	}
+
+	t.Run("refresh error leaves the request alone", func(t *testing.T) {
+		refreshErr = errors.New("signing failed")
+		now = testNow.Add(55 * time.Minute)
+		if got := get(handler, authorization); got.code != http.StatusOK || got.expiresIn != "300" || got.refreshed != "" {
+			t.Errorf("got %+v", got)
+		}
+	})
+
+	t.Run("expired tokens are not refreshed", func(t *testing.T) {
+		refreshErr, refreshedFor = nil, nil
+		now = testNow.Add(time.Hour)
+		if got := get(handler, authorization); got.code != http.StatusUnauthorized || got.refreshed != "" || len(refreshedFor) != 0 {
+			t.Errorf("got %+v, Refresh calls %d", got, len(refreshedFor))
+		}
+	})
+}
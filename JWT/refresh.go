@@ -0,0 +1,132 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrRefreshTokenReused is returned when an already consumed refresh
+	// token is presented again. Either the client or an attacker holds a
+	// stolen copy, so the whole family is revoked.
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected: possible token theft, session revoked")
+
+	// ErrRefreshFamilyRevoked is returned for tokens of a revoked family
+	ErrRefreshFamilyRevoked = errors.New("refresh token family has been revoked")
+
+	// ErrUnknownRefreshFamily is returned for families the store has never
+	// seen or has already expired
+	ErrUnknownRefreshFamily = errors.New("unknown refresh token family")
+)
+
+// refreshFamily tracks the chain of refresh tokens issued from one login
+type refreshFamily struct {
+	current   string          // jti of the only refresh token that may be used next
+	consumed  map[string]bool // jtis already exchanged for new tokens
+	revoked   bool
+	expiresAt time.Time // exp of the newest refresh token in the family
+}
+
+// RefreshFamilyStore implements refresh token rotation. Every login starts
+// a family (the "fam" claim); every refresh consumes the presented token
+// and makes the newly issued one current. Families are dropped once their
+// newest refresh token has expired, on Cleanup and on every Start.
+type RefreshFamilyStore struct {
+	mu       sync.Mutex
+	families map[string]*refreshFamily
+	now      func() time.Time
+}
+
+// NewRefreshFamilyStore creates an empty store
+func NewRefreshFamilyStore() *RefreshFamilyStore {
+	return &RefreshFamilyStore{families: make(map[string]*refreshFamily), now: time.Now}
+}
+
+// Start records a new family whose first refresh token is jti
+func (s *RefreshFamilyStore) Start(family, jti string, expiresAt time.Time) error {
+	if family == "" || jti == "" {
+		return errors.New("refresh family: family and jti are required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cleanupLocked()
+	s.families[family] = &refreshFamily{
+		current:   jti,
+		consumed:  make(map[string]bool),
+		expiresAt: expiresAt,
+	}
+	return nil
+}
+
+// Rotate consumes the refresh token oldJTI and makes newJTI, expiring at
+// expiresAt, the current token of family. Presenting a consumed token
+// revokes the family and returns ErrRefreshTokenReused.
+func (s *RefreshFamilyStore) Rotate(family, oldJTI, newJTI string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, ok := s.families[family]
+	if !ok || !f.expiresAt.After(s.now()) {
+		return ErrUnknownRefreshFamily
+	}
+	if f.revoked {
+		return ErrRefreshFamilyRevoked
+	}
+	if f.consumed[oldJTI] || oldJTI != f.current {
+		f.revoked = true
+		return ErrRefreshTokenReused
+	}
+
+	f.consumed[oldJTI] = true
+	f.current = newJTI
+	if expiresAt.After(f.expiresAt) {
+		f.expiresAt = expiresAt
+	}
+	return nil
+}
+
+// RevokeFamily revokes every token of family, e.g. on logout
+func (s *RefreshFamilyStore) RevokeFamily(family string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if f, ok := s.families[family]; ok {
+		f.revoked = true
+	}
+}
+
+// IsRevoked reports whether family has been revoked; unknown families are
+// not revoked
+func (s *RefreshFamilyStore) IsRevoked(family string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, ok := s.families[family]
+	return ok && f.revoked && f.expiresAt.After(s.now())
+}
+
+// Cleanup removes expired families and returns how many were removed
+func (s *RefreshFamilyStore) Cleanup() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cleanupLocked()
+}
+
+// Len returns the number of families currently held
+func (s *RefreshFamilyStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.families)
+}
+
+func (s *RefreshFamilyStore) cleanupLocked() int {
+	removed := 0
+	now := s.now()
+	for id, f := range s.families {
+		if !f.expiresAt.After(now) {
+			delete(s.families, id)
+			removed++
+		}
+	}
+	return removed
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func testFamilyStore() (*RefreshFamilyStore, *time.Time) {
+	now := time.Unix(1_700_000_000, 0)
+	store := NewRefreshFamilyStore()
+	store.now = func() time.Time { return now }
+	return store, &now
+}
+
+func TestRefreshFamilyRotationChain(t *testing.T) {
+	store, now := testFamilyStore()
+	if err := store.Start("fam-1", "jti-0", now.Add(time.Hour)); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	jtis := []string{"jti-0", "jti-1", "jti-2", "jti-3"}
+	for i := 1; i < len(jtis); i++ {
+		if err := store.Rotate("fam-1", jtis[i-1], jtis[i], now.Add(time.Hour)); err != nil {
+			t.Fatalf("rotate %s -> %s: %v", jtis[i-1], jtis[i], err)
+		}
+	}
+	if store.IsRevoked("fam-1") {
+		t.Error("family revoked after legitimate rotation")
+	}
+
+	// Any earlier link of the chain is consumed, not just the previous one
+	if err := store.Rotate("fam-1", "jti-1", "jti-x", now.Add(time.Hour)); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Errorf("replay jti-1: err = %v, want %v", err, ErrRefreshTokenReused)
+	}
+}
+
+func TestRefreshFamilyReuseRevokesFamily(t *testing.T) {
+	store, now := testFamilyStore()
+	store.Start("stolen", "a", now.Add(time.Hour))
+	store.Start("other", "x", now.Add(time.Hour))
+
+	if err := store.Rotate("stolen", "a", "b", now.Add(time.Hour)); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	if err := store.Rotate("stolen", "a", "c", now.Add(time.Hour)); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("replay: err = %v, want %v", err, ErrRefreshTokenReused)
+	}
+	if !store.IsRevoked("stolen") {
+		t.Error("family not revoked after reuse")
+	}
+
+	// The current token of the family is dead too
+	if err := store.Rotate("stolen", "b", "d", now.Add(time.Hour)); !errors.Is(err, ErrRefreshFamilyRevoked) {
+		t.Errorf("current token after reuse: err = %v, want %v", err, ErrRefreshFamilyRevoked)
+	}
+
+	// Other families are untouched
+	if store.IsRevoked("other") {
+		t.Error("unrelated family was revoked")
+	}
+	if err := store.Rotate("other", "x", "y", now.Add(time.Hour)); err != nil {
+		t.Errorf("unrelated family: %v", err)
+	}
+
+	store.RevokeFamily("other")
+	if err := store.Rotate("other", "y", "z", now.Add(time.Hour)); !errors.Is(err, ErrRefreshFamilyRevoked) {
+		t.Errorf("after RevokeFamily: err = %v, want %v", err, ErrRefreshFamilyRevoked)
+	}
+}
+
+func TestRefreshFamilyExpiry(t *testing.T) {
+	store, now := testFamilyStore()
+	store.Start("short", "a", now.Add(time.Minute))
+	store.Start("long", "x", now.Add(time.Minute))
+
+	// Rotating extends the family to the new token's expiry
+	if err := store.Rotate("long", "x", "y", now.Add(time.Hour)); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+
+	*now = now.Add(2 * time.Minute)
+	if err := store.Rotate("short", "a", "b", now.Add(time.Hour)); !errors.Is(err, ErrUnknownRefreshFamily) {
+		t.Errorf("expired family: err = %v, want %v", err, ErrUnknownRefreshFamily)
+	}
+	if err := store.Rotate("never", "a", "b", now.Add(time.Hour)); !errors.Is(err, ErrUnknownRefreshFamily) {
+		t.Errorf("unknown family: err = %v, want %v", err, ErrUnknownRefreshFamily)
+	}
+	if removed := store.Cleanup(); removed != 1 || store.Len() != 1 {
+		t.Errorf("Cleanup removed %d, %d left; want 1 and 1", removed, store.Len())
+	}
+	if err := store.Rotate("long", "y", "z", now.Add(time.Hour)); err != nil {
+		t.Errorf("extended family: %v", err)
+	}
+
+	if err := store.Start("", "a", now.Add(time.Hour)); err == nil {
+		t.Error("Start without family succeeded")
+	}
+}
+
+func TestRefreshAccessTokenRotates(t *testing.T) {
+	first, err := startRefreshFamily(123, "john_doe", "admin")
+	if err != nil {
+		t.Fatalf("startRefreshFamily: %v", err)
+	}
+
+	access, second := refreshAccessToken(first)
+	if access == "" || second == "" || second == first {
+		t.Fatalf("refreshAccessToken returned %q, %q", access, second)
+	}
+	_, third := refreshAccessToken(second)
+	if third == "" {
+		t.Fatal("second refresh token was rejected")
+	}
+
+	if again, _ := refreshAccessToken(first); again != "" {
+		t.Error("consumed refresh token was accepted again")
+	}
+	if again, _ := refreshAccessToken(third); again != "" {
+		t.Error("current refresh token still works after reuse was detected")
+	}
+}
+
+func TestAuthServerRefreshReuseDetection(t *testing.T) {
+	s, _ := testAuthServer(t)
+	h := s.Handler()
+	_, first := login(t, h, "jane_doe", "password456")
+	otherAccess, _ := login(t, h, "jane_doe", "password456")
+
+	rec, body := do(t, h, http.MethodPost, "/refresh", `{"refresh_token":"`+first+`"}`, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("refresh: status = %d, body %v", rec.Code, body)
+	}
+	access, second := body["access_token"].(string), body["refresh_token"].(string)
+
+	// Replaying the consumed token reports possible theft
+	rec, body = do(t, h, http.MethodPost, "/refresh", `{"refresh_token":"`+first+`"}`, "")
+	if rec.Code != http.StatusUnauthorized || body["error"] != ErrRefreshTokenReused.Error() {
+		t.Fatalf("replay: status = %d, body %v", rec.Code, body)
+	}
+
+	// Everything issued from the family is now rejected
+	if rec, body := do(t, h, http.MethodPost, "/refresh", `{"refresh_token":"`+second+`"}`, ""); rec.Code != http.StatusUnauthorized || body["error"] != ErrRefreshFamilyRevoked.Error() {
+		t.Errorf("current refresh token: status = %d, body %v", rec.Code, body)
+	}
+	if rec, body := do(t, h, http.MethodGet, "/me", "", "Bearer "+access); rec.Code != http.StatusUnauthorized || body["error"] != ErrRefreshFamilyRevoked.Error() {
+		t.Errorf("access token of revoked family: status = %d, body %v", rec.Code, body)
+	}
+
+	// A separate login is a separate family
+	if rec, _ := do(t, h, http.MethodGet, "/me", "", "Bearer "+otherAccess); rec.Code != http.StatusOK {
+		t.Errorf("other session: status = %d", rec.Code)
+	}
+}
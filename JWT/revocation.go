@@ -0,0 +1,134 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"jwt-demo/claims"
+	"jwt-demo/tokenerr"
+)
+
+// ErrTokenRevoked is returned for tokens whose jti has been revoked
+var ErrTokenRevoked = tokenerr.ErrRevoked
+
+// RevocationStore records revoked token IDs (the jti claim) until the
+// token would have expired anyway
+type RevocationStore interface {
+	Revoke(jti string, expiresAt time.Time) error
+	IsRevoked(jti string) (bool, error)
+}
+
+// MemoryRevocationStore is an in-memory RevocationStore. Entries are dropped
+// once their token has expired, on Cleanup and on every Revoke.
+type MemoryRevocationStore struct {
+	mu      sync.Mutex
+	entries map[string]time.Time
+	now     func() time.Time
+}
+
+// NewMemoryRevocationStore creates an empty store
+func NewMemoryRevocationStore() *MemoryRevocationStore {
+	return &MemoryRevocationStore{entries: make(map[string]time.Time), now: time.Now}
+}
+
+// Revoke records jti as revoked until expiresAt
+func (s *MemoryRevocationStore) Revoke(jti string, expiresAt time.Time) error {
+	if jti == "" {
+		return errors.New("revoke: token has no jti claim")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cleanupLocked()
+	if expiresAt.After(s.now()) {
+		s.entries[jti] = expiresAt
+	}
+	return nil
+}
+
+// IsRevoked reports whether jti is revoked and not yet expired
+func (s *MemoryRevocationStore) IsRevoked(jti string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expiresAt, ok := s.entries[jti]
+	return ok && expiresAt.After(s.now()), nil
+}
+
+// Cleanup removes entries whose tokens have expired and returns how many
+// were removed
+func (s *MemoryRevocationStore) Cleanup() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cleanupLocked()
+}
+
+// Len returns the number of entries currently held
+func (s *MemoryRevocationStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+func (s *MemoryRevocationStore) cleanupLocked() int {
+	removed := 0
+	now := s.now()
+	for jti, expiresAt := range s.entries {
+		if !expiresAt.After(now) {
+			delete(s.entries, jti)
+			removed++
+		}
+	}
+	return removed
+}
+
+// Revoke verifies tokenString with keyfunc and records its jti in store
+// until the token's exp. Tokens must be valid to be revoked, so forged
+// tokens cannot fill the store; already expired tokens need no entry.
+func Revoke(store RevocationStore, tokenString string, keyfunc jwt.Keyfunc) error {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, keyfunc)
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("revoke: %w", err)
+	}
+
+	jti, _ := claims["jti"].(string)
+	exp, err := claims.GetExpirationTime()
+	if err != nil || exp == nil {
+		return errors.New("revoke: token has no exp claim")
+	}
+	return store.Revoke(jti, exp.Time)
+}
+
+// checkNotRevoked returns ErrTokenRevoked when the jti of tokenClaims is in
+// store; call it after the signature and registered claims have been
+// validated. Tokens without a jti cannot be revoked.
+func checkNotRevoked(store RevocationStore, tokenClaims jwt.Claims) error {
+	var jti string
+	switch c := tokenClaims.(type) {
+	case jwt.MapClaims:
+		jti, _ = c["jti"].(string)
+	case *claims.Claims:
+		jti = c.ID
+	case *jwt.RegisteredClaims:
+		jti = c.ID
+	}
+	if jti == "" {
+		return nil
+	}
+
+	revoked, err := store.IsRevoked(jti)
+	if err != nil {
+		return fmt.Errorf("check revocation: %w", err)
+	}
+	if revoked {
+		return ErrTokenRevoked
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"jwt-demo/claims"
+)
+
+func signHMAC(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+
+	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(hmacSecret)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return tokenString
+}
+
+func TestRevokedTokenRejected(t *testing.T) {
+	store := NewMemoryRevocationStore()
+	revoked := signHMAC(t, jwt.MapClaims{"jti": claims.NewID(), "exp": time.Now().Add(time.Hour).Unix()})
+	kept := signHMAC(t, jwt.MapClaims{"jti": claims.NewID(), "exp": time.Now().Add(time.Hour).Unix()})
+
+	if err := Revoke(store, revoked, hmacKeyfunc); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+
+	for _, tc := range []struct {
+		token string
+		want  error
+	}{
+		{revoked, ErrTokenRevoked},
+		{kept, nil},
+	} {
+		token, err := jwt.Parse(tc.token, hmacKeyfunc)
+		if err != nil {
+			t.Fatalf("parse: %v", err)
+		}
+		if err := checkNotRevoked(store, token.Claims); !errors.Is(err, tc.want) {
+			t.Errorf("checkNotRevoked = %v, want %v", err, tc.want)
+		}
+	}
+}
+
+func TestAuthServerHonorsRevocation(t *testing.T) {
+	s, _ := testAuthServer(t)
+	h := s.Handler()
+	access, refresh := login(t, h, "jane_doe", "password456")
+	otherAccess, _ := login(t, h, "jane_doe", "password456")
+
+	if err := Revoke(s.revoked, access, strictKeyfunc(jwt.SigningMethodHS256, s.secret)); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+
+	if rec, body := do(t, h, http.MethodGet, "/me", "", "Bearer "+access); rec.Code != http.StatusUnauthorized || body["error"] != ErrTokenRevoked.Error() {
+		t.Errorf("revoked access token: status = %d, body %v", rec.Code, body)
+	}
+	if rec, _ := do(t, h, http.MethodGet, "/me", "", "Bearer "+otherAccess); rec.Code != http.StatusOK {
+		t.Errorf("unrevoked access token: status = %d", rec.Code)
+	}
+
+	if err := Revoke(s.revoked, refresh, strictKeyfunc(jwt.SigningMethodHS256, s.secret)); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if rec, _ := do(t, h, http.MethodPost, "/refresh", `{"refresh_token":"`+refresh+`"}`, ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("revoked refresh token: status = %d", rec.Code)
+	}
+}
+
+func TestRevokeRequiresValidToken(t *testing.T) {
+	store := NewMemoryRevocationStore()
+
+	forged, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"jti": claims.NewID(),
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte("not-the-secret"))
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if err := Revoke(store, forged, hmacKeyfunc); !errors.Is(err, jwt.ErrTokenSignatureInvalid) {
+		t.Errorf("Revoke(forged) = %v, want %v", err, jwt.ErrTokenSignatureInvalid)
+	}
+
+	noJTI := signHMAC(t, jwt.MapClaims{"exp": time.Now().Add(time.Hour).Unix()})
+	if err := Revoke(store, noJTI, hmacKeyfunc); err == nil {
+		t.Error("Revoke(no jti) should fail")
+	}
+
+	expired := signHMAC(t, jwt.MapClaims{"jti": claims.NewID(), "exp": time.Now().Add(-time.Minute).Unix()})
+	if err := Revoke(store, expired, hmacKeyfunc); err != nil {
+		t.Errorf("Revoke(expired) = %v, want nil", err)
+	}
+
+	if store.Len() != 0 {
+		t.Errorf("store holds %d entries, want 0", store.Len())
+	}
+}
+
+func TestMemoryRevocationStoreCleanup(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryRevocationStore()
+	store.now = func() time.Time { return now }
+
+	store.Revoke("short", now.Add(time.Minute))
+	store.Revoke("long", now.Add(time.Hour))
+	store.Revoke("already-expired", now.Add(-time.Second))
+	if store.Len() != 2 {
+		t.Fatalf("Len = %d, want 2", store.Len())
+	}
+
+	now = now.Add(2 * time.Minute)
+	if revoked, _ := store.IsRevoked("short"); revoked {
+		t.Error("expired entry still reported as revoked")
+	}
+	if removed := store.Cleanup(); removed != 1 {
+		t.Errorf("Cleanup removed %d, want 1", removed)
+	}
+	if revoked, _ := store.IsRevoked("long"); !revoked || store.Len() != 1 {
+		t.Errorf("long-lived entry lost: revoked=%t len=%d", revoked, store.Len())
+	}
+
+	// Revoke also sweeps expired entries
+	now = now.Add(2 * time.Hour)
+	store.Revoke("new", now.Add(time.Minute))
+	if store.Len() != 1 {
+		t.Errorf("Len = %d after Revoke sweep, want 1", store.Len())
+	}
+}
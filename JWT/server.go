@@ -0,0 +1,319 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"jwt-demo/claims"
+	"jwt-demo/middleware"
+	"jwt-demo/tokenerr"
+)
+
+// demoUser is an account the demo auth server accepts at /login
+type demoUser struct {
+	UserID   int
+	Password string
+	Role     string
+}
+
+// demoUsers stands in for a user database
+var demoUsers = map[string]demoUser{
+	"john_doe": {UserID: 123, Password: "password123", Role: "admin"},
+	"jane_doe": {UserID: 456, Password: "password456", Role: "user"},
+}
+
+// AuthServer exposes the token patterns from the demos over HTTP:
+//
+//	POST /login    {"username", "password"} -> access + refresh tokens
+//	POST /refresh  {"refresh_token"}        -> new access + refresh tokens
+//	GET  /me       bearer access token      -> the token's claims
+//	GET  /admin    bearer access token with role "admin"
+type AuthServer struct {
+	secret     []byte
+	issuer     string
+	audience   string
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	// slideWithin is how close to expiry an access token must be for
+	// RequireAuth to issue a replacement in X-Refreshed-Token
+	slideWithin time.Duration
+	users       map[string]demoUser
+	revoked     RevocationStore
+	families    *RefreshFamilyStore
+	now         func() time.Time
+}
+
+// NewAuthServer creates an auth server signing HS256 tokens with the
+// secret, issuer, audience and lifetimes of cfg
+func NewAuthServer(cfg *Config) *AuthServer {
+	return &AuthServer{
+		secret:      cfg.Secret,
+		issuer:      cfg.Issuer,
+		audience:    cfg.Audience,
+		accessTTL:   cfg.AccessTTL,
+		refreshTTL:  cfg.RefreshTTL,
+		slideWithin: cfg.AccessTTL / 3,
+		users:       demoUsers,
+		revoked:     NewMemoryRevocationStore(),
+		families:    NewRefreshFamilyStore(),
+		now:         time.Now,
+	}
+}
+
+// Handler returns the routes of the auth server
+func (s *AuthServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/login", allowMethod(http.MethodPost, http.HandlerFunc(s.handleLogin)))
+	mux.Handle("/refresh", allowMethod(http.MethodPost, http.HandlerFunc(s.handleRefresh)))
+	mux.Handle("/me", allowMethod(http.MethodGet, s.RequireAuth(http.HandlerFunc(s.handleMe))))
+	mux.Handle("/admin", allowMethod(http.MethodGet, s.RequireRole("admin", http.HandlerFunc(s.handleAdmin))))
+	return mux
+}
+
+// tokenResponse is returned by /login and /refresh
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+func (s *AuthServer) handleLogin(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	user, ok := s.users[req.Username]
+	if !ok || subtle.ConstantTimeCompare([]byte(user.Password), []byte(req.Password)) != 1 {
+		writeError(w, http.StatusUnauthorized, "invalid username or password")
+		return
+	}
+
+	// Each login starts a new refresh token family
+	family := claims.NewID()
+	access, err := s.sign(claims.NewAccessClaims(user.UserID, req.Username, user.Role, s.accessTTL, s.claimOptions(family)...))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "could not issue token")
+		return
+	}
+	refreshClaims := claims.NewRefreshClaims(user.UserID, req.Username, user.Role, s.refreshTTL, s.claimOptions(family)...)
+	refresh, err := s.sign(refreshClaims)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "could not issue token")
+		return
+	}
+	if err := s.families.Start(family, refreshClaims.ID, refreshClaims.ExpiresAt.Time); err != nil {
+		writeError(w, http.StatusInternalServerError, "could not issue token")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tokenResponse{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(s.accessTTL.Seconds()),
+	})
+}
+
+func (s *AuthServer) handleRefresh(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		writeError(w, http.StatusBadRequest, "refresh_token is required")
+		return
+	}
+
+	presented, err := s.parseToken(req.RefreshToken, claims.TypeRefresh)
+	if err != nil {
+		writeError(w, tokenerr.HTTPStatus(err), err.Error())
+		return
+	}
+
+	opts := s.claimOptions(presented.Family)
+	access, err := s.sign(claims.NewAccessClaims(presented.UserID, presented.Username, presented.Role, s.accessTTL, opts...))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "could not issue token")
+		return
+	}
+	refreshClaims := claims.NewRefreshClaims(presented.UserID, presented.Username, presented.Role, s.refreshTTL, opts...)
+	refresh, err := s.sign(refreshClaims)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "could not issue token")
+		return
+	}
+
+	// Consume the presented refresh token; a consumed one coming back
+	// revokes the family, including access tokens issued from it
+	if err := s.families.Rotate(presented.Family, presented.ID, refreshClaims.ID, refreshClaims.ExpiresAt.Time); err != nil {
+		writeError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tokenResponse{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(s.accessTTL.Seconds()),
+	})
+}
+
+func (s *AuthServer) handleMe(w http.ResponseWriter, r *http.Request) {
+	claims, _ := ClaimsFromContext(r.Context())
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"user_id":  claims.UserID,
+		"username": claims.Username,
+		"role":     claims.Role,
+		"expires":  claims.ExpiresAt.Time.Format(time.RFC3339),
+	})
+}
+
+func (s *AuthServer) handleAdmin(w http.ResponseWriter, r *http.Request) {
+	claims, _ := ClaimsFromContext(r.Context())
+	writeJSON(w, http.StatusOK, map[string]string{
+		"message": fmt.Sprintf("welcome to the admin area, %s", claims.Username),
+	})
+}
+
+// claimOptions issues tokens from this server's issuer and clock within
+// the refresh token family
+func (s *AuthServer) claimOptions(family string) []claims.Option {
+	opts := []claims.Option{
+		claims.WithIssuer(s.issuer),
+		claims.WithFamily(family),
+		claims.WithIssuedAt(s.now()),
+	}
+	if s.audience != "" {
+		opts = append(opts, claims.WithAudience(s.audience))
+	}
+	return opts
+}
+
+// slide issues a fresh access token for the user of an access token that is
+// about to expire, within the same refresh token family
+func (s *AuthServer) slide(c *claims.Claims) (string, error) {
+	return s.sign(claims.NewAccessClaims(c.UserID, c.Username, c.Role, s.accessTTL, s.claimOptions(c.Family)...))
+}
+
+// sign signs c with HS256
+func (s *AuthServer) sign(c *claims.Claims) (string, error) {
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, c).SignedString(s.secret)
+}
+
+// parseToken validates tokenString, checks that it is of the expected type
+// and that neither it nor its refresh token family has been revoked
+func (s *AuthServer) parseToken(tokenString, tokenType string) (*claims.Claims, error) {
+	c := &claims.Claims{}
+	validMethods := []string{jwt.SigningMethodHS256.Alg()}
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(validMethods),
+		jwt.WithIssuer(s.issuer),
+		jwt.WithTimeFunc(s.now),
+	}
+	if s.audience != "" {
+		opts = append(opts, jwt.WithAudience(s.audience))
+	}
+	token, err := jwt.ParseWithClaims(tokenString, c, strictKeyfunc(jwt.SigningMethodHS256, s.secret), opts...)
+	if err != nil {
+		return nil, tokenerr.Parsed(token, err, validMethods)
+	}
+	if err := s.checkToken(c, tokenType); err != nil {
+		return nil, tokenerr.Wrap(err)
+	}
+	return c, nil
+}
+
+// checkToken checks the type of a verified token and that neither it nor
+// its refresh token family has been revoked
+func (s *AuthServer) checkToken(c *claims.Claims, tokenType string) error {
+	if c.Type != tokenType {
+		return fmt.Errorf("expected a %s token, got %q", tokenType, c.Type)
+	}
+	if err := checkNotRevoked(s.revoked, c); err != nil {
+		return err
+	}
+	if c.Family != "" && s.families.IsRevoked(c.Family) {
+		return ErrRefreshFamilyRevoked
+	}
+	return nil
+}
+
+// ClaimsFromContext returns the claims stored by RequireAuth or RequireRole
+func ClaimsFromContext(ctx context.Context) (*claims.Claims, bool) {
+	c, _ := middleware.ClaimsFromContext(ctx)
+	userClaims, ok := c.(*claims.Claims)
+	return userClaims, ok
+}
+
+// RequireAuth validates the bearer access token and stores its claims in
+// the request context. Missing or invalid tokens get 401, headers that are
+// not "Bearer <token>" and tokens that are not JWTs get 400. Accepted
+// requests get X-Token-Expires-In, and X-Refreshed-Token with a new access
+// token once the presented one is in the last third of its lifetime.
+func (s *AuthServer) RequireAuth(next http.Handler) http.Handler {
+	return s.middleware().Handler(next)
+}
+
+// RequireRole works like RequireAuth and also rejects tokens whose role is
+// not one of roles with 403
+func (s *AuthServer) RequireRole(role string, next http.Handler) http.Handler {
+	return s.middleware(role).Handler(next)
+}
+
+// middleware accepts this server's HS256 access tokens
+func (s *AuthServer) middleware(roles ...string) *middleware.Middleware {
+	m, err := middleware.New(middleware.Options{
+		Keyfunc:      strictKeyfunc(jwt.SigningMethodHS256, s.secret),
+		ValidMethods: []string{jwt.SigningMethodHS256.Alg()},
+		Issuer:       s.issuer,
+		Audience:     s.audience,
+		NewClaims:    func() jwt.Claims { return &claims.Claims{} },
+		Validate: func(c jwt.Claims) error {
+			return s.checkToken(c.(*claims.Claims), claims.TypeAccess)
+		},
+		Role:  func(c jwt.Claims) string { return c.(*claims.Claims).Role },
+		Roles: roles,
+		Refresh: func(c jwt.Claims) (string, error) {
+			return s.slide(c.(*claims.Claims))
+		},
+		RefreshThreshold: s.slideWithin,
+		Now:              func() time.Time { return s.now() },
+	})
+	if err != nil {
+		// Only a missing Keyfunc fails, and one is always set
+		panic(err)
+	}
+	return m
+}
+
+// allowMethod answers 405 for any method other than method
+func allowMethod(method string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			w.Header().Set("Allow", method)
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"jwt-demo/middleware"
+)
+
+// testAuthServer returns a server whose clock the test controls. The clock
+// starts on a whole second, like the NumericDate claims it issues.
+func testAuthServer(t *testing.T) (*AuthServer, *FakeClock) {
+	t.Helper()
+
+	clock := NewFakeClock(time.Now().Truncate(time.Second))
+	cfg := DefaultConfig()
+	cfg.Secret = []byte(testSecret)
+	s := NewAuthServer(&cfg)
+	s.now = clock.Now
+	s.families.now = clock.Now
+	return s, clock
+}
+
+func do(t *testing.T, h http.Handler, method, path, body, authorization string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &decoded); err != nil {
+		t.Fatalf("%s %s: invalid JSON response %q: %v", method, path, rec.Body.String(), err)
+	}
+	return rec, decoded
+}
+
+func login(t *testing.T, h http.Handler, username, password string) (access, refresh string) {
+	t.Helper()
+
+	rec, body := do(t, h, http.MethodPost, "/login",
+		`{"username":"`+username+`","password":"`+password+`"}`, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("login %s: status %d, body %v", username, rec.Code, body)
+	}
+	return body["access_token"].(string), body["refresh_token"].(string)
+}
+
+func TestLogin(t *testing.T) {
+	s, _ := testAuthServer(t)
+	h := s.Handler()
+
+	rec, body := do(t, h, http.MethodPost, "/login", `{"username":"john_doe","password":"password123"}`, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %v", rec.Code, body)
+	}
+	if body["token_type"] != "Bearer" || body["expires_in"] != float64(900) {
+		t.Errorf("body = %v", body)
+	}
+
+	claims, err := s.parseToken(body["access_token"].(string), "access")
+	if err != nil {
+		t.Fatalf("access token: %v", err)
+	}
+	if claims.UserID != 123 || claims.Username != "john_doe" || claims.Role != "admin" {
+		t.Errorf("claims = %+v", claims)
+	}
+	if _, err := s.parseToken(body["refresh_token"].(string), "refresh"); err != nil {
+		t.Errorf("refresh token: %v", err)
+	}
+
+	for _, tc := range []struct {
+		body string
+		code int
+	}{
+		{`{"username":"john_doe","password":"wrong"}`, http.StatusUnauthorized},
+		{`{"username":"nobody","password":"password123"}`, http.StatusUnauthorized},
+		{`not json`, http.StatusBadRequest},
+	} {
+		if rec, _ := do(t, h, http.MethodPost, "/login", tc.body, ""); rec.Code != tc.code {
+			t.Errorf("login %s: status = %d, want %d", tc.body, rec.Code, tc.code)
+		}
+	}
+
+	if rec, _ := do(t, h, http.MethodGet, "/login", "", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET /login: status = %d", rec.Code)
+	}
+}
+
+func TestMe(t *testing.T) {
+	s, _ := testAuthServer(t)
+	h := s.Handler()
+	access, _ := login(t, h, "jane_doe", "password456")
+
+	rec, body := do(t, h, http.MethodGet, "/me", "", "Bearer "+access)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %v", rec.Code, body)
+	}
+	if body["username"] != "jane_doe" || body["role"] != "user" || body["user_id"] != float64(456) {
+		t.Errorf("body = %v", body)
+	}
+}
+
+func TestAuthServerAudience(t *testing.T) {
+	s, _ := testAuthServer(t)
+	s.audience = "web-app"
+	access, _ := login(t, s.Handler(), "jane_doe", "password456")
+
+	if rec, body := do(t, s.Handler(), http.MethodGet, "/me", "", "Bearer "+access); rec.Code != http.StatusOK {
+		t.Errorf("same audience: status = %d, body %v", rec.Code, body)
+	}
+
+	// Same secret and issuer, but the token is meant for another service
+	other, _ := testAuthServer(t)
+	other.audience = "billing"
+	if rec, _ := do(t, other.Handler(), http.MethodGet, "/me", "", "Bearer "+access); rec.Code != http.StatusUnauthorized {
+		t.Errorf("other audience: status = %d, want 401", rec.Code)
+	}
+}
+
+func TestExpiredAccessToken(t *testing.T) {
+	s, clock := testAuthServer(t)
+	h := s.Handler()
+	access, _ := login(t, h, "jane_doe", "password456")
+
+	clock.Advance(16 * time.Minute)
+
+	rec, body := do(t, h, http.MethodGet, "/me", "", "Bearer "+access)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401", rec.Code)
+	}
+	if !strings.Contains(body["error"].(string), "expired") {
+		t.Errorf("error = %v", body["error"])
+	}
+	if got := rec.Header().Get("WWW-Authenticate"); got != `Bearer error="invalid_token"` {
+		t.Errorf("WWW-Authenticate = %q", got)
+	}
+}
+
+func TestSlidingSession(t *testing.T) {
+	s, clock := testAuthServer(t)
+	h := s.Handler()
+	access, _ := login(t, h, "john_doe", "password123")
+
+	rec, _ := do(t, h, http.MethodGet, "/me", "", "Bearer "+access)
+	if got := rec.Header().Get(middleware.ExpiresInHeader); got != "900" {
+		t.Errorf("fresh token: %s = %q, want 900", middleware.ExpiresInHeader, got)
+	}
+	if got := rec.Header().Get(middleware.RefreshedTokenHeader); got != "" {
+		t.Errorf("fresh token was refreshed: %q", got)
+	}
+
+	// A third of the 15m lifetime left: the server slides the session
+	clock.Advance(10 * time.Minute)
+	rec, _ = do(t, h, http.MethodGet, "/me", "", "Bearer "+access)
+	if got := rec.Header().Get(middleware.ExpiresInHeader); got != "300" {
+		t.Errorf("after 10m: %s = %q, want 300", middleware.ExpiresInHeader, got)
+	}
+	refreshed := rec.Header().Get(middleware.RefreshedTokenHeader)
+	if refreshed == "" {
+		t.Fatalf("no %s after 10m", middleware.RefreshedTokenHeader)
+	}
+
+	// The original token expires; the refreshed one lives on with its own
+	// 15 minutes and the same claims
+	clock.Advance(6 * time.Minute)
+	if rec, _ := do(t, h, http.MethodGet, "/me", "", "Bearer "+access); rec.Code != http.StatusUnauthorized {
+		t.Errorf("original token after 16m: status %d, want 401", rec.Code)
+	}
+	rec, body := do(t, h, http.MethodGet, "/me", "", "Bearer "+refreshed)
+	if rec.Code != http.StatusOK || body["username"] != "john_doe" {
+		t.Fatalf("refreshed token: status %d, body %v", rec.Code, body)
+	}
+	if got := rec.Header().Get(middleware.ExpiresInHeader); got != "540" {
+		t.Errorf("refreshed token: %s = %q, want 540", middleware.ExpiresInHeader, got)
+	}
+}
+
+func TestRefreshFlow(t *testing.T) {
+	s, clock := testAuthServer(t)
+	h := s.Handler()
+	access, refresh := login(t, h, "jane_doe", "password456")
+
+	// The access token expires, the refresh token is still good
+	clock.Advance(time.Hour)
+	if rec, _ := do(t, h, http.MethodGet, "/me", "", "Bearer "+access); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expired access token: status = %d", rec.Code)
+	}
+
+	rec, body := do(t, h, http.MethodPost, "/refresh", `{"refresh_token":"`+refresh+`"}`, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("refresh: status = %d, body %v", rec.Code, body)
+	}
+	newAccess := body["access_token"].(string)
+	if body["refresh_token"] == nil || body["refresh_token"] == refresh {
+		t.Errorf("refresh did not rotate the refresh token: %v", body["refresh_token"])
+	}
+	if rec, body := do(t, h, http.MethodGet, "/me", "", "Bearer "+newAccess); rec.Code != http.StatusOK {
+		t.Fatalf("/me with refreshed token: status = %d, body %v", rec.Code, body)
+	}
+
+	// Token types cannot be swapped
+	if rec, _ := do(t, h, http.MethodPost, "/refresh", `{"refresh_token":"`+newAccess+`"}`, ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("access token at /refresh: status = %d", rec.Code)
+	}
+	if rec, _ := do(t, h, http.MethodGet, "/me", "", "Bearer "+refresh); rec.Code != http.StatusUnauthorized {
+		t.Errorf("refresh token at /me: status = %d", rec.Code)
+	}
+	if rec, _ := do(t, h, http.MethodPost, "/refresh", `{}`, ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("empty refresh body: status = %d", rec.Code)
+	}
+
+	// The refresh token itself expires after 7 days
+	clock.Advance(8 * 24 * time.Hour)
+	if rec, _ := do(t, h, http.MethodPost, "/refresh", `{"refresh_token":"`+refresh+`"}`, ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expired refresh token: status = %d", rec.Code)
+	}
+}
+
+func TestAdminRequiresRole(t *testing.T) {
+	s, _ := testAuthServer(t)
+	h := s.Handler()
+
+	userAccess, _ := login(t, h, "jane_doe", "password456")
+	if rec, body := do(t, h, http.MethodGet, "/admin", "", "Bearer "+userAccess); rec.Code != http.StatusForbidden {
+		t.Errorf("user at /admin: status = %d, body %v", rec.Code, body)
+	}
+
+	adminAccess, _ := login(t, h, "john_doe", "password123")
+	rec, body := do(t, h, http.MethodGet, "/admin", "", "Bearer "+adminAccess)
+	if rec.Code != http.StatusOK {
+		t.Errorf("admin at /admin: status = %d, body %v", rec.Code, body)
+	}
+}
+
+func TestMalformedAuthorizationHeaders(t *testing.T) {
+	s, _ := testAuthServer(t)
+	h := s.Handler()
+	access, _ := login(t, h, "jane_doe", "password456")
+
+	tests := []struct {
+		name   string
+		header string
+		code   int
+	}{
+		{"missing", "", http.StatusUnauthorized},
+		{"wrong scheme", "Basic am9objpwYXNz", http.StatusBadRequest},
+		{"scheme only", "Bearer", http.StatusBadRequest},
+		{"empty token", "Bearer   ", http.StatusBadRequest},
+		{"extra parts", "Bearer " + access + " extra", http.StatusBadRequest},
+		{"token without scheme", access, http.StatusBadRequest},
+		{"garbage token", "Bearer not.a.jwt", http.StatusBadRequest},
+		{"lowercase scheme", "bearer " + access, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, body := do(t, h, http.MethodGet, "/me", "", tt.header)
+			if rec.Code != tt.code {
+				t.Errorf("status = %d, want %d (body %v)", rec.Code, tt.code, body)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Measurement summarizes the timed runs of one operation
+type Measurement struct {
+	Name       string
+	Iterations int
+	Total      time.Duration
+	Min, Max   time.Duration
+}
+
+// OpsPerSecond is the throughput over Total, or 0 when nothing was timed
+func (m Measurement) OpsPerSecond() float64 {
+	if m.Total <= 0 {
+		return 0
+	}
+	return float64(m.Iterations) / m.Total.Seconds()
+}
+
+// AverageLatency is the mean time of one run
+func (m Measurement) AverageLatency() time.Duration {
+	if m.Iterations == 0 {
+		return 0
+	}
+	return m.Total / time.Duration(m.Iterations)
+}
+
+// Measure runs op n times, timing each run with now (time.Now outside
+// tests). It stops at the first error.
+func Measure(name string, n int, now func() time.Time, op func() error) (Measurement, error) {
+	if n <= 0 {
+		return Measurement{}, fmt.Errorf("measure %s: iterations must be positive, got %d", name, n)
+	}
+
+	m := Measurement{Name: name}
+	for i := 0; i < n; i++ {
+		start := now()
+		if err := op(); err != nil {
+			return m, fmt.Errorf("measure %s: run %d: %w", name, i+1, err)
+		}
+		elapsed := now().Sub(start)
+
+		m.Iterations++
+		m.Total += elapsed
+		if m.Iterations == 1 || elapsed < m.Min {
+			m.Min = elapsed
+		}
+		if elapsed > m.Max {
+			m.Max = elapsed
+		}
+	}
+	return m, nil
+}
+
+// writeMeasurements prints measurements as an aligned table
+func writeMeasurements(w io.Writer, measurements []Measurement) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "Operation\tOps/sec\tAvg latency\tMin\tMax\t")
+	for _, m := range measurements {
+		fmt.Fprintf(tw, "%s\t%.0f\t%s\t%s\t%s\t\n", m.Name, m.OpsPerSecond(),
+			roundLatency(m.AverageLatency()), roundLatency(m.Min), roundLatency(m.Max))
+	}
+	return tw.Flush()
+}
+
+// roundLatency keeps about three significant digits, e.g. 1.02ms or 71.8µs
+func roundLatency(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Second:
+		return d.Round(10 * time.Millisecond)
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond)
+	case d >= 100*time.Microsecond:
+		return d.Round(time.Microsecond)
+	}
+	return d.Round(100 * time.Nanosecond)
+}
+
+// throughputAlgorithm is an algorithm with the demo's key material
+type throughputAlgorithm struct {
+	method  jwt.SigningMethod
+	key     interface{}
+	keyfunc jwt.Keyfunc
+}
+
+// throughputAlgorithms returns the compared algorithms with the current
+// keys, which --keys may have replaced
+func throughputAlgorithms() []throughputAlgorithm {
+	return []throughputAlgorithm{
+		{jwt.SigningMethodHS256, hmacSecret, hmacKeyfunc},
+		{jwt.SigningMethodRS256, privateKey, rsaKeyfunc},
+		{jwt.SigningMethodES256, ecdsaPrivateKey, ecdsaKeyfunc},
+		{jwt.SigningMethodEdDSA, ed25519PrivateKey, ed25519Keyfunc},
+	}
+}
+
+// throughputClaims are the claims signed by the demo and the benchmarks
+func throughputClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub":  "1234567890",
+		"role": "user",
+		"iat":  time.Now().Unix(),
+		"exp":  time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+// measureAlgorithm times n signatures and n verifications with alg
+func measureAlgorithm(alg throughputAlgorithm, n int, now func() time.Time) ([]Measurement, error) {
+	claims := throughputClaims()
+	var tokenString string
+
+	sign, err := Measure(alg.method.Alg()+" sign", n, now, func() error {
+		var err error
+		tokenString, err = jwt.NewWithClaims(alg.method, claims).SignedString(alg.key)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	verify, err := Measure(alg.method.Alg()+" verify", n, now, func() error {
+		token, err := jwt.Parse(tokenString, alg.keyfunc)
+		if err == nil && !token.Valid {
+			err = errors.New("token not valid")
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return []Measurement{sign, verify}, nil
+}
+
+// Demo 13: compare the cost of signing and verifying per algorithm
+func throughputDemo(n int) {
+	fmt.Printf("Timing %d signatures and verifications per algorithm...\n", n)
+
+	var measurements []Measurement
+	for _, alg := range throughputAlgorithms() {
+		results, err := measureAlgorithm(alg, n, time.Now)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return
+		}
+		measurements = append(measurements, results...)
+	}
+
+	// Decoding without verifying skips the crypto entirely; never trust
+	// what it returns
+	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodRS256, throughputClaims()).SignedString(privateKey)
+	if err != nil {
+		log.Fatal("Error signing token:", err)
+	}
+	parser := jwt.NewParser()
+	decode, err := Measure("decode only", n, time.Now, func() error {
+		_, _, err := parser.ParseUnverified(tokenString, jwt.MapClaims{})
+		return err
+	})
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	measurements = append(measurements, decode)
+
+	if err := writeMeasurements(os.Stdout, measurements); err != nil {
+		fmt.Printf("❌ %v\n", err)
+	}
+	fmt.Println("Run `go test -bench . -run ^$` for steadier numbers")
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// steppingNow returns a clock for Measure whose runs take the given
+// durations in turn
+func steppingNow(durations ...time.Duration) func() time.Time {
+	clock := NewFakeClock(time.Date(2024, 12, 25, 12, 0, 0, 0, time.UTC))
+	calls := 0
+	return func() time.Time {
+		// Measure reads the clock before and after every run
+		if calls%2 == 1 {
+			clock.Advance(durations[calls/2])
+		}
+		calls++
+		return clock.Now()
+	}
+}
+
+func TestMeasure(t *testing.T) {
+	runs := 0
+	m, err := Measure("op", 3, steppingNow(2*time.Millisecond, 6*time.Millisecond, 4*time.Millisecond), func() error {
+		runs++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Measurement{Name: "op", Iterations: 3, Total: 12 * time.Millisecond, Min: 2 * time.Millisecond, Max: 6 * time.Millisecond}
+	if m != want || runs != 3 {
+		t.Fatalf("Measure = %+v after %d runs, want %+v", m, runs, want)
+	}
+	if got := m.AverageLatency(); got != 4*time.Millisecond {
+		t.Errorf("AverageLatency = %s, want 4ms", got)
+	}
+	if got := m.OpsPerSecond(); got != 250 {
+		t.Errorf("OpsPerSecond = %v, want 250", got)
+	}
+}
+
+func TestMeasureEdgeCases(t *testing.T) {
+	t.Run("single run", func(t *testing.T) {
+		m, err := Measure("op", 1, steppingNow(time.Second), func() error { return nil })
+		if err != nil || m.Min != time.Second || m.Max != time.Second || m.OpsPerSecond() != 1 {
+			t.Errorf("Measure = %+v, %v", m, err)
+		}
+	})
+
+	t.Run("zero duration", func(t *testing.T) {
+		m, err := Measure("op", 2, steppingNow(0, 0), func() error { return nil })
+		if err != nil || m.OpsPerSecond() != 0 || m.AverageLatency() != 0 {
+			t.Errorf("Measure = %+v, %v; ops/sec %v", m, err, m.OpsPerSecond())
+		}
+	})
+
+	t.Run("empty measurement", func(t *testing.T) {
+		var m Measurement
+		if m.OpsPerSecond() != 0 || m.AverageLatency() != 0 {
+			t.Errorf("zero Measurement: %v ops/sec, %s", m.OpsPerSecond(), m.AverageLatency())
+		}
+	})
+
+	t.Run("no iterations", func(t *testing.T) {
+		if _, err := Measure("op", 0, time.Now, func() error { return nil }); err == nil {
+			t.Error("Measure with n = 0 succeeded")
+		}
+	})
+
+	t.Run("stops at the first error", func(t *testing.T) {
+		boom := errors.New("boom")
+		runs := 0
+		m, err := Measure("op", 5, steppingNow(time.Millisecond, time.Millisecond, time.Millisecond), func() error {
+			runs++
+			if runs == 3 {
+				return boom
+			}
+			return nil
+		})
+		if !errors.Is(err, boom) || !strings.Contains(err.Error(), "run 3") {
+			t.Errorf("err = %v", err)
+		}
+		if runs != 3 || m.Iterations != 2 || m.Total != 2*time.Millisecond {
+			t.Errorf("after error: %d runs, %+v", runs, m)
+		}
+	})
+}
+
+func TestWriteMeasurements(t *testing.T) {
+	var buf bytes.Buffer
+	err := writeMeasurements(&buf, []Measurement{
+		{Name: "HS256 sign", Iterations: 4, Total: 10 * time.Microsecond, Min: 2 * time.Microsecond, Max: 3 * time.Microsecond},
+		{Name: "RS256 sign", Iterations: 2, Total: 2040 * time.Microsecond, Min: 1012345 * time.Nanosecond, Max: 1027654 * time.Nanosecond},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("table:\n%s", buf.String())
+	}
+	for i, want := range [][]string{
+		{"Operation", "Ops/sec", "Avg", "latency", "Min", "Max"},
+		{"HS256", "sign", "400000", "2.5µs", "2µs", "3µs"},
+		{"RS256", "sign", "980", "1.02ms", "1.01ms", "1.03ms"},
+	} {
+		if got := strings.Fields(lines[i]); strings.Join(got, " ") != strings.Join(want, " ") {
+			t.Errorf("line %d = %q, want fields %q", i, lines[i], want)
+		}
+	}
+}
+
+func TestThroughputAlgorithms(t *testing.T) {
+	for _, alg := range throughputAlgorithms() {
+		measurements, err := measureAlgorithm(alg, 2, time.Now)
+		if err != nil {
+			t.Fatalf("%s: %v", alg.method.Alg(), err)
+		}
+		if len(measurements) != 2 || measurements[0].Iterations != 2 || measurements[1].Iterations != 2 {
+			t.Errorf("%s: %+v", alg.method.Alg(), measurements)
+		}
+	}
+}
+
+func BenchmarkSign(b *testing.B) {
+	for _, alg := range throughputAlgorithms() {
+		b.Run(alg.method.Alg(), func(b *testing.B) {
+			claims := throughputClaims()
+			for i := 0; i < b.N; i++ {
+				if _, err := jwt.NewWithClaims(alg.method, claims).SignedString(alg.key); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkVerify(b *testing.B) {
+	for _, alg := range throughputAlgorithms() {
+		b.Run(alg.method.Alg(), func(b *testing.B) {
+			tokenString, err := jwt.NewWithClaims(alg.method, throughputClaims()).SignedString(alg.key)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := jwt.Parse(tokenString, alg.keyfunc); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkParseUnverified shows how little decoding costs without the
+// signature check
+func BenchmarkParseUnverified(b *testing.B) {
+	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodRS256, throughputClaims()).SignedString(privateKey)
+	if err != nil {
+		b.Fatal(err)
+	}
+	parser := jwt.NewParser()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := parser.ParseUnverified(tokenString, jwt.MapClaims{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+// Package tokenerr sorts token validation failures into a few classes, so
+// callers can tell an expired token from a forged one without matching
+// golang-jwt's error strings.
+package tokenerr
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/golang-jwt/jwt/v5"
+	"jwt-demo/claims"
+)
+
+// Error classes returned by Classify
+var (
+	ErrMalformed      = errors.New("malformed token")
+	ErrBadSignature   = errors.New("invalid signature")
+	ErrWrongAlgorithm = errors.New("signing algorithm not allowed")
+	ErrExpired        = errors.New("token has expired")
+	ErrNotYetValid    = errors.New("token is not valid yet")
+	ErrRevoked        = errors.New("token has been revoked")
+	ErrInvalidClaims  = errors.New("invalid claims")
+)
+
+// Classify returns the class of err, or nil when err is nil or not a token
+// validation error. A disallowed algorithm is only recognized when err
+// wraps ErrWrongAlgorithm; see Parsed.
+func Classify(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, ErrMalformed), errors.Is(err, jwt.ErrTokenMalformed):
+		return ErrMalformed
+	// Before the signature: jwt reports a disallowed alg as an invalid
+	// signature, and keyfunc failures as unverifiable
+	case errors.Is(err, ErrWrongAlgorithm):
+		return ErrWrongAlgorithm
+	case errors.Is(err, ErrBadSignature), errors.Is(err, jwt.ErrTokenSignatureInvalid),
+		errors.Is(err, jwt.ErrTokenUnverifiable):
+		return ErrBadSignature
+	case errors.Is(err, ErrRevoked):
+		return ErrRevoked
+	case errors.Is(err, ErrExpired), errors.Is(err, jwt.ErrTokenExpired), errors.Is(err, claims.ErrExpired):
+		return ErrExpired
+	case errors.Is(err, ErrNotYetValid), errors.Is(err, jwt.ErrTokenNotValidYet),
+		errors.Is(err, jwt.ErrTokenUsedBeforeIssued):
+		return ErrNotYetValid
+	case errors.Is(err, ErrInvalidClaims), errors.Is(err, jwt.ErrTokenInvalidClaims):
+		return ErrInvalidClaims
+	}
+	return nil
+}
+
+// Error is a classified token error. errors.Is matches both Class and the
+// original error.
+type Error struct {
+	Class error
+	Err   error
+}
+
+// Error returns the class message, followed by the original error when the
+// class alone does not say what was wrong
+func (e *Error) Error() string {
+	switch {
+	case e.Class == ErrExpired, e.Class == ErrNotYetValid, e.Class == ErrRevoked:
+		return e.Class.Error()
+	case errors.Is(e.Err, e.Class):
+		// e.g. a keyfunc error that already names the class
+		return e.Err.Error()
+	}
+	return e.Class.Error() + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() []error { return []error{e.Class, e.Err} }
+
+// Wrap returns err as an *Error, or unchanged when it is nil, already
+// wrapped or matches no class
+func Wrap(err error) error {
+	var classified *Error
+	if errors.As(err, &classified) {
+		return err
+	}
+	class := Classify(err)
+	if class == nil {
+		return err
+	}
+	return &Error{Class: class, Err: err}
+}
+
+// Parsed wraps the error of parsing token with a parser restricted to
+// validMethods. jwt reports any other alg as an invalid signature; Parsed
+// classifies it as ErrWrongAlgorithm instead.
+func Parsed(token *jwt.Token, err error, validMethods []string) error {
+	if err == nil {
+		return nil
+	}
+	if token != nil && token.Method != nil && len(validMethods) > 0 && !contains(validMethods, token.Method.Alg()) {
+		return &Error{Class: ErrWrongAlgorithm, Err: fmt.Errorf("alg %q: %w", token.Method.Alg(), err)}
+	}
+	return Wrap(err)
+}
+
+// HTTPStatus is the status for rejecting a request over err: 400 when the
+// token is not a JWT at all, 401 otherwise
+func HTTPStatus(err error) int {
+	if Classify(err) == ErrMalformed {
+		return http.StatusBadRequest
+	}
+	return http.StatusUnauthorized
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,164 @@
+package tokenerr
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"jwt-demo/claims"
+)
+
+var (
+	testSecret = []byte("tokenerr-test-secret-0123456789ab")
+	testNow    = time.Date(2024, 12, 25, 12, 0, 0, 0, time.UTC)
+)
+
+func keyfunc(*jwt.Token) (interface{}, error) { return testSecret, nil }
+
+func sign(t *testing.T, method jwt.SigningMethod, edit func(jwt.MapClaims)) string {
+	t.Helper()
+
+	c := jwt.MapClaims{
+		"sub": "123",
+		"aud": "web-app",
+		"iat": testNow.Unix(),
+		"exp": testNow.Add(time.Hour).Unix(),
+	}
+	if edit != nil {
+		edit(c)
+	}
+	key := interface{}(testSecret)
+	if method == jwt.SigningMethodNone {
+		key = jwt.UnsafeAllowNoneSignatureType
+	}
+	tokenString, err := jwt.NewWithClaims(method, c).SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tokenString
+}
+
+// parse verifies like the demos do: HS256 only, audience and exp required
+func parse(tokenString string, keyfunc jwt.Keyfunc) error {
+	validMethods := []string{"HS256"}
+	token, err := jwt.NewParser(
+		jwt.WithValidMethods(validMethods),
+		jwt.WithAudience("web-app"),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithTimeFunc(func() time.Time { return testNow }),
+	).Parse(tokenString, keyfunc)
+	return Parsed(token, err, validMethods)
+}
+
+func TestClassify(t *testing.T) {
+	// Like strictKeyfunc in the demo, pinned to an alg the token lacks
+	hs512Only := func(token *jwt.Token) (interface{}, error) {
+		if token.Method.Alg() != "HS512" {
+			return nil, fmt.Errorf("%w: %s", ErrWrongAlgorithm, token.Method.Alg())
+		}
+		return testSecret, nil
+	}
+	valid := sign(t, jwt.SigningMethodHS256, nil)
+
+	tests := []struct {
+		name    string
+		token   string
+		keyfunc jwt.Keyfunc
+		want    error
+	}{
+		{"valid", valid, keyfunc, nil},
+		{"not a JWT", "not.a.jwt", keyfunc, ErrMalformed},
+		{"two segments", "abc.def", keyfunc, ErrMalformed},
+		{"tampered signature", valid[:len(valid)-4] + "AAAA", keyfunc, ErrBadSignature},
+		{"other secret", valid, func(*jwt.Token) (interface{}, error) { return []byte("another-secret-another-secret-00"), nil }, ErrBadSignature},
+		{"keyfunc failure", valid, func(*jwt.Token) (interface{}, error) { return nil, errors.New("unknown kid") }, ErrBadSignature},
+		{"alg outside ValidMethods", sign(t, jwt.SigningMethodHS384, nil), keyfunc, ErrWrongAlgorithm},
+		{"alg none", sign(t, jwt.SigningMethodNone, nil), keyfunc, ErrWrongAlgorithm},
+		{"alg rejected by keyfunc", valid, hs512Only, ErrWrongAlgorithm},
+		{"expired", sign(t, jwt.SigningMethodHS256, func(c jwt.MapClaims) { c["exp"] = testNow.Add(-time.Second).Unix() }), keyfunc, ErrExpired},
+		{"nbf in the future", sign(t, jwt.SigningMethodHS256, func(c jwt.MapClaims) { c["nbf"] = testNow.Add(time.Minute).Unix() }), keyfunc, ErrNotYetValid},
+		{"iat in the future", sign(t, jwt.SigningMethodHS256, func(c jwt.MapClaims) { c["iat"] = testNow.Add(time.Minute).Unix() }), keyfunc, ErrNotYetValid},
+		{"wrong audience", sign(t, jwt.SigningMethodHS256, func(c jwt.MapClaims) { c["aud"] = "billing" }), keyfunc, ErrInvalidClaims},
+		{"no exp", sign(t, jwt.SigningMethodHS256, func(c jwt.MapClaims) { delete(c, "exp") }), keyfunc, ErrInvalidClaims},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := parse(tt.token, tt.keyfunc)
+			if got := Classify(err); got != tt.want {
+				t.Errorf("Classify(%v) = %v, want %v", err, got, tt.want)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("errors.Is(%v, %v) = false", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassifyOtherErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"nil", nil, nil},
+		{"unrelated", errors.New("database down"), nil},
+		{"revoked", fmt.Errorf("check: %w", ErrRevoked), ErrRevoked},
+		{"claims.TimeToExpiry", claims.ErrExpired, ErrExpired},
+		{"already classified", &Error{Class: ErrBadSignature, Err: errors.New("x")}, ErrBadSignature},
+	}
+	for _, tt := range tests {
+		if got := Classify(tt.err); got != tt.want {
+			t.Errorf("%s: Classify = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	if Wrap(nil) != nil {
+		t.Error("Wrap(nil) != nil")
+	}
+	unrelated := errors.New("database down")
+	if Wrap(unrelated) != unrelated {
+		t.Error("unclassified error was wrapped")
+	}
+
+	expired := parse(sign(t, jwt.SigningMethodHS256, func(c jwt.MapClaims) { c["exp"] = testNow.Add(-time.Hour).Unix() }), keyfunc)
+	if expired.Error() != "token has expired" {
+		t.Errorf("expired message = %q", expired)
+	}
+	if !errors.Is(expired, jwt.ErrTokenExpired) {
+		t.Error("wrapped error lost the jwt error")
+	}
+	if Wrap(expired) != expired {
+		t.Error("Wrap wrapped an *Error again")
+	}
+
+	audience := parse(sign(t, jwt.SigningMethodHS256, func(c jwt.MapClaims) { c["aud"] = "billing" }), keyfunc)
+	if msg := audience.Error(); !strings.HasPrefix(msg, "invalid claims: ") || !strings.Contains(msg, "audience") {
+		t.Errorf("audience message = %q", msg)
+	}
+}
+
+func TestHTTPStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{ErrMalformed, http.StatusBadRequest},
+		{Wrap(fmt.Errorf("%w: bad base64", jwt.ErrTokenMalformed)), http.StatusBadRequest},
+		{ErrExpired, http.StatusUnauthorized},
+		{ErrBadSignature, http.StatusUnauthorized},
+		{ErrRevoked, http.StatusUnauthorized},
+		{errors.New("wrong token type"), http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		if got := HTTPStatus(tt.err); got != tt.want {
+			t.Errorf("HTTPStatus(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"jwt-demo/tokenerr"
+)
+
+// ErrAlgorithmNotAllowed is returned when a token's alg header is not in
+// the verifier's ValidMethods. It is wrapped together with the
+// jwt.ErrTokenSignatureInvalid reported by the parser.
+var ErrAlgorithmNotAllowed = tokenerr.ErrWrongAlgorithm
+
+// VerifierOptions configures NewVerifier
+type VerifierOptions struct {
+	// Issuer and Audience are required; tokens must carry matching
+	// iss and aud claims
+	Issuer   string
+	Audience string
+
+	// ValidMethods lists the accepted alg values, e.g. []string{"HS256"}
+	ValidMethods []string
+
+	// Leeway tolerates clock skew when checking exp, nbf and iat
+	Leeway time.Duration
+
+	// Keyfunc supplies the verification key
+	Keyfunc jwt.Keyfunc
+
+	// Now overrides the clock, mainly for tests
+	Now func() time.Time
+}
+
+// Verifier parses tokens with a fixed set of validation rules
+type Verifier struct {
+	parser       *jwt.Parser
+	keyfunc      jwt.Keyfunc
+	validMethods []string
+}
+
+// NewVerifier builds a Verifier whose parser requires the configured
+// issuer and audience, an exp claim, one of ValidMethods, and honours
+// Leeway for every time-based claim
+func NewVerifier(opts VerifierOptions) (*Verifier, error) {
+	if opts.Issuer == "" {
+		return nil, errors.New("verifier: issuer is required")
+	}
+	if opts.Audience == "" {
+		return nil, errors.New("verifier: audience is required")
+	}
+	if len(opts.ValidMethods) == 0 {
+		return nil, errors.New("verifier: at least one valid method is required")
+	}
+	for _, alg := range opts.ValidMethods {
+		if alg == "none" {
+			return nil, errors.New(`verifier: "none" cannot be a valid method`)
+		}
+	}
+	if opts.Keyfunc == nil {
+		return nil, errors.New("verifier: keyfunc is required")
+	}
+	if opts.Leeway < 0 {
+		return nil, fmt.Errorf("verifier: negative leeway %s", opts.Leeway)
+	}
+
+	parserOpts := []jwt.ParserOption{
+		jwt.WithIssuer(opts.Issuer),
+		jwt.WithAudience(opts.Audience),
+		jwt.WithValidMethods(opts.ValidMethods),
+		jwt.WithLeeway(opts.Leeway),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	}
+	if opts.Now != nil {
+		parserOpts = append(parserOpts, jwt.WithTimeFunc(opts.Now))
+	}
+
+	return &Verifier{
+		parser:       jwt.NewParser(parserOpts...),
+		keyfunc:      opts.Keyfunc,
+		validMethods: append([]string(nil), opts.ValidMethods...),
+	}, nil
+}
+
+// Verify parses and validates tokenString into claims. Errors are
+// *tokenerr.Error values, so errors.Is matches both the tokenerr class and
+// the jwt error.
+func (v *Verifier) Verify(tokenString string, claims jwt.Claims) (*jwt.Token, error) {
+	token, err := v.parser.ParseWithClaims(tokenString, claims, v.keyfunc)
+	return token, tokenerr.Parsed(token, err, v.validMethods)
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func testVerifier(t *testing.T, now time.Time, leeway time.Duration) *Verifier {
+	t.Helper()
+
+	v, err := NewVerifier(VerifierOptions{
+		Issuer:       "jwt-demo-app",
+		Audience:     "jwt-demo-api",
+		ValidMethods: []string{"HS256"},
+		Leeway:       leeway,
+		Keyfunc:      hmacKeyfunc,
+		Now:          func() time.Time { return now },
+	})
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	return v
+}
+
+func verifierClaims(now time.Time) jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub": "1234567890",
+		"iss": "jwt-demo-app",
+		"aud": "jwt-demo-api",
+		"iat": now.Unix(),
+		"exp": now.Add(time.Hour).Unix(),
+	}
+}
+
+func TestVerifierRejections(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+
+	noneToken, err := jwt.NewWithClaims(jwt.SigningMethodNone, verifierClaims(now)).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsToken, err := jwt.NewWithClaims(jwt.SigningMethodRS256, verifierClaims(now)).SignedString(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"wrong audience", signHMAC(t, with(verifierClaims(now), "aud", "billing-api")), jwt.ErrTokenInvalidAudience},
+		{"missing audience", signHMAC(t, without(verifierClaims(now), "aud")), jwt.ErrTokenRequiredClaimMissing},
+		{"wrong issuer", signHMAC(t, with(verifierClaims(now), "iss", "evil-issuer")), jwt.ErrTokenInvalidIssuer},
+		{"missing expiry", signHMAC(t, without(verifierClaims(now), "exp")), jwt.ErrTokenRequiredClaimMissing},
+		{"expired", signHMAC(t, with(verifierClaims(now), "exp", now.Add(-time.Second).Unix())), jwt.ErrTokenExpired},
+		{"not valid yet", signHMAC(t, with(verifierClaims(now), "nbf", now.Add(30*time.Second).Unix())), jwt.ErrTokenNotValidYet},
+		{"issued in the future", signHMAC(t, with(verifierClaims(now), "iat", now.Add(30*time.Second).Unix())), jwt.ErrTokenUsedBeforeIssued},
+		{`alg "none"`, noneToken, ErrAlgorithmNotAllowed},
+		{"RS256 not allowed", rsToken, ErrAlgorithmNotAllowed},
+	}
+
+	v := testVerifier(t, now, 0)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := v.Verify(tt.token, jwt.MapClaims{})
+			if !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	t.Run("algorithm errors still wrap the parser error", func(t *testing.T) {
+		_, err := v.Verify(noneToken, jwt.MapClaims{})
+		if !errors.Is(err, jwt.ErrTokenSignatureInvalid) {
+			t.Errorf("err = %v, want it to wrap %v", err, jwt.ErrTokenSignatureInvalid)
+		}
+	})
+}
+
+func TestVerifierLeeway(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+
+	// Issued by a server whose clock runs 30 seconds fast
+	early := verifierClaims(now)
+	early["iat"] = now.Add(30 * time.Second).Unix()
+	early["nbf"] = now.Add(30 * time.Second).Unix()
+	token := signHMAC(t, early)
+
+	if _, err := testVerifier(t, now, 0).Verify(token, jwt.MapClaims{}); !errors.Is(err, jwt.ErrTokenNotValidYet) {
+		t.Errorf("without leeway: err = %v, want %v", err, jwt.ErrTokenNotValidYet)
+	}
+	if _, err := testVerifier(t, now, 29*time.Second).Verify(token, jwt.MapClaims{}); !errors.Is(err, jwt.ErrTokenNotValidYet) {
+		t.Errorf("29s leeway: err = %v, want %v", err, jwt.ErrTokenNotValidYet)
+	}
+	if _, err := testVerifier(t, now, time.Minute).Verify(token, jwt.MapClaims{}); err != nil {
+		t.Errorf("1m leeway: %v", err)
+	}
+
+	// Leeway applies to exp as well
+	expired := signHMAC(t, with(verifierClaims(now), "exp", now.Add(-30*time.Second).Unix()))
+	if _, err := testVerifier(t, now, time.Minute).Verify(expired, jwt.MapClaims{}); err != nil {
+		t.Errorf("expired within leeway: %v", err)
+	}
+}
+
+func TestNewVerifierValidatesOptions(t *testing.T) {
+	valid := VerifierOptions{
+		Issuer:       "jwt-demo-app",
+		Audience:     "jwt-demo-api",
+		ValidMethods: []string{"HS256"},
+		Keyfunc:      hmacKeyfunc,
+	}
+	if _, err := NewVerifier(valid); err != nil {
+		t.Fatalf("valid options: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*VerifierOptions)
+	}{
+		{"no issuer", func(o *VerifierOptions) { o.Issuer = "" }},
+		{"no audience", func(o *VerifierOptions) { o.Audience = "" }},
+		{"no methods", func(o *VerifierOptions) { o.ValidMethods = nil }},
+		{"none allowed", func(o *VerifierOptions) { o.ValidMethods = []string{"HS256", "none"} }},
+		{"no keyfunc", func(o *VerifierOptions) { o.Keyfunc = nil }},
+		{"negative leeway", func(o *VerifierOptions) { o.Leeway = -time.Second }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := valid
+			tt.modify(&opts)
+			if _, err := NewVerifier(opts); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func with(claims jwt.MapClaims, key string, value interface{}) jwt.MapClaims {
+	claims[key] = value
+	return claims
+}
+
+func without(claims jwt.MapClaims, key string) jwt.MapClaims {
+	delete(claims, key)
+	return claims
+}
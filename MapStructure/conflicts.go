@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// remainKey stands in for ",remain" fields when looking for conflicts; only
+// one catch-all map per level makes sense.
+const remainKey = "(remain)"
+
+// TagConflict is an input key claimed by more than one struct field.
+type TagConflict struct {
+	Key    string   // dotted key path, e.g. "server.id"
+	Fields []string // Go fields claiming it, e.g. "ID", "AuditFields.ID"
+}
+
+// TagConflictError lists every key claimed by more than one field.
+type TagConflictError struct {
+	Conflicts []TagConflict
+}
+
+func (e *TagConflictError) Error() string {
+	parts := make([]string, len(e.Conflicts))
+	for i, c := range e.Conflicts {
+		parts[i] = fmt.Sprintf("%s (%s)", c.Key, strings.Join(c.Fields, ", "))
+	}
+	return "conflicting field keys: " + strings.Join(parts, "; ")
+}
+
+// DetectTagConflicts walks a struct type, including ",squash" embedded
+// structs and nested structs, slices and maps of structs, and reports
+// effective mapstructure keys claimed by more than one field. mapstructure
+// does not reject such structs; which field wins depends on field order,
+// so checking up front (e.g. in a test) is the only safe option. Keys are
+// compared case-insensitively, as mapstructure matches them.
+func DetectTagConflicts(t reflect.Type) error {
+	t = derefType(t)
+	if t.Kind() != reflect.Struct {
+		return fmt.Errorf("detect tag conflicts: expected a struct, got %s", t)
+	}
+
+	var conflicts []TagConflict
+	detectConflicts(t, "", map[reflect.Type]bool{}, &conflicts)
+	if len(conflicts) == 0 {
+		return nil
+	}
+
+	sort.Slice(conflicts, func(i, j int) bool {
+		return conflicts[i].Key < conflicts[j].Key
+	})
+	return &TagConflictError{Conflicts: conflicts}
+}
+
+// fieldKey is one effective key at a struct level and the fields claiming it.
+type fieldKey struct {
+	key    string
+	fields []string
+	nested reflect.Type // struct to descend into, if any
+	suffix string       // "[]" when nested is a slice or map element
+}
+
+func detectConflicts(t reflect.Type, prefix string, visiting map[reflect.Type]bool, conflicts *[]TagConflict) {
+	if visiting[t] {
+		return
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	keys := make(map[string]*fieldKey)
+	var order []string
+	collectKeys(t, "", keys, &order)
+
+	for _, lower := range order {
+		k := keys[lower]
+		path := joinPath(prefix, k.key)
+		if len(k.fields) > 1 {
+			*conflicts = append(*conflicts, TagConflict{Key: path, Fields: k.fields})
+		}
+		if k.nested != nil {
+			detectConflicts(k.nested, path+k.suffix, visiting, conflicts)
+		}
+	}
+}
+
+// collectKeys records the effective key of every field of t, flattening
+// squashed structs into the same level.
+func collectKeys(t reflect.Type, goPrefix string, keys map[string]*fieldKey, order *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		if name == "-" {
+			continue
+		}
+		goPath := goPrefix + field.Name
+
+		if strings.Contains(opts, "squash") {
+			if inner := derefType(field.Type); inner.Kind() == reflect.Struct {
+				collectKeys(inner, goPath+".", keys, order)
+				continue
+			}
+		}
+
+		key := name
+		switch {
+		case strings.Contains(opts, "remain"):
+			key = remainKey
+		case key == "":
+			key = field.Name
+		}
+
+		lower := strings.ToLower(key)
+		k, seen := keys[lower]
+		if !seen {
+			k = &fieldKey{key: key}
+			if key != remainKey {
+				k.nested, k.suffix = nestedStruct(field.Type)
+			}
+			keys[lower] = k
+			*order = append(*order, lower)
+		}
+		k.fields = append(k.fields, goPath)
+	}
+}
+
+// nestedStruct returns the struct type decoded below a field of type t and
+// the path suffix for slice or map elements, or nil if there is none.
+func nestedStruct(t reflect.Type) (reflect.Type, string) {
+	t = derefType(t)
+	suffix := ""
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		t = derefType(t.Elem())
+		suffix = "[]"
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, ""
+	}
+	return t, suffix
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func conflictsOf(t *testing.T, typ reflect.Type) []TagConflict {
+	t.Helper()
+
+	err := DetectTagConflicts(typ)
+	if err == nil {
+		return nil
+	}
+	var conflictErr *TagConflictError
+	if !errors.As(err, &conflictErr) {
+		t.Fatalf("DetectTagConflicts: %v", err)
+	}
+	return conflictErr.Conflicts
+}
+
+func TestDetectTagConflictsNone(t *testing.T) {
+	for _, v := range []interface{}{AppConfig{}, ContainerStruct{}, &Project{}, DatabaseConfig{}} {
+		if err := DetectTagConflicts(reflect.TypeOf(v)); err != nil {
+			t.Errorf("%T: %v", v, err)
+		}
+	}
+}
+
+func TestDetectTagConflictsNestedSquash(t *testing.T) {
+	type Base struct {
+		ID string `mapstructure:"id"`
+	}
+	type Middle struct {
+		Base `mapstructure:",squash"`
+		Name string `mapstructure:"name"`
+	}
+	type Outer struct {
+		Middle `mapstructure:",squash"`
+		Ident  int    `mapstructure:"ID"` // keys match case-insensitively
+		Label  string `mapstructure:"name"`
+		Note   string `mapstructure:"note"`
+	}
+
+	want := []TagConflict{
+		{Key: "id", Fields: []string{"Middle.Base.ID", "Ident"}},
+		{Key: "name", Fields: []string{"Middle.Name", "Label"}},
+	}
+	if got := conflictsOf(t, reflect.TypeOf(Outer{})); !reflect.DeepEqual(got, want) {
+		t.Errorf("conflicts = %+v, want %+v", got, want)
+	}
+}
+
+func TestDetectTagConflictsNestedSections(t *testing.T) {
+	type Listener struct {
+		Port int    `mapstructure:"port"`
+		Bind string `mapstructure:"port"`
+	}
+	type Route struct {
+		Path string `mapstructure:"path"`
+		URL  string `mapstructure:"Path"`
+	}
+	type Config struct {
+		Server *Listener        `mapstructure:"server"`
+		Routes []Route          `mapstructure:"routes"`
+		Named  map[string]Route `mapstructure:"named"`
+		Debug  bool             // untagged fields use the Go name
+		Trace  bool             `mapstructure:"debug"`
+		Skip   string           `mapstructure:"-"`
+		Other  string           `mapstructure:"-"`
+	}
+
+	want := []TagConflict{
+		{Key: "Debug", Fields: []string{"Debug", "Trace"}},
+		{Key: "named[].path", Fields: []string{"Path", "URL"}},
+		{Key: "routes[].path", Fields: []string{"Path", "URL"}},
+		{Key: "server.port", Fields: []string{"Port", "Bind"}},
+	}
+	if got := conflictsOf(t, reflect.TypeOf(Config{})); !reflect.DeepEqual(got, want) {
+		t.Errorf("conflicts = %+v, want %+v", got, want)
+	}
+}
+
+func TestDetectTagConflictsRemain(t *testing.T) {
+	type Extras struct {
+		Extra map[string]interface{} `mapstructure:",remain"`
+	}
+	type Twice struct {
+		Extras `mapstructure:",squash"`
+		Rest   map[string]interface{} `mapstructure:",remain"`
+	}
+
+	want := []TagConflict{{Key: "(remain)", Fields: []string{"Extras.Extra", "Rest"}}}
+	if got := conflictsOf(t, reflect.TypeOf(Twice{})); !reflect.DeepEqual(got, want) {
+		t.Errorf("conflicts = %+v, want %+v", got, want)
+	}
+}
+
+func TestDetectTagConflictsRecursiveType(t *testing.T) {
+	type Node struct {
+		Name     string  `mapstructure:"name"`
+		Children []*Node `mapstructure:"children"`
+	}
+	if err := DetectTagConflicts(reflect.TypeOf(Node{})); err != nil {
+		t.Errorf("DetectTagConflicts: %v", err)
+	}
+}
+
+func TestDetectTagConflictsNotAStruct(t *testing.T) {
+	if err := DetectTagConflicts(reflect.TypeOf(42)); err == nil {
+		t.Error("expected an error for a non-struct type")
+	}
+}
+
+func TestRemainRoundTrip(t *testing.T) {
+	type Base struct {
+		Name string `mapstructure:"name"`
+	}
+	type Service struct {
+		Base `mapstructure:",squash"`
+		Port int                    `mapstructure:"port"`
+		Rest map[string]interface{} `mapstructure:",remain"`
+	}
+	type Observability struct {
+		MetricsPath string `mapstructure:"metrics_path"`
+		Sampling    struct {
+			Rate float64 `mapstructure:"rate"`
+		} `mapstructure:"sampling"`
+	}
+
+	input := map[string]interface{}{
+		"name":         "billing",
+		"port":         9090,
+		"metrics_path": "/metrics",
+		"sampling":     map[string]interface{}{"rate": 0.5},
+	}
+
+	if err := DetectTagConflicts(reflect.TypeOf(Service{})); err != nil {
+		t.Fatalf("DetectTagConflicts: %v", err)
+	}
+
+	service, err := Decode[Service](input)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if service.Name != "billing" || service.Port != 9090 || len(service.Rest) != 2 {
+		t.Fatalf("service = %+v", service)
+	}
+
+	// The leftovers decode strictly into a second struct.
+	observability, err := Decode[Observability](service.Rest, WithStrict())
+	if err != nil {
+		t.Fatalf("re-decode remain: %v", err)
+	}
+	if observability.MetricsPath != "/metrics" || observability.Sampling.Rate != 0.5 {
+		t.Errorf("observability = %+v", observability)
+	}
+
+	// Encoding merges the remain map back, reproducing the input.
+	encoded, err := Encode(service)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	if !reflect.DeepEqual(encoded, input) {
+		t.Errorf("Encode = %#v, want %#v", encoded, input)
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import "github.com/mitchellh/mapstructure"
+
+// Decode decodes input into a new value of type T, hiding the
+// DecoderConfig/NewDecoder boilerplate:
+//
+//	cfg, err := Decode[DatabaseConfig](input, WithDecodeHook(hooks.ComposeAll()))
+func Decode[T any](input interface{}, opts ...Option) (T, error) {
+	var result T
+
+	o := newDecodeOptions(opts)
+	if o.strict {
+		return result, DecodeStrict(input, &result, opts...)
+	}
+
+	decoder, err := mapstructure.NewDecoder(o.decoderConfig(&result))
+	if err != nil {
+		return result, err
+	}
+	return result, decoder.Decode(input)
+}
+
+// MustDecode is like Decode but panics on error. It is intended for inputs
+// that are known to be valid, such as literals in tests and examples.
+func MustDecode[T any](input interface{}, opts ...Option) T {
+	result, err := Decode[T](input, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"example.com/mapstructure-demo/hooks"
+)
+
+func TestDecodeStruct(t *testing.T) {
+	got, err := Decode[DatabaseConfig](map[string]interface{}{
+		"host":            "localhost",
+		"port":            5432,
+		"connect_timeout": "30s",
+	}, WithDecodeHook(hooks.ComposeAll()))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+
+	want := DatabaseConfig{Host: "localhost", Port: 5432, ConnectTimeout: 30 * time.Second}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestDecodeSliceOfStructs(t *testing.T) {
+	got, err := Decode[[]Task]([]interface{}{
+		map[string]interface{}{"id": 1, "title": "Design", "completed": true},
+		map[string]interface{}{"id": 2, "title": "Build"},
+	})
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+
+	want := []Task{{ID: 1, Title: "Design", Completed: true}, {ID: 2, Title: "Build"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestDecodeMapOfConfigs(t *testing.T) {
+	got, err := Decode[map[string]DatabaseConfig](map[string]interface{}{
+		"primary": map[string]interface{}{"host": "db1", "port": 5432},
+		"replica": map[string]interface{}{"host": "db2", "port": 5433, "connect_timeout": "5s"},
+	}, WithDecodeHook(hooks.ComposeAll()))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+
+	want := map[string]DatabaseConfig{
+		"primary": {Host: "db1", Port: 5432},
+		"replica": {Host: "db2", Port: 5433, ConnectTimeout: 5 * time.Second},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestDecodeOptions(t *testing.T) {
+	type jsonTagged struct {
+		Port    int  `json:"listen_port"`
+		Verbose bool `json:"is_verbose"`
+	}
+	input := map[string]interface{}{"listen_port": "8080", "is_verbose": "1"}
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		want    jsonTagged
+		wantErr bool
+	}{
+		{
+			name: "default tag ignores json names",
+			want: jsonTagged{},
+		},
+		{
+			name:    "custom tag without weak typing",
+			opts:    []Option{WithTagName("json")},
+			wantErr: true,
+		},
+		{
+			name: "custom tag with weak typing",
+			opts: []Option{WithTagName("json"), WithWeakTyping()},
+			want: jsonTagged{Port: 8080, Verbose: true},
+		},
+		{
+			name: "strict with custom tag and weak typing",
+			opts: []Option{WithStrict(), WithTagName("json"), WithWeakTyping()},
+			want: jsonTagged{Port: 8080, Verbose: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decode[jsonTagged](input, tt.opts...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %t", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeStrictOption(t *testing.T) {
+	_, err := Decode[Person](map[string]interface{}{"name": "Ann", "agee": 30}, WithStrict())
+
+	var strictErr *StrictError
+	if !errors.As(err, &strictErr) {
+		t.Fatalf("expected *StrictError, got %T: %v", err, err)
+	}
+	if want := []string{"agee"}; !reflect.DeepEqual(strictErr.Unused, want) {
+		t.Errorf("Unused = %q, want %q", strictErr.Unused, want)
+	}
+	if want := []UnsetField{{"Age", "int"}, {"City", "string"}}; !reflect.DeepEqual(strictErr.Unset, want) {
+		t.Errorf("Unset = %+v, want %+v", strictErr.Unset, want)
+	}
+}
+
+func TestDecodeStrictSliceElements(t *testing.T) {
+	_, err := Decode[[]Task]([]interface{}{
+		map[string]interface{}{"id": 1, "title": "a", "completed": true},
+		map[string]interface{}{"id": 2, "completed": false},
+	}, WithStrict())
+
+	var strictErr *StrictError
+	if !errors.As(err, &strictErr) {
+		t.Fatalf("expected *StrictError, got %T: %v", err, err)
+	}
+	if want := []UnsetField{{"[1].title", "string"}}; !reflect.DeepEqual(strictErr.Unset, want) {
+		t.Errorf("Unset = %+v, want %+v", strictErr.Unset, want)
+	}
+}
+
+func TestMustDecode(t *testing.T) {
+	got := MustDecode[Product](map[string]interface{}{"id": 1, "name": "Laptop", "price": 999.99})
+	if got != (Product{ID: 1, Name: "Laptop", Price: 999.99}) {
+		t.Errorf("got %+v", got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected MustDecode to panic on invalid input")
+		}
+	}()
+	MustDecode[Product](map[string]interface{}{"id": "not a number"})
+}
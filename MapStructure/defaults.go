@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// ApplyDefaults fills every zero-valued field that has a `default:"..."` tag,
+// recursing into nested structs, non-nil pointers and slice elements. Call it
+// on a fresh struct before decoding to pre-populate defaults; because it only
+// looks at values it cannot tell an explicit zero from a missing key, so
+// prefer DecodeWithDefaults after decoding.
+func ApplyDefaults(result interface{}) error {
+	return applyDefaultsTo(result, func(_ string, field reflect.Value) bool {
+		return !field.IsZero()
+	})
+}
+
+// DecodeWithDefaults decodes input into output and then applies default tags
+// to every field whose key was absent from the input, using the decoder
+// metadata. Explicit zeros such as "port: 0" or "ssl: false" are kept.
+func DecodeWithDefaults(input, output interface{}, opts ...Option) error {
+	var md mapstructure.Metadata
+	config := newDecodeOptions(opts).decoderConfig(output)
+	config.Metadata = &md
+
+	decoder, err := mapstructure.NewDecoder(config)
+	if err != nil {
+		return err
+	}
+	if err := decoder.Decode(input); err != nil {
+		return err
+	}
+
+	present := make(map[string]bool, len(md.Keys))
+	for _, key := range md.Keys {
+		present[key] = true
+	}
+	return applyDefaultsTo(output, func(path string, _ reflect.Value) bool {
+		return present[path]
+	})
+}
+
+// isSetFunc reports whether the field at path already has a value that a
+// default must not overwrite.
+type isSetFunc func(path string, field reflect.Value) bool
+
+func applyDefaultsTo(result interface{}, isSet isSetFunc) error {
+	v := reflect.ValueOf(result)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("defaults: expected a pointer to a struct, got %T", result)
+	}
+	return applyStructDefaults(v.Elem(), "", isSet)
+}
+
+func applyStructDefaults(v reflect.Value, prefix string, isSet isSetFunc) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		if name == "-" {
+			continue
+		}
+		fieldVal := v.Field(i)
+		if field.Anonymous && strings.Contains(opts, "squash") {
+			if inner := reflect.Indirect(fieldVal); inner.Kind() == reflect.Struct {
+				if err := applyStructDefaults(inner, prefix, isSet); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		path := joinPath(prefix, name)
+
+		if def, ok := field.Tag.Lookup("default"); ok && !isSet(path, fieldVal) {
+			if err := setDefault(fieldVal, def); err != nil {
+				return fmt.Errorf("default for %s: %w", path, err)
+			}
+		}
+
+		if err := applyNestedDefaults(fieldVal, path, isSet); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applyNestedDefaults(v reflect.Value, path string, isSet isSetFunc) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		return applyNestedDefaults(v.Elem(), path, isSet)
+	case reflect.Struct:
+		return applyStructDefaults(v, path, isSet)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := applyNestedDefaults(v.Index(i), fmt.Sprintf("%s[%d]", path, i), isSet); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			// Map values aren't addressable, so update a copy and store it back.
+			item := reflect.New(iter.Value().Type()).Elem()
+			item.Set(iter.Value())
+			if err := applyNestedDefaults(item, fmt.Sprintf("%s[%v]", path, iter.Key().Interface()), isSet); err != nil {
+				return err
+			}
+			v.SetMapIndex(iter.Key(), item)
+		}
+	}
+	return nil
+}
+
+// setDefault parses a default tag value into v.
+func setDefault(v reflect.Value, def string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(def)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(def)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(def)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(def, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(def, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(def, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		parts := []string{}
+		if def != "" {
+			parts = strings.Split(def, ",")
+		}
+		slice := reflect.MakeSlice(v.Type(), len(parts), len(parts))
+		for i, part := range parts {
+			if err := setDefault(slice.Index(i), strings.TrimSpace(part)); err != nil {
+				return fmt.Errorf("element %d: %w", i, err)
+			}
+		}
+		v.Set(slice)
+	case reflect.Ptr:
+		elem := reflect.New(v.Type().Elem())
+		if err := setDefault(elem.Elem(), def); err != nil {
+			return err
+		}
+		v.Set(elem)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/mapstructure-demo/hooks"
+	"github.com/mitchellh/mapstructure"
+)
+
+type defaultsWorker struct {
+	Name    string `mapstructure:"name"`
+	Retries int    `mapstructure:"retries" default:"3"`
+}
+
+type defaultsConfig struct {
+	Name     string                    `mapstructure:"name" default:"app"`
+	Ratio    float64                   `mapstructure:"ratio" default:"0.5"`
+	Limit    uint16                    `mapstructure:"limit" default:"100"`
+	Tags     []string                  `mapstructure:"tags" default:"a, b"`
+	Ports    []int                     `mapstructure:"ports" default:"80,443"`
+	Level    *string                   `mapstructure:"level" default:"info"`
+	Database DatabaseConfig            `mapstructure:"database"`
+	Workers  []defaultsWorker          `mapstructure:"workers"`
+	Pools    map[string]defaultsWorker `mapstructure:"pools"`
+}
+
+func TestDecodeWithDefaultsFillsAbsentKeys(t *testing.T) {
+	input := map[string]interface{}{
+		"database": map[string]interface{}{"host": "db"},
+	}
+
+	var cfg defaultsConfig
+	if err := DecodeWithDefaults(input, &cfg, WithDecodeHook(hooks.ComposeAll())); err != nil {
+		t.Fatalf("DecodeWithDefaults: %v", err)
+	}
+
+	if cfg.Name != "app" || cfg.Ratio != 0.5 || cfg.Limit != 100 {
+		t.Errorf("scalars: %+v", cfg)
+	}
+	if !reflect.DeepEqual(cfg.Tags, []string{"a", "b"}) || !reflect.DeepEqual(cfg.Ports, []int{80, 443}) {
+		t.Errorf("slices: tags=%q ports=%v", cfg.Tags, cfg.Ports)
+	}
+	if cfg.Level == nil || *cfg.Level != "info" {
+		t.Errorf("level = %v", cfg.Level)
+	}
+
+	want := DatabaseConfig{Host: "db", Port: 5432, ConnectTimeout: 10 * time.Second, SSL: true}
+	if cfg.Database != want {
+		t.Errorf("database = %+v, want %+v", cfg.Database, want)
+	}
+}
+
+func TestDecodeWithDefaultsPreservesExplicitZeros(t *testing.T) {
+	input := map[string]interface{}{
+		"name":  "",
+		"ratio": 0.0,
+		"tags":  []interface{}{},
+		"database": map[string]interface{}{
+			"port":            0,
+			"ssl":             false,
+			"connect_timeout": "0s",
+		},
+	}
+
+	var cfg defaultsConfig
+	if err := DecodeWithDefaults(input, &cfg, WithDecodeHook(hooks.ComposeAll())); err != nil {
+		t.Fatalf("DecodeWithDefaults: %v", err)
+	}
+
+	if cfg.Name != "" || cfg.Ratio != 0 || len(cfg.Tags) != 0 {
+		t.Errorf("explicit zeros overwritten: name=%q ratio=%v tags=%q", cfg.Name, cfg.Ratio, cfg.Tags)
+	}
+	if cfg.Database.Port != 0 || cfg.Database.SSL || cfg.Database.ConnectTimeout != 0 {
+		t.Errorf("explicit database zeros overwritten: %+v", cfg.Database)
+	}
+	// Absent keys still get their defaults.
+	if cfg.Limit != 100 {
+		t.Errorf("limit = %d, want 100", cfg.Limit)
+	}
+}
+
+func TestDecodeWithDefaultsNestedElements(t *testing.T) {
+	input := map[string]interface{}{
+		"workers": []interface{}{
+			map[string]interface{}{"name": "a"},
+			map[string]interface{}{"name": "b", "retries": 0},
+		},
+		"pools": map[string]interface{}{
+			"fast": map[string]interface{}{"retries": 1},
+			"slow": map[string]interface{}{},
+		},
+	}
+
+	var cfg defaultsConfig
+	if err := DecodeWithDefaults(input, &cfg); err != nil {
+		t.Fatalf("DecodeWithDefaults: %v", err)
+	}
+
+	if cfg.Workers[0].Retries != 3 || cfg.Workers[1].Retries != 0 {
+		t.Errorf("workers = %+v", cfg.Workers)
+	}
+	if cfg.Pools["fast"].Retries != 1 || cfg.Pools["slow"].Retries != 3 {
+		t.Errorf("pools = %+v", cfg.Pools)
+	}
+}
+
+func TestApplyDefaultsBeforeDecode(t *testing.T) {
+	var db DatabaseConfig
+	if err := ApplyDefaults(&db); err != nil {
+		t.Fatalf("ApplyDefaults: %v", err)
+	}
+	if db.Port != 5432 || !db.SSL || db.ConnectTimeout != 10*time.Second {
+		t.Fatalf("pre-populated = %+v", db)
+	}
+
+	// Decoding over the pre-populated struct only replaces supplied keys.
+	if err := mapstructure.Decode(map[string]interface{}{"port": 6432, "ssl": false}, &db); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if db.Port != 6432 || db.SSL || db.ConnectTimeout != 10*time.Second {
+		t.Errorf("after decode = %+v", db)
+	}
+
+	// Without metadata a value that is already set is left alone.
+	db.Port = 1
+	if err := ApplyDefaults(&db); err != nil {
+		t.Fatalf("ApplyDefaults: %v", err)
+	}
+	if db.Port != 1 {
+		t.Errorf("port = %d, want 1", db.Port)
+	}
+}
+
+func TestDefaultsAndRequiredFields(t *testing.T) {
+	// A default never satisfies required:"true"; the key must be in the input.
+	var db DatabaseConfig
+	if err := ApplyDefaults(&db); err != nil {
+		t.Fatalf("ApplyDefaults: %v", err)
+	}
+
+	err := DecodeValidated(map[string]interface{}{"host": "db", "username": "u"}, &db)
+	if err == nil || !strings.Contains(err.Error(), "password") {
+		t.Errorf("err = %v, want missing password", err)
+	}
+	if db.Port != 5432 {
+		t.Errorf("port = %d, want default 5432", db.Port)
+	}
+}
+
+func TestDefaultTagErrors(t *testing.T) {
+	type badInt struct {
+		Server struct {
+			Port int `mapstructure:"port" default:"eighty"`
+		} `mapstructure:"server"`
+	}
+	type badDuration struct {
+		Timeout time.Duration `mapstructure:"timeout" default:"soon"`
+	}
+	type unsupported struct {
+		Ch chan int `mapstructure:"ch" default:"1"`
+	}
+
+	tests := []struct {
+		name   string
+		target interface{}
+		want   string
+	}{
+		{"int", &badInt{}, "default for server.port"},
+		{"duration", &badDuration{}, "default for timeout"},
+		{"unsupported type", &unsupported{}, "unsupported type chan int"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := DecodeWithDefaults(map[string]interface{}{}, tt.target)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+
+	if err := ApplyDefaults(badDuration{}); err == nil {
+		t.Error("expected an error for a non-pointer target")
+	}
+}
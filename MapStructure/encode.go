@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	durationType      = reflect.TypeOf(time.Duration(0))
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// Encode is the reverse of mapstructure.Decode: it walks a struct and builds
+// a map keyed by the mapstructure tag names. ",squash" fields are flattened
+// into the parent, ",remain" maps are merged back in, ",omitempty" fields are
+// skipped when zero and "-" fields are ignored.
+//
+// time.Duration values become strings like "30s" and anything implementing
+// encoding.TextMarshaler (time.Time, net.IP, *regexp.Regexp) becomes its
+// text form, so the result decodes back with hooks.ComposeAll().
+func Encode(input interface{}) (map[string]interface{}, error) {
+	v := reflect.ValueOf(input)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, fmt.Errorf("encode: nil %s", v.Type())
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("encode: expected a struct, got %T", input)
+	}
+
+	out := make(map[string]interface{})
+	if err := encodeStruct(v, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func encodeStruct(v reflect.Value, out map[string]interface{}) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		if name == "-" {
+			continue
+		}
+
+		fieldVal := v.Field(i)
+		switch {
+		case strings.Contains(opts, "squash"):
+			inner := reflect.Indirect(fieldVal)
+			if !inner.IsValid() {
+				continue
+			}
+			if inner.Kind() != reflect.Struct {
+				return fmt.Errorf("encode: %s: squash requires a struct, got %s", field.Name, inner.Type())
+			}
+			if err := encodeStruct(inner, out); err != nil {
+				return err
+			}
+			continue
+
+		case strings.Contains(opts, "remain"):
+			if fieldVal.Kind() != reflect.Map {
+				continue
+			}
+			iter := fieldVal.MapRange()
+			for iter.Next() {
+				value, err := encodeValue(iter.Value())
+				if err != nil {
+					return fmt.Errorf("encode: %s: %w", field.Name, err)
+				}
+				out[fmt.Sprint(iter.Key().Interface())] = value
+			}
+			continue
+
+		case strings.Contains(opts, "omitempty") && fieldVal.IsZero():
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		value, err := encodeValue(fieldVal)
+		if err != nil {
+			return fmt.Errorf("encode: %s: %w", name, err)
+		}
+		out[name] = value
+	}
+	return nil
+}
+
+func encodeValue(v reflect.Value) (interface{}, error) {
+	if !v.IsValid() {
+		return nil, nil
+	}
+
+	if v.Type() == durationType {
+		return time.Duration(v.Int()).String(), nil
+	}
+	if v.Type().Implements(textMarshalerType) {
+		if v.Kind() == reflect.Ptr && v.IsNil() {
+			return nil, nil
+		}
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return nil, err
+		}
+		return string(text), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return encodeValue(v.Elem())
+
+	case reflect.Struct:
+		out := make(map[string]interface{})
+		if err := encodeStruct(v, out); err != nil {
+			return nil, err
+		}
+		return out, nil
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil, nil
+		}
+		items := make([]interface{}, v.Len())
+		for i := range items {
+			item, err := encodeValue(v.Index(i))
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+			items[i] = item
+		}
+		return items, nil
+
+	case reflect.Map:
+		if v.IsNil() {
+			return nil, nil
+		}
+		out := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			key := fmt.Sprint(iter.Key().Interface())
+			item, err := encodeValue(iter.Value())
+			if err != nil {
+				return nil, fmt.Errorf("[%s]: %w", key, err)
+			}
+			out[key] = item
+		}
+		return out, nil
+
+	default:
+		return v.Interface(), nil
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"net"
+	"reflect"
+	"regexp"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"example.com/mapstructure-demo/hooks"
+)
+
+// roundTrip encodes v and decodes the result into a fresh value of the same
+// type, returning the decoded value.
+func roundTrip[T any](t *testing.T, v T) T {
+	t.Helper()
+
+	encoded, err := Encode(v)
+	if err != nil {
+		t.Fatalf("Encode(%+v): %v", v, err)
+	}
+
+	var decoded T
+	if err := DecodeStrict(encoded, &decoded, WithDecodeHook(hooks.ComposeAll())); err != nil {
+		t.Fatalf("decode %v: %v", encoded, err)
+	}
+	return decoded
+}
+
+func checkRoundTrip[T any](t *testing.T) {
+	t.Helper()
+
+	property := func(v T) bool {
+		return reflect.DeepEqual(roundTrip(t, v), v)
+	}
+	if err := quick.Check(property, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	t.Run("Person", func(t *testing.T) { checkRoundTrip[Person](t) })
+	t.Run("Product", func(t *testing.T) { checkRoundTrip[Product](t) })
+	t.Run("User", func(t *testing.T) { checkRoundTrip[User](t) })
+	t.Run("Team", func(t *testing.T) { checkRoundTrip[Team](t) })
+	t.Run("Project", func(t *testing.T) { checkRoundTrip[Project](t) })
+	t.Run("ContainerStruct", func(t *testing.T) { checkRoundTrip[ContainerStruct](t) })
+	t.Run("DatabaseConfig", func(t *testing.T) { checkRoundTrip[DatabaseConfig](t) })
+	t.Run("AppConfig", func(t *testing.T) { checkRoundTrip[AppConfig](t) })
+}
+
+// Event holds a time.Time, which testing/quick cannot generate, so the
+// property is expressed over its components instead.
+func TestEncodeRoundTripEvent(t *testing.T) {
+	property := func(name string, sec int64, nsec uint32, d time.Duration) bool {
+		// Keep years within what RFC3339 can represent.
+		ts := time.Unix(sec%(1<<35), int64(nsec%1e9)).UTC()
+		event := Event{Name: name, Timestamp: ts, Duration: d}
+
+		got := roundTrip(t, event)
+		return got.Name == event.Name && got.Timestamp.Equal(ts) && got.Duration == d
+	}
+	if err := quick.Check(property, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestEncodeTags(t *testing.T) {
+	type inner struct {
+		Level int `mapstructure:"level"`
+	}
+	type sample struct {
+		EmbeddedStruct `mapstructure:",squash"`
+		Skipped        string                 `mapstructure:"-"`
+		Optional       string                 `mapstructure:"optional,omitempty"`
+		Timeout        time.Duration          `mapstructure:"timeout"`
+		Bind           net.IP                 `mapstructure:"bind"`
+		Pattern        *regexp.Regexp         `mapstructure:"pattern"`
+		Nested         *inner                 `mapstructure:"nested"`
+		Items          []inner                `mapstructure:"items"`
+		Labels         map[string]inner       `mapstructure:"labels"`
+		Extra          map[string]interface{} `mapstructure:",remain"`
+		untagged       int
+	}
+
+	got, err := Encode(&sample{
+		EmbeddedStruct: EmbeddedStruct{ID: 1, Name: "n"},
+		Skipped:        "hidden",
+		Timeout:        90 * time.Second,
+		Bind:           net.ParseIP("10.0.0.1"),
+		Pattern:        regexp.MustCompile(`^a+$`),
+		Nested:         &inner{Level: 2},
+		Items:          []inner{{Level: 3}},
+		Labels:         map[string]inner{"x": {Level: 4}},
+		Extra:          map[string]interface{}{"leftover": true},
+		untagged:       5,
+	})
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	want := map[string]interface{}{
+		"id":       1,
+		"name":     "n",
+		"timeout":  "1m30s",
+		"bind":     "10.0.0.1",
+		"pattern":  "^a+$",
+		"nested":   map[string]interface{}{"level": 2},
+		"items":    []interface{}{map[string]interface{}{"level": 3}},
+		"labels":   map[string]interface{}{"x": map[string]interface{}{"level": 4}},
+		"leftover": true,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Encode =\n%#v\nwant\n%#v", got, want)
+	}
+}
+
+func TestEncodeRejectsNonStructs(t *testing.T) {
+	for _, input := range []interface{}{42, "text", []int{1}, (*Person)(nil)} {
+		if _, err := Encode(input); err == nil {
+			t.Errorf("Encode(%#v): expected an error", input)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+var (
+	hookErrorPattern  = regexp.MustCompile(`(?s)^error decoding '([^']*)': (.+)$`)
+	fieldErrorPattern = regexp.MustCompile(`(?s)^'([^']*)':? (.+)$`)
+)
+
+// FieldError is a single decode problem attributed to a dotted field path.
+// An empty Path means the problem concerns the input as a whole.
+type FieldError struct {
+	Path    string
+	Message string
+}
+
+func (e FieldError) String() string {
+	if e.Path == "" {
+		return "(root): " + e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// FieldErrors flattens err into one FieldError per problem. It understands
+// *mapstructure.Error, *StrictError and errors joined with errors.Join;
+// anything else becomes a single root-level entry. Unused keys and unset
+// fields are expanded to one entry per key.
+func FieldErrors(err error) []FieldError {
+	var fields []FieldError
+	collectFieldErrors(err, &fields)
+	sort.SliceStable(fields, func(i, j int) bool {
+		return fields[i].Path < fields[j].Path
+	})
+	return fields
+}
+
+// FormatDecodeError renders err as a list of "path: message" lines grouped
+// by top-level section, so "server.port" and "database.port" can no longer
+// be confused. It returns "" for a nil error.
+func FormatDecodeError(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	fields := FieldErrors(err)
+	sections := make(map[string][]FieldError)
+	var order []string
+	for _, field := range fields {
+		section := topLevelSection(field.Path)
+		if _, seen := sections[section]; !seen {
+			order = append(order, section)
+		}
+		sections[section] = append(sections[section], field)
+	}
+	sort.Strings(order)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d decode error(s):", len(fields))
+	for _, section := range order {
+		fmt.Fprintf(&b, "\n  %s:", section)
+		for _, field := range sections[section] {
+			fmt.Fprintf(&b, "\n    - %s", field)
+		}
+	}
+	return b.String()
+}
+
+// WrapWithPath decodes the section of input found at path (e.g. "server" or
+// "projects[0].tasks") into output. Decoding a section on its own normally
+// loses its position, giving errors such as "'port' expected type 'int'";
+// WrapWithPath rewrites every error path to start from the root of input and
+// to use the key spelling found there. A path missing from input leaves
+// output untouched. An empty path decodes the whole input.
+func WrapWithPath(path string, input, output interface{}, opts ...Option) error {
+	section := input
+	if path != "" {
+		value, ok := lookupPath(input, path)
+		if !ok {
+			return nil
+		}
+		section = value
+	}
+
+	decoder, err := mapstructure.NewDecoder(newDecodeOptions(opts).decoderConfig(output))
+	if err != nil {
+		return err
+	}
+
+	var decodeErr *mapstructure.Error
+	if err := decoder.Decode(section); !errors.As(err, &decodeErr) {
+		return err
+	}
+
+	rewritten := make([]string, len(decodeErr.Errors))
+	for i, msg := range decodeErr.Errors {
+		rewritten[i] = rewriteErrorPath(msg, path, input)
+	}
+	return &mapstructure.Error{Errors: rewritten}
+}
+
+func collectFieldErrors(err error, fields *[]FieldError) {
+	if err == nil {
+		return
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, inner := range joined.Unwrap() {
+			collectFieldErrors(inner, fields)
+		}
+		return
+	}
+
+	var strictErr *StrictError
+	if errors.As(err, &strictErr) {
+		for _, key := range strictErr.Unused {
+			*fields = append(*fields, FieldError{Path: key, Message: "unused key"})
+		}
+		for _, field := range strictErr.Unset {
+			*fields = append(*fields, FieldError{Path: field.Path, Message: "unset field of type " + field.Type})
+		}
+		for _, msg := range strictErr.Errors {
+			*fields = append(*fields, parseFieldErrors(msg)...)
+		}
+		return
+	}
+
+	var decodeErr *mapstructure.Error
+	if errors.As(err, &decodeErr) {
+		for _, msg := range decodeErr.Errors {
+			*fields = append(*fields, parseFieldErrors(msg)...)
+		}
+		return
+	}
+
+	*fields = append(*fields, FieldError{Message: err.Error()})
+}
+
+// parseFieldErrors splits one mapstructure message into its path and detail.
+func parseFieldErrors(msg string) []FieldError {
+	if m := invalidKeysPattern.FindStringSubmatch(msg); m != nil {
+		var fields []FieldError
+		for _, key := range strings.Split(m[2], ", ") {
+			fields = append(fields, FieldError{Path: joinPath(m[1], key), Message: "unused key"})
+		}
+		return fields
+	}
+	if m := unsetFieldsPattern.FindStringSubmatch(msg); m != nil {
+		var fields []FieldError
+		for _, key := range strings.Split(m[2], ", ") {
+			fields = append(fields, FieldError{Path: joinPath(m[1], key), Message: "unset field"})
+		}
+		return fields
+	}
+	if m := hookErrorPattern.FindStringSubmatch(msg); m != nil {
+		return []FieldError{{Path: m[1], Message: m[2]}}
+	}
+	if m := fieldErrorPattern.FindStringSubmatch(msg); m != nil {
+		return []FieldError{{Path: m[1], Message: m[2]}}
+	}
+	return []FieldError{{Message: msg}}
+}
+
+// rewriteErrorPath replaces the quoted field path in a mapstructure message
+// with the same path anchored at prefix and spelled as in input.
+func rewriteErrorPath(msg, prefix string, input interface{}) string {
+	m := hookErrorPattern.FindStringSubmatch(msg)
+	if m == nil {
+		m = fieldErrorPattern.FindStringSubmatch(msg)
+	}
+	if m == nil {
+		return msg
+	}
+
+	path := m[1]
+	switch {
+	case path == "":
+		path = prefix
+	case strings.HasPrefix(path, "["):
+		path = prefix + path
+	default:
+		path = joinPath(prefix, path)
+	}
+	return strings.Replace(msg, "'"+m[1]+"'", "'"+canonicalPath(input, path)+"'", 1)
+}
+
+// canonicalPath respells each key of path as it appears in input.
+// mapstructure matches keys case-insensitively and reports untagged fields
+// by their Go name, so "Server.Port" may really be "server.port".
+func canonicalPath(input interface{}, path string) string {
+	current := reflect.ValueOf(input)
+	var b strings.Builder
+	for i, segment := range strings.Split(path, ".") {
+		name, indexes, _ := strings.Cut(segment, "[")
+		if name != "" {
+			current, name = lookupKey(current, name)
+			if i > 0 {
+				b.WriteString(".")
+			}
+			b.WriteString(name)
+		}
+		if indexes == "" {
+			continue
+		}
+		for _, index := range strings.Split(strings.TrimSuffix(indexes, "]"), "][") {
+			current, index = lookupKey(current, index)
+			b.WriteString("[" + index + "]")
+		}
+	}
+	return b.String()
+}
+
+// lookupKey steps from v into the map entry or slice element named key,
+// preferring an exact map key over a case-insensitive one. It returns the
+// key as spelled in the input and an invalid Value when there is no match.
+func lookupKey(v reflect.Value, key string) (reflect.Value, string) {
+	for v.IsValid() && (v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr) {
+		if v.IsNil() {
+			return reflect.Value{}, key
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			break
+		}
+		if next := v.MapIndex(reflect.ValueOf(key).Convert(v.Type().Key())); next.IsValid() {
+			return next, key
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			if strings.EqualFold(iter.Key().String(), key) {
+				return iter.Value(), iter.Key().String()
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if i, err := strconv.Atoi(key); err == nil && i >= 0 && i < v.Len() {
+			return v.Index(i), key
+		}
+	}
+	return reflect.Value{}, key
+}
+
+// topLevelSection returns the first key of path, or "(root)".
+func topLevelSection(path string) string {
+	if i := strings.IndexAny(path, ".["); i >= 0 {
+		path = path[:i]
+	}
+	if path == "" {
+		return "(root)"
+	}
+	return path
+}
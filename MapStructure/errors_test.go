@@ -0,0 +1,153 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+func paths(fields []FieldError) []string {
+	var out []string
+	for _, field := range fields {
+		out = append(out, field.Path)
+	}
+	return out
+}
+
+func TestWrapWithPathThreeLevelsDeep(t *testing.T) {
+	input := map[string]interface{}{
+		"server": map[string]interface{}{
+			"port": 8080,
+			"tls":  map[string]interface{}{"enabled": "definitely"},
+		},
+	}
+
+	var cfg AppConfig
+	err := WrapWithPath("server.tls", input, &cfg.Server.TLS)
+
+	fields := FieldErrors(err)
+	if want := []string{"server.tls.enabled"}; !reflect.DeepEqual(paths(fields), want) {
+		t.Fatalf("paths = %q, want %q (err: %v)", paths(fields), want, err)
+	}
+	if !strings.HasPrefix(fields[0].Message, "expected type 'bool'") {
+		t.Errorf("message = %q", fields[0].Message)
+	}
+
+	// The rewritten error is still a *mapstructure.Error
+	var decodeErr *mapstructure.Error
+	if !errors.As(err, &decodeErr) || !strings.Contains(decodeErr.Errors[0], "'server.tls.enabled'") {
+		t.Errorf("err = %#v", err)
+	}
+}
+
+func TestWrapWithPathSliceElements(t *testing.T) {
+	input := map[string]interface{}{
+		"projects": []interface{}{
+			map[string]interface{}{
+				"name": "site",
+				"tasks": []interface{}{
+					map[string]interface{}{"id": 1, "title": "ok"},
+					map[string]interface{}{"id": "two", "completed": "maybe"},
+				},
+			},
+		},
+	}
+
+	var tasks []Task
+	err := WrapWithPath("projects[0].tasks", input, &tasks)
+
+	want := []string{"projects[0].tasks[1].completed", "projects[0].tasks[1].id"}
+	if got := paths(FieldErrors(err)); !reflect.DeepEqual(got, want) {
+		t.Errorf("paths = %q, want %q (err: %v)", got, want, err)
+	}
+}
+
+func TestWrapWithPathHookErrorsAndKeySpelling(t *testing.T) {
+	type Limits struct {
+		Timeout time.Duration
+	}
+
+	// mapstructure reports untagged fields by their Go name; the rewritten
+	// path uses the key spelling from the input instead.
+	input := map[string]interface{}{
+		"Upstream": map[string]interface{}{"timeout": "soon"},
+	}
+
+	var limits Limits
+	err := WrapWithPath("Upstream", input, &limits, WithDecodeHook(mapstructure.StringToTimeDurationHookFunc()))
+
+	fields := FieldErrors(err)
+	if want := []string{"Upstream.timeout"}; !reflect.DeepEqual(paths(fields), want) {
+		t.Fatalf("paths = %q, want %q (err: %v)", paths(fields), want, err)
+	}
+	if !strings.Contains(fields[0].Message, `invalid duration "soon"`) {
+		t.Errorf("message = %q", fields[0].Message)
+	}
+}
+
+func TestWrapWithPathMissingSection(t *testing.T) {
+	cfg := DatabaseConfig{Host: "unchanged"}
+	if err := WrapWithPath("database", map[string]interface{}{}, &cfg); err != nil {
+		t.Fatalf("WrapWithPath: %v", err)
+	}
+	if cfg.Host != "unchanged" {
+		t.Errorf("Host = %q", cfg.Host)
+	}
+}
+
+func TestFormatDecodeError(t *testing.T) {
+	input := map[string]interface{}{
+		"server": map[string]interface{}{
+			"port": "eighty",
+			"tls":  map[string]interface{}{"enabled": "yes"},
+		},
+		"database": map[string]interface{}{"port": "five"},
+	}
+
+	var cfg AppConfig
+	err := errors.Join(
+		WrapWithPath("server", input, &cfg.Server),
+		WrapWithPath("database", input, &cfg.Database),
+		errors.New("config file is read-only"),
+	)
+
+	want := strings.Join([]string{
+		"4 decode error(s):",
+		"  (root):",
+		"    - (root): config file is read-only",
+		"  database:",
+		"    - database.port: expected type 'int', got unconvertible type 'string', value: 'five'",
+		"  server:",
+		"    - server.port: expected type 'int', got unconvertible type 'string', value: 'eighty'",
+		"    - server.tls.enabled: expected type 'bool', got unconvertible type 'string', value: 'yes'",
+	}, "\n")
+	if got := FormatDecodeError(err); got != want {
+		t.Errorf("FormatDecodeError() =\n%s\nwant\n%s", got, want)
+	}
+
+	if got := FormatDecodeError(nil); got != "" {
+		t.Errorf("FormatDecodeError(nil) = %q, want empty", got)
+	}
+}
+
+func TestFieldErrorsStrict(t *testing.T) {
+	type Target struct {
+		Name string `mapstructure:"name"`
+		Port int    `mapstructure:"port"`
+	}
+
+	err := DecodeStrict(map[string]interface{}{"nmae": "x", "port": "p"}, &Target{})
+
+	want := []FieldError{
+		{Path: "name", Message: "unset field of type string"},
+		{Path: "nmae", Message: "unused key"},
+		{Path: "port", Message: "expected type 'int', got unconvertible type 'string', value: 'p'"},
+	}
+	if got := FieldErrors(err); !reflect.DeepEqual(got, want) {
+		t.Errorf("FieldErrors() = %+v, want %+v", got, want)
+	}
+}
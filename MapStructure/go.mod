@@ -2,4 +2,7 @@ module example.com/mapstructure-demo
 
 go 1.25.0
 
-require github.com/mitchellh/mapstructure v1.5.0 // indirect
+require (
+	github.com/mitchellh/mapstructure v1.5.0
+	gopkg.in/yaml.v3 v3.0.1
+)
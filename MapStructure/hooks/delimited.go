@@ -0,0 +1,98 @@
+package hooks
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// StringToSliceHook splits a delimited string into a slice, trimming
+// whitespace and dropping empty segments, so "" becomes an empty slice
+// rather than [""]. A backslash before the delimiter keeps it literal:
+// with "," as delimiter, `a\,b,c` becomes ["a,b", "c"]. Any other backslash
+// is left alone, and an empty delimiter keeps the whole string as a single
+// element. The resulting []string is decoded element by element, so
+// other hooks and weak typing still apply to the items.
+func StringToSliceHook(delimiter string) mapstructure.DecodeHookFunc {
+	return func(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+		if from.Kind() != reflect.String || to.Kind() != reflect.Slice {
+			return data, nil
+		}
+		// []byte targets (including net.IP) are not lists.
+		if to.Elem().Kind() == reflect.Uint8 {
+			return data, nil
+		}
+
+		parts := []string{}
+		for _, part := range splitEscaped(data.(string), delimiter) {
+			if part = strings.TrimSpace(part); part != "" {
+				parts = append(parts, part)
+			}
+		}
+		return parts, nil
+	}
+}
+
+// StringToMapHook parses "k1=v1;k2=v2" into a map with string keys. Keys
+// and values are trimmed, empty pairs are dropped and "" becomes an empty
+// map. Either separator can be escaped with a backslash, e.g.
+// `query=a\=b\;c` becomes {"query": "a=b;c"}. A pair without "=", an empty
+// key or a repeated key is an error. Values are decoded into the map's
+// element type, so with weak typing "a=1;b=2" fills a map[string]int.
+func StringToMapHook() mapstructure.DecodeHookFunc {
+	return func(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+		if from.Kind() != reflect.String || to.Kind() != reflect.Map || to.Key().Kind() != reflect.String {
+			return data, nil
+		}
+
+		pairs := map[string]string{}
+		for _, pair := range splitEscaped(data.(string), ";") {
+			if strings.TrimSpace(pair) == "" {
+				continue
+			}
+
+			kv := splitEscaped(pair, "=")
+			if len(kv) != 2 {
+				return nil, fmt.Errorf("invalid pair %q, want key=value", strings.TrimSpace(pair))
+			}
+			key, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+			if key == "" {
+				return nil, fmt.Errorf("empty key in pair %q", strings.TrimSpace(pair))
+			}
+			if _, dup := pairs[key]; dup {
+				return nil, fmt.Errorf("duplicate key %q", key)
+			}
+			pairs[key] = value
+		}
+		return pairs, nil
+	}
+}
+
+// splitEscaped splits s around each delimiter that is not preceded by a
+// backslash and removes the backslash from escaped delimiters. Escapes of
+// other separators are kept, so a nested split can still see them.
+func splitEscaped(s, delimiter string) []string {
+	if delimiter == "" {
+		return []string{s}
+	}
+
+	var parts []string
+	var current strings.Builder
+	for len(s) > 0 {
+		switch {
+		case strings.HasPrefix(s, `\`+delimiter):
+			current.WriteString(delimiter)
+			s = s[len(delimiter)+1:]
+		case strings.HasPrefix(s, delimiter):
+			parts = append(parts, current.String())
+			current.Reset()
+			s = s[len(delimiter):]
+		default:
+			current.WriteByte(s[0])
+			s = s[1:]
+		}
+	}
+	return append(parts, current.String())
+}
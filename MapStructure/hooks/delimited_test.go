@@ -0,0 +1,153 @@
+package hooks
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+func TestStringToSliceHook(t *testing.T) {
+	type target struct {
+		Server struct {
+			Hosts []string `mapstructure:"hosts"`
+		} `mapstructure:"server"`
+	}
+
+	tests := []struct {
+		name      string
+		delimiter string
+		input     string
+		want      []string
+	}{
+		{"empty input", ",", "", []string{}},
+		{"only whitespace", ",", "   ", []string{}},
+		{"only delimiters", ",", ",, ,", []string{}},
+		{"single value", ",", "localhost", []string{"localhost"}},
+		{"trimmed", ",", " localhost ,\texample.com\n", []string{"localhost", "example.com"}},
+		{"empty segments", ",", "a,,b,", []string{"a", "b"}},
+		{"escaped delimiter", ",", `a\,b,c`, []string{"a,b", "c"}},
+		{"other backslashes kept", ",", `C:\temp,D:\data`, []string{`C:\temp`, `D:\data`}},
+		{"multi-character delimiter", "::", "a::b:c:: d", []string{"a", "b:c", "d"}},
+		{"pipe delimiter", "|", "a|b\\|c", []string{"a", "b|c"}},
+		{"empty delimiter", "", "a,b", []string{"a,b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out target
+			if err := decode(t, StringToSliceHook(tt.delimiter), wrap("hosts", tt.input), &out); err != nil {
+				t.Fatalf("decode %q: %v", tt.input, err)
+			}
+			if !reflect.DeepEqual(out.Server.Hosts, tt.want) {
+				t.Errorf("decode %q: got %q, want %q", tt.input, out.Server.Hosts, tt.want)
+			}
+		})
+	}
+}
+
+func TestStringToMapHook(t *testing.T) {
+	type target struct {
+		Server struct {
+			Labels map[string]string `mapstructure:"labels"`
+		} `mapstructure:"server"`
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  map[string]string
+	}{
+		{"empty input", "", map[string]string{}},
+		{"pairs", "env=prod;team=ops", map[string]string{"env": "prod", "team": "ops"}},
+		{"trimmed", " env = prod ; team=ops ;", map[string]string{"env": "prod", "team": "ops"}},
+		{"empty value", "env=", map[string]string{"env": ""}},
+		{"escaped separators", `query=a\=b\;c;x=1`, map[string]string{"query": "a=b;c", "x": "1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out target
+			if err := decode(t, StringToMapHook(), wrap("labels", tt.input), &out); err != nil {
+				t.Fatalf("decode %q: %v", tt.input, err)
+			}
+			if !reflect.DeepEqual(out.Server.Labels, tt.want) {
+				t.Errorf("decode %q: got %q, want %q", tt.input, out.Server.Labels, tt.want)
+			}
+		})
+	}
+
+	invalid := []struct {
+		input  string
+		detail string
+	}{
+		{"env", `invalid pair "env", want key=value`},
+		{"a=1=2", `invalid pair "a=1=2", want key=value`},
+		{"=prod", `empty key in pair "=prod"`},
+		{"env=a;env=b", `duplicate key "env"`},
+	}
+	for _, tt := range invalid {
+		var out target
+		err := decode(t, StringToMapHook(), wrap("labels", tt.input), &out)
+		assertPathError(t, err, "server.labels", tt.detail)
+	}
+}
+
+func TestDelimitedHooksWithWeakTyping(t *testing.T) {
+	type target struct {
+		Ports   []int          `mapstructure:"ports"`
+		Hosts   []string       `mapstructure:"hosts"`
+		Weights map[string]int `mapstructure:"weights"`
+	}
+
+	decodeWith := func(hook mapstructure.DecodeHookFunc, weak bool, input map[string]interface{}) (target, error) {
+		var out target
+		decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+			DecodeHook:       hook,
+			WeaklyTypedInput: weak,
+			Result:           &out,
+		})
+		if err != nil {
+			t.Fatalf("NewDecoder: %v", err)
+		}
+		return out, decoder.Decode(input)
+	}
+
+	hook := mapstructure.ComposeDecodeHookFunc(StringToSliceHook(","), StringToMapHook())
+	input := map[string]interface{}{
+		"ports":   "80, 443",
+		"hosts":   "",
+		"weights": "a=1;b=2",
+	}
+
+	// Weak typing converts the split strings into the element types.
+	out, err := decodeWith(hook, true, input)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !reflect.DeepEqual(out.Ports, []int{80, 443}) {
+		t.Errorf("ports = %v", out.Ports)
+	}
+	if out.Hosts == nil || len(out.Hosts) != 0 {
+		t.Errorf("hosts = %#v, want an empty slice", out.Hosts)
+	}
+	if !reflect.DeepEqual(out.Weights, map[string]int{"a": 1, "b": 2}) {
+		t.Errorf("weights = %v", out.Weights)
+	}
+
+	// Without weak typing the string elements do not become ints.
+	_, err = decodeWith(hook, false, input)
+	assertPathError(t, err, "ports[0]", "expected type 'int'")
+
+	// Weak typing alone wraps the whole string in a one-element slice.
+	out, err = decodeWith(nil, true, map[string]interface{}{"hosts": "a,b", "ports": ""})
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !reflect.DeepEqual(out.Hosts, []string{"a,b"}) {
+		t.Errorf("hosts without hook = %q", out.Hosts)
+	}
+	if !reflect.DeepEqual(out.Ports, []int{0}) {
+		t.Errorf("ports without hook = %v", out.Ports)
+	}
+}
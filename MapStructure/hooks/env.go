@@ -0,0 +1,69 @@
+package hooks
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// ExpandEnvHook expands ${VAR} and ${VAR:-default} placeholders in strings
+// decoded into string fields, including strings inside slices. Unlike
+// os.ExpandEnv, a variable that is unset and has no default is an error
+// rather than an empty string. Write $$ for a literal dollar sign.
+func ExpandEnvHook() mapstructure.DecodeHookFunc {
+	return func(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+		if from.Kind() != reflect.String || to.Kind() != reflect.String {
+			return data, nil
+		}
+		return expandEnv(data.(string), os.LookupEnv)
+	}
+}
+
+func expandEnv(s string, lookup func(string) (string, bool)) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		switch s[i+1] {
+		case '$':
+			b.WriteByte('$')
+			i++
+		case '{':
+			end := strings.IndexByte(s[i:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated ${ in %q", s)
+			}
+
+			expr := s[i+2 : i+end]
+			name, fallback, hasDefault := strings.Cut(expr, ":-")
+			if name == "" {
+				return "", fmt.Errorf("empty variable name in %q", s)
+			}
+
+			value, ok := lookup(name)
+			switch {
+			case ok && value != "":
+			case hasDefault:
+				value = fallback
+			case !ok:
+				return "", fmt.Errorf("environment variable %s is not set", name)
+			}
+
+			b.WriteString(value)
+			i += end
+		default:
+			b.WriteByte('$')
+		}
+	}
+	return b.String(), nil
+}
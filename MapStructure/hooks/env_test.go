@@ -0,0 +1,108 @@
+package hooks
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+func TestExpandEnvHook(t *testing.T) {
+	type target struct {
+		Database struct {
+			Password string   `mapstructure:"password"`
+			Hosts    []string `mapstructure:"hosts"`
+		} `mapstructure:"database"`
+	}
+
+	t.Setenv("MS_DB_PASSWORD", "s3cret")
+	t.Setenv("MS_DB_REGION", "eu")
+	t.Setenv("MS_EMPTY", "")
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"${MS_DB_PASSWORD}", "s3cret"},
+		{"prefix-${MS_DB_REGION}-suffix", "prefix-eu-suffix"},
+		{"${MS_DB_REGION}/${MS_DB_PASSWORD}", "eu/s3cret"},
+		{"${MS_UNSET_VAR:-fallback}", "fallback"},
+		{"${MS_DB_REGION:-fallback}", "eu"},
+		{"${MS_EMPTY:-fallback}", "fallback"},
+		{"${MS_EMPTY}", ""},
+		{"${MS_UNSET_VAR:-}", ""},
+		{"cost: $$5", "cost: $5"},
+		{"$${MS_DB_PASSWORD}", "${MS_DB_PASSWORD}"},
+		{"no placeholders", "no placeholders"},
+		{"trailing $", "trailing $"},
+		{"$HOME stays literal", "$HOME stays literal"},
+	}
+
+	for _, tt := range tests {
+		var out target
+		err := decode(t, ExpandEnvHook(), wrapDatabase("password", tt.input), &out)
+		if err != nil {
+			t.Errorf("decode %q: %v", tt.input, err)
+			continue
+		}
+		if out.Database.Password != tt.want {
+			t.Errorf("decode %q: got %q, want %q", tt.input, out.Database.Password, tt.want)
+		}
+	}
+
+	t.Run("strings inside slices", func(t *testing.T) {
+		var out target
+		input := wrapDatabase("hosts", []interface{}{"db-${MS_DB_REGION}-1", "${MS_UNSET_VAR:-db-local}"})
+		if err := decode(t, ExpandEnvHook(), input, &out); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if strings.Join(out.Database.Hosts, ",") != "db-eu-1,db-local" {
+			t.Errorf("hosts = %q", out.Database.Hosts)
+		}
+	})
+
+	t.Run("missing variable", func(t *testing.T) {
+		var out target
+		err := decode(t, ExpandEnvHook(), wrapDatabase("password", "${MS_UNSET_VAR}"), &out)
+		assertPathError(t, err, "database.password", "environment variable MS_UNSET_VAR is not set")
+	})
+
+	t.Run("missing variable inside a slice", func(t *testing.T) {
+		var out target
+		err := decode(t, ExpandEnvHook(), wrapDatabase("hosts", []interface{}{"ok", "${MS_UNSET_VAR}"}), &out)
+		assertPathError(t, err, "database.hosts[1]", "MS_UNSET_VAR is not set")
+	})
+
+	t.Run("malformed placeholders", func(t *testing.T) {
+		for _, input := range []string{"${MS_DB_PASSWORD", "${}", "${:-x}"} {
+			var out target
+			if err := decode(t, ExpandEnvHook(), wrapDatabase("password", input), &out); err == nil {
+				t.Errorf("decode %q: expected an error", input)
+			}
+		}
+	})
+
+	t.Run("composed with the duration hook", func(t *testing.T) {
+		type config struct {
+			Password string        `mapstructure:"password"`
+			Timeout  time.Duration `mapstructure:"timeout"`
+		}
+
+		var out config
+		hook := mapstructure.ComposeDecodeHookFunc(ExpandEnvHook(), mapstructure.StringToTimeDurationHookFunc())
+		err := decode(t, hook, map[string]interface{}{"password": "${MS_DB_PASSWORD}", "timeout": "30s"}, &out)
+		if err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if out.Password != "s3cret" || out.Timeout != 30*time.Second {
+			t.Errorf("got %+v", out)
+		}
+	})
+}
+
+func wrapDatabase(key string, value interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"database": map[string]interface{}{key: value},
+	}
+}
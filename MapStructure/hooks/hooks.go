@@ -0,0 +1,154 @@
+// Package hooks provides reusable mapstructure decode hooks for the types
+// that show up most often in configuration maps: timestamps, URLs, IP
+// addresses, regular expressions, delimited lists and key=value maps.
+//
+// Each hook only acts when the source value is a string and the target type
+// matches, so they can be freely combined with ComposeAll or
+// mapstructure.ComposeDecodeHookFunc.
+package hooks
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// UnixEpoch is a pseudo layout accepted by StringToTimeHook that parses a
+// string of digits as seconds since the Unix epoch.
+const UnixEpoch = "unix"
+
+// DefaultTimeLayouts are tried in order when StringToTimeHook is called
+// without explicit layouts.
+var DefaultTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05",
+	time.DateOnly,
+	UnixEpoch,
+}
+
+var (
+	timeType   = reflect.TypeOf(time.Time{})
+	urlType    = reflect.TypeOf(url.URL{})
+	ipType     = reflect.TypeOf(net.IP{})
+	regexpType = reflect.TypeOf(regexp.Regexp{})
+)
+
+// StringToTimeHook converts strings to time.Time, trying each layout in turn
+// and returning the first successful parse. Pass UnixEpoch as a layout to
+// accept epoch seconds such as "1735137000".
+func StringToTimeHook(layouts ...string) mapstructure.DecodeHookFunc {
+	if len(layouts) == 0 {
+		layouts = DefaultTimeLayouts
+	}
+
+	return func(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+		if from.Kind() != reflect.String || to != timeType {
+			return data, nil
+		}
+
+		value := strings.TrimSpace(data.(string))
+		for _, layout := range layouts {
+			if layout == UnixEpoch {
+				if secs, err := strconv.ParseInt(value, 10, 64); err == nil {
+					return time.Unix(secs, 0).UTC(), nil
+				}
+				continue
+			}
+			if t, err := time.Parse(layout, value); err == nil {
+				return t, nil
+			}
+		}
+
+		return nil, fmt.Errorf("cannot parse %q as time (tried layouts %q)", value, layouts)
+	}
+}
+
+// StringToURLHook converts strings to url.URL or *url.URL. Only absolute
+// URLs are accepted, since a missing scheme is almost always a typo in a
+// config file.
+func StringToURLHook() mapstructure.DecodeHookFunc {
+	return func(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+		if from.Kind() != reflect.String || indirect(to) != urlType {
+			return data, nil
+		}
+
+		u, err := url.Parse(data.(string))
+		if err != nil {
+			return nil, err
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("%q is not an absolute URL", data)
+		}
+
+		return u, nil
+	}
+}
+
+// StringToIPHook converts strings to net.IP, rejecting anything that is not
+// a valid IPv4 or IPv6 address.
+func StringToIPHook() mapstructure.DecodeHookFunc {
+	return func(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+		if from.Kind() != reflect.String || to != ipType {
+			return data, nil
+		}
+
+		ip := net.ParseIP(strings.TrimSpace(data.(string)))
+		if ip == nil {
+			return nil, fmt.Errorf("%q is not a valid IP address", data)
+		}
+
+		return ip, nil
+	}
+}
+
+// StringToRegexpHook compiles strings into regexp.Regexp or *regexp.Regexp.
+func StringToRegexpHook() mapstructure.DecodeHookFunc {
+	return func(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+		if from.Kind() != reflect.String || indirect(to) != regexpType {
+			return data, nil
+		}
+
+		re, err := regexp.Compile(data.(string))
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression: %w", err)
+		}
+
+		return re, nil
+	}
+}
+
+// CommaStringToSliceHook is StringToSliceHook(","): it splits a comma
+// separated string into a slice, trimming whitespace and dropping empty
+// segments. The resulting []string is decoded element by element, so other
+// hooks still apply to the items.
+func CommaStringToSliceHook() mapstructure.DecodeHookFunc {
+	return StringToSliceHook(",")
+}
+
+// ComposeAll bundles every hook in this package together with mapstructure's
+// built-in duration hook.
+func ComposeAll() mapstructure.DecodeHookFunc {
+	return mapstructure.ComposeDecodeHookFunc(
+		mapstructure.StringToTimeDurationHookFunc(),
+		StringToTimeHook(),
+		StringToURLHook(),
+		StringToIPHook(),
+		StringToRegexpHook(),
+		CommaStringToSliceHook(),
+		StringToMapHook(),
+	)
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Ptr {
+		return t.Elem()
+	}
+	return t
+}
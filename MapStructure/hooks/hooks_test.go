@@ -0,0 +1,270 @@
+package hooks
+
+import (
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+func decode(t *testing.T, hook mapstructure.DecodeHookFunc, input map[string]interface{}, out interface{}) error {
+	t.Helper()
+
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: hook,
+		Result:     out,
+	})
+	if err != nil {
+		t.Fatalf("NewDecoder: %v", err)
+	}
+	return decoder.Decode(input)
+}
+
+// wrap nests a value two levels deep so errors carry a dotted field path.
+func wrap(key string, value interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"server": map[string]interface{}{key: value},
+	}
+}
+
+func assertPathError(t *testing.T, err error, path, detail string) {
+	t.Helper()
+
+	if err == nil {
+		t.Fatalf("expected an error for %s, got nil", path)
+	}
+	if !strings.Contains(err.Error(), "'"+path+"'") {
+		t.Errorf("error %q does not mention field path %q", err, path)
+	}
+	if !strings.Contains(err.Error(), detail) {
+		t.Errorf("error %q does not contain %q", err, detail)
+	}
+}
+
+func TestStringToTimeHook(t *testing.T) {
+	type target struct {
+		Server struct {
+			Started time.Time `mapstructure:"started"`
+		} `mapstructure:"server"`
+	}
+
+	want := time.Date(2024, 12, 25, 14, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		layouts []string
+		input   string
+		want    time.Time
+	}{
+		{"rfc3339", nil, "2024-12-25T14:30:00Z", want},
+		{"datetime", nil, "2024-12-25 14:30:00", want},
+		{"date only", nil, "2024-12-25", time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC)},
+		{"unix epoch", nil, "1735137000", want},
+		{"custom layout", []string{"02/01/2006 15:04"}, "25/12/2024 14:30", want},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out target
+			if err := decode(t, StringToTimeHook(tt.layouts...), wrap("started", tt.input), &out); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if !out.Server.Started.Equal(tt.want) {
+				t.Errorf("got %s, want %s", out.Server.Started, tt.want)
+			}
+		})
+	}
+
+	t.Run("no matching layout", func(t *testing.T) {
+		var out target
+		err := decode(t, StringToTimeHook(time.DateOnly), wrap("started", "25/12/2024"), &out)
+		assertPathError(t, err, "server.started", `cannot parse "25/12/2024" as time`)
+	})
+
+	t.Run("epoch not enabled", func(t *testing.T) {
+		var out target
+		err := decode(t, StringToTimeHook(time.RFC3339), wrap("started", "1735137000"), &out)
+		assertPathError(t, err, "server.started", "cannot parse")
+	})
+}
+
+func TestStringToURLHook(t *testing.T) {
+	type target struct {
+		Server struct {
+			Endpoint url.URL  `mapstructure:"endpoint"`
+			Callback *url.URL `mapstructure:"callback"`
+		} `mapstructure:"server"`
+	}
+
+	var out target
+	input := map[string]interface{}{
+		"server": map[string]interface{}{
+			"endpoint": "https://api.example.com/v1",
+			"callback": "http://localhost:8080/cb?x=1",
+		},
+	}
+	if err := decode(t, StringToURLHook(), input, &out); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if out.Server.Endpoint.Host != "api.example.com" || out.Server.Endpoint.Path != "/v1" {
+		t.Errorf("unexpected endpoint: %#v", out.Server.Endpoint)
+	}
+	if out.Server.Callback == nil || out.Server.Callback.Query().Get("x") != "1" {
+		t.Errorf("unexpected callback: %v", out.Server.Callback)
+	}
+
+	t.Run("relative URL", func(t *testing.T) {
+		var out target
+		err := decode(t, StringToURLHook(), wrap("endpoint", "/just/a/path"), &out)
+		assertPathError(t, err, "server.endpoint", "is not an absolute URL")
+	})
+
+	t.Run("unparseable URL", func(t *testing.T) {
+		var out target
+		err := decode(t, StringToURLHook(), wrap("callback", "http://[::1"), &out)
+		assertPathError(t, err, "server.callback", "missing ']'")
+	})
+}
+
+func TestStringToIPHook(t *testing.T) {
+	type target struct {
+		Server struct {
+			Bind net.IP `mapstructure:"bind"`
+		} `mapstructure:"server"`
+	}
+
+	for _, input := range []string{"10.0.0.1", "::1", " 192.168.1.10 "} {
+		var out target
+		if err := decode(t, StringToIPHook(), wrap("bind", input), &out); err != nil {
+			t.Fatalf("decode %q: %v", input, err)
+		}
+		if !out.Server.Bind.Equal(net.ParseIP(strings.TrimSpace(input))) {
+			t.Errorf("decode %q: got %v", input, out.Server.Bind)
+		}
+	}
+
+	t.Run("invalid IP", func(t *testing.T) {
+		var out target
+		err := decode(t, StringToIPHook(), wrap("bind", "300.1.1.1"), &out)
+		assertPathError(t, err, "server.bind", `"300.1.1.1" is not a valid IP address`)
+	})
+}
+
+func TestStringToRegexpHook(t *testing.T) {
+	type target struct {
+		Server struct {
+			Allow *regexp.Regexp `mapstructure:"allow"`
+			Deny  regexp.Regexp  `mapstructure:"deny"`
+		} `mapstructure:"server"`
+	}
+
+	var out target
+	input := map[string]interface{}{
+		"server": map[string]interface{}{
+			"allow": `^/api/v\d+/`,
+			"deny":  `\.php$`,
+		},
+	}
+	if err := decode(t, StringToRegexpHook(), input, &out); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !out.Server.Allow.MatchString("/api/v2/users") {
+		t.Errorf("allow pattern did not match: %s", out.Server.Allow)
+	}
+	if !out.Server.Deny.MatchString("/index.php") {
+		t.Errorf("deny pattern did not match: %s", out.Server.Deny.String())
+	}
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		var out target
+		err := decode(t, StringToRegexpHook(), wrap("allow", "([a-z"), &out)
+		assertPathError(t, err, "server.allow", "invalid regular expression")
+	})
+}
+
+func TestCommaStringToSliceHook(t *testing.T) {
+	type target struct {
+		Server struct {
+			Hosts []string `mapstructure:"hosts"`
+		} `mapstructure:"server"`
+	}
+
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"localhost,example.com", []string{"localhost", "example.com"}},
+		{" a , b ,, c ", []string{"a", "b", "c"}},
+		{"", []string{}},
+	}
+
+	for _, tt := range tests {
+		var out target
+		if err := decode(t, CommaStringToSliceHook(), wrap("hosts", tt.input), &out); err != nil {
+			t.Fatalf("decode %q: %v", tt.input, err)
+		}
+		if strings.Join(out.Server.Hosts, "|") != strings.Join(tt.want, "|") || len(out.Server.Hosts) != len(tt.want) {
+			t.Errorf("decode %q: got %q, want %q", tt.input, out.Server.Hosts, tt.want)
+		}
+	}
+}
+
+func TestComposeAll(t *testing.T) {
+	type target struct {
+		Timeout  time.Duration    `mapstructure:"timeout"`
+		Started  time.Time        `mapstructure:"started"`
+		Endpoint *url.URL         `mapstructure:"endpoint"`
+		Bind     net.IP           `mapstructure:"bind"`
+		Pattern  *regexp.Regexp   `mapstructure:"pattern"`
+		Peers    []net.IP         `mapstructure:"peers"`
+		Windows  []time.Time      `mapstructure:"windows"`
+		Limits   map[string]int64 `mapstructure:"limits"`
+	}
+
+	input := map[string]interface{}{
+		"timeout":  "1m30s",
+		"started":  "2024-12-25",
+		"endpoint": "https://example.com",
+		"bind":     "127.0.0.1",
+		"pattern":  "^ok$",
+		"peers":    "10.0.0.1, 10.0.0.2",
+		"windows":  "2024-01-01,1735137000",
+		"limits":   map[string]interface{}{"rps": 100},
+	}
+
+	var out target
+	if err := decode(t, ComposeAll(), input, &out); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	if out.Timeout != 90*time.Second {
+		t.Errorf("timeout = %s", out.Timeout)
+	}
+	if out.Started.Format(time.DateOnly) != "2024-12-25" {
+		t.Errorf("started = %s", out.Started)
+	}
+	if out.Endpoint.Host != "example.com" {
+		t.Errorf("endpoint = %v", out.Endpoint)
+	}
+	if !out.Bind.Equal(net.IPv4(127, 0, 0, 1)) {
+		t.Errorf("bind = %v", out.Bind)
+	}
+	if !out.Pattern.MatchString("ok") {
+		t.Errorf("pattern = %v", out.Pattern)
+	}
+	if len(out.Peers) != 2 || !out.Peers[1].Equal(net.ParseIP("10.0.0.2")) {
+		t.Errorf("peers = %v", out.Peers)
+	}
+	if len(out.Windows) != 2 || out.Windows[1].Unix() != 1735137000 {
+		t.Errorf("windows = %v", out.Windows)
+	}
+
+	t.Run("element errors carry the index", func(t *testing.T) {
+		var out target
+		err := decode(t, ComposeAll(), map[string]interface{}{"peers": "10.0.0.1,nope"}, &out)
+		assertPathError(t, err, "peers[1]", `"nope" is not a valid IP address`)
+	})
+}
@@ -3,54 +3,203 @@ package main
 import (
 	"bufio"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"net"
+	"net/url"
 	"os"
 	"reflect"
+	"regexp"
 	"runtime"
+	"strconv"
+	"strings"
 	"time"
 
+	"example.com/mapstructure-demo/hooks"
 	"github.com/mitchellh/mapstructure"
+	"gopkg.in/yaml.v3"
 )
 
-func main() {
-	fmt.Println("🗺️ MapStructure Library Demo")
-	fmt.Println("=============================")
+// Demo types shared by the sections below and the Encode round-trip tests.
 
-	// Basic map to struct conversion
-	fmt.Println("\n1. 📦 Basic Map to Struct")
-	basicMapToStruct()
+type Person struct {
+	Name string
+	Age  int
+	City string
+}
 
-	// JSON to struct via map
-	fmt.Println("\n2. 🔄 JSON to Struct via Map")
-	jsonToStruct()
+type Product struct {
+	ID    int     `mapstructure:"id"`
+	Name  string  `mapstructure:"name"`
+	Price float64 `mapstructure:"price"`
+}
 
-	// Nested structures
-	fmt.Println("\n3. 🏗️ Nested Structures")
-	nestedStructures()
+type Address struct {
+	Street  string `mapstructure:"street"`
+	City    string `mapstructure:"city"`
+	ZipCode string `mapstructure:"zip_code"`
+}
 
-	// Custom field mapping with tags
-	fmt.Println("\n4. 🏷️ Custom Field Mapping")
-	customFieldMapping()
+type User struct {
+	Name    string  `mapstructure:"name"`
+	Email   string  `mapstructure:"email"`
+	Address Address `mapstructure:"address"`
+}
 
-	// Type conversion and hooks
-	fmt.Println("\n5. 🔧 Type Conversion & Hooks")
-	typeConversionHooks()
+type Event struct {
+	Name      string        `mapstructure:"name"`
+	Timestamp time.Time     `mapstructure:"timestamp"`
+	Duration  time.Duration `mapstructure:"duration"`
+}
 
-	// Slice and array handling
-	fmt.Println("\n6. 📋 Slice and Array Handling")
-	sliceArrayHandling()
+type Team struct {
+	Name    string   `mapstructure:"name"`
+	Members []string `mapstructure:"members"`
+	Scores  []int    `mapstructure:"scores"`
+}
 
-	// Error handling and validation
-	fmt.Println("\n7. ❌ Error Handling")
-	errorHandling()
+type Task struct {
+	ID        int    `mapstructure:"id"`
+	Title     string `mapstructure:"title"`
+	Completed bool   `mapstructure:"completed"`
+}
 
-	// Advanced configuration
-	fmt.Println("\n8. ⚙️ Advanced Configuration")
-	advancedConfiguration()
+type Project struct {
+	Name  string `mapstructure:"name"`
+	Tasks []Task `mapstructure:"tasks"`
+}
 
-	// Real-world examples
-	fmt.Println("\n9. 🌍 Real-World Examples")
-	realWorldExamples()
+type EmbeddedStruct struct {
+	ID   int    `mapstructure:"id"`
+	Name string `mapstructure:"name"`
+}
+
+type ContainerStruct struct {
+	EmbeddedStruct `mapstructure:",squash"`
+	Extra          string `mapstructure:"extra"`
+}
+
+type DatabaseConfig struct {
+	Host           string        `mapstructure:"host" required:"true"`
+	Port           int           `mapstructure:"port" default:"5432"`
+	Username       string        `mapstructure:"username" required:"true"`
+	Password       string        `mapstructure:"password" required:"true"`
+	Database       string        `mapstructure:"database"`
+	MaxConnections int           `mapstructure:"max_connections"`
+	ConnectTimeout time.Duration `mapstructure:"connect_timeout" default:"10s"`
+	SSL            bool          `mapstructure:"ssl" default:"true"`
+}
+
+type ServerConfig struct {
+	Port         int      `mapstructure:"port"`
+	Host         string   `mapstructure:"host"`
+	AllowedHosts []string `mapstructure:"allowed_hosts"`
+	TLS          struct {
+		Enabled  bool   `mapstructure:"enabled"`
+		CertFile string `mapstructure:"cert_file"`
+		KeyFile  string `mapstructure:"key_file"`
+	} `mapstructure:"tls"`
+}
+
+type AppConfig struct {
+	Debug    bool           `mapstructure:"debug"`
+	LogLevel string         `mapstructure:"log_level"`
+	Server   ServerConfig   `mapstructure:"server"`
+	Database DatabaseConfig `mapstructure:"database"`
+}
+
+// Notifier is implemented by the notification channels decoded in the
+// polymorphic decoding section.
+type Notifier interface {
+	Notify(message string) string
+}
+
+type EmailNotifier struct {
+	Address string `mapstructure:"address"`
+	Subject string `mapstructure:"subject"`
+}
+
+func (n *EmailNotifier) Notify(message string) string {
+	return fmt.Sprintf("email to %s [%s]: %s", n.Address, n.Subject, message)
+}
+
+type SMSNotifier struct {
+	Number string `mapstructure:"number"`
+}
+
+func (n *SMSNotifier) Notify(message string) string {
+	return fmt.Sprintf("sms to %s: %s", n.Number, message)
+}
+
+type WebhookNotifier struct {
+	URL     *url.URL      `mapstructure:"url"`
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+func (n *WebhookNotifier) Notify(message string) string {
+	return fmt.Sprintf("POST %s (timeout %s): %s", n.URL, n.Timeout, message)
+}
+
+// notifierRegistry maps the "type" discriminator to a concrete Notifier.
+var notifierRegistry = map[string]func() Notifier{
+	"email":   func() Notifier { return &EmailNotifier{} },
+	"sms":     func() Notifier { return &SMSNotifier{} },
+	"webhook": func() Notifier { return &WebhookNotifier{} },
+}
+
+// demoSection is one numbered part of the demo.
+type demoSection struct {
+	id          int
+	name        string
+	description string
+	run         func()
+}
+
+// sections lists every demo section in the order main runs them.
+var sections = []demoSection{
+	{1, "📦 Basic Map to Struct", "Decode a flat map into a struct", basicMapToStruct},
+	{2, "🔄 JSON to Struct via Map", "Unmarshal JSON into a map, then decode it", jsonToStruct},
+	{3, "🏗️ Nested Structures", "Decode a nested map into nested structs", nestedStructures},
+	{4, "🏷️ Custom Field Mapping", "Map input keys to fields with mapstructure tags", customFieldMapping},
+	{5, "🔧 Type Conversion & Hooks", "Weak typing and custom decode hooks", typeConversionHooks},
+	{6, "📋 Slice and Array Handling", "Slices of scalars and structs", sliceArrayHandling},
+	{7, "❌ Error Handling", "Strict decoding and errors with full field paths", errorHandling},
+	{8, "⚙️ Advanced Configuration", "Remain, squash, tag conflict detection and remain round-trips", advancedConfiguration},
+	{9, "🌍 Real-World Examples", "Database, API response and app config with env expansion and defaults", realWorldExamples},
+	{10, "🪝 Reusable Decode Hooks", "Time, URL, IP, regexp and list hooks from the hooks package", reusableHooks},
+	{11, "🔁 Struct to Map Encoding", "Encode structs back into maps", structToMap},
+	{12, "🔎 Decode Metadata Report", "Used, unused and unset keys after a decode", decodeMetadataReport},
+	{13, "✅ Required Field Validation", `required:"true" checked against the input keys`, requiredFieldValidation},
+	{14, "🧱 Layered Configuration (Deep Merge)", "Deep merge defaults, file config and overrides", layeredConfiguration},
+	{15, "🧬 Polymorphic Decoding", `Decode a list into types chosen by a "type" key`, polymorphicDecoding},
+	{16, "📄 YAML to Struct via Map", "Normalize interface{}-keyed YAML maps before decoding", yamlToStruct},
+	{17, "🧮 Weakly Typed Input Matrix", "What weak typing converts, and what DecodeWeakChecked rejects", weakTypingMatrix},
+}
+
+func main() {
+	only := flag.String("only", "", "comma-separated section numbers to run, e.g. 5,9")
+	list := flag.Bool("list", false, "list the demo sections and exit")
+	flag.Parse()
+
+	if *list {
+		listSections(os.Stdout)
+		return
+	}
+
+	selected, err := selectSections(*only)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	fmt.Println("🗺️ MapStructure Library Demo")
+	fmt.Println("=============================")
+
+	for _, section := range selected {
+		runSection(section)
+	}
 
 	// Prevent terminal window from closing on Windows
 	if runtime.GOOS == "windows" {
@@ -59,15 +208,52 @@ func main() {
 	}
 }
 
-// 1. Basic Map to Struct
-func basicMapToStruct() {
-	// Define a simple struct
-	type Person struct {
-		Name string
-		Age  int
-		City string
+// runSection prints the section header and runs it.
+func runSection(section demoSection) {
+	fmt.Printf("\n%d. %s\n", section.id, section.name)
+	section.run()
+}
+
+// listSections prints the numbered sections with their descriptions.
+func listSections(w io.Writer) {
+	for _, section := range sections {
+		fmt.Fprintf(w, "%2d. %s\n    %s\n", section.id, section.name, section.description)
+	}
+}
+
+// selectSections parses the --only value ("5,9") into sections, in the order
+// given. An empty value selects every section.
+func selectSections(only string) ([]demoSection, error) {
+	if strings.TrimSpace(only) == "" {
+		return sections, nil
+	}
+
+	byID := make(map[int]demoSection, len(sections))
+	for _, section := range sections {
+		byID[section.id] = section
+	}
+
+	var selected []demoSection
+	seen := make(map[int]bool)
+	for _, field := range strings.Split(only, ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return nil, fmt.Errorf("--only: %q is not a section number", strings.TrimSpace(field))
+		}
+		section, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("--only: no section %d (use --list to see them)", id)
+		}
+		if !seen[id] {
+			seen[id] = true
+			selected = append(selected, section)
+		}
 	}
+	return selected, nil
+}
 
+// 1. Basic Map to Struct
+func basicMapToStruct() {
 	// Source map
 	input := map[string]interface{}{
 		"name": "John Doe",
@@ -75,8 +261,7 @@ func basicMapToStruct() {
 		"city": "New York",
 	}
 
-	var result Person
-	err := mapstructure.Decode(input, &result)
+	result, err := Decode[Person](input)
 	if err != nil {
 		fmt.Printf("   ❌ Error: %v\n", err)
 		return
@@ -88,12 +273,6 @@ func basicMapToStruct() {
 
 // 2. JSON to Struct via Map
 func jsonToStruct() {
-	type Product struct {
-		ID    int     `mapstructure:"id"`
-		Name  string  `mapstructure:"name"`
-		Price float64 `mapstructure:"price"`
-	}
-
 	// JSON data
 	jsonData := `{
 		"id": 123,
@@ -110,8 +289,7 @@ func jsonToStruct() {
 	}
 
 	// Convert map to struct
-	var product Product
-	err = mapstructure.Decode(inputMap, &product)
+	product, err := Decode[Product](inputMap)
 	if err != nil {
 		fmt.Printf("   ❌ MapStructure error: %v\n", err)
 		return
@@ -124,18 +302,6 @@ func jsonToStruct() {
 
 // 3. Nested Structures
 func nestedStructures() {
-	type Address struct {
-		Street  string `mapstructure:"street"`
-		City    string `mapstructure:"city"`
-		ZipCode string `mapstructure:"zip_code"`
-	}
-
-	type User struct {
-		Name    string  `mapstructure:"name"`
-		Email   string  `mapstructure:"email"`
-		Address Address `mapstructure:"address"`
-	}
-
 	input := map[string]interface{}{
 		"name":  "Alice Smith",
 		"email": "alice@example.com",
@@ -146,8 +312,7 @@ func nestedStructures() {
 		},
 	}
 
-	var user User
-	err := mapstructure.Decode(input, &user)
+	user, err := Decode[User](input)
 	if err != nil {
 		fmt.Printf("   ❌ Error: %v\n", err)
 		return
@@ -174,8 +339,7 @@ func customFieldMapping() {
 		"request_timeout": 30,
 	}
 
-	var config Config
-	err := mapstructure.Decode(input, &config)
+	config, err := Decode[Config](input)
 	if err != nil {
 		fmt.Printf("   ❌ Error: %v\n", err)
 		return
@@ -189,33 +353,17 @@ func customFieldMapping() {
 
 // 5. Type Conversion & Hooks
 func typeConversionHooks() {
-	type Event struct {
-		Name      string    `mapstructure:"name"`
-		Timestamp time.Time `mapstructure:"timestamp"`
-		Duration  time.Duration `mapstructure:"duration"`
-	}
-
-	// Custom decode hook for time parsing
-	timeHook := func(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
-		if to == reflect.TypeOf(time.Time{}) && from == reflect.TypeOf("") {
-			return time.Parse("2006-01-02 15:04:05", data.(string))
-		}
-		if to == reflect.TypeOf(time.Duration(0)) && from == reflect.TypeOf("") {
-			return time.ParseDuration(data.(string))
-		}
-		return data, nil
-	}
-
 	input := map[string]interface{}{
 		"name":      "Meeting",
 		"timestamp": "2024-12-25 14:30:00",
 		"duration":  "2h30m",
 	}
 
-	var event Event
+	// The raw API: build a DecoderConfig, create a decoder, then decode
+	var rawEvent Event
 	config := &mapstructure.DecoderConfig{
-		DecodeHook: timeHook,
-		Result:     &event,
+		DecodeHook: hooks.ComposeAll(),
+		Result:     &rawEvent,
 	}
 
 	decoder, err := mapstructure.NewDecoder(config)
@@ -230,6 +378,14 @@ func typeConversionHooks() {
 		return
 	}
 
+	// The same decode with the generic helper used everywhere else
+	event, err := Decode[Event](input, WithDecodeHook(hooks.ComposeAll()))
+	if err != nil {
+		fmt.Printf("   ❌ Decode error: %v\n", err)
+		return
+	}
+	fmt.Printf("   🔁 Raw API and Decode[Event] agree: %t\n", rawEvent == event)
+
 	fmt.Printf("   📅 Event: %+v\n", event)
 	fmt.Printf("   ⏰ Timestamp: %s\n", event.Timestamp.Format("2006-01-02 15:04:05"))
 	fmt.Printf("   ⏱️ Duration: %s\n", event.Duration)
@@ -237,20 +393,13 @@ func typeConversionHooks() {
 
 // 6. Slice and Array Handling
 func sliceArrayHandling() {
-	type Team struct {
-		Name    string   `mapstructure:"name"`
-		Members []string `mapstructure:"members"`
-		Scores  []int    `mapstructure:"scores"`
-	}
-
 	input := map[string]interface{}{
 		"name":    "Development Team",
 		"members": []interface{}{"Alice", "Bob", "Charlie"},
 		"scores":  []interface{}{85, 92, 78},
 	}
 
-	var team Team
-	err := mapstructure.Decode(input, &team)
+	team, err := Decode[Team](input)
 	if err != nil {
 		fmt.Printf("   ❌ Error: %v\n", err)
 		return
@@ -261,16 +410,6 @@ func sliceArrayHandling() {
 	fmt.Printf("   📊 Scores: %v\n", team.Scores)
 
 	// Nested struct slices
-	type Task struct {
-		ID          int    `mapstructure:"id"`
-		Title       string `mapstructure:"title"`
-		Completed   bool   `mapstructure:"completed"`
-	}
-
-	type Project struct {
-		Name  string `mapstructure:"name"`
-		Tasks []Task `mapstructure:"tasks"`
-	}
 
 	projectInput := map[string]interface{}{
 		"name": "Website Redesign",
@@ -288,8 +427,7 @@ func sliceArrayHandling() {
 		},
 	}
 
-	var project Project
-	err = mapstructure.Decode(projectInput, &project)
+	project, err := Decode[Project](projectInput)
 	if err != nil {
 		fmt.Printf("   ❌ Project decode error: %v\n", err)
 		return
@@ -310,80 +448,100 @@ func errorHandling() {
 	type StrictConfig struct {
 		RequiredField string `mapstructure:"required"`
 		NumberField   int    `mapstructure:"number"`
+		Comment       string `mapstructure:"comment" optional:"true"`
 	}
 
-	// Test with missing required field
-	fmt.Println("   🧪 Testing missing field:")
+	// Plain Decode silently accepts a missing field
+	fmt.Println("   🧪 Missing field with plain Decode:")
 	input1 := map[string]interface{}{
 		"number": 42,
 		// "required" field is missing
 	}
 
-	var config1 StrictConfig
-	err := mapstructure.Decode(input1, &config1)
+	config1, err := Decode[StrictConfig](input1)
 	if err != nil {
-		fmt.Printf("   ❌ Expected error: %v\n", err)
+		fmt.Printf("   ❌ Error: %v\n", err)
 	} else {
 		fmt.Printf("   ⚠️ No error, result: %+v\n", config1)
 	}
 
-	// Test with type mismatch
-	fmt.Println("   🧪 Testing type mismatch:")
+	// Strict decoding reports typos, missing fields and type errors together
+	fmt.Println("   🧪 Same input plus a typo and a type mismatch with WithStrict:")
 	input2 := map[string]interface{}{
-		"required": "hello",
-		"number":   "not_a_number", // Should be int
+		"number":  "not_a_number", // Should be int
+		"reqired": "hello",        // Typo of "required"
 	}
 
-	var config2 StrictConfig
-	err = mapstructure.Decode(input2, &config2)
+	_, err = Decode[StrictConfig](input2, WithStrict())
 	if err != nil {
-		fmt.Printf("   ❌ Type conversion error: %v\n", err)
-	} else {
-		fmt.Printf("   ✅ Successful conversion: %+v\n", config2)
+		fmt.Printf("   ❌ %s\n", indent(err.Error()))
 	}
 
-	// Using WeaklyTypedInput for flexible conversion
-	fmt.Println("   🧪 Using WeaklyTypedInput:")
-	var config3 StrictConfig
-	decoder, _ := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
-		WeaklyTypedInput: true,
-		Result:           &config3,
-	})
+	// Fields tagged optional:"true" can be whitelisted
+	fmt.Println("   🧪 Optional fields whitelisted with WithOptionalTag:")
+	input3 := map[string]interface{}{
+		"required": "hello",
+		"number":   "42",
+	}
 
-	err = decoder.Decode(input2)
+	_, err = Decode[StrictConfig](input3, WithStrict(), WithOptionalTag())
+	if err != nil {
+		fmt.Printf("   ❌ %s\n", indent(err.Error()))
+	}
+
+	// Weak typing fixes the string number, the optional comment is skipped
+	config4, err := Decode[StrictConfig](input3, WithStrict(), WithOptionalTag(), WithWeakTyping())
 	if err != nil {
 		fmt.Printf("   ❌ Still failed: %v\n", err)
 	} else {
-		fmt.Printf("   ✅ Weakly typed conversion: %+v\n", config3)
+		fmt.Printf("   ✅ Weakly typed strict decode: %+v\n", config4)
+	}
+
+	// Decoding one section at a time loses the parent path
+	fmt.Println("   🧪 Decoding sections on their own:")
+	appInput := map[string]interface{}{
+		"server": map[string]interface{}{
+			"port": "eighty",
+			"tls":  map[string]interface{}{"enabled": "yes please"},
+		},
+		"database": map[string]interface{}{"host": "db.internal", "port": "five"},
 	}
+
+	_, err = Decode[ServerConfig](appInput["server"])
+	fmt.Printf("   ❌ %s\n", indent(err.Error()))
+	_, err = Decode[DatabaseConfig](appInput["database"])
+	fmt.Printf("   ❌ %s\n", indent(err.Error()))
+
+	// WrapWithPath keeps the context and FormatDecodeError groups by section
+	fmt.Println("   🧪 Same sections with WrapWithPath and FormatDecodeError:")
+	var app AppConfig
+	err = errors.Join(
+		WrapWithPath("server", appInput, &app.Server),
+		WrapWithPath("database", appInput, &app.Database),
+	)
+	fmt.Printf("   ❌ %s\n", indent(FormatDecodeError(err)))
+}
+
+// indent aligns multi-line messages with the demo output.
+func indent(s string) string {
+	return strings.ReplaceAll(s, "\n", "\n   ")
 }
 
 // 8. Advanced Configuration
 func advancedConfiguration() {
 	type FlexibleStruct struct {
-		KnownField   string                 `mapstructure:"known"`
+		KnownField    string                 `mapstructure:"known"`
 		UnknownFields map[string]interface{} `mapstructure:",remain"`
 	}
 
 	input := map[string]interface{}{
-		"known":    "This is known",
-		"extra1":   "This is extra",
-		"extra2":   42,
-		"extra3":   true,
-	}
-
-	var result FlexibleStruct
-	config := &mapstructure.DecoderConfig{
-		Result:   &result,
+		"known":  "This is known",
+		"extra1": "This is extra",
+		"extra2": 42,
+		"extra3": true,
 	}
 
-	decoder, err := mapstructure.NewDecoder(config)
-	if err != nil {
-		fmt.Printf("   ❌ Decoder error: %v\n", err)
-		return
-	}
-
-	err = decoder.Decode(input)
+	result, err := Decode[FlexibleStruct](input)
 	if err != nil {
 		fmt.Printf("   ❌ Decode error: %v\n", err)
 		return
@@ -393,15 +551,6 @@ func advancedConfiguration() {
 	fmt.Printf("   🗃️ Unknown fields: %+v\n", result.UnknownFields)
 
 	// Using squash for embedding
-	type EmbeddedStruct struct {
-		ID   int    `mapstructure:"id"`
-		Name string `mapstructure:"name"`
-	}
-
-	type ContainerStruct struct {
-		EmbeddedStruct `mapstructure:",squash"`
-		Extra          string `mapstructure:"extra"`
-	}
 
 	squashInput := map[string]interface{}{
 		"id":    123,
@@ -409,53 +558,104 @@ func advancedConfiguration() {
 		"extra": "Extra Field",
 	}
 
-	var container ContainerStruct
-	err = mapstructure.Decode(squashInput, &container)
+	container, err := Decode[ContainerStruct](squashInput)
 	if err != nil {
 		fmt.Printf("   ❌ Squash error: %v\n", err)
 		return
 	}
 
 	fmt.Printf("   🔗 Container: %+v\n", container)
-	fmt.Printf("   📌 ID: %d, Name: %s, Extra: %s\n", 
+	fmt.Printf("   📌 ID: %d, Name: %s, Extra: %s\n",
 		container.ID, container.Name, container.Extra)
+
+	// Squash and a field of the outer struct can claim the same key
+	type AuditFields struct {
+		ID        int    `mapstructure:"id"`
+		CreatedBy string `mapstructure:"created_by"`
+	}
+	type Record struct {
+		AuditFields `mapstructure:",squash"`
+		ID          string                 `mapstructure:"id"`
+		Rest        map[string]interface{} `mapstructure:",remain"`
+	}
+
+	if err := DetectTagConflicts(reflect.TypeOf(Record{})); err != nil {
+		fmt.Printf("   🚫 Record rejected up front: %v\n", err)
+	}
+
+	// Squash and remain together, then re-decode the leftovers
+	type ServiceBase struct {
+		Name    string `mapstructure:"name"`
+		Version string `mapstructure:"version"`
+	}
+	type Service struct {
+		ServiceBase `mapstructure:",squash"`
+		Port        int                    `mapstructure:"port"`
+		Rest        map[string]interface{} `mapstructure:",remain"`
+	}
+	type Observability struct {
+		MetricsPath string  `mapstructure:"metrics_path"`
+		TraceRate   float64 `mapstructure:"trace_rate"`
+	}
+
+	if err := DetectTagConflicts(reflect.TypeOf(Service{})); err != nil {
+		fmt.Printf("   ❌ Unexpected conflict: %v\n", err)
+		return
+	}
+
+	serviceInput := map[string]interface{}{
+		"name":         "billing",
+		"version":      "1.4.2",
+		"port":         9090,
+		"metrics_path": "/metrics",
+		"trace_rate":   0.25,
+	}
+
+	service, err := Decode[Service](serviceInput)
+	if err != nil {
+		fmt.Printf("   ❌ Service error: %v\n", err)
+		return
+	}
+	fmt.Printf("   🧩 Service: %s %s on :%d, rest: %v\n", service.Name, service.Version, service.Port, service.Rest)
+
+	observability, err := Decode[Observability](service.Rest, WithStrict())
+	if err != nil {
+		fmt.Printf("   ❌ Remain re-decode error: %v\n", err)
+		return
+	}
+	fmt.Printf("   📈 Observability from remain: %+v\n", observability)
+
+	encoded, err := Encode(service)
+	if err != nil {
+		fmt.Printf("   ❌ Encode error: %v\n", err)
+		return
+	}
+	fmt.Printf("   🔁 Encoded back to the input: %t\n", reflect.DeepEqual(encoded, serviceInput))
 }
 
 // 9. Real-World Examples
 func realWorldExamples() {
 	// Database configuration example
 	fmt.Println("   🗄️ Database Configuration:")
-	
-	type DatabaseConfig struct {
-		Host            string        `mapstructure:"host"`
-		Port            int           `mapstructure:"port"`
-		Username        string        `mapstructure:"username"`
-		Password        string        `mapstructure:"password"`
-		Database        string        `mapstructure:"database"`
-		MaxConnections  int           `mapstructure:"max_connections"`
-		ConnectTimeout  time.Duration `mapstructure:"connect_timeout"`
-		SSL             bool          `mapstructure:"ssl"`
-	}
 
+	// port, ssl and connect_timeout come from the default tags
 	dbConfigMap := map[string]interface{}{
 		"host":            "localhost",
-		"port":            5432,
 		"username":        "admin",
-		"password":        "secret",
+		"password":        "${DB_PASSWORD:-secret}", // expanded from the environment
 		"database":        "myapp",
 		"max_connections": 25,
-		"connect_timeout": "30s",
-		"ssl":             true,
 	}
 
-	var dbConfig DatabaseConfig
-	config := &mapstructure.DecoderConfig{
-		DecodeHook: mapstructure.StringToTimeDurationHookFunc(),
-		Result:     &dbConfig,
-	}
+	// Expand ${VAR} placeholders, parse durations and split lists in one pass
+	envHook := mapstructure.ComposeDecodeHookFunc(
+		hooks.ExpandEnvHook(),
+		mapstructure.StringToTimeDurationHookFunc(),
+		hooks.StringToSliceHook(","),
+	)
 
-	decoder, _ := mapstructure.NewDecoder(config)
-	err := decoder.Decode(dbConfigMap)
+	var dbConfig DatabaseConfig
+	err := DecodeWithDefaults(dbConfigMap, &dbConfig, WithDecodeHook(envHook))
 	if err != nil {
 		fmt.Printf("   ❌ DB config error: %v\n", err)
 		return
@@ -463,12 +663,14 @@ func realWorldExamples() {
 
 	fmt.Printf("   🔗 Connection: %s:%d\n", dbConfig.Host, dbConfig.Port)
 	fmt.Printf("   👤 User: %s\n", dbConfig.Username)
+	fmt.Printf("   🔑 Password: %s\n", passwordSource(dbConfig.Password))
 	fmt.Printf("   🗄️ Database: %s\n", dbConfig.Database)
 	fmt.Printf("   🔒 SSL: %t\n", dbConfig.SSL)
+	fmt.Printf("   ⏱️ Connect Timeout: %s\n", dbConfig.ConnectTimeout)
 
 	// API Response parsing
 	fmt.Println("\n   📡 API Response Parsing:")
-	
+
 	type APIResponse struct {
 		Status   string      `mapstructure:"status"`
 		Message  string      `mapstructure:"message"`
@@ -499,8 +701,7 @@ func realWorldExamples() {
 	var apiMap map[string]interface{}
 	json.Unmarshal([]byte(apiJSON), &apiMap)
 
-	var apiResponse APIResponse
-	err = mapstructure.Decode(apiMap, &apiResponse)
+	apiResponse, err := Decode[APIResponse](apiMap)
 	if err != nil {
 		fmt.Printf("   ❌ API parse error: %v\n", err)
 		return
@@ -508,31 +709,13 @@ func realWorldExamples() {
 
 	fmt.Printf("   📊 Status: %s\n", apiResponse.Status)
 	fmt.Printf("   💬 Message: %s\n", apiResponse.Message)
-	fmt.Printf("   📄 Page: %d/%d (Total: %d)\n", 
-		apiResponse.Metadata.Page, 
-		apiResponse.Metadata.TotalPages, 
+	fmt.Printf("   📄 Page: %d/%d (Total: %d)\n",
+		apiResponse.Metadata.Page,
+		apiResponse.Metadata.TotalPages,
 		apiResponse.Metadata.Total)
 
 	// Configuration file parsing
 	fmt.Println("\n   ⚙️ Application Configuration:")
-	
-	type ServerConfig struct {
-		Port         int      `mapstructure:"port"`
-		Host         string   `mapstructure:"host"`
-		AllowedHosts []string `mapstructure:"allowed_hosts"`
-		TLS          struct {
-			Enabled  bool   `mapstructure:"enabled"`
-			CertFile string `mapstructure:"cert_file"`
-			KeyFile  string `mapstructure:"key_file"`
-		} `mapstructure:"tls"`
-	}
-
-	type AppConfig struct {
-		Debug    bool           `mapstructure:"debug"`
-		LogLevel string         `mapstructure:"log_level"`
-		Server   ServerConfig   `mapstructure:"server"`
-		Database DatabaseConfig `mapstructure:"database"`
-	}
 
 	configMap := map[string]interface{}{
 		"debug":     true,
@@ -540,7 +723,7 @@ func realWorldExamples() {
 		"server": map[string]interface{}{
 			"port":          8080,
 			"host":          "0.0.0.0",
-			"allowed_hosts": []interface{}{"localhost", "example.com"},
+			"allowed_hosts": "localhost, example.com", // as it arrives from an env var
 			"tls": map[string]interface{}{
 				"enabled":   true,
 				"cert_file": "/path/to/cert.pem",
@@ -551,13 +734,7 @@ func realWorldExamples() {
 	}
 
 	var appConfig AppConfig
-	config = &mapstructure.DecoderConfig{
-		DecodeHook: mapstructure.StringToTimeDurationHookFunc(),
-		Result:     &appConfig,
-	}
-
-	decoder, _ = mapstructure.NewDecoder(config)
-	err = decoder.Decode(configMap)
+	err = DecodeWithDefaults(configMap, &appConfig, WithDecodeHook(envHook))
 	if err != nil {
 		fmt.Printf("   ❌ App config error: %v\n", err)
 		return
@@ -569,3 +746,355 @@ func realWorldExamples() {
 	fmt.Printf("   🔒 TLS: %t\n", appConfig.Server.TLS.Enabled)
 	fmt.Printf("   🏠 Allowed Hosts: %v\n", appConfig.Server.AllowedHosts)
 }
+
+// passwordSource describes where the demo password came from without
+// printing it.
+func passwordSource(password string) string {
+	if _, ok := os.LookupEnv("DB_PASSWORD"); ok && password != "" {
+		return fmt.Sprintf("%d characters from $DB_PASSWORD", len(password))
+	}
+	return fmt.Sprintf("default %q (set DB_PASSWORD to override)", password)
+}
+
+// 10. Reusable Decode Hooks
+func reusableHooks() {
+	// Timestamps in several layouts
+	type Schedule struct {
+		Start   time.Time `mapstructure:"start"`
+		End     time.Time `mapstructure:"end"`
+		Created time.Time `mapstructure:"created"`
+	}
+
+	schedule, err := Decode[Schedule](map[string]interface{}{
+		"start":   "2024-12-25T09:00:00Z",
+		"end":     "2024-12-31",
+		"created": "1735137000",
+	}, WithDecodeHook(hooks.StringToTimeHook()))
+	if err != nil {
+		fmt.Printf("   ❌ Time hook error: %v\n", err)
+		return
+	}
+	fmt.Printf("   📅 RFC3339: %s\n", schedule.Start.Format(time.RFC3339))
+	fmt.Printf("   📅 Date only: %s\n", schedule.End.Format(time.DateOnly))
+	fmt.Printf("   📅 Unix epoch: %s\n", schedule.Created.Format(time.RFC3339))
+
+	// URL, IP, regexp, comma separated lists and key=value maps
+	type Gateway struct {
+		Upstream *url.URL          `mapstructure:"upstream"`
+		Bind     net.IP            `mapstructure:"bind"`
+		Route    *regexp.Regexp    `mapstructure:"route"`
+		Hosts    []string          `mapstructure:"hosts"`
+		Labels   map[string]string `mapstructure:"labels"`
+	}
+
+	gateway, err := Decode[Gateway](map[string]interface{}{
+		"upstream": "https://backend.internal:9000/api",
+		"bind":     "0.0.0.0",
+		"route":    `^/v\d+/users/\d+$`,
+		"hosts":    "localhost, example.com",
+		"labels":   "tier=edge; region=eu-west",
+	}, WithDecodeHook(hooks.ComposeAll()))
+	if err != nil {
+		fmt.Printf("   ❌ Composed hook error: %v\n", err)
+		return
+	}
+	fmt.Printf("   🔗 URL: host=%s path=%s\n", gateway.Upstream.Host, gateway.Upstream.Path)
+	fmt.Printf("   🌐 IP: %s\n", gateway.Bind)
+	fmt.Printf("   🔍 Regexp matches /v1/users/42: %t\n", gateway.Route.MatchString("/v1/users/42"))
+	fmt.Printf("   📋 Hosts: %q\n", gateway.Hosts)
+	fmt.Printf("   🏷️ Labels: %v\n", gateway.Labels)
+
+	// Failures report the field that could not be decoded
+	fmt.Println("   🧪 Invalid values:")
+	_, err = Decode[Gateway](map[string]interface{}{"bind": "300.1.1.1"},
+		WithDecodeHook(hooks.ComposeAll()))
+	fmt.Printf("   ❌ %v\n", err)
+
+	_, err = Decode[Schedule](map[string]interface{}{"start": "25/12/2024"},
+		WithDecodeHook(hooks.StringToTimeHook(time.DateOnly)))
+	fmt.Printf("   ❌ %v\n", err)
+}
+
+// 11. Struct to Map Encoding
+func structToMap() {
+	original := DatabaseConfig{
+		Host:           "db.internal",
+		Port:           5432,
+		Username:       "admin",
+		Password:       "secret",
+		Database:       "myapp",
+		MaxConnections: 25,
+		ConnectTimeout: 30 * time.Second,
+		SSL:            true,
+	}
+
+	encoded, err := Encode(original)
+	if err != nil {
+		fmt.Printf("   ❌ Encode error: %v\n", err)
+		return
+	}
+	fmt.Printf("   🗺️ Encoded: %v\n", encoded)
+
+	decoded, err := Decode[DatabaseConfig](encoded, WithStrict(), WithDecodeHook(hooks.ComposeAll()))
+	if err != nil {
+		fmt.Printf("   ❌ Decode error: %v\n", err)
+		return
+	}
+	fmt.Printf("   📦 Decoded: %+v\n", decoded)
+	fmt.Printf("   🔁 Round-trip equal: %t\n", decoded == original)
+
+	// Squashed fields are flattened and omitempty fields are dropped
+	type Patch struct {
+		EmbeddedStruct `mapstructure:",squash"`
+		Note           string `mapstructure:"note,omitempty"`
+	}
+
+	patch, err := Encode(Patch{EmbeddedStruct: EmbeddedStruct{ID: 7, Name: "renamed"}})
+	if err != nil {
+		fmt.Printf("   ❌ Encode error: %v\n", err)
+		return
+	}
+	fmt.Printf("   🩹 Patch: %v\n", patch)
+}
+
+// 12. Decode Metadata Report
+func decodeMetadataReport() {
+	// "servre" is a typo, so server.port never gets a value
+	input := map[string]interface{}{
+		"debug":     true,
+		"log_level": "info",
+		"server": map[string]interface{}{
+			"host":          "0.0.0.0",
+			"allowed_hosts": []interface{}{"localhost"},
+		},
+		"servre": map[string]interface{}{
+			"port": 8080,
+		},
+	}
+
+	var appConfig AppConfig
+	report, err := DecodeWithReport(input, &appConfig, WithDecodeHook(hooks.ComposeAll()))
+	if err != nil {
+		fmt.Printf("   ❌ Decode error: %v\n", err)
+		return
+	}
+
+	fmt.Printf("   ⚠️ Server port after decode: %d\n", appConfig.Server.Port)
+	fmt.Printf("   📋 %s\n", indent(report.String()))
+}
+
+// 13. Required Field Validation
+func requiredFieldValidation() {
+	input := map[string]interface{}{
+		"log_level": "info",
+		"database": map[string]interface{}{
+			"host":     "db.internal",
+			"username": "admin",
+			// "password" is missing
+		},
+	}
+
+	// Plain Decode happily leaves the password empty
+	if unchecked, err := Decode[AppConfig](input); err == nil {
+		fmt.Printf("   ⚠️ Decode succeeded with password %q\n", unchecked.Database.Password)
+	}
+
+	// DecodeValidated checks fields tagged required:"true"
+	var appConfig AppConfig
+	err := DecodeValidated(input, &appConfig, WithDecodeHook(hooks.ComposeAll()))
+	if err != nil {
+		fmt.Printf("   ❌ %v\n", err)
+	}
+
+	input["database"].(map[string]interface{})["password"] = "secret"
+	err = DecodeValidated(input, &appConfig, WithDecodeHook(hooks.ComposeAll()))
+	if err != nil {
+		fmt.Printf("   ❌ %v\n", err)
+		return
+	}
+	fmt.Printf("   ✅ Valid config for %s@%s\n", appConfig.Database.Username, appConfig.Database.Host)
+}
+
+// 14. Layered Configuration (Deep Merge)
+func layeredConfiguration() {
+	defaults := map[string]interface{}{
+		"debug":     true,
+		"log_level": "debug",
+		"server": map[string]interface{}{
+			"port":          8080,
+			"host":          "127.0.0.1",
+			"allowed_hosts": []interface{}{"localhost"},
+			"tls": map[string]interface{}{
+				"enabled":   false,
+				"cert_file": "/etc/ssl/dev.pem",
+				"key_file":  "/etc/ssl/dev.key",
+			},
+		},
+		"database": map[string]interface{}{
+			"host":            "localhost",
+			"port":            5432,
+			"database":        "myapp",
+			"max_connections": 5,
+			"connect_timeout": "5s",
+		},
+	}
+
+	productionOverrides := map[string]interface{}{
+		"debug":     false,
+		"log_level": "warn",
+		"server": map[string]interface{}{
+			"host":          "0.0.0.0",
+			"allowed_hosts": []interface{}{"example.com", "www.example.com"},
+			"tls":           map[string]interface{}{"enabled": true},
+		},
+		"database": map[string]interface{}{
+			"host":            "db.prod.internal",
+			"max_connections": 50,
+			"connect_timeout": "30s",
+		},
+	}
+
+	var appConfig AppConfig
+	if err := DecodeMerged(&appConfig, defaults, productionOverrides); err != nil {
+		fmt.Printf("   ❌ Merge decode error: %v\n", err)
+		return
+	}
+
+	fmt.Printf("   🐛 Debug: %t, Log Level: %s\n", appConfig.Debug, appConfig.LogLevel)
+	fmt.Printf("   🌐 Server: %s:%d (port from defaults)\n", appConfig.Server.Host, appConfig.Server.Port)
+	fmt.Printf("   🏠 Allowed Hosts: %v (slice replaced)\n", appConfig.Server.AllowedHosts)
+	fmt.Printf("   🔒 TLS: %t with %s (nested map merged)\n", appConfig.Server.TLS.Enabled, appConfig.Server.TLS.CertFile)
+	fmt.Printf("   🗄️ Database: %s:%d/%s, %d connections, %s timeout\n",
+		appConfig.Database.Host, appConfig.Database.Port, appConfig.Database.Database,
+		appConfig.Database.MaxConnections, appConfig.Database.ConnectTimeout)
+}
+
+// 15. Polymorphic Decoding
+func polymorphicDecoding() {
+	notificationsJSON := `[
+		{"type": "email", "address": "ops@example.com", "subject": "Alert"},
+		{"type": "sms", "number": "+15550100"},
+		{"type": "webhook", "url": "https://hooks.example.com/alerts", "timeout": "5s"}
+	]`
+
+	var raw []interface{}
+	if err := json.Unmarshal([]byte(notificationsJSON), &raw); err != nil {
+		fmt.Printf("   ❌ JSON parse error: %v\n", err)
+		return
+	}
+
+	notifiers, err := DecodePolymorphic(raw, notifierRegistry, WithStrict(), WithDecodeHook(hooks.ComposeAll()))
+	if err != nil {
+		fmt.Printf("   ❌ Decode error: %v\n", err)
+		return
+	}
+	for _, notifier := range notifiers {
+		fmt.Printf("   📣 %T -> %s\n", notifier, notifier.Notify("disk almost full"))
+	}
+
+	// Unknown and missing discriminators are reported per element
+	fmt.Println("   🧪 Invalid elements:")
+	_, err = DecodePolymorphic([]interface{}{
+		map[string]interface{}{"type": "fax", "number": "123"},
+		map[string]interface{}{"address": "ops@example.com"},
+	}, notifierRegistry)
+	fmt.Printf("   ❌ %s\n", indent(err.Error()))
+}
+
+// 16. YAML to Struct via Map
+func yamlToStruct() {
+	yamlData := `
+debug: false
+log_level: warn
+server:
+  host: 0.0.0.0
+  port: 8443
+  allowed_hosts:
+    - example.com
+    - www.example.com
+  tls:
+    enabled: true
+    cert_file: /etc/ssl/app.pem
+    key_file: /etc/ssl/app.key
+database:
+  host: db.internal
+  username: app
+  password: ${DB_PASSWORD:-secret}
+  connect_timeout: 15s
+status_pages:
+  404: /errors/not-found.html
+  500: /errors/server.html
+`
+
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal([]byte(yamlData), &raw); err != nil {
+		fmt.Printf("   ❌ YAML parse error: %v\n", err)
+		return
+	}
+
+	// Integer keys make yaml produce an interface{}-keyed map
+	fmt.Printf("   🔑 status_pages before: %T\n", raw["status_pages"])
+
+	if _, err := Decode[map[string]string](raw["status_pages"]); err != nil {
+		fmt.Printf("   ⚠️ Decoding without normalizing fails: %s\n", indent(err.Error()))
+	}
+
+	normalized, err := NormalizeYAMLMap(raw)
+	if err != nil {
+		fmt.Printf("   ❌ Normalize error: %v\n", err)
+		return
+	}
+	fmt.Printf("   🔑 status_pages after: %T\n", normalized["status_pages"])
+
+	type SiteConfig struct {
+		AppConfig   `mapstructure:",squash"`
+		StatusPages map[string]string `mapstructure:"status_pages"`
+	}
+
+	hook := mapstructure.ComposeDecodeHookFunc(hooks.ExpandEnvHook(), hooks.ComposeAll())
+	var site SiteConfig
+	if err := DecodeWithDefaults(normalized, &site, WithDecodeHook(hook)); err != nil {
+		fmt.Printf("   ❌ Decode error: %v\n", err)
+		return
+	}
+
+	fmt.Printf("   🌐 Server: %s:%d, TLS: %t\n", site.Server.Host, site.Server.Port, site.Server.TLS.Enabled)
+	fmt.Printf("   🏠 Allowed Hosts: %v\n", site.Server.AllowedHosts)
+	fmt.Printf("   🗄️ Database: %s:%d (timeout %s)\n", site.Database.Host, site.Database.Port, site.Database.ConnectTimeout)
+	fmt.Printf("   📄 Status Pages: %v\n", site.StatusPages)
+}
+
+// 17. Weakly Typed Input Matrix
+func weakTypingMatrix() {
+	// Even without weak typing mapstructure truncates 1.5 and wraps 300 into an int8
+	for _, mode := range []conversionMode{modeStrict, modeWeak, modeWeakChecked} {
+		fmt.Printf("   📊 %s:\n", mode)
+		fmt.Printf("      %s\n", strings.ReplaceAll(formatMatrix(conversionMatrix(mode)), "\n", "\n      "))
+	}
+
+	type Limits struct {
+		Retries  int8 `mapstructure:"retries"`
+		Replicas uint `mapstructure:"replicas"`
+		Workers  int  `mapstructure:"workers"`
+	}
+
+	input := map[string]interface{}{
+		"limits": map[string]interface{}{"retries": 300, "replicas": -1, "workers": "4"},
+	}
+
+	weak, err := Decode[struct {
+		Limits Limits `mapstructure:"limits"`
+	}](input, WithWeakTyping())
+	if err != nil {
+		fmt.Printf("   ❌ Decode error: %v\n", err)
+	} else {
+		fmt.Printf("   ⚠️ Weak decode: %+v\n", weak.Limits)
+	}
+
+	var checked struct {
+		Limits Limits `mapstructure:"limits"`
+	}
+	if err := DecodeWeakChecked(input, &checked); err != nil {
+		fmt.Printf("   ✅ Checked decode rejects lossy values:\n   %s\n", indent(err.Error()))
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// captureStdout runs fn with os.Stdout redirected and returns what it wrote.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("os.Pipe: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	done := make(chan string)
+	go func() {
+		var buf bytes.Buffer
+		io.Copy(&buf, r)
+		done <- buf.String()
+	}()
+
+	fn()
+	w.Close()
+	return <-done
+}
+
+func TestSectionsRun(t *testing.T) {
+	for _, section := range sections {
+		t.Run(section.name, func(t *testing.T) {
+			var recovered interface{}
+			out := captureStdout(t, func() {
+				defer func() { recovered = recover() }()
+				runSection(section)
+			})
+			if recovered != nil {
+				t.Fatalf("section %d panicked: %v", section.id, recovered)
+			}
+
+			header := fmt.Sprintf("\n%d. %s\n", section.id, section.name)
+			if !strings.HasPrefix(out, header) {
+				t.Errorf("section %d output does not start with its header:\n%s", section.id, out)
+			}
+		})
+	}
+}
+
+func TestSectionIDsAreSequential(t *testing.T) {
+	for i, section := range sections {
+		if section.id != i+1 {
+			t.Errorf("sections[%d].id = %d, want %d", i, section.id, i+1)
+		}
+	}
+}
+
+func TestSelectSections(t *testing.T) {
+	ids := func(selected []demoSection) []int {
+		var out []int
+		for _, section := range selected {
+			out = append(out, section.id)
+		}
+		return out
+	}
+
+	tests := []struct {
+		only string
+		want []int
+	}{
+		{"5,9", []int{5, 9}},
+		{" 9 , 5 ", []int{9, 5}},
+		{"3,3", []int{3}},
+	}
+	for _, tt := range tests {
+		selected, err := selectSections(tt.only)
+		if err != nil {
+			t.Fatalf("selectSections(%q): %v", tt.only, err)
+		}
+		if got := ids(selected); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("selectSections(%q) = %v, want %v", tt.only, got, tt.want)
+		}
+	}
+
+	if all, err := selectSections(""); err != nil || len(all) != len(sections) {
+		t.Errorf("selectSections(\"\") = %d sections, %v", len(all), err)
+	}
+
+	for _, only := range []string{"42", "five", "1,"} {
+		if _, err := selectSections(only); err == nil {
+			t.Errorf("selectSections(%q): expected an error", only)
+		}
+	}
+}
+
+func TestListSections(t *testing.T) {
+	var buf bytes.Buffer
+	listSections(&buf)
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2*len(sections) {
+		t.Fatalf("got %d lines, want %d:\n%s", len(lines), 2*len(sections), buf.String())
+	}
+	if want := " 5. " + sections[4].name; lines[8] != want {
+		t.Errorf("line 9 = %q, want %q", lines[8], want)
+	}
+}
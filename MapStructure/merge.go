@@ -0,0 +1,92 @@
+package main
+
+import (
+	"example.com/mapstructure-demo/hooks"
+	"github.com/mitchellh/mapstructure"
+)
+
+// MergeOptions controls how MergeMapsWithOptions resolves conflicts.
+type MergeOptions struct {
+	// NilDeletes removes a key from the result when a later map sets it to
+	// nil. Without it a nil value simply replaces the earlier one.
+	NilDeletes bool
+}
+
+// MergeMaps deep-merges srcs into dst in order, so later maps win. Nested
+// maps are merged key by key; scalars and slices are replaced wholesale.
+// Values taken from srcs are copied, so the sources are never modified by
+// later merges. dst is modified in place and returned (a new map is
+// allocated when dst is nil).
+func MergeMaps(dst map[string]interface{}, srcs ...map[string]interface{}) map[string]interface{} {
+	return MergeMapsWithOptions(MergeOptions{}, dst, srcs...)
+}
+
+// MergeMapsWithOptions is MergeMaps with explicit conflict options.
+func MergeMapsWithOptions(opts MergeOptions, dst map[string]interface{}, srcs ...map[string]interface{}) map[string]interface{} {
+	if dst == nil {
+		dst = make(map[string]interface{})
+	}
+	for _, src := range srcs {
+		mergeInto(dst, src, opts)
+	}
+	return dst
+}
+
+// DecodeMerged layers maps with MergeMaps and decodes the result into output
+// using hooks.ComposeAll(), since layered config usually comes from files
+// where durations and times are strings.
+func DecodeMerged(output interface{}, maps ...map[string]interface{}) error {
+	merged := MergeMaps(nil, maps...)
+
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: hooks.ComposeAll(),
+		Result:     output,
+	})
+	if err != nil {
+		return err
+	}
+	return decoder.Decode(merged)
+}
+
+func mergeInto(dst, src map[string]interface{}, opts MergeOptions) {
+	for key, value := range src {
+		if value == nil && opts.NilDeletes {
+			delete(dst, key)
+			continue
+		}
+
+		if srcMap, ok := value.(map[string]interface{}); ok {
+			dstMap, ok := dst[key].(map[string]interface{})
+			if !ok {
+				// Replace any scalar with a fresh map so src stays untouched.
+				dstMap = make(map[string]interface{}, len(srcMap))
+				dst[key] = dstMap
+			}
+			mergeInto(dstMap, srcMap, opts)
+			continue
+		}
+
+		dst[key] = deepCopy(value)
+	}
+}
+
+// deepCopy copies slices (and maps nested inside them) so that replaced
+// values never alias a source map.
+func deepCopy(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			out[key] = deepCopy(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = deepCopy(item)
+		}
+		return out
+	default:
+		return value
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMergeMapsPrecedence(t *testing.T) {
+	defaults := map[string]interface{}{"log_level": "info", "debug": false, "workers": 4}
+	file := map[string]interface{}{"log_level": "warn", "debug": true}
+	overrides := map[string]interface{}{"log_level": "error"}
+
+	got := MergeMaps(nil, defaults, file, overrides)
+	want := map[string]interface{}{"log_level": "error", "debug": true, "workers": 4}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// Reversing the order reverses who wins.
+	got = MergeMaps(nil, overrides, file, defaults)
+	want = map[string]interface{}{"log_level": "info", "debug": false, "workers": 4}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("reversed: got %v, want %v", got, want)
+	}
+}
+
+func TestMergeMapsNested(t *testing.T) {
+	defaults := map[string]interface{}{
+		"server": map[string]interface{}{
+			"host": "0.0.0.0",
+			"port": 8080,
+			"tls":  map[string]interface{}{"enabled": false, "cert_file": "dev.pem"},
+		},
+	}
+	overrides := map[string]interface{}{
+		"server": map[string]interface{}{
+			"port": 443,
+			"tls":  map[string]interface{}{"enabled": true},
+		},
+	}
+
+	got := MergeMaps(nil, defaults, overrides)
+	want := map[string]interface{}{
+		"server": map[string]interface{}{
+			"host": "0.0.0.0",
+			"port": 443,
+			"tls":  map[string]interface{}{"enabled": true, "cert_file": "dev.pem"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestMergeMapsReplacesSlicesAndScalars(t *testing.T) {
+	defaults := map[string]interface{}{
+		"allowed_hosts": []interface{}{"localhost", "127.0.0.1"},
+		"tls":           "off",
+		"limits":        map[string]interface{}{"rps": 10},
+	}
+	overrides := map[string]interface{}{
+		"allowed_hosts": []interface{}{"example.com"},
+		"tls":           map[string]interface{}{"enabled": true},
+		"limits":        100,
+	}
+
+	got := MergeMaps(nil, defaults, overrides)
+	want := map[string]interface{}{
+		"allowed_hosts": []interface{}{"example.com"},
+		"tls":           map[string]interface{}{"enabled": true},
+		"limits":        100,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestMergeMapsNilValues(t *testing.T) {
+	base := map[string]interface{}{
+		"password": "secret",
+		"server":   map[string]interface{}{"host": "h", "port": 1},
+	}
+	patch := map[string]interface{}{
+		"password": nil,
+		"server":   map[string]interface{}{"port": nil},
+	}
+
+	got := MergeMaps(nil, base, patch)
+	want := map[string]interface{}{
+		"password": nil,
+		"server":   map[string]interface{}{"host": "h", "port": nil},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("without NilDeletes: got %v, want %v", got, want)
+	}
+
+	got = MergeMapsWithOptions(MergeOptions{NilDeletes: true}, nil, base, patch)
+	want = map[string]interface{}{
+		"server": map[string]interface{}{"host": "h"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("with NilDeletes: got %v, want %v", got, want)
+	}
+}
+
+func TestMergeMapsDoesNotModifySources(t *testing.T) {
+	defaults := map[string]interface{}{
+		"server": map[string]interface{}{"port": 8080},
+		"hosts":  []interface{}{map[string]interface{}{"name": "a"}},
+	}
+	overrides := map[string]interface{}{
+		"server": map[string]interface{}{"port": 443},
+	}
+
+	merged := MergeMaps(nil, defaults, overrides)
+	merged["server"].(map[string]interface{})["host"] = "changed"
+	merged["hosts"].([]interface{})[0].(map[string]interface{})["name"] = "changed"
+
+	wantDefaults := map[string]interface{}{
+		"server": map[string]interface{}{"port": 8080},
+		"hosts":  []interface{}{map[string]interface{}{"name": "a"}},
+	}
+	if !reflect.DeepEqual(defaults, wantDefaults) {
+		t.Errorf("defaults modified: %v", defaults)
+	}
+	if port := overrides["server"].(map[string]interface{})["port"]; port != 443 {
+		t.Errorf("overrides modified: %v", overrides)
+	}
+}
+
+func TestDecodeMerged(t *testing.T) {
+	defaults := map[string]interface{}{
+		"log_level": "info",
+		"database":  map[string]interface{}{"host": "localhost", "port": 5432, "connect_timeout": "5s"},
+	}
+	production := map[string]interface{}{
+		"database": map[string]interface{}{"host": "db.prod", "connect_timeout": "30s"},
+	}
+
+	var cfg AppConfig
+	if err := DecodeMerged(&cfg, defaults, production); err != nil {
+		t.Fatalf("DecodeMerged: %v", err)
+	}
+
+	if cfg.LogLevel != "info" || cfg.Database.Host != "db.prod" || cfg.Database.Port != 5432 {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+	if cfg.Database.ConnectTimeout != 30*time.Second {
+		t.Errorf("ConnectTimeout = %s, want 30s", cfg.Database.ConnectTimeout)
+	}
+}
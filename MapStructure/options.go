@@ -0,0 +1,59 @@
+package main
+
+import "github.com/mitchellh/mapstructure"
+
+// Option tweaks how the decode helpers in this demo configure mapstructure.
+type Option func(*decodeOptions)
+
+type decodeOptions struct {
+	hook        mapstructure.DecodeHookFunc
+	weak        bool
+	tagName     string
+	strict      bool
+	optionalTag bool
+}
+
+func newDecodeOptions(opts []Option) decodeOptions {
+	o := decodeOptions{tagName: "mapstructure"}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// decoderConfig builds a DecoderConfig writing into result.
+func (o decodeOptions) decoderConfig(result interface{}) *mapstructure.DecoderConfig {
+	return &mapstructure.DecoderConfig{
+		DecodeHook:       o.hook,
+		WeaklyTypedInput: o.weak,
+		TagName:          o.tagName,
+		Result:           result,
+	}
+}
+
+// WithDecodeHook installs a decode hook, e.g. hooks.ComposeAll().
+func WithDecodeHook(hook mapstructure.DecodeHookFunc) Option {
+	return func(o *decodeOptions) { o.hook = hook }
+}
+
+// WithWeakTyping enables mapstructure's WeaklyTypedInput conversions.
+func WithWeakTyping() Option {
+	return func(o *decodeOptions) { o.weak = true }
+}
+
+// WithTagName reads field names from a struct tag other than
+// "mapstructure", e.g. "json".
+func WithTagName(name string) Option {
+	return func(o *decodeOptions) { o.tagName = name }
+}
+
+// WithStrict makes Decode behave like DecodeStrict.
+func WithStrict() Option {
+	return func(o *decodeOptions) { o.strict = true }
+}
+
+// WithOptionalTag lets strict decoding skip fields tagged `optional:"true"`
+// when they are missing from the input.
+func WithOptionalTag() Option {
+	return func(o *decodeOptions) { o.optionalTag = true }
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// discriminatorKey is the map key DecodePolymorphic reads to pick a type.
+const discriminatorKey = "type"
+
+// DecodePolymorphic decodes a heterogeneous list such as
+//
+//	[{"type": "email", "address": ...}, {"type": "sms", "number": ...}]
+//
+// by looking up each element's "type" in registry and decoding the rest of
+// the element into the value the factory returns. Factories must return a
+// pointer. Problems with individual elements are collected and returned
+// together, each prefixed with the element index.
+func DecodePolymorphic[T any](input interface{}, registry map[string]func() T, opts ...Option) ([]T, error) {
+	list := reflect.ValueOf(input)
+	if list.Kind() != reflect.Slice && list.Kind() != reflect.Array {
+		return nil, fmt.Errorf("decode polymorphic: expected a list, got %T", input)
+	}
+
+	results := make([]T, 0, list.Len())
+	var errs []error
+	for i := 0; i < list.Len(); i++ {
+		item, err := decodeVariant(list.Index(i).Interface(), registry, opts)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("[%d]: %w", i, err))
+			continue
+		}
+		results = append(results, item)
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return results, nil
+}
+
+func decodeVariant[T any](element interface{}, registry map[string]func() T, opts []Option) (T, error) {
+	var zero T
+
+	fields, ok := element.(map[string]interface{})
+	if !ok {
+		return zero, fmt.Errorf("expected a map, got %T", element)
+	}
+
+	raw, ok := fields[discriminatorKey]
+	if !ok {
+		return zero, fmt.Errorf("missing %q discriminator", discriminatorKey)
+	}
+	name, ok := raw.(string)
+	if !ok {
+		return zero, fmt.Errorf("%q discriminator must be a string, got %T", discriminatorKey, raw)
+	}
+
+	factory, ok := registry[name]
+	if !ok {
+		return zero, fmt.Errorf("unknown %s %q (known: %s)", discriminatorKey, name, strings.Join(registryNames(registry), ", "))
+	}
+
+	target := factory()
+	if reflect.ValueOf(target).Kind() != reflect.Ptr {
+		return zero, fmt.Errorf("factory for %q must return a pointer, got %T", name, target)
+	}
+
+	// Drop the discriminator so strict decoding doesn't flag it as unused.
+	body := make(map[string]interface{}, len(fields)-1)
+	for key, value := range fields {
+		if key != discriminatorKey {
+			body[key] = value
+		}
+	}
+
+	o := newDecodeOptions(opts)
+	if o.strict {
+		return target, DecodeStrict(body, target, opts...)
+	}
+	decoder, err := mapstructure.NewDecoder(o.decoderConfig(target))
+	if err != nil {
+		return zero, err
+	}
+	return target, decoder.Decode(body)
+}
+
+func registryNames[T any](registry map[string]func() T) []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
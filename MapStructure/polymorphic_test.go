@@ -0,0 +1,133 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/mapstructure-demo/hooks"
+)
+
+func TestDecodePolymorphicBranches(t *testing.T) {
+	input := []interface{}{
+		map[string]interface{}{"type": "email", "address": "ops@example.com", "subject": "Alert"},
+		map[string]interface{}{"type": "sms", "number": "+15550100"},
+		map[string]interface{}{"type": "webhook", "url": "https://hooks.example.com/x", "timeout": "5s"},
+	}
+
+	notifiers, err := DecodePolymorphic(input, notifierRegistry, WithDecodeHook(hooks.ComposeAll()))
+	if err != nil {
+		t.Fatalf("DecodePolymorphic: %v", err)
+	}
+	if len(notifiers) != 3 {
+		t.Fatalf("got %d notifiers, want 3", len(notifiers))
+	}
+
+	email, ok := notifiers[0].(*EmailNotifier)
+	if !ok || email.Address != "ops@example.com" || email.Subject != "Alert" {
+		t.Errorf("notifiers[0] = %#v", notifiers[0])
+	}
+	sms, ok := notifiers[1].(*SMSNotifier)
+	if !ok || sms.Number != "+15550100" {
+		t.Errorf("notifiers[1] = %#v", notifiers[1])
+	}
+	webhook, ok := notifiers[2].(*WebhookNotifier)
+	if !ok || webhook.URL.Host != "hooks.example.com" || webhook.Timeout != 5*time.Second {
+		t.Errorf("notifiers[2] = %#v", notifiers[2])
+	}
+}
+
+func TestDecodePolymorphicAnyRegistry(t *testing.T) {
+	registry := map[string]func() interface{}{
+		"point": func() interface{} { return &struct{ X, Y int }{} },
+	}
+
+	items, err := DecodePolymorphic([]interface{}{map[string]interface{}{"type": "point", "x": 1, "y": 2}}, registry)
+	if err != nil {
+		t.Fatalf("DecodePolymorphic: %v", err)
+	}
+	if p := items[0].(*struct{ X, Y int }); p.X != 1 || p.Y != 2 {
+		t.Errorf("got %+v", p)
+	}
+}
+
+func TestDecodePolymorphicErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input interface{}
+		opts  []Option
+		want  []string
+	}{
+		{
+			name:  "unknown discriminator",
+			input: []interface{}{map[string]interface{}{"type": "fax"}},
+			want:  []string{`[0]: unknown type "fax" (known: email, sms, webhook)`},
+		},
+		{
+			name:  "missing discriminator",
+			input: []interface{}{map[string]interface{}{"number": "1"}},
+			want:  []string{`[0]: missing "type" discriminator`},
+		},
+		{
+			name:  "non-string discriminator",
+			input: []interface{}{map[string]interface{}{"type": 7}},
+			want:  []string{`[0]: "type" discriminator must be a string, got int`},
+		},
+		{
+			name:  "element is not a map",
+			input: []interface{}{"email"},
+			want:  []string{"[0]: expected a map, got string"},
+		},
+		{
+			name:  "input is not a list",
+			input: map[string]interface{}{"type": "email"},
+			want:  []string{"expected a list"},
+		},
+		{
+			name:  "decode error in a branch",
+			input: []interface{}{map[string]interface{}{"type": "webhook", "url": "not a url"}},
+			opts:  []Option{WithDecodeHook(hooks.ComposeAll())},
+			want:  []string{"[0]:", "'url'", "is not an absolute URL"},
+		},
+		{
+			name:  "strict mode ignores the discriminator but not typos",
+			input: []interface{}{map[string]interface{}{"type": "sms", "numbr": "1"}},
+			opts:  []Option{WithStrict()},
+			want:  []string{"[0]:", "numbr", "number (string)"},
+		},
+		{
+			name: "errors from several elements are joined",
+			input: []interface{}{
+				map[string]interface{}{"type": "sms", "number": "1"},
+				map[string]interface{}{"type": "pager"},
+				map[string]interface{}{},
+			},
+			want: []string{`[1]: unknown type "pager"`, `[2]: missing "type"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notifiers, err := DecodePolymorphic(tt.input, notifierRegistry, tt.opts...)
+			if err == nil {
+				t.Fatalf("expected an error, got %v", notifiers)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodePolymorphicRequiresPointerFactories(t *testing.T) {
+	registry := map[string]func() interface{}{
+		"value": func() interface{} { return SMSNotifier{} },
+	}
+
+	_, err := DecodePolymorphic([]interface{}{map[string]interface{}{"type": "value"}}, registry)
+	if err == nil || !strings.Contains(err.Error(), "must return a pointer") {
+		t.Errorf("err = %v", err)
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// DecodeReport summarises which parts of the input were used by a decode.
+// All entries are dotted field paths such as "server.tls.enabled".
+type DecodeReport struct {
+	Keys   []string // input keys that were decoded into a field
+	Unused []string // input keys (expanded to leaves) that matched no field
+	Unset  []string // struct fields that received no value
+}
+
+// DecodeWithReport decodes input into output while collecting
+// mapstructure.Metadata, the quickest way to spot typos in config keys.
+// The report is returned even when decoding fails, although mapstructure
+// does not record metadata for structs that produced errors.
+func DecodeWithReport(input, output interface{}, opts ...Option) (DecodeReport, error) {
+	var md mapstructure.Metadata
+	config := newDecodeOptions(opts).decoderConfig(output)
+	config.Metadata = &md
+
+	decoder, err := mapstructure.NewDecoder(config)
+	if err != nil {
+		return DecodeReport{}, err
+	}
+	err = decoder.Decode(input)
+
+	report := DecodeReport{
+		Keys:  append([]string(nil), md.Keys...),
+		Unset: append([]string(nil), md.Unset...),
+	}
+	for _, key := range md.Unused {
+		if value, ok := lookupPath(input, key); ok {
+			report.Unused = append(report.Unused, leafPaths(key, value)...)
+		} else {
+			report.Unused = append(report.Unused, key)
+		}
+	}
+
+	sort.Strings(report.Keys)
+	sort.Strings(report.Unused)
+	sort.Strings(report.Unset)
+	return report, err
+}
+
+// String groups the report by category and then by top-level section.
+func (r DecodeReport) String() string {
+	var b strings.Builder
+	writeGroup(&b, "used", r.Keys)
+	writeGroup(&b, "unused", r.Unused)
+	writeGroup(&b, "unset", r.Unset)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func writeGroup(b *strings.Builder, title string, paths []string) {
+	fmt.Fprintf(b, "%s (%d):\n", title, len(paths))
+
+	sections := make(map[string][]string)
+	var order []string
+	for _, path := range paths {
+		section, rest, nested := strings.Cut(path, ".")
+		if !nested {
+			section, rest = "(root)", path
+		}
+		if _, seen := sections[section]; !seen {
+			order = append(order, section)
+		}
+		sections[section] = append(sections[section], rest)
+	}
+
+	sort.Strings(order)
+	for _, section := range order {
+		fmt.Fprintf(b, "  %s: %s\n", section, strings.Join(sections[section], ", "))
+	}
+}
+
+// lookupPath follows a mapstructure field path ("a.b[2].c") through nested
+// maps and slices of the raw input.
+func lookupPath(input interface{}, path string) (interface{}, bool) {
+	current := reflect.ValueOf(input)
+	for _, segment := range splitPath(path) {
+		for current.Kind() == reflect.Interface || current.Kind() == reflect.Ptr {
+			if current.IsNil() {
+				return nil, false
+			}
+			current = current.Elem()
+		}
+
+		switch current.Kind() {
+		case reflect.Map:
+			next := current.MapIndex(reflect.ValueOf(segment))
+			if !next.IsValid() {
+				return nil, false
+			}
+			current = next
+		case reflect.Slice, reflect.Array:
+			i, err := strconv.Atoi(segment)
+			if err != nil || i < 0 || i >= current.Len() {
+				return nil, false
+			}
+			current = current.Index(i)
+		default:
+			return nil, false
+		}
+	}
+
+	if !current.IsValid() {
+		return nil, false
+	}
+	return current.Interface(), true
+}
+
+// splitPath turns "a.b[2].c" into ["a", "b", "2", "c"].
+func splitPath(path string) []string {
+	path = strings.ReplaceAll(path, "[", ".")
+	path = strings.ReplaceAll(path, "]", "")
+	return strings.Split(path, ".")
+}
+
+// leafPaths lists the dotted paths of every leaf value below prefix.
+func leafPaths(prefix string, value interface{}) []string {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Map || v.Len() == 0 {
+		return []string{prefix}
+	}
+
+	var paths []string
+	iter := v.MapRange()
+	for iter.Next() {
+		key := fmt.Sprint(iter.Key().Interface())
+		paths = append(paths, leafPaths(prefix+"."+key, iter.Value().Interface())...)
+	}
+	return paths
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeWithReportNested(t *testing.T) {
+	input := map[string]interface{}{
+		"debug": true,
+		"server": map[string]interface{}{
+			"host": "0.0.0.0",
+			"tls":  map[string]interface{}{"enabled": true, "cert": "x.pem"},
+		},
+		"servre": map[string]interface{}{
+			"port": 8080,
+		},
+	}
+
+	var cfg AppConfig
+	report, err := DecodeWithReport(input, &cfg)
+	if err != nil {
+		t.Fatalf("DecodeWithReport: %v", err)
+	}
+
+	wantKeys := []string{"debug", "server", "server.host", "server.tls", "server.tls.enabled"}
+	wantUnused := []string{"server.tls.cert", "servre.port"}
+	wantUnset := []string{
+		"database", "log_level",
+		"server.allowed_hosts", "server.port",
+		"server.tls.cert_file", "server.tls.key_file",
+	}
+
+	if !reflect.DeepEqual(report.Keys, wantKeys) {
+		t.Errorf("Keys = %q, want %q", report.Keys, wantKeys)
+	}
+	if !reflect.DeepEqual(report.Unused, wantUnused) {
+		t.Errorf("Unused = %q, want %q", report.Unused, wantUnused)
+	}
+	if !reflect.DeepEqual(report.Unset, wantUnset) {
+		t.Errorf("Unset = %q, want %q", report.Unset, wantUnset)
+	}
+}
+
+func TestDecodeWithReportSquashed(t *testing.T) {
+	input := map[string]interface{}{
+		"id":    1,
+		"extra": "x",
+		"nmae":  "typo",
+	}
+
+	var container ContainerStruct
+	report, err := DecodeWithReport(input, &container)
+	if err != nil {
+		t.Fatalf("DecodeWithReport: %v", err)
+	}
+
+	// Squashed fields are reported at the level they are decoded from.
+	if want := []string{"extra", "id"}; !reflect.DeepEqual(report.Keys, want) {
+		t.Errorf("Keys = %q, want %q", report.Keys, want)
+	}
+	if want := []string{"nmae"}; !reflect.DeepEqual(report.Unused, want) {
+		t.Errorf("Unused = %q, want %q", report.Unused, want)
+	}
+	if want := []string{"name"}; !reflect.DeepEqual(report.Unset, want) {
+		t.Errorf("Unset = %q, want %q", report.Unset, want)
+	}
+}
+
+func TestDecodeWithReportSliceElements(t *testing.T) {
+	input := map[string]interface{}{
+		"name": "site",
+		"tasks": []interface{}{
+			map[string]interface{}{"id": 1, "title": "a", "completed": true},
+			map[string]interface{}{"id": 2, "titel": "b", "meta": map[string]interface{}{"owner": "ops"}},
+		},
+	}
+
+	var project Project
+	report, err := DecodeWithReport(input, &project)
+	if err != nil {
+		t.Fatalf("DecodeWithReport: %v", err)
+	}
+
+	if want := []string{"tasks[1].meta.owner", "tasks[1].titel"}; !reflect.DeepEqual(report.Unused, want) {
+		t.Errorf("Unused = %q, want %q", report.Unused, want)
+	}
+	if want := []string{"tasks[1].completed", "tasks[1].title"}; !reflect.DeepEqual(report.Unset, want) {
+		t.Errorf("Unset = %q, want %q", report.Unset, want)
+	}
+}
+
+func TestDecodeReportString(t *testing.T) {
+	report := DecodeReport{
+		Keys:   []string{"debug", "server", "server.host"},
+		Unused: []string{"servre.port"},
+		Unset:  []string{"server.port", "server.tls.enabled"},
+	}
+
+	want := strings.Join([]string{
+		"used (3):",
+		"  (root): debug, server",
+		"  server: host",
+		"unused (1):",
+		"  servre: port",
+		"unset (2):",
+		"  server: port, tls.enabled",
+	}, "\n")
+	if got := report.String(); got != want {
+		t.Errorf("String() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+var (
+	invalidKeysPattern = regexp.MustCompile(`^'(.*)' has invalid keys: (.+)$`)
+	unsetFieldsPattern = regexp.MustCompile(`^'(.*)' has unset fields: (.+)$`)
+)
+
+// UnsetField is a struct field that received no value from the input.
+type UnsetField struct {
+	Path string
+	Type string
+}
+
+// StrictError collects every problem found by DecodeStrict so they can be
+// fixed in one go instead of one decode attempt at a time.
+type StrictError struct {
+	Unused []string     // input keys that matched no struct field
+	Unset  []UnsetField // struct fields that no input key populated
+	Errors []string     // any other decode errors (type mismatches, hooks)
+}
+
+func (e *StrictError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "strict decode: %d problem(s)", len(e.Unused)+len(e.Unset)+len(e.Errors))
+	if len(e.Unused) > 0 {
+		b.WriteString("\n  unused input keys:")
+		for _, key := range e.Unused {
+			fmt.Fprintf(&b, "\n    - %s", key)
+		}
+	}
+	if len(e.Unset) > 0 {
+		b.WriteString("\n  unset fields:")
+		for _, field := range e.Unset {
+			fmt.Fprintf(&b, "\n    - %s (%s)", field.Path, field.Type)
+		}
+	}
+	if len(e.Errors) > 0 {
+		b.WriteString("\n  decode errors:")
+		for _, msg := range e.Errors {
+			fmt.Fprintf(&b, "\n    - %s", msg)
+		}
+	}
+	return b.String()
+}
+
+// DecodeStrict decodes input into output with ErrorUnused and ErrorUnset
+// enabled, so typos in the input and forgotten fields are both reported.
+// All problems are returned together as a *StrictError.
+func DecodeStrict(input, output interface{}, opts ...Option) error {
+	o := newDecodeOptions(opts)
+	config := o.decoderConfig(output)
+	config.ErrorUnused = true
+	config.ErrorUnset = true
+
+	decoder, err := mapstructure.NewDecoder(config)
+	if err != nil {
+		return err
+	}
+
+	var decodeErr *mapstructure.Error
+	if err := decoder.Decode(input); !errors.As(err, &decodeErr) {
+		return err
+	}
+
+	strictErr := &StrictError{}
+	for _, msg := range decodeErr.Errors {
+		if m := invalidKeysPattern.FindStringSubmatch(msg); m != nil {
+			for _, key := range strings.Split(m[2], ", ") {
+				strictErr.Unused = append(strictErr.Unused, joinPath(m[1], key))
+			}
+			continue
+		}
+
+		if m := unsetFieldsPattern.FindStringSubmatch(msg); m != nil {
+			for _, key := range strings.Split(m[2], ", ") {
+				path := joinPath(m[1], key)
+				field, ok := fieldByPath(reflect.TypeOf(output), path, o.tagName)
+				if ok && o.optionalTag && field.Tag.Get("optional") == "true" {
+					continue
+				}
+
+				typeName := "unknown"
+				if ok {
+					typeName = field.Type.String()
+				}
+				strictErr.Unset = append(strictErr.Unset, UnsetField{Path: path, Type: typeName})
+			}
+			continue
+		}
+
+		strictErr.Errors = append(strictErr.Errors, msg)
+	}
+
+	if len(strictErr.Unused)+len(strictErr.Unset)+len(strictErr.Errors) == 0 {
+		return nil
+	}
+
+	sort.Strings(strictErr.Unused)
+	sort.Slice(strictErr.Unset, func(i, j int) bool {
+		return strictErr.Unset[i].Path < strictErr.Unset[j].Path
+	})
+	sort.Strings(strictErr.Errors)
+	return strictErr
+}
+
+func joinPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// fieldByPath resolves a mapstructure field path such as
+// "server.tls.enabled" or "tasks[0].title" to the struct field it names.
+func fieldByPath(t reflect.Type, path, tagName string) (reflect.StructField, bool) {
+	var field reflect.StructField
+	for _, segment := range strings.Split(path, ".") {
+		name, indexes, hasIndex := strings.Cut(segment, "[")
+
+		// A leading "[0]" segment addresses elements of a top-level slice.
+		if name != "" {
+			t = derefType(t)
+			if t.Kind() != reflect.Struct {
+				return reflect.StructField{}, false
+			}
+
+			var ok bool
+			if field, ok = lookupField(t, name, tagName); !ok {
+				return reflect.StructField{}, false
+			}
+			t = field.Type
+		}
+
+		// Each [index] or [key] steps into a slice, array or map element.
+		depth := 0
+		if hasIndex {
+			depth = 1 + strings.Count(indexes, "[")
+		}
+		for ; depth > 0; depth-- {
+			t = derefType(t)
+			switch t.Kind() {
+			case reflect.Slice, reflect.Array, reflect.Map:
+				t = t.Elem()
+			default:
+				return reflect.StructField{}, false
+			}
+		}
+	}
+	return field, true
+}
+
+// lookupField finds the field mapstructure would match against key,
+// descending into squashed embedded structs.
+func lookupField(t reflect.Type, key, tagName string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, opts, _ := strings.Cut(f.Tag.Get(tagName), ",")
+		if name == "-" {
+			continue
+		}
+
+		if f.Anonymous && strings.Contains(opts, "squash") {
+			if inner, ok := lookupField(derefType(f.Type), key, tagName); ok {
+				return inner, true
+			}
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+		if strings.EqualFold(name, key) {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/mapstructure-demo/hooks"
+)
+
+type strictServer struct {
+	Host    string        `mapstructure:"host"`
+	Port    int           `mapstructure:"port"`
+	Timeout time.Duration `mapstructure:"timeout" optional:"true"`
+}
+
+type strictConfig struct {
+	Name   string       `mapstructure:"name"`
+	Debug  bool         `mapstructure:"debug" optional:"true"`
+	Server strictServer `mapstructure:"server"`
+}
+
+func TestDecodeStrict(t *testing.T) {
+	validServer := map[string]interface{}{"host": "localhost", "port": 8080, "timeout": "5s"}
+
+	tests := []struct {
+		name       string
+		input      map[string]interface{}
+		opts       []Option
+		wantUnused []string
+		wantUnset  []UnsetField
+		wantErrors []string
+	}{
+		{
+			name:  "complete input",
+			input: map[string]interface{}{"name": "api", "debug": true, "server": validServer},
+			opts:  []Option{WithDecodeHook(hooks.ComposeAll())},
+		},
+		{
+			name: "unused keys",
+			input: map[string]interface{}{
+				"name": "api", "debug": true, "colour": "blue",
+				"server": map[string]interface{}{"host": "localhost", "port": 8080, "timeout": 5, "prot": 9090},
+			},
+			wantUnused: []string{"colour", "server.prot"},
+		},
+		{
+			name: "unset fields report expected types",
+			input: map[string]interface{}{
+				"debug":  true,
+				"server": map[string]interface{}{"host": "localhost", "timeout": 5},
+			},
+			wantUnset: []UnsetField{
+				{Path: "name", Type: "string"},
+				{Path: "server.port", Type: "int"},
+			},
+		},
+		{
+			name:      "missing optional fields without the whitelist",
+			input:     map[string]interface{}{"name": "api", "server": map[string]interface{}{"host": "h", "port": 1}},
+			wantUnset: []UnsetField{{Path: "debug", Type: "bool"}, {Path: "server.timeout", Type: "time.Duration"}},
+		},
+		{
+			name:  "missing optional fields with the whitelist",
+			input: map[string]interface{}{"name": "api", "server": map[string]interface{}{"host": "h", "port": 1}},
+			opts:  []Option{WithOptionalTag()},
+		},
+		{
+			name:      "whitelist does not hide required fields",
+			input:     map[string]interface{}{"server": map[string]interface{}{"host": "h"}},
+			opts:      []Option{WithOptionalTag()},
+			wantUnset: []UnsetField{{Path: "name", Type: "string"}, {Path: "server.port", Type: "int"}},
+		},
+		{
+			name: "all problems are aggregated",
+			input: map[string]interface{}{
+				"nmae":   "typo",
+				"debug":  "yes",
+				"server": map[string]interface{}{"host": "h", "port": 1},
+			},
+			opts:       []Option{WithOptionalTag()},
+			wantUnused: []string{"nmae"},
+			wantUnset:  []UnsetField{{Path: "name", Type: "string"}},
+			wantErrors: []string{"'debug' expected type 'bool'"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg strictConfig
+			err := DecodeStrict(tt.input, &cfg, tt.opts...)
+
+			if tt.wantUnused == nil && tt.wantUnset == nil && tt.wantErrors == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var strictErr *StrictError
+			if !errors.As(err, &strictErr) {
+				t.Fatalf("expected *StrictError, got %T: %v", err, err)
+			}
+			if !reflect.DeepEqual(strictErr.Unused, tt.wantUnused) {
+				t.Errorf("Unused = %q, want %q", strictErr.Unused, tt.wantUnused)
+			}
+			if !reflect.DeepEqual(strictErr.Unset, tt.wantUnset) {
+				t.Errorf("Unset = %+v, want %+v", strictErr.Unset, tt.wantUnset)
+			}
+			if len(strictErr.Errors) != len(tt.wantErrors) {
+				t.Fatalf("Errors = %q, want %d entries", strictErr.Errors, len(tt.wantErrors))
+			}
+			for i, want := range tt.wantErrors {
+				if !strings.Contains(strictErr.Errors[i], want) {
+					t.Errorf("Errors[%d] = %q, want it to contain %q", i, strictErr.Errors[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestStrictErrorMessage(t *testing.T) {
+	var cfg strictConfig
+	err := DecodeStrict(map[string]interface{}{"extra": 1}, &cfg, WithOptionalTag())
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	want := `strict decode: 3 problem(s)
+  unused input keys:
+    - extra
+  unset fields:
+    - name (string)
+    - server (main.strictServer)`
+	if err.Error() != want {
+		t.Errorf("message =\n%s\nwant\n%s", err, want)
+	}
+}
+
+func TestFieldByPath(t *testing.T) {
+	type task struct {
+		Title string `mapstructure:"title"`
+	}
+	type embedded struct {
+		ID int `mapstructure:"id"`
+	}
+	type project struct {
+		embedded `mapstructure:",squash"`
+		Tasks    []task          `mapstructure:"tasks"`
+		ByName   map[string]task `mapstructure:"by_name"`
+		Owner    *struct{ Name string }
+	}
+
+	tests := map[string]string{
+		"id":                 "int",
+		"tasks":              "[]main.task",
+		"tasks[3].title":     "string",
+		"by_name[ops].title": "string",
+		"owner.name":         "string",
+	}
+	for path, want := range tests {
+		field, ok := fieldByPath(reflect.TypeOf(&project{}), path, "mapstructure")
+		if !ok {
+			t.Errorf("%s: not found", path)
+			continue
+		}
+		if field.Type.String() != want {
+			t.Errorf("%s: type %s, want %s", path, field.Type, want)
+		}
+	}
+
+	if _, ok := fieldByPath(reflect.TypeOf(project{}), "tasks.missing", "mapstructure"); ok {
+		t.Error("expected tasks.missing to be unresolvable")
+	}
+}
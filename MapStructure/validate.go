@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// MissingFieldsError lists required fields that were absent from the input.
+type MissingFieldsError struct {
+	Paths []string
+}
+
+func (e *MissingFieldsError) Error() string {
+	return "missing required field(s): " + strings.Join(e.Paths, ", ")
+}
+
+// Validate checks every field tagged `required:"true"` against md.Keys, so a
+// field counts as present only when the input supplied it; a value left over
+// from a pre-populated struct or a zero value does not. Nested structs, slice
+// and map elements are checked when their parent was supplied, which keeps
+// whole optional sections optional.
+func Validate(result interface{}, md mapstructure.Metadata) error {
+	present := make(map[string]bool, len(md.Keys))
+	for _, key := range md.Keys {
+		present[key] = true
+	}
+
+	v := reflect.Indirect(reflect.ValueOf(result))
+	if v.Kind() != reflect.Struct {
+		return fmt.Errorf("validate: expected a struct, got %T", result)
+	}
+
+	var missing []string
+	validateStruct(v, "", present, &missing)
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return &MissingFieldsError{Paths: missing}
+	}
+	return nil
+}
+
+// DecodeValidated decodes input into output and then runs Validate.
+func DecodeValidated(input, output interface{}, opts ...Option) error {
+	var md mapstructure.Metadata
+	config := newDecodeOptions(opts).decoderConfig(output)
+	config.Metadata = &md
+
+	decoder, err := mapstructure.NewDecoder(config)
+	if err != nil {
+		return err
+	}
+	if err := decoder.Decode(input); err != nil {
+		return err
+	}
+	return Validate(output, md)
+}
+
+func validateStruct(v reflect.Value, prefix string, present map[string]bool, missing *[]string) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && strings.Contains(opts, "squash") {
+			if inner := reflect.Indirect(v.Field(i)); inner.Kind() == reflect.Struct {
+				validateStruct(inner, prefix, present, missing)
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		path := joinPath(prefix, name)
+		if !present[path] {
+			if field.Tag.Get("required") == "true" {
+				*missing = append(*missing, path)
+			}
+			continue
+		}
+		validateValue(v.Field(i), path, present, missing)
+	}
+}
+
+func validateValue(v reflect.Value, path string, present map[string]bool, missing *[]string) {
+	v = reflect.Indirect(v)
+	switch v.Kind() {
+	case reflect.Struct:
+		validateStruct(v, path, present, missing)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			validateValue(v.Index(i), fmt.Sprintf("%s[%d]", path, i), present, missing)
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			validateValue(iter.Value(), fmt.Sprintf("%s[%v]", path, iter.Key().Interface()), present, missing)
+		}
+	}
+}
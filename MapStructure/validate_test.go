@@ -0,0 +1,136 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type validatedTask struct {
+	Title string `mapstructure:"title" required:"true"`
+	Owner string `mapstructure:"owner"`
+}
+
+type validatedDatabase struct {
+	Host     string `mapstructure:"host" required:"true"`
+	Port     int    `mapstructure:"port"`
+	Password string `mapstructure:"password" required:"true"`
+}
+
+type validatedConfig struct {
+	EmbeddedStruct `mapstructure:",squash"`
+	Database       validatedDatabase            `mapstructure:"database" required:"true"`
+	Cache          *validatedDatabase           `mapstructure:"cache"`
+	Tasks          []validatedTask              `mapstructure:"tasks"`
+	Queues         map[string]validatedDatabase `mapstructure:"queues"`
+}
+
+func missingPaths(t *testing.T, err error) []string {
+	t.Helper()
+
+	if err == nil {
+		return nil
+	}
+	var missingErr *MissingFieldsError
+	if !errors.As(err, &missingErr) {
+		t.Fatalf("expected *MissingFieldsError, got %T: %v", err, err)
+	}
+	return missingErr.Paths
+}
+
+func TestDecodeValidated(t *testing.T) {
+	validDB := map[string]interface{}{"host": "db", "password": "secret"}
+
+	tests := []struct {
+		name  string
+		input map[string]interface{}
+		want  []string
+	}{
+		{
+			name:  "all required fields present",
+			input: map[string]interface{}{"database": validDB},
+		},
+		{
+			name:  "missing required section",
+			input: map[string]interface{}{"id": 1},
+			want:  []string{"database"},
+		},
+		{
+			name:  "nested required field",
+			input: map[string]interface{}{"database": map[string]interface{}{"host": "db", "port": 5432}},
+			want:  []string{"database.password"},
+		},
+		{
+			name: "optional section is only checked when supplied",
+			input: map[string]interface{}{
+				"database": validDB,
+				"cache":    map[string]interface{}{"port": 6379},
+			},
+			want: []string{"cache.host", "cache.password"},
+		},
+		{
+			name: "required fields inside slice elements",
+			input: map[string]interface{}{
+				"database": validDB,
+				"tasks": []interface{}{
+					map[string]interface{}{"title": "ok"},
+					map[string]interface{}{"owner": "ops"},
+					map[string]interface{}{},
+				},
+			},
+			want: []string{"tasks[1].title", "tasks[2].title"},
+		},
+		{
+			name: "required fields inside map values",
+			input: map[string]interface{}{
+				"database": validDB,
+				"queues": map[string]interface{}{
+					"jobs": map[string]interface{}{"host": "mq"},
+				},
+			},
+			want: []string{"queues[jobs].password"},
+		},
+		{
+			name:  "explicit zero values count as present",
+			input: map[string]interface{}{"database": map[string]interface{}{"host": "", "password": ""}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg validatedConfig
+			got := missingPaths(t, DecodeValidated(tt.input, &cfg))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("missing = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateIgnoresPrepopulatedDefaults(t *testing.T) {
+	// A default filled in before decoding does not satisfy required:"true";
+	// only keys present in the input do.
+	cfg := validatedConfig{
+		Database: validatedDatabase{Host: "localhost", Password: "changeme"},
+	}
+	input := map[string]interface{}{
+		"database": map[string]interface{}{"port": 5433},
+	}
+
+	got := missingPaths(t, DecodeValidated(input, &cfg))
+	want := []string{"database.host", "database.password"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("missing = %q, want %q", got, want)
+	}
+	if cfg.Database.Host != "localhost" || cfg.Database.Port != 5433 {
+		t.Errorf("decode should keep defaults for absent keys, got %+v", cfg.Database)
+	}
+}
+
+func TestMissingFieldsErrorMessage(t *testing.T) {
+	err := &MissingFieldsError{Paths: []string{"database.password", "tasks[0].title"}}
+	want := "missing required field(s): database.password, tasks[0].title"
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
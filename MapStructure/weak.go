@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// DecodeWeakChecked decodes with WeaklyTypedInput enabled but rejects
+// numeric conversions that would silently lose information: floats with a
+// fractional part into integers, integers that overflow the target width,
+// negative numbers into unsigned fields and floats beyond float32 range.
+// mapstructure truncates or wraps all of these without complaint.
+func DecodeWeakChecked(input, output interface{}, opts ...Option) error {
+	o := newDecodeOptions(append(opts, WithWeakTyping()))
+	config := o.decoderConfig(output)
+	if o.hook != nil {
+		config.DecodeHook = mapstructure.ComposeDecodeHookFunc(o.hook, lossyConversionHook)
+	} else {
+		config.DecodeHook = lossyConversionHook
+	}
+
+	decoder, err := mapstructure.NewDecoder(config)
+	if err != nil {
+		return err
+	}
+	return decoder.Decode(input)
+}
+
+// lossyConversionHook validates each numeric value before mapstructure
+// converts it; the decoder wraps any error with the field path.
+func lossyConversionHook(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+	v := reflect.ValueOf(data)
+	if !v.IsValid() {
+		return data, nil
+	}
+
+	switch to.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch from.Kind() {
+		case reflect.Float32, reflect.Float64:
+			f := v.Float()
+			if f != math.Trunc(f) {
+				return nil, fmt.Errorf("%v has a fractional part and cannot be stored in %s", f, to)
+			}
+			if f < math.MinInt64 || f >= math.MaxInt64 || reflect.Zero(to).OverflowInt(int64(f)) {
+				return nil, fmt.Errorf("%v overflows %s", f, to)
+			}
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if reflect.Zero(to).OverflowInt(v.Int()) {
+				return nil, fmt.Errorf("%d overflows %s", v.Int(), to)
+			}
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			if v.Uint() > math.MaxInt64 || reflect.Zero(to).OverflowInt(int64(v.Uint())) {
+				return nil, fmt.Errorf("%d overflows %s", v.Uint(), to)
+			}
+		}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		switch from.Kind() {
+		case reflect.Float32, reflect.Float64:
+			f := v.Float()
+			if f != math.Trunc(f) {
+				return nil, fmt.Errorf("%v has a fractional part and cannot be stored in %s", f, to)
+			}
+			if f < 0 {
+				return nil, fmt.Errorf("%v is negative and cannot be stored in %s", f, to)
+			}
+			if f >= math.MaxUint64 || reflect.Zero(to).OverflowUint(uint64(f)) {
+				return nil, fmt.Errorf("%v overflows %s", f, to)
+			}
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if v.Int() < 0 {
+				return nil, fmt.Errorf("%d is negative and cannot be stored in %s", v.Int(), to)
+			}
+			if reflect.Zero(to).OverflowUint(uint64(v.Int())) {
+				return nil, fmt.Errorf("%d overflows %s", v.Int(), to)
+			}
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			if reflect.Zero(to).OverflowUint(v.Uint()) {
+				return nil, fmt.Errorf("%d overflows %s", v.Uint(), to)
+			}
+		}
+
+	case reflect.Float32:
+		if from.Kind() == reflect.Float64 && reflect.Zero(to).OverflowFloat(v.Float()) {
+			return nil, fmt.Errorf("%v overflows %s", v.Float(), to)
+		}
+	}
+
+	return data, nil
+}
+
+// weakMatrixSources and weakMatrixTargets define the conversion matrix shown
+// in the demo and pinned by the tests.
+var (
+	weakMatrixSources = []interface{}{
+		true, 1, 300, -1, 1.5, 2.0, "1", "", "true", "1.5", []interface{}{"a"},
+	}
+	weakMatrixTargets = []reflect.Type{
+		reflect.TypeOf(false),
+		reflect.TypeOf(0),
+		reflect.TypeOf(int8(0)),
+		reflect.TypeOf(uint(0)),
+		reflect.TypeOf(0.0),
+		reflect.TypeOf(""),
+		reflect.TypeOf([]string(nil)),
+	}
+)
+
+// conversionMode selects how a matrix cell is decoded.
+type conversionMode int
+
+const (
+	modeStrict conversionMode = iota
+	modeWeak
+	modeWeakChecked
+)
+
+func (m conversionMode) String() string {
+	return [...]string{"strict", "weak", "weak checked"}[m]
+}
+
+// convertCell decodes a single value into a field of type target and
+// describes the outcome: the resulting value, or "error".
+func convertCell(value interface{}, target reflect.Type, mode conversionMode) string {
+	structType := reflect.StructOf([]reflect.StructField{{
+		Name: "V",
+		Type: target,
+		Tag:  `mapstructure:"v"`,
+	}})
+	out := reflect.New(structType)
+	input := map[string]interface{}{"v": value}
+
+	var err error
+	switch mode {
+	case modeStrict:
+		err = mapstructure.Decode(input, out.Interface())
+	case modeWeak:
+		err = mapstructure.WeakDecode(input, out.Interface())
+	case modeWeakChecked:
+		err = DecodeWeakChecked(input, out.Interface())
+	}
+	if err != nil {
+		return "error"
+	}
+	return formatCell(out.Elem().Field(0).Interface())
+}
+
+// conversionMatrix returns one row per source value and one column per
+// target type.
+func conversionMatrix(mode conversionMode) [][]string {
+	rows := make([][]string, len(weakMatrixSources))
+	for i, source := range weakMatrixSources {
+		rows[i] = make([]string, len(weakMatrixTargets))
+		for j, target := range weakMatrixTargets {
+			rows[i][j] = convertCell(source, target, mode)
+		}
+	}
+	return rows
+}
+
+func formatCell(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return fmt.Sprintf("%q", v)
+	case []string:
+		return fmt.Sprintf("%q", v)
+	case []interface{}:
+		return fmt.Sprintf("%v", v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// formatMatrix renders a conversion matrix as an aligned text table.
+func formatMatrix(rows [][]string) string {
+	header := []string{"source"}
+	for _, target := range weakMatrixTargets {
+		header = append(header, target.String())
+	}
+
+	table := [][]string{header}
+	for i, row := range rows {
+		table = append(table, append([]string{formatCell(weakMatrixSources[i])}, row...))
+	}
+
+	widths := make([]int, len(header))
+	for _, row := range table {
+		for j, cell := range row {
+			widths[j] = max(widths[j], len(cell))
+		}
+	}
+
+	lines := make([]string, len(table))
+	for i, row := range table {
+		var b strings.Builder
+		for j, cell := range row {
+			fmt.Fprintf(&b, "%-*s  ", widths[j], cell)
+		}
+		lines[i] = strings.TrimRight(b.String(), " ")
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// The matrices below pin mapstructure v1.5.0 behaviour; a library upgrade
+// that changes any cell should be a deliberate, reviewed change.
+//
+// Rows follow weakMatrixSources; columns are bool, int, int8, uint, float64,
+// string, []string.
+func TestConversionMatrix(t *testing.T) {
+	const e = "error"
+
+	tests := []struct {
+		mode conversionMode
+		want [][]string
+	}{
+		{modeStrict, [][]string{
+			{"true", e, e, e, e, e, e},
+			{e, "1", "1", "1", "1", e, e},
+			{e, "300", "44", "300", "300", e, e},
+			{e, "-1", "-1", e, "-1", e, e},
+			{e, "1", "1", "1", "1.5", e, e},
+			{e, "2", "2", "2", "2", e, e},
+			{e, e, e, e, e, `"1"`, e},
+			{e, e, e, e, e, `""`, e},
+			{e, e, e, e, e, `"true"`, e},
+			{e, e, e, e, e, `"1.5"`, e},
+			{e, e, e, e, e, e, `["a"]`},
+		}},
+		{modeWeak, [][]string{
+			{"true", "1", "1", "1", "1", `"1"`, `["1"]`},
+			{"true", "1", "1", "1", "1", `"1"`, `["1"]`},
+			{"true", "300", "44", "300", "300", `"300"`, `["300"]`},
+			{"true", "-1", "-1", "18446744073709551615", "-1", `"-1"`, `["-1"]`},
+			{"true", "1", "1", "1", "1.5", `"1.5"`, `["1.5"]`},
+			{"true", "2", "2", "2", "2", `"2"`, `["2"]`},
+			{"true", "1", "1", "1", "1", `"1"`, `["1"]`},
+			{"false", "0", "0", "0", "0", `""`, `[""]`},
+			{"true", e, e, e, e, `"true"`, `["true"]`},
+			{e, e, e, e, "1.5", `"1.5"`, `["1.5"]`},
+			{e, e, e, e, e, e, `["a"]`},
+		}},
+		{modeWeakChecked, [][]string{
+			{"true", "1", "1", "1", "1", `"1"`, `["1"]`},
+			{"true", "1", "1", "1", "1", `"1"`, `["1"]`},
+			{"true", "300", e, "300", "300", `"300"`, `["300"]`},
+			{"true", "-1", "-1", e, "-1", `"-1"`, `["-1"]`},
+			{"true", e, e, e, "1.5", `"1.5"`, `["1.5"]`},
+			{"true", "2", "2", "2", "2", `"2"`, `["2"]`},
+			{"true", "1", "1", "1", "1", `"1"`, `["1"]`},
+			{"false", "0", "0", "0", "0", `""`, `[""]`},
+			{"true", e, e, e, e, `"true"`, `["true"]`},
+			{e, e, e, e, "1.5", `"1.5"`, `["1.5"]`},
+			{e, e, e, e, e, e, `["a"]`},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode.String(), func(t *testing.T) {
+			got := conversionMatrix(tt.mode)
+			for i, row := range got {
+				for j, cell := range row {
+					if cell != tt.want[i][j] {
+						t.Errorf("%s -> %s = %s, want %s",
+							formatCell(weakMatrixSources[i]), weakMatrixTargets[j], cell, tt.want[i][j])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeWeakCheckedRejectsLossyValues(t *testing.T) {
+	type Limits struct {
+		Small  int8    `mapstructure:"small"`
+		Count  uint16  `mapstructure:"count"`
+		Whole  int     `mapstructure:"whole"`
+		Ratio  float32 `mapstructure:"ratio"`
+		Nested struct {
+			Depth int32 `mapstructure:"depth"`
+		} `mapstructure:"nested"`
+	}
+
+	tests := []struct {
+		name   string
+		input  map[string]interface{}
+		path   string
+		detail string
+	}{
+		{"fraction into int", map[string]interface{}{"whole": 2.5}, "whole", "2.5 has a fractional part"},
+		{"int64 overflows int8", map[string]interface{}{"small": int64(128)}, "small", "128 overflows int8"},
+		{"int8 minimum", map[string]interface{}{"small": -129}, "small", "-129 overflows int8"},
+		{"negative into uint", map[string]interface{}{"count": -1}, "count", "-1 is negative"},
+		{"uint overflows uint16", map[string]interface{}{"count": uint64(70000)}, "count", "70000 overflows uint16"},
+		{"float overflows float32", map[string]interface{}{"ratio": math.MaxFloat64}, "ratio", "overflows float32"},
+		{"nested path", map[string]interface{}{"nested": map[string]interface{}{"depth": 1e10}}, "nested.depth", "overflows int32"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out Limits
+			err := DecodeWeakChecked(tt.input, &out)
+			if err == nil {
+				t.Fatalf("expected an error, got %+v", out)
+			}
+			if !strings.Contains(err.Error(), "'"+tt.path+"'") || !strings.Contains(err.Error(), tt.detail) {
+				t.Errorf("error %q does not mention %q and %q", err, tt.path, tt.detail)
+			}
+		})
+	}
+}
+
+func TestDecodeWeakCheckedAcceptsLosslessValues(t *testing.T) {
+	type Limits struct {
+		Small int8     `mapstructure:"small"`
+		Count uint16   `mapstructure:"count"`
+		Whole int      `mapstructure:"whole"`
+		Ratio float32  `mapstructure:"ratio"`
+		Hosts []string `mapstructure:"hosts"`
+	}
+
+	input := map[string]interface{}{
+		"small": -128,
+		"count": 65535.0,
+		"whole": "42",
+		"ratio": 0.25,
+		"hosts": "localhost",
+	}
+
+	var out Limits
+	if err := DecodeWeakChecked(input, &out); err != nil {
+		t.Fatalf("DecodeWeakChecked: %v", err)
+	}
+	want := Limits{Small: -128, Count: 65535, Whole: 42, Ratio: 0.25, Hosts: []string{"localhost"}}
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("got %+v, want %+v", out, want)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// NormalizeYAMLMap converts the output of yaml.Unmarshal into something
+// mapstructure can decode. yaml.v2 (and yaml.v3 whenever a mapping has a
+// non-string key) produces map[interface{}]interface{}, which mapstructure
+// rejects when decoding into a struct. Keys are converted with fmt.Sprint,
+// recursively and inside lists, so `80: http` becomes "80". Keys that are
+// themselves maps or lists, or that collide after conversion, are errors.
+func NormalizeYAMLMap(input interface{}) (map[string]interface{}, error) {
+	normalized, err := normalizeYAMLValue("", input)
+	if err != nil {
+		return nil, err
+	}
+
+	out, ok := normalized.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("yaml: expected a mapping at the top level, got %T", input)
+	}
+	return out, nil
+}
+
+func normalizeYAMLValue(path string, value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			normalized, err := normalizeYAMLValue(joinPath(path, key), item)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = normalized
+		}
+		return out, nil
+
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for rawKey, item := range v {
+			key, err := yamlKeyString(path, rawKey)
+			if err != nil {
+				return nil, err
+			}
+			if _, dup := out[key]; dup {
+				return nil, fmt.Errorf("yaml: %s: keys %q collide after converting to strings", displayPath(path), key)
+			}
+
+			normalized, err := normalizeYAMLValue(joinPath(path, key), item)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = normalized
+		}
+		return out, nil
+
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			normalized, err := normalizeYAMLValue(fmt.Sprintf("%s[%d]", path, i), item)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = normalized
+		}
+		return out, nil
+
+	default:
+		return value, nil
+	}
+}
+
+func yamlKeyString(path string, key interface{}) (string, error) {
+	if key == nil {
+		return "", fmt.Errorf("yaml: %s: null mapping key", displayPath(path))
+	}
+	switch reflect.TypeOf(key).Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array:
+		return "", fmt.Errorf("yaml: %s: unsupported %T mapping key", displayPath(path), key)
+	}
+	return fmt.Sprint(key), nil
+}
+
+func displayPath(path string) string {
+	if path == "" {
+		return "(root)"
+	}
+	return path
+}
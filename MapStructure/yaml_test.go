@@ -0,0 +1,156 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const yamlTestDoc = `
+name: Website Redesign
+tasks:
+  - id: 1
+    title: Design mockups
+    completed: true
+  - id: 2
+    title: Implement frontend
+    labels:
+      1: urgent
+      2: frontend
+owners:
+  10: alice
+  20: bob
+matrix:
+  - [1, 2]
+  - - {3: three}
+`
+
+func TestNormalizeYAMLMap(t *testing.T) {
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal([]byte(yamlTestDoc), &raw); err != nil {
+		t.Fatalf("yaml: %v", err)
+	}
+
+	got, err := NormalizeYAMLMap(raw)
+	if err != nil {
+		t.Fatalf("NormalizeYAMLMap: %v", err)
+	}
+
+	want := map[string]interface{}{
+		"name": "Website Redesign",
+		"tasks": []interface{}{
+			map[string]interface{}{"id": 1, "title": "Design mockups", "completed": true},
+			map[string]interface{}{
+				"id": 2, "title": "Implement frontend",
+				"labels": map[string]interface{}{"1": "urgent", "2": "frontend"},
+			},
+		},
+		"owners": map[string]interface{}{"10": "alice", "20": "bob"},
+		"matrix": []interface{}{
+			[]interface{}{1, 2},
+			[]interface{}{map[string]interface{}{"3": "three"}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %#v\nwant %#v", got, want)
+	}
+
+	// The normalized map decodes straight into typed structs and maps.
+	type labelledTask struct {
+		Task   `mapstructure:",squash"`
+		Labels map[string]string `mapstructure:"labels"`
+	}
+	type document struct {
+		Name   string            `mapstructure:"name"`
+		Tasks  []labelledTask    `mapstructure:"tasks"`
+		Owners map[string]string `mapstructure:"owners"`
+		Matrix []interface{}     `mapstructure:"matrix"`
+	}
+
+	doc, err := Decode[document](got)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if doc.Tasks[1].Labels["2"] != "frontend" || doc.Owners["20"] != "bob" || !doc.Tasks[0].Completed {
+		t.Errorf("decoded = %+v", doc)
+	}
+}
+
+func TestNormalizeYAMLMapV2Style(t *testing.T) {
+	// yaml.v2 produces interface{}-keyed maps at every level.
+	raw := map[interface{}]interface{}{
+		"server": map[interface{}]interface{}{
+			"port":          8080,
+			"allowed_hosts": []interface{}{"localhost"},
+			"tls":           map[interface{}]interface{}{"enabled": true},
+		},
+		true:  "yes",
+		3.5:   "float key",
+		"log": nil,
+	}
+
+	got, err := NormalizeYAMLMap(raw)
+	if err != nil {
+		t.Fatalf("NormalizeYAMLMap: %v", err)
+	}
+
+	want := map[string]interface{}{
+		"server": map[string]interface{}{
+			"port":          8080,
+			"allowed_hosts": []interface{}{"localhost"},
+			"tls":           map[string]interface{}{"enabled": true},
+		},
+		"true": "yes",
+		"3.5":  "float key",
+		"log":  nil,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %#v\nwant %#v", got, want)
+	}
+}
+
+func TestNormalizeYAMLMapErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input interface{}
+		want  string
+	}{
+		{
+			name:  "top level is a list",
+			input: []interface{}{1, 2},
+			want:  "expected a mapping at the top level",
+		},
+		{
+			name: "composite key",
+			input: map[string]interface{}{
+				"server": map[interface{}]interface{}{[2]int{1, 2}: "x"},
+			},
+			want: "yaml: server: unsupported [2]int mapping key",
+		},
+		{
+			name: "keys collide",
+			input: map[string]interface{}{
+				"tasks": []interface{}{
+					map[interface{}]interface{}{1: "int", "1": "string"},
+				},
+			},
+			want: `yaml: tasks[0]: keys "1" collide`,
+		},
+		{
+			name:  "null key",
+			input: map[interface{}]interface{}{nil: "x"},
+			want:  "yaml: (root): null mapping key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NormalizeYAMLMap(tt.input)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"templ-demo/templates"
+
+	"github.com/gorilla/mux"
+)
+
+// registerAPIRoutes adds the JSON API under /api/v1. It works on the same
+// stores with the same validation as the pages; only the encoding of
+// requests and responses differs.
+func registerAPIRoutes(r *mux.Router) {
+	api := r.PathPrefix("/api/v1").Subrouter()
+
+	api.HandleFunc("/todos", apiListTodosHandler).Methods("GET")
+	api.HandleFunc("/todos", apiCreateTodoHandler).Methods("POST")
+	api.HandleFunc("/todos/{id}", apiGetTodoHandler).Methods("GET")
+	api.HandleFunc("/todos/{id}", apiUpdateTodoHandler).Methods("PUT")
+	api.HandleFunc("/todos/{id}", apiDeleteTodoHandler).Methods("DELETE")
+
+	api.HandleFunc("/users", apiListUsersHandler).Methods("GET")
+	api.HandleFunc("/users", apiCreateUserHandler).Methods("POST")
+	api.HandleFunc("/users/{id}", apiGetUserHandler).Methods("GET")
+	api.HandleFunc("/users/{id}", apiUpdateUserHandler).Methods("PUT")
+	api.HandleFunc("/users/{id}", apiDeleteUserHandler).Methods("DELETE")
+}
+
+// listResponse is a page of a list. The lists take the same filter and
+// page parameters as the pages.
+type listResponse[T any] struct {
+	Data    []T `json:"data"`
+	Page    int `json:"page"`
+	PerPage int `json:"per_page"`
+	Total   int `json:"total"`
+	Pages   int `json:"pages"`
+}
+
+func newListResponse[T any](items []T, info templates.PageInfo) listResponse[T] {
+	if items == nil {
+		items = []T{}
+	}
+	return listResponse[T]{
+		Data:    items,
+		Page:    info.Page,
+		PerPage: info.PerPage,
+		Total:   info.Total,
+		Pages:   info.Pages(),
+	}
+}
+
+// Todo API handlers
+func apiListTodosHandler(w http.ResponseWriter, r *http.Request) {
+	shown, _, info := todoPage(r)
+	writeJSON(w, http.StatusOK, newListResponse(shown, info))
+}
+
+func apiGetTodoHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := apiID(w, r)
+	if !ok {
+		return
+	}
+	todo, ok := todos.Get(id)
+	if !ok {
+		writeJSONError(w, http.StatusNotFound, "Todo not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, todo)
+}
+
+func apiCreateTodoHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Text     string `json:"text"`
+		DueDate  string `json:"due_date"`
+		Priority string `json:"priority"`
+	}
+	if !readJSON(w, r, &req) {
+		return
+	}
+	values := templates.TodoFormValues{
+		Text:     strings.TrimSpace(req.Text),
+		DueDate:  strings.TrimSpace(req.DueDate),
+		Priority: strings.TrimSpace(req.Priority),
+	}
+	todo, errs := parseTodoForm(values, templates.Now(r.Context()))
+	if errs != nil {
+		writeValidationErrors(w, errs)
+		return
+	}
+
+	todo = todos.Add(todo)
+	w.Header().Set("Location", "/api/v1/todos/"+strconv.Itoa(todo.ID))
+	writeJSON(w, http.StatusCreated, todo)
+}
+
+func apiUpdateTodoHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := apiID(w, r)
+	if !ok {
+		return
+	}
+	var update todoUpdate
+	if !readJSON(w, r, &update) {
+		return
+	}
+	change, errs := update.check()
+	if errs != nil {
+		writeValidationErrors(w, errs)
+		return
+	}
+
+	todo, ok := todos.Update(id, change)
+	if !ok {
+		writeJSONError(w, http.StatusNotFound, "Todo not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, todo)
+}
+
+func apiDeleteTodoHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := apiID(w, r)
+	if !ok {
+		return
+	}
+	if !todos.Delete(id) {
+		writeJSONError(w, http.StatusNotFound, "Todo not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// User API handlers
+func apiListUsersHandler(w http.ResponseWriter, r *http.Request) {
+	shown, info := usersPage(r)
+	writeJSON(w, http.StatusOK, newListResponse(shown, info))
+}
+
+func apiGetUserHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := apiID(w, r)
+	if !ok {
+		return
+	}
+	user, ok := users.Get(id)
+	if !ok {
+		writeJSONError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, user)
+}
+
+func apiCreateUserHandler(w http.ResponseWriter, r *http.Request) {
+	var req userUpdate
+	if !readJSON(w, r, &req) {
+		return
+	}
+	user := User{
+		Name:    strings.TrimSpace(req.Name),
+		Email:   strings.TrimSpace(req.Email),
+		Role:    strings.TrimSpace(req.Role),
+		Version: 1,
+	}
+	if errs := validateUser(user); errs != nil {
+		writeValidationErrors(w, errs)
+		return
+	}
+
+	user = users.Add(user)
+	w.Header().Set("Location", "/api/v1/users/"+strconv.Itoa(user.ID))
+	writeJSON(w, http.StatusCreated, user)
+}
+
+func apiUpdateUserHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := apiID(w, r)
+	if !ok {
+		return
+	}
+	var update userUpdate
+	if !readJSON(w, r, &update) {
+		return
+	}
+	user, errs, err := updateUser(id, update)
+	switch {
+	case errors.Is(err, errUserNotFound):
+		writeJSONError(w, http.StatusNotFound, "User not found")
+		return
+	case errors.Is(err, errUserConflict):
+		// The user as it is now, to make the change to again
+		writeJSON(w, http.StatusConflict, map[string]any{
+			"error": "The user was changed since version " + strconv.Itoa(*update.Version),
+			"user":  user,
+		})
+		return
+	case errs != nil:
+		writeValidationErrors(w, errs)
+		return
+	}
+	writeJSON(w, http.StatusOK, user)
+}
+
+func apiDeleteUserHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := apiID(w, r)
+	if !ok {
+		return
+	}
+	if !users.Delete(id) {
+		writeJSONError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// isAPIRequest reports whether r wants JSON back: it is for the API, or
+// asks for JSON in its Accept header
+func isAPIRequest(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.Path, "/api/") || wantsJSON(r)
+}
+
+// wantsJSON reports whether r's Accept header lists application/json
+func wantsJSON(r *http.Request) bool {
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, _ := strings.Cut(accepted, ";")
+		if strings.TrimSpace(mediaType) == "application/json" {
+			return true
+		}
+	}
+	return false
+}
+
+// apiID is the route's id, or false after a 400 when it is not a number
+func apiID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid ID")
+		return 0, false
+	}
+	return id, true
+}
+
+// readJSON decodes the request body into v, or sends a 400 and returns
+// false
+func readJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid JSON")
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeJSONError sends {"error": message}
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// writeValidationErrors sends a 422 with what is wrong with each field,
+// keyed by the field's JSON name
+func writeValidationErrors(w http.ResponseWriter, errs templates.FormErrors) {
+	writeJSON(w, http.StatusUnprocessableEntity, map[string]any{
+		"error":  "Validation failed",
+		"errors": errs,
+	})
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var apiHeaders = map[string]string{"Content-Type": "application/json", "Accept": "application/json"}
+
+// decode reads rec's JSON body into a new T
+func decode[T any](t *testing.T, rec *httptest.ResponseRecorder) T {
+	t.Helper()
+	var v T
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("Content-Type = %q, want application/json", ct)
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&v); err != nil {
+		t.Fatalf("decoding %T: %v", v, err)
+	}
+	return v
+}
+
+type apiError struct {
+	Error  string            `json:"error"`
+	Errors map[string]string `json:"errors"`
+}
+
+func TestAPITodos(t *testing.T) {
+	seedData()
+
+	// The list takes the page's filter and page parameters
+	rec := serve(t, "GET", "/api/v1/todos?status=open&per_page=2", "", apiHeaders)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("list = %d", rec.Code)
+	}
+	list := decode[listResponse[Todo]](t, rec)
+	if len(list.Data) != 2 || list.Total != 3 || list.Pages != 2 || list.Data[0].ID != 2 {
+		t.Errorf("list = %+v", list)
+	}
+
+	// Create
+	rec = serve(t, "POST", "/api/v1/todos", `{"text": "  From the API ", "due_date": "2030-01-02", "priority": "high"}`, apiHeaders)
+	if rec.Code != http.StatusCreated || rec.Header().Get("Location") != "/api/v1/todos/5" {
+		t.Fatalf("create = %d at %q", rec.Code, rec.Header().Get("Location"))
+	}
+	if todo := decode[Todo](t, rec); todo.ID != 5 || todo.Text != "From the API" || todo.Priority != "high" || todo.DueDate.Year() != 2030 {
+		t.Errorf("created %+v", todo)
+	}
+
+	// Get, update and delete it
+	rec = serve(t, "GET", "/api/v1/todos/5", "", apiHeaders)
+	if todo := decode[Todo](t, rec); rec.Code != http.StatusOK || todo.Text != "From the API" {
+		t.Errorf("get = %d %+v", rec.Code, todo)
+	}
+	rec = serve(t, "PUT", "/api/v1/todos/5", `{"text": "Renamed", "due_date": ""}`, apiHeaders)
+	if todo := decode[Todo](t, rec); rec.Code != http.StatusOK || todo.Text != "Renamed" || !todo.DueDate.IsZero() || todo.Priority != "high" {
+		t.Errorf("update = %d %+v", rec.Code, todo)
+	}
+	rec = serve(t, "DELETE", "/api/v1/todos/5", "", apiHeaders)
+	if rec.Code != http.StatusNoContent || todos.Len() != 4 {
+		t.Errorf("delete = %d leaving %d todos", rec.Code, todos.Len())
+	}
+}
+
+func TestAPIUsers(t *testing.T) {
+	seedData()
+
+	rec := serve(t, "GET", "/api/v1/users", "", apiHeaders)
+	if list := decode[listResponse[User]](t, rec); rec.Code != http.StatusOK || len(list.Data) != 4 || list.Data[0].Name != "Alice Johnson" {
+		t.Errorf("list = %d %+v", rec.Code, list)
+	}
+
+	rec = serve(t, "POST", "/api/v1/users", `{"name": "Eve Adams", "email": "eve@example.com", "role": "Editor"}`, apiHeaders)
+	if rec.Code != http.StatusCreated || rec.Header().Get("Location") != "/api/v1/users/5" {
+		t.Fatalf("create = %d at %q", rec.Code, rec.Header().Get("Location"))
+	}
+	rec = serve(t, "PUT", "/api/v1/users/5", `{"role": "Admin"}`, apiHeaders)
+	if user := decode[User](t, rec); rec.Code != http.StatusOK || user.Name != "Eve Adams" || user.Role != "Admin" {
+		t.Errorf("update = %d %+v", rec.Code, user)
+	}
+	rec = serve(t, "GET", "/api/v1/users/5", "", apiHeaders)
+	if user := decode[User](t, rec); user.Role != "Admin" {
+		t.Errorf("get after update = %+v", user)
+	}
+	rec = serve(t, "DELETE", "/api/v1/users/5", "", apiHeaders)
+	if rec.Code != http.StatusNoContent || users.Len() != 4 {
+		t.Errorf("delete = %d leaving %d users", rec.Code, users.Len())
+	}
+}
+
+func TestAPIErrors(t *testing.T) {
+	seedData()
+	tests := []struct {
+		method, target, body string
+		want                 int
+		fields               []string // fields the validation errors name
+	}{
+		{"POST", "/api/v1/todos", `{"text": "", "priority": "urgent"}`, http.StatusUnprocessableEntity, []string{"text", "priority"}},
+		{"POST", "/api/v1/todos", `{"text": "Late", "due_date": "soon"}`, http.StatusUnprocessableEntity, []string{"due_date"}},
+		{"POST", "/api/v1/todos", `not json`, http.StatusBadRequest, nil},
+		{"PUT", "/api/v1/todos/1", `{"priority": "urgent"}`, http.StatusUnprocessableEntity, []string{"priority"}},
+		{"PUT", "/api/v1/todos/99", `{"text": "Nobody"}`, http.StatusNotFound, nil},
+		{"GET", "/api/v1/todos/abc", "", http.StatusBadRequest, nil},
+		{"DELETE", "/api/v1/todos/99", "", http.StatusNotFound, nil},
+		{"POST", "/api/v1/users", `{"name": "", "email": "nope", "role": "Boss"}`, http.StatusUnprocessableEntity, []string{"name", "email", "role"}},
+		{"PUT", "/api/v1/users/1", `{"email": "not an email"}`, http.StatusUnprocessableEntity, []string{"email"}},
+		{"GET", "/api/v1/users/99", "", http.StatusNotFound, nil},
+	}
+	for _, tt := range tests {
+		rec := serve(t, tt.method, tt.target, tt.body, apiHeaders)
+		if rec.Code != tt.want {
+			t.Errorf("%s %s = %d, want %d", tt.method, tt.target, rec.Code, tt.want)
+			continue
+		}
+		got := decode[apiError](t, rec)
+		if got.Error == "" || len(got.Errors) != len(tt.fields) {
+			t.Errorf("%s %s error = %+v", tt.method, tt.target, got)
+		}
+		for _, field := range tt.fields {
+			if got.Errors[field] == "" {
+				t.Errorf("%s %s has no error for %s: %+v", tt.method, tt.target, field, got)
+			}
+		}
+	}
+
+	// Nothing invalid was stored
+	if todos.Len() != 4 || users.Len() != 4 || todos.All()[0].Priority != "high" || users.All()[0].Email != "alice@example.com" {
+		t.Error("failed requests changed the data")
+	}
+
+	// Without a login or CSRF token the answer is JSON too
+	rec := serveWithCookies(t, "GET", "/api/v1/todos", "", apiHeaders, nil)
+	if got := decode[apiError](t, rec); rec.Code != http.StatusUnauthorized || got.Error == "" {
+		t.Errorf("anonymous list = %d %+v", rec.Code, got)
+	}
+	rec = serveWithCookies(t, "POST", "/api/v1/todos", `{"text": "Forged"}`, apiHeaders, sessionCookie("admin", time.Now().Add(time.Hour)))
+	if got := decode[apiError](t, rec); rec.Code != http.StatusForbidden || got.Error == "" {
+		t.Errorf("create without a CSRF token = %d %+v", rec.Code, got)
+	}
+}
+
+// The pages and the API share the stores, so each sees what the other
+// changed
+func TestHTMLAndJSONShareData(t *testing.T) {
+	seedData()
+
+	// Added through the page, listed by GET /todo as JSON
+	serve(t, "POST", "/todo", "text=Added+on+the+page", formHeaders)
+	rec := serve(t, "GET", "/todo?per_page=50", "", map[string]string{"Accept": "application/json"})
+	list := decode[listResponse[Todo]](t, rec)
+	if list.Total != 5 || list.Data[4].Text != "Added on the page" {
+		t.Errorf("GET /todo as JSON = %+v", list)
+	}
+
+	// Added and toggled through the API, shown on the page
+	serve(t, "POST", "/api/v1/todos", `{"text": "Added by the API"}`, apiHeaders)
+	serve(t, "PUT", "/api/v1/users/2", `{"name": "Robert Smith"}`, apiHeaders)
+	serve(t, "POST", "/todo/6/toggle", "", htmxHeaders)
+	page := serve(t, "GET", "/todo?per_page=50", "", nil).Body.String()
+	if !strings.Contains(page, `<s class="text-muted">Added by the API</s>`) {
+		t.Error("todo page does not show the API's todo, completed")
+	}
+	if !strings.Contains(serve(t, "GET", "/users", "", nil).Body.String(), "Robert Smith") {
+		t.Error("users page does not show the API's rename")
+	}
+
+	// And the API agrees with the page
+	rec = serve(t, "GET", "/api/v1/todos/6", "", apiHeaders)
+	if todo := decode[Todo](t, rec); !todo.Completed {
+		t.Errorf("API todo 6 = %+v, want it completed", todo)
+	}
+}
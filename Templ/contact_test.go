@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"templ-demo/templates"
+
+	"github.com/a-h/templ"
+)
+
+// fakeMailer records the messages it is given, failing with err if set
+type fakeMailer struct {
+	sent []Message
+	err  error
+}
+
+func (m *fakeMailer) Send(ctx context.Context, msg Message) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+// useFakeMailer swaps in a fake mailer and a fresh rate limit until the
+// test ends
+func useFakeMailer(t *testing.T) *fakeMailer {
+	t.Helper()
+	fake := &fakeMailer{}
+	oldMailer, oldLimiter := mailer, contactLimiter
+	mailer, contactLimiter = fake, newRateLimiter(contactLimit, time.Hour)
+	t.Cleanup(func() { mailer, contactLimiter = oldMailer, oldLimiter })
+	return fake
+}
+
+var contactForm = url.Values{
+	"name":    {"Ada Lovelace"},
+	"email":   {"ada@example.com"},
+	"message": {"Hello there,\nI like the demo."},
+}
+
+func TestContactSendsMail(t *testing.T) {
+	fake := useFakeMailer(t)
+	captureLogs(t)
+
+	rec := serve(t, "POST", "/contact", contactForm.Encode(), htmxHeaders)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Message sent") || strings.Contains(rec.Body.String(), "<html") {
+		t.Fatalf("htmx post = %d: %s", rec.Code, rec.Body)
+	}
+	if len(fake.sent) != 1 {
+		t.Fatalf("sent %d messages", len(fake.sent))
+	}
+	msg := fake.sent[0]
+	if msg.ReplyTo.Name != "Ada Lovelace" || msg.ReplyTo.Address != "ada@example.com" || msg.Subject != "Contact form: Ada Lovelace" {
+		t.Errorf("message = %+v", msg)
+	}
+	if want := "Name: Ada Lovelace\nEmail: ada@example.com\n\nHello there,\nI like the demo.\n"; msg.Body != want {
+		t.Errorf("body = %q, want %q", msg.Body, want)
+	}
+
+	// A plain post goes to the thank you page
+	rec = serve(t, "POST", "/contact", contactForm.Encode(), formHeaders)
+	if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "/contact?sent=1" {
+		t.Errorf("plain post = %d to %q", rec.Code, rec.Header().Get("Location"))
+	}
+	if page := serve(t, "GET", "/contact?sent=1", "", nil).Body.String(); !strings.Contains(page, "Message sent") {
+		t.Error("thank you page does not say the message was sent")
+	}
+}
+
+func TestContactValidation(t *testing.T) {
+	fake := useFakeMailer(t)
+	captureLogs(t)
+	tests := []struct {
+		name   string
+		change url.Values
+		field  string
+	}{
+		{"no name", url.Values{"name": {"  "}}, "Name is required"},
+		{"bad email", url.Values{"email": {"ada at example"}}, "Email must be a valid address"},
+		{"named email", url.Values{"email": {"Ada <ada@example.com>"}}, "Email must be a valid address"},
+		{"no message", url.Values{"message": {""}}, "Message is required"},
+		{"long message", url.Values{"message": {strings.Repeat("é", maxContactMessageLength+1)}}, "Message must be at most 2000 characters"},
+	}
+	for _, tt := range tests {
+		form := url.Values{}
+		for k, v := range contactForm {
+			form[k] = v
+		}
+		for k, v := range tt.change {
+			form[k] = v
+		}
+		rec := serve(t, "POST", "/contact", form.Encode(), htmxHeaders)
+		if rec.Code != http.StatusUnprocessableEntity || !strings.Contains(rec.Body.String(), tt.field) {
+			t.Errorf("%s: = %d without %q", tt.name, rec.Code, tt.field)
+		}
+		// The form comes back with what was entered
+		if body := rec.Body.String(); !strings.Contains(body, `id="contact-form"`) || !strings.Contains(body, `value="`+templ.EscapeString(form.Get("email"))+`"`) {
+			t.Errorf("%s: form not sent back filled in: %s", tt.name, body)
+		}
+	}
+	if len(fake.sent) != 0 {
+		t.Errorf("invalid posts sent %d messages", len(fake.sent))
+	}
+}
+
+func TestContactRateLimit(t *testing.T) {
+	fake := useFakeMailer(t)
+	captureLogs(t)
+
+	for i := range contactLimit {
+		if rec := serve(t, "POST", "/contact", contactForm.Encode(), htmxHeaders); rec.Code != http.StatusOK {
+			t.Fatalf("post %d = %d", i+1, rec.Code)
+		}
+	}
+	rec := serve(t, "POST", "/contact", contactForm.Encode(), htmxHeaders)
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Fatalf("post over the limit = %d with Retry-After %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if body := rec.Body.String(); !strings.Contains(body, "Please wait a little") || !strings.Contains(body, "in 60 minutes") {
+		t.Errorf("rate limit fragment = %s", body)
+	}
+	rec = serve(t, "POST", "/contact", contactForm.Encode(), formHeaders)
+	if rec.Code != http.StatusTooManyRequests || !strings.Contains(rec.Body.String(), "<html") {
+		t.Errorf("plain post over the limit = %d, want the page with the message", rec.Code)
+	}
+	if len(fake.sent) != contactLimit {
+		t.Errorf("sent %d messages, want %d", len(fake.sent), contactLimit)
+	}
+}
+
+func TestContactMailerFailure(t *testing.T) {
+	fake := useFakeMailer(t)
+	fake.err = errors.New("connection refused")
+	captureLogs(t)
+
+	rec := serve(t, "POST", "/contact", contactForm.Encode(), htmxHeaders)
+	if rec.Code != http.StatusBadGateway || !strings.Contains(rec.Body.String(), "could not be sent") {
+		t.Errorf("failed send = %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestMessageFormat(t *testing.T) {
+	msg := composeContactMessage(contactFormValues("Ada\r\nBcc: victim@example.com", "ada@example.com", "Line one\nLine two"))
+	got := string(msg.format("site@example.com", "owner@example.com"))
+
+	// The name's line break cannot start a header of its own
+	if strings.Contains(got, "\r\nBcc:") {
+		t.Errorf("header injected:\n%s", got)
+	}
+	for _, want := range []string{
+		"From: site@example.com\r\n",
+		"To: owner@example.com\r\n",
+		"Reply-To: \"Ada Bcc: victim@example.com\" <ada@example.com>\r\n",
+		"Subject: Contact form: Ada Bcc: victim@example.com\r\n",
+		"Content-Type: text/plain; charset=utf-8\r\n",
+		"Content-Transfer-Encoding: 8bit\r\n\r\n",
+		"Line one\r\nLine two\r\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("message is missing %q:\n%s", want, got)
+		}
+	}
+
+	// Names beyond ASCII are encoded
+	msg = composeContactMessage(contactFormValues("Émile", "emile@example.com", "Bonjour"))
+	if got := string(msg.format("a@example.com", "b@example.com")); !strings.Contains(got, "Subject: =?utf-8?q?Contact_form:_=C3=89mile?=\r\n") {
+		t.Errorf("encoded subject missing:\n%s", got)
+	}
+}
+
+func contactFormValues(name, email, message string) templates.ContactFormValues {
+	return templates.ContactFormValues{Name: name, Email: email, Message: message}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+
+	"templ-demo/templates"
+)
+
+const csrfCookieName = "templ_csrf"
+
+// newCSRFToken returns a random token for a browser session
+func newCSRFToken() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// setCSRFCookie keeps token in a cookie for the browser session; the
+// pages carry a copy in their forms and headers to send back
+func setCSRFCookie(w http.ResponseWriter, r *http.Request, token string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     csrfCookieName,
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// rotateCSRFToken gives the browser a new token, so one seen before a
+// login is no good after it
+func rotateCSRFToken(w http.ResponseWriter, r *http.Request) {
+	setCSRFCookie(w, r, newCSRFToken())
+}
+
+// csrfMiddleware issues each browser a token and puts it in the request
+// context for the pages to render. POST, PUT, PATCH and DELETE requests
+// must send it back in the _csrf form field or the X-CSRF-Token header, or
+// they are refused with a 403.
+func csrfMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var token string
+		if cookie, err := r.Cookie(csrfCookieName); err == nil {
+			token = cookie.Value
+		}
+
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+		default:
+			if !validCSRFToken(token, submittedCSRFToken(r)) {
+				renderCSRFError(w, r)
+				return
+			}
+		}
+
+		if token == "" {
+			token = newCSRFToken()
+			setCSRFCookie(w, r, token)
+		}
+		next.ServeHTTP(w, r.WithContext(templates.WithCSRFToken(r.Context(), token)))
+	})
+}
+
+// submittedCSRFToken is the token a request sent, from the header page
+// scripts use or the form field
+func submittedCSRFToken(r *http.Request) string {
+	if token := r.Header.Get(templates.CSRFHeaderName); token != "" {
+		return token
+	}
+	return r.PostFormValue(templates.CSRFFieldName)
+}
+
+func validCSRFToken(want, got string) bool {
+	return want != "" && subtle.ConstantTimeCompare([]byte(want), []byte(got)) == 1
+}
+
+// renderCSRFError refuses a request whose token is missing or wrong. Page
+// scripts get the message on its own, which they show as it is, and API
+// clients get it in JSON.
+func renderCSRFError(w http.ResponseWriter, r *http.Request) {
+	const message = "This form has expired or did not come from this site. Go back, reload the page and try again."
+	if isAPIRequest(r) {
+		writeJSONError(w, http.StatusForbidden, "Missing or invalid CSRF token")
+		return
+	}
+	if isFragmentRequest(r) {
+		http.Error(w, message, http.StatusForbidden)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusForbidden)
+	component := templates.ErrorPage("Request Refused", message)
+	component.Render(r.Context(), w)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"templ-demo/templates"
+)
+
+func TestCSRFRejectsMissingOrWrongToken(t *testing.T) {
+	seedData()
+	session := sessionCookie("admin", time.Now().Add(time.Hour))
+	tests := []struct {
+		name    string
+		method  string
+		target  string
+		body    string
+		headers map[string]string
+		cookie  *http.Cookie
+	}{
+		{"no token", "POST", "/todo", "text=Forged", formHeaders, csrfCookie()},
+		{"wrong token", "POST", "/todo", "text=Forged&_csrf=guess", formHeaders, csrfCookie()},
+		{"no cookie", "POST", "/todo", "text=Forged&_csrf=" + testCSRFToken, formHeaders, nil},
+		{"toggle", "POST", "/todo/1/toggle", "", htmxHeaders, csrfCookie()},
+		{"delete user", "DELETE", "/users/1", "", jsonHeaders, csrfCookie()},
+		{"update user", "PUT", "/users/1", `{"name": "Mallory"}`, jsonHeaders, csrfCookie()},
+		{"contact", "POST", "/contact", "name=Spam", formHeaders, csrfCookie()},
+	}
+	for _, tt := range tests {
+		rec := serveWithCookies(t, tt.method, tt.target, tt.body, tt.headers, session, tt.cookie)
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("%s: %s %s = %d, want 403", tt.name, tt.method, tt.target, rec.Code)
+		}
+	}
+	if todos.Len() != 4 || users.Len() != 4 || todos.All()[0].Completed != true || users.All()[0].Name != "Alice Johnson" {
+		t.Error("refused requests changed the data")
+	}
+
+	// A plain form post gets a page explaining why
+	rec := serveWithCookies(t, "POST", "/todo", "text=Forged", formHeaders, session, csrfCookie())
+	if page := rec.Body.String(); !strings.Contains(page, "<title>Request Refused - Templ Demo</title>") || !strings.Contains(page, "reload the page") {
+		t.Errorf("refusal page = %s", page)
+	}
+	// A page script gets just the message
+	rec = serveWithCookies(t, "DELETE", "/users/1", "", jsonHeaders, session, csrfCookie())
+	if strings.Contains(rec.Body.String(), "<html") {
+		t.Errorf("refusal for a script = %s", rec.Body)
+	}
+}
+
+func TestCSRFAcceptsToken(t *testing.T) {
+	seedData()
+	session := sessionCookie("admin", time.Now().Add(time.Hour))
+
+	// In the form field, as the forms send it
+	form := url.Values{"text": {"With token"}, templates.CSRFFieldName: {testCSRFToken}}.Encode()
+	rec := serveWithCookies(t, "POST", "/todo", form, formHeaders, session, csrfCookie())
+	if rec.Code != http.StatusSeeOther {
+		t.Errorf("post with the form token = %d, want 303", rec.Code)
+	}
+
+	// In the header, as htmx and the users page script send it
+	rec = serveWithCookies(t, "DELETE", "/users/1", "", withCSRF(jsonHeaders), session, csrfCookie())
+	if rec.Code != http.StatusOK {
+		t.Errorf("delete with the header token = %d, want 200", rec.Code)
+	}
+	if todos.Len() != 5 || users.Len() != 3 {
+		t.Error("accepted requests did not change the data")
+	}
+}
+
+func TestCSRFTokenIssuedAndRendered(t *testing.T) {
+	seedData()
+	session := sessionCookie("admin", time.Now().Add(time.Hour))
+
+	// A first visit is given a token, which the page carries
+	rec := serveWithCookies(t, "GET", "/users", "", nil, session)
+	issued := findCookie(rec, csrfCookieName)
+	if issued == nil || issued.Value == "" || !issued.HttpOnly {
+		t.Fatalf("first visit cookies = %v", rec.Result().Cookies())
+	}
+	page := rec.Body.String()
+	for _, want := range []string{
+		`<meta name="csrf-token" content="` + issued.Value + `">`,
+		`<input type="hidden" name="_csrf" value="` + issued.Value + `">`,
+		`'X-CSRF-Token': csrfToken`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("users page does not contain %q", want)
+		}
+	}
+
+	// Later visits keep it, and the todo page hands it to htmx
+	rec = serveWithCookies(t, "GET", "/todo", "", nil, session, csrfCookie())
+	if findCookie(rec, csrfCookieName) != nil {
+		t.Error("an existing token was replaced")
+	}
+	page = rec.Body.String()
+	if !strings.Contains(page, `hx-headers="{&#34;X-CSRF-Token&#34;:&#34;`+testCSRFToken+`&#34;}"`) || !strings.Contains(page, `name="_csrf" value="`+testCSRFToken+`"`) {
+		t.Error("todo page does not carry the token for htmx and the form")
+	}
+	rec = serveWithCookies(t, "GET", "/login", "", nil, csrfCookie())
+	if !strings.Contains(rec.Body.String(), `name="_csrf" value="`+testCSRFToken+`"`) {
+		t.Error("login form does not carry the token")
+	}
+}
+
+func TestCSRFTokenRotatesOnLogin(t *testing.T) {
+	seedData()
+	form := url.Values{"username": {"admin"}, "password": {"admin123"}, templates.CSRFFieldName: {testCSRFToken}}
+	rec := serveWithCookies(t, "POST", "/login", form.Encode(), formHeaders, csrfCookie())
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("login = %d", rec.Code)
+	}
+	rotated := findCookie(rec, csrfCookieName)
+	if rotated == nil || rotated.Value == "" || rotated.Value == testCSRFToken {
+		t.Fatalf("login did not rotate the token: %v", rec.Result().Cookies())
+	}
+	session := findCookie(rec, sessionCookieName)
+
+	// The old token is no good with the new cookie; the new one is
+	old := url.Values{"text": {"Old token"}, templates.CSRFFieldName: {testCSRFToken}}.Encode()
+	if rec := serveWithCookies(t, "POST", "/todo", old, formHeaders, session, rotated); rec.Code != http.StatusForbidden {
+		t.Errorf("post with the pre-login token = %d, want 403", rec.Code)
+	}
+	current := url.Values{"text": {"New token"}, templates.CSRFFieldName: {rotated.Value}}.Encode()
+	if rec := serveWithCookies(t, "POST", "/todo", current, formHeaders, session, rotated); rec.Code != http.StatusSeeOther {
+		t.Errorf("post with the rotated token = %d, want 303", rec.Code)
+	}
+}
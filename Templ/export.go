@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"templ-demo/templates"
+)
+
+// exportTodosHandler downloads the todos the filter in the query shows,
+// all of them rather than a page, as format=csv or format=ics
+func exportTodosHandler(w http.ResponseWriter, r *http.Request) {
+	shown := listTodos(parseTodoFilter(r))
+	switch r.URL.Query().Get("format") {
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="todos.csv"`)
+		writeTodosCSV(w, shown)
+	case "ics":
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="todos.ics"`)
+		writeTodosICS(w, shown, templates.Now(r.Context()))
+	default:
+		http.Error(w, "format must be csv or ics", http.StatusBadRequest)
+	}
+}
+
+// writeTodosCSV writes a header row and a row for each todo, leaving the
+// due date empty when there is none
+func writeTodosCSV(w http.ResponseWriter, shown []Todo) {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "text", "completed", "due_date", "priority"})
+	for _, todo := range shown {
+		due := ""
+		if !todo.DueDate.IsZero() {
+			due = todo.DueDate.Format(time.DateOnly)
+		}
+		cw.Write([]string{
+			strconv.Itoa(todo.ID),
+			todo.Text,
+			strconv.FormatBool(todo.Completed),
+			due,
+			todo.Priority,
+		})
+	}
+	cw.Flush()
+}
+
+// icsPriority maps the todo priorities onto iCalendar's 1 (highest) to 9
+var icsPriority = map[string]int{"high": 1, "medium": 5, "low": 9}
+
+// writeTodosICS writes an iCalendar file with a VTODO for each todo.
+// Todos don't record when they were completed, so COMPLETED is the time
+// of the export.
+func writeTodosICS(w http.ResponseWriter, shown []Todo, now time.Time) {
+	stamp := now.UTC().Format("20060102T150405Z")
+	var b strings.Builder
+	line := func(s string) {
+		b.WriteString(foldICSLine(s))
+		b.WriteString("\r\n")
+	}
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//templ-demo//Todos//EN")
+	for _, todo := range shown {
+		line("BEGIN:VTODO")
+		line(fmt.Sprintf("UID:todo-%d@templ-demo", todo.ID))
+		line("DTSTAMP:" + stamp)
+		line("SUMMARY:" + escapeICSText(todo.Text))
+		if !todo.DueDate.IsZero() {
+			line("DUE;VALUE=DATE:" + todo.DueDate.Format("20060102"))
+		}
+		if p, ok := icsPriority[todo.Priority]; ok {
+			line("PRIORITY:" + strconv.Itoa(p))
+		}
+		if todo.Completed {
+			line("STATUS:COMPLETED")
+			line("COMPLETED:" + stamp)
+		} else {
+			line("STATUS:NEEDS-ACTION")
+		}
+		line("END:VTODO")
+	}
+	line("END:VCALENDAR")
+	w.Write([]byte(b.String()))
+}
+
+// escapeICSText escapes a TEXT value as RFC 5545 section 3.3.11 asks
+var escapeICSText = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+	"\r", `\n`,
+).Replace
+
+// foldICSLine breaks a content line longer than 75 octets into lines
+// that continue with a leading space, without splitting a UTF-8 sequence
+func foldICSLine(s string) string {
+	const limit = 75
+	var b strings.Builder
+	width := 0
+	for _, r := range s {
+		n := len(string(r))
+		if width+n > limit {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += n
+	}
+	return b.String()
+}
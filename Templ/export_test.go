@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/csv"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExportCSV(t *testing.T) {
+	seedData()
+	todos.Add(Todo{Text: `say "hi", then
+leave`, Priority: "low", DueDate: time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local)})
+
+	rec := serve(t, "GET", "/todo/export?format=csv", "", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /todo/export?format=csv = %d", rec.Code)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "text/csv; charset=utf-8" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	if got := rec.Header().Get("Content-Disposition"); got != `attachment; filename="todos.csv"` {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("reading CSV: %v", err)
+	}
+	if len(records) != 6 || strings.Join(records[0], ",") != "id,text,completed,due_date,priority" {
+		t.Fatalf("records = %q", records)
+	}
+	if got := strings.Join(records[1], "|"); got != "1|Learn Templ basics|true||high" {
+		t.Errorf("first todo = %q", got)
+	}
+	want := []string{"5", "say \"hi\", then\nleave", "false", "2026-03-01", "low"}
+	if got := records[5]; strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("quoted todo = %q, want %q", got, want)
+	}
+}
+
+func TestExportHonorsFilter(t *testing.T) {
+	seedData()
+	records, err := csv.NewReader(serve(t, "GET", "/todo/export?format=csv&status=open&sort=text&order=desc&page=2&per_page=1", "", nil).Body).ReadAll()
+	if err != nil {
+		t.Fatalf("reading CSV: %v", err)
+	}
+	// Every matching todo, not just the requested page
+	var texts []string
+	for _, record := range records[1:] {
+		texts = append(texts, record[1])
+	}
+	if got := strings.Join(texts, "|"); got != "Create reusable components|Build a demo application|Add interactive features" {
+		t.Errorf("exported %q", texts)
+	}
+}
+
+func TestExportICS(t *testing.T) {
+	seedData()
+	todos.Add(Todo{Text: `Backslash \ semi; comma, and
+newline`, Priority: "medium", DueDate: time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local)})
+	todos.Update(5, func(todo *Todo) { todo.Completed = true })
+
+	now := time.Date(2026, 2, 14, 9, 30, 0, 0, time.UTC)
+	rec := serveAt(t, now, "GET", "/todo/export?format=ics&q=backslash", "", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /todo/export?format=ics = %d", rec.Code)
+	}
+	if got := rec.Header().Get("Content-Disposition"); got != `attachment; filename="todos.ics"` {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+	body := rec.Body.String()
+	if !strings.HasPrefix(body, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n") || !strings.HasSuffix(body, "END:VCALENDAR\r\n") {
+		t.Errorf("calendar = %q", body)
+	}
+	if n := strings.Count(body, "BEGIN:VTODO"); n != 1 {
+		t.Errorf("%d VTODOs, want the one the filter matches", n)
+	}
+	for _, want := range []string{
+		`SUMMARY:Backslash \\ semi\; comma\, and\nnewline` + "\r\n",
+		"DUE;VALUE=DATE:20260301\r\n",
+		"DTSTAMP:20260214T093000Z\r\n",
+		"STATUS:COMPLETED\r\n",
+		"COMPLETED:20260214T093000Z\r\n",
+		"PRIORITY:5\r\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("calendar does not contain %q", want)
+		}
+	}
+
+	// Every date value has the form its property needs
+	dates := regexp.MustCompile(`(?m)^(DTSTAMP|COMPLETED|DUE;VALUE=DATE):(.*)\r$`)
+	formats := map[string]string{"DTSTAMP": "20060102T150405Z", "COMPLETED": "20060102T150405Z", "DUE;VALUE=DATE": "20060102"}
+	for _, m := range dates.FindAllStringSubmatch(body, -1) {
+		if _, err := time.Parse(formats[m[1]], m[2]); err != nil {
+			t.Errorf("%s value %q: %v", m[1], m[2], err)
+		}
+	}
+}
+
+func TestExportICSFoldsLongLines(t *testing.T) {
+	seedData()
+	todos.Reset([]Todo{{ID: 1, Text: strings.Repeat("é", 60)}})
+	body := serve(t, "GET", "/todo/export?format=ics", "", nil).Body.String()
+	for _, line := range strings.Split(body, "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line of %d octets: %q", len(line), line)
+		}
+	}
+	unfolded := strings.ReplaceAll(body, "\r\n ", "")
+	if !strings.Contains(unfolded, "SUMMARY:"+strings.Repeat("é", 60)+"\r\n") {
+		t.Errorf("unfolded summary lost text: %q", unfolded)
+	}
+}
+
+func TestExportUnknownFormat(t *testing.T) {
+	seedData()
+	if rec := serve(t, "GET", "/todo/export?format=pdf", "", nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("format=pdf = %d, want 400", rec.Code)
+	}
+}
+
+func TestTodoPageExportLinksKeepFilter(t *testing.T) {
+	seedData()
+	page := serve(t, "GET", "/todo?status=open&page=1", "", nil).Body.String()
+	for _, want := range []string{`href="/todo/export?format=csv&amp;status=open"`, `href="/todo/export?format=ics&amp;status=open"`} {
+		if !strings.Contains(page, want) {
+			t.Errorf("page does not contain %q", want)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"templ-demo/templates"
+)
+
+// parseTodoFilter reads the filter bar's q, status, sort and order from
+// the query or, for the add and delete requests htmx sends with the bar
+// included, the form. Values it does not know fall back to the defaults:
+// both statuses, in the order they were created.
+func parseTodoFilter(r *http.Request) templates.TodoFilter {
+	filter := templates.TodoFilter{
+		Query:  strings.TrimSpace(r.FormValue("q")),
+		Status: r.FormValue("status"),
+		Sort:   r.FormValue("sort"),
+		Order:  r.FormValue("order"),
+	}
+	if filter.Status != "open" && filter.Status != "done" {
+		filter.Status = ""
+	}
+	if filter.Sort != "text" && filter.Sort != "due" {
+		filter.Sort = "created"
+	}
+	if filter.Order != "desc" {
+		filter.Order = "asc"
+	}
+	return filter
+}
+
+// listTodos returns the todos filter shows, in its order. The store is
+// left as it is.
+func listTodos(filter templates.TodoFilter) []Todo {
+	query := strings.ToLower(filter.Query)
+	var shown []Todo
+	for _, todo := range todos.All() {
+		switch {
+		case filter.Status == "open" && todo.Completed,
+			filter.Status == "done" && !todo.Completed,
+			!strings.Contains(strings.ToLower(todo.Text), query):
+			continue
+		}
+		shown = append(shown, todo)
+	}
+
+	// IDs count up, so they are the order the todos were created in.
+	// Todos without a due date come after the rest whichever the order.
+	slices.SortStableFunc(shown, func(a, b Todo) int {
+		c := a.ID - b.ID
+		switch filter.Sort {
+		case "text":
+			if t := strings.Compare(strings.ToLower(a.Text), strings.ToLower(b.Text)); t != 0 {
+				c = t
+			}
+		case "due":
+			if a.DueDate.IsZero() != b.DueDate.IsZero() {
+				if a.DueDate.IsZero() {
+					return 1
+				}
+				return -1
+			}
+			if d := a.DueDate.Compare(b.DueDate); d != 0 {
+				c = d
+			}
+		}
+		if filter.Order == "desc" {
+			return -c
+		}
+		return c
+	})
+	return shown
+}
+
+// todoFilterQuery is filter as query parameters, leaving out the defaults,
+// for links that keep it
+func todoFilterQuery(filter templates.TodoFilter) url.Values {
+	q := url.Values{}
+	if filter.Query != "" {
+		q.Set("q", filter.Query)
+	}
+	if filter.Status != "" {
+		q.Set("status", filter.Status)
+	}
+	if filter.Sort != "created" {
+		q.Set("sort", filter.Sort)
+	}
+	if filter.Order != "asc" {
+		q.Set("order", filter.Order)
+	}
+	return q
+}
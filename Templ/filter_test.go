@@ -0,0 +1,131 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"templ-demo/templates"
+)
+
+var todoTextPattern = regexp.MustCompile(`<(?:span|s class="text-muted")>([^<]*)</`)
+
+// listedTodos is the text of each todo on a page or in a section, in order
+func listedTodos(html string) []string {
+	var texts []string
+	for _, m := range todoTextPattern.FindAllStringSubmatch(html, -1) {
+		texts = append(texts, m[1])
+	}
+	return texts
+}
+
+func TestParseTodoFilter(t *testing.T) {
+	tests := []struct {
+		query string
+		want  templates.TodoFilter
+	}{
+		{"", templates.TodoFilter{Sort: "created", Order: "asc"}},
+		{"q=+Templ+&status=done&sort=text&order=desc", templates.TodoFilter{Query: "Templ", Status: "done", Sort: "text", Order: "desc"}},
+		{"status=closed&sort=priority&order=sideways", templates.TodoFilter{Sort: "created", Order: "asc"}},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/todo?"+tt.query, nil)
+		if got := parseTodoFilter(r); got != tt.want {
+			t.Errorf("parseTodoFilter(%q) = %+v, want %+v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestTodoFilters(t *testing.T) {
+	seedData()
+	todos.Add(Todo{Text: "build the docs", Completed: true})
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		// The seeded todos in the order they were created
+		{"", []string{"Learn Templ basics", "Build a demo application", "Create reusable components", "Add interactive features", "build the docs"}},
+		// Search ignores case
+		{"q=BUILD", []string{"Build a demo application", "build the docs"}},
+		{"status=open", []string{"Build a demo application", "Create reusable components", "Add interactive features"}},
+		{"status=done&sort=text", []string{"build the docs", "Learn Templ basics"}},
+		{"sort=created&order=desc", []string{"build the docs", "Add interactive features", "Create reusable components", "Build a demo application", "Learn Templ basics"}},
+		// Combined: search, status and a descending text sort
+		{"q=a&status=open&sort=text&order=desc", []string{"Create reusable components", "Build a demo application", "Add interactive features"}},
+		// Unknown values fall back to every todo in created order
+		{"status=archived&sort=priority&order=random", []string{"Learn Templ basics", "Build a demo application", "Create reusable components", "Add interactive features", "build the docs"}},
+	}
+	for _, tt := range tests {
+		rec := serve(t, "GET", "/todo?"+tt.query, "", nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET /todo?%s = %d", tt.query, rec.Code)
+		}
+		if got := listedTodos(rec.Body.String()); strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("GET /todo?%s lists %q, want %q", tt.query, got, tt.want)
+		}
+	}
+	if todos.Len() != 5 || todos.All()[0].Text != "Learn Templ basics" {
+		t.Error("filtering changed the store")
+	}
+}
+
+func TestTodoFilterControlsKeepSelections(t *testing.T) {
+	seedData()
+	page := serve(t, "GET", "/todo?q=demo+app&status=open&sort=text&order=desc", "", nil).Body.String()
+	for _, want := range []string{
+		`<form id="todo-filter"`,
+		`name="q" value="demo app"`,
+		`<option value="open" selected>Open</option>`,
+		`<option value="text" selected>Text</option>`,
+		`<option value="desc" selected>Descending</option>`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("page does not contain %q", want)
+		}
+	}
+
+	// Invalid values show the defaults selected
+	page = serve(t, "GET", "/todo?sort=bogus&order=bogus", "", nil).Body.String()
+	for _, want := range []string{`<option value="" selected>All</option>`, `<option value="created" selected>Created</option>`, `<option value="asc" selected>Ascending</option>`} {
+		if !strings.Contains(page, want) {
+			t.Errorf("page does not contain %q", want)
+		}
+	}
+}
+
+func TestTodoFilterEmptyState(t *testing.T) {
+	seedData()
+
+	// htmx gets just the section, with the counters still for every todo
+	rec := serve(t, "GET", "/todo?q=nothing+like+this", "", htmxHeaders)
+	body := rec.Body.String()
+	if strings.Contains(body, "<html") || !strings.Contains(body, `<h5 class="card-title text-primary">4</h5>`) {
+		t.Errorf("filter fragment = %s", body)
+	}
+	if !strings.Contains(body, "No matching todos") || !strings.Contains(body, `href="/todo">clear the filters`) || strings.Contains(body, "todo-item") {
+		t.Errorf("empty filter result = %s", body)
+	}
+
+	// With no todos at all it is the usual empty list
+	todos.Reset(nil)
+	if body := serve(t, "GET", "/todo?status=done", "", nil).Body.String(); !strings.Contains(body, "No todos yet") || strings.Contains(body, "No matching todos") {
+		t.Error("empty store does not show the no todos state")
+	}
+}
+
+func TestTodoChangesKeepFilter(t *testing.T) {
+	seedData()
+
+	// The add form includes the filter bar, so the new section stays filtered
+	rec := serve(t, "POST", "/todo", "text=Templ+filters&q=templ&status=open", htmxHeaders)
+	if got := listedTodos(rec.Body.String()); strings.Join(got, "|") != "Templ filters" {
+		t.Errorf("create with filter lists %q", got)
+	}
+	rec = serve(t, "DELETE", "/todo/5?q=templ", "", htmxHeaders)
+	if got := listedTodos(rec.Body.String()); strings.Join(got, "|") != "Learn Templ basics" {
+		t.Errorf("delete with filter lists %q", got)
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// captureLogs sends the default logger's output, as JSON, to the returned
+// buffer until the test ends
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	old := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(old) })
+	return &buf
+}
+
+type logLine struct {
+	Level     string `json:"level"`
+	Msg       string `json:"msg"`
+	RequestID string `json:"request_id"`
+	Method    string `json:"method"`
+	Path      string `json:"path"`
+	Status    int    `json:"status"`
+}
+
+// requestLines are the request log lines in buf
+func requestLines(t *testing.T, buf *bytes.Buffer) []logLine {
+	t.Helper()
+	var lines []logLine
+	for _, raw := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var line logLine
+		if err := json.Unmarshal([]byte(raw), &line); err != nil {
+			t.Fatalf("log line %q: %v", raw, err)
+		}
+		if line.Msg == "request" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func TestLoggingCapturesStatus(t *testing.T) {
+	seedData()
+	tests := []struct {
+		method, target, body string
+		headers              map[string]string
+		status               int
+		level                string
+	}{
+		{"GET", "/todo", "", nil, http.StatusOK, "INFO"},
+		{"POST", "/todo", "text=Logged", formHeaders, http.StatusSeeOther, "INFO"},
+		{"POST", "/todo", "text=", htmxHeaders, http.StatusUnprocessableEntity, "WARN"},
+		{"GET", "/api/v1/todos/99", "", apiHeaders, http.StatusNotFound, "WARN"},
+		{"PUT", "/todo/abc", "{}", jsonHeaders, http.StatusBadRequest, "WARN"},
+	}
+	for _, tt := range tests {
+		buf := captureLogs(t)
+		rec := serve(t, tt.method, tt.target, tt.body, tt.headers)
+		if rec.Code != tt.status {
+			t.Fatalf("%s %s = %d, want %d", tt.method, tt.target, rec.Code, tt.status)
+		}
+		lines := requestLines(t, buf)
+		if len(lines) != 1 {
+			t.Fatalf("%s %s logged %d request lines", tt.method, tt.target, len(lines))
+		}
+		line := lines[0]
+		if line.Status != tt.status || line.Level != tt.level || line.Method != tt.method || line.Path != strings.Split(tt.target, "?")[0] {
+			t.Errorf("%s %s logged %+v, want status %d at %s", tt.method, tt.target, line, tt.status, tt.level)
+		}
+		if id := rec.Header().Get(requestIDHeader); id == "" || line.RequestID != id {
+			t.Errorf("%s %s logged request ID %q, response has %q", tt.method, tt.target, line.RequestID, id)
+		}
+	}
+
+	// A refused CSRF check is logged as the 403 it is
+	buf := captureLogs(t)
+	serveWithCookies(t, "DELETE", "/todo/1", "", htmxHeaders, sessionCookie("admin", time.Now().Add(time.Hour)))
+	if lines := requestLines(t, buf); len(lines) != 1 || lines[0].Status != http.StatusForbidden {
+		t.Errorf("CSRF refusal logged %+v", lines)
+	}
+}
+
+func TestStatusRecorder(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		want    int
+	}{
+		{"nothing written", func(w http.ResponseWriter, r *http.Request) {}, http.StatusOK},
+		{"body only", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("hi")) }, http.StatusOK},
+		{"error", func(w http.ResponseWriter, r *http.Request) { http.Error(w, "boom", http.StatusInternalServerError) }, http.StatusInternalServerError},
+		{"first header wins", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+			w.WriteHeader(http.StatusOK)
+		}, http.StatusTeapot},
+	}
+	for _, tt := range tests {
+		rec := &statusRecorder{ResponseWriter: httptest.NewRecorder()}
+		tt.handler(rec, httptest.NewRequest("GET", "/", nil))
+		if rec.Status() != tt.want {
+			t.Errorf("%s: Status = %d, want %d", tt.name, rec.Status(), tt.want)
+		}
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	captureLogs(t)
+
+	// A sensible ID from the client is kept, anything else replaced
+	rec := serve(t, "GET", "/health", "", map[string]string{requestIDHeader: "client-42"})
+	if got := rec.Header().Get(requestIDHeader); got != "client-42" {
+		t.Errorf("kept request ID = %q", got)
+	}
+	rec = serve(t, "GET", "/health", "", map[string]string{requestIDHeader: "bad id\nwith a newline"})
+	if got := rec.Header().Get(requestIDHeader); !validRequestID.MatchString(got) || got == "client-42" {
+		t.Errorf("replaced request ID = %q", got)
+	}
+	if a, b := serve(t, "GET", "/health", "", nil), serve(t, "GET", "/health", "", nil); a.Header().Get(requestIDHeader) == b.Header().Get(requestIDHeader) {
+		t.Error("two requests got the same ID")
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	slow := func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}
+	buf := captureLogs(t)
+	handler := requestIDMiddleware(loggingMiddleware(withTimeout(slow, 10*time.Millisecond)))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("POST", "/contact", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("slow handler = %d, want 503", rec.Code)
+	}
+	if lines := requestLines(t, buf); len(lines) != 1 || lines[0].Status != http.StatusServiceUnavailable || lines[0].Level != "ERROR" {
+		t.Errorf("timeout logged %+v", lines)
+	}
+}
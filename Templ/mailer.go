@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"strings"
+
+	"templ-demo/templates"
+)
+
+// Mailer sends the messages the contact form composes
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Message is an email to the site's owner. The mailer fills in who it is
+// from and to.
+type Message struct {
+	ReplyTo mail.Address
+	Subject string
+	Body    string
+}
+
+// format writes msg as a plain text email from and to the given addresses
+func (msg Message) format(from, to string) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Reply-To: %s\r\n", msg.ReplyTo.String())
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", oneLine(msg.Subject)))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	body := strings.ReplaceAll(msg.Body, "\r\n", "\n")
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	return b.Bytes()
+}
+
+// oneLine replaces line breaks, so a header value cannot start another
+// header
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// composeContactMessage is the email a contact form submission sends,
+// which replies go back to the sender
+func composeContactMessage(values templates.ContactFormValues) Message {
+	name := oneLine(values.Name)
+	return Message{
+		ReplyTo: mail.Address{Name: name, Address: values.Email},
+		Subject: "Contact form: " + name,
+		Body: fmt.Sprintf("Name: %s\nEmail: %s\n\n%s\n",
+			name, values.Email, values.Message),
+	}
+}
+
+// newMailerFromEnv sends mail through the SMTP server in SMTP_HOST, or
+// only logs it when that is not set:
+//
+//	SMTP_HOST, SMTP_PORT     the server; the port defaults to 587
+//	SMTP_USERNAME, SMTP_PASSWORD  login, if the server needs one
+//	SMTP_FROM                the sender address
+//	CONTACT_TO               where messages go; defaults to SMTP_FROM
+func newMailerFromEnv() Mailer {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		return logMailer{}
+	}
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = "587"
+	}
+	from := os.Getenv("SMTP_FROM")
+	to := os.Getenv("CONTACT_TO")
+	if to == "" {
+		to = from
+	}
+	m := &smtpMailer{addr: net.JoinHostPort(host, port), host: host, from: from, to: to}
+	if username := os.Getenv("SMTP_USERNAME"); username != "" {
+		m.auth = smtp.PlainAuth("", username, os.Getenv("SMTP_PASSWORD"), host)
+	}
+	return m
+}
+
+// logMailer logs messages instead of sending them, for running the demo
+// without a mail server
+type logMailer struct{}
+
+func (logMailer) Send(ctx context.Context, msg Message) error {
+	slog.InfoContext(ctx, "contact message (not sent: SMTP_HOST is not set)",
+		"reply_to", msg.ReplyTo.String(),
+		"subject", msg.Subject,
+		"body", msg.Body,
+	)
+	return nil
+}
+
+// smtpMailer sends messages through an SMTP server, upgrading to TLS when
+// it offers STARTTLS
+type smtpMailer struct {
+	addr string
+	host string
+	from string
+	to   string
+	auth smtp.Auth
+}
+
+func (m *smtpMailer) Send(ctx context.Context, msg Message) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", m.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+			return err
+		}
+	}
+	if m.auth != nil {
+		if err := c.Auth(m.auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(m.from); err != nil {
+		return err
+	}
+	if err := c.Rcpt(m.to); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg.format(m.from, m.to)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
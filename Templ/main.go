@@ -1,15 +1,24 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"mime"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
 	"time"
 
+	"templ-demo/static"
 	"templ-demo/templates"
 
+	"github.com/a-h/templ"
 	"github.com/gorilla/mux"
 )
 
@@ -19,36 +28,96 @@ type User = templates.User
 
 // Global data stores (in a real app, you'd use a database)
 var (
-	todos  []Todo
-	users  []User
-	nextID = 1
-	userID = 1
+	todos = newStore(func(t *Todo) *int { return &t.ID })
+	users = newStore(func(u *User) *int { return &u.ID })
 )
 
 func init() {
-	// Initialize with sample data
-	todos = []Todo{
-		{ID: nextID, Text: "Learn Templ basics", Completed: true},
-		{ID: nextID + 1, Text: "Build a demo application", Completed: false},
-		{ID: nextID + 2, Text: "Create reusable components", Completed: false},
-		{ID: nextID + 3, Text: "Add interactive features", Completed: false},
-	}
-	nextID = 5
-
-	users = []User{
-		{ID: userID, Name: "Alice Johnson", Email: "alice@example.com", Role: "Admin"},
-		{ID: userID + 1, Name: "Bob Smith", Email: "bob@example.com", Role: "User"},
-		{ID: userID + 2, Name: "Carol Davis", Email: "carol@example.com", Role: "Editor"},
-		{ID: userID + 3, Name: "David Wilson", Email: "david@example.com", Role: "User"},
-	}
-	userID = 5
+	seedData()
 }
 
+// seedData resets the stores to the sample data
+func seedData() {
+	todos.Reset([]Todo{
+		{ID: 1, Text: "Learn Templ basics", Completed: true, Priority: "high"},
+		{ID: 2, Text: "Build a demo application", Completed: false, Priority: "high"},
+		{ID: 3, Text: "Create reusable components", Completed: false, Priority: "medium"},
+		{ID: 4, Text: "Add interactive features", Completed: false, Priority: "low"},
+	})
+
+	users.Reset([]User{
+		{ID: 1, Name: "Alice Johnson", Email: "alice@example.com", Role: "Admin", Version: 1},
+		{ID: 2, Name: "Bob Smith", Email: "bob@example.com", Role: "User", Version: 1},
+		{ID: 3, Name: "Carol Davis", Email: "carol@example.com", Role: "Editor", Version: 1},
+		{ID: 4, Name: "David Wilson", Email: "david@example.com", Role: "User", Version: 1},
+	})
+}
+
+// shutdownDrain is how long the server waits for requests in flight when
+// it is stopped
+const shutdownDrain = 10 * time.Second
+
+// contactTimeout is how long the contact form handler, sending mail, may
+// take
+const contactTimeout = 10 * time.Second
+
+// contactLimit is how many messages the contact form sends for one address
+// in an hour
+const contactLimit = 5
+
+var (
+	mailer         = newMailerFromEnv()
+	contactLimiter = newRateLimiter(contactLimit, time.Hour)
+)
+
 func main() {
+	r := newRouter()
+
+	fmt.Println("🚀 Templ Demo Server Starting...")
+	fmt.Println("📍 Server running on http://localhost:8080")
+	fmt.Println("🌐 Open your browser and navigate to:")
+	fmt.Println("   • Home: http://localhost:8080")
+	fmt.Println("   • Components: http://localhost:8080/components")
+	fmt.Println("   • Todo App: http://localhost:8080/todo")
+	fmt.Println("   • Users: http://localhost:8080/users")
+	fmt.Println("   • Log In: http://localhost:8080/login (admin / admin123)")
+	fmt.Println("")
+
+	server := &http.Server{
+		Addr:    ":8080",
+		Handler: r,
+		// Add timeouts for production readiness
+		WriteTimeout: 15 * time.Second,
+		ReadTimeout:  15 * time.Second,
+		IdleTimeout:  60 * time.Second,
+	}
+
+	// Ctrl+C or a SIGTERM lets the requests in flight finish first
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		slog.Error("listening", "addr", server.Addr, "error", err)
+		os.Exit(1)
+	}
+	if err := runServer(ctx, server, listener, shutdownDrain); err != nil {
+		slog.Error("server stopped", "error", err)
+		os.Exit(1)
+	}
+	slog.Info("server stopped")
+}
+
+// newRouter registers the pages, API routes and middleware
+func newRouter() *mux.Router {
 	r := mux.NewRouter()
 
-	// Middleware for logging
-	r.Use(loggingMiddleware)
+	// Middleware for request IDs and logging, then sessions; the todo, users and API
+	// routes need a login, and every post needs the CSRF token
+	r.Use(requestIDMiddleware, loggingMiddleware, sessionMiddleware, requireLogin, csrfMiddleware)
+
+	// Stylesheet and favicon, embedded in the binary
+	r.PathPrefix(static.Prefix).Handler(static.Default.Handler()).Methods("GET", "HEAD")
 
 	// Static routes
 	r.HandleFunc("/", homeHandler).Methods("GET")
@@ -61,37 +130,31 @@ func main() {
 	r.HandleFunc("/todo/{id}", updateTodoHandler).Methods("PUT")
 	r.HandleFunc("/todo/{id}", deleteTodoHandler).Methods("DELETE")
 	r.HandleFunc("/todo/{id}/toggle", toggleTodoHandler).Methods("POST")
+	r.HandleFunc("/todo/stats", todoStatsHandler).Methods("GET")
+	r.HandleFunc("/todo/export", exportTodosHandler).Methods("GET")
 
 	// API routes for Users
 	r.HandleFunc("/users", createUserHandler).Methods("POST")
+	r.HandleFunc("/users/{id}/edit", editUserHandler).Methods("GET")
 	r.HandleFunc("/users/{id}", updateUserHandler).Methods("PUT")
 	r.HandleFunc("/users/{id}", deleteUserHandler).Methods("DELETE")
 
+	// The JSON API
+	registerAPIRoutes(r)
+
+	// Login and logout
+	r.HandleFunc("/login", loginPageHandler).Methods("GET")
+	r.HandleFunc("/login", loginHandler).Methods("POST")
+	r.HandleFunc("/logout", logoutHandler).Methods("GET")
+
 	// Contact form handler
-	r.HandleFunc("/contact", contactHandler).Methods("POST")
+	r.HandleFunc("/contact", contactPageHandler).Methods("GET")
+	r.Handle("/contact", withTimeout(contactHandler, contactTimeout)).Methods("POST")
 
 	// Health check
 	r.HandleFunc("/health", healthHandler).Methods("GET")
 
-	fmt.Println("🚀 Templ Demo Server Starting...")
-	fmt.Println("📍 Server running on http://localhost:8080")
-	fmt.Println("🌐 Open your browser and navigate to:")
-	fmt.Println("   • Home: http://localhost:8080")
-	fmt.Println("   • Components: http://localhost:8080/components")
-	fmt.Println("   • Todo App: http://localhost:8080/todo")
-	fmt.Println("   • Users: http://localhost:8080/users")
-	fmt.Println("")
-
-	server := &http.Server{
-		Addr:    ":8080",
-		Handler: r,
-		// Add timeouts for production readiness
-		WriteTimeout: 15 * time.Second,
-		ReadTimeout:  15 * time.Second,
-		IdleTimeout:  60 * time.Second,
-	}
-
-	log.Fatal(server.ListenAndServe())
+	return r
 }
 
 // Handlers
@@ -101,41 +164,60 @@ func homeHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func componentsHandler(w http.ResponseWriter, r *http.Request) {
-	// For now, redirect to home (we can add a components page later)
-	http.Redirect(w, r, "/", http.StatusFound)
+	templates.ComponentsPage(templates.Showcase).Render(r.Context(), w)
 }
 
 func todoHandler(w http.ResponseWriter, r *http.Request) {
-	component := templates.TodoPage(todos)
+	// The same list for clients that ask for JSON
+	if wantsJSON(r) {
+		apiListTodosHandler(w, r)
+		return
+	}
+	shown, filter, info := todoPage(r)
+	// The filter bar's htmx requests only need the list
+	if isFragmentRequest(r) {
+		component := templates.TodoSection(todos.All(), shown, filter, info)
+		component.Render(r.Context(), w)
+		return
+	}
+	component := templates.TodoPage(todos.All(), shown, filter, info)
 	component.Render(r.Context(), w)
 }
 
 func usersHandler(w http.ResponseWriter, r *http.Request) {
-	// For now, return a simple JSON response
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Users page coming soon!",
-		"users":   users,
-	})
+	shown, info := usersPage(r)
+	component := templates.UsersPage(users.All(), shown, info)
+	component.Render(r.Context(), w)
 }
 
 // Todo API handlers
 func createTodoHandler(w http.ResponseWriter, r *http.Request) {
-	text := r.FormValue("text")
-	if text == "" {
-		http.Error(w, "Text is required", http.StatusBadRequest)
+	values := templates.TodoFormValues{
+		Text:     formValue(r, "text"),
+		DueDate:  formValue(r, "due_date"),
+		Priority: formValue(r, "priority"),
+	}
+	todo, errs := parseTodoForm(values, templates.Now(r.Context()))
+	if errs != nil {
+		// htmx would otherwise put the form where the list goes
+		if isFragmentRequest(r) {
+			w.Header().Set("HX-Retarget", "#todo-form")
+			w.Header().Set("HX-Reswap", "innerHTML")
+		}
+		renderInvalid(w, r, templates.TodoForm(values, errs))
 		return
 	}
 
-	todo := Todo{
-		ID:        nextID,
-		Text:      text,
-		Completed: false,
-	}
-	todos = append(todos, todo)
-	nextID++
+	todos.Add(todo)
 
-	http.Redirect(w, r, "/todo", http.StatusSeeOther)
+	if !isFragmentRequest(r) {
+		http.Redirect(w, r, "/todo", http.StatusSeeOther)
+		return
+	}
+	w.Header().Set("HX-Trigger", "todoCreated")
+	shown, filter, info := todoPage(r)
+	component := templates.TodoCreated(todos.All(), shown, filter, info)
+	component.Render(r.Context(), w)
 }
 
 func updateTodoHandler(w http.ResponseWriter, r *http.Request) {
@@ -146,23 +228,24 @@ func updateTodoHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var requestData struct {
-		Text string `json:"text"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+	var update todoUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
 		http.Error(w, "Invalid JSON", http.StatusBadRequest)
 		return
 	}
-
-	for i, todo := range todos {
-		if todo.ID == id {
-			todos[i].Text = requestData.Text
-			w.WriteHeader(http.StatusOK)
-			return
-		}
+	change, errs := update.check()
+	if errs != nil {
+		http.Error(w, errs.Join(todoFields...), http.StatusUnprocessableEntity)
+		return
 	}
 
-	http.Error(w, "Todo not found", http.StatusNotFound)
+	todo, ok := todos.Update(id, change)
+	if !ok {
+		http.Error(w, "Todo not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(todo)
 }
 
 func deleteTodoHandler(w http.ResponseWriter, r *http.Request) {
@@ -173,15 +256,18 @@ func deleteTodoHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	for i, todo := range todos {
-		if todo.ID == id {
-			todos = append(todos[:i], todos[i+1:]...)
-			w.WriteHeader(http.StatusOK)
-			return
-		}
+	if !todos.Delete(id) {
+		http.Error(w, "Todo not found", http.StatusNotFound)
+		return
 	}
-
-	http.Error(w, "Todo not found", http.StatusNotFound)
+	if !isFragmentRequest(r) {
+		http.Redirect(w, r, "/todo", http.StatusSeeOther)
+		return
+	}
+	w.Header().Set("HX-Trigger", "todoDeleted")
+	shown, filter, info := todoPage(r)
+	component := templates.TodoSection(todos.All(), shown, filter, info)
+	component.Render(r.Context(), w)
 }
 
 func toggleTodoHandler(w http.ResponseWriter, r *http.Request) {
@@ -192,40 +278,75 @@ func toggleTodoHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	for i, todo := range todos {
-		if todo.ID == id {
-			todos[i].Completed = !todos[i].Completed
-			w.WriteHeader(http.StatusOK)
-			return
-		}
+	todo, ok := todos.Update(id, func(todo *Todo) {
+		todo.Completed = !todo.Completed
+	})
+	if !ok {
+		http.Error(w, "Todo not found", http.StatusNotFound)
+		return
+	}
+	if !isFragmentRequest(r) {
+		http.Redirect(w, r, "/todo", http.StatusSeeOther)
+		return
 	}
+	// Only the item is sent back; the trigger tells the statistics to
+	// refresh themselves
+	w.Header().Set("HX-Trigger", "todoToggled")
+	component := templates.TodoItem(todo)
+	component.Render(r.Context(), w)
+}
 
-	http.Error(w, "Todo not found", http.StatusNotFound)
+func todoStatsHandler(w http.ResponseWriter, r *http.Request) {
+	component := templates.TodoStats(todos.All())
+	component.Render(r.Context(), w)
 }
 
 // User API handlers
 func createUserHandler(w http.ResponseWriter, r *http.Request) {
-	name := r.FormValue("name")
-	email := r.FormValue("email")
-	role := r.FormValue("role")
-
-	if name == "" || email == "" || role == "" {
-		http.Error(w, "All fields are required", http.StatusBadRequest)
+	user := User{
+		Name:    formValue(r, "name"),
+		Email:   formValue(r, "email"),
+		Role:    formValue(r, "role"),
+		Version: 1,
+	}
+	if errs := validateUser(user); errs != nil {
+		renderInvalid(w, r, templates.AddUserForm(user, errs))
 		return
 	}
 
-	user := User{
-		ID:    userID,
-		Name:  name,
-		Email: email,
-		Role:  role,
+	users.Add(user)
+
+	// The page's script swaps in the updated section; a plain form post
+	// goes back to the page
+	if !isFragmentRequest(r) {
+		http.Redirect(w, r, "/users", http.StatusSeeOther)
+		return
 	}
-	users = append(users, user)
-	userID++
+	shown, info := usersPage(r)
+	component := templates.UsersSection(users.All(), shown, info)
+	component.Render(r.Context(), w)
+}
 
-	http.Redirect(w, r, "/users", http.StatusSeeOther)
+// editUserHandler sends the edit modal, filled in with the user as it is
+// now
+func editUserHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+	user, ok := users.Get(id)
+	if !ok {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+	component := templates.EditUserModal(user)
+	component.Render(r.Context(), w)
 }
 
+// updateUserHandler changes a user from JSON, or from the edit modal's
+// form. The form gets the updated row back, or itself again: with errors,
+// or with the user as it is now if someone else changed it first.
 func updateUserHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
@@ -234,23 +355,70 @@ func updateUserHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var requestData struct {
-		Name string `json:"name"`
+	if isFormPost(r) {
+		updateUserFromForm(w, r, id)
+		return
 	}
-	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+	var update userUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
 		http.Error(w, "Invalid JSON", http.StatusBadRequest)
 		return
 	}
+	user, errs, err := updateUser(id, update)
+	switch {
+	case errors.Is(err, errUserNotFound):
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	case errors.Is(err, errUserConflict):
+		http.Error(w, "The user was changed by someone else; reload and try again", http.StatusConflict)
+		return
+	case errs != nil:
+		http.Error(w, errs.Join(userFields...), http.StatusUnprocessableEntity)
+		return
+	}
+	component := templates.EnhancedUserRow(user)
+	component.Render(r.Context(), w)
+}
 
-	for i, user := range users {
-		if user.ID == id {
-			users[i].Name = requestData.Name
-			w.WriteHeader(http.StatusOK)
-			return
-		}
+func updateUserFromForm(w http.ResponseWriter, r *http.Request, id int) {
+	version, err := strconv.Atoi(r.FormValue("version"))
+	if err != nil {
+		http.Error(w, "Invalid version", http.StatusBadRequest)
+		return
+	}
+	values := User{
+		ID:      id,
+		Name:    formValue(r, "name"),
+		Email:   formValue(r, "email"),
+		Role:    formValue(r, "role"),
+		Version: version,
+	}
+
+	// The form sends every field, so none may be left empty. Its errors
+	// go over the form in the modal rather than the row.
+	if errs := validateUser(values); errs != nil {
+		retargetEditForm(w)
+		renderInvalid(w, r, templates.EditUserForm(values, errs))
+		return
+	}
+	user, _, err := updateUser(id, userUpdate{Name: values.Name, Email: values.Email, Role: values.Role, Version: &version})
+	switch {
+	case errors.Is(err, errUserNotFound):
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	case errors.Is(err, errUserConflict):
+		retargetEditForm(w)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusConflict)
+		component := templates.EditUserConflict(user)
+		component.Render(r.Context(), w)
+		return
 	}
 
-	http.Error(w, "User not found", http.StatusNotFound)
+	// The row replaces the old one, and the trigger closes the modal
+	w.Header().Set("HX-Trigger", "userUpdated")
+	component := templates.EnhancedUserRow(user)
+	component.Render(r.Context(), w)
 }
 
 func deleteUserHandler(w http.ResponseWriter, r *http.Request) {
@@ -261,33 +429,86 @@ func deleteUserHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	for i, user := range users {
-		if user.ID == id {
-			users = append(users[:i], users[i+1:]...)
-			w.WriteHeader(http.StatusOK)
-			return
-		}
+	if !users.Delete(id) {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+	shown, info := usersPage(r)
+	component := templates.UsersSection(users.All(), shown, info)
+	component.Render(r.Context(), w)
+}
+
+// Session handlers
+func loginPageHandler(w http.ResponseWriter, r *http.Request) {
+	component := templates.LoginPage("", r.URL.Query().Get("next"), "")
+	component.Render(r.Context(), w)
+}
+
+func loginHandler(w http.ResponseWriter, r *http.Request) {
+	username := formValue(r, "username")
+	next := r.FormValue("next")
+
+	if !checkCredentials(username, r.FormValue("password")) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusUnauthorized)
+		component := templates.LoginPage(username, next, "Invalid username or password")
+		component.Render(r.Context(), w)
+		return
 	}
 
-	http.Error(w, "User not found", http.StatusNotFound)
+	setSessionCookie(w, r, signSession(username, time.Now().Add(sessionTTL)), int(sessionTTL.Seconds()))
+	rotateCSRFToken(w, r)
+	http.Redirect(w, r, loginRedirect(next), http.StatusSeeOther)
+}
+
+func logoutHandler(w http.ResponseWriter, r *http.Request) {
+	setSessionCookie(w, r, "", -1)
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+func contactPageHandler(w http.ResponseWriter, r *http.Request) {
+	content := templates.ContactForm(templates.ContactFormValues{}, nil)
+	if r.URL.Query().Get("sent") == "1" {
+		content = templates.ContactSent()
+	}
+	templates.ContactPage(content).Render(r.Context(), w)
 }
 
 func contactHandler(w http.ResponseWriter, r *http.Request) {
-	name := r.FormValue("name")
-	email := r.FormValue("email")
-	message := r.FormValue("message")
+	values := templates.ContactFormValues{
+		Name:    formValue(r, "name"),
+		Email:   formValue(r, "email"),
+		Message: formValue(r, "message"),
+	}
+	if errs := validateContact(values); errs != nil {
+		renderInvalid(w, r, templates.ContactForm(values, errs))
+		return
+	}
 
-	// In a real app, you'd save this to a database or send an email
-	fmt.Printf("📧 Contact Form Submission:\n")
-	fmt.Printf("   Name: %s\n", name)
-	fmt.Printf("   Email: %s\n", email)
-	fmt.Printf("   Message: %s\n", message)
+	if retryAfter, ok := contactLimiter.Allow(clientIP(r), time.Now()); !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusTooManyRequests)
+		component := templates.ContactRateLimited(retryAfter)
+		if !isFragmentRequest(r) {
+			component = templates.ContactPage(component)
+		}
+		component.Render(r.Context(), w)
+		return
+	}
 
-	// Return a simple success response
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{
-		"message": "Thank you for your message! We'll get back to you soon.",
-	})
+	if err := mailer.Send(r.Context(), composeContactMessage(values)); err != nil {
+		requestLogger(r).Error("sending contact message", "error", err)
+		http.Error(w, "Your message could not be sent. Please try again later.", http.StatusBadGateway)
+		return
+	}
+	requestLogger(r).Info("contact message sent", "reply_to", values.Email)
+
+	if !isFragmentRequest(r) {
+		http.Redirect(w, r, "/contact?sent=1", http.StatusSeeOther)
+		return
+	}
+	templates.ContactSent().Render(r.Context(), w)
 }
 
 func healthHandler(w http.ResponseWriter, r *http.Request) {
@@ -296,25 +517,39 @@ func healthHandler(w http.ResponseWriter, r *http.Request) {
 		"timestamp": time.Now().Format(time.RFC3339),
 		"version":   "1.0.0",
 		"data": map[string]int{
-			"todos": len(todos),
-			"users": len(users),
+			"todos": todos.Len(),
+			"users": users.Len(),
 		},
 	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
-// Middleware
-func loggingMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-		next.ServeHTTP(w, r)
-		duration := time.Since(start)
-
-		fmt.Printf("📝 %s %s - %v\n",
-			r.Method,
-			r.URL.Path,
-			duration,
-		)
-	})
+// isFragmentRequest reports whether r came from a page script that wants
+// just the changed HTML back rather than a whole page: htmx sends
+// HX-Request, and the users page script X-Fragment
+func isFragmentRequest(r *http.Request) bool {
+	return r.Header.Get("HX-Request") == "true" || r.Header.Get("X-Fragment") == "true"
+}
+
+// retargetEditForm tells htmx to put the response over the edit modal's
+// form
+func retargetEditForm(w http.ResponseWriter) {
+	w.Header().Set("HX-Retarget", "#editUserForm")
+	w.Header().Set("HX-Reswap", "outerHTML")
+}
+
+// isFormPost reports whether r's body is an HTML form rather than JSON
+func isFormPost(r *http.Request) bool {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return mediaType == "application/x-www-form-urlencoded" || mediaType == "multipart/form-data"
+}
+
+// renderInvalid sends back a form that failed validation, filled in with
+// what was entered and its errors. A plain form post gets the form on its
+// own, which posts again when corrected.
+func renderInvalid(w http.ResponseWriter, r *http.Request, form templ.Component) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	form.Render(r.Context(), w)
 }
@@ -0,0 +1,486 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"templ-demo/templates"
+)
+
+// serve sends a request through the router, logged in as admin and with
+// the CSRF token, and returns the response
+func serve(t *testing.T, method, target string, body string, headers map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	return serveWithCookies(t, method, target, body, withCSRF(headers),
+		sessionCookie("admin", time.Now().Add(time.Hour)), csrfCookie())
+}
+
+// serveWithCookies sends a request with the given cookies, skipping nil ones
+func serveWithCookies(t *testing.T, method, target string, body string, headers map[string]string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	for _, cookie := range cookies {
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+	}
+	rec := httptest.NewRecorder()
+	newRouter().ServeHTTP(rec, req)
+	return rec
+}
+
+// serveAt is serve with the pages' clock stopped at now
+func serveAt(t *testing.T, now time.Time, method, target string, body string, headers map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for k, v := range withCSRF(headers) {
+		req.Header.Set(k, v)
+	}
+	req.AddCookie(sessionCookie("admin", time.Now().Add(time.Hour)))
+	req.AddCookie(csrfCookie())
+	req = req.WithContext(templates.WithClock(req.Context(), func() time.Time { return now }))
+	rec := httptest.NewRecorder()
+	newRouter().ServeHTTP(rec, req)
+	return rec
+}
+
+const testCSRFToken = "test-csrf-token"
+
+func csrfCookie() *http.Cookie {
+	return &http.Cookie{Name: csrfCookieName, Value: testCSRFToken}
+}
+
+// withCSRF is headers plus the CSRF token header
+func withCSRF(headers map[string]string) map[string]string {
+	h := map[string]string{templates.CSRFHeaderName: testCSRFToken}
+	for k, v := range headers {
+		h[k] = v
+	}
+	return h
+}
+
+func sessionCookie(username string, expires time.Time) *http.Cookie {
+	return &http.Cookie{Name: sessionCookieName, Value: signSession(username, expires)}
+}
+
+var (
+	formHeaders     = map[string]string{"Content-Type": "application/x-www-form-urlencoded"}
+	fragmentHeaders = map[string]string{"Content-Type": "application/x-www-form-urlencoded", "X-Fragment": "true"}
+	jsonHeaders     = map[string]string{"Content-Type": "application/json", "X-Fragment": "true"}
+)
+
+func TestUsersPageListsSeededUsers(t *testing.T) {
+	seedData()
+	rec := serve(t, "GET", "/users", "", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /users = %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Errorf("Content-Type = %q, want HTML", ct)
+	}
+	for _, name := range []string{"Alice Johnson", "Bob Smith", "Carol Davis", "David Wilson"} {
+		if !strings.Contains(rec.Body.String(), name) {
+			t.Errorf("page does not list %s", name)
+		}
+	}
+}
+
+func TestUserCRUDRoundTrip(t *testing.T) {
+	seedData()
+	form := url.Values{"name": {"Eve Adams"}, "email": {"eve@example.com"}, "role": {"Editor"}}.Encode()
+
+	// Create from the page's script returns the new section
+	rec := serve(t, "POST", "/users", form, fragmentHeaders)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("create = %d: %s", rec.Code, rec.Body)
+	}
+	section := rec.Body.String()
+	if !strings.Contains(section, `id="user-5"`) || !strings.Contains(section, "Eve Adams") || !strings.Contains(section, "All Users (5)") {
+		t.Errorf("create fragment does not show Eve as user 5 of 5: %s", section)
+	}
+	if strings.Contains(section, "<html") {
+		t.Error("create returned a whole page, want a fragment")
+	}
+
+	// A plain form post goes back to the page
+	rec = serve(t, "POST", "/users", url.Values{"name": {"Frank"}, "email": {"f@example.com"}, "role": {"User"}}.Encode(), formHeaders)
+	if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "/users" {
+		t.Errorf("plain create = %d to %q, want a redirect to /users", rec.Code, rec.Header().Get("Location"))
+	}
+
+	// Update returns just the changed row
+	rec = serve(t, "PUT", "/users/5", `{"name": "Eve Baker", "role": "Admin"}`, jsonHeaders)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("update = %d: %s", rec.Code, rec.Body)
+	}
+	row := rec.Body.String()
+	if !strings.HasPrefix(row, "<tr") || !strings.Contains(row, "Eve Baker") || !strings.Contains(row, "eve@example.com") || !strings.Contains(row, "bg-danger") {
+		t.Errorf("update fragment = %s", row)
+	}
+
+	// Delete returns the section without the user
+	rec = serve(t, "DELETE", "/users/5", "", jsonHeaders)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("delete = %d: %s", rec.Code, rec.Body)
+	}
+	if strings.Contains(rec.Body.String(), "Eve Baker") || !strings.Contains(rec.Body.String(), "All Users (5)") {
+		t.Errorf("delete fragment still lists Eve or has the wrong count")
+	}
+
+	// The page shows the result of all that
+	page := serve(t, "GET", "/users", "", nil).Body.String()
+	if strings.Contains(page, "Eve Baker") || !strings.Contains(page, "Frank") {
+		t.Error("page does not reflect the changes")
+	}
+}
+
+func TestUserHandlerErrors(t *testing.T) {
+	seedData()
+	tests := []struct {
+		method, target, body string
+		want                 int
+	}{
+		{"POST", "/users", url.Values{"name": {"No Email"}}.Encode(), http.StatusUnprocessableEntity},
+		{"PUT", "/users/99", `{"name": "Nobody"}`, http.StatusNotFound},
+		{"PUT", "/users/1", `not json`, http.StatusBadRequest},
+		{"PUT", "/users/abc", `{}`, http.StatusBadRequest},
+		{"DELETE", "/users/99", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		headers := jsonHeaders
+		if tt.method == "POST" {
+			headers = fragmentHeaders
+		}
+		if rec := serve(t, tt.method, tt.target, tt.body, headers); rec.Code != tt.want {
+			t.Errorf("%s %s = %d, want %d", tt.method, tt.target, rec.Code, tt.want)
+		}
+	}
+	if users.Len() != 4 {
+		t.Errorf("failed requests changed the users: %v", users.All())
+	}
+}
+
+var htmxHeaders = map[string]string{"Content-Type": "application/x-www-form-urlencoded", "HX-Request": "true"}
+
+func TestTodoHTMXFragments(t *testing.T) {
+	seedData()
+
+	// Create returns the list and counters
+	rec := serve(t, "POST", "/todo", "text=Write+tests", htmxHeaders)
+	if rec.Code != http.StatusOK || rec.Header().Get("HX-Trigger") != "todoCreated" {
+		t.Fatalf("create = %d with HX-Trigger %q", rec.Code, rec.Header().Get("HX-Trigger"))
+	}
+	body := rec.Body.String()
+	if strings.Contains(body, "<html") || !strings.Contains(body, `id="todo-stats"`) || !strings.Contains(body, `id="todo-list"`) {
+		t.Errorf("create fragment is not the list and counters: %s", body)
+	}
+	if !strings.Contains(body, "Write tests") || !strings.Contains(body, `<h5 class="card-title text-primary">5</h5>`) {
+		t.Error("create fragment does not show the new todo and a count of 5")
+	}
+
+	// Toggle returns the one item, now completed
+	rec = serve(t, "POST", "/todo/5/toggle", "", htmxHeaders)
+	if rec.Code != http.StatusOK || rec.Header().Get("HX-Trigger") != "todoToggled" {
+		t.Fatalf("toggle = %d with HX-Trigger %q", rec.Code, rec.Header().Get("HX-Trigger"))
+	}
+	body = rec.Body.String()
+	if !strings.HasPrefix(body, `<div class="card mb-2 todo-item border-success"`) || strings.Count(body, `class="card mb-2 todo-item`) != 1 {
+		t.Errorf("toggle fragment is not the single completed item: %s", body)
+	}
+	if !strings.Contains(body, "<s class=\"text-muted\">Write tests</s>") || !strings.Contains(body, `hx-post="/todo/5/toggle"`) {
+		t.Errorf("toggle fragment = %s", body)
+	}
+
+	// The counters it triggers a refresh of
+	rec = serve(t, "GET", "/todo/stats", "", htmxHeaders)
+	if !strings.Contains(rec.Body.String(), `<h5 class="card-title text-success">2</h5>`) {
+		t.Errorf("stats after toggle = %s", rec.Body)
+	}
+
+	// Delete returns the list and counters without the item
+	rec = serve(t, "DELETE", "/todo/5", "", htmxHeaders)
+	if rec.Code != http.StatusOK || rec.Header().Get("HX-Trigger") != "todoDeleted" {
+		t.Fatalf("delete = %d with HX-Trigger %q", rec.Code, rec.Header().Get("HX-Trigger"))
+	}
+	body = rec.Body.String()
+	if strings.Contains(body, "Write tests") || !strings.Contains(body, `<h5 class="card-title text-primary">4</h5>`) {
+		t.Errorf("delete fragment still has the todo or the wrong count: %s", body)
+	}
+
+	// Deleting the rest leaves the empty state
+	for _, id := range []string{"1", "2", "3", "4"} {
+		rec = serve(t, "DELETE", "/todo/"+id, "", htmxHeaders)
+	}
+	if !strings.Contains(rec.Body.String(), "No todos yet") {
+		t.Errorf("empty list fragment = %s", rec.Body)
+	}
+}
+
+func TestTodoRedirectWithoutHTMX(t *testing.T) {
+	seedData()
+	tests := []struct {
+		method, target, body string
+	}{
+		{"POST", "/todo", "text=Plain+form"},
+		{"POST", "/todo/1/toggle", ""},
+		{"DELETE", "/todo/2", ""},
+	}
+	for _, tt := range tests {
+		rec := serve(t, tt.method, tt.target, tt.body, formHeaders)
+		if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "/todo" {
+			t.Errorf("%s %s = %d to %q, want a redirect to /todo", tt.method, tt.target, rec.Code, rec.Header().Get("Location"))
+		}
+		if rec.Header().Get("HX-Trigger") != "" || strings.Contains(rec.Body.String(), "todo-item") {
+			t.Errorf("%s %s sent a fragment without HX-Request", tt.method, tt.target)
+		}
+	}
+
+	// The changes show on the page it redirects to
+	page := serve(t, "GET", "/todo", "", nil).Body.String()
+	if !strings.Contains(page, "Plain form") || strings.Contains(page, "Build a demo application") || !strings.Contains(page, `<span>Learn Templ basics</span>`) {
+		t.Error("page does not reflect the create, delete and toggle")
+	}
+
+	if rec := serve(t, "POST", "/todo/99/toggle", "", htmxHeaders); rec.Code != http.StatusNotFound {
+		t.Errorf("toggle of a missing todo = %d, want 404", rec.Code)
+	}
+}
+
+func TestCreateTodoValidation(t *testing.T) {
+	seedData()
+	long := strings.Repeat("é", maxTodoLength+1)
+	tests := []struct {
+		text, want string
+	}{
+		{"", "Text is required"},
+		{"   ", "Text is required"},
+		{long, "Text must be at most 200 characters"},
+	}
+	for _, tt := range tests {
+		for _, headers := range []map[string]string{htmxHeaders, formHeaders} {
+			rec := serve(t, "POST", "/todo", url.Values{"text": {tt.text}}.Encode(), headers)
+			if rec.Code != http.StatusUnprocessableEntity {
+				t.Fatalf("create %q = %d, want 422", tt.text, rec.Code)
+			}
+			body := rec.Body.String()
+			if !strings.HasPrefix(body, "<form") || !strings.Contains(body, tt.want) || !strings.Contains(body, "is-invalid") {
+				t.Errorf("create %q did not send back the form with %q: %s", tt.text, tt.want, body)
+			}
+			if tt.text == long && !strings.Contains(body, `value="`+long+`"`) {
+				t.Errorf("form does not keep the text entered")
+			}
+		}
+	}
+
+	// htmx is pointed at the form instead of the list
+	rec := serve(t, "POST", "/todo", "text=", htmxHeaders)
+	if rec.Header().Get("HX-Retarget") != "#todo-form" || rec.Header().Get("HX-Trigger") != "" {
+		t.Errorf("invalid htmx create has HX-Retarget %q and HX-Trigger %q", rec.Header().Get("HX-Retarget"), rec.Header().Get("HX-Trigger"))
+	}
+	if todos.Len() != 4 {
+		t.Errorf("invalid creates added todos: %v", todos.All())
+	}
+
+	// Valid text, with space around it, is trimmed and clears the form
+	rec = serve(t, "POST", "/todo", "text=++Valid+todo++", htmxHeaders)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `<div id="todo-form" hx-swap-oob="true">`) {
+		t.Errorf("valid create = %d without a blank form: %s", rec.Code, rec.Body)
+	}
+	if got := last(todos).Text; got != "Valid todo" {
+		t.Errorf("stored text = %q", got)
+	}
+	rec = serve(t, "POST", "/todo", "text=Another", formHeaders)
+	if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "/todo" {
+		t.Errorf("valid plain create = %d to %q, want a redirect to /todo", rec.Code, rec.Header().Get("Location"))
+	}
+}
+
+func TestCreateUserValidation(t *testing.T) {
+	seedData()
+	tests := []struct {
+		name         string
+		form         url.Values
+		errs, values []string
+	}{
+		{
+			"all missing", url.Values{},
+			[]string{"Name is required", "Email is required", "Role must be User, Editor or Admin"},
+			nil,
+		},
+		{
+			"bad email", url.Values{"name": {"Eve <Adams>"}, "email": {"eve@"}, "role": {"Editor"}},
+			[]string{"Email must be a valid address"},
+			[]string{`value="Eve &lt;Adams&gt;"`, `value="eve@"`, `<option value="Editor" selected>`},
+		},
+		{
+			"named address", url.Values{"name": {"Eve"}, "email": {"Eve <eve@example.com>"}, "role": {"User"}},
+			[]string{"Email must be a valid address"},
+			[]string{`value="Eve"`, `<option value="User" selected>`},
+		},
+		{
+			"unknown role", url.Values{"name": {"Eve"}, "email": {"eve@example.com"}, "role": {"Owner"}},
+			[]string{"Role must be User, Editor or Admin"},
+			[]string{`value="eve@example.com"`},
+		},
+	}
+	for _, tt := range tests {
+		for _, headers := range []map[string]string{fragmentHeaders, formHeaders} {
+			rec := serve(t, "POST", "/users", tt.form.Encode(), headers)
+			if rec.Code != http.StatusUnprocessableEntity {
+				t.Fatalf("%s: create = %d, want 422", tt.name, rec.Code)
+			}
+			body := rec.Body.String()
+			if !strings.HasPrefix(body, `<form id="addUserForm"`) {
+				t.Errorf("%s: response is not the add form: %s", tt.name, body)
+			}
+			for _, want := range append(tt.errs, tt.values...) {
+				if !strings.Contains(body, want) {
+					t.Errorf("%s: form does not contain %q", tt.name, want)
+				}
+			}
+			if strings.Count(body, `<div class="invalid-feedback">`) != len(tt.errs) {
+				t.Errorf("%s: form has the wrong number of errors: %s", tt.name, body)
+			}
+		}
+	}
+	if users.Len() != 4 {
+		t.Errorf("invalid creates added users: %v", users.All())
+	}
+
+	// Valid input still redirects a plain post
+	form := url.Values{"name": {" Eve Adams "}, "email": {"eve@example.com"}, "role": {"Admin"}}.Encode()
+	rec := serve(t, "POST", "/users", form, formHeaders)
+	if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "/users" {
+		t.Errorf("valid plain create = %d to %q, want a redirect to /users", rec.Code, rec.Header().Get("Location"))
+	}
+	if got := last(users); got.Name != "Eve Adams" || got.Role != "Admin" {
+		t.Errorf("stored user = %+v", got)
+	}
+}
+
+func TestTodoDueDateAndPriorityRoundTrip(t *testing.T) {
+	seedData()
+	now := time.Date(2025, time.June, 15, 9, 30, 0, 0, time.Local)
+
+	// Create with both fields
+	form := url.Values{"text": {"Renew passport"}, "due_date": {"2025-06-10"}, "priority": {"high"}}.Encode()
+	rec := serveAt(t, now, "POST", "/todo", form, htmxHeaders)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("create = %d: %s", rec.Code, rec.Body)
+	}
+	got := last(todos)
+	if got.Priority != "high" || got.DueDate.Format(templates.DateLayout) != "2025-06-10" || got.DueDate.Location() != time.Local {
+		t.Errorf("stored todo = %+v", got)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, "Overdue: Jun 10") || !strings.Contains(body, "todo-overdue") {
+		t.Error("created todo is not shown overdue")
+	}
+
+	// Without them it has no due date and medium priority
+	serveAt(t, now, "POST", "/todo", "text=Water+plants", htmxHeaders)
+	if got := last(todos); !got.DueDate.IsZero() || got.Priority != "medium" {
+		t.Errorf("todo without schedule = %+v", got)
+	}
+
+	// Update moves the due date into the future, then clears it
+	target := fmt.Sprintf("/todo/%d", got.ID)
+	rec = serveAt(t, now, "PUT", target, `{"due_date": "2025-07-01", "priority": "low"}`, jsonHeaders)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("update = %d: %s", rec.Code, rec.Body)
+	}
+	var updated Todo
+	if err := json.NewDecoder(rec.Body).Decode(&updated); err != nil {
+		t.Fatal(err)
+	}
+	if updated.Text != "Renew passport" || updated.Priority != "low" || updated.DueDate.Format(templates.DateLayout) != "2025-07-01" {
+		t.Errorf("updated todo = %+v", updated)
+	}
+	page := serveAt(t, now, "GET", "/todo", "", nil).Body.String()
+	if !strings.Contains(page, "Due Jul 1") || strings.Contains(page, "Overdue") {
+		t.Error("page does not show the new due date")
+	}
+	serveAt(t, now, "PUT", target, `{"due_date": ""}`, jsonHeaders)
+	if due := todos.All()[todos.Len()-2].DueDate; !due.IsZero() {
+		t.Errorf("due date not cleared: %v", due)
+	}
+}
+
+func TestTodoScheduleValidation(t *testing.T) {
+	seedData()
+	now := time.Date(2025, time.June, 15, 9, 30, 0, 0, time.Local)
+
+	tests := []struct {
+		form url.Values
+		want string
+	}{
+		{url.Values{"text": {"Old"}, "due_date": {"2024-06-14"}}, "Due date must be no more than a year ago"},
+		{url.Values{"text": {"Odd"}, "due_date": {"next tuesday"}}, "Due date must be a date like 2025-12-31"},
+		{url.Values{"text": {"Urgent"}, "priority": {"critical"}}, "Priority must be low, medium or high"},
+	}
+	for _, tt := range tests {
+		rec := serveAt(t, now, "POST", "/todo", tt.form.Encode(), htmxHeaders)
+		if rec.Code != http.StatusUnprocessableEntity || !strings.Contains(rec.Body.String(), tt.want) {
+			t.Errorf("create %v = %d: %s", tt.form, rec.Code, rec.Body)
+		}
+		if !strings.Contains(rec.Body.String(), `value="`+tt.form.Get("text")+`"`) {
+			t.Errorf("create %v did not keep the text", tt.form)
+		}
+	}
+	// A year ago to the day is still allowed
+	if rec := serveAt(t, now, "POST", "/todo", "text=Late&due_date=2024-06-15", htmxHeaders); rec.Code != http.StatusOK {
+		t.Errorf("due a year ago = %d", rec.Code)
+	}
+
+	// Updates check the fields they are given
+	for _, body := range []string{`{"priority": "urgent"}`, `{"due_date": "15/06/2025"}`, `{"text": "  "}`} {
+		if rec := serveAt(t, now, "PUT", "/todo/1", body, jsonHeaders); rec.Code != http.StatusUnprocessableEntity {
+			t.Errorf("update %s = %d, want 422", body, rec.Code)
+		}
+	}
+	if todos.All()[0].Text != "Learn Templ basics" || todos.All()[0].Priority != "high" {
+		t.Errorf("failed updates changed the todo: %+v", todos.All()[0])
+	}
+}
+
+func TestTodoSortByDueDate(t *testing.T) {
+	seedData()
+	todos.Update(2, func(t *Todo) { t.DueDate = time.Date(2025, time.July, 1, 0, 0, 0, 0, time.Local) })
+	todos.Update(4, func(t *Todo) { t.DueDate = time.Date(2025, time.June, 20, 0, 0, 0, 0, time.Local) })
+
+	for query, want := range map[string]string{
+		"sort=due":            "Add interactive features|Build a demo application|Learn Templ basics|Create reusable components",
+		"sort=due&order=desc": "Build a demo application|Add interactive features|Create reusable components|Learn Templ basics",
+	} {
+		body := serve(t, "GET", "/todo?"+query, "", nil).Body.String()
+		if got := strings.Join(listedTodos(body), "|"); got != want {
+			t.Errorf("GET /todo?%s lists %s, want %s", query, got, want)
+		}
+	}
+}
+
+func TestStaticAssets(t *testing.T) {
+	// The pages link the stylesheet by its hashed URL, which the router
+	// serves to anyone
+	page := serveWithCookies(t, "GET", "/", "", nil, nil).Body.String()
+	href := regexp.MustCompile(`href="(/static/css/app\.css\?v=[0-9a-f]+)"`).FindStringSubmatch(page)
+	if href == nil {
+		t.Fatal("home page does not link the hashed stylesheet")
+	}
+	rec := serveWithCookies(t, "GET", href[1], "", nil, nil)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "text/css; charset=utf-8" {
+		t.Errorf("GET %s = %d with Content-Type %q", href[1], rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(rec.Body.String(), ".hero-section") {
+		t.Error("stylesheet does not have the page styles")
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"templ-demo/templates"
+)
+
+// pageInfo reads the page and per_page parameters for a list of total
+// items at path. A missing or unusable per_page is the default and one
+// over the cap is the cap; the page is kept between the first and last.
+func pageInfo(r *http.Request, path string, query url.Values, total int) templates.PageInfo {
+	perPage, err := strconv.Atoi(r.FormValue("per_page"))
+	if err != nil || perPage < 1 {
+		perPage = templates.DefaultPerPage
+	}
+	perPage = min(perPage, templates.MaxPerPage)
+
+	info := templates.PageInfo{PerPage: perPage, Total: total, Path: path, Query: query}
+	page, err := strconv.Atoi(r.FormValue("page"))
+	if err != nil {
+		page = 1
+	}
+	info.Page = max(1, min(page, info.Pages()))
+	return info
+}
+
+// pageOf is the part of items on info's page
+func pageOf[T any](items []T, info templates.PageInfo) []T {
+	start := min(info.Offset(), len(items))
+	end := min(start+info.PerPage, len(items))
+	return items[start:end]
+}
+
+// todoPage is the page of todos the request's filter and page parameters
+// ask for
+func todoPage(r *http.Request) ([]Todo, templates.TodoFilter, templates.PageInfo) {
+	filter := parseTodoFilter(r)
+	shown := listTodos(filter)
+	info := pageInfo(r, "/todo", todoFilterQuery(filter), len(shown))
+	return pageOf(shown, info), filter, info
+}
+
+// usersPage is the page of users the request's page parameters ask for
+func usersPage(r *http.Request) ([]User, templates.PageInfo) {
+	all := users.All()
+	info := pageInfo(r, "/users", nil, len(all))
+	return pageOf(all, info), info
+}
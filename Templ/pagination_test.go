@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestPageInfoClamps(t *testing.T) {
+	tests := []struct {
+		query         string
+		page, perPage int
+	}{
+		{"", 1, 10},
+		{"page=3&per_page=5", 3, 5},
+		{"page=0", 1, 10},
+		{"page=-4", 1, 10},
+		{"page=99", 5, 10},
+		{"page=abc&per_page=abc", 1, 10},
+		{"per_page=0", 1, 10},
+		{"per_page=1000", 1, 50},
+		{"page=99&per_page=7", 7, 7},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/todo?"+tt.query, nil)
+		info := pageInfo(r, "/todo", nil, 45)
+		if info.Page != tt.page || info.PerPage != tt.perPage {
+			t.Errorf("pageInfo(%q) = page %d of %d per page, want page %d of %d", tt.query, info.Page, info.PerPage, tt.page, tt.perPage)
+		}
+	}
+
+	// An empty list is on its one page
+	r := httptest.NewRequest("GET", "/users?page=3", nil)
+	if info := pageInfo(r, "/users", nil, 0); info.Page != 1 {
+		t.Errorf("empty list is on page %d", info.Page)
+	}
+}
+
+func TestTodoPagination(t *testing.T) {
+	seedData()
+	for i := 0; i < 21; i++ {
+		todos.Add(Todo{Text: fmt.Sprintf("Extra %02d", i)})
+	}
+
+	// 25 todos, the last page of 10 has 5
+	body := serve(t, "GET", "/todo?page=3", "", nil).Body.String()
+	if got := listedTodos(body); len(got) != 5 || got[0] != "Extra 16" {
+		t.Errorf("page 3 lists %q", got)
+	}
+	if !strings.Contains(body, "Showing 21–25 of 25") || !strings.Contains(body, `<h5 class="card-title text-primary">25</h5>`) {
+		t.Error("page 3 summary or counters are wrong")
+	}
+
+	// Out of range pages are clamped rather than empty
+	body = serve(t, "GET", "/todo?page=40&per_page=20", "", nil).Body.String()
+	if got := listedTodos(body); len(got) != 5 || !strings.Contains(body, "Showing 21–25 of 25") {
+		t.Errorf("page 40 of 20 lists %q", got)
+	}
+
+	// Links keep the filter and sort, and the filter bar the page size
+	body = serve(t, "GET", "/todo?q=extra&sort=text&order=desc&per_page=5", "", nil).Body.String()
+	next := "/todo?" + url.Values{"order": {"desc"}, "page": {"2"}, "per_page": {"5"}, "q": {"extra"}, "sort": {"text"}}.Encode()
+	if !strings.Contains(body, `href="`+strings.ReplaceAll(next, "&", "&amp;")+`">Next</a>`) {
+		t.Errorf("next link does not keep the filter: want %s", next)
+	}
+	if !strings.Contains(body, `<input type="hidden" name="per_page" value="5">`) {
+		t.Error("filter bar does not keep the page size")
+	}
+	if got := listedTodos(body); len(got) != 5 || got[0] != "Extra 20" {
+		t.Errorf("first filtered page lists %q", got)
+	}
+
+	// A delete sends its page back and stays on it
+	rec := serve(t, "DELETE", "/todo/5?page=3", "", htmxHeaders)
+	if !strings.Contains(rec.Body.String(), "Showing 21–24 of 24") {
+		t.Errorf("delete on page 3 = %s", rec.Body)
+	}
+}
+
+func TestUsersPagination(t *testing.T) {
+	seedData()
+	for i := 0; i < 8; i++ {
+		users.Add(User{Name: fmt.Sprintf("Extra %d", i), Email: "x@example.com", Role: "User"})
+	}
+
+	body := serve(t, "GET", "/users?page=2&per_page=5", "", nil).Body.String()
+	if !strings.Contains(body, `id="user-6"`) || strings.Contains(body, `id="user-5"`) || strings.Contains(body, `id="user-11"`) {
+		t.Error("page 2 of 5 does not show users 6 to 10")
+	}
+	if !strings.Contains(body, "Showing 6–10 of 12") || !strings.Contains(body, "All Users (12)") {
+		t.Error("users page summary or count is wrong")
+	}
+
+	// The script sends the page's query with deletes, so the section stays
+	// on the page, clamped when it has emptied
+	rec := serve(t, "DELETE", "/users/12?page=3&per_page=5", "", jsonHeaders)
+	if !strings.Contains(rec.Body.String(), "Showing 11–11 of 11") {
+		t.Error("delete from the last page did not stay on it")
+	}
+	rec = serve(t, "DELETE", "/users/11?page=3&per_page=5", "", jsonHeaders)
+	if !strings.Contains(rec.Body.String(), "Showing 6–10 of 10") || !strings.Contains(rec.Body.String(), `id="user-10"`) {
+		t.Error("delete of the last page's only user did not move back a page")
+	}
+}
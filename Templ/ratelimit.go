@@ -0,0 +1,48 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// rateLimiter allows each key a number of uses in any window of time
+type rateLimiter struct {
+	mu     sync.Mutex
+	limit  int
+	window time.Duration
+	uses   map[string][]time.Time // oldest first
+}
+
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{limit: limit, window: window, uses: map[string][]time.Time{}}
+}
+
+// Allow records a use by key at now if it is under the limit. Otherwise
+// it returns how long until the oldest use in the window expires.
+func (l *rateLimiter) Allow(key string, now time.Time) (retryAfter time.Duration, ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	uses := l.uses[key]
+	for len(uses) > 0 && !uses[0].After(now.Add(-l.window)) {
+		uses = uses[1:]
+	}
+	if len(uses) >= l.limit {
+		l.uses[key] = uses
+		return uses[0].Add(l.window).Sub(now), false
+	}
+	l.uses[key] = append(uses, now)
+	return 0, true
+}
+
+// clientIP is the address r came from. X-Forwarded-For is ignored, as
+// anyone can send it; behind a proxy, trust the proxy's header instead.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	l := newRateLimiter(2, time.Hour)
+	start := time.Date(2025, time.June, 1, 12, 0, 0, 0, time.UTC)
+
+	if _, ok := l.Allow("a", start); !ok {
+		t.Fatal("first use refused")
+	}
+	if _, ok := l.Allow("a", start.Add(10*time.Minute)); !ok {
+		t.Fatal("second use refused")
+	}
+	retry, ok := l.Allow("a", start.Add(20*time.Minute))
+	if ok || retry != 40*time.Minute {
+		t.Errorf("third use = %v, %v; want refused for 40m", retry, ok)
+	}
+
+	// Other keys have their own count
+	if _, ok := l.Allow("b", start.Add(20*time.Minute)); !ok {
+		t.Error("another key refused")
+	}
+
+	// Once the first use is an hour old there is room again
+	if _, ok := l.Allow("a", start.Add(time.Hour)); !ok {
+		t.Error("use after the window refused")
+	}
+	if _, ok := l.Allow("a", start.Add(time.Hour+time.Minute)); ok {
+		t.Error("the window let a third use in")
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	r := httptest.NewRequest("POST", "/contact", nil)
+	r.RemoteAddr = "203.0.113.7:51234"
+	r.Header.Set("X-Forwarded-For", "198.51.100.1")
+	if got := clientIP(r); got != "203.0.113.7" {
+		t.Errorf("clientIP = %q", got)
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"templ-demo/templates"
+)
+
+const (
+	sessionCookieName = "templ_session"
+	sessionTTL        = 2 * time.Hour
+)
+
+// Session errors
+var (
+	errSessionMalformed = errors.New("malformed session cookie")
+	errSessionTampered  = errors.New("session signature mismatch")
+	errSessionExpired   = errors.New("session expired")
+)
+
+// credentials are the accounts that can log in (in a real app, you'd
+// store password hashes in a database)
+var credentials = map[string]string{
+	"admin": "admin123",
+	"demo":  "demo123",
+}
+
+// sessionSecret signs the session cookies. Without SESSION_SECRET a random
+// one is generated, so sessions do not survive a restart.
+var sessionSecret = newSessionSecret()
+
+func newSessionSecret() []byte {
+	if secret := os.Getenv("SESSION_SECRET"); secret != "" {
+		return []byte(secret)
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic(err)
+	}
+	return secret
+}
+
+// signSession returns a session cookie value for username that expires at
+// expires. It is "<base64(username:expiryUnix)>.<base64(HMAC-SHA256)>", so
+// no sessions are stored yet any change to it is detected.
+func signSession(username string, expires time.Time) string {
+	payload := username + ":" + strconv.FormatInt(expires.Unix(), 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(sessionMAC([]byte(payload)))
+}
+
+// verifySession checks the signature and expiry of a session cookie value
+// at now and returns the username in it
+func verifySession(value string, now time.Time) (string, error) {
+	encPayload, encMAC, ok := strings.Cut(value, ".")
+	if !ok {
+		return "", errSessionMalformed
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encPayload)
+	if err != nil {
+		return "", errSessionMalformed
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(encMAC)
+	if err != nil {
+		return "", errSessionMalformed
+	}
+	if !hmac.Equal(mac, sessionMAC(payload)) {
+		return "", errSessionTampered
+	}
+
+	// The username comes first, so the expiry is after the last colon
+	i := strings.LastIndexByte(string(payload), ':')
+	if i < 0 {
+		return "", errSessionMalformed
+	}
+	expiry, err := strconv.ParseInt(string(payload[i+1:]), 10, 64)
+	if err != nil {
+		return "", errSessionMalformed
+	}
+	if !now.Before(time.Unix(expiry, 0)) {
+		return "", errSessionExpired
+	}
+	return string(payload[:i]), nil
+}
+
+func sessionMAC(payload []byte) []byte {
+	h := hmac.New(sha256.New, sessionSecret)
+	h.Write(payload)
+	return h.Sum(nil)
+}
+
+// checkCredentials reports whether password is the one for username
+func checkCredentials(username, password string) bool {
+	want, ok := credentials[username]
+	return ok && subtle.ConstantTimeCompare([]byte(password), []byte(want)) == 1
+}
+
+// setSessionCookie sets the session cookie on w; maxAge < 0 deletes it
+func setSessionCookie(w http.ResponseWriter, r *http.Request, value string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// sessionMiddleware puts the logged in username into the request context,
+// where the pages read it. Malformed, tampered or expired cookies are
+// cleared and the request carries on anonymously.
+func sessionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie(sessionCookieName)
+		if err != nil || cookie.Value == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		username, err := verifySession(cookie.Value, time.Now())
+		if err != nil {
+			requestLogger(r).Warn("rejecting session cookie", "error", err)
+			setSessionCookie(w, r, "", -1)
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx := templates.WithUsername(r.Context(), username)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// protectedPrefixes are the pages, and the routes under them, that need a
+// login
+var protectedPrefixes = []string{"/todo", "/users", "/api"}
+
+// requireLogin sends anonymous requests for the protected routes to the
+// login page. Page scripts get a 401 instead, with HX-Redirect telling
+// htmx where to go, and API clients a 401 in JSON.
+func requireLogin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isProtected(r.URL.Path) || templates.Username(r.Context()) != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if isAPIRequest(r) {
+			writeJSONError(w, http.StatusUnauthorized, "Log in to continue")
+			return
+		}
+		if isFragmentRequest(r) {
+			w.Header().Set("HX-Redirect", "/login")
+			http.Error(w, "Log in to continue", http.StatusUnauthorized)
+			return
+		}
+		target := "/login"
+		if r.Method == http.MethodGet {
+			target += "?next=" + url.QueryEscape(r.URL.RequestURI())
+		}
+		http.Redirect(w, r, target, http.StatusSeeOther)
+	})
+}
+
+func isProtected(path string) bool {
+	for _, prefix := range protectedPrefixes {
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// loginRedirect is where to go after logging in: next if it is a path on
+// this site, otherwise the todo page
+func loginRedirect(next string) string {
+	if strings.HasPrefix(next, "/") && !strings.HasPrefix(next, "//") && !strings.HasPrefix(next, "/\\") {
+		return next
+	}
+	return "/todo"
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAnonymousRedirectedToLogin(t *testing.T) {
+	seedData()
+	for _, target := range []string{"/todo", "/users", "/todo/stats"} {
+		rec := serveWithCookies(t, "GET", target, "", nil, nil)
+		want := "/login?next=" + url.QueryEscape(target)
+		if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != want {
+			t.Errorf("anonymous GET %s = %d to %q, want a redirect to %s", target, rec.Code, rec.Header().Get("Location"), want)
+		}
+	}
+
+	// Page scripts are told to go to the login page, and nothing changes
+	rec := serveWithCookies(t, "POST", "/todo", "text=Sneaky", htmxHeaders, nil)
+	if rec.Code != http.StatusUnauthorized || rec.Header().Get("HX-Redirect") != "/login" {
+		t.Errorf("anonymous htmx create = %d with HX-Redirect %q", rec.Code, rec.Header().Get("HX-Redirect"))
+	}
+	if rec := serveWithCookies(t, "DELETE", "/users/1", "", jsonHeaders, nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("anonymous delete = %d, want 401", rec.Code)
+	}
+	if todos.Len() != 4 || users.Len() != 4 {
+		t.Error("anonymous requests changed the data")
+	}
+
+	// The home and components pages stay public and offer a login
+	for _, target := range []string{"/", "/components"} {
+		rec = serveWithCookies(t, "GET", target, "", nil, nil)
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `href="/login"`) {
+			t.Errorf("anonymous GET %s = %d without a login link", target, rec.Code)
+		}
+	}
+}
+
+func TestLoginAndAccess(t *testing.T) {
+	seedData()
+
+	// A wrong password shows the form again with the username kept
+	form := url.Values{"username": {"demo"}, "password": {"wrong"}, "next": {"/users"}}
+	rec := serveWithCookies(t, "POST", "/login", form.Encode(), withCSRF(formHeaders), csrfCookie())
+	if rec.Code != http.StatusUnauthorized || len(rec.Result().Cookies()) != 0 {
+		t.Fatalf("bad login = %d with cookies %v", rec.Code, rec.Result().Cookies())
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, "Invalid username or password") || !strings.Contains(body, `value="demo"`) || !strings.Contains(body, `value="/users"`) {
+		t.Errorf("bad login page = %s", body)
+	}
+
+	// The right one sets the cookie and goes where the user was headed
+	form.Set("password", "demo123")
+	rec = serveWithCookies(t, "POST", "/login", form.Encode(), withCSRF(formHeaders), csrfCookie())
+	if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "/users" {
+		t.Fatalf("login = %d to %q, want a redirect to /users", rec.Code, rec.Header().Get("Location"))
+	}
+	cookie := findCookie(rec, sessionCookieName)
+	if cookie == nil {
+		t.Fatalf("login cookies = %v", rec.Result().Cookies())
+	}
+	if !cookie.HttpOnly || cookie.SameSite != http.SameSiteLaxMode || cookie.MaxAge != int(sessionTTL.Seconds()) {
+		t.Errorf("session cookie = %+v", cookie)
+	}
+
+	// With it the pages open and name the user
+	for _, target := range []string{"/users", "/todo", "/"} {
+		rec = serveWithCookies(t, "GET", target, "", nil, cookie)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s with the cookie = %d", target, rec.Code)
+		}
+		if page := rec.Body.String(); !strings.Contains(page, ">demo</span>") || !strings.Contains(page, `href="/logout"`) {
+			t.Errorf("GET %s does not show demo and a logout link", target)
+		}
+	}
+
+	// Logging out clears it
+	rec = serveWithCookies(t, "GET", "/logout", "", nil, cookie)
+	if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "/" {
+		t.Errorf("logout = %d to %q", rec.Code, rec.Header().Get("Location"))
+	}
+	if cleared := findCookie(rec, sessionCookieName); cleared == nil || cleared.MaxAge >= 0 {
+		t.Errorf("logout cookies = %v", rec.Result().Cookies())
+	}
+}
+
+func TestTamperedAndExpiredSessions(t *testing.T) {
+	seedData()
+
+	// Swapping the name in the payload keeps admin's signature, which no
+	// longer matches
+	valid := signSession("admin", time.Now().Add(time.Hour))
+	_, mac, _ := strings.Cut(valid, ".")
+	payload := "demo:" + strings.SplitN(mustDecode(t, valid), ":", 2)[1]
+	tampered := base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + mac
+
+	tests := []struct {
+		name  string
+		value string
+		want  error
+	}{
+		{"tampered", tampered, errSessionTampered},
+		{"expired", signSession("admin", time.Now().Add(-time.Minute)), errSessionExpired},
+		{"malformed", "not-a-session", errSessionMalformed},
+	}
+	for _, tt := range tests {
+		if _, err := verifySession(tt.value, time.Now()); !errors.Is(err, tt.want) {
+			t.Errorf("%s: verifySession = %v, want %v", tt.name, err, tt.want)
+		}
+
+		cookie := &http.Cookie{Name: sessionCookieName, Value: tt.value}
+		rec := serveWithCookies(t, "GET", "/todo", "", nil, cookie)
+		if rec.Code != http.StatusSeeOther || !strings.HasPrefix(rec.Header().Get("Location"), "/login") {
+			t.Errorf("%s: GET /todo = %d to %q, want a redirect to the login page", tt.name, rec.Code, rec.Header().Get("Location"))
+		}
+		if cookies := rec.Result().Cookies(); len(cookies) != 1 || cookies[0].MaxAge >= 0 {
+			t.Errorf("%s: bad cookie was not cleared: %v", tt.name, cookies)
+		}
+	}
+
+	// A session is good until its expiry
+	expires := time.Now().Add(time.Hour)
+	value := signSession("admin", expires)
+	if name, err := verifySession(value, expires.Add(-time.Second)); err != nil || name != "admin" {
+		t.Errorf("verifySession before expiry = %q, %v", name, err)
+	}
+	if _, err := verifySession(value, expires.Add(time.Second)); !errors.Is(err, errSessionExpired) {
+		t.Errorf("verifySession after expiry = %v, want %v", err, errSessionExpired)
+	}
+}
+
+func TestLoginRedirect(t *testing.T) {
+	for next, want := range map[string]string{
+		"/users":               "/users",
+		"/todo?x=1":            "/todo?x=1",
+		"":                     "/todo",
+		"https://evil.example": "/todo",
+		"//evil.example":       "/todo",
+		"/\\evil.example":      "/todo",
+	} {
+		if got := loginRedirect(next); got != want {
+			t.Errorf("loginRedirect(%q) = %q, want %q", next, got, want)
+		}
+	}
+}
+
+func mustDecode(t *testing.T, value string) string {
+	t.Helper()
+	enc, _, _ := strings.Cut(value, ".")
+	payload, err := base64.RawURLEncoding.DecodeString(enc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(payload)
+}
+
+// findCookie is the cookie named name that rec sets, or nil
+func findCookie(rec *httptest.ResponseRecorder, name string) *http.Cookie {
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == name {
+			return cookie
+		}
+	}
+	return nil
+}
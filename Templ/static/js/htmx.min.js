@@ -0,0 +1,2 @@
+/* Stand-in for htmx 1.9.12, loading it from unpkg until `go generate ./static` replaces this file with the real htmx.min.js */
+document.write('<script src="https://unpkg.com/htmx.org@1.9.12/dist/htmx.min.js"><\/script>');
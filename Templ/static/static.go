@@ -0,0 +1,99 @@
+// Package static holds the demo's stylesheet, favicon and htmx, embedded
+// in the binary, and serves them with URLs that change whenever the files do.
+package static
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// Prefix is where the assets are served
+const Prefix = "/static/"
+
+//go:generate curl -fsSL -o js/htmx.min.js https://unpkg.com/htmx.org@1.9.12/dist/htmx.min.js
+
+//go:embed css favicon.svg js
+var files embed.FS
+
+// Default are the embedded assets, hashed when the program starts
+var Default = Must(New(files, Prefix))
+
+// Assets are the files of a file system, served under a prefix. Each
+// file's URL carries a hash of its content, so it can be cached for good:
+// when the file changes, so does the URL.
+type Assets struct {
+	fsys   fs.FS
+	prefix string
+	hashes map[string]string // file path to content hash
+}
+
+// New hashes every file in fsys, to be served under prefix
+func New(fsys fs.FS, prefix string) (*Assets, error) {
+	hashes := map[string]string{}
+	err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(data)
+		hashes[path] = hex.EncodeToString(sum[:6])
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Assets{fsys: fsys, prefix: prefix, hashes: hashes}, nil
+}
+
+// Must is New's assets, panicking on its error
+func Must(a *Assets, err error) *Assets {
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+// URL is where the file at path is served, with its hash in the query.
+// A path that is not one of the files gets no hash, and a 404 when asked
+// for.
+func (a *Assets) URL(path string) string {
+	path = strings.TrimPrefix(path, "/")
+	if hash, ok := a.hashes[path]; ok {
+		return a.prefix + path + "?v=" + hash
+	}
+	return a.prefix + path
+}
+
+// contentTypes are the Content-Types of extensions whose type would
+// otherwise depend on the system's MIME tables
+var contentTypes = map[string]string{
+	".js": "application/javascript",
+}
+
+// Handler serves the files under the prefix, with a Content-Type from
+// their extension, an ETag of their hash, and headers letting browsers
+// keep them for a year. Directories are not listed.
+func (a *Assets) Handler() http.Handler {
+	files := http.FileServerFS(a.fsys)
+	return http.StripPrefix(a.prefix, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hash, ok := a.hashes[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		w.Header().Set("ETag", `"`+hash+`"`)
+		if contentType, ok := contentTypes[path.Ext(r.URL.Path)]; ok {
+			w.Header().Set("Content-Type", contentType)
+		}
+		files.ServeHTTP(w, r)
+	}))
+}
@@ -0,0 +1,67 @@
+package static
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestURLHashFollowsContent(t *testing.T) {
+	fsys := fstest.MapFS{"css/app.css": {Data: []byte("body { color: red; }")}}
+	before := Must(New(fsys, Prefix)).URL("css/app.css")
+	if !strings.HasPrefix(before, "/static/css/app.css?v=") {
+		t.Fatalf("URL = %q", before)
+	}
+	if again := Must(New(fsys, Prefix)).URL("/css/app.css"); again != before {
+		t.Errorf("the same bytes hash to %q and %q", before, again)
+	}
+
+	fsys["css/app.css"] = &fstest.MapFile{Data: []byte("body { color: blue; }")}
+	if after := Must(New(fsys, Prefix)).URL("css/app.css"); after == before {
+		t.Errorf("URL stayed %q after the file changed", after)
+	}
+
+	// A file that is not there gets no hash
+	if got := Must(New(fsys, Prefix)).URL("missing.js"); got != "/static/missing.js" {
+		t.Errorf("URL of a missing file = %q", got)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	tests := []struct {
+		path        string
+		contentType string
+	}{
+		{"css/app.css", "text/css; charset=utf-8"},
+		{"favicon.svg", "image/svg+xml"},
+		{"js/htmx.min.js", "application/javascript"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		Default.Handler().ServeHTTP(rec, httptest.NewRequest("GET", Default.URL(tt.path), nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("GET %s = %d", tt.path, rec.Code)
+			continue
+		}
+		if got := rec.Header().Get("Content-Type"); got != tt.contentType {
+			t.Errorf("GET %s Content-Type = %q, want %q", tt.path, got, tt.contentType)
+		}
+		if got := rec.Header().Get("Cache-Control"); !strings.Contains(got, "max-age=31536000") {
+			t.Errorf("GET %s Cache-Control = %q", tt.path, got)
+		}
+		if want, _ := files.ReadFile(tt.path); rec.Body.String() != string(want) {
+			t.Errorf("GET %s did not serve the embedded file", tt.path)
+		}
+	}
+
+	// Only the files are served, not directory listings or anything else
+	for _, path := range []string{"/static/", "/static/css/", "/static/missing.js"} {
+		rec := httptest.NewRecorder()
+		Default.Handler().ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("GET %s = %d, want 404", path, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"slices"
+	"sync"
+)
+
+// store is an in-memory list of todos or users, safe for the handlers to
+// use at once. Everything goes through its methods, which take the lock,
+// and the items they return are copies the caller may keep.
+type store[T any] struct {
+	mu     sync.RWMutex
+	items  []T
+	nextID int
+	id     func(*T) *int // the item's ID field
+}
+
+func newStore[T any](id func(*T) *int) *store[T] {
+	return &store[T]{nextID: 1, id: id}
+}
+
+// All is every item, in the order they were added
+func (s *store[T]) All() []T {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Clone(s.items)
+}
+
+// Get is the item with id; ok is false when there is none
+func (s *store[T]) Get(id int) (item T, ok bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if i := s.index(id); i >= 0 {
+		return s.items[i], true
+	}
+	return item, false
+}
+
+// Len is how many items there are
+func (s *store[T]) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.items)
+}
+
+// Add gives item the next ID and stores it
+func (s *store[T]) Add(item T) T {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	*s.id(&item) = s.nextID
+	s.nextID++
+	s.items = append(s.items, item)
+	return item
+}
+
+// Update calls change on the item with id, under the lock, and returns it
+// as changed. ok is false when there is no such item.
+func (s *store[T]) Update(id int, change func(*T)) (item T, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.index(id)
+	if i < 0 {
+		return item, false
+	}
+	change(&s.items[i])
+	return s.items[i], true
+}
+
+// Delete removes the item with id and reports whether there was one
+func (s *store[T]) Delete(id int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.index(id)
+	if i < 0 {
+		return false
+	}
+	s.items = slices.Delete(s.items, i, i+1)
+	return true
+}
+
+// Reset replaces the items, which keep their IDs; new ones are numbered
+// after the highest
+func (s *store[T]) Reset(items []T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items = slices.Clone(items)
+	s.nextID = 1
+	for i := range s.items {
+		s.nextID = max(s.nextID, *s.id(&s.items[i])+1)
+	}
+}
+
+// index is where the item with id is, or -1; s.mu must be held
+func (s *store[T]) index(id int) int {
+	for i := range s.items {
+		if *s.id(&s.items[i]) == id {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+)
+
+// Run with -race: the handlers share the stores
+func TestConcurrentRequests(t *testing.T) {
+	seedData()
+	const creates = 50
+
+	var wg sync.WaitGroup
+	codes := make(chan string, 2*creates+20)
+	for i := range creates {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			form := url.Values{"text": {fmt.Sprintf("Concurrent %02d", i)}}
+			if rec := serve(t, "POST", "/todo", form.Encode(), htmxHeaders); rec.Code != http.StatusOK {
+				codes <- fmt.Sprintf("create todo %d = %d", i, rec.Code)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			form := url.Values{"name": {fmt.Sprintf("User %02d", i)}, "email": {fmt.Sprintf("user%d@example.com", i)}, "role": {"User"}}
+			if rec := serve(t, "POST", "/users", form.Encode(), fragmentHeaders); rec.Code != http.StatusOK {
+				codes <- fmt.Sprintf("create user %d = %d", i, rec.Code)
+			}
+		}()
+	}
+	// An even number of toggles leaves todo 2 open
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if rec := serve(t, "POST", "/todo/2/toggle", "", htmxHeaders); rec.Code != http.StatusOK {
+				codes <- fmt.Sprintf("toggle = %d", rec.Code)
+			}
+		}()
+	}
+	for _, target := range []string{"/todo/1", "/todo/3", "/users/2", "/users/4"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if rec := serve(t, "DELETE", target, "", htmxHeaders); rec.Code != http.StatusOK {
+				codes <- fmt.Sprintf("delete %s = %d", target, rec.Code)
+			}
+		}()
+	}
+	wg.Wait()
+	close(codes)
+	for msg := range codes {
+		t.Error(msg)
+	}
+
+	if n := todos.Len(); n != 4+creates-2 {
+		t.Errorf("%d todos, want %d", n, 4+creates-2)
+	}
+	if n := users.Len(); n != 4+creates-2 {
+		t.Errorf("%d users, want %d", n, 4+creates-2)
+	}
+	checkUniqueIDs(t, "todo", todos.All(), func(t Todo) int { return t.ID })
+	checkUniqueIDs(t, "user", users.All(), func(u User) int { return u.ID })
+	for _, todo := range todos.All() {
+		if todo.ID == 2 && todo.Completed {
+			t.Error("todo 2 was toggled an even number of times but is completed")
+		}
+	}
+}
+
+func TestStore(t *testing.T) {
+	s := newStore(func(u *User) *int { return &u.ID })
+	s.Reset([]User{{ID: 3, Name: "Ann"}, {ID: 7, Name: "Ben"}})
+
+	// New items are numbered after the highest
+	if added := s.Add(User{Name: "Cat"}); added.ID != 8 {
+		t.Errorf("Add gave ID %d, want 8", added.ID)
+	}
+
+	// What All returns is a copy
+	all := s.All()
+	all[0].Name = "Changed"
+	if s.All()[0].Name != "Ann" {
+		t.Error("changing All's result changed the store")
+	}
+
+	if got, ok := s.Update(7, func(u *User) { u.Name = "Bea" }); !ok || got.Name != "Bea" {
+		t.Errorf("Update = %+v, %v", got, ok)
+	}
+	if _, ok := s.Update(99, func(*User) {}); ok {
+		t.Error("Update of a missing ID succeeded")
+	}
+	if !s.Delete(3) || s.Delete(3) || s.Len() != 2 {
+		t.Errorf("after deleting ID 3 twice the store has %v", s.All())
+	}
+}
+
+func checkUniqueIDs[T any](t *testing.T, kind string, items []T, id func(T) int) {
+	t.Helper()
+	seen := map[int]bool{}
+	for _, item := range items {
+		if seen[id(item)] {
+			t.Errorf("%s ID %d is used twice", kind, id(item))
+		}
+		seen[id(item)] = true
+	}
+}
+
+// last is the item added most recently
+func last[T any](s *store[T]) T {
+	all := s.All()
+	return all[len(all)-1]
+}
@@ -0,0 +1,9 @@
+package templates
+
+import "templ-demo/static"
+
+// asset is the URL of a file in static/, which changes when the file does
+// so browsers fetch it again
+func asset(path string) string {
+	return static.Default.URL(path)
+}
@@ -0,0 +1,47 @@
+package templates
+
+import "strings"
+
+// FormErrors maps a form field's name to what is wrong with the value
+// entered for it. A nil FormErrors is a form with nothing to report.
+type FormErrors map[string]string
+
+// Join is the errors for fields, in that order, as one message
+func (e FormErrors) Join(fields ...string) string {
+	var msgs []string
+	for _, field := range fields {
+		if msg := e[field]; msg != "" {
+			msgs = append(msgs, msg)
+		}
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// ContactFormValues are the contact form's fields as they were entered
+type ContactFormValues struct {
+	Name    string
+	Email   string
+	Message string
+}
+
+// RoleOption is a role a user can be given and how the form labels it
+type RoleOption struct {
+	Value string
+	Label string
+}
+
+// UserRoles are the roles the add user form offers, in order
+var UserRoles = []RoleOption{
+	{Value: "User", Label: "User"},
+	{Value: "Editor", Label: "Editor"},
+	{Value: "Admin", Label: "Administrator"},
+}
+
+// inputClass adds Bootstrap's is-invalid to class when the field has an
+// error, which also shows the invalid-feedback after it
+func inputClass(class string, errs FormErrors, field string) string {
+	if errs[field] != "" {
+		return class + " is-invalid"
+	}
+	return class
+}
@@ -0,0 +1,117 @@
+package templates
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+)
+
+// Page sizes: what a list shows unless asked otherwise, the most it will
+// show, and the sizes the per-page selector offers
+const (
+	DefaultPerPage = 10
+	MaxPerPage     = 50
+)
+
+var PerPageOptions = []int{5, 10, 25, 50}
+
+// PageInfo is where a list is: which page it is on, how many items are on
+// a page and how many there are in all. Path and Query are the list's URL,
+// whose other parameters, such as a filter, the page links keep.
+type PageInfo struct {
+	Page    int
+	PerPage int
+	Total   int
+	Path    string
+	Query   url.Values
+}
+
+// Pages is how many pages there are; an empty list still has one
+func (p PageInfo) Pages() int {
+	if p.Total == 0 || p.PerPage <= 0 {
+		return 1
+	}
+	return (p.Total + p.PerPage - 1) / p.PerPage
+}
+
+// Offset is the index of the first item on the page
+func (p PageInfo) Offset() int {
+	return (p.Page - 1) * p.PerPage
+}
+
+// HasPrev and HasNext report whether there are pages either side
+func (p PageInfo) HasPrev() bool { return p.Page > 1 }
+func (p PageInfo) HasNext() bool { return p.Page < p.Pages() }
+
+// URL links to page of the list, keeping the page size and Query
+func (p PageInfo) URL(page int) string {
+	q := url.Values{}
+	for k, v := range p.Query {
+		q[k] = v
+	}
+	q.Set("page", strconv.Itoa(page))
+	if p.PerPage != DefaultPerPage {
+		q.Set("per_page", strconv.Itoa(p.PerPage))
+	}
+	return p.Path + "?" + q.Encode()
+}
+
+// Links are the page numbers to show: the first and last pages and two
+// either side of the current one. A 0 stands for the pages left out
+// between them, unless only one page is left out, which is shown instead.
+func (p PageInfo) Links() []int {
+	last := p.Pages()
+	var links []int
+	prev := 0
+	for n := 1; n <= last; n++ {
+		if n != 1 && n != last && (n < p.Page-2 || n > p.Page+2) {
+			continue
+		}
+		switch n - prev {
+		case 1:
+		case 2:
+			links = append(links, n-1)
+		default:
+			links = append(links, 0)
+		}
+		links = append(links, n)
+		prev = n
+	}
+	return links
+}
+
+// Summary says which items the page shows, like "Showing 11–20 of 45"
+func (p PageInfo) Summary() string {
+	if p.Total == 0 {
+		return "Nothing to show"
+	}
+	last := min(p.Offset()+p.PerPage, p.Total)
+	return fmt.Sprintf("Showing %d–%d of %d", p.Offset()+1, last, p.Total)
+}
+
+// queryParam is one of Query's values, which the per-page selector sends
+// again as a hidden input
+type queryParam struct {
+	Name  string
+	Value string
+}
+
+// keptParams are Query's values in name order, leaving out the page and
+// page size the selector replaces
+func (p PageInfo) keptParams() []queryParam {
+	names := make([]string, 0, len(p.Query))
+	for name := range p.Query {
+		if name != "page" && name != "per_page" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	var params []queryParam
+	for _, name := range names {
+		for _, value := range p.Query[name] {
+			params = append(params, queryParam{name, value})
+		}
+	}
+	return params
+}
@@ -0,0 +1,105 @@
+package templates
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestPageInfoLinks(t *testing.T) {
+	tests := []struct {
+		page, total int
+		want        []int
+	}{
+		{1, 0, []int{1}},
+		{1, 10, []int{1}},
+		{2, 11, []int{1, 2}},
+		{10, 200, []int{1, 0, 8, 9, 10, 11, 12, 0, 20}},
+		{1, 200, []int{1, 2, 3, 0, 20}},
+		{20, 200, []int{1, 0, 18, 19, 20}},
+		// A gap of one page shows the page rather than an ellipsis
+		{5, 200, []int{1, 2, 3, 4, 5, 6, 7, 0, 20}},
+	}
+	for _, tt := range tests {
+		info := PageInfo{Page: tt.page, PerPage: 10, Total: tt.total}
+		if got := info.Links(); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("page %d of %d items: Links() = %v, want %v", tt.page, tt.total, got, tt.want)
+		}
+	}
+}
+
+func TestPageInfoURL(t *testing.T) {
+	info := PageInfo{Page: 2, PerPage: 25, Total: 100, Path: "/todo", Query: url.Values{"q": {"a b"}, "status": {"open"}}}
+	if got, want := info.URL(3), "/todo?page=3&per_page=25&q=a+b&status=open"; got != want {
+		t.Errorf("URL(3) = %q, want %q", got, want)
+	}
+	// The default page size is left out
+	info.PerPage = DefaultPerPage
+	if got, want := info.URL(1), "/todo?page=1&q=a+b&status=open"; got != want {
+		t.Errorf("URL(1) = %q, want %q", got, want)
+	}
+	if info.Query.Get("page") != "" {
+		t.Error("URL changed Query")
+	}
+}
+
+func TestPaginationOnePage(t *testing.T) {
+	html := render(t, Pagination(PageInfo{Page: 1, PerPage: 10, Total: 4, Path: "/users"}))
+	if !strings.Contains(html, "Showing 1–4 of 4") {
+		t.Errorf("summary missing: %s", html)
+	}
+	if strings.Contains(html, `class="pagination`) {
+		t.Error("a single page has page links")
+	}
+	if !strings.Contains(html, `<option value="10" selected>10</option>`) {
+		t.Error("per-page selector does not show 10")
+	}
+}
+
+func TestPaginationTwoPages(t *testing.T) {
+	html := render(t, Pagination(PageInfo{Page: 2, PerPage: 5, Total: 7, Path: "/users"}))
+	for _, want := range []string{
+		"Showing 6–7 of 7",
+		`<a class="page-link" href="/users?page=1&amp;per_page=5">Previous</a>`,
+		`<a class="page-link" href="/users?page=1&amp;per_page=5">1</a>`,
+		`<li class="page-item active" aria-current="page"><span class="page-link">2</span></li>`,
+		`<li class="page-item disabled"><span class="page-link">Next</span></li>`,
+		`<option value="5" selected>5</option>`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("pagination does not contain %q", want)
+		}
+	}
+	if strings.Contains(html, "…") {
+		t.Error("two pages have an ellipsis")
+	}
+}
+
+func TestPaginationManyPages(t *testing.T) {
+	info := PageInfo{Page: 10, PerPage: 10, Total: 200, Path: "/todo", Query: url.Values{"q": {"templ"}, "sort": {"text"}}}
+	html := render(t, Pagination(info))
+	for _, want := range []string{
+		"Showing 91–100 of 200",
+		`href="/todo?page=9&amp;q=templ&amp;sort=text">Previous</a>`,
+		`href="/todo?page=11&amp;q=templ&amp;sort=text">Next</a>`,
+		`href="/todo?page=1&amp;q=templ&amp;sort=text">1</a>`,
+		`href="/todo?page=20&amp;q=templ&amp;sort=text">20</a>`,
+		`<span class="page-link">10</span>`,
+		// The per-page form sends the filter again
+		`<form class="d-flex align-items-center gap-2" action="/todo" method="get">`,
+		`<input type="hidden" name="q" value="templ"> <input type="hidden" name="sort" value="text">`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("pagination does not contain %q", want)
+		}
+	}
+	if n := strings.Count(html, "…"); n != 2 {
+		t.Errorf("pagination has %d ellipses, want 2", n)
+	}
+	for _, page := range []string{"7", "13"} {
+		if strings.Contains(html, ">"+page+"</a>") {
+			t.Errorf("page %s is linked, want it behind an ellipsis", page)
+		}
+	}
+}
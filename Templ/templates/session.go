@@ -0,0 +1,66 @@
+package templates
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// CSRFFieldName and CSRFHeaderName are where a request carries its CSRF
+// token: a form field, or a header for scripts
+const (
+	CSRFFieldName  = "_csrf"
+	CSRFHeaderName = "X-CSRF-Token"
+)
+
+type usernameKey struct{}
+
+// WithUsername returns ctx carrying the logged in user's name, which the
+// navigation bar shows
+func WithUsername(ctx context.Context, username string) context.Context {
+	return context.WithValue(ctx, usernameKey{}, username)
+}
+
+// Username is the logged in user's name from ctx, or "" when nobody is
+// logged in
+func Username(ctx context.Context) string {
+	username, _ := ctx.Value(usernameKey{}).(string)
+	return username
+}
+
+type csrfTokenKey struct{}
+
+// WithCSRFToken returns ctx carrying the token that forms and page
+// scripts send back with their posts
+func WithCSRFToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, csrfTokenKey{}, token)
+}
+
+// CSRFToken is the token from ctx
+func CSRFToken(ctx context.Context) string {
+	token, _ := ctx.Value(csrfTokenKey{}).(string)
+	return token
+}
+
+// CSRFHeaders is an hx-headers value that sends the token from ctx with
+// every htmx request made inside the element carrying it
+func CSRFHeaders(ctx context.Context) string {
+	headers, _ := json.Marshal(map[string]string{CSRFHeaderName: CSRFToken(ctx)})
+	return string(headers)
+}
+
+type clockKey struct{}
+
+// WithClock returns ctx carrying the clock that decides which todos are
+// overdue, so tests can fix the time
+func WithClock(ctx context.Context, now func() time.Time) context.Context {
+	return context.WithValue(ctx, clockKey{}, now)
+}
+
+// Now is the time from ctx's clock, or time.Now without one
+func Now(ctx context.Context) time.Time {
+	if now, ok := ctx.Value(clockKey{}).(func() time.Time); ok {
+		return now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,111 @@
+package templates
+
+import (
+	"time"
+
+	"github.com/a-h/templ"
+)
+
+// ComponentSection is one component on the showcase page: its anchor,
+// which the page's contents link to, and the examples of it
+type ComponentSection struct {
+	ID          string
+	Title       string
+	Description string
+	Examples    []ComponentExample
+}
+
+// ComponentExample is a component in one state. Source is the call that
+// renders it, shown beside it on the page.
+type ComponentExample struct {
+	Title     string
+	Source    string
+	Component templ.Component
+}
+
+// Showcase is everything the components page shows, in order. Adding an
+// example of a component is one more entry here.
+var Showcase = []ComponentSection{
+	{
+		ID:          "buttons",
+		Title:       "Buttons",
+		Description: "Button takes its text, a Bootstrap variant and an optional onclick script.",
+		Examples: []ComponentExample{
+			{"Primary", `@Button("Save", "primary", "")`, Button("Save", "primary", "")},
+			{"Outline", `@Button("Cancel", "outline-secondary", "")`, Button("Cancel", "outline-secondary", "")},
+			{"With a script", `@Button("Say hello", "success", "alert('Hello from Templ!')")`, Button("Say hello", "success", "alert('Hello from Templ!')")},
+		},
+	},
+	{
+		ID:          "badges",
+		Title:       "Badges",
+		Description: "RoleBadge colours a user's role and PriorityBadge a todo's priority.",
+		Examples: []ComponentExample{
+			{"Admin role", `@RoleBadge("Admin")`, RoleBadge("Admin")},
+			{"Editor role", `@RoleBadge("Editor")`, RoleBadge("Editor")},
+			{"User role", `@RoleBadge("User")`, RoleBadge("User")},
+			{"High priority", `@PriorityBadge("high")`, PriorityBadge("high")},
+			{"Low priority", `@PriorityBadge("low")`, PriorityBadge("low")},
+		},
+	},
+	{
+		ID:          "form-fields",
+		Title:       "Form fields",
+		Description: "FormField is a labelled input, or a textarea, that can be marked required.",
+		Examples: []ComponentExample{
+			{"Required text", `@FormField("name", "text", "Name", "Enter your name", true)`, FormField("name", "text", "Name", "Enter your name", true)},
+			{"Optional email", `@FormField("email", "email", "Email", "your@email.com", false)`, FormField("email", "email", "Email", "your@email.com", false)},
+			{"Textarea", `@FormField("message", "textarea", "Message", "Your message...", false)`, FormField("message", "textarea", "Message", "Your message...", false)},
+		},
+	},
+	{
+		ID:          "contact-form",
+		Title:       "Contact form",
+		Description: "ContactForm shows what was entered with an error under each field that is wrong; the other components take its place once it is posted.",
+		Examples: []ComponentExample{
+			{"Blank", `@ContactForm(ContactFormValues{}, nil)`, ContactForm(ContactFormValues{}, nil)},
+			{"With errors", `@ContactForm(ContactFormValues{Name: "Ada", Email: "ada@"}, FormErrors{"email": "Email must be a valid address", "message": "Message is required"})`, ContactForm(ContactFormValues{Name: "Ada", Email: "ada@"}, FormErrors{"email": "Email must be a valid address", "message": "Message is required"})},
+			{"Sent", `@ContactSent()`, ContactSent()},
+			{"Rate limited", `@ContactRateLimited(25 * time.Minute)`, ContactRateLimited(25 * time.Minute)},
+		},
+	},
+	{
+		ID:          "pagination",
+		Title:       "Pagination",
+		Description: "Pagination links the pages of a list and offers page sizes, keeping the list's other query parameters.",
+		Examples: []ComponentExample{
+			{"One page", `@Pagination(PageInfo{Page: 1, PerPage: 10, Total: 4, Path: "/todo"})`, Pagination(PageInfo{Page: 1, PerPage: 10, Total: 4, Path: "/todo"})},
+			{"First of several", `@Pagination(PageInfo{Page: 1, PerPage: 10, Total: 45, Path: "/todo"})`, Pagination(PageInfo{Page: 1, PerPage: 10, Total: 45, Path: "/todo"})},
+			{"Middle of many", `@Pagination(PageInfo{Page: 10, PerPage: 5, Total: 100, Path: "/users"})`, Pagination(PageInfo{Page: 10, PerPage: 5, Total: 100, Path: "/users"})},
+		},
+	},
+	{
+		ID:          "flash-messages",
+		Title:       "Flash messages",
+		Description: "Alert is a dismissible message in any Bootstrap variant.",
+		Examples: []ComponentExample{
+			{"Success", `@Alert("success", "Saved!", "Your changes were saved.")`, Alert("success", "Saved!", "Your changes were saved.")},
+			{"Warning", `@Alert("warning", "Heads up!", "Your session ends soon.")`, Alert("warning", "Heads up!", "Your session ends soon.")},
+			{"Error", `@Alert("danger", "Error!", "Something went wrong.")`, Alert("danger", "Error!", "Something went wrong.")},
+		},
+	},
+	{
+		ID:          "todo-item",
+		Title:       "Todo item",
+		Description: "TodoItem shows a todo with its priority and due date, highlighting it once it is overdue.",
+		Examples: []ComponentExample{
+			{"Open", `@TodoItem(Todo{ID: 101, Text: "Learn Templ", Priority: "high"})`, TodoItem(Todo{ID: 101, Text: "Learn Templ", Priority: "high"})},
+			{"Completed", `@TodoItem(Todo{ID: 102, Text: "Install Go", Completed: true, Priority: "low"})`, TodoItem(Todo{ID: 102, Text: "Install Go", Completed: true, Priority: "low"})},
+			{"Overdue", `@TodoItem(Todo{ID: 103, Text: "File taxes", Priority: "medium", DueDate: time.Date(2020, 4, 15, 0, 0, 0, 0, time.Local)})`, TodoItem(Todo{ID: 103, Text: "File taxes", Priority: "medium", DueDate: time.Date(2020, 4, 15, 0, 0, 0, 0, time.Local)})},
+		},
+	},
+	{
+		ID:          "user-row",
+		Title:       "User row",
+		Description: "EnhancedUserRow is a row of the users table, with an avatar, role badge and actions.",
+		Examples: []ComponentExample{
+			{"Admin", `@EnhancedUserRow(User{ID: 101, Name: "Ada Lovelace", Email: "ada@example.com", Role: "Admin"})`, showcaseTable(EnhancedUserRow(User{ID: 101, Name: "Ada Lovelace", Email: "ada@example.com", Role: "Admin"}))},
+			{"User", `@EnhancedUserRow(User{ID: 102, Name: "Émile Zola", Email: "emile@example.com", Role: "User"})`, showcaseTable(EnhancedUserRow(User{ID: 102, Name: "Émile Zola", Email: "emile@example.com", Role: "User"}))},
+		},
+	},
+}
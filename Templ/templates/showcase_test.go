@@ -0,0 +1,47 @@
+package templates
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/a-h/templ"
+)
+
+func TestShowcaseExamplesRender(t *testing.T) {
+	ids := map[string]bool{}
+	for _, section := range Showcase {
+		if section.ID == "" || ids[section.ID] {
+			t.Errorf("section %q has a missing or repeated ID", section.Title)
+		}
+		ids[section.ID] = true
+		if len(section.Examples) == 0 {
+			t.Errorf("section %q has no examples", section.ID)
+		}
+		for _, example := range section.Examples {
+			if html := render(t, example.Component); strings.TrimSpace(html) == "" {
+				t.Errorf("%s / %s rendered nothing", section.ID, example.Title)
+			}
+		}
+	}
+}
+
+func TestComponentsPage(t *testing.T) {
+	html := render(t, ComponentsPage(Showcase))
+
+	for _, section := range Showcase {
+		for _, want := range []string{
+			`<section id="` + section.ID + `"`,
+			`href="#` + section.ID + `"`,
+		} {
+			if !strings.Contains(html, want) {
+				t.Errorf("components page is missing %s", want)
+			}
+		}
+		// Each example's source is shown, escaped, beside it
+		for _, example := range section.Examples {
+			if source := templ.EscapeString(example.Source); !strings.Contains(html, source) {
+				t.Errorf("components page is missing the source %s", source)
+			}
+		}
+	}
+}
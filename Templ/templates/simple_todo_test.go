@@ -0,0 +1,90 @@
+package templates
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fixedClock is a clock stopped at 2025-06-15 09:30 in the server's zone
+func fixedClock() time.Time {
+	return time.Date(2025, time.June, 15, 9, 30, 0, 0, time.Local)
+}
+
+func dueOn(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.Local)
+}
+
+func renderAt(t *testing.T, todo Todo) string {
+	t.Helper()
+	var b strings.Builder
+	ctx := WithClock(context.Background(), fixedClock)
+	if err := TodoItem(todo).Render(ctx, &b); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	return b.String()
+}
+
+func TestTodoItemOverdue(t *testing.T) {
+	html := renderAt(t, Todo{ID: 3, Text: "File taxes", Priority: "high", DueDate: dueOn(2025, time.June, 14)})
+	for _, want := range []string{
+		`class="card mb-2 todo-item border-danger todo-overdue"`,
+		`<span class="badge bg-danger todo-due">Overdue: Jun 14</span>`,
+		`<span class="badge todo-priority bg-danger">High</span>`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("overdue item does not contain %q: %s", want, html)
+		}
+	}
+
+	// Done todos are never overdue
+	html = renderAt(t, Todo{ID: 3, Text: "File taxes", Completed: true, Priority: "high", DueDate: dueOn(2025, time.June, 14)})
+	if strings.Contains(html, "Overdue") || !strings.Contains(html, "border-success") {
+		t.Errorf("completed item is highlighted as overdue: %s", html)
+	}
+}
+
+func TestTodoItemNotYetDue(t *testing.T) {
+	tests := []struct {
+		name string
+		due  time.Time
+		want string
+	}{
+		// Due today is not overdue until tomorrow
+		{"today", dueOn(2025, time.June, 15), "Due Jun 15"},
+		{"future", dueOn(2025, time.July, 1), "Due Jul 1"},
+		{"next year", dueOn(2026, time.January, 3), "Due Jan 3, 2026"},
+	}
+	for _, tt := range tests {
+		html := renderAt(t, Todo{ID: 4, Text: "Plan trip", Priority: "low", DueDate: tt.due})
+		if !strings.Contains(html, `todo-due">`+tt.want+`</span>`) || strings.Contains(html, "Overdue") || !strings.Contains(html, "border-primary") {
+			t.Errorf("%s: item = %s", tt.name, html)
+		}
+		if !strings.Contains(html, `<span class="badge todo-priority bg-secondary">Low</span>`) {
+			t.Errorf("%s: no low priority badge", tt.name)
+		}
+	}
+
+	// Without a due date there is no due badge
+	if html := renderAt(t, Todo{ID: 5, Text: "Someday", Priority: "medium"}); strings.Contains(html, "todo-due") {
+		t.Errorf("item without a due date has a due badge: %s", html)
+	}
+}
+
+func TestTodoFormKeepsScheduleValues(t *testing.T) {
+	html := render(t, TodoForm(TodoFormValues{Text: "Pay rent", DueDate: "2025-07-01", Priority: "high"}, FormErrors{"due_date": "Due date must be no more than a year ago"}))
+	for _, want := range []string{
+		`type="date"`, `name="due_date" value="2025-07-01"`,
+		`<option value="high" selected>High</option>`,
+		"Due date must be no more than a year ago",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("form does not contain %q", want)
+		}
+	}
+	// A blank form starts at medium priority
+	if html := render(t, TodoForm(TodoFormValues{}, nil)); !strings.Contains(html, `<option value="medium" selected>Medium</option>`) {
+		t.Error("blank form does not select medium priority")
+	}
+}
@@ -0,0 +1,44 @@
+package templates
+
+import "net/url"
+
+// TodoFilter is what the todo page's filter bar has selected, and what
+// the list shows
+type TodoFilter struct {
+	// Query is text the todo must contain, ignoring case
+	Query string
+	// Status is "open", "done", or "" for both
+	Status string
+	// Sort is "created", "text" or "due", in Order "asc" or "desc"
+	Sort  string
+	Order string
+}
+
+// Active reports whether the filter hides any todos
+func (f TodoFilter) Active() bool {
+	return f.Query != "" || f.Status != ""
+}
+
+// option is a choice in a select
+type option struct {
+	Value string
+	Label string
+}
+
+var (
+	todoStatusOptions = []option{{"", "All"}, {"open", "Open"}, {"done", "Done"}}
+	todoSortOptions   = []option{{"created", "Created"}, {"text", "Text"}, {"due", "Due date"}}
+	todoOrderOptions  = []option{{"asc", "Ascending"}, {"desc", "Descending"}}
+)
+
+// exportURL downloads the todos query's filter shows in format, csv or ics
+func exportURL(query url.Values, format string) string {
+	q := url.Values{}
+	for k, v := range query {
+		if k != "page" && k != "per_page" {
+			q[k] = v
+		}
+	}
+	q.Set("format", format)
+	return "/todo/export?" + q.Encode()
+}
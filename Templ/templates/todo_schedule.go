@@ -0,0 +1,67 @@
+package templates
+
+import "time"
+
+// DateLayout is how due dates are written in forms, JSON and the date
+// picker
+const DateLayout = "2006-01-02"
+
+// DefaultPriority is the priority of a todo that was not given one
+const DefaultPriority = "medium"
+
+// TodoPriorities are the priorities a todo can have, in the order the form
+// offers them
+var TodoPriorities = []option{
+	{Value: "low", Label: "Low"},
+	{Value: "medium", Label: "Medium"},
+	{Value: "high", Label: "High"},
+}
+
+// TodoFormValues are the add todo form's fields as they were entered
+type TodoFormValues struct {
+	Text     string
+	DueDate  string
+	Priority string
+}
+
+// Overdue reports whether the todo is still open after its due day has
+// passed, going by the calendar day of now
+func (t Todo) Overdue(now time.Time) bool {
+	return !t.Completed && !t.DueDate.IsZero() && day(t.DueDate).Before(day(now))
+}
+
+// day is the calendar day of t, comparable with other days whatever their
+// zones
+func day(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func priorityBadgeClass(priority string) string {
+	switch priority {
+	case "high":
+		return "bg-danger"
+	case "low":
+		return "bg-secondary"
+	default:
+		return "bg-warning text-dark"
+	}
+}
+
+func priorityLabel(priority string) string {
+	for _, p := range TodoPriorities {
+		if p.Value == priority {
+			return p.Label
+		}
+	}
+	return priority
+}
+
+// dueLabel is the due date as the todo's badge shows it, with the year
+// only when it is not this year
+func dueLabel(due, now time.Time) string {
+	if due.Year() == now.Year() {
+		return due.Format("Jan 2")
+	}
+	return due.Format("Jan 2, 2006")
+}
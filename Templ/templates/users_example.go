@@ -0,0 +1,26 @@
+package templates
+
+// usersExample is shown in the code block; it is a string so the templ
+// syntax in it is not parsed
+const usersExample = `// Users page template
+templ UsersPage(users []User) {
+  @Base("Users") {
+    <div id="users-section">
+      @UsersSection(users)
+    </div>
+    @AddUserModal()
+  }
+}
+
+// Individual user row with actions
+templ EnhancedUserRow(user User) {
+  <tr id={ fmt.Sprintf("user-%d", user.ID) }>
+    <td>{ user.Name }</td>
+    <td>{ user.Email }</td>
+    <td>@RoleBadge(user.Role)</td>
+    <td>@UserActions(user.ID)</td>
+  </tr>
+}
+
+// The handlers render just the changed part
+templates.EnhancedUserRow(user).Render(r.Context(), w)`
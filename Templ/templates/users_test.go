@@ -0,0 +1,95 @@
+package templates
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/a-h/templ"
+)
+
+var fixtureUsers = []User{
+	{ID: 1, Name: "Alice Johnson", Email: "alice@example.com", Role: "Admin"},
+	{ID: 2, Name: "Bob Smith", Email: "bob@example.com", Role: "User"},
+	{ID: 7, Name: "Émile <Zola>", Email: "emile@example.com", Role: "Editor"},
+}
+
+var fixturePage = PageInfo{Page: 1, PerPage: DefaultPerPage, Total: len(fixtureUsers), Path: "/users"}
+
+func render(t *testing.T, c templ.Component) string {
+	t.Helper()
+	var b strings.Builder
+	if err := c.Render(context.Background(), &b); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	return b.String()
+}
+
+func TestUsersPage(t *testing.T) {
+	html := render(t, UsersPage(fixtureUsers, fixtureUsers, fixturePage))
+
+	for _, want := range []string{
+		// Layout and navigation from Base
+		"<title>Users - Templ Demo</title>",
+		`<a class="nav-link" href="/users">Users</a>`,
+		// A row per user, with role badges and actions
+		`id="user-1"`, "Alice Johnson", "mailto:alice@example.com",
+		`id="user-2"`, "Bob Smith",
+		`id="user-7"`, "Émile &lt;Zola&gt;",
+		"badge fs-6 bg-danger", "badge fs-6 bg-primary", "badge fs-6 bg-warning text-dark",
+		`hx-get="/users/7/edit"`, "deleteUser(7)",
+		"All Users (3)",
+		// The add form posts to the create handler
+		`<form id="addUserForm" action="/users" method="post">`,
+		`name="name"`, `name="email"`, `name="role"`,
+		// The example is shown as text
+		"templ UsersPage(users []User)",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("page does not contain %q", want)
+		}
+	}
+	if strings.Contains(html, "<Zola>") {
+		t.Error("user name is not escaped")
+	}
+	if !strings.Contains(html, `<div id="users-section">`) || !strings.Contains(html, "async function deleteUser(id)") {
+		t.Error("page is missing the section the script replaces, or the script")
+	}
+}
+
+func TestUsersSection(t *testing.T) {
+	html := render(t, UsersSection(fixtureUsers, fixtureUsers, fixturePage))
+	if strings.Contains(html, "<html") || strings.Contains(html, "addUserModal") {
+		t.Error("section fragment includes the page around it")
+	}
+	// Statistics: 3 users, 1 admin, 1 editor, 1 regular user
+	for _, want := range []string{`<h4 class="mt-2">3</h4>`, `<h4 class="mt-2">1</h4>`, "Bob Smith"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("section does not contain %q", want)
+		}
+	}
+
+	empty := render(t, UsersSection(nil, nil, PageInfo{Page: 1, PerPage: DefaultPerPage, Path: "/users"}))
+	if !strings.Contains(empty, "No users found") || strings.Contains(empty, "<table") {
+		t.Errorf("empty section = %s", empty)
+	}
+}
+
+func TestEnhancedUserRow(t *testing.T) {
+	html := render(t, EnhancedUserRow(fixtureUsers[2]))
+	if !strings.HasPrefix(html, `<tr class="user-row" id="user-7"`) || !strings.HasSuffix(html, "</tr>") {
+		t.Errorf("row fragment = %s", html)
+	}
+	// The avatar shows the whole first letter, not its first byte
+	if !strings.Contains(html, ">É</div>") {
+		t.Error("avatar initial is not É")
+	}
+}
+
+func TestUserInitial(t *testing.T) {
+	for name, want := range map[string]string{"Alice": "A", "Émile": "É", "": "?"} {
+		if got := userInitial(name); got != want {
+			t.Errorf("userInitial(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestEditUserInPlace(t *testing.T) {
+	seedData()
+
+	// Edit loads the modal, filled in with the user and their version
+	rec := serve(t, "GET", "/users/2/edit", "", htmxHeaders)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("edit = %d", rec.Code)
+	}
+	modal := rec.Body.String()
+	for _, want := range []string{
+		`id="editUserModal"`, `hx-put="/users/2"`, `hx-target="#user-2"`,
+		`name="version" value="1"`, `value="Bob Smith"`, `value="bob@example.com"`,
+		`<option value="User" selected>`,
+	} {
+		if !strings.Contains(modal, want) {
+			t.Errorf("edit modal is missing %s", want)
+		}
+	}
+
+	// Saving returns the updated row and closes the modal
+	form := url.Values{"name": {"Robert Smith"}, "email": {"rob@example.com"}, "role": {"Editor"}, "version": {"1"}}
+	rec = serve(t, "PUT", "/users/2", form.Encode(), htmxHeaders)
+	if rec.Code != http.StatusOK || rec.Header().Get("HX-Trigger") != "userUpdated" || rec.Header().Get("HX-Retarget") != "" {
+		t.Fatalf("save = %d with HX-Trigger %q and HX-Retarget %q", rec.Code, rec.Header().Get("HX-Trigger"), rec.Header().Get("HX-Retarget"))
+	}
+	if row := rec.Body.String(); !strings.HasPrefix(row, `<tr class="user-row" id="user-2"`) || !strings.Contains(row, "Robert Smith") || !strings.Contains(row, "rob@example.com") {
+		t.Errorf("save fragment = %s", row)
+	}
+	if user, _ := users.Get(2); user.Name != "Robert Smith" || user.Role != "Editor" || user.Version != 2 {
+		t.Errorf("stored user = %+v, want it renamed at version 2", user)
+	}
+
+	// Errors come back in the form, over the modal's form
+	form.Set("email", "not an email")
+	form.Set("version", "2")
+	rec = serve(t, "PUT", "/users/2", form.Encode(), htmxHeaders)
+	if rec.Code != http.StatusUnprocessableEntity || rec.Header().Get("HX-Retarget") != "#editUserForm" {
+		t.Errorf("invalid save = %d with HX-Retarget %q", rec.Code, rec.Header().Get("HX-Retarget"))
+	}
+	if body := rec.Body.String(); !strings.Contains(body, `id="editUserForm"`) || !strings.Contains(body, "Email must be a valid address") {
+		t.Errorf("invalid save fragment = %s", body)
+	}
+	if user, _ := users.Get(2); user.Email != "rob@example.com" || user.Version != 2 {
+		t.Errorf("invalid save changed the user: %+v", user)
+	}
+}
+
+func TestEditUserConflict(t *testing.T) {
+	seedData()
+
+	// Two people open the edit form for Carol at version 1
+	for range 2 {
+		if rec := serve(t, "GET", "/users/3/edit", "", htmxHeaders); !strings.Contains(rec.Body.String(), `name="version" value="1"`) {
+			t.Fatal("edit form is not at version 1")
+		}
+	}
+
+	// The first save goes through
+	first := url.Values{"name": {"Carol Danvers"}, "email": {"carol@example.com"}, "role": {"Admin"}, "version": {"1"}}
+	if rec := serve(t, "PUT", "/users/3", first.Encode(), htmxHeaders); rec.Code != http.StatusOK {
+		t.Fatalf("first save = %d", rec.Code)
+	}
+
+	// The second, made to version 1, is refused with the form showing the
+	// user as the first left them
+	second := url.Values{"name": {"Carol D."}, "email": {"cd@example.com"}, "role": {"User"}, "version": {"1"}}
+	rec := serve(t, "PUT", "/users/3", second.Encode(), htmxHeaders)
+	if rec.Code != http.StatusConflict || rec.Header().Get("HX-Retarget") != "#editUserForm" {
+		t.Fatalf("second save = %d with HX-Retarget %q", rec.Code, rec.Header().Get("HX-Retarget"))
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		"This user was changed while you were editing", `id="editUserForm"`,
+		`value="Carol Danvers"`, `<option value="Admin" selected>`, `name="version" value="2"`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("conflict fragment is missing %s", want)
+		}
+	}
+	if user, _ := users.Get(3); user.Name != "Carol Danvers" || user.Version != 2 {
+		t.Errorf("conflicting save changed the user: %+v", user)
+	}
+
+	// Saving again from the refreshed form works
+	second.Set("version", "2")
+	if rec := serve(t, "PUT", "/users/3", second.Encode(), htmxHeaders); rec.Code != http.StatusOK {
+		t.Errorf("save after the conflict = %d", rec.Code)
+	}
+
+	// JSON clients that send a version get the same check
+	rec = serve(t, "PUT", "/users/3", `{"name": "Stale", "version": 1}`, jsonHeaders)
+	if rec.Code != http.StatusConflict {
+		t.Errorf("stale JSON update = %d, want 409", rec.Code)
+	}
+	rec = serve(t, "PUT", "/api/v1/users/3", `{"name": "Stale", "version": 2}`, apiHeaders)
+	if got := decode[struct {
+		Error string `json:"error"`
+		User  User   `json:"user"`
+	}](t, rec); rec.Code != http.StatusConflict || got.User.Version != 3 || got.User.Name != "Carol D." {
+		t.Errorf("stale API update = %d %+v", rec.Code, got)
+	}
+}
+
+func TestEditUserErrors(t *testing.T) {
+	seedData()
+	tests := []struct {
+		method, target, body string
+		want                 int
+	}{
+		{"GET", "/users/99/edit", "", http.StatusNotFound},
+		{"GET", "/users/abc/edit", "", http.StatusBadRequest},
+		{"PUT", "/users/99", "name=Nobody&email=n%40example.com&role=User&version=1", http.StatusNotFound},
+		{"PUT", "/users/1", "name=Alice&email=a%40example.com&role=Admin&version=x", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if rec := serve(t, tt.method, tt.target, tt.body, htmxHeaders); rec.Code != tt.want {
+			t.Errorf("%s %s = %d, want %d", tt.method, tt.target, rec.Code, tt.want)
+		}
+	}
+}
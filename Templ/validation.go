@@ -0,0 +1,260 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"templ-demo/templates"
+)
+
+// The most characters a todo's text, and a contact form's name and
+// message, may have
+const (
+	maxTodoLength           = 200
+	maxContactNameLength    = 100
+	maxContactMessageLength = 2000
+)
+
+// parseTodoForm checks the add todo form's values, which have already had
+// their surrounding space trimmed, and returns the todo they describe. A
+// new todo's due date may be up to a year before the day of now, for
+// entering things that are already late, but no earlier.
+func parseTodoForm(values templates.TodoFormValues, now time.Time) (Todo, templates.FormErrors) {
+	errs := templates.FormErrors{}
+	todo := Todo{Text: values.Text, Priority: values.Priority}
+	if msg := todoTextError(values.Text); msg != "" {
+		errs["text"] = msg
+	}
+	if todo.Priority == "" {
+		todo.Priority = templates.DefaultPriority
+	} else if msg := todoPriorityError(todo.Priority); msg != "" {
+		errs["priority"] = msg
+	}
+	due, msg := parseDueDate(values.DueDate)
+	if msg == "" && !due.IsZero() && due.Before(earliestDueDate(now)) {
+		msg = "Due date must be no more than a year ago"
+	}
+	if msg != "" {
+		errs["due_date"] = msg
+	}
+	todo.DueDate = due
+	return todo, nonEmpty(errs)
+}
+
+func todoTextError(text string) string {
+	switch n := utf8.RuneCountInString(text); {
+	case n == 0:
+		return "Text is required"
+	case n > maxTodoLength:
+		return fmt.Sprintf("Text must be at most %d characters", maxTodoLength)
+	}
+	return ""
+}
+
+func todoPriorityError(priority string) string {
+	for _, p := range templates.TodoPriorities {
+		if p.Value == priority {
+			return ""
+		}
+	}
+	return "Priority must be low, medium or high"
+}
+
+// parseDueDate reads a due date in the date picker's layout as midnight in
+// the server's zone. An empty one is no due date.
+func parseDueDate(s string) (time.Time, string) {
+	if s == "" {
+		return time.Time{}, ""
+	}
+	due, err := time.ParseInLocation(templates.DateLayout, s, time.Local)
+	if err != nil {
+		return time.Time{}, "Due date must be a date like 2025-12-31"
+	}
+	return due, ""
+}
+
+// earliestDueDate is the first day a new todo may be due: a year before
+// the day of now
+func earliestDueDate(now time.Time) time.Time {
+	y, m, d := now.In(time.Local).Date()
+	return time.Date(y-1, m, d, 0, 0, 0, 0, time.Local)
+}
+
+// todoFields and userFields are the fields the forms and API check, in
+// the order their errors are listed
+var (
+	todoFields = []string{"text", "due_date", "priority"}
+	userFields = []string{"name", "email", "role"}
+)
+
+// todoUpdate is a change to a todo sent as JSON. Fields left out keep
+// their current values; an empty due_date clears it.
+type todoUpdate struct {
+	Text     *string `json:"text"`
+	DueDate  *string `json:"due_date"`
+	Priority *string `json:"priority"`
+}
+
+// check validates the fields that were sent and returns the change they
+// make to a todo
+func (u todoUpdate) check() (func(*Todo), templates.FormErrors) {
+	errs := templates.FormErrors{}
+	var text string
+	var due time.Time
+	if u.Text != nil {
+		text = strings.TrimSpace(*u.Text)
+		if msg := todoTextError(text); msg != "" {
+			errs["text"] = msg
+		}
+	}
+	if u.DueDate != nil {
+		var msg string
+		if due, msg = parseDueDate(strings.TrimSpace(*u.DueDate)); msg != "" {
+			errs["due_date"] = msg
+		}
+	}
+	if u.Priority != nil {
+		if msg := todoPriorityError(*u.Priority); msg != "" {
+			errs["priority"] = msg
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return func(todo *Todo) {
+		if u.Text != nil {
+			todo.Text = text
+		}
+		if u.DueDate != nil {
+			todo.DueDate = due
+		}
+		if u.Priority != nil {
+			todo.Priority = *u.Priority
+		}
+	}, nil
+}
+
+// userUpdate is a change to a user sent as JSON or by the edit form.
+// Fields left out, or left empty, keep their current values. Version, when
+// sent, is the version the change was made to, which must still be the
+// user's.
+type userUpdate struct {
+	Name    string `json:"name"`
+	Email   string `json:"email"`
+	Role    string `json:"role"`
+	Version *int   `json:"version"`
+}
+
+// Errors from updateUser
+var (
+	errUserNotFound = errors.New("user not found")
+	errUserConflict = errors.New("user changed since it was loaded")
+)
+
+// updateUser applies update to the user with id if the result is valid,
+// and counts up the user's version. With errs, or errUserConflict, the
+// user is left as it was and returned as it is now.
+func updateUser(id int, update userUpdate) (user User, errs templates.FormErrors, err error) {
+	user, ok := users.Update(id, func(current *User) {
+		if update.Version != nil && *update.Version != current.Version {
+			err = errUserConflict
+			return
+		}
+		changed := *current
+		if name := strings.TrimSpace(update.Name); name != "" {
+			changed.Name = name
+		}
+		if email := strings.TrimSpace(update.Email); email != "" {
+			changed.Email = email
+		}
+		if role := strings.TrimSpace(update.Role); role != "" {
+			changed.Role = role
+		}
+		if errs = validateUser(changed); errs == nil {
+			changed.Version++
+			*current = changed
+		}
+	})
+	if !ok {
+		return user, nil, errUserNotFound
+	}
+	return user, errs, err
+}
+
+// validateUser checks the fields of a new user, which have already had
+// their surrounding space trimmed. It returns nil when there is nothing
+// wrong.
+func validateUser(user User) templates.FormErrors {
+	errs := templates.FormErrors{}
+	if user.Name == "" {
+		errs["name"] = "Name is required"
+	}
+	if msg := emailError(user.Email); msg != "" {
+		errs["email"] = msg
+	}
+	if !isUserRole(user.Role) {
+		errs["role"] = "Role must be User, Editor or Admin"
+	}
+	return nonEmpty(errs)
+}
+
+// validateContact checks the contact form's fields, which have already
+// had their surrounding space trimmed. It returns nil when there is
+// nothing wrong.
+func validateContact(values templates.ContactFormValues) templates.FormErrors {
+	errs := templates.FormErrors{}
+	switch n := utf8.RuneCountInString(values.Name); {
+	case n == 0:
+		errs["name"] = "Name is required"
+	case n > maxContactNameLength:
+		errs["name"] = fmt.Sprintf("Name must be at most %d characters", maxContactNameLength)
+	}
+	if msg := emailError(values.Email); msg != "" {
+		errs["email"] = msg
+	}
+	switch n := utf8.RuneCountInString(values.Message); {
+	case n == 0:
+		errs["message"] = "Message is required"
+	case n > maxContactMessageLength:
+		errs["message"] = fmt.Sprintf("Message must be at most %d characters", maxContactMessageLength)
+	}
+	return nonEmpty(errs)
+}
+
+// emailError says what is wrong with an email address, if anything
+func emailError(email string) string {
+	if email == "" {
+		return "Email is required"
+	}
+	// A bare address only; ParseAddress also takes "Name <address>"
+	if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+		return "Email must be a valid address"
+	}
+	return ""
+}
+
+func isUserRole(role string) bool {
+	for _, r := range templates.UserRoles {
+		if r.Value == role {
+			return true
+		}
+	}
+	return false
+}
+
+func nonEmpty(errs templates.FormErrors) templates.FormErrors {
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// formValue is the named form field with its surrounding space trimmed
+func formValue(r *http.Request, name string) string {
+	return strings.TrimSpace(r.FormValue(name))
+}
@@ -0,0 +1,54 @@
+package main
+
+import "time"
+
+// DaysInMonth returns the number of days in month, 29 for February in leap
+// years
+func DaysInMonth(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// ISOWeekStart returns midnight in loc on the Monday starting ISO week
+// week of year, the inverse of time.Time.ISOWeek. Week 1 is the week with
+// the year's first Thursday, so it can start in late December; weeks
+// outside 1 to WeeksInYear(year) roll into the neighbouring years.
+func ISOWeekStart(year, week int, loc *time.Location) time.Time {
+	// January 4th is always in week 1
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, loc)
+	back := (int(jan4.Weekday()) + 6) % 7 // days since Monday
+	return time.Date(year, time.January, 4-back+7*(week-1), 0, 0, 0, 0, loc)
+}
+
+// WeeksInYear returns the number of ISO weeks in year: 53 when it starts
+// on a Thursday, or on a Wednesday in a leap year, otherwise 52
+func WeeksInYear(year int) int {
+	// December 28th is always in the last week
+	_, week := time.Date(year, time.December, 28, 0, 0, 0, 0, time.UTC).ISOWeek()
+	return week
+}
+
+// Quarter returns t's quarter, 1 to 4
+func Quarter(t time.Time) int {
+	return int(t.Month()-1)/3 + 1
+}
+
+// QuarterRange returns quarter q of year in loc, from midnight on its
+// first day to midnight on the next quarter's
+func QuarterRange(year, q int, loc *time.Location) Range {
+	start := time.Date(year, time.Month(3*(q-1)+1), 1, 0, 0, 0, 0, loc)
+	return Range{start, start.AddDate(0, 3, 0)}
+}
+
+// AddQuarters moves t by n quarters, clamping the day to the end of
+// shorter months: May 31st, 2024 less one quarter is February 29th
+func AddQuarters(t time.Time, n int) time.Time {
+	return addMonthsClamped(t, 3*n)
+}
+
+// WeekOfMonth returns which week of its month t falls in, counting the
+// week containing the 1st as week 1 and starting weeks on weekStart
+func WeekOfMonth(t time.Time, weekStart time.Weekday) int {
+	first := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	offset := (int(first.Weekday()) - int(weekStart) + 7) % 7
+	return (t.Day()-1+offset)/7 + 1
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestISOWeekOfJanuaryFirst(t *testing.T) {
+	tests := []struct {
+		date       time.Time
+		year, week int
+	}{
+		{date(2016, time.January, 1), 2015, 53}, // Friday
+		{date(2021, time.January, 1), 2020, 53}, // Friday
+		{date(2021, time.January, 3), 2020, 53}, // Sunday
+		{date(2021, time.January, 4), 2021, 1},  // Monday
+		{date(2022, time.January, 1), 2021, 52}, // Saturday
+		{date(2023, time.January, 1), 2022, 52}, // Sunday
+		{date(2024, time.January, 1), 2024, 1},  // Monday
+		{date(2026, time.January, 1), 2026, 1},  // Thursday
+		{date(2019, time.December, 30), 2020, 1},
+		{date(2024, time.December, 30), 2025, 1},
+	}
+	for _, tt := range tests {
+		year, week := tt.date.ISOWeek()
+		if year != tt.year || week != tt.week {
+			t.Errorf("%s is in %d-W%02d, want %d-W%02d", tt.date.Format("Mon 2006-01-02"), year, week, tt.year, tt.week)
+		}
+		// ISOWeekStart gives the Monday on or before the date
+		start := ISOWeekStart(year, week, time.UTC)
+		if start.Weekday() != time.Monday || start.After(tt.date) || tt.date.Sub(start) >= 7*daySize {
+			t.Errorf("ISOWeekStart(%d, %d) = %s, not the Monday of %s's week", year, week,
+				start.Format("Mon 2006-01-02"), tt.date.Format("2006-01-02"))
+		}
+	}
+}
+
+func TestISOWeekStart(t *testing.T) {
+	tests := []struct {
+		year, week int
+		want       time.Time
+	}{
+		{2020, 1, date(2019, time.December, 30)},
+		{2020, 53, date(2020, time.December, 28)},
+		{2021, 1, date(2021, time.January, 4)},
+		{2015, 1, date(2014, time.December, 29)},
+		{2015, 53, date(2015, time.December, 28)},
+		{2024, 1, date(2024, time.January, 1)},
+		{2024, 52, date(2024, time.December, 23)},
+		// Out-of-range weeks roll over
+		{2024, 53, date(2024, time.December, 30)},
+		{2024, 0, date(2023, time.December, 25)},
+	}
+	for _, tt := range tests {
+		if got := ISOWeekStart(tt.year, tt.week, time.UTC); !got.Equal(tt.want) {
+			t.Errorf("ISOWeekStart(%d, %d) = %s, want %s", tt.year, tt.week, got.Format("Mon 2006-01-02"), tt.want.Format("Mon 2006-01-02"))
+		}
+	}
+
+	// Round trip every week of three decades
+	for year := 2000; year <= 2030; year++ {
+		for week := 1; week <= WeeksInYear(year); week++ {
+			y, w := ISOWeekStart(year, week, time.UTC).ISOWeek()
+			if y != year || w != week {
+				t.Fatalf("ISOWeekStart(%d, %d).ISOWeek() = %d, %d", year, week, y, w)
+			}
+		}
+	}
+
+	// Midnight in the given location
+	tokyo := mustZone(t, "Asia/Tokyo")
+	if got := ISOWeekStart(2024, 20, tokyo); !got.Equal(time.Date(2024, time.May, 13, 0, 0, 0, 0, tokyo)) {
+		t.Errorf("ISOWeekStart in Tokyo = %s", got)
+	}
+}
+
+func TestWeeksInYear(t *testing.T) {
+	for year, want := range map[int]int{
+		2004: 53, // leap year starting on Thursday
+		2009: 53,
+		2015: 53, // starts on Thursday
+		2020: 53, // leap year starting on Wednesday
+		2026: 53,
+		2019: 52,
+		2021: 52,
+		2024: 52, // leap year starting on Monday
+		2025: 52,
+	} {
+		if got := WeeksInYear(year); got != want {
+			t.Errorf("WeeksInYear(%d) = %d, want %d", year, got, want)
+		}
+	}
+}
+
+func TestQuarters(t *testing.T) {
+	for month, want := range map[time.Month]int{
+		time.January: 1, time.March: 1, time.April: 2, time.June: 2,
+		time.July: 3, time.September: 3, time.October: 4, time.December: 4,
+	} {
+		if got := Quarter(date(2024, month, 15)); got != want {
+			t.Errorf("Quarter(%s) = %d, want %d", month, got, want)
+		}
+	}
+
+	tests := []struct {
+		year, q    int
+		start, end time.Time
+		days       int
+	}{
+		{2024, 1, date(2024, time.January, 1), date(2024, time.April, 1), 91}, // leap February
+		{2023, 1, date(2023, time.January, 1), date(2023, time.April, 1), 90},
+		{2024, 2, date(2024, time.April, 1), date(2024, time.July, 1), 91},
+		{2024, 3, date(2024, time.July, 1), date(2024, time.October, 1), 92},
+		{2024, 4, date(2024, time.October, 1), date(2025, time.January, 1), 92},
+	}
+	for _, tt := range tests {
+		r := QuarterRange(tt.year, tt.q, time.UTC)
+		if !r.Start.Equal(tt.start) || !r.End.Equal(tt.end) {
+			t.Errorf("QuarterRange(%d, %d) = %s to %s, want %s to %s", tt.year, tt.q, r.Start, r.End, tt.start, tt.end)
+		}
+		if days := int(r.Duration() / daySize); days != tt.days {
+			t.Errorf("Q%d %d has %d days, want %d", tt.q, tt.year, days, tt.days)
+		}
+		if !r.Contains(tt.start) || r.Contains(tt.end) {
+			t.Errorf("QuarterRange(%d, %d) is not [start, end)", tt.year, tt.q)
+		}
+	}
+}
+
+func TestAddQuartersLeapDay(t *testing.T) {
+	tests := []struct {
+		t    time.Time
+		n    int
+		want time.Time
+	}{
+		{date(2024, time.May, 31), -1, date(2024, time.February, 29)},
+		{date(2023, time.May, 31), -1, date(2023, time.February, 28)},
+		{date(2023, time.November, 30), 1, date(2024, time.February, 29)},
+		{date(2024, time.February, 29), 1, date(2024, time.May, 29)},
+		{date(2024, time.February, 29), 4, date(2025, time.February, 28)},
+		{date(2024, time.February, 29), 16, date(2028, time.February, 29)},
+		{date(2024, time.August, 31), -2, date(2024, time.February, 29)},
+		{date(2024, time.March, 31), -1, date(2023, time.December, 31)},
+		{time.Date(2024, time.May, 31, 9, 30, 0, 0, time.UTC), -1, time.Date(2024, time.February, 29, 9, 30, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := AddQuarters(tt.t, tt.n); !got.Equal(tt.want) {
+			t.Errorf("AddQuarters(%s, %d) = %s, want %s", tt.t.Format("2006-01-02"), tt.n, got.Format("2006-01-02"), tt.want.Format("2006-01-02"))
+		}
+	}
+}
+
+func TestWeekOfMonth(t *testing.T) {
+	tests := []struct {
+		t         time.Time
+		weekStart time.Weekday
+		want      int
+	}{
+		// May 2024 starts on a Wednesday
+		{date(2024, time.May, 1), time.Monday, 1},
+		{date(2024, time.May, 5), time.Monday, 1},
+		{date(2024, time.May, 6), time.Monday, 2},
+		{date(2024, time.May, 31), time.Monday, 5},
+		{date(2024, time.May, 4), time.Sunday, 1},
+		{date(2024, time.May, 5), time.Sunday, 2},
+		// September 2024 starts on a Sunday
+		{date(2024, time.September, 1), time.Monday, 1},
+		{date(2024, time.September, 2), time.Monday, 2},
+		{date(2024, time.September, 30), time.Monday, 6},
+		{date(2024, time.September, 1), time.Sunday, 1},
+		{date(2024, time.September, 30), time.Sunday, 5},
+		// February 2021 starts on a Monday and fills exactly four weeks
+		{date(2021, time.February, 28), time.Monday, 4},
+	}
+	for _, tt := range tests {
+		if got := WeekOfMonth(tt.t, tt.weekStart); got != tt.want {
+			t.Errorf("WeekOfMonth(%s, %s) = %d, want %d", tt.t.Format("Mon 2006-01-02"), tt.weekStart, got, tt.want)
+		}
+	}
+}
+
+func TestDaysInMonth(t *testing.T) {
+	tests := []struct {
+		year  int
+		month time.Month
+		want  int
+	}{
+		{2024, time.February, 29},
+		{2023, time.February, 28},
+		{2000, time.February, 29},
+		{1900, time.February, 28},
+		{2024, time.April, 30},
+		{2024, time.December, 31},
+		{2024, time.January, 31},
+	}
+	for _, tt := range tests {
+		if got := DaysInMonth(tt.year, tt.month); got != tt.want {
+			t.Errorf("DaysInMonth(%d, %s) = %d, want %d", tt.year, tt.month, got, tt.want)
+		}
+	}
+}
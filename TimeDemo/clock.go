@@ -0,0 +1,138 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Clock tells the current time. Code that asks a Clock instead of calling
+// time.Now can be tested for a fixed date with a FakeClock.
+type Clock interface {
+	Now() time.Time
+}
+
+// TimerClock is a Clock that can also call a function later
+type TimerClock interface {
+	Clock
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is a pending AfterFunc call; Stop cancels it and reports whether
+// it was still pending, like time.Timer.Stop
+type Timer interface {
+	Stop() bool
+}
+
+// RealClock is the system clock
+type RealClock struct{}
+
+// Now returns time.Now()
+func (RealClock) Now() time.Time { return time.Now() }
+
+// AfterFunc calls time.AfterFunc
+func (RealClock) AfterFunc(d time.Duration, f func()) Timer { return time.AfterFunc(d, f) }
+
+// FakeClock is a Clock that only moves when told to. It is safe for
+// concurrent use.
+type FakeClock struct {
+	mu     sync.Mutex
+	t      time.Time
+	timers []*fakeTimer // pending AfterFunc calls
+}
+
+// fakeTimer is an AfterFunc call waiting for its FakeClock to reach at
+type fakeTimer struct {
+	clock *FakeClock
+	at    time.Time
+	f     func()
+}
+
+// NewFakeClock returns a FakeClock reading t
+func NewFakeClock(t time.Time) *FakeClock {
+	return &FakeClock{t: t}
+}
+
+// Now returns the clock's current reading
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+// Advance moves the clock forward by d, or back if d is negative, running
+// the AfterFunc calls that fall due
+func (c *FakeClock) Advance(d time.Duration) {
+	c.move(func(now time.Time) time.Time { return now.Add(d) })
+}
+
+// Set moves the clock to t. AfterFunc calls due by t run before Set
+// returns, in order, each with the clock reading the time it was due;
+// calls they schedule run too if they fall due by t.
+func (c *FakeClock) Set(t time.Time) {
+	c.move(func(time.Time) time.Time { return t })
+}
+
+// move moves the clock to to(now), reading now and moving under one lock
+// when no calls are due, so concurrent Advances all count
+func (c *FakeClock) move(to func(now time.Time) time.Time) {
+	c.mu.Lock()
+	target := to(c.t)
+	for len(c.timers) > 0 && !c.timers[0].at.After(target) {
+		next := c.timers[0]
+		c.timers = c.timers[1:]
+		if next.at.After(c.t) {
+			c.t = next.at
+		}
+		c.mu.Unlock()
+		next.f()
+		c.mu.Lock()
+	}
+	c.t = target
+	c.mu.Unlock()
+}
+
+// AfterFunc calls f once the clock has moved on by d. Unlike
+// time.AfterFunc, f runs in the goroutine that moves the clock.
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	timer := &fakeTimer{clock: c, at: c.t.Add(d), f: f}
+	// Keep the timers in the order they fall due, first come first for
+	// the same time
+	i, _ := slices.BinarySearchFunc(c.timers, timer.at, func(t *fakeTimer, at time.Time) int {
+		if t.at.After(at) {
+			return 1
+		}
+		return -1
+	})
+	c.timers = slices.Insert(c.timers, i, timer)
+	return timer
+}
+
+// pendingTimers is the number of AfterFunc calls still to run
+func (c *FakeClock) pendingTimers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+// Stop cancels the call, reporting whether it was still pending
+func (t *fakeTimer) Stop() bool {
+	c := t.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i := slices.Index(c.timers, t)
+	if i < 0 {
+		return false
+	}
+	c.timers = slices.Delete(c.timers, i, i+1)
+	return true
+}
+
+// hasMonotonic reports whether t carries a monotonic clock reading. The
+// time package has no accessor for it; String shows it as "m=±<seconds>".
+func hasMonotonic(t time.Time) bool {
+	return strings.Contains(t.String(), " m=")
+}
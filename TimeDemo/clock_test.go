@@ -0,0 +1,123 @@
+package main
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2024, time.February, 28, 23, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+
+	if !clock.Now().Equal(start) {
+		t.Fatalf("Now = %s, want %s", clock.Now(), start)
+	}
+	if !clock.Now().Equal(clock.Now()) {
+		t.Error("FakeClock moved without Advance")
+	}
+
+	clock.Advance(2 * time.Hour)
+	if want := time.Date(2024, time.February, 29, 1, 0, 0, 0, time.UTC); !clock.Now().Equal(want) {
+		t.Errorf("after Advance(2h): %s, want %s", clock.Now(), want)
+	}
+	clock.Advance(-time.Hour)
+	if want := time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC); !clock.Now().Equal(want) {
+		t.Errorf("after Advance(-1h): %s, want %s", clock.Now(), want)
+	}
+
+	later := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+	clock.Set(later)
+	if !clock.Now().Equal(later) {
+		t.Errorf("after Set: %s, want %s", clock.Now(), later)
+	}
+
+	// Safe for concurrent use (run with -race)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			clock.Advance(time.Second)
+			_ = clock.Now()
+		}()
+	}
+	wg.Wait()
+	if got := clock.Now().Sub(later); got != 10*time.Second {
+		t.Errorf("10 concurrent Advance(1s): moved %v", got)
+	}
+}
+
+func TestFakeClockAfterFunc(t *testing.T) {
+	clock := NewFakeClock(date(2024, time.January, 1))
+	var order []string
+	var at []time.Time
+	record := func(name string) func() {
+		return func() {
+			order = append(order, name)
+			at = append(at, clock.Now())
+		}
+	}
+	clock.AfterFunc(2*time.Second, record("b"))
+	clock.AfterFunc(time.Second, func() {
+		record("a")()
+		// Scheduled from a callback, due within the same Advance
+		clock.AfterFunc(500*time.Millisecond, record("a2"))
+	})
+	stopped := clock.AfterFunc(1500*time.Millisecond, record("stopped"))
+	clock.AfterFunc(time.Hour, record("later"))
+
+	if !stopped.Stop() {
+		t.Error("Stop of a pending timer returned false")
+	}
+	if stopped.Stop() {
+		t.Error("second Stop returned true")
+	}
+	clock.Advance(3 * time.Second)
+	if got := strings.Join(order, " "); got != "a a2 b" {
+		t.Errorf("ran %s, want a a2 b", got)
+	}
+	for i, want := range []time.Duration{time.Second, 1500 * time.Millisecond, 2 * time.Second} {
+		if got := at[i].Sub(date(2024, time.January, 1)); got != want {
+			t.Errorf("%s ran with the clock at +%v, want +%v", order[i], got, want)
+		}
+	}
+	if !clock.Now().Equal(date(2024, time.January, 1).Add(3 * time.Second)) {
+		t.Errorf("clock at %s after Advance(3s)", clock.Now())
+	}
+	if n := clock.pendingTimers(); n != 1 {
+		t.Errorf("%d timers pending, want 1", n)
+	}
+}
+
+func TestMonotonicReadings(t *testing.T) {
+	now := RealClock{}.Now()
+	if !hasMonotonic(now) {
+		t.Fatalf("RealClock.Now() = %s, want a monotonic reading", now)
+	}
+
+	tests := []struct {
+		name string
+		t    time.Time
+		want bool
+	}{
+		{"Add keeps it", now.Add(time.Hour), true},
+		{"Round(0) strips it", now.Round(0), false},
+		{"Truncate strips it", now.Truncate(time.Second), false},
+		{"AddDate strips it", now.AddDate(0, 0, 1), false},
+		{"In strips it", now.In(time.UTC), false},
+		{"time.Date has none", time.Date(2024, time.May, 15, 0, 0, 0, 0, time.UTC), false},
+		{"FakeClock has none", NewFakeClock(now.Round(0)).Now(), false},
+	}
+	for _, tt := range tests {
+		if got := hasMonotonic(tt.t); got != tt.want {
+			t.Errorf("%s: hasMonotonic = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// == compares the monotonic reading too; Equal does not
+	if stripped := now.Round(0); now == stripped || !now.Equal(stripped) {
+		t.Error("want t != t.Round(0) but t.Equal(t.Round(0))")
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Conversion is one row of the convert table
+type Conversion struct {
+	Zone     string
+	Time     time.Time
+	DayShift int // calendar days ahead of (or behind) the source date
+}
+
+// Convert shows t in each zone, noting when the local date differs from
+// t's own
+func Convert(t time.Time, zones []*time.Location) []Conversion {
+	conversions := make([]Conversion, 0, len(zones))
+	for _, loc := range zones {
+		local := t.In(loc)
+		conversions = append(conversions, Conversion{
+			Zone:     loc.String(),
+			Time:     local,
+			DayShift: daysBetween(t, local),
+		})
+	}
+	return conversions
+}
+
+// daysBetween counts the calendar days from a's date to b's date, each in
+// its own location
+func daysBetween(a, b time.Time) int {
+	dateA := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	dateB := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(dateB.Sub(dateA).Hours() / 24)
+}
+
+// formatOffset formats a zone offset in seconds as UTC+09:00
+func formatOffset(seconds int) string {
+	sign := '+'
+	if seconds < 0 {
+		sign = '-'
+		seconds = -seconds
+	}
+	return fmt.Sprintf("UTC%c%02d:%02d", sign, seconds/3600, seconds%3600/60)
+}
+
+// formatDayShift returns "+1d", "-1d" or "" for the same day
+func formatDayShift(days int) string {
+	if days == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%+dd", days)
+}
+
+// writeConversions prints the source time and its conversions as a table
+func writeConversions(w io.Writer, source Conversion, conversions []Conversion) error {
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "   Zone\tTime\tOffset\t")
+	rows := append([]Conversion{source}, conversions...)
+	for i, c := range rows {
+		_, offset := c.Time.Zone()
+		note := formatDayShift(c.DayShift)
+		if i == 0 {
+			note = "(from)"
+		}
+		fmt.Fprintf(tw, "   %s\t%s\t%s\t%s\n", c.Zone, c.Time.Format("Mon 2006-01-02 15:04 MST"), formatOffset(offset), note)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	// Rows without a note end in padding
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		if _, err := fmt.Fprintln(w, strings.TrimRight(line, " ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runConvert implements
+//
+//	timedemo convert "2024-12-25 15:30" --from America/New_York --to Asia/Tokyo,Europe/London
+//
+// and returns the exit code: 0 on success, 1 for bad input, 2 for usage
+// errors
+func runConvert(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("convert", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	from := fs.String("from", "Local", "zone the input time is in (IANA name or abbreviation such as EST)")
+	to := fs.String("to", "", "comma-separated zones to convert to")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, `usage: timedemo convert "<time>" --from <zone> --to <zone>[,<zone>...]`)
+		fs.PrintDefaults()
+	}
+
+	// The time usually comes before the flags, which flag.Parse would
+	// stop at
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return 0
+			}
+			return 2
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	if len(positional) == 0 || *to == "" {
+		fs.Usage()
+		return 2
+	}
+
+	fromLoc, err := ResolveZone(*from)
+	if err != nil {
+		fmt.Fprintf(stderr, "❌ --from: %v\n", err)
+		return 1
+	}
+	var zones []*time.Location
+	var problems []string
+	for _, name := range strings.Split(*to, ",") {
+		loc, err := ResolveZone(name)
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		zones = append(zones, loc)
+	}
+	if len(problems) > 0 {
+		fmt.Fprintf(stderr, "❌ --to: %s\n", strings.Join(problems, "; "))
+		return 1
+	}
+
+	t, err := ParseFlexible(strings.Join(positional, " "), fromLoc)
+	if err != nil {
+		fmt.Fprintf(stderr, "❌ %v\n", err)
+		return 1
+	}
+
+	source := Conversion{Zone: fromLoc.String(), Time: t}
+	if err := writeConversions(stdout, source, Convert(t, zones)); err != nil {
+		fmt.Fprintf(stderr, "❌ %v\n", err)
+		return 1
+	}
+	return 0
+}
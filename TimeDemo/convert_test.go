@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRunConvert(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := runConvert([]string{"2024-12-25 15:30", "--from", "America/New_York", "--to", "Asia/Tokyo,Europe/London,Pacific/Honolulu"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr.String())
+	}
+
+	want := [][]string{
+		{"Zone", "Time", "Offset"},
+		{"America/New_York", "Wed", "2024-12-25", "15:30", "EST", "UTC-05:00", "(from)"},
+		{"Asia/Tokyo", "Thu", "2024-12-26", "05:30", "JST", "UTC+09:00", "+1d"},
+		{"Europe/London", "Wed", "2024-12-25", "20:30", "GMT", "UTC+00:00"},
+		{"Pacific/Honolulu", "Wed", "2024-12-25", "10:30", "HST", "UTC-10:00"},
+	}
+	lines := strings.Split(strings.TrimSuffix(stdout.String(), "\n"), "\n")
+	if len(lines) != len(want) {
+		t.Fatalf("output:\n%s", stdout.String())
+	}
+	for i, fields := range want {
+		if got := strings.Join(strings.Fields(lines[i]), " "); got != strings.Join(fields, " ") {
+			t.Errorf("line %d = %q, want %q", i, got, strings.Join(fields, " "))
+		}
+		if strings.HasSuffix(lines[i], " ") {
+			t.Errorf("line %d has trailing spaces", i)
+		}
+	}
+}
+
+func TestRunConvertDayShifts(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := runConvert([]string{"--from", "Asia/Tokyo", "2024-01-01", "08:00", "--to", "PST,Pacific/Kiritimati"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr.String())
+	}
+	out := stdout.String()
+	if !strings.Contains(out, "Sun 2023-12-31 15:00 PST  UTC-08:00  -1d") {
+		t.Errorf("missing -1d row:\n%s", out)
+	}
+	if !strings.Contains(out, "Mon 2024-01-01 13:00 +14  UTC+14:00\n") {
+		t.Errorf("Kiritimati row:\n%s", out)
+	}
+}
+
+func TestRunConvertErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+		wantErr  string
+	}{
+		{"no time", []string{"--to", "UTC"}, 2, "usage:"},
+		{"no targets", []string{"2024-12-25 15:30"}, 2, "usage:"},
+		{"unknown flag", []string{"2024-12-25 15:30", "--too", "UTC"}, 2, "flag provided but not defined"},
+		{"bad source zone", []string{"2024-12-25 15:30", "--from", "Europe/Pariss", "--to", "UTC"}, 1, `--from: unknown time zone "Europe/Pariss" (did you mean Europe/Paris?)`},
+		{"bad target zones", []string{"2024-12-25 15:30", "--to", "Asia/Tokio,UTC,Nowhere"}, 1, `"Asia/Tokio" (did you mean Asia/Tokyo?); unknown time zone "Nowhere"`},
+		{"unparseable time", []string{"next tuesday", "--to", "UTC"}, 1, `cannot parse "next tuesday"`},
+		{"skipped time", []string{"2024-03-10 02:30", "--from", "US/Eastern", "--to", "UTC"}, 1, "does not exist"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := runConvert(tt.args, &stdout, &stderr)
+			if code != tt.wantCode || !strings.Contains(stderr.String(), tt.wantErr) {
+				t.Errorf("exit %d, stderr %q; want %d and %q", code, stderr.String(), tt.wantCode, tt.wantErr)
+			}
+			if stdout.Len() != 0 {
+				t.Errorf("stdout = %q", stdout.String())
+			}
+		})
+	}
+}
+
+func TestFormatOffset(t *testing.T) {
+	tests := map[int]string{
+		0:                "UTC+00:00",
+		9 * 3600:         "UTC+09:00",
+		-5 * 3600:        "UTC-05:00",
+		5*3600 + 1800:    "UTC+05:30",
+		-(3*3600 + 1800): "UTC-03:30",
+		5*3600 + 2700:    "UTC+05:45",
+	}
+	for seconds, want := range tests {
+		if got := formatOffset(seconds); got != want {
+			t.Errorf("formatOffset(%d) = %q, want %q", seconds, got, want)
+		}
+	}
+}
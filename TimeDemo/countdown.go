@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Ticker delivers ticks on a channel like time.Ticker; tests substitute a
+// fake one they tick by hand
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// realTicker adapts *time.Ticker to Ticker
+type realTicker struct {
+	t *time.Ticker
+}
+
+func (r realTicker) C() <-chan time.Time { return r.t.C }
+func (r realTicker) Stop()               { r.t.Stop() }
+
+func newRealTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+// activeCountdowns counts running Countdown goroutines, so tests can check
+// that none are left behind
+var activeCountdowns atomic.Int64
+
+// Countdown counts a duration down, reporting progress every tick. Time
+// spent paused does not count. It is safe to Pause, Resume and read it
+// from other goroutines while it runs.
+type Countdown struct {
+	// OnProgress is called every tick, except while paused, with the time
+	// left and the percentage done; the last call has 0 and 100
+	OnProgress func(remaining time.Duration, percent float64)
+	// OnDone is called once the countdown reaches zero, not when cancelled
+	OnDone func()
+
+	// Clock and NewTicker default to the real ones; tests substitute fakes
+	Clock     Clock
+	NewTicker func(d time.Duration) Ticker
+
+	duration, tick time.Duration
+	done           chan struct{}
+
+	mu        sync.Mutex
+	started   bool
+	paused    bool
+	left      time.Duration // remaining at resumedAt
+	resumedAt time.Time
+	err       error
+}
+
+// NewCountdown returns a countdown of d that reports progress every tick.
+// It panics if tick is not positive, as time.NewTicker does.
+func NewCountdown(d, tick time.Duration) *Countdown {
+	if tick <= 0 {
+		panic("NewCountdown: non-positive tick")
+	}
+	return &Countdown{duration: d, tick: tick, left: d, done: make(chan struct{})}
+}
+
+// Start runs the countdown in a goroutine until it reaches zero or ctx is
+// cancelled; Done is closed when that goroutine has exited. Zero is noticed
+// on the first tick at or after it, so a countdown that was paused can end
+// up to one tick late.
+func (c *Countdown) Start(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.started {
+		return errors.New("countdown already started")
+	}
+	c.started = true
+	if c.Clock == nil {
+		c.Clock = RealClock{}
+	}
+	if c.NewTicker == nil {
+		c.NewTicker = newRealTicker
+	}
+
+	// Read the clock before starting the ticker, so the ticks never come
+	// early
+	c.resumedAt = c.Clock.Now()
+	ticker := c.NewTicker(c.tick)
+	activeCountdowns.Add(1)
+	go c.run(ctx, ticker)
+	return nil
+}
+
+func (c *Countdown) run(ctx context.Context, ticker Ticker) {
+	// Deferred calls run last first: stop the ticker and uncount the
+	// goroutine before anyone waiting on Done is released
+	defer close(c.done)
+	defer activeCountdowns.Add(-1)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			c.mu.Lock()
+			c.err = ctx.Err()
+			c.mu.Unlock()
+			return
+		case <-ticker.C():
+		}
+
+		c.mu.Lock()
+		paused, remaining := c.paused, c.remainingLocked()
+		c.mu.Unlock()
+		if paused {
+			continue
+		}
+		if c.OnProgress != nil {
+			c.OnProgress(remaining, c.percent(remaining))
+		}
+		if remaining == 0 {
+			if c.OnDone != nil {
+				c.OnDone()
+			}
+			return
+		}
+	}
+}
+
+// Pause stops the remaining time from going down until Resume
+func (c *Countdown) Pause() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.started && !c.paused {
+		c.left = c.remainingLocked()
+		c.paused = true
+	}
+}
+
+// Resume continues a paused countdown
+func (c *Countdown) Resume() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.paused {
+		c.paused = false
+		c.resumedAt = c.Clock.Now()
+	}
+}
+
+// Paused reports whether the countdown is paused
+func (c *Countdown) Paused() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.paused
+}
+
+// Remaining is the time left, never negative
+func (c *Countdown) Remaining() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.remainingLocked()
+}
+
+func (c *Countdown) remainingLocked() time.Duration {
+	if !c.started || c.paused {
+		return c.left
+	}
+	return max(c.left-c.Clock.Now().Sub(c.resumedAt), 0)
+}
+
+// percent is how much of the countdown is done, from 0 to 100
+func (c *Countdown) percent(remaining time.Duration) float64 {
+	if c.duration <= 0 {
+		return 100
+	}
+	return float64(c.duration-remaining) / float64(c.duration) * 100
+}
+
+// Done is closed when the countdown has finished or been cancelled
+func (c *Countdown) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err is the context's error if the countdown was cancelled, and nil
+// while it runs or once it has reached zero
+func (c *Countdown) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
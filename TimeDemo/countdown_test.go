@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// fakeTicker is a Ticker that ticks when the test calls tick
+type fakeTicker struct {
+	c       chan time.Time
+	stopped chan struct{}
+}
+
+func (f *fakeTicker) C() <-chan time.Time { return f.c }
+func (f *fakeTicker) Stop()               { close(f.stopped) }
+
+// progress is one OnProgress call
+type progress struct {
+	remaining time.Duration
+	percent   float64
+}
+
+// newFakeCountdown returns a countdown on a FakeClock, a function that
+// advances the clock by d and ticks, and the channel OnProgress reports on
+func newFakeCountdown(t *testing.T, d, tick time.Duration) (*Countdown, *FakeClock, func(time.Duration), chan progress) {
+	t.Helper()
+	clock := NewFakeClock(time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC))
+	ticker := &fakeTicker{c: make(chan time.Time), stopped: make(chan struct{})}
+	c := NewCountdown(d, tick)
+	c.Clock = clock
+	c.NewTicker = func(got time.Duration) Ticker {
+		if got != tick {
+			t.Errorf("ticker interval %v, want %v", got, tick)
+		}
+		return ticker
+	}
+	updates := make(chan progress, 16)
+	c.OnProgress = func(remaining time.Duration, percent float64) {
+		updates <- progress{remaining, percent}
+	}
+	advance := func(d time.Duration) {
+		clock.Advance(d)
+		select {
+		case ticker.c <- clock.Now():
+		case <-c.Done():
+		}
+	}
+	t.Cleanup(func() {
+		select {
+		case <-ticker.stopped:
+		case <-time.After(time.Second):
+			t.Error("ticker not stopped")
+		}
+	})
+	return c, clock, advance, updates
+}
+
+func TestCountdown(t *testing.T) {
+	c, _, advance, updates := newFakeCountdown(t, 4*time.Second, time.Second)
+	finished := make(chan struct{})
+	c.OnDone = func() { close(finished) }
+	if err := c.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Start(context.Background()); err == nil {
+		t.Error("second Start succeeded")
+	}
+
+	for _, want := range []progress{{3 * time.Second, 25}, {2 * time.Second, 50}, {time.Second, 75}, {0, 100}} {
+		advance(time.Second)
+		if got := <-updates; got != want {
+			t.Errorf("progress %v, want %v", got, want)
+		}
+	}
+	<-finished
+	<-c.Done()
+	if c.Err() != nil {
+		t.Errorf("Err = %v after finishing", c.Err())
+	}
+	if n := activeCountdowns.Load(); n != 0 {
+		t.Errorf("%d countdown goroutines still running", n)
+	}
+}
+
+func TestCountdownOvershoot(t *testing.T) {
+	// A late tick reports zero, not a negative time
+	c, _, advance, updates := newFakeCountdown(t, time.Second, 700*time.Millisecond)
+	c.Start(context.Background())
+	advance(700 * time.Millisecond)
+	<-updates
+	advance(700 * time.Millisecond)
+	if got := <-updates; got != (progress{0, 100}) {
+		t.Errorf("last progress %v, want 0s at 100%%", got)
+	}
+	<-c.Done()
+}
+
+func TestCountdownPause(t *testing.T) {
+	c, clock, advance, updates := newFakeCountdown(t, 10*time.Second, time.Second)
+	c.Start(context.Background())
+	advance(time.Second)
+	<-updates
+
+	c.Pause()
+	if !c.Paused() {
+		t.Error("not paused")
+	}
+	clock.Advance(500 * time.Millisecond)
+	if got := c.Remaining(); got != 9*time.Second {
+		t.Errorf("Remaining after pausing = %v, want 9s", got)
+	}
+	// Ticks while paused report nothing and take no time off
+	advance(5 * time.Second)
+	advance(5 * time.Second)
+	if got := c.Remaining(); got != 9*time.Second {
+		t.Errorf("Remaining after paused ticks = %v, want 9s", got)
+	}
+	select {
+	case got := <-updates:
+		t.Errorf("progress %v while paused", got)
+	default:
+	}
+
+	c.Resume()
+	advance(time.Second)
+	if got := <-updates; got != (progress{8 * time.Second, 20}) {
+		t.Errorf("progress after resuming %v, want 8s at 20%%", got)
+	}
+	if got := c.Remaining(); got != 8*time.Second {
+		t.Errorf("Remaining after resuming = %v, want 8s", got)
+	}
+
+	// Pausing twice keeps the first pause's reading
+	c.Pause()
+	clock.Advance(time.Second)
+	c.Pause()
+	c.Resume()
+	if got := c.Remaining(); got != 8*time.Second {
+		t.Errorf("Remaining after pausing twice = %v, want 8s", got)
+	}
+	c.Resume() // not paused: no effect
+
+	advance(8 * time.Second)
+	<-c.Done()
+}
+
+func TestCountdownCancel(t *testing.T) {
+	c, _, advance, updates := newFakeCountdown(t, 3*time.Second, time.Second)
+	c.OnDone = func() { t.Error("OnDone called after cancelling") }
+	ctx, cancel := context.WithCancel(context.Background())
+	c.Start(ctx)
+	advance(time.Second)
+	<-updates
+
+	// Cancelling while paused still stops the goroutine
+	c.Pause()
+	cancel()
+	select {
+	case <-c.Done():
+	case <-time.After(time.Second):
+		t.Fatal("countdown still running after cancelling")
+	}
+	if c.Err() != context.Canceled {
+		t.Errorf("Err = %v, want context.Canceled", c.Err())
+	}
+	if got := c.Remaining(); got != 2*time.Second {
+		t.Errorf("Remaining = %v, want 2s", got)
+	}
+	if n := activeCountdowns.Load(); n != 0 {
+		t.Errorf("%d countdown goroutines still running", n)
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// callConfig holds the Debounce and Throttle options
+type callConfig struct {
+	leading, trailing bool
+	clock             TimerClock
+}
+
+// CallOption configures Debounce and Throttle
+type CallOption func(*callConfig)
+
+// WithLeading sets whether the first call of a burst runs at once
+func WithLeading(on bool) CallOption {
+	return func(c *callConfig) { c.leading = on }
+}
+
+// WithTrailing sets whether a call is made when the wait is over if any
+// came in during it
+func WithTrailing(on bool) CallOption {
+	return func(c *callConfig) { c.trailing = on }
+}
+
+// WithTimerClock replaces the real clock; tests pass a FakeClock
+func WithTimerClock(clock TimerClock) CallOption {
+	return func(c *callConfig) { c.clock = clock }
+}
+
+// pacer is the state Debouncer and Throttler share: the function, a timer
+// for the end of the current wait and whether a trailing call is owed
+type pacer struct {
+	fn   func()
+	wait time.Duration
+	cfg  callConfig
+
+	mu      sync.Mutex
+	timer   Timer
+	gen     int // which timer is current, so a stale one that fired late does nothing
+	pending bool
+}
+
+func newPacer(fn func(), wait time.Duration, cfg callConfig, opts []CallOption) pacer {
+	cfg.clock = RealClock{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return pacer{fn: fn, wait: wait, cfg: cfg}
+}
+
+// startLocked starts a new wait, replacing any current one
+func (p *pacer) startLocked(fired func()) {
+	if p.timer != nil {
+		p.timer.Stop()
+	}
+	p.gen++
+	gen := p.gen
+	p.timer = p.cfg.clock.AfterFunc(p.wait, func() {
+		p.mu.Lock()
+		if gen != p.gen {
+			p.mu.Unlock()
+			return
+		}
+		fired()
+	})
+}
+
+// stopLocked ends the current wait without a call
+func (p *pacer) stopLocked() {
+	if p.timer != nil {
+		p.timer.Stop()
+		p.timer = nil
+	}
+	p.gen++
+	p.pending = false
+}
+
+// Cancel drops any call owed and stops the timer
+func (p *pacer) Cancel() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stopLocked()
+}
+
+// Flush makes the call owed now, if any, instead of when the wait is over,
+// and ends the wait
+func (p *pacer) Flush() {
+	p.mu.Lock()
+	run := p.pending
+	p.stopLocked()
+	p.mu.Unlock()
+	if run {
+		p.fn()
+	}
+}
+
+// Pending reports whether a call is owed at the end of the wait
+func (p *pacer) Pending() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.pending
+}
+
+// Debouncer collapses a burst of calls into one, made once the calls have
+// stopped for the wait. It is safe for concurrent use; the function itself
+// runs outside the lock, in the caller's goroutine for leading calls and
+// the timer's for trailing ones.
+type Debouncer struct {
+	pacer
+}
+
+// Debounce returns a Debouncer for fn. By default only the trailing call
+// is made; WithLeading(true) calls fn at the start of a burst too, and a
+// burst of a single call then makes only the leading one.
+func Debounce(fn func(), wait time.Duration, opts ...CallOption) *Debouncer {
+	return &Debouncer{newPacer(fn, wait, callConfig{trailing: true}, opts)}
+}
+
+// Call calls fn, or puts it off until wait has passed without another
+// Call
+func (d *Debouncer) Call() {
+	d.mu.Lock()
+	leading := d.timer == nil && d.cfg.leading
+	if !leading {
+		d.pending = d.cfg.trailing
+	}
+	d.startLocked(d.fire)
+	d.mu.Unlock()
+	if leading {
+		d.fn()
+	}
+}
+
+// fire ends the wait; called with d.mu held
+func (d *Debouncer) fire() {
+	run := d.pending
+	d.timer, d.pending = nil, false
+	d.mu.Unlock()
+	if run {
+		d.fn()
+	}
+}
+
+// Throttler makes at most one call per interval, however often it is
+// called. It is safe for concurrent use, like Debouncer.
+type Throttler struct {
+	pacer
+}
+
+// Throttle returns a Throttler for fn. By default the first call of a
+// burst is made at once and the last one at the end of the interval;
+// WithLeading(false) or WithTrailing(false) drops either.
+func Throttle(fn func(), interval time.Duration, opts ...CallOption) *Throttler {
+	return &Throttler{newPacer(fn, interval, callConfig{leading: true, trailing: true}, opts)}
+}
+
+// Call calls fn unless it was called less than an interval ago, in which
+// case the call is made at the end of the interval, or dropped without
+// trailing calls
+func (t *Throttler) Call() {
+	t.mu.Lock()
+	if t.timer != nil {
+		t.pending = t.pending || t.cfg.trailing
+		t.mu.Unlock()
+		return
+	}
+	leading := t.cfg.leading
+	t.pending = !leading && t.cfg.trailing
+	t.startLocked(t.fire)
+	t.mu.Unlock()
+	if leading {
+		t.fn()
+	}
+}
+
+// fire ends the interval; called with t.mu held. A trailing call starts a
+// new interval, so calls right after it wait too.
+func (t *Throttler) fire() {
+	run := t.pending
+	t.timer, t.pending = nil, false
+	if run {
+		t.startLocked(t.fire)
+	}
+	t.mu.Unlock()
+	if run {
+		t.fn()
+	}
+}
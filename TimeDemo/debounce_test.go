@@ -0,0 +1,232 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// callCounter counts calls to its fn, safely across goroutines
+type callCounter struct {
+	n atomic.Int64
+}
+
+func (c *callCounter) fn()        { c.n.Add(1) }
+func (c *callCounter) count() int { return int(c.n.Load()) }
+
+func TestDebounce(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []CallOption
+		// Calls at these offsets in milliseconds, checked at the end
+		calls []int
+		want  int
+	}{
+		{"single call", nil, []int{0}, 1},
+		{"burst", nil, []int{0, 50, 90, 140, 230}, 1},
+		{"two bursts", nil, []int{0, 50, 300, 350}, 2},
+		{"leading single call", []CallOption{WithLeading(true)}, []int{0}, 1},
+		{"leading burst", []CallOption{WithLeading(true)}, []int{0, 50, 90}, 2},
+		{"leading only burst", []CallOption{WithLeading(true), WithTrailing(false)}, []int{0, 50, 90}, 1},
+		{"neither", []CallOption{WithTrailing(false)}, []int{0, 50}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := NewFakeClock(date(2024, time.January, 1))
+			var calls callCounter
+			d := Debounce(calls.fn, 100*time.Millisecond, append(tt.opts, WithTimerClock(clock))...)
+			at := 0
+			for _, offset := range tt.calls {
+				clock.Advance(time.Duration(offset-at) * time.Millisecond)
+				at = offset
+				d.Call()
+			}
+			clock.Advance(time.Second)
+			if got := calls.count(); got != tt.want {
+				t.Errorf("%d calls, want %d", got, tt.want)
+			}
+			if n := clock.pendingTimers(); n != 0 {
+				t.Errorf("%d timers left", n)
+			}
+		})
+	}
+}
+
+func TestDebounceTiming(t *testing.T) {
+	clock := NewFakeClock(date(2024, time.January, 1))
+	var calls callCounter
+	d := Debounce(calls.fn, 100*time.Millisecond, WithTimerClock(clock))
+
+	// Trailing: the wait restarts with every call
+	d.Call()
+	clock.Advance(99 * time.Millisecond)
+	d.Call()
+	clock.Advance(99 * time.Millisecond)
+	if calls.count() != 0 || !d.Pending() {
+		t.Fatalf("called %d times during the burst, pending %v", calls.count(), d.Pending())
+	}
+	clock.Advance(time.Millisecond)
+	if calls.count() != 1 || d.Pending() {
+		t.Fatalf("called %d times 100ms after the last call, pending %v", calls.count(), d.Pending())
+	}
+
+	// Leading: at once, then not again until the calls stop for the wait
+	calls = callCounter{}
+	lead := Debounce(calls.fn, 100*time.Millisecond, WithLeading(true), WithTrailing(false), WithTimerClock(clock))
+	lead.Call()
+	if calls.count() != 1 {
+		t.Fatalf("leading call not made at once")
+	}
+	clock.Advance(60 * time.Millisecond)
+	lead.Call()
+	clock.Advance(60 * time.Millisecond)
+	lead.Call() // 120ms after the first, but only 60ms after the last
+	if calls.count() != 1 {
+		t.Errorf("called %d times during the burst, want 1", calls.count())
+	}
+	clock.Advance(100 * time.Millisecond)
+	lead.Call()
+	if calls.count() != 2 {
+		t.Errorf("called %d times after a quiet wait, want 2", calls.count())
+	}
+}
+
+func TestDebounceFlushCancel(t *testing.T) {
+	clock := NewFakeClock(date(2024, time.January, 1))
+	var calls callCounter
+	d := Debounce(calls.fn, 100*time.Millisecond, WithTimerClock(clock))
+
+	// Flush makes the pending call now, and only once
+	d.Call()
+	d.Call()
+	d.Flush()
+	if calls.count() != 1 {
+		t.Errorf("Flush: %d calls, want 1", calls.count())
+	}
+	if n := clock.pendingTimers(); n != 0 {
+		t.Errorf("Flush left %d timers", n)
+	}
+	clock.Advance(time.Second)
+	if calls.count() != 1 {
+		t.Errorf("called again after Flush: %d calls", calls.count())
+	}
+
+	// Flush with nothing pending does nothing
+	d.Flush()
+	if calls.count() != 1 {
+		t.Errorf("Flush with nothing pending called fn")
+	}
+
+	// Cancel drops the pending call and its timer
+	d.Call()
+	d.Cancel()
+	if n := clock.pendingTimers(); n != 0 {
+		t.Errorf("Cancel left %d timers", n)
+	}
+	clock.Advance(time.Second)
+	if calls.count() != 1 {
+		t.Errorf("called after Cancel: %d calls", calls.count())
+	}
+
+	// ...and it is usable again afterwards
+	d.Call()
+	clock.Advance(time.Second)
+	if calls.count() != 2 {
+		t.Errorf("%d calls after Cancel and another Call, want 2", calls.count())
+	}
+}
+
+func TestThrottle(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  []CallOption
+		calls []int // milliseconds
+		// Total calls when the clock reaches each of these times
+		checks map[int]int
+	}{
+		// A call every 30ms for 300ms: at 0, then at the end of each 100ms
+		// interval that saw calls
+		{"leading and trailing", nil, []int{0, 30, 60, 90, 120, 150, 180, 210, 240, 270}, map[int]int{0: 1, 99: 1, 100: 2, 200: 3, 300: 4, 400: 4}},
+		{"leading only", []CallOption{WithTrailing(false)}, []int{0, 30, 60, 90, 120, 150}, map[int]int{0: 1, 100: 1, 120: 2, 1000: 2}},
+		{"trailing only", []CallOption{WithLeading(false)}, []int{0, 30, 60, 90, 120, 150}, map[int]int{0: 0, 100: 1, 200: 2, 1000: 2}},
+		{"single call", nil, []int{0}, map[int]int{0: 1, 1000: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := NewFakeClock(date(2024, time.January, 1))
+			var calls callCounter
+			th := Throttle(calls.fn, 100*time.Millisecond, append(tt.opts, WithTimerClock(clock))...)
+
+			// Step a millisecond at a time, calling and checking on the way
+			next := 0
+			for ms := 0; ms <= 1000; ms++ {
+				if ms > 0 {
+					clock.Advance(time.Millisecond)
+				}
+				if next < len(tt.calls) && tt.calls[next] == ms {
+					th.Call()
+					next++
+				}
+				if want, ok := tt.checks[ms]; ok && calls.count() != want {
+					t.Errorf("at %dms: %d calls, want %d", ms, calls.count(), want)
+				}
+			}
+			if n := clock.pendingTimers(); n != 0 {
+				t.Errorf("%d timers left", n)
+			}
+		})
+	}
+}
+
+func TestThrottleFlushCancel(t *testing.T) {
+	clock := NewFakeClock(date(2024, time.January, 1))
+	var calls callCounter
+	th := Throttle(calls.fn, 100*time.Millisecond, WithTimerClock(clock))
+
+	th.Call()
+	th.Call()
+	if !th.Pending() {
+		t.Fatal("no trailing call pending")
+	}
+	th.Flush()
+	if calls.count() != 2 || clock.pendingTimers() != 0 {
+		t.Errorf("Flush: %d calls and %d timers, want 2 and 0", calls.count(), clock.pendingTimers())
+	}
+
+	th.Call()
+	th.Call()
+	th.Cancel()
+	clock.Advance(time.Second)
+	if calls.count() != 3 || clock.pendingTimers() != 0 {
+		t.Errorf("Cancel: %d calls and %d timers, want 3 and 0", calls.count(), clock.pendingTimers())
+	}
+}
+
+func TestDebounceThrottleConcurrent(t *testing.T) {
+	// Run with -race; real timers, many callers
+	var debounced, throttled callCounter
+	d := Debounce(debounced.fn, 5*time.Millisecond)
+	th := Throttle(throttled.fn, 5*time.Millisecond)
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				d.Call()
+				th.Call()
+			}
+		}()
+	}
+	wg.Wait()
+	d.Flush()
+	th.Flush()
+	if debounced.count() < 1 || throttled.count() < 1 {
+		t.Errorf("debounced %d, throttled %d: want both called", debounced.count(), throttled.count())
+	}
+	if d.Pending() || th.Pending() {
+		t.Error("calls still pending after Flush")
+	}
+}
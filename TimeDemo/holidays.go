@@ -0,0 +1,296 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Holiday is one entry in a HolidayCalendar: either a fixed Date or a Rule
+type Holiday struct {
+	Name string `json:"name"`
+	// Date is "MM-DD" for every year or "YYYY-MM-DD" for one year only
+	Date string `json:"date,omitempty"`
+	// Rule picks a weekday of a month: "third monday of january",
+	// "last monday of may"
+	Rule string `json:"rule,omitempty"`
+	// Observed moves a holiday falling on Saturday to the Friday before and
+	// one on Sunday to the Monday after, as US federal holidays are
+	Observed bool `json:"observed,omitempty"`
+	// From is the first year the holiday applies, 0 for always
+	From int `json:"from,omitempty"`
+}
+
+// HolidayDate is a holiday as taken in a particular year
+type HolidayDate struct {
+	Name string
+	Date time.Time // the day off, midnight UTC
+	// Moved is set when Date is the observed day rather than the holiday
+	Moved bool
+}
+
+// HolidayCalendar is a set of holidays that are not business days
+type HolidayCalendar struct {
+	Name  string
+	rules []holidayRule
+}
+
+// holidayRule is a validated Holiday
+type holidayRule struct {
+	Holiday
+	year    int // 0 for every year
+	month   time.Month
+	day     int // 0 for weekday rules
+	nth     int // 1 to 5, or -1 for last
+	weekday time.Weekday
+}
+
+// NewHolidayCalendar builds a calendar from holidays
+func NewHolidayCalendar(name string, holidays ...Holiday) (*HolidayCalendar, error) {
+	cal := &HolidayCalendar{Name: name}
+	for _, h := range holidays {
+		if err := cal.Add(h); err != nil {
+			return nil, err
+		}
+	}
+	return cal, nil
+}
+
+// Add adds a holiday to the calendar
+func (c *HolidayCalendar) Add(h Holiday) error {
+	rule, err := compileHoliday(h)
+	if err != nil {
+		return fmt.Errorf("holiday %q: %w", h.Name, err)
+	}
+	c.rules = append(c.rules, rule)
+	return nil
+}
+
+// holidayFile is the JSON form of a HolidayCalendar:
+//
+//	{"name": "Acme", "holidays": [{"name": "Christmas Day", "date": "12-25", "observed": true}]}
+type holidayFile struct {
+	Name     string    `json:"name"`
+	Holidays []Holiday `json:"holidays"`
+}
+
+// LoadHolidayCalendar reads a JSON calendar file
+func LoadHolidayCalendar(path string) (*HolidayCalendar, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cal, err := ParseHolidayCalendar(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cal, nil
+}
+
+// ParseHolidayCalendar parses a JSON calendar; unknown fields are errors
+// so that a misspelt "observed" is not silently ignored
+func ParseHolidayCalendar(data []byte) (*HolidayCalendar, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var file holidayFile
+	if err := dec.Decode(&file); err != nil {
+		return nil, fmt.Errorf("invalid holiday calendar: %w", err)
+	}
+	return NewHolidayCalendar(file.Name, file.Holidays...)
+}
+
+var ordinals = map[string]int{"first": 1, "second": 2, "third": 3, "fourth": 4, "fifth": 5, "last": -1}
+
+func compileHoliday(h Holiday) (holidayRule, error) {
+	rule := holidayRule{Holiday: h}
+	switch {
+	case h.Name == "":
+		return rule, fmt.Errorf("missing name")
+	case (h.Date == "") == (h.Rule == ""):
+		return rule, fmt.Errorf("needs exactly one of date or rule")
+	case h.Date != "":
+		var d time.Time
+		var err error
+		if strings.Count(h.Date, "-") == 2 {
+			d, err = time.Parse("2006-01-02", h.Date)
+			rule.year = d.Year()
+		} else {
+			// Parse in a leap year so that "02-29" is accepted
+			d, err = time.Parse("2006-01-02", "2024-"+h.Date)
+		}
+		if err != nil {
+			return rule, fmt.Errorf("invalid date %q (use MM-DD or YYYY-MM-DD)", h.Date)
+		}
+		rule.month, rule.day = d.Month(), d.Day()
+		return rule, nil
+	}
+
+	words := strings.Fields(strings.ToLower(h.Rule))
+	if len(words) != 4 || words[2] != "of" {
+		return rule, fmt.Errorf("invalid rule %q (use e.g. \"third monday of january\")", h.Rule)
+	}
+	nth, ok := ordinals[words[0]]
+	if !ok {
+		return rule, fmt.Errorf("invalid rule %q: unknown ordinal %q (use first to fifth or last)", h.Rule, words[0])
+	}
+	weekday, ok := weekdayNames[words[1]]
+	if !ok {
+		return rule, fmt.Errorf("invalid rule %q: unknown weekday %q", h.Rule, words[1])
+	}
+	month, err := time.Parse("January", strings.ToUpper(words[3][:1])+words[3][1:])
+	if err != nil {
+		return rule, fmt.Errorf("invalid rule %q: unknown month %q", h.Rule, words[3])
+	}
+	rule.nth, rule.weekday, rule.month = nth, weekday, month.Month()
+	return rule, nil
+}
+
+// date returns the holiday itself in year, before any observed move
+func (r holidayRule) date(year int) (time.Time, bool) {
+	if (r.year != 0 && r.year != year) || year < r.From {
+		return time.Time{}, false
+	}
+	if r.day != 0 {
+		d := time.Date(year, r.month, r.day, 0, 0, 0, 0, time.UTC)
+		return d, d.Month() == r.month // no February 29th in other years
+	}
+
+	if r.nth < 0 {
+		last := time.Date(year, r.month+1, 0, 0, 0, 0, 0, time.UTC)
+		return last.AddDate(0, 0, -((int(last.Weekday()) - int(r.weekday) + 7) % 7)), true
+	}
+	first := time.Date(year, r.month, 1, 0, 0, 0, 0, time.UTC)
+	d := first.AddDate(0, 0, (int(r.weekday)-int(first.Weekday())+7)%7+7*(r.nth-1))
+	return d, d.Month() == r.month // there may be no fifth Monday
+}
+
+// HolidaysIn returns the days off taken in year, in date order. An observed
+// holiday can move across the year boundary: New Year's Day 2022 was a
+// Saturday, so it was taken on December 31st, 2021.
+func (c *HolidayCalendar) HolidaysIn(year int) []HolidayDate {
+	var days []HolidayDate
+	for _, r := range c.rules {
+		for y := year - 1; y <= year+1; y++ {
+			d, ok := r.date(y)
+			if !ok {
+				continue
+			}
+			moved := false
+			if r.Observed {
+				switch d.Weekday() {
+				case time.Saturday:
+					d, moved = d.AddDate(0, 0, -1), true
+				case time.Sunday:
+					d, moved = d.AddDate(0, 0, 1), true
+				}
+			}
+			if d.Year() == year {
+				days = append(days, HolidayDate{Name: r.Name, Date: d, Moved: moved})
+			}
+		}
+	}
+	sort.SliceStable(days, func(i, j int) bool { return days[i].Date.Before(days[j].Date) })
+	return days
+}
+
+// Holiday returns the holiday taken on t's date, if any
+func (c *HolidayCalendar) Holiday(t time.Time) (HolidayDate, bool) {
+	date := civilDate(t)
+	for _, h := range c.HolidaysIn(t.Year()) {
+		if h.Date.Equal(date) {
+			return h, true
+		}
+	}
+	return HolidayDate{}, false
+}
+
+// civilDate returns t's calendar date as midnight UTC
+func civilDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// String formats a holiday as "Mon 2021-12-31 New Year's Day (observed)"
+func (h HolidayDate) String() string {
+	s := h.Date.Format("Mon 2006-01-02 ") + h.Name
+	if h.Moved {
+		s += " (observed)"
+	}
+	return s
+}
+
+// usFederalHolidays are the US federal holidays of 5 U.S.C. 6103
+var usFederalHolidays = []Holiday{
+	{Name: "New Year's Day", Date: "01-01", Observed: true},
+	{Name: "Martin Luther King Jr. Day", Rule: "third monday of january"},
+	{Name: "Washington's Birthday", Rule: "third monday of february"},
+	{Name: "Memorial Day", Rule: "last monday of may"},
+	{Name: "Juneteenth", Date: "06-19", Observed: true, From: 2021},
+	{Name: "Independence Day", Date: "07-04", Observed: true},
+	{Name: "Labor Day", Rule: "first monday of september"},
+	{Name: "Columbus Day", Rule: "second monday of october"},
+	{Name: "Veterans Day", Date: "11-11", Observed: true},
+	{Name: "Thanksgiving Day", Rule: "fourth thursday of november"},
+	{Name: "Christmas Day", Date: "12-25", Observed: true},
+}
+
+// USFederalHolidays returns the built-in US federal holiday calendar
+func USFederalHolidays() *HolidayCalendar {
+	cal, err := NewHolidayCalendar("US federal", usFederalHolidays...)
+	if err != nil {
+		panic(err) // the list above is fixed and covered by tests
+	}
+	return cal
+}
+
+// IsBusinessDay reports whether t's date is a weekday that is not a
+// holiday in cal; a nil cal has no holidays
+func IsBusinessDay(t time.Time, cal *HolidayCalendar) bool {
+	if isWeekend(t) {
+		return false
+	}
+	if cal == nil {
+		return true
+	}
+	_, holiday := cal.Holiday(t)
+	return !holiday
+}
+
+// NextBusinessDay returns the first business day after t's date, at t's
+// time of day
+func NextBusinessDay(t time.Time, cal *HolidayCalendar) time.Time {
+	return AddBusinessDays(t, 1, cal)
+}
+
+// AddBusinessDays moves t by n business days, backwards when n is negative,
+// keeping its time of day. The starting day itself is not counted, so
+// adding 1 on a Friday gives Monday; n == 0 returns t.
+func AddBusinessDays(t time.Time, n int, cal *HolidayCalendar) time.Time {
+	step := 1
+	if n < 0 {
+		step, n = -1, -n
+	}
+	for n > 0 {
+		t = t.AddDate(0, 0, step)
+		if IsBusinessDay(t, cal) {
+			n--
+		}
+	}
+	return t
+}
+
+// BusinessDaysBetween counts the business days from start's date to end's,
+// both included; 0 when end is before start
+func BusinessDaysBetween(start, end time.Time, cal *HolidayCalendar) int {
+	count := 0
+	last := civilDate(end)
+	for d := civilDate(start); !d.After(last); d = d.AddDate(0, 0, 1) {
+		if IsBusinessDay(d, cal) {
+			count++
+		}
+	}
+	return count
+}
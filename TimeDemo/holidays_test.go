@@ -0,0 +1,237 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestUSFederalHolidays(t *testing.T) {
+	federal := USFederalHolidays()
+
+	tests := []struct {
+		year int
+		want []string
+	}{
+		{2024, []string{
+			"Mon 2024-01-01 New Year's Day",
+			"Mon 2024-01-15 Martin Luther King Jr. Day",
+			"Mon 2024-02-19 Washington's Birthday",
+			"Mon 2024-05-27 Memorial Day",
+			"Wed 2024-06-19 Juneteenth",
+			"Thu 2024-07-04 Independence Day",
+			"Mon 2024-09-02 Labor Day",
+			"Mon 2024-10-14 Columbus Day",
+			"Mon 2024-11-11 Veterans Day",
+			"Thu 2024-11-28 Thanksgiving Day",
+			"Wed 2024-12-25 Christmas Day",
+		}},
+		// Saturday holidays move to Friday, including New Year's Day 2022
+		// into 2021
+		{2021, []string{
+			"Fri 2021-01-01 New Year's Day",
+			"Mon 2021-01-18 Martin Luther King Jr. Day",
+			"Mon 2021-02-15 Washington's Birthday",
+			"Mon 2021-05-31 Memorial Day",
+			"Fri 2021-06-18 Juneteenth (observed)",
+			"Mon 2021-07-05 Independence Day (observed)",
+			"Mon 2021-09-06 Labor Day",
+			"Mon 2021-10-11 Columbus Day",
+			"Thu 2021-11-11 Veterans Day",
+			"Thu 2021-11-25 Thanksgiving Day",
+			"Fri 2021-12-24 Christmas Day (observed)",
+			"Fri 2021-12-31 New Year's Day (observed)",
+		}},
+		// ...so 2022 has no New Year's Day; Sunday holidays move to Monday
+		{2022, []string{
+			"Mon 2022-01-17 Martin Luther King Jr. Day",
+			"Mon 2022-02-21 Washington's Birthday",
+			"Mon 2022-05-30 Memorial Day",
+			"Mon 2022-06-20 Juneteenth (observed)",
+			"Mon 2022-07-04 Independence Day",
+			"Mon 2022-09-05 Labor Day",
+			"Mon 2022-10-10 Columbus Day",
+			"Fri 2022-11-11 Veterans Day",
+			"Thu 2022-11-24 Thanksgiving Day",
+			"Mon 2022-12-26 Christmas Day (observed)",
+		}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, h := range federal.HolidaysIn(tt.year) {
+			got = append(got, h.String())
+		}
+		if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("HolidaysIn(%d) =\n%s\nwant\n%s", tt.year, strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+		}
+	}
+
+	// Juneteenth became a federal holiday in 2021
+	if h, ok := federal.Holiday(date(2020, time.June, 19)); ok {
+		t.Errorf("2020-06-19 is %s, want no holiday", h)
+	}
+}
+
+func TestIsBusinessDay(t *testing.T) {
+	federal := USFederalHolidays()
+	tokyo := mustZone(t, "Asia/Tokyo")
+
+	tests := []struct {
+		name string
+		t    time.Time
+		cal  *HolidayCalendar
+		want bool
+	}{
+		{"weekday", date(2024, time.July, 3), federal, true},
+		{"holiday", date(2024, time.July, 4), federal, false},
+		{"holiday without a calendar", date(2024, time.July, 4), nil, true},
+		{"saturday", date(2024, time.July, 6), federal, false},
+		{"observed friday", date(2021, time.December, 31), federal, false},
+		{"holiday itself on saturday", date(2022, time.January, 1), federal, false},
+		{"observed monday", date(2022, time.December, 26), federal, false},
+		// The date in t's own location counts: this is July 4th in Tokyo
+		{"date in t's location", time.Date(2024, time.July, 4, 8, 0, 0, 0, tokyo), federal, false},
+	}
+	for _, tt := range tests {
+		if got := IsBusinessDay(tt.t, tt.cal); got != tt.want {
+			t.Errorf("%s: IsBusinessDay(%s) = %v, want %v", tt.name, tt.t.Format("Mon 2006-01-02"), got, tt.want)
+		}
+	}
+}
+
+func TestAddBusinessDays(t *testing.T) {
+	federal := USFederalHolidays()
+
+	tests := []struct {
+		name  string
+		start time.Time
+		n     int
+		cal   *HolidayCalendar
+		want  time.Time
+	}{
+		{"zero", date(2024, time.July, 6), 0, federal, date(2024, time.July, 6)},
+		{"over a weekend", date(2024, time.July, 5), 1, federal, date(2024, time.July, 8)},
+		{"over a holiday", date(2024, time.July, 3), 1, federal, date(2024, time.July, 5)},
+		{"from a weekend", date(2024, time.July, 6), 1, federal, date(2024, time.July, 8)},
+		{"two weeks", date(2024, time.November, 1), 10, federal, date(2024, time.November, 18)},
+		{"negative", date(2024, time.July, 8), -1, federal, date(2024, time.July, 5)},
+		{"negative over a holiday", date(2024, time.July, 5), -1, federal, date(2024, time.July, 3)},
+		{"negative from a weekend", date(2024, time.July, 7), -2, federal, date(2024, time.July, 3)},
+		{"into the new year", date(2021, time.December, 30), 1, federal, date(2022, time.January, 3)},
+		{"into the new year without holidays", date(2021, time.December, 30), 1, nil, date(2021, time.December, 31)},
+		{"back into the old year", date(2022, time.January, 3), -1, federal, date(2021, time.December, 30)},
+		{"back across Christmas and New Year", date(2023, time.January, 3), -5, federal, date(2022, time.December, 23)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AddBusinessDays(tt.start, tt.n, tt.cal); !got.Equal(tt.want) {
+				t.Errorf("AddBusinessDays(%s, %d) = %s, want %s", tt.start.Format("Mon 2006-01-02"), tt.n,
+					got.Format("Mon 2006-01-02"), tt.want.Format("Mon 2006-01-02"))
+			}
+		})
+	}
+
+	// The time of day is kept
+	start := time.Date(2024, time.July, 3, 17, 30, 0, 0, time.UTC)
+	if got := NextBusinessDay(start, federal); !got.Equal(time.Date(2024, time.July, 5, 17, 30, 0, 0, time.UTC)) {
+		t.Errorf("NextBusinessDay = %s", got)
+	}
+}
+
+func TestBusinessDaysBetween(t *testing.T) {
+	federal := USFederalHolidays()
+
+	tests := []struct {
+		name       string
+		start, end time.Time
+		cal        *HolidayCalendar
+		want       int
+	}{
+		{"first half of January", date(2024, time.January, 1), date(2024, time.January, 15), nil, 11},
+		{"with holidays", date(2024, time.January, 1), date(2024, time.January, 15), federal, 9},
+		{"whole year", date(2024, time.January, 1), date(2024, time.December, 31), nil, 262},
+		{"whole year with holidays", date(2024, time.January, 1), date(2024, time.December, 31), federal, 251},
+		{"across the year boundary", date(2021, time.December, 20), date(2022, time.January, 7), federal, 13},
+		{"single holiday", date(2024, time.July, 4), date(2024, time.July, 4), federal, 0},
+		{"single day", date(2024, time.July, 5), date(2024, time.July, 5), federal, 1},
+		{"end before start", date(2024, time.July, 10), date(2024, time.July, 1), federal, 0},
+		{"times of day ignored", time.Date(2024, time.July, 1, 23, 0, 0, 0, time.UTC), time.Date(2024, time.July, 2, 1, 0, 0, 0, time.UTC), federal, 2},
+	}
+	for _, tt := range tests {
+		if got := BusinessDaysBetween(tt.start, tt.end, tt.cal); got != tt.want {
+			t.Errorf("%s: got %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLoadHolidayCalendar(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "acme.json")
+	data := `{
+		"name": "Acme",
+		"holidays": [
+			{"name": "Christmas Eve", "date": "12-24", "observed": true},
+			{"name": "Leap Day", "date": "02-29"},
+			{"name": "Founders' Day", "rule": "Last Friday of June"},
+			{"name": "Office move", "date": "2024-08-16"}
+		]
+	}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cal, err := LoadHolidayCalendar(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cal.Name != "Acme" {
+		t.Errorf("Name = %q", cal.Name)
+	}
+	for year, want := range map[int]string{
+		2024: "Thu 2024-02-29 Leap Day, Fri 2024-06-28 Founders' Day, Fri 2024-08-16 Office move, Tue 2024-12-24 Christmas Eve",
+		2022: "Fri 2022-06-24 Founders' Day, Fri 2022-12-23 Christmas Eve (observed)",
+	} {
+		var got []string
+		for _, h := range cal.HolidaysIn(year) {
+			got = append(got, h.String())
+		}
+		if strings.Join(got, ", ") != want {
+			t.Errorf("HolidaysIn(%d) = %s, want %s", year, strings.Join(got, ", "), want)
+		}
+	}
+
+	if _, err := LoadHolidayCalendar(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("missing file loaded")
+	}
+}
+
+func TestHolidayCalendarErrors(t *testing.T) {
+	tests := []struct {
+		json    string
+		wantErr string
+	}{
+		{`{"holidays": [{"name": "X", "date": "13-01"}]}`, `holiday "X": invalid date "13-01"`},
+		{`{"holidays": [{"name": "X", "date": "2023-02-29"}]}`, `invalid date "2023-02-29"`},
+		{`{"holidays": [{"name": "X", "rule": "third monday in january"}]}`, `invalid rule "third monday in january"`},
+		{`{"holidays": [{"name": "X", "rule": "sixth monday of january"}]}`, `unknown ordinal "sixth"`},
+		{`{"holidays": [{"name": "X", "rule": "third moonday of january"}]}`, `unknown weekday "moonday"`},
+		{`{"holidays": [{"name": "X", "rule": "third monday of janvier"}]}`, `unknown month "janvier"`},
+		{`{"holidays": [{"name": "X"}]}`, "needs exactly one of date or rule"},
+		{`{"holidays": [{"name": "X", "date": "01-01", "rule": "first monday of may"}]}`, "needs exactly one of date or rule"},
+		{`{"holidays": [{"date": "01-01"}]}`, "missing name"},
+		{`{"holidays": [{"name": "X", "date": "01-01", "observd": true}]}`, `unknown field "observd"`},
+		{`{"holidays": [`, "invalid holiday calendar"},
+	}
+	for _, tt := range tests {
+		_, err := ParseHolidayCalendar([]byte(tt.json))
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("ParseHolidayCalendar(%s): %v, want %q", tt.json, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Units longer than an hour; months and years are approximate, and
+// Humanize says "about" when it uses them
+const (
+	daySize   = 24 * time.Hour
+	weekSize  = 7 * daySize
+	monthSize = 30 * daySize
+	yearSize  = 365 * daySize
+)
+
+// humanUnit is a unit Humanizer can print, largest first
+type humanUnit struct {
+	size        time.Duration
+	name, short string
+}
+
+var humanUnits = []humanUnit{
+	{yearSize, "year", "y"},
+	{monthSize, "month", "mo"},
+	{weekSize, "week", "w"},
+	{daySize, "day", "d"},
+	{time.Hour, "hour", "h"},
+	{time.Minute, "minute", "m"},
+	{time.Second, "second", "s"},
+	{time.Millisecond, "millisecond", "ms"},
+}
+
+// Humanizer formats durations for people. The zero value gives one unit
+// down to seconds in words: "2 hours", "about a month".
+type Humanizer struct {
+	// Precision is the number of adjacent units shown, from the largest
+	// one present: 2 gives "1 hour 23 minutes". Default 1.
+	Precision int
+	// Granularity is the smallest unit shown, such as time.Minute.
+	// Default time.Second.
+	Granularity time.Duration
+	// Short writes "1h 23m" instead of "1 hour 23 minutes"
+	Short bool
+	// Exact writes d exactly, for ParseHuman to read back: weeks are the
+	// largest unit, the seconds carry any fraction, under a second is
+	// written as time.Duration does, and the sign is kept: "1w 2d 3h",
+	// "-1h 30m 0.5s", "1.5ms". Granularity is ignored, and Precision
+	// defaults to every unit; a smaller one rounds down.
+	Exact bool
+	// ZeroUnits writes zero units between non-zero ones: "1w 0d 3h"
+	ZeroUnits bool
+}
+
+// Humanize formats d as a single unit, rounded down: "59 minutes",
+// "1 hour", "3 days", "about a month". The sign is ignored.
+func Humanize(d time.Duration) string {
+	return Humanizer{}.Duration(d)
+}
+
+// FormatDuration writes d exactly with weeks and days, which
+// time.Duration's own String does not use: 72h0m0s is "3d", and 1h30m0.5s
+// is "1h 30m 0.5s". ParseHuman reads it back.
+func FormatDuration(d time.Duration) string {
+	return Humanizer{Exact: true, Short: true}.Duration(d)
+}
+
+// RelativeTime describes t from now: "5 minutes ago", "in 3 days"
+func RelativeTime(t, now time.Time) string {
+	return Humanizer{}.Relative(t, now)
+}
+
+// Duration formats d, rounded down to the units shown
+func (h Humanizer) Duration(d time.Duration) string {
+	// Work in uint64 so that math.MinInt64 has a magnitude
+	rest := uint64(d)
+	sign := ""
+	if d < 0 {
+		rest = -rest
+		if h.Exact {
+			sign = "-"
+		}
+	}
+	precision := max(h.Precision, 1)
+	granularity := h.Granularity
+	if granularity <= 0 {
+		granularity = time.Second
+	}
+	if h.Exact {
+		if rest < uint64(time.Second) {
+			return sign + time.Duration(rest).String()
+		}
+		if h.Precision <= 0 {
+			precision = len(humanUnits)
+		}
+		granularity = time.Second
+	}
+
+	var parts, zeros []string
+	approximate := false
+	smallest := humanUnits[0]
+	for _, u := range humanUnits {
+		if u.size < granularity {
+			break
+		}
+		if h.Exact && u.size > weekSize {
+			continue
+		}
+		smallest = u
+		n := rest / uint64(u.size)
+		if n == 0 && len(parts) == 0 {
+			continue
+		}
+		rest -= n * uint64(u.size)
+		value := strconv.FormatUint(n, 10)
+		if h.Exact && u.size == time.Second && rest > 0 {
+			// The rest as a fraction of a second, without trailing zeros
+			value += strings.TrimRight(fmt.Sprintf(".%09d", rest), "0")
+		}
+		if len(parts) == 0 {
+			approximate = u.size >= monthSize
+		}
+		switch {
+		case value != "0":
+			parts = append(append(parts, zeros...), h.unit(value, u, approximate && precision == 1))
+			zeros = nil
+		case h.ZeroUnits:
+			// Held back until a non-zero unit follows
+			zeros = append(zeros, h.unit(value, u, false))
+		}
+		// Empty units still use up precision: 1 day 5 minutes at
+		// precision 2 is "1 day"
+		if precision--; precision == 0 {
+			break
+		}
+	}
+
+	if len(parts) == 0 {
+		return h.unit("0", smallest, false)
+	}
+	s := sign + strings.Join(parts, " ")
+	if approximate && !h.Short {
+		s = "about " + s
+	}
+	return s
+}
+
+// unit formats n of u: "1 hour", "2 hours", "1.5 seconds", "1h", or "a
+// month" when article is set
+func (h Humanizer) unit(n string, u humanUnit, article bool) string {
+	switch {
+	case h.Short:
+		return n + u.short
+	case n == "1" && article:
+		return "a " + u.name
+	case n == "1":
+		return "1 " + u.name
+	}
+	return n + " " + u.name + "s"
+}
+
+// Relative describes t from now; differences below the granularity are
+// "just now"
+func (h Humanizer) Relative(t, now time.Time) string {
+	diff := t.Sub(now)
+	granularity := h.Granularity
+	if granularity <= 0 {
+		granularity = time.Second
+	}
+	switch {
+	case diff > -granularity && diff < granularity:
+		return "just now"
+	case diff < 0:
+		return h.Duration(diff) + " ago"
+	}
+	return "in " + h.Duration(diff)
+}
+
+// humanParseUnits are the units ParseHuman accepts
+var humanParseUnits = map[string]time.Duration{
+	"ns": time.Nanosecond, "nanosecond": time.Nanosecond,
+	"us": time.Microsecond, "µs": time.Microsecond, "microsecond": time.Microsecond,
+	"ms": time.Millisecond, "millisecond": time.Millisecond,
+	"s": time.Second, "sec": time.Second, "second": time.Second,
+	"m": time.Minute, "min": time.Minute, "minute": time.Minute,
+	"h": time.Hour, "hr": time.Hour, "hour": time.Hour,
+	"d": daySize, "day": daySize,
+	"w": weekSize, "wk": weekSize, "week": weekSize,
+}
+
+// ParseHuman parses durations as people write them: "1 day 4 hours",
+// "2 weeks, 3 days", "1h 30m", "1.5 hours", "an hour and 10 minutes",
+// "-1w 2d". Unlike time.ParseDuration it accepts days and weeks; months
+// and years vary in length and are refused. It reads back what an Exact
+// Humanizer writes to the nanosecond.
+func ParseHuman(s string) (time.Duration, error) {
+	input := s
+	s = strings.ToLower(strings.TrimSpace(s))
+	neg := false
+	if rest, ok := strings.CutPrefix(s, "-"); ok {
+		neg, s = true, rest
+	} else {
+		s = strings.TrimPrefix(s, "+")
+	}
+	if s == "" {
+		return 0, fmt.Errorf("empty duration")
+	}
+
+	// In nanoseconds, up to 1<<63 for math.MinInt64
+	var total uint64
+	for s != "" {
+		s = strings.TrimLeft(s, " ,")
+		if s == "" {
+			break
+		}
+		if rest, ok := strings.CutPrefix(s, "and "); ok {
+			s = rest
+			continue
+		}
+
+		// Number, or "a"/"an" for one
+		var number string
+		end := strings.IndexFunc(s, func(r rune) bool { return !unicode.IsDigit(r) && r != '.' })
+		if end == -1 {
+			end = len(s)
+		}
+		switch {
+		case end > 0:
+			if _, err := strconv.ParseFloat(s[:end], 64); err != nil {
+				return 0, fmt.Errorf("duration %q: invalid number %q", input, s[:end])
+			}
+			number, s = s[:end], s[end:]
+		case strings.HasPrefix(s, "an "):
+			number, s = "1", s[3:]
+		case strings.HasPrefix(s, "a "):
+			number, s = "1", s[2:]
+		default:
+			return 0, fmt.Errorf("duration %q: expected a number at %q", input, s)
+		}
+
+		// Unit, with or without a space and a plural s
+		s = strings.TrimLeft(s, " ")
+		end = strings.IndexFunc(s, func(r rune) bool { return !unicode.IsLetter(r) })
+		if end == -1 {
+			end = len(s)
+		}
+		word := s[:end]
+		s = s[end:]
+		if word == "" {
+			return 0, fmt.Errorf("duration %q: missing unit after %s", input, number)
+		}
+		size, ok := humanParseUnits[word]
+		if !ok && len(word) > 1 {
+			size, ok = humanParseUnits[strings.TrimSuffix(word, "s")]
+		}
+		if !ok {
+			switch strings.TrimSuffix(word, "s") {
+			case "month", "mo", "year", "yr", "y":
+				return 0, fmt.Errorf("duration %q: months and years vary in length; use days or weeks", input)
+			}
+			return 0, fmt.Errorf("duration %q: unknown unit %q", input, word)
+		}
+
+		n, ok := scaleDecimal(number, size)
+		if !ok || n > 1<<63-total {
+			return 0, fmt.Errorf("duration %q is too long", input)
+		}
+		total += n
+	}
+
+	if neg {
+		return time.Duration(-total), nil
+	}
+	if total == 1<<63 {
+		return 0, fmt.Errorf("duration %q is too long", input)
+	}
+	return time.Duration(total), nil
+}
+
+// scaleDecimal is number, a decimal such as "1.5", times size in
+// nanoseconds, rounded down to a nanosecond. It reports false when that
+// is more than 1<<63.
+func scaleDecimal(number string, size time.Duration) (uint64, bool) {
+	whole, frac, _ := strings.Cut(number, ".")
+	var n uint64
+	if whole != "" {
+		w, err := strconv.ParseUint(whole, 10, 64)
+		if err != nil || w > (1<<63)/uint64(size) {
+			return 0, false
+		}
+		n = w * uint64(size)
+	}
+	// Each digit is worth a tenth of the one before; the units are whole
+	// nanoseconds, so this is exact to the nanosecond
+	scale := uint64(size)
+	for _, digit := range frac {
+		scale /= 10
+		n += uint64(digit-'0') * scale
+	}
+	return n, true
+}
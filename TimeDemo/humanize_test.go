@@ -0,0 +1,247 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHumanize(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "0 seconds"},
+		{999 * time.Millisecond, "0 seconds"},
+		{time.Second, "1 second"},
+		{2 * time.Second, "2 seconds"},
+		{59 * time.Second, "59 seconds"},
+		{time.Minute, "1 minute"},
+		{59*time.Minute + 59*time.Second, "59 minutes"},
+		{time.Hour, "1 hour"},
+		{2 * time.Hour, "2 hours"},
+		{23*time.Hour + 59*time.Minute, "23 hours"},
+		{daySize, "1 day"},
+		{3 * daySize, "3 days"},
+		{6*daySize + 23*time.Hour, "6 days"},
+		{weekSize, "1 week"},
+		{29 * daySize, "4 weeks"},
+		{30 * daySize, "about a month"},
+		{59 * daySize, "about a month"},
+		{60 * daySize, "about 2 months"},
+		{364 * daySize, "about 12 months"},
+		{365 * daySize, "about a year"},
+		{3 * 365 * daySize, "about 3 years"},
+		{-2 * time.Hour, "2 hours"},
+		{math.MinInt64, "about 292 years"},
+	}
+	for _, tt := range tests {
+		if got := Humanize(tt.d); got != tt.want {
+			t.Errorf("Humanize(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestHumanizerOptions(t *testing.T) {
+	d := daySize + 4*time.Hour + 23*time.Minute + 45*time.Second
+
+	tests := []struct {
+		name string
+		h    Humanizer
+		d    time.Duration
+		want string
+	}{
+		{"two units", Humanizer{Precision: 2}, d, "1 day 4 hours"},
+		{"three units", Humanizer{Precision: 3}, d, "1 day 4 hours 23 minutes"},
+		{"all units", Humanizer{Precision: 10}, d, "1 day 4 hours 23 minutes 45 seconds"},
+		{"short", Humanizer{Precision: 2, Short: true}, 83 * time.Minute, "1h 23m"},
+		{"short single", Humanizer{Short: true}, 83 * time.Minute, "1h"},
+		{"short zero", Humanizer{Short: true}, 0, "0s"},
+		{"skipped unit uses up precision", Humanizer{Precision: 2}, daySize + 5*time.Minute, "1 day"},
+		{"skipped unit in the middle", Humanizer{Precision: 3}, daySize + 5*time.Minute, "1 day 5 minutes"},
+		{"zero tail", Humanizer{Precision: 2}, 2 * time.Hour, "2 hours"},
+		{"approximate two units", Humanizer{Precision: 2}, 45 * daySize, "about 1 month 2 weeks"},
+		{"granularity stops early", Humanizer{Precision: 3, Granularity: time.Hour}, d, "1 day 4 hours"},
+		{"below granularity", Humanizer{Granularity: time.Minute}, 59 * time.Second, "0 minutes"},
+		{"granularity at the boundary", Humanizer{Granularity: time.Hour}, 59 * time.Minute, "0 hours"},
+		{"milliseconds", Humanizer{Precision: 2, Granularity: time.Millisecond}, 1500 * time.Millisecond, "1 second 500 milliseconds"},
+		{"day granularity", Humanizer{Granularity: daySize}, 23 * time.Hour, "0 days"},
+	}
+	for _, tt := range tests {
+		if got := tt.h.Duration(tt.d); got != tt.want {
+			t.Errorf("%s: %+v.Duration(%v) = %q, want %q", tt.name, tt.h, tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestHumanizerExact(t *testing.T) {
+	exact := Humanizer{Exact: true, Short: true}
+	tests := []struct {
+		h    Humanizer
+		d    time.Duration
+		want string
+	}{
+		{exact, 0, "0s"},
+		{exact, 72 * time.Hour, "3d"},
+		{exact, weekSize + 2*daySize + 3*time.Hour, "1w 2d 3h"},
+		// The week/day boundary, and no months or years
+		{exact, 6*daySize + 23*time.Hour + 59*time.Minute + 59*time.Second, "6d 23h 59m 59s"},
+		{exact, weekSize + time.Second, "1w 1s"},
+		{exact, 60 * daySize, "8w 4d"},
+		// Sub-second components
+		{exact, time.Hour + 30*time.Minute + 500*time.Millisecond, "1h 30m 0.5s"},
+		{exact, 1500 * time.Microsecond, "1.5ms"},
+		{exact, 999 * time.Nanosecond, "999ns"},
+		// The sign is kept
+		{exact, -90 * time.Minute, "-1h 30m"},
+		{exact, -time.Millisecond, "-1ms"},
+		{exact, math.MinInt64, "-15250w 1d 23h 47m 16.854775808s"},
+		{exact, math.MaxInt64, "15250w 1d 23h 47m 16.854775807s"},
+		// Precision rounds down
+		{Humanizer{Exact: true, Short: true, Precision: 2}, weekSize + 2*daySize + 3*time.Hour, "1w 2d"},
+		{Humanizer{Exact: true, Short: true, Precision: 2}, weekSize + 3*time.Hour, "1w"},
+		{Humanizer{Exact: true, Short: true, Precision: 1}, 2*time.Second + 500*time.Millisecond, "2.5s"},
+		// Zero units between non-zero ones
+		{Humanizer{Exact: true, Short: true, ZeroUnits: true}, weekSize + 3*time.Hour, "1w 0d 3h"},
+		{Humanizer{Exact: true, Short: true, ZeroUnits: true}, daySize, "1d"},
+		{Humanizer{Exact: true, ZeroUnits: true}, weekSize + 90*time.Second, "1 week 0 days 0 hours 1 minute 30 seconds"},
+	}
+	for _, tt := range tests {
+		if got := tt.h.Duration(tt.d); got != tt.want {
+			t.Errorf("%+v.Duration(%v) = %q, want %q", tt.h, tt.d, got, tt.want)
+		}
+	}
+	if got := FormatDuration(36 * time.Hour); got != "1d 12h" {
+		t.Errorf("FormatDuration(36h) = %q", got)
+	}
+}
+
+func TestExactRoundTrip(t *testing.T) {
+	for _, d := range []time.Duration{
+		0,
+		time.Nanosecond,
+		-time.Nanosecond,
+		999 * time.Microsecond,
+		time.Second,
+		59*time.Minute + 59*time.Second + 999*time.Millisecond,
+		daySize - time.Nanosecond,
+		weekSize - time.Nanosecond,
+		weekSize + daySize + time.Hour + time.Minute + time.Second + time.Millisecond + time.Microsecond + time.Nanosecond,
+		-(3*weekSize + 4*time.Hour + 250*time.Millisecond),
+		math.MaxInt64,
+		math.MinInt64,
+	} {
+		for _, h := range []Humanizer{{Exact: true, Short: true}, {Exact: true}, {Exact: true, Short: true, ZeroUnits: true}} {
+			s := h.Duration(d)
+			if got, err := ParseHuman(s); err != nil || got != d {
+				t.Errorf("%d: %+v gives %q, which parses to %d, %v", d, h, s, got, err)
+			}
+		}
+	}
+}
+
+func TestRelativeTime(t *testing.T) {
+	now := time.Date(2024, time.May, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		h    Humanizer
+		t    time.Time
+		want string
+	}{
+		{Humanizer{}, now, "just now"},
+		{Humanizer{}, now.Add(-999 * time.Millisecond), "just now"},
+		{Humanizer{}, now.Add(-time.Second), "1 second ago"},
+		{Humanizer{}, now.Add(-5 * time.Minute), "5 minutes ago"},
+		{Humanizer{}, now.Add(59 * time.Minute), "in 59 minutes"},
+		{Humanizer{}, now.Add(time.Hour), "in 1 hour"},
+		{Humanizer{}, now.Add(3 * daySize), "in 3 days"},
+		{Humanizer{}, now.Add(-40 * daySize), "about a month ago"},
+		{Humanizer{Precision: 2, Short: true}, now.Add(-83 * time.Minute), "1h 23m ago"},
+		{Humanizer{Granularity: time.Minute}, now.Add(59 * time.Second), "just now"},
+		{Humanizer{Granularity: time.Minute}, now.Add(time.Minute), "in 1 minute"},
+	}
+	for _, tt := range tests {
+		if got := tt.h.Relative(tt.t, now); got != tt.want {
+			t.Errorf("%+v.Relative(now%+v) = %q, want %q", tt.h, tt.t.Sub(now), got, tt.want)
+		}
+	}
+	if got := RelativeTime(now.Add(-2*daySize), now); got != "2 days ago" {
+		t.Errorf("RelativeTime = %q, want %q", got, "2 days ago")
+	}
+}
+
+func TestParseHuman(t *testing.T) {
+	tests := []struct {
+		input string
+		want  time.Duration
+	}{
+		{"1 day 4 hours", 28 * time.Hour},
+		{"1 day, 4 hours", 28 * time.Hour},
+		{"1 day and 4 hours", 28 * time.Hour},
+		{"2 weeks", 14 * daySize},
+		{"1 week 2 days", 9 * daySize},
+		{"1d4h", 28 * time.Hour},
+		{"1h 23m", 83 * time.Minute},
+		{"2h30m15s", 2*time.Hour + 30*time.Minute + 15*time.Second},
+		{"1.5 hours", 90 * time.Minute},
+		{"an hour and 10 minutes", 70 * time.Minute},
+		{"a day", daySize},
+		{"90 secs", 90 * time.Second},
+		{"3 hrs", 3 * time.Hour},
+		{"250ms", 250 * time.Millisecond},
+		{"10 µs", 10 * time.Microsecond},
+		{"  1 Day 4 HOURS  ", 28 * time.Hour},
+		{"0 seconds", 0},
+		{"-1.5d", -36 * time.Hour},
+		{"+90m", 90 * time.Minute},
+		{"1s500ms", 1500 * time.Millisecond},
+		{"2.000000001s", 2*time.Second + time.Nanosecond},
+		{"1µs 1us 1ns", 2001},
+	}
+	for _, tt := range tests {
+		got, err := ParseHuman(tt.input)
+		if err != nil {
+			t.Errorf("ParseHuman(%q): %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseHuman(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+
+	// Humanize's multi-unit output parses back
+	d := 9*daySize + 4*time.Hour + 5*time.Minute + 6*time.Second
+	for _, h := range []Humanizer{{Precision: 5}, {Precision: 5, Short: true}} {
+		if got, err := ParseHuman(h.Duration(d)); err != nil || got != d {
+			t.Errorf("ParseHuman(%q) = %v, %v; want %v", h.Duration(d), got, err, d)
+		}
+	}
+}
+
+func TestParseHumanErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		wantErr string
+	}{
+		{"", "empty duration"},
+		{"4", "missing unit"},
+		{"hours", "expected a number"},
+		{"1 hour -5 minutes", "expected a number"},
+		{"-", "empty duration"},
+		{"1 fortnight", `unknown unit "fortnight"`},
+		{"1 month", "months and years vary in length"},
+		{"2 years", "months and years vary in length"},
+		{"1.2.3 hours", `invalid number "1.2.3"`},
+		{"300 years", "months and years"},
+		{"400000 weeks", "too long"},
+		{"15251w", "too long"},
+		{"15250w 2d", "too long"},
+	}
+	for _, tt := range tests {
+		_, err := ParseHuman(tt.input)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("ParseHuman(%q): %v, want %q", tt.input, err, tt.wantErr)
+		}
+	}
+}
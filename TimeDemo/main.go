@@ -2,16 +2,32 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"fmt"
+	"math/rand/v2"
 	"os"
 	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"example.com/time-demo/ratelimit"
 )
 
 func main() {
+	// Subcommands; without one the demo runs
+	if len(os.Args) > 1 && os.Args[1] == "convert" {
+		os.Exit(runConvert(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
 	fmt.Println("⏰ Go Time Package Demo")
 	fmt.Println("=======================")
 
+	// Sections that depend on today's date ask the clock, so tests can
+	// pin it
+	clock := RealClock{}
+
 	// Basic time operations
 	fmt.Println("\n1. 📅 Basic Time Operations")
 	basicTimeOperations()
@@ -34,7 +50,7 @@ func main() {
 
 	// Practical examples
 	fmt.Println("\n6. 🛠️ Practical Examples")
-	practicalExamples()
+	practicalExamples(clock)
 
 	// Performance timing
 	fmt.Println("\n7. 📊 Performance Timing")
@@ -44,6 +60,50 @@ func main() {
 	fmt.Println("\n8. ⏲️ Timers and Tickers")
 	timersAndTickers()
 
+	// Recurring schedules
+	fmt.Println("\n9. 🔁 Recurring Schedules")
+	recurringSchedules(clock)
+
+	// Date ranges and bucketing
+	fmt.Println("\n10. 📦 Date Ranges and Bucketing")
+	dateRanges()
+
+	// Wall and monotonic clocks
+	fmt.Println("\n11. 🕰️ Wall vs Monotonic Clocks")
+	monotonicClocks()
+
+	// ISO weeks and quarters
+	fmt.Println("\n12. 🗓️ ISO Weeks and Quarters")
+	weeksAndQuarters(clock)
+
+	// Parsing messy input
+	fmt.Println("\n13. 🧩 Parsing Messy Input")
+	parsingMessyInput(clock)
+
+	// Rate limiting
+	fmt.Println("\n14. 🚦 Rate Limiting")
+	rateLimiting()
+
+	// Debounce and throttle
+	fmt.Println("\n15. 🧹 Debounce and Throttle")
+	debounceAndThrottle()
+
+	// Recurrence rules
+	fmt.Println("\n16. 📆 Recurrence Rules (RRULE)")
+	recurrenceRules()
+
+	// Month calendars
+	fmt.Println("\n17. 🗓️ Month Calendars")
+	monthCalendars(clock)
+
+	// Retries, timeouts and deadlines
+	fmt.Println("\n18. 🔄 Retries, Timeouts and Deadlines")
+	retriesAndDeadlines()
+
+	// Clock drift against NTP servers
+	fmt.Println("\n19. 🌐 Clock Drift (SNTP)")
+	clockDrift()
+
 	// Prevent terminal window from closing on Windows
 	if runtime.GOOS == "windows" {
 		fmt.Println("\nPress Enter to exit...")
@@ -139,23 +199,47 @@ func timeZoneOperations() {
 	fixedZone := time.FixedZone("CUSTOM", 5*3600) // +5 hours
 	customTime := now.In(fixedZone)
 	fmt.Printf("   🎯 Custom timezone (+5): %s\n", customTime.Format("2006-01-02 15:04:05 MST"))
+
+	// Converting a meeting time; same as
+	// go run . convert "2024-12-25 15:30" --from EST --to Asia/Tokyo,Europe/London
+	fmt.Println("\n   🔄 Converting \"Dec 25, 2024 3:30 PM\" from EST:")
+	newYork, _ := ResolveZone("EST")
+	meeting, err := ParseFlexible("Dec 25, 2024 3:30 PM", newYork)
+	if err != nil {
+		fmt.Printf("   ❌ Parse error: %v\n", err)
+		return
+	}
+	var targets []*time.Location
+	for _, name := range []string{"Asia/Tokyo", "Europe/London"} {
+		loc, _ := ResolveZone(name)
+		targets = append(targets, loc)
+	}
+	writeConversions(os.Stdout, Conversion{Zone: newYork.String(), Time: meeting}, Convert(meeting, targets))
+
+	// Skipped wall-clock times and typos are reported, not guessed
+	if _, err := ParseFlexible("2024-03-10 02:30", newYork); err != nil {
+		fmt.Printf("   ⚠️ %v\n", err)
+	}
+	if _, err := ResolveZone("Asia/Tokio"); err != nil {
+		fmt.Printf("   ⚠️ %v\n", err)
+	}
 }
 
 // 4. Duration Operations
 func durationOperations() {
 	// Creating durations
 	fmt.Println("   ⏱️ Duration examples:")
-	fmt.Printf("   • 1 second: %v\n", time.Second)
-	fmt.Printf("   • 5 minutes: %v\n", 5*time.Minute)
-	fmt.Printf("   • 2 hours: %v\n", 2*time.Hour)
-	fmt.Printf("   • 30 days: %v\n", 24*30*time.Hour)
+	fmt.Printf("   • 1 second: %s\n", FormatDuration(time.Second))
+	fmt.Printf("   • 5 minutes: %s\n", FormatDuration(5*time.Minute))
+	fmt.Printf("   • 2 hours: %s\n", FormatDuration(2*time.Hour))
+	fmt.Printf("   • 30 days: %s (%v with time.Duration's String)\n", FormatDuration(24*30*time.Hour), 24*30*time.Hour)
 
 	// Parse duration from string
 	duration, err := time.ParseDuration("2h30m15s")
 	if err != nil {
 		fmt.Printf("   ❌ Duration parse error: %v\n", err)
 	} else {
-		fmt.Printf("   ✅ Parsed duration: %v\n", duration)
+		fmt.Printf("   ✅ Parsed duration: %s\n", FormatDuration(duration))
 		fmt.Printf("   📊 In seconds: %.0f\n", duration.Seconds())
 		fmt.Printf("   📊 In minutes: %.2f\n", duration.Minutes())
 		fmt.Printf("   📊 In hours: %.2f\n", duration.Hours())
@@ -167,18 +251,34 @@ func durationOperations() {
 	past := now.Add(-duration)
 
 	fmt.Printf("   📅 Now: %s\n", now.Format("2006-01-02 15:04:05"))
-	fmt.Printf("   📅 Future (+%v): %s\n", duration, future.Format("2006-01-02 15:04:05"))
-	fmt.Printf("   📅 Past (-%v): %s\n", duration, past.Format("2006-01-02 15:04:05"))
+	fmt.Printf("   📅 Future (+%s): %s\n", FormatDuration(duration), future.Format("2006-01-02 15:04:05"))
+	fmt.Printf("   📅 Past (-%s): %s\n", FormatDuration(duration), past.Format("2006-01-02 15:04:05"))
 
 	// Time since/until
 	birthDate := time.Date(1990, time.January, 1, 0, 0, 0, 0, time.UTC)
 	age := time.Since(birthDate)
-	fmt.Printf("   🎂 Age since 1990-01-01: %.0f days\n", age.Hours()/24)
+	fmt.Printf("   🎂 Age since 1990-01-01: %s\n", Humanizer{Precision: 2}.Duration(age))
+
+	newYear := time.Date(now.Year()+1, time.January, 1, 0, 0, 0, 0, time.Local)
+	fmt.Printf("   🎊 New Year %d: %s\n", newYear.Year(), Humanizer{Precision: 2, Granularity: time.Hour}.Relative(newYear, now))
+
+	// Human-friendly durations
+	fmt.Println("   🗣️ Humanized:")
+	for _, d := range []time.Duration{59 * time.Minute, time.Hour, 3*24*time.Hour + 5*time.Hour, 45 * 24 * time.Hour} {
+		fmt.Printf("   • %s: %s (%s)\n", FormatDuration(d), Humanize(d), Humanizer{Precision: 2, Short: true}.Duration(d))
+	}
+	fmt.Printf("   • Past (-%s): %s\n", FormatDuration(duration), RelativeTime(past, now))
+	fmt.Printf("   • Future (+%s): %s\n", FormatDuration(duration), Humanizer{Precision: 2}.Relative(future, now))
 
-	newYear := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
-	untilNewYear := time.Until(newYear)
-	if untilNewYear > 0 {
-		fmt.Printf("   🎊 Days until 2026: %.0f days\n", untilNewYear.Hours()/24)
+	// time.ParseDuration stops at hours
+	if _, err := time.ParseDuration("1d4h"); err != nil {
+		fmt.Printf("   ❌ time.ParseDuration(\"1d4h\"): %v\n", err)
+	}
+	if d, err := ParseHuman("1 day 4 hours"); err == nil {
+		fmt.Printf("   ✅ ParseHuman(\"1 day 4 hours\"): %s\n", FormatDuration(d))
+	}
+	if d, err := ParseHuman("1w2d3h"); err == nil {
+		fmt.Printf("   ✅ ParseHuman(\"1w2d3h\"): %s, or %s to 2 units\n", FormatDuration(d), Humanizer{Exact: true, Short: true, Precision: 2}.Duration(d))
 	}
 }
 
@@ -208,10 +308,12 @@ func timeComparisons() {
 }
 
 // 6. Practical Examples
-func practicalExamples() {
+func practicalExamples(clock Clock) {
+	now := clock.Now()
+
 	// Age calculation
 	birthDate := time.Date(1990, time.May, 15, 0, 0, 0, 0, time.UTC)
-	age := calculateAge(birthDate)
+	age := calculateAge(birthDate, now)
 	fmt.Printf("   🎂 Age from 1990-05-15: %d years\n", age)
 
 	// Business days calculation
@@ -220,14 +322,44 @@ func practicalExamples() {
 	businessDays := calculateBusinessDays(start, end)
 	fmt.Printf("   💼 Business days (Jan 1-15, 2024): %d\n", businessDays)
 
+	// The same with US federal holidays: New Year's Day and MLK Day
+	federal := USFederalHolidays()
+	fmt.Printf("   🏛️ With %s holidays: %d\n", federal.Name, BusinessDaysBetween(start, end, federal))
+	fmt.Println("   🔀 Weekend holidays observed on a weekday in 2021:")
+	for _, h := range federal.HolidaysIn(2021) {
+		if h.Moved {
+			fmt.Printf("   • %s\n", h)
+		}
+	}
+	christmas := time.Date(2021, time.December, 23, 9, 0, 0, 0, time.UTC)
+	fmt.Printf("   📦 3 business days after %s: %s without holidays, %s with\n",
+		christmas.Format("Mon Jan 2"),
+		AddBusinessDays(christmas, 3, nil).Format("Mon Jan 2"),
+		AddBusinessDays(christmas, 3, federal).Format("Mon Jan 2"))
+	fmt.Printf("   🎆 Next business day after Jul 3, 2024: %s\n",
+		NextBusinessDay(time.Date(2024, time.July, 3, 0, 0, 0, 0, time.UTC), federal).Format("Mon Jan 2"))
+
+	// Calendars can also be loaded from JSON
+	company, err := ParseHolidayCalendar([]byte(`{
+		"name": "Acme Corp",
+		"holidays": [
+			{"name": "Christmas Eve", "date": "12-24"},
+			{"name": "Founders' Day", "rule": "second friday of june"},
+			{"name": "Office move", "date": "2024-08-16"}
+		]
+	}`))
+	if err != nil {
+		fmt.Printf("   ❌ %v\n", err)
+	} else {
+		fmt.Printf("   🏢 %s holidays in 2024: %d\n", company.Name, len(company.HolidaysIn(2024)))
+	}
+
 	// Time until next Friday
-	nextFriday := getNextWeekday(time.Now(), time.Friday)
-	untilFriday := time.Until(nextFriday)
-	fmt.Printf("   📅 Next Friday: %s (in %.1f days)\n",
-		nextFriday.Format("2006-01-02"), untilFriday.Hours()/24)
+	nextFriday := getNextWeekday(now, time.Friday)
+	fmt.Printf("   📅 Next Friday: %s (%s)\n",
+		nextFriday.Format("2006-01-02"), Humanizer{Precision: 2, Granularity: time.Hour}.Relative(nextFriday, now))
 
 	// Start/end of day
-	now := time.Now()
 	startOfDay := getStartOfDay(now)
 	endOfDay := getEndOfDay(now)
 	fmt.Printf("   🌅 Start of day: %s\n", startOfDay.Format("2006-01-02 15:04:05"))
@@ -245,12 +377,56 @@ func practicalExamples() {
 	} else {
 		fmt.Printf("   🏠 Outside business hours\n")
 	}
+
+	// Meeting slots across time zones
+	meetingSlots(now)
+}
+
+func meetingSlots(now time.Time) {
+	var team []Participant
+	for _, member := range []struct {
+		name, zone          string
+		startHour, stopHour int
+	}{
+		{"New York", "America/New_York", 8, 18},
+		{"London", "Europe/London", 8, 18},
+		{"Tokyo", "Asia/Tokyo", 7, 23}, // happy to take late calls
+	} {
+		loc, err := ResolveZone(member.zone)
+		if err != nil {
+			fmt.Printf("   ❌ %v\n", err)
+			return
+		}
+		team = append(team, Participant{Name: member.name, Location: loc,
+			WorkStart: time.Duration(member.startHour) * time.Hour, WorkEnd: time.Duration(member.stopHour) * time.Hour})
+	}
+
+	// Next week, Monday to Friday in UTC
+	monday := StartOfWeek(now.UTC(), time.Monday).AddDate(0, 0, 7)
+	week := Range{monday, monday.AddDate(0, 0, 5)}
+	slots := FindMeetingSlots(team, time.Hour, week)
+	fmt.Printf("   🤝 %d one-hour slots for New York, London and Tokyo next week; the friendliest:\n", len(slots))
+	for _, slot := range slots[:min(3, len(slots))] {
+		var local []string
+		for _, p := range team {
+			local = append(local, fmt.Sprintf("%s %s", p.Name, slot.Start.In(p.Location).Format("15:04")))
+		}
+		fmt.Printf("   • %s  score %.2f  (%s)\n", slot.Start.Format("Mon Jan 2 15:04 MST"), slot.Score, strings.Join(local, ", "))
+	}
+
+	// Nine to five in New York and Tokyo never overlap
+	nineToFive := []Participant{team[0], team[2]}
+	for i := range nineToFive {
+		nineToFive[i].WorkStart, nineToFive[i].WorkEnd = 9*time.Hour, 17*time.Hour
+	}
+	fmt.Printf("   🚫 Nine to five in New York and Tokyo: %d slots\n", len(FindMeetingSlots(nineToFive, 30*time.Minute, week)))
 }
 
 // 7. Performance Timing
 func performanceTiming() {
 	// Simple timing
-	start := time.Now()
+	var sw Stopwatch
+	sw.Start()
 
 	// Simulate some work
 	total := 0
@@ -258,30 +434,34 @@ func performanceTiming() {
 		total += i
 	}
 
-	elapsed := time.Since(start)
-	fmt.Printf("   ⚡ Simple loop took: %v\n", elapsed)
+	sw.Stop()
+	fmt.Printf("   ⚡ Simple loop took: %v\n", sw.Elapsed())
+
+	// Paused time is not counted
+	sw.Reset()
+	sw.Start()
+	time.Sleep(5 * time.Millisecond)
+	sw.Lap("first half")
+	sw.Stop()
+	time.Sleep(20 * time.Millisecond)
+	sw.Start()
+	time.Sleep(5 * time.Millisecond)
+	sw.Lap("second half")
+	sw.Stop()
+	fmt.Printf("   ⏸️ Two 5ms laps around a 20ms pause: %v\n", sw.Elapsed().Round(time.Millisecond))
 
 	// More precise timing with multiple measurements
 	fmt.Println("   📊 Performance measurements:")
 
-	measurements := make([]time.Duration, 5)
-	for i := 0; i < 5; i++ {
-		start := time.Now()
-
+	report := Measure("sleep 10ms", func() {
 		// Simulate work
 		time.Sleep(10 * time.Millisecond)
-
-		measurements[i] = time.Since(start)
-	}
-
-	var total_time time.Duration
-	for i, duration := range measurements {
-		fmt.Printf("   • Run %d: %v\n", i+1, duration)
-		total_time += duration
+	}, 5)
+	for _, lap := range report.Laps {
+		fmt.Printf("   • %s: %v\n", lap.Name, lap.Duration)
 	}
 
-	average := total_time / time.Duration(len(measurements))
-	fmt.Printf("   📊 Average: %v\n", average)
+	fmt.Printf("   📊 Average: %v (min %v, max %v, p90 %v)\n", report.Mean, report.Min, report.Max, report.P90)
 }
 
 // 8. Timers and Tickers
@@ -325,16 +505,476 @@ func timersAndTickers() {
 	})
 
 	<-done // Wait for completion
+
+	// Countdown with progress reports every half second
+	fmt.Println("   ⏳ Countdown example (3 seconds):")
+	countdown := NewCountdown(3*time.Second, 500*time.Millisecond)
+	countdown.OnProgress = func(remaining time.Duration, percent float64) {
+		fmt.Printf("   [%-20s] %3.0f%% %v left\n", progressBar(percent, 20), percent, remaining.Round(100*time.Millisecond))
+	}
+	countdown.OnDone = func() { fmt.Println("   🚀 Liftoff!") }
+	countdown.Start(context.Background())
+	<-countdown.Done()
+
+	// Cancelling the context stops a countdown part way
+	ctx, cancel := context.WithTimeout(context.Background(), 1200*time.Millisecond)
+	defer cancel()
+	countdown = NewCountdown(3*time.Second, 500*time.Millisecond)
+	countdown.Start(ctx)
+	<-countdown.Done()
+	fmt.Printf("   🛑 Cancelled with %v left: %v\n", countdown.Remaining().Round(100*time.Millisecond), countdown.Err())
+}
+
+// progressBar draws percent as a bar of width characters
+func progressBar(percent float64, width int) string {
+	filled := int(percent / 100 * float64(width))
+	return strings.Repeat("█", min(max(filled, 0), width))
+}
+
+// 9. Recurring Schedules
+func recurringSchedules(clock Clock) {
+	loc, err := ResolveZone("America/New_York")
+	if err != nil {
+		fmt.Printf("   ❌ %v\n", err)
+		return
+	}
+	now := clock.Now().In(loc)
+	fmt.Printf("   🕘 Next occurrences after %s:\n", now.Format("Mon 2006-01-02 15:04 MST"))
+
+	expressions := []string{
+		"every monday 09:00",
+		"every 2 weeks from 2024-01-05 17:00",
+		"last day of month 17:00",
+		"*/30 9-11 * * mon-fri",
+		"0 12 29 2 *",
+	}
+	for _, expr := range expressions {
+		schedule, err := ParseSchedule(expr, loc)
+		if err != nil {
+			fmt.Printf("   ❌ %v\n", err)
+			continue
+		}
+		fmt.Printf("   🔁 %s\n", schedule)
+		for _, t := range schedule.Upcoming(clock, 5) {
+			fmt.Printf("      • %s\n", t.Format("Mon 2006-01-02 15:04 MST"))
+		}
+	}
+
+	// Jan 31 + 1 month clamps to the end of February, then returns to the 31st
+	monthly, _ := ParseSchedule("every month from 2024-01-31 09:00", loc)
+	fmt.Printf("   📆 %s:", monthly)
+	for _, t := range monthly.NextN(time.Date(2024, time.January, 1, 0, 0, 0, 0, loc), 4) {
+		fmt.Printf(" %s", t.Format("Jan 2"))
+	}
+	fmt.Println()
+
+	// 02:30 does not exist on the spring-forward day
+	nightly, _ := ParseSchedule("every day 02:30", loc)
+	skipped := nightly.Next(time.Date(2024, time.March, 9, 12, 0, 0, 0, loc))
+	fmt.Printf("   ⚠️ %s on 2024-03-10 fires at %s\n", nightly, skipped.Format("15:04 MST"))
+
+	// Mistakes are reported, not guessed at
+	for _, expr := range []string{"every funday 09:00", "61 * * * *", "0 0 30 2 *"} {
+		if _, err := ParseSchedule(expr, loc); err != nil {
+			fmt.Printf("   ❌ %v\n", err)
+		}
+	}
+}
+
+// 10. Date Ranges and Bucketing
+func dateRanges() {
+	loc, err := ResolveZone("America/New_York")
+	if err != nil {
+		fmt.Printf("   ❌ %v\n", err)
+		return
+	}
+
+	// Period boundaries
+	t := time.Date(2024, time.May, 15, 14, 30, 0, 0, loc)
+	fmt.Printf("   📅 %s\n", t.Format("Mon 2006-01-02 15:04"))
+	fmt.Printf("   • Week starting Sunday: %s, Monday: %s\n",
+		StartOfWeek(t, time.Sunday).Format("Mon Jan 2"), StartOfWeek(t, time.Monday).Format("Mon Jan 2"))
+	fmt.Printf("   • Month: %s to %s\n", StartOfMonth(t).Format("Jan 2"), EndOfMonth(t).Format("Jan 2 15:04:05"))
+	fmt.Printf("   • Quarter starts: %s\n", StartOfQuarter(t).Format("Jan 2"))
+
+	// Elapsed-time steps drift across the DST change; calendar days do not
+	week := Range{time.Date(2024, time.March, 9, 0, 0, 0, 0, loc), time.Date(2024, time.March, 12, 0, 0, 0, 0, loc)}
+	fmt.Print("   🌗 24h steps:")
+	for t := range week.Iter(24 * time.Hour) {
+		fmt.Printf(" %s", t.Format("Jan 2 15:04"))
+	}
+	fmt.Print("\n   🌗 Calendar days:")
+	for day := range week.IterCalendar(UnitDay) {
+		fmt.Printf(" %s (%v)", day.Start.Format("Jan 2"), day.Duration())
+	}
+	fmt.Println()
+
+	// A synthetic series of events, bucketed per day and per month
+	series := Range{time.Date(2024, time.January, 1, 0, 0, 0, 0, loc), time.Date(2024, time.April, 1, 0, 0, 0, 0, loc)}
+	rng := rand.New(rand.NewPCG(1, 2))
+	events := make([]time.Time, 500)
+	for i := range events {
+		events[i] = series.Start.Add(time.Duration(rng.Int64N(int64(series.Duration()))))
+	}
+
+	fmt.Println("   📊 Events per day around the DST change:")
+	days := Range{time.Date(2024, time.March, 8, 0, 0, 0, 0, loc), time.Date(2024, time.March, 13, 0, 0, 0, 0, loc)}
+	for _, b := range days.Count(events, UnitDay) {
+		fmt.Printf("   • %s %3d %s\n", b.Start.Format("Mon Jan _2"), b.Count, strings.Repeat("█", b.Count))
+	}
+	fmt.Println("   📊 Events per month:")
+	for _, b := range series.Count(events, UnitMonth) {
+		fmt.Printf("   • %-13s %3d (%d days)\n", b.Start.Format("January 2006"), b.Count, DaysInMonth(b.Start.Year(), b.Start.Month()))
+	}
+}
+
+// 11. Wall vs Monotonic Clocks
+func monotonicClocks() {
+	// time.Now carries two readings: the wall clock, which NTP or a user
+	// can move, and a monotonic clock, which only moves forward
+	start := time.Now()
+	fmt.Printf("   🕰️ time.Now(): %s\n", start)
+	fmt.Printf("   • Has a monotonic reading (m=...): %t\n", hasMonotonic(start))
+
+	// Sub, Since, Before and After use the monotonic readings when both
+	// times have one, so a clock change mid-measurement cannot make
+	// elapsed time negative
+	time.Sleep(10 * time.Millisecond)
+	elapsed := time.Since(start)
+	fmt.Printf("   ⏱️ time.Since(start): %v (monotonic)\n", elapsed.Round(time.Millisecond))
+
+	// Wall-clock computations strip the monotonic reading; Add keeps it
+	fmt.Println("   ✂️ Monotonic reading after:")
+	for _, op := range []struct {
+		name string
+		t    time.Time
+	}{
+		{"t.Round(0)", start.Round(0)},
+		{"t.Truncate(time.Second)", start.Truncate(time.Second)},
+		{"t.AddDate(0, 0, 1)", start.AddDate(0, 0, 1)},
+		{"t.UTC()", start.UTC()},
+		{"t.Add(time.Hour)", start.Add(time.Hour)},
+	} {
+		result := "stripped"
+		if hasMonotonic(op.t) {
+			result = "kept"
+		}
+		fmt.Printf("   • %-24s %s\n", op.name+":", result)
+	}
+
+	// t.Round(0) is the idiom for a wall-clock-only copy, e.g. before
+	// comparing with ==, using times as map keys, or to print cleanly
+	wall := start.Round(0)
+	fmt.Printf("   📌 t.Round(0): %s\n", wall)
+	fmt.Printf("   ⚠️ t == t.Round(0): %t, t.Equal(t.Round(0)): %t (use Equal)\n", start == wall, start.Equal(wall))
+
+	// Serialized times never carry it either
+	data, _ := start.MarshalJSON()
+	fmt.Printf("   💾 JSON: %s\n", data)
+}
+
+// 12. ISO Weeks and Quarters
+func weeksAndQuarters(clock Clock) {
+	now := clock.Now()
+
+	// The current ISO week, Monday to Sunday
+	year, week := now.ISOWeek()
+	weekStart := ISOWeekStart(year, week, now.Location())
+	fmt.Printf("   📅 Today is in ISO week %d-W%02d: %s to %s (%d weeks in %d)\n", year, week,
+		weekStart.Format("Mon Jan 2"), weekStart.AddDate(0, 0, 6).Format("Mon Jan 2"), WeeksInYear(year), year)
+	fmt.Printf("   • Week %d of %s (weeks starting Monday)\n", WeekOfMonth(now, time.Monday), now.Format("January"))
+
+	// Early January can still be in the previous year's last week
+	for _, y := range []int{2021, 2022, 2024} {
+		jan1 := time.Date(y, time.January, 1, 0, 0, 0, 0, time.UTC)
+		isoYear, isoWeek := jan1.ISOWeek()
+		fmt.Printf("   • %s is in %d-W%02d\n", jan1.Format("Mon Jan 2, 2006"), isoYear, isoWeek)
+	}
+
+	// Quarter boundaries
+	q := Quarter(now)
+	quarter := QuarterRange(now.Year(), q, now.Location())
+	days := 0
+	for range quarter.IterCalendar(UnitDay) {
+		days++
+	}
+	fmt.Printf("   📊 Q%d %d: %s to %s (%d days)\n", q, now.Year(), quarter.Start.Format("Jan 2"),
+		quarter.End.AddDate(0, 0, -1).Format("Jan 2"), days)
+
+	// Same day last quarter, clamped to shorter months
+	fmt.Printf("   ⏪ Same day last quarter: %s\n", AddQuarters(now, -1).Format("Mon 2006-01-02"))
+	may31 := time.Date(2024, time.May, 31, 0, 0, 0, 0, time.UTC)
+	fmt.Printf("   ⏪ %s minus a quarter: %s\n", may31.Format("Jan 2, 2006"), AddQuarters(may31, -1).Format("Jan 2, 2006"))
+}
+
+func parsingMessyInput(clock Clock) {
+	inputs := []string{
+		"2023-12-25T15:30:45Z",
+		"2023-12-25 15:30",
+		"Mon, 25 Dec 2023 15:30:45 GMT",
+		"12/25/2023",
+		"01/02/2023",
+		"25/12/2023",
+		"Dec 25, 2023 3:30 PM",
+		"  25   December 2023 ",
+		"3:30PM",
+		"tomorrow",
+		"1703518245",
+		"1703518245123",
+		"next thursday",
+	}
+	for _, input := range inputs {
+		t, layout, err := ParseAny(input, WithClock(clock), WithLocation(time.UTC))
+		if err != nil {
+			fmt.Printf("   ❌ %-32q %v\n", input, err)
+			continue
+		}
+		fmt.Printf("   ✅ %-32q %s  (%s)\n", input, t.Format("2006-01-02 15:04:05 MST"), layout)
+	}
+
+	// 01/02/2023 is January 2nd unless dates are known to be day first
+	t, layout, _ := ParseAny("01/02/2023", WithDayFirst(), WithLocation(time.UTC))
+	fmt.Printf("   🇪🇺 \"01/02/2023\" day first: %s  (%s)\n", t.Format("Mon Jan 2, 2006"), layout)
+}
+
+func rateLimiting() {
+	// Ten requests at once against 5 a second: the first five use up the
+	// burst, the rest wait their turn
+	fmt.Println("   🪣 Token bucket, 5 requests/second with bursts of 5:")
+	bucket := ratelimit.NewTokenBucket(5, 5, nil)
+	start := time.Now()
+	for i := 1; i <= 10; i++ {
+		if bucket.Allow() {
+			fmt.Printf("   ✅ Request %2d allowed at +%v\n", i, time.Since(start).Round(10*time.Millisecond))
+			continue
+		}
+		waitStart := time.Now()
+		if err := bucket.Wait(context.Background()); err != nil {
+			fmt.Printf("   ❌ Request %2d: %v\n", i, err)
+			continue
+		}
+		fmt.Printf("   ⏳ Request %2d delayed %v, sent at +%v\n", i,
+			time.Since(waitStart).Round(10*time.Millisecond), time.Since(start).Round(10*time.Millisecond))
+	}
+
+	// A request every 100ms against 3 per 500ms: the excess is rejected
+	// rather than delayed
+	fmt.Println("   🪟 Sliding window, 3 requests per 500ms, one request every 100ms:")
+	window := ratelimit.NewSlidingWindowCounter(3, 500*time.Millisecond, nil)
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for i := 1; i <= 8; i++ {
+		<-ticker.C
+		if window.Allow() {
+			fmt.Printf("   ✅ Request %d allowed (%d in window)\n", i, window.Count())
+		} else {
+			fmt.Printf("   🚫 Request %d rejected, retry in %v\n", i, window.RetryAfter().Round(time.Millisecond))
+		}
+	}
+}
+
+func debounceAndThrottle() {
+	// An editor saving several files at once: rebuild once, after the
+	// changes stop for 100ms
+	var mu sync.Mutex
+	var changed []string
+	rebuilt := make(chan struct{})
+	rebuild := Debounce(func() {
+		mu.Lock()
+		fmt.Printf("   🔨 Rebuilding after %d changes: %s\n", len(changed), strings.Join(changed, ", "))
+		changed = nil
+		mu.Unlock()
+		close(rebuilt)
+	}, 100*time.Millisecond)
+
+	for _, file := range []string{"main.go", "clock.go", "main.go", "README.md", "go.mod"} {
+		fmt.Printf("   📝 %s changed\n", file)
+		mu.Lock()
+		changed = append(changed, file)
+		mu.Unlock()
+		rebuild.Call()
+		time.Sleep(20 * time.Millisecond)
+	}
+	<-rebuilt
+
+	// Scroll events every 10ms for 300ms, handled at most every 100ms
+	var handled atomic.Int64
+	scroll := Throttle(func() { handled.Add(1) }, 100*time.Millisecond)
+	for range 30 {
+		scroll.Call()
+		time.Sleep(10 * time.Millisecond)
+	}
+	scroll.Flush()
+	fmt.Printf("   🖱️ 30 scroll events, %d handled with a 100ms throttle\n", handled.Load())
+}
+
+func recurrenceRules() {
+	newYork, err := ResolveZone("America/New_York")
+	if err != nil {
+		fmt.Printf("   ❌ %v\n", err)
+		return
+	}
+
+	// Every other Tuesday at 9am New York time, across the end of
+	// daylight saving time on November 3rd, 2024
+	start := time.Date(2024, time.October, 15, 9, 0, 0, 0, newYork)
+	rule, err := ParseRRule("FREQ=WEEKLY;INTERVAL=2;BYDAY=TU;COUNT=5", start)
+	if err != nil {
+		fmt.Printf("   ❌ %v\n", err)
+		return
+	}
+	occurrences, err := Expand(rule, start, start.AddDate(1, 0, 0))
+	if err != nil {
+		fmt.Printf("   ❌ %v\n", err)
+		return
+	}
+	fmt.Printf("   🔁 %s from %s:\n", rule, start.Format("Mon Jan 2, 2006 15:04 MST"))
+	for _, t := range occurrences {
+		fmt.Printf("   • %s  =  %s\n", t.Format("Mon Jan 2 15:04 MST"), t.UTC().Format("15:04 UTC"))
+	}
+	fmt.Println("   💡 Still 09:00 in New York after the change, an hour later in UTC")
+
+	// The last Friday of every month until the end of the year
+	rule, _ = ParseRRule("FREQ=MONTHLY;BYDAY=-1FR;UNTIL=20241231", time.Date(2024, time.September, 1, 16, 0, 0, 0, newYork))
+	occurrences, _ = Expand(rule, rule.Start, rule.Start.AddDate(1, 0, 0))
+	var days []string
+	for _, t := range occurrences {
+		days = append(days, t.Format("Jan 2"))
+	}
+	fmt.Printf("   🍕 %s: %s\n", rule, strings.Join(days, ", "))
+}
+
+func monthCalendars(clock Clock) {
+	now := clock.Now()
+	federal := USFederalHolidays()
+	color := useColor()
+
+	this := RenderMonth(MonthGrid(now.Year(), now.Month(), time.Sunday), federal, color)
+	next := RenderMonth(MonthGrid(now.Year(), now.Month()+1, time.Sunday), federal, color)
+	for _, line := range sideBySide(this, next) {
+		fmt.Println("   " + line)
+	}
+	if color {
+		fmt.Println("   Today in reverse video, US federal holidays in red")
+	} else {
+		fmt.Println("   > today, * US federal holiday")
+	}
+
+	// Weeks starting on Monday, as ISO 8601 has them
+	for _, line := range RenderMonth(MonthGrid(2024, time.September, time.Monday), nil, false) {
+		fmt.Println("   " + line)
+	}
+	fmt.Println("   September 2024 starts on a Sunday, so Monday weeks need a sixth row")
+}
+
+func retriesAndDeadlines() {
+	// A flaky operation that works on the third try
+	attempts := 0
+	flaky := func(ctx context.Context) error {
+		attempts++
+		if attempts < 3 {
+			return fmt.Errorf("connection reset (attempt %d)", attempts)
+		}
+		return nil
+	}
+	retrier := Retrier{
+		Attempts: 5,
+		Backoff:  ExponentialBackoff{Base: 100 * time.Millisecond, Max: time.Second, Jitter: 0.2},
+		OnRetry: func(attempt int, err error, wait time.Duration) {
+			fmt.Printf("   ⚠️ %v; retrying in %s\n", err, FormatDuration(wait.Round(time.Millisecond)))
+		},
+	}
+	start := time.Now()
+	if err := retrier.Do(context.Background(), flaky); err != nil {
+		fmt.Printf("   ❌ %v\n", err)
+	} else {
+		fmt.Printf("   ✅ Succeeded on attempt %d after %s\n", attempts, FormatDuration(time.Since(start).Round(10*time.Millisecond)))
+	}
+
+	// Backoff strategies side by side
+	var constant, exponential, fibonacci []string
+	for retry := 1; retry <= 6; retry++ {
+		constant = append(constant, FormatDuration(ConstantBackoff(100*time.Millisecond).Backoff(retry)))
+		exponential = append(exponential, FormatDuration(ExponentialBackoff{Base: 100 * time.Millisecond}.Backoff(retry)))
+		fibonacci = append(fibonacci, FormatDuration(FibonacciBackoff{Base: 100 * time.Millisecond}.Backoff(retry)))
+	}
+	fmt.Printf("   • Constant:    %s\n", strings.Join(constant, ", "))
+	fmt.Printf("   • Exponential: %s\n", strings.Join(exponential, ", "))
+	fmt.Printf("   • Fibonacci:   %s\n", strings.Join(fibonacci, ", "))
+
+	// A 500ms budget split across three calls; each gets an even share of
+	// what is left, so the slow one times out without starving the last
+	deadline := NewDeadline(500*time.Millisecond, nil)
+	steps := []struct {
+		name string
+		took time.Duration
+	}{{"fetch user", 100 * time.Millisecond}, {"fetch orders", 250 * time.Millisecond}, {"render page", 50 * time.Millisecond}}
+	for i, step := range steps {
+		timeout, err := deadline.NextTimeout(20*time.Millisecond, deadline.Remaining()/time.Duration(len(steps)-i))
+		if err != nil {
+			fmt.Printf("   ❌ %s: %v\n", step.name, err)
+			continue
+		}
+		err = WithTimeout(func(ctx context.Context) error {
+			select {
+			case <-time.After(step.took):
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}, timeout)
+		limits := fmt.Sprintf("timeout %s, needs %s", FormatDuration(timeout.Round(time.Millisecond)), FormatDuration(step.took))
+		if err != nil {
+			fmt.Printf("   ⏰ %s (%s): %v\n", step.name, limits, err)
+		} else {
+			fmt.Printf("   ✅ %s (%s)\n", step.name, limits)
+		}
+	}
+	fmt.Printf("   💰 Budget left: %s\n", FormatDuration(deadline.Remaining().Round(time.Millisecond)))
+}
+
+// driftWarning is how far off the local clock may be before clockDrift
+// warns; TLS certificates, tokens and logs start to misbehave beyond a
+// second or so
+const driftWarning = time.Second
+
+func clockDrift() {
+	servers := []string{"time.google.com", "time.cloudflare.com", "pool.ntp.org"}
+	report, err := CheckDrift(servers, 2*time.Second)
+	for _, s := range report.Samples {
+		if s.Err != nil {
+			fmt.Printf("   ❌ %v\n", s.Err)
+			continue
+		}
+		fmt.Printf("   • %-20s offset %+v, round trip %v, stratum %d\n",
+			s.Server, s.Offset.Round(time.Microsecond), s.Delay.Round(time.Microsecond), s.Stratum)
+	}
+	if err != nil {
+		fmt.Println("   ⚠️ Could not measure drift; no NTP server answered (is UDP port 123 blocked?)")
+		return
+	}
+
+	drift := report.Offset.Abs()
+	switch {
+	case drift > driftWarning:
+		fmt.Printf("   🚨 Local clock is off by %s (median), more than %s: check the time sync service\n",
+			FormatDuration(drift.Round(time.Millisecond)), FormatDuration(driftWarning))
+	case report.Offset > 0:
+		fmt.Printf("   ✅ Local clock is %v behind network time (median)\n", drift.Round(time.Microsecond))
+	default:
+		fmt.Printf("   ✅ Local clock is %v ahead of network time (median)\n", drift.Round(time.Microsecond))
+	}
 }
 
 // Helper functions
 
-func calculateAge(birthDate time.Time) int {
-	now := time.Now()
+func calculateAge(birthDate, now time.Time) int {
 	age := now.Year() - birthDate.Year()
 
-	// Check if birthday hasn't occurred this year
-	if now.YearDay() < birthDate.YearDay() {
+	// Check if birthday hasn't occurred this year. Compare month and day
+	// rather than YearDay, which shifts by one after February in leap
+	// years; a February 29th birthday counts from March 1st otherwise.
+	if now.Month() < birthDate.Month() || (now.Month() == birthDate.Month() && now.Day() < birthDate.Day()) {
 		age--
 	}
 
@@ -342,21 +982,15 @@ func calculateAge(birthDate time.Time) int {
 }
 
 func calculateBusinessDays(start, end time.Time) int {
-	count := 0
-	for d := start; d.Before(end) || d.Equal(end); d = d.AddDate(0, 0, 1) {
-		if d.Weekday() != time.Saturday && d.Weekday() != time.Sunday {
-			count++
-		}
-	}
-	return count
+	// Weekends only; pass a HolidayCalendar to skip holidays too
+	return BusinessDaysBetween(start, end, nil)
 }
 
 func getNextWeekday(from time.Time, weekday time.Weekday) time.Time {
-	daysUntil := (int(weekday) - int(from.Weekday()) + 7) % 7
-	if daysUntil == 0 {
-		daysUntil = 7 // Next week if today is the target day
-	}
-	return from.AddDate(0, 0, daysUntil)
+	// Next week if today is the target day, since from's own time of day
+	// has already been reached
+	next := Weekly(from.Hour(), from.Minute(), from.Location(), weekday).Next(from)
+	return next.Add(time.Duration(from.Second())*time.Second + time.Duration(from.Nanosecond()))
 }
 
 func getStartOfDay(t time.Time) time.Time {
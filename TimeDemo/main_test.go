@@ -0,0 +1,90 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalculateAge(t *testing.T) {
+	tests := []struct {
+		name       string
+		birth, now time.Time
+		want       int
+	}{
+		{"day before birthday", date(1990, time.May, 15), date(2024, time.May, 14), 33},
+		{"on birthday", date(1990, time.May, 15), date(2024, time.May, 15), 34},
+		{"day after birthday", date(1990, time.May, 15), date(2024, time.May, 16), 34},
+		{"newborn", date(2024, time.May, 15), date(2024, time.May, 15), 0},
+		// YearDay is 60 for both: Mar 1 in 1990, Feb 29 in 2024
+		{"leap day before a March birthday", date(1990, time.March, 1), date(2024, time.February, 29), 33},
+		// YearDay 61 in 2024 is Mar 1 in a leap year, Mar 2 otherwise
+		{"March birthday born in a leap year", date(2000, time.March, 2), date(2023, time.March, 1), 22},
+		{"March birthday in a leap year", date(1999, time.March, 1), date(2024, time.March, 1), 25},
+		{"Feb 29 birthday, Feb 28 in a common year", date(2000, time.February, 29), date(2023, time.February, 28), 22},
+		{"Feb 29 birthday, Mar 1 in a common year", date(2000, time.February, 29), date(2023, time.March, 1), 23},
+		{"Feb 29 birthday on Feb 29", date(2000, time.February, 29), date(2024, time.February, 29), 24},
+		{"December birthday, Dec 31 in a leap year", date(1990, time.December, 31), date(2024, time.December, 30), 33},
+	}
+	for _, tt := range tests {
+		// Ask a FakeClock, as the demo asks its Clock
+		clock := NewFakeClock(tt.now.Add(12 * time.Hour))
+		if got := calculateAge(tt.birth, clock.Now()); got != tt.want {
+			t.Errorf("%s: calculateAge(%s, %s) = %d, want %d", tt.name,
+				tt.birth.Format("2006-01-02"), tt.now.Format("2006-01-02"), got, tt.want)
+		}
+	}
+
+	// A year later, one year older
+	clock := NewFakeClock(date(2024, time.May, 14))
+	birth := date(1990, time.May, 15)
+	before := calculateAge(birth, clock.Now())
+	clock.Advance(24 * time.Hour)
+	if after := calculateAge(birth, clock.Now()); after != before+1 {
+		t.Errorf("age went from %d to %d over the birthday", before, after)
+	}
+}
+
+func TestBusinessHoursAndWeekends(t *testing.T) {
+	// Wednesday
+	clock := NewFakeClock(time.Date(2024, time.May, 15, 8, 59, 0, 0, time.UTC))
+
+	tests := []struct {
+		advance  time.Duration
+		weekend  bool
+		business bool
+	}{
+		{0, false, false},                           // Wed 08:59
+		{time.Minute, false, true},                  // Wed 09:00
+		{7*time.Hour + 59*time.Minute, false, true}, // Wed 16:59
+		{time.Minute, false, false},                 // Wed 17:00
+		{43 * time.Hour, false, true},               // Fri 12:00
+		{24 * time.Hour, true, false},               // Sat 12:00
+		{24 * time.Hour, true, false},               // Sun 12:00
+		{24 * time.Hour, false, true},               // Mon 12:00
+	}
+	for _, tt := range tests {
+		clock.Advance(tt.advance)
+		now := clock.Now()
+		if got := isWeekend(now); got != tt.weekend {
+			t.Errorf("isWeekend(%s) = %v, want %v", now.Format("Mon 15:04"), got, tt.weekend)
+		}
+		if got := isBusinessHours(now); got != tt.business {
+			t.Errorf("isBusinessHours(%s) = %v, want %v", now.Format("Mon 15:04"), got, tt.business)
+		}
+	}
+}
+
+func TestGetNextWeekdayFromClock(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, time.May, 13, 9, 0, 0, 0, time.UTC)) // Monday
+
+	// Each day of the week, the next Friday counts down and then jumps a
+	// week once Friday arrives
+	want := []string{"2024-05-17", "2024-05-17", "2024-05-17", "2024-05-17", "2024-05-24", "2024-05-24", "2024-05-24"}
+	for i, w := range want {
+		now := clock.Now()
+		if got := getNextWeekday(now, time.Friday).Format("2006-01-02"); got != w {
+			t.Errorf("day %d (%s): next Friday %s, want %s", i, now.Format("Mon"), got, w)
+		}
+		clock.Advance(24 * time.Hour)
+	}
+}
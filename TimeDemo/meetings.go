@@ -0,0 +1,150 @@
+package main
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+// meetingStep is how far apart FindMeetingSlots tries start times
+const meetingStep = 30 * time.Minute
+
+// Friendly hours, local to each participant; meeting time outside them
+// lowers a slot's score
+const (
+	friendlyStart = 9 * time.Hour
+	friendlyEnd   = 17 * time.Hour
+)
+
+// Participant is someone who has to attend a meeting
+type Participant struct {
+	Name     string
+	Location *time.Location
+	// WorkStart and WorkEnd are local times of day, such as 8*time.Hour for
+	// 08:00. A WorkEnd at or before WorkStart runs past midnight into the
+	// next day, for night shifts.
+	WorkStart, WorkEnd time.Duration
+	// Days are the days a working day can start on; Monday to Friday when
+	// empty
+	Days []time.Weekday
+}
+
+// worksOn reports whether a working day starts on weekday
+func (p Participant) worksOn(weekday time.Weekday) bool {
+	if len(p.Days) == 0 {
+		return weekday != time.Saturday && weekday != time.Sunday
+	}
+	return slices.Contains(p.Days, weekday)
+}
+
+// hours returns the wall-clock times from and to on day's date in p's
+// location; a to at or before from is on the next day. Wall-clock rather
+// than elapsed, so 09:00 stays 09:00 across a daylight saving change.
+func (p Participant) hours(day time.Time, from, to time.Duration) Range {
+	if to <= from {
+		to += daySize
+	}
+	at := func(d time.Duration) time.Time {
+		return time.Date(day.Year(), day.Month(), day.Day()+int(d/daySize),
+			int(d%daySize/time.Hour), int(d%time.Hour/time.Minute), 0, 0, p.Location)
+	}
+	return Range{at(from), at(to)}
+}
+
+// localDays returns midnight on each of p's local dates from the day
+// before r starts, for shifts running past midnight, to the day r ends
+func (p Participant) localDays(r Range) []time.Time {
+	var days []time.Time
+	last := getStartOfDay(r.End.In(p.Location))
+	for day := getStartOfDay(r.Start.In(p.Location)).AddDate(0, 0, -1); !day.After(last); day = day.AddDate(0, 0, 1) {
+		days = append(days, day)
+	}
+	return days
+}
+
+// available reports whether all of r is within one of p's working days
+func (p Participant) available(r Range) bool {
+	for _, day := range p.localDays(r) {
+		if !p.worksOn(day.Weekday()) {
+			continue
+		}
+		shift := p.hours(day, p.WorkStart, p.WorkEnd)
+		if !r.Start.Before(shift.Start) && !r.End.After(shift.End) {
+			return true
+		}
+	}
+	return false
+}
+
+// friendly is how much of r is between 9am and 5pm local time
+func (p Participant) friendly(r Range) time.Duration {
+	var total time.Duration
+	for _, day := range p.localDays(r) {
+		core := p.hours(day, friendlyStart, friendlyEnd)
+		start, end := later(r.Start, core.Start), earlier(r.End, core.End)
+		if start.Before(end) {
+			total += end.Sub(start)
+		}
+	}
+	return total
+}
+
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func earlier(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+// MeetingSlot is a time everyone can meet
+type MeetingSlot struct {
+	Range
+	// Score is the share of the participants' meeting time that falls
+	// between 9am and 5pm their own time: 1 when it is all within, 0 when
+	// everyone is in early or staying late
+	Score float64
+}
+
+// FindMeetingSlots returns the slots of duration within r, starting every
+// half hour from r.Start, in which every participant is working. The
+// friendliest slots come first, and the earliest among equally friendly
+// ones. There are none when the participants' working hours do not
+// overlap for long enough.
+func FindMeetingSlots(participants []Participant, duration time.Duration, within Range) []MeetingSlot {
+	if len(participants) == 0 || duration <= 0 {
+		return nil
+	}
+
+	var slots []MeetingSlot
+	for start := range within.Iter(meetingStep) {
+		slot := Range{start, start.Add(duration)}
+		if slot.End.After(within.End) {
+			break
+		}
+		var friendly time.Duration
+		ok := true
+		for _, p := range participants {
+			if !p.available(slot) {
+				ok = false
+				break
+			}
+			friendly += p.friendly(slot)
+		}
+		if ok {
+			score := float64(friendly) / float64(duration*time.Duration(len(participants)))
+			slots = append(slots, MeetingSlot{Range: slot, Score: score})
+		}
+	}
+
+	slices.SortStableFunc(slots, func(a, b MeetingSlot) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+	return slots
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func nineToFive(t *testing.T, name, zone string) Participant {
+	t.Helper()
+	return Participant{Name: name, Location: mustZone(t, zone), WorkStart: 9 * time.Hour, WorkEnd: 17 * time.Hour}
+}
+
+func TestFindMeetingSlotsNoOverlap(t *testing.T) {
+	team := []Participant{nineToFive(t, "New York", "America/New_York"), nineToFive(t, "Tokyo", "Asia/Tokyo")}
+	week := Range{date(2024, time.January, 8), date(2024, time.January, 13)}
+	if slots := FindMeetingSlots(team, 30*time.Minute, week); len(slots) != 0 {
+		t.Errorf("got %d slots, want none; first %s", len(slots), slots[0].Start)
+	}
+
+	// Overlapping, but not for long enough
+	london := nineToFive(t, "London", "Europe/London")
+	london.WorkStart = 16*time.Hour + 30*time.Minute
+	team = []Participant{nineToFive(t, "New York", "America/New_York"), london}
+	if slots := FindMeetingSlots(team, time.Hour, week); len(slots) != 0 {
+		t.Errorf("got %d one-hour slots in a half-hour overlap", len(slots))
+	}
+	if slots := FindMeetingSlots(team, 30*time.Minute, week); len(slots) != 5 {
+		t.Errorf("got %d half-hour slots in a half-hour overlap on 5 days, want 5", len(slots))
+	}
+}
+
+func TestFindMeetingSlotsDST(t *testing.T) {
+	// New York moves its clocks on March 10th 2024 and London on March
+	// 31st; for the three weeks between, the overlap is an hour longer
+	team := []Participant{nineToFive(t, "New York", "America/New_York"), nineToFive(t, "London", "Europe/London")}
+	slots := FindMeetingSlots(team, time.Hour, Range{date(2024, time.March, 4), date(2024, time.April, 6)})
+
+	perDay := map[string]int{}
+	first := map[string]string{}
+	for _, slot := range slots {
+		day := slot.Start.Format("Jan 2")
+		perDay[day]++
+		if first[day] == "" || slot.Start.Format("15:04") < first[day] {
+			first[day] = slot.Start.Format("15:04")
+		}
+		if slot.Score != 1 {
+			t.Errorf("%s: score %v, want 1 inside everyone's nine to five", slot.Start, slot.Score)
+		}
+	}
+
+	tests := []struct {
+		day   string
+		slots int
+		first string // UTC
+	}{
+		{"Mar 8", 5, "14:00"},  // New York 09:00 EST is 14:00 UTC, London closes at 17:00 GMT
+		{"Mar 11", 7, "13:00"}, // New York 09:00 EDT is 13:00 UTC
+		{"Mar 29", 7, "13:00"},
+		{"Apr 1", 5, "13:00"}, // London closes at 17:00 BST, 16:00 UTC
+	}
+	for _, tt := range tests {
+		if perDay[tt.day] != tt.slots || first[tt.day] != tt.first {
+			t.Errorf("%s: %d slots from %s UTC, want %d from %s", tt.day, perDay[tt.day], first[tt.day], tt.slots, tt.first)
+		}
+	}
+	if perDay["Mar 9"] != 0 || perDay["Mar 10"] != 0 {
+		t.Error("slots on a weekend")
+	}
+}
+
+func TestFindMeetingSlotsAcrossMidnight(t *testing.T) {
+	// A night shift in Tokyo, 22:00 to 06:00, overlaps London's day from
+	// 13:00 to 17:00 UTC: 22:00 to 02:00 in Tokyo
+	tokyo := Participant{Name: "Tokyo", Location: mustZone(t, "Asia/Tokyo"), WorkStart: 22 * time.Hour, WorkEnd: 6 * time.Hour}
+	team := []Participant{nineToFive(t, "London", "Europe/London"), tokyo}
+
+	// Friday only: the shift that starts on Friday night runs into
+	// Saturday morning in Tokyo
+	friday := Range{date(2024, time.January, 12), date(2024, time.January, 13)}
+	slots := FindMeetingSlots(team, time.Hour, friday)
+	if len(slots) != 7 {
+		t.Fatalf("got %d slots, want 7", len(slots))
+	}
+	found := false
+	for _, slot := range slots {
+		if slot.Start.Format("15:04") == "14:30" {
+			found = true
+			local := slot.Start.In(tokyo.Location).Format("Mon 15:04") + "-" + slot.End.In(tokyo.Location).Format("Mon 15:04")
+			if local != "Fri 23:30-Sat 00:30" {
+				t.Errorf("14:30 UTC in Tokyo is %s", local)
+			}
+		}
+		// Tokyo is always outside nine to five, London always inside
+		if slot.Score != 0.5 {
+			t.Errorf("%s: score %v, want 0.5", slot.Start, slot.Score)
+		}
+	}
+	if !found {
+		t.Error("no slot across midnight in Tokyo")
+	}
+
+	// A shift has to start on a working day: none starts on Sunday night
+	sunday := Range{date(2024, time.January, 14), date(2024, time.January, 15)}
+	if slots := FindMeetingSlots([]Participant{tokyo}, time.Hour, sunday); len(slots) != 0 {
+		t.Errorf("got %d slots in the Sunday night shift", len(slots))
+	}
+}
+
+func TestFindMeetingSlotsScore(t *testing.T) {
+	// New York starts at 07:00 but is friendliest from 09:00, 14:00 UTC
+	newYork := nineToFive(t, "New York", "America/New_York")
+	newYork.WorkStart, newYork.WorkEnd = 7*time.Hour, 19*time.Hour
+	team := []Participant{newYork, nineToFive(t, "London", "Europe/London")}
+	slots := FindMeetingSlots(team, time.Hour, Range{date(2024, time.January, 8), date(2024, time.January, 9)})
+
+	var got []string
+	for _, slot := range slots {
+		got = append(got, slot.Start.Format("15:04"))
+	}
+	// Friendliest first, then earliest
+	want := "14:00 14:30 15:00 15:30 16:00 13:30 12:00 12:30 13:00"
+	if joined := strings.Join(got, " "); joined != want {
+		t.Errorf("slots %s, want %s", joined, want)
+	}
+	// Until 09:00 in New York only London counts
+	scores := map[string]float64{"14:00": 1, "13:30": 0.75, "13:00": 0.5, "12:00": 0.5}
+	for _, slot := range slots {
+		if want, ok := scores[slot.Start.Format("15:04")]; ok && slot.Score != want {
+			t.Errorf("%s: score %v, want %v", slot.Start.Format("15:04"), slot.Score, want)
+		}
+	}
+}
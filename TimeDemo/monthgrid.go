@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Day is one cell of a MonthGrid
+type Day struct {
+	Date    time.Time // midnight UTC, like the holiday calendars' dates
+	InMonth bool      // false for the days before and after the month that fill its first and last weeks
+	Today   bool
+	Weekend bool
+}
+
+// MonthGrid returns the weeks of month as rows of seven days starting on
+// weekStart, as a wall calendar shows them: four to six rows, the first
+// and last filled out with days of the neighbouring months
+func MonthGrid(year int, month time.Month, weekStart time.Weekday) [][]Day {
+	return monthGrid(year, month, weekStart, time.Now())
+}
+
+// monthGrid is MonthGrid with today's date given
+func monthGrid(year int, month time.Month, weekStart time.Weekday, today time.Time) [][]Day {
+	todayDate := civilDate(today)
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	// first is normalized, so that month 13 is next January
+	year, month = first.Year(), first.Month()
+	day := StartOfWeek(first, weekStart)
+	last := time.Date(year, month, DaysInMonth(year, month), 0, 0, 0, 0, time.UTC)
+
+	var weeks [][]Day
+	for !day.After(last) {
+		week := make([]Day, 7)
+		for i := range week {
+			week[i] = Day{
+				Date:    day,
+				InMonth: day.Month() == month,
+				Today:   day.Equal(todayDate),
+				Weekend: isWeekend(day),
+			}
+			day = day.AddDate(0, 0, 1)
+		}
+		weeks = append(weeks, week)
+	}
+	return weeks
+}
+
+// ANSI escapes RenderMonth highlights with
+const (
+	ansiReset   = "\033[0m"
+	ansiReverse = "\033[7m"
+	ansiRed     = "\033[31m"
+	ansiDim     = "\033[2m"
+)
+
+// monthWidth is the width of a rendered month: seven days of a marker
+// column and two digits
+const monthWidth = 7 * 3
+
+// RenderMonth draws grid like cal(1): the month's name, the weekdays and
+// one line per week, all monthWidth wide so months can be printed side by
+// side. Days of other months are left blank.
+//
+// With color, today is shown in reverse video, holidays from cal in red
+// and weekends dimmed. Without it, today is marked with > and holidays
+// with * in the column before the day. cal may be nil.
+func RenderMonth(grid [][]Day, cal *HolidayCalendar, color bool) []string {
+	if len(grid) == 0 {
+		return nil
+	}
+	// The last day of the first week is always in the month
+	title := grid[0][6].Date.Format("January 2006")
+	lines := []string{fmt.Sprintf("%-*s", monthWidth, strings.Repeat(" ", (monthWidth-len(title))/2)+title)}
+
+	var header strings.Builder
+	for _, d := range grid[0] {
+		header.WriteString(" " + d.Date.Weekday().String()[:2])
+	}
+	lines = append(lines, header.String())
+
+	for _, week := range grid {
+		var b strings.Builder
+		for _, d := range week {
+			b.WriteString(renderDay(d, cal, color))
+		}
+		lines = append(lines, b.String())
+	}
+	return lines
+}
+
+// renderDay draws a day three columns wide: a marker column and the day
+func renderDay(d Day, cal *HolidayCalendar, color bool) string {
+	if !d.InMonth {
+		return "   "
+	}
+	holiday := false
+	if cal != nil {
+		_, holiday = cal.Holiday(d.Date)
+	}
+	day := fmt.Sprintf("%2d", d.Date.Day())
+
+	if !color {
+		switch {
+		case d.Today:
+			return ">" + day
+		case holiday:
+			return "*" + day
+		}
+		return " " + day
+	}
+	switch {
+	case d.Today:
+		return " " + ansiReverse + day + ansiReset
+	case holiday:
+		return " " + ansiRed + day + ansiReset
+	case d.Weekend:
+		return " " + ansiDim + day + ansiReset
+	}
+	return " " + day
+}
+
+// sideBySide joins rendered months into lines of months next to each
+// other, padding the shorter ones with blank lines
+func sideBySide(months ...[]string) []string {
+	rows := 0
+	for _, m := range months {
+		rows = max(rows, len(m))
+	}
+	lines := make([]string, rows)
+	for i := range lines {
+		var parts []string
+		for _, m := range months {
+			if i < len(m) {
+				parts = append(parts, m[i])
+			} else {
+				parts = append(parts, strings.Repeat(" ", monthWidth))
+			}
+		}
+		lines[i] = strings.Join(parts, "   ")
+	}
+	return lines
+}
+
+// useColor reports whether stdout is a terminal that wants color
+func useColor() bool {
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
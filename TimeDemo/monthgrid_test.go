@@ -0,0 +1,139 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMonthGridShapes(t *testing.T) {
+	// A month of 2024 starting on each weekday, and February in a leap
+	// year and a common one
+	tests := []struct {
+		year  int
+		month time.Month
+		// Rows and the column of the 1st for weeks starting on Sunday and
+		// on Monday
+		sundayRows, sundayCol int
+		mondayRows, mondayCol int
+	}{
+		{2024, time.September, 5, 0, 6, 6}, // Sunday
+		{2024, time.January, 5, 1, 5, 0},   // Monday
+		{2024, time.October, 5, 2, 5, 1},   // Tuesday
+		{2024, time.May, 5, 3, 5, 2},       // Wednesday
+		{2024, time.February, 5, 4, 5, 3},  // Thursday, 29 days
+		{2024, time.March, 6, 5, 5, 4},     // Friday, 31 days
+		{2024, time.June, 6, 6, 5, 5},      // Saturday
+		{2015, time.February, 4, 0, 5, 6},  // Sunday, 28 days: four rows exactly
+		{2021, time.February, 5, 1, 4, 0},  // Monday, 28 days
+	}
+	for _, tt := range tests {
+		for _, c := range []struct {
+			weekStart time.Weekday
+			rows, col int
+		}{{time.Sunday, tt.sundayRows, tt.sundayCol}, {time.Monday, tt.mondayRows, tt.mondayCol}} {
+			name := time.Date(tt.year, tt.month, 1, 0, 0, 0, 0, time.UTC).Format("Jan 2006") + " from " + c.weekStart.String()
+			grid := MonthGrid(tt.year, tt.month, c.weekStart)
+			if len(grid) != c.rows {
+				t.Errorf("%s: %d rows, want %d", name, len(grid), c.rows)
+				continue
+			}
+
+			inMonth := 0
+			for i, week := range grid {
+				if len(week) != 7 {
+					t.Fatalf("%s: row %d has %d days", name, i, len(week))
+				}
+				if week[0].Date.Weekday() != c.weekStart {
+					t.Errorf("%s: row %d starts on %s", name, i, week[0].Date.Weekday())
+				}
+				for j, d := range week {
+					if d.InMonth != (d.Date.Month() == tt.month) {
+						t.Errorf("%s: %s InMonth = %v", name, d.Date.Format("Jan 2"), d.InMonth)
+					}
+					if d.InMonth {
+						inMonth++
+					}
+					if d.Weekend != (d.Date.Weekday() == time.Saturday || d.Date.Weekday() == time.Sunday) {
+						t.Errorf("%s: %s Weekend = %v", name, d.Date.Format("Mon Jan 2"), d.Weekend)
+					}
+					// Consecutive days
+					if i+j > 0 && !d.Date.Equal(grid[0][0].Date.AddDate(0, 0, 7*i+j)) {
+						t.Errorf("%s: row %d column %d is %s", name, i, j, d.Date.Format("Jan 2"))
+					}
+				}
+			}
+			if want := DaysInMonth(tt.year, tt.month); inMonth != want {
+				t.Errorf("%s: %d days in the month, want %d", name, inMonth, want)
+			}
+			if first := grid[0][c.col]; first.Date.Day() != 1 || !first.InMonth {
+				t.Errorf("%s: the 1st is not in column %d", name, c.col)
+			}
+		}
+	}
+}
+
+func TestMonthGridToday(t *testing.T) {
+	// Late on the 16th in New York is already the 17th in UTC; the date in
+	// today's own location counts
+	newYork := mustZone(t, "America/New_York")
+	today := time.Date(2026, time.October, 16, 23, 0, 0, 0, newYork)
+	var marked []string
+	for _, week := range monthGrid(2026, time.October, time.Sunday, today) {
+		for _, d := range week {
+			if d.Today {
+				marked = append(marked, d.Date.Format("Jan 2"))
+			}
+		}
+	}
+	if strings.Join(marked, ",") != "Oct 16" {
+		t.Errorf("today marked on %v, want Oct 16", marked)
+	}
+
+	// Month 13 is January of the next year
+	grid := monthGrid(2026, 13, time.Sunday, today)
+	if got := grid[1][0].Date.Format("Jan 2006"); got != "Jan 2027" {
+		t.Errorf("month 13 of 2026 is %s, want Jan 2027", got)
+	}
+}
+
+func TestRenderMonth(t *testing.T) {
+	today := time.Date(2024, time.February, 14, 12, 0, 0, 0, time.UTC)
+	grid := monthGrid(2024, time.February, time.Sunday, today)
+
+	want := []string{
+		"    February 2024    ",
+		" Su Mo Tu We Th Fr Sa",
+		"              1  2  3",
+		"  4  5  6  7  8  9 10",
+		" 11 12 13>14 15 16 17",
+		" 18*19 20 21 22 23 24",
+		" 25 26 27 28 29      ",
+	}
+	got := RenderMonth(grid, USFederalHolidays(), false)
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("RenderMonth =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	for _, line := range got {
+		if len(line) != monthWidth {
+			t.Errorf("line %q is %d wide, want %d", line, len(line), monthWidth)
+		}
+	}
+
+	// Monday weeks, colored
+	colored := RenderMonth(monthGrid(2024, time.February, time.Monday, today), USFederalHolidays(), true)
+	if colored[1] != " Mo Tu We Th Fr Sa Su" {
+		t.Errorf("Monday header %q", colored[1])
+	}
+	for _, want := range []string{ansiReverse + "14" + ansiReset, ansiRed + "19" + ansiReset, ansiDim + "10" + ansiReset} {
+		if !strings.Contains(strings.Join(colored, "\n"), want) {
+			t.Errorf("colored month missing %q", want)
+		}
+	}
+
+	// Side by side, the shorter month padded
+	lines := sideBySide(got, []string{"x"})
+	if len(lines) != len(got) || lines[1] != got[1]+"   "+strings.Repeat(" ", monthWidth) {
+		t.Errorf("sideBySide = %q", lines)
+	}
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"time"
+)
+
+// NTP timestamps count seconds from 1900 in their upper 32 bits and
+// fractions of a second in the lower 32. The seconds wrap in February
+// 2036; times with the top bit clear are taken to be after that.
+const (
+	ntpEpochOffset = 2208988800 // seconds from 1900 to 1970
+	ntpPacketSize  = 48
+	ntpPort        = "123"
+)
+
+// ntpTime converts t to an NTP timestamp
+func ntpTime(t time.Time) uint64 {
+	nanos := uint64(t.UnixNano() + ntpEpochOffset*int64(time.Second))
+	secs := nanos / uint64(time.Second)
+	frac := (nanos % uint64(time.Second)) << 32 / uint64(time.Second)
+	return secs<<32 | frac
+}
+
+// fromNTPTime converts an NTP timestamp to a time, rounding the fraction
+// of a second to the nearest nanosecond
+func fromNTPTime(ts uint64) time.Time {
+	secs := int64(ts >> 32)
+	if secs&0x80000000 == 0 {
+		secs += 1 << 32 // era 1, from 2036
+	}
+	nanos := (int64(ts&0xffffffff)*int64(time.Second) + 1<<31) >> 32
+	return time.Unix(secs-ntpEpochOffset, nanos)
+}
+
+// NTPTransport sends an NTP request packet to server and returns the
+// reply; tests substitute a fake responder
+type NTPTransport interface {
+	Exchange(ctx context.Context, server string, request []byte) ([]byte, error)
+}
+
+// UDPTransport exchanges NTP packets over UDP, on port 123 unless server
+// names another
+type UDPTransport struct{}
+
+// Exchange sends request and reads one reply, giving up when ctx is done
+func (UDPTransport) Exchange(ctx context.Context, server string, request []byte) ([]byte, error) {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, ntpPort)
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp", server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if _, err := conn.Write(request); err != nil {
+		return nil, err
+	}
+	reply := make([]byte, ntpPacketSize)
+	n, err := conn.Read(reply)
+	if err != nil {
+		return nil, err
+	}
+	return reply[:n], nil
+}
+
+// NTPSample is one server's answer
+type NTPSample struct {
+	Server string
+	// Offset is how far the server's clock is ahead of ours: add it to
+	// local time to get network time
+	Offset time.Duration
+	// Delay is the round trip, without the time the server took to reply
+	Delay   time.Duration
+	Stratum int // 1 for servers with a reference clock, 2 for their clients...
+	Err     error
+}
+
+// DriftReport is the result of a drift check
+type DriftReport struct {
+	// Offset is the median of the servers' offsets, which a single wrong
+	// server cannot pull far
+	Offset  time.Duration
+	Samples []NTPSample // in the order the servers were given
+}
+
+// DriftChecker measures the local clock against NTP servers with a simple
+// SNTP (RFC 4330) client
+type DriftChecker struct {
+	Transport NTPTransport  // UDPTransport when nil
+	Clock     Clock         // the clock being checked, RealClock when nil
+	Timeout   time.Duration // per server; 5 seconds when 0
+}
+
+// CheckDrift queries servers over UDP, each with timeout, and reports how
+// far the local clock is off
+func CheckDrift(servers []string, timeout time.Duration) (DriftReport, error) {
+	return DriftChecker{Timeout: timeout}.Check(servers)
+}
+
+// Check queries the servers at the same time. It fails only if none of
+// them answered; the samples hold each server's error.
+func (c DriftChecker) Check(servers []string) (DriftReport, error) {
+	if c.Transport == nil {
+		c.Transport = UDPTransport{}
+	}
+	if c.Clock == nil {
+		c.Clock = RealClock{}
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = 5 * time.Second
+	}
+
+	report := DriftReport{Samples: make([]NTPSample, len(servers))}
+	done := make(chan struct{})
+	for i, server := range servers {
+		go func() {
+			report.Samples[i] = c.query(server)
+			done <- struct{}{}
+		}()
+	}
+	for range servers {
+		<-done
+	}
+
+	var offsets []time.Duration
+	var errs []error
+	for _, s := range report.Samples {
+		if s.Err != nil {
+			errs = append(errs, s.Err)
+		} else {
+			offsets = append(offsets, s.Offset)
+		}
+	}
+	if len(offsets) == 0 {
+		return report, fmt.Errorf("no NTP server answered: %w", errors.Join(errs...))
+	}
+	report.Offset = median(offsets)
+	return report, nil
+}
+
+// query asks one server, giving up after the timeout even if the transport
+// does not
+func (c DriftChecker) query(server string) NTPSample {
+	sample := NTPSample{Server: server}
+	ctx, cancel := context.WithTimeout(context.Background(), c.Timeout)
+	defer cancel()
+
+	// Version 4, client mode; our transmit time comes back as the
+	// originate time, which ties the reply to this request
+	request := make([]byte, ntpPacketSize)
+	request[0] = 4<<3 | 3
+	t1 := c.Clock.Now()
+	binary.BigEndian.PutUint64(request[40:], ntpTime(t1))
+
+	type result struct {
+		reply []byte
+		err   error
+	}
+	results := make(chan result, 1) // buffered, so a late transport does not block
+	go func() {
+		reply, err := c.Transport.Exchange(ctx, server, request)
+		results <- result{reply, err}
+	}()
+	var r result
+	select {
+	case r = <-results:
+	case <-ctx.Done():
+		r.err = ctx.Err()
+	}
+	t4 := c.Clock.Now()
+	if r.err != nil {
+		sample.Err = fmt.Errorf("%s: %w", server, r.err)
+		return sample
+	}
+
+	reply := r.reply
+	switch {
+	case len(reply) < ntpPacketSize:
+		sample.Err = fmt.Errorf("%s: short reply of %d bytes", server, len(reply))
+	case reply[0]&7 != 4:
+		sample.Err = fmt.Errorf("%s: reply is not from a server (mode %d)", server, reply[0]&7)
+	case reply[0]>>6 == 3:
+		sample.Err = fmt.Errorf("%s: server clock is not synchronized", server)
+	case reply[1] == 0:
+		sample.Err = fmt.Errorf("%s: server refused the request (kiss code %q)", server, reply[12:16])
+	case binary.BigEndian.Uint64(reply[24:]) != binary.BigEndian.Uint64(request[40:]):
+		sample.Err = fmt.Errorf("%s: reply does not match the request", server)
+	}
+	if sample.Err != nil {
+		return sample
+	}
+
+	// t2 and t3 are when the server received the request and sent the
+	// reply, by its clock; the offset assumes the trip took as long each
+	// way
+	t2 := fromNTPTime(binary.BigEndian.Uint64(reply[32:]))
+	t3 := fromNTPTime(binary.BigEndian.Uint64(reply[40:]))
+	sample.Offset = (t2.Sub(t1) + t3.Sub(t4)) / 2
+	sample.Delay = t4.Sub(t1) - t3.Sub(t2)
+	sample.Stratum = int(reply[1])
+	return sample
+}
+
+// median returns the middle value, or the mean of the middle two
+func median(values []time.Duration) time.Duration {
+	sorted := slices.Sorted(slices.Values(values))
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return sorted[mid]
+	}
+	return sorted[mid-1] + (sorted[mid]-sorted[mid-1])/2
+}
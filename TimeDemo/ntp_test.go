@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNTPTimeConversion(t *testing.T) {
+	tests := []struct {
+		time time.Time
+		ntp  uint64
+	}{
+		{time.Unix(0, 0), 2208988800 << 32},
+		{time.Unix(0, 500_000_000), 2208988800<<32 | 1<<31},
+		{time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC), (2208988800 + 1709294400) << 32},
+		// The seconds wrap in 2036 and era 1 starts again from 0
+		{time.Date(2036, time.February, 7, 6, 28, 15, 0, time.UTC), 0xffffffff << 32},
+		{time.Date(2036, time.February, 7, 6, 28, 16, 0, time.UTC), 0},
+		{time.Date(2040, time.January, 1, 0, 0, 0, 0, time.UTC), (2208988800 + 2208988800 - 1<<32) << 32}, // 2040 is 2208988800s after 1970,
+	}
+	for _, tt := range tests {
+		if got := ntpTime(tt.time); got != tt.ntp {
+			t.Errorf("ntpTime(%v) = %#x, want %#x", tt.time, got, tt.ntp)
+		}
+		if got := fromNTPTime(tt.ntp); !got.Equal(tt.time) {
+			t.Errorf("fromNTPTime(%#x) = %v, want %v", tt.ntp, got, tt.time)
+		}
+	}
+
+	// Fractions of a second survive the round trip to the nanosecond
+	want := time.Date(2024, time.March, 1, 12, 0, 0, 123456789, time.UTC)
+	if got := fromNTPTime(ntpTime(want)); !got.Equal(want) {
+		t.Errorf("round trip of %v = %v", want, got)
+	}
+}
+
+// fakeNTPServer answers requests like an NTP server whose clock is offset
+// ahead of clock. The request takes delay to arrive, the server takes
+// processing to reply, and the reply takes delay to come back.
+type fakeNTPServer struct {
+	clock             *FakeClock
+	offset            time.Duration
+	delay, processing time.Duration
+	stratum           byte
+	hang              bool // never answer
+	mangle            func(reply []byte)
+}
+
+func (s *fakeNTPServer) Exchange(ctx context.Context, server string, request []byte) ([]byte, error) {
+	if s.hang {
+		<-ctx.Done()
+		return nil, errors.New("read udp: i/o timeout")
+	}
+	reply := make([]byte, ntpPacketSize)
+	reply[0] = 4<<3 | 4
+	reply[1] = s.stratum
+	copy(reply[24:32], request[40:48])
+	s.clock.Advance(s.delay)
+	binary.BigEndian.PutUint64(reply[32:], ntpTime(s.clock.Now().Add(s.offset)))
+	s.clock.Advance(s.processing)
+	binary.BigEndian.PutUint64(reply[40:], ntpTime(s.clock.Now().Add(s.offset)))
+	s.clock.Advance(s.delay)
+	if s.mangle != nil {
+		s.mangle(reply)
+	}
+	return reply, nil
+}
+
+func TestCheckDriftOffset(t *testing.T) {
+	for _, offset := range []time.Duration{0, 1500 * time.Millisecond, -3 * time.Second, 250 * time.Microsecond} {
+		clock := NewFakeClock(time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC))
+		server := &fakeNTPServer{clock: clock, offset: offset, delay: 20 * time.Millisecond, processing: 5 * time.Millisecond, stratum: 2}
+		c := DriftChecker{Transport: server, Clock: clock, Timeout: time.Second}
+		report, err := c.Check([]string{"ntp.example"})
+		if err != nil {
+			t.Fatalf("offset %v: %v", offset, err)
+		}
+		s := report.Samples[0]
+		if report.Offset != offset || s.Offset != offset {
+			t.Errorf("offset %v: measured %v (sample %v)", offset, report.Offset, s.Offset)
+		}
+		if s.Delay != 40*time.Millisecond {
+			t.Errorf("offset %v: delay = %v, want 40ms", offset, s.Delay)
+		}
+		if s.Stratum != 2 || s.Server != "ntp.example" {
+			t.Errorf("offset %v: sample = %+v", offset, s)
+		}
+	}
+}
+
+// multiServer routes each server name to its own fake
+type multiServer map[string]*fakeNTPServer
+
+func (m multiServer) Exchange(ctx context.Context, server string, request []byte) ([]byte, error) {
+	return m[server].Exchange(ctx, server, request)
+}
+
+func TestCheckDriftMedian(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC))
+	servers := func(offsets ...time.Duration) (multiServer, []string) {
+		m := multiServer{}
+		var names []string
+		for i, offset := range offsets {
+			name := string(rune('a'+i)) + ".ntp.example"
+			m[name] = &fakeNTPServer{clock: clock, offset: offset, stratum: 1}
+			names = append(names, name)
+		}
+		return m, names
+	}
+
+	tests := []struct {
+		offsets []time.Duration
+		want    time.Duration
+	}{
+		{[]time.Duration{time.Second}, time.Second},
+		// One server far out does not move the median
+		{[]time.Duration{100 * time.Millisecond, time.Hour, 120 * time.Millisecond}, 120 * time.Millisecond},
+		{[]time.Duration{-time.Second, 300 * time.Millisecond, 200 * time.Millisecond, 5 * time.Second}, 250 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		m, names := servers(tt.offsets...)
+		report, err := DriftChecker{Transport: m, Clock: clock, Timeout: time.Second}.Check(names)
+		if err != nil {
+			t.Fatalf("%v: %v", tt.offsets, err)
+		}
+		if report.Offset != tt.want {
+			t.Errorf("%v: offset = %v, want %v", tt.offsets, report.Offset, tt.want)
+		}
+		for i, s := range report.Samples {
+			if s.Server != names[i] || s.Offset != tt.offsets[i] {
+				t.Errorf("%v: sample %d = %+v", tt.offsets, i, s)
+			}
+		}
+	}
+}
+
+func TestCheckDriftTimeout(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC))
+	m := multiServer{
+		"slow.example": {clock: clock, hang: true},
+		"good.example": {clock: clock, offset: 2 * time.Second, stratum: 1},
+	}
+	c := DriftChecker{Transport: m, Clock: clock, Timeout: 20 * time.Millisecond}
+
+	// A server that never answers is given up on, and the others still count
+	report, err := c.Check([]string{"slow.example", "good.example"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Offset != 2*time.Second {
+		t.Errorf("offset = %v, want 2s", report.Offset)
+	}
+	if err := report.Samples[0].Err; !errors.Is(err, context.DeadlineExceeded) || !strings.HasPrefix(err.Error(), "slow.example: ") {
+		t.Errorf("slow server error = %v, want its deadline exceeded", err)
+	}
+
+	// A transport that ignores the context is given up on too
+	stuck := make(chan struct{})
+	defer close(stuck)
+	c.Transport = transportFunc(func(context.Context, string, []byte) ([]byte, error) {
+		<-stuck
+		return nil, nil
+	})
+	start := time.Now()
+	_, err = c.Check([]string{"stuck.example"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("stuck transport: err = %v, want deadline exceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("stuck transport took %v to give up", elapsed)
+	}
+
+	// With no answers at all the check fails
+	_, err = DriftChecker{Transport: m, Clock: clock, Timeout: 20 * time.Millisecond}.Check([]string{"slow.example"})
+	if err == nil || !strings.Contains(err.Error(), "no NTP server answered") {
+		t.Errorf("err = %v, want no NTP server answered", err)
+	}
+}
+
+type transportFunc func(ctx context.Context, server string, request []byte) ([]byte, error)
+
+func (f transportFunc) Exchange(ctx context.Context, server string, request []byte) ([]byte, error) {
+	return f(ctx, server, request)
+}
+
+func TestCheckDriftBadReplies(t *testing.T) {
+	tests := []struct {
+		name   string
+		mangle func(reply []byte)
+		want   string
+	}{
+		{"short", nil, "short reply"},
+		{"client mode", func(r []byte) { r[0] = 4<<3 | 3 }, "not from a server"},
+		{"unsynchronized", func(r []byte) { r[0] |= 3 << 6 }, "not synchronized"},
+		{"kiss of death", func(r []byte) { r[1] = 0; copy(r[12:], "RATE") }, `kiss code "RATE"`},
+		{"wrong originate", func(r []byte) { r[31]++ }, "does not match"},
+	}
+	for _, tt := range tests {
+		clock := NewFakeClock(time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC))
+		server := &fakeNTPServer{clock: clock, stratum: 1, mangle: tt.mangle}
+		var transport NTPTransport = server
+		if tt.mangle == nil {
+			transport = transportFunc(func(ctx context.Context, name string, req []byte) ([]byte, error) {
+				reply, err := server.Exchange(ctx, name, req)
+				return reply[:40], err
+			})
+		}
+		report, err := DriftChecker{Transport: transport, Clock: clock}.Check([]string{"ntp.example"})
+		if err == nil || !strings.Contains(report.Samples[0].Err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrNonexistentTime is returned for a wall-clock time skipped by a
+// daylight saving change, such as 02:30 on 2024-03-10 in New York
+var ErrNonexistentTime = errors.New("time does not exist in this zone")
+
+// flexibleLayouts are tried in order by ParseFlexible and ParseAny.
+// Slash dates are month first, as in the US, then day first for dates
+// such as 25/12/2023 that can't be. Layouts without a date are times of
+// day.
+var flexibleLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02 3:04 PM",
+	"2006-01-02 3:04PM",
+	"2006-01-02",
+	"2006/01/02 15:04:05",
+	"2006/01/02 15:04",
+	"2006/01/02",
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC822Z,
+	time.RFC822,
+	time.UnixDate,
+	time.ANSIC,
+	"1/2/2006 15:04:05",
+	"1/2/2006 15:04",
+	"1/2/2006 3:04 PM",
+	"1/2/2006",
+	"2/1/2006 15:04:05",
+	"2/1/2006 15:04",
+	"2/1/2006 3:04 PM",
+	"2/1/2006",
+	"Jan 2, 2006 15:04",
+	"Jan 2, 2006 3:04 PM",
+	"Jan 2, 2006",
+	"January 2, 2006 15:04",
+	"January 2, 2006 3:04 PM",
+	"January 2, 2006",
+	"2 Jan 2006 15:04",
+	"2 January 2006 15:04",
+	"2 Jan 2006",
+	"2 January 2006",
+	time.Kitchen,
+	"3:04 PM",
+	"15:04",
+}
+
+// errNoLayout is parseLayouts finding no layout that matches
+var errNoLayout = errors.New("no layout matches")
+
+// ParseFlexible parses input with the first matching layout in
+// flexibleLayouts. Inputs without an offset are wall-clock times in loc;
+// one skipped by a daylight saving change fails with ErrNonexistentTime,
+// and one repeated by it resolves to the first occurrence. A time of day
+// is today.
+func ParseFlexible(input string, loc *time.Location) (time.Time, error) {
+	input = strings.Join(strings.Fields(input), " ")
+	if loc == nil {
+		loc = time.Local
+	}
+
+	t, _, err := parseLayouts(input, flexibleLayouts, loc, time.Now())
+	if errors.Is(err, errNoLayout) {
+		return time.Time{}, fmt.Errorf("cannot parse %q; use e.g. \"2006-01-02 15:04\", \"01/02/2006 3:04 PM\" or RFC 3339", input)
+	}
+	return t, err
+}
+
+// parseLayouts parses input with the first of layouts that matches and
+// returns that layout, or errNoLayout. Layouts without a date are times
+// of day on now's date in loc.
+func parseLayouts(input string, layouts []string, loc *time.Location, now time.Time) (time.Time, string, error) {
+	for _, layout := range layouts {
+		t, err := time.ParseInLocation(layout, input, loc)
+		if err != nil {
+			continue
+		}
+		if layoutHasZone(layout) {
+			return t, layout, nil
+		}
+
+		wall, _ := time.Parse(layout, input)
+		if !layoutHasDate(layout) {
+			now := now.In(loc)
+			wall = time.Date(now.Year(), now.Month(), now.Day(), wall.Hour(), wall.Minute(), wall.Second(), wall.Nanosecond(), time.UTC)
+			t = time.Date(now.Year(), now.Month(), now.Day(), wall.Hour(), wall.Minute(), wall.Second(), wall.Nanosecond(), loc)
+		}
+		if !sameWallClock(t, wall) {
+			return time.Time{}, layout, fmt.Errorf("%q in %s: %w (clocks skip ahead)", input, loc, ErrNonexistentTime)
+		}
+		return t, layout, nil
+	}
+	return time.Time{}, "", errNoLayout
+}
+
+// sameWallClock reports whether t shows the date and time of wall.
+// time.Date moves times skipped by a daylight saving change instead of
+// rejecting them, so a mismatch means the written time does not exist.
+func sameWallClock(t, wall time.Time) bool {
+	return t.Year() == wall.Year() && t.YearDay() == wall.YearDay() &&
+		t.Hour() == wall.Hour() && t.Minute() == wall.Minute() && t.Second() == wall.Second()
+}
+
+// layoutHasZone reports whether layout reads an offset or zone name, in
+// which case loc does not apply
+func layoutHasZone(layout string) bool {
+	return strings.Contains(layout, "Z07") || strings.Contains(layout, "-0700") || strings.Contains(layout, "MST")
+}
+
+// layoutHasDate reports whether layout reads a year; those that do not,
+// like time.Kitchen, are times of day
+func layoutHasDate(layout string) bool {
+	return strings.Contains(layout, "06")
+}
+
+// dayFirstLayouts is layouts with day and month swapped in the slash
+// layouts, so that the day-first ones are tried where the month-first ones
+// were
+func dayFirstLayouts(layouts []string) []string {
+	swapped := make([]string, len(layouts))
+	for i, layout := range layouts {
+		if rest, ok := strings.CutPrefix(layout, "1/2/"); ok {
+			layout = "2/1/" + rest
+		} else if rest, ok := strings.CutPrefix(layout, "2/1/"); ok {
+			layout = "1/2/" + rest
+		}
+		swapped[i] = layout
+	}
+	return swapped
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func mustZone(t *testing.T, name string) *time.Location {
+	t.Helper()
+
+	loc, err := ResolveZone(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+func TestParseFlexibleLayouts(t *testing.T) {
+	tokyo := mustZone(t, "Asia/Tokyo")
+	want := time.Date(2024, time.December, 25, 15, 30, 0, 0, tokyo)
+
+	inputs := []string{
+		"2024-12-25 15:30",
+		"2024-12-25 15:30:00",
+		"2024-12-25T15:30",
+		"2024-12-25 3:30 PM",
+		"2024/12/25 15:30",
+		"12/25/2024 15:30",
+		"12/25/2024 3:30 PM",
+		"25/12/2024 15:30",
+		"Dec 25, 2024 15:30",
+		"Dec 25, 2024 3:30 PM",
+		"December 25, 2024 3:30 PM",
+		"25 Dec 2024 15:30",
+		"  2024-12-25   15:30 ",
+		"2024-12-25T15:30:00+09:00",
+		"2024-12-25T06:30:00Z",
+	}
+	for _, input := range inputs {
+		got, err := ParseFlexible(input, tokyo)
+		if err != nil {
+			t.Errorf("ParseFlexible(%q): %v", input, err)
+			continue
+		}
+		if !got.Equal(want) {
+			t.Errorf("ParseFlexible(%q) = %s, want %s", input, got, want)
+		}
+	}
+
+	if got, err := ParseFlexible("2024-12-25", tokyo); err != nil || !got.Equal(time.Date(2024, 12, 25, 0, 0, 0, 0, tokyo)) {
+		t.Errorf("date only: %s, %v", got, err)
+	}
+	for _, input := range []string{"", "tomorrow", "2024-13-01 10:00", "2024-02-30"} {
+		if _, err := ParseFlexible(input, tokyo); err == nil {
+			t.Errorf("ParseFlexible(%q) succeeded", input)
+		}
+	}
+}
+
+func TestParseFlexibleDST(t *testing.T) {
+	eastern := mustZone(t, "US/Eastern")
+	london := mustZone(t, "Europe/London")
+
+	tests := []struct {
+		name    string
+		input   string
+		loc     *time.Location
+		wantUTC string
+		wantErr error
+	}{
+		{"before spring forward", "2024-03-10 01:59", eastern, "2024-03-10T06:59:00Z", nil},
+		{"skipped by spring forward", "2024-03-10 02:30", eastern, "", ErrNonexistentTime},
+		{"first minute skipped", "2024-03-10 02:00", eastern, "", ErrNonexistentTime},
+		{"after spring forward", "2024-03-10 03:00", eastern, "2024-03-10T07:00:00Z", nil},
+		{"repeated by fall back: first occurrence", "2024-11-03 01:30", eastern, "2024-11-03T05:30:00Z", nil},
+		{"after fall back", "2024-11-03 02:00", eastern, "2024-11-03T07:00:00Z", nil},
+		{"skipped in London", "2024-03-31 01:30", london, "", ErrNonexistentTime},
+		{"explicit offset is never skipped", "2024-03-10T02:30:00-05:00", eastern, "2024-03-10T07:30:00Z", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFlexible(tt.input, tt.loc)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && got.UTC().Format(time.RFC3339) != tt.wantUTC {
+				t.Errorf("got %s, want %s", got.UTC().Format(time.RFC3339), tt.wantUTC)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Layout names ParseAny returns for inputs that are not matched by a
+// time layout
+const (
+	LayoutUnixSeconds = "unix seconds"
+	LayoutUnixMillis  = "unix milliseconds"
+	LayoutUnixMicros  = "unix microseconds"
+	LayoutUnixNanos   = "unix nanoseconds"
+)
+
+// parseConfig holds the ParseAny options
+type parseConfig struct {
+	loc      *time.Location
+	clock    Clock
+	layouts  []string
+	dayFirst bool
+}
+
+// ParseOption configures ParseAny
+type ParseOption func(*parseConfig)
+
+// WithLocation reads times without an offset in loc instead of time.Local
+func WithLocation(loc *time.Location) ParseOption {
+	return func(c *parseConfig) { c.loc = loc }
+}
+
+// WithClock sets the clock for "now", "today", "yesterday", "tomorrow"
+// and times of day such as "3:04PM", which mean today
+func WithClock(clock Clock) ParseOption {
+	return func(c *parseConfig) { c.clock = clock }
+}
+
+// WithLayouts replaces the layouts ParseFlexible tries
+func WithLayouts(layouts ...string) ParseOption {
+	return func(c *parseConfig) { c.layouts = layouts }
+}
+
+// WithDayFirst reads ambiguous slash dates such as 01/02/2023 day first,
+// as in Europe: February 1st rather than January 2nd
+func WithDayFirst() ParseOption {
+	return func(c *parseConfig) { c.dayFirst = true }
+}
+
+// ParseAnyError lists everything ParseAny tried
+type ParseAnyError struct {
+	Input    string
+	Attempts []string
+}
+
+func (e *ParseAnyError) Error() string {
+	return fmt.Sprintf("cannot parse %q; tried %d formats: %s", e.Input, len(e.Attempts), strings.Join(e.Attempts, ", "))
+}
+
+// ParseAny parses s with the first format that matches, returning the
+// time and the layout used. It tries, in order:
+//
+//   - the keywords now, today, yesterday and tomorrow (the last three at
+//     midnight)
+//   - the layouts ParseFlexible tries, unless WithLayouts is given; times
+//     of day without a date are today
+//   - a Unix timestamp, whose unit is guessed from its size: below 1e11 is
+//     seconds (up to the year 5138), below 1e14 milliseconds, below 1e17
+//     microseconds, and nanoseconds above that
+//
+// Times without an offset are in the WithLocation location, time.Local by
+// default; one skipped by a daylight saving change fails with
+// ErrNonexistentTime.
+func ParseAny(s string, opts ...ParseOption) (time.Time, string, error) {
+	cfg := parseConfig{loc: time.Local, clock: RealClock{}, layouts: flexibleLayouts}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	input := strings.Join(strings.Fields(s), " ")
+	now := cfg.clock.Now().In(cfg.loc)
+
+	today := getStartOfDay(now)
+	switch strings.ToLower(input) {
+	case "now":
+		return now, "now", nil
+	case "today":
+		return today, "today", nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), "yesterday", nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), "tomorrow", nil
+	}
+
+	layouts := cfg.layouts
+	if cfg.dayFirst {
+		layouts = dayFirstLayouts(layouts)
+	}
+	t, layout, err := parseLayouts(input, layouts, cfg.loc, now)
+	if !errors.Is(err, errNoLayout) {
+		return t, layout, err
+	}
+
+	if n, err := strconv.ParseInt(input, 10, 64); err == nil {
+		t, layout := unixTime(n)
+		return t.In(cfg.loc), layout, nil
+	}
+	attempts := append([]string{"now/today/yesterday/tomorrow"}, layouts...)
+	return time.Time{}, "", &ParseAnyError{Input: s, Attempts: append(attempts, "unix timestamp")}
+}
+
+// unixTime converts a Unix timestamp, guessing its unit from its size
+func unixTime(n int64) (time.Time, string) {
+	abs := n
+	if abs < 0 {
+		abs = -abs
+	}
+	switch {
+	case abs < 1e11:
+		return time.Unix(n, 0), LayoutUnixSeconds
+	case abs < 1e14:
+		return time.UnixMilli(n), LayoutUnixMillis
+	case abs < 1e17:
+		return time.UnixMicro(n), LayoutUnixMicros
+	}
+	return time.Unix(0, n), LayoutUnixNanos
+}
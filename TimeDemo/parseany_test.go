@@ -0,0 +1,133 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseAny(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, time.March, 15, 10, 20, 30, 0, time.UTC))
+	newYork := mustZone(t, "America/New_York")
+
+	tests := []struct {
+		input      string
+		opts       []ParseOption
+		want       time.Time
+		wantLayout string
+	}{
+		{"2023-12-25T15:30:45+02:00", nil, time.Date(2023, time.December, 25, 13, 30, 45, 0, time.UTC), time.RFC3339},
+		{"2023-12-25", nil, date(2023, time.December, 25), "2006-01-02"},
+		{"Mon, 25 Dec 2023 15:30:45 +0100", nil, time.Date(2023, time.December, 25, 14, 30, 45, 0, time.UTC), time.RFC1123Z},
+		{"Dec 25, 2023", nil, date(2023, time.December, 25), "Jan 2, 2006"},
+		{"  25   December 2023 ", nil, date(2023, time.December, 25), "2 January 2006"},
+
+		// Ambiguous slash dates are month first unless WithDayFirst is given;
+		// impossible months fall through to the other order
+		{"01/02/2023", nil, date(2023, time.January, 2), "1/2/2006"},
+		{"01/02/2023", []ParseOption{WithDayFirst()}, date(2023, time.February, 1), "2/1/2006"},
+		{"13/02/2023", nil, date(2023, time.February, 13), "2/1/2006"},
+		{"02/13/2023", []ParseOption{WithDayFirst()}, date(2023, time.February, 13), "1/2/2006"},
+		{"1/2/2023 9:05", nil, time.Date(2023, time.January, 2, 9, 5, 0, 0, time.UTC), "1/2/2006 15:04"},
+
+		// Keywords and times of day are read from the clock
+		{"now", nil, clock.Now(), "now"},
+		{"Today", nil, date(2024, time.March, 15), "today"},
+		{"yesterday", nil, date(2024, time.March, 14), "yesterday"},
+		{"tomorrow", nil, date(2024, time.March, 16), "tomorrow"},
+		{"3:04PM", nil, time.Date(2024, time.March, 15, 15, 4, 0, 0, time.UTC), time.Kitchen},
+		{"7:45 AM", nil, time.Date(2024, time.March, 15, 7, 45, 0, 0, time.UTC), "3:04 PM"},
+		{"18:30", nil, time.Date(2024, time.March, 15, 18, 30, 0, 0, time.UTC), "15:04"},
+
+		// The location applies to times without an offset only
+		{"2024-07-04 12:00", []ParseOption{WithLocation(newYork)}, time.Date(2024, time.July, 4, 16, 0, 0, 0, time.UTC), "2006-01-02 15:04"},
+		{"2024-07-04T12:00:00Z", []ParseOption{WithLocation(newYork)}, time.Date(2024, time.July, 4, 12, 0, 0, 0, time.UTC), time.RFC3339},
+		{"today", []ParseOption{WithLocation(newYork)}, time.Date(2024, time.March, 15, 4, 0, 0, 0, time.UTC), "today"},
+
+		// Custom layouts replace the defaults
+		{"2023.12.25", []ParseOption{WithLayouts("2006.01.02")}, date(2023, time.December, 25), "2006.01.02"},
+	}
+	for _, tt := range tests {
+		opts := append([]ParseOption{WithClock(clock), WithLocation(time.UTC)}, tt.opts...)
+		got, layout, err := ParseAny(tt.input, opts...)
+		if err != nil {
+			t.Errorf("ParseAny(%q): %v", tt.input, err)
+			continue
+		}
+		if !got.Equal(tt.want) || layout != tt.wantLayout {
+			t.Errorf("ParseAny(%q) = %s (%s), want %s (%s)", tt.input, got, layout, tt.want, tt.wantLayout)
+		}
+	}
+}
+
+func TestParseAnyEpoch(t *testing.T) {
+	want := time.Date(2023, time.December, 25, 15, 30, 45, 0, time.UTC)
+
+	tests := []struct {
+		input      string
+		want       time.Time
+		wantLayout string
+	}{
+		{"1703518245", want, LayoutUnixSeconds},
+		{"1703518245123", want.Add(123 * time.Millisecond), LayoutUnixMillis},
+		{"1703518245123456", want.Add(123456 * time.Microsecond), LayoutUnixMicros},
+		{"1703518245123456789", want.Add(123456789), LayoutUnixNanos},
+		// 1e11 seconds is the year 5138; below that is seconds, above is
+		// milliseconds from March 1973 on
+		{"99999999999", time.Unix(99999999999, 0), LayoutUnixSeconds},
+		{"100000000000", time.UnixMilli(100000000000), LayoutUnixMillis},
+		{"0", time.Unix(0, 0), LayoutUnixSeconds},
+		{"-86400", date(1969, time.December, 31), LayoutUnixSeconds},
+		// The guess goes by size alone, so this is seconds, not a day of
+		// milliseconds before 1970
+		{"-86400000", date(1967, time.April, 7), LayoutUnixSeconds},
+	}
+	for _, tt := range tests {
+		got, layout, err := ParseAny(tt.input, WithLocation(time.UTC))
+		if err != nil {
+			t.Errorf("ParseAny(%q): %v", tt.input, err)
+			continue
+		}
+		if !got.Equal(tt.want) || layout != tt.wantLayout {
+			t.Errorf("ParseAny(%q) = %s (%s), want %s (%s)", tt.input, got, layout, tt.want, tt.wantLayout)
+		}
+		if got.Location() != time.UTC {
+			t.Errorf("ParseAny(%q) is in %s, want UTC", tt.input, got.Location())
+		}
+	}
+}
+
+func TestParseAnyErrors(t *testing.T) {
+	_, _, err := ParseAny("next thursday", WithLayouts("2006-01-02", time.Kitchen))
+	var parseErr *ParseAnyError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("err = %v, want a *ParseAnyError", err)
+	}
+	wantAttempts := []string{"now/today/yesterday/tomorrow", "2006-01-02", time.Kitchen, "unix timestamp"}
+	if strings.Join(parseErr.Attempts, "|") != strings.Join(wantAttempts, "|") {
+		t.Errorf("Attempts = %q, want %q", parseErr.Attempts, wantAttempts)
+	}
+	want := `cannot parse "next thursday"; tried 4 formats: now/today/yesterday/tomorrow, 2006-01-02, 3:04PM, unix timestamp`
+	if err.Error() != want {
+		t.Errorf("err = %q, want %q", err, want)
+	}
+
+	// Every default layout is listed
+	_, _, err = ParseAny("")
+	for _, layout := range flexibleLayouts {
+		if !strings.Contains(err.Error(), layout) {
+			t.Errorf("error does not list %q: %v", layout, err)
+		}
+	}
+
+	// Times skipped by a daylight saving change do not exist
+	newYork := mustZone(t, "America/New_York")
+	if _, _, err := ParseAny("2024-03-10 02:30", WithLocation(newYork)); !errors.Is(err, ErrNonexistentTime) {
+		t.Errorf("2:30 on the spring-forward day: err = %v, want ErrNonexistentTime", err)
+	}
+	clock := NewFakeClock(time.Date(2024, time.March, 10, 12, 0, 0, 0, newYork))
+	if _, _, err := ParseAny("2:30 AM", WithLocation(newYork), WithClock(clock)); !errors.Is(err, ErrNonexistentTime) {
+		t.Errorf("2:30 AM today on the spring-forward day: err = %v, want ErrNonexistentTime", err)
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"iter"
+	"sort"
+	"time"
+)
+
+// CalendarUnit is a calendar period for Range.IterCalendar
+type CalendarUnit int
+
+const (
+	UnitDay CalendarUnit = iota
+	UnitWeek
+	UnitMonth
+	UnitQuarter
+)
+
+func (u CalendarUnit) String() string {
+	switch u {
+	case UnitDay:
+		return "day"
+	case UnitWeek:
+		return "week"
+	case UnitMonth:
+		return "month"
+	case UnitQuarter:
+		return "quarter"
+	}
+	return fmt.Sprintf("CalendarUnit(%d)", int(u))
+}
+
+// add moves t by n units on the wall clock, so days stay at the same time
+// of day across daylight saving changes and months clamp to shorter ones
+func (u CalendarUnit) add(t time.Time, n int) time.Time {
+	switch u {
+	case UnitWeek:
+		return t.AddDate(0, 0, 7*n)
+	case UnitMonth:
+		return addMonthsClamped(t, n)
+	case UnitQuarter:
+		return addMonthsClamped(t, 3*n)
+	}
+	return t.AddDate(0, 0, n)
+}
+
+// Range is the half-open interval [Start, End)
+type Range struct {
+	Start, End time.Time
+}
+
+// Contains reports whether t is in the range
+func (r Range) Contains(t time.Time) bool {
+	return !t.Before(r.Start) && t.Before(r.End)
+}
+
+// Duration is the elapsed time from Start to End
+func (r Range) Duration() time.Duration {
+	return r.End.Sub(r.Start)
+}
+
+// Iter yields Start, Start+step, ... before End. Steps are elapsed time,
+// so 24-hour steps drift an hour on the wall clock across a daylight
+// saving change; IterCalendar does not. A step <= 0 yields nothing.
+func (r Range) Iter(step time.Duration) iter.Seq[time.Time] {
+	return func(yield func(time.Time) bool) {
+		if step <= 0 {
+			return
+		}
+		for t := r.Start; t.Before(r.End); t = t.Add(step) {
+			if !yield(t) {
+				return
+			}
+		}
+	}
+}
+
+// IterCalendar yields consecutive periods of unit from Start, the last one
+// cut short at End. A day is 23 or 25 hours long across a daylight saving
+// change, and months starting on the 31st continue on the last day of
+// shorter months.
+func (r Range) IterCalendar(unit CalendarUnit) iter.Seq[Range] {
+	return func(yield func(Range) bool) {
+		// Count from Start rather than from the previous period, so that
+		// Jan 31, Feb 29 is followed by Mar 31
+		for n := 0; ; n++ {
+			start := unit.add(r.Start, n)
+			if !start.Before(r.End) {
+				return
+			}
+			end := unit.add(r.Start, n+1)
+			if end.After(r.End) {
+				end = r.End
+			}
+			if !yield(Range{start, end}) {
+				return
+			}
+		}
+	}
+}
+
+// Bucket is a period and the number of times that fell in it
+type Bucket struct {
+	Range
+	Count int
+}
+
+// Count buckets times into the periods of r.IterCalendar(unit); periods
+// without any times are included with a count of 0, and times outside r
+// are ignored
+func (r Range) Count(times []time.Time, unit CalendarUnit) []Bucket {
+	var buckets []Bucket
+	for period := range r.IterCalendar(unit) {
+		buckets = append(buckets, Bucket{Range: period})
+	}
+	for _, t := range times {
+		if !r.Contains(t) {
+			continue
+		}
+		// The first bucket ending after t holds it
+		i := sort.Search(len(buckets), func(i int) bool { return buckets[i].End.After(t) })
+		buckets[i].Count++
+	}
+	return buckets
+}
+
+// StartOfWeek returns midnight on the most recent weekStart on or before
+// t's date, in t's location
+func StartOfWeek(t time.Time, weekStart time.Weekday) time.Time {
+	back := (int(t.Weekday()) - int(weekStart) + 7) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-back, 0, 0, 0, 0, t.Location())
+}
+
+// StartOfMonth returns midnight on the first of t's month
+func StartOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// StartOfQuarter returns midnight on the first of t's quarter: January,
+// April, July or October
+func StartOfQuarter(t time.Time) time.Time {
+	first := (t.Month()-1)/3*3 + 1
+	return time.Date(t.Year(), first, 1, 0, 0, 0, 0, t.Location())
+}
+
+// EndOfMonth returns the last nanosecond of t's month, like getEndOfDay
+func EndOfMonth(t time.Time) time.Time {
+	return getEndOfDay(time.Date(t.Year(), t.Month(), DaysInMonth(t.Year(), t.Month()), 0, 0, 0, 0, t.Location()))
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRangeIterDST(t *testing.T) {
+	eastern := mustZone(t, "America/New_York")
+	r := Range{time.Date(2024, time.March, 9, 0, 0, 0, 0, eastern), time.Date(2024, time.March, 12, 0, 0, 0, 0, eastern)}
+
+	// Calendar days keep midnight; the spring-forward day is 23 hours
+	var starts []string
+	var lengths []time.Duration
+	for day := range r.IterCalendar(UnitDay) {
+		starts = append(starts, day.Start.Format("Jan 2 15:04 MST"))
+		lengths = append(lengths, day.Duration())
+	}
+	wantStarts := []string{"Mar 9 00:00 EST", "Mar 10 00:00 EST", "Mar 11 00:00 EDT"}
+	wantLengths := []time.Duration{24 * time.Hour, 23 * time.Hour, 24 * time.Hour}
+	if len(starts) != 3 {
+		t.Fatalf("IterCalendar(day) = %v, want 3 days", starts)
+	}
+	for i := range starts {
+		if starts[i] != wantStarts[i] || lengths[i] != wantLengths[i] {
+			t.Errorf("day %d = %s (%v), want %s (%v)", i, starts[i], lengths[i], wantStarts[i], wantLengths[i])
+		}
+	}
+
+	// Elapsed 24-hour steps drift to 01:00
+	var steps []string
+	for t := range r.Iter(24 * time.Hour) {
+		steps = append(steps, t.Format("Jan 2 15:04"))
+	}
+	if got, want := len(steps), 3; got != want || steps[2] != "Mar 11 01:00" {
+		t.Errorf("Iter(24h) = %v, want 3 steps ending Mar 11 01:00", steps)
+	}
+
+	// The autumn change gives a 25-hour day
+	fall := Range{time.Date(2024, time.November, 3, 0, 0, 0, 0, eastern), time.Date(2024, time.November, 4, 0, 0, 0, 0, eastern)}
+	for day := range fall.IterCalendar(UnitDay) {
+		if day.Duration() != 25*time.Hour {
+			t.Errorf("Nov 3 lasts %v, want 25h", day.Duration())
+		}
+	}
+}
+
+func TestRangeIterLeapYears(t *testing.T) {
+	tests := []struct {
+		year int
+		want int
+	}{
+		{2023, 28},
+		{2024, 29},
+		{2100, 28},
+		{2000, 29},
+	}
+	for _, tt := range tests {
+		feb := Range{date(tt.year, time.February, 1), date(tt.year, time.March, 1)}
+		days := 0
+		for range feb.IterCalendar(UnitDay) {
+			days++
+		}
+		if days != tt.want {
+			t.Errorf("February %d: %d days, want %d", tt.year, days, tt.want)
+		}
+		if got := EndOfMonth(date(tt.year, time.February, 10)).Day(); got != tt.want {
+			t.Errorf("EndOfMonth(February %d) is the %dth, want %dth", tt.year, got, tt.want)
+		}
+	}
+
+	// Monthly periods from Jan 31 clamp to February's end and recover
+	r := Range{date(2024, time.January, 31), date(2024, time.May, 15)}
+	var got []string
+	for m := range r.IterCalendar(UnitMonth) {
+		got = append(got, m.Start.Format("Jan 2")+"-"+m.End.Format("Jan 2"))
+	}
+	want := []string{"Jan 31-Feb 29", "Feb 29-Mar 31", "Mar 31-Apr 30", "Apr 30-May 15"}
+	if len(got) != len(want) {
+		t.Fatalf("months = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("month %d = %s, want %s", i, got[i], want[i])
+		}
+	}
+}
+
+func TestRangeIterCalendarUnits(t *testing.T) {
+	r := Range{date(2024, time.January, 1), date(2025, time.January, 1)}
+
+	tests := []struct {
+		unit CalendarUnit
+		want int
+	}{
+		{UnitDay, 366},
+		{UnitWeek, 53},
+		{UnitMonth, 12},
+		{UnitQuarter, 4},
+	}
+	for _, tt := range tests {
+		n := 0
+		var last Range
+		for period := range r.IterCalendar(tt.unit) {
+			n++
+			last = period
+		}
+		if n != tt.want || !last.End.Equal(r.End) {
+			t.Errorf("IterCalendar(%s): %d periods ending %s, want %d ending %s", tt.unit, n, last.End, tt.want, r.End)
+		}
+	}
+
+	// Breaking out of the loop stops the iterator
+	n := 0
+	for range r.IterCalendar(UnitDay) {
+		if n++; n == 3 {
+			break
+		}
+	}
+	if n != 3 {
+		t.Errorf("break after 3 days: got %d", n)
+	}
+
+	for range r.Iter(0) {
+		t.Fatal("Iter(0) yielded")
+	}
+	for range (Range{r.End, r.Start}).IterCalendar(UnitDay) {
+		t.Fatal("empty range yielded")
+	}
+}
+
+func TestStartOfWeek(t *testing.T) {
+	tests := []struct {
+		t         time.Time
+		weekStart time.Weekday
+		want      time.Time
+	}{
+		// Wednesday
+		{time.Date(2024, time.May, 15, 14, 30, 0, 0, time.UTC), time.Sunday, date(2024, time.May, 12)},
+		{time.Date(2024, time.May, 15, 14, 30, 0, 0, time.UTC), time.Monday, date(2024, time.May, 13)},
+		// Sunday
+		{date(2024, time.May, 19), time.Sunday, date(2024, time.May, 19)},
+		{date(2024, time.May, 19), time.Monday, date(2024, time.May, 13)},
+		// Monday
+		{date(2024, time.May, 20), time.Monday, date(2024, time.May, 20)},
+		{date(2024, time.May, 20), time.Sunday, date(2024, time.May, 19)},
+		{date(2024, time.May, 20), time.Saturday, date(2024, time.May, 18)},
+		// Across month and year boundaries
+		{date(2024, time.March, 1), time.Monday, date(2024, time.February, 26)},
+		{date(2025, time.January, 1), time.Sunday, date(2024, time.December, 29)},
+	}
+	for _, tt := range tests {
+		if got := StartOfWeek(tt.t, tt.weekStart); !got.Equal(tt.want) {
+			t.Errorf("StartOfWeek(%s, %s) = %s, want %s", tt.t.Format("Mon 2006-01-02"), tt.weekStart,
+				got.Format("Mon 2006-01-02"), tt.want.Format("Mon 2006-01-02"))
+		}
+	}
+
+	// The result is in t's location
+	tokyo := mustZone(t, "Asia/Tokyo")
+	if got := StartOfWeek(time.Date(2024, time.May, 15, 8, 0, 0, 0, tokyo), time.Monday); !got.Equal(time.Date(2024, time.May, 13, 0, 0, 0, 0, tokyo)) {
+		t.Errorf("StartOfWeek in Tokyo = %s", got)
+	}
+}
+
+func TestMonthAndQuarterBoundaries(t *testing.T) {
+	tests := []struct {
+		t                          time.Time
+		month, quarter, endOfMonth string
+	}{
+		{time.Date(2024, time.May, 15, 14, 30, 0, 0, time.UTC), "2024-05-01", "2024-04-01", "2024-05-31 23:59:59.999999999"},
+		{date(2024, time.January, 1), "2024-01-01", "2024-01-01", "2024-01-31 23:59:59.999999999"},
+		{date(2024, time.March, 31), "2024-03-01", "2024-01-01", "2024-03-31 23:59:59.999999999"},
+		{date(2024, time.December, 31), "2024-12-01", "2024-10-01", "2024-12-31 23:59:59.999999999"},
+		{date(2023, time.September, 30), "2023-09-01", "2023-07-01", "2023-09-30 23:59:59.999999999"},
+	}
+	for _, tt := range tests {
+		if got := StartOfMonth(tt.t).Format("2006-01-02"); got != tt.month {
+			t.Errorf("StartOfMonth(%s) = %s, want %s", tt.t, got, tt.month)
+		}
+		if got := StartOfQuarter(tt.t).Format("2006-01-02"); got != tt.quarter {
+			t.Errorf("StartOfQuarter(%s) = %s, want %s", tt.t, got, tt.quarter)
+		}
+		if got := EndOfMonth(tt.t).Format("2006-01-02 15:04:05.999999999"); got != tt.endOfMonth {
+			t.Errorf("EndOfMonth(%s) = %s, want %s", tt.t, got, tt.endOfMonth)
+		}
+	}
+}
+
+func TestRangeCount(t *testing.T) {
+	eastern := mustZone(t, "America/New_York")
+	at := func(day, hour int) time.Time { return time.Date(2024, time.March, day, hour, 0, 0, 0, eastern) }
+	r := Range{at(9, 0), at(12, 0)}
+
+	times := []time.Time{
+		at(9, 0), at(9, 23),
+		at(10, 23), // last hour of the 23-hour day
+		at(11, 0), at(11, 12), at(11, 23),
+		at(8, 23), at(12, 0), // outside the range
+	}
+	buckets := r.Count(times, UnitDay)
+	want := []int{2, 1, 3}
+	if len(buckets) != len(want) {
+		t.Fatalf("got %d buckets, want %d", len(buckets), len(want))
+	}
+	for i, b := range buckets {
+		if b.Count != want[i] {
+			t.Errorf("%s: count %d, want %d", b.Start.Format("Jan 2"), b.Count, want[i])
+		}
+	}
+
+	// Empty periods are kept
+	months := Range{date(2024, time.January, 1), date(2024, time.April, 1)}.Count([]time.Time{date(2024, time.March, 5)}, UnitMonth)
+	if len(months) != 3 || months[0].Count != 0 || months[1].Count != 0 || months[2].Count != 1 {
+		t.Errorf("monthly counts = %+v, want 0, 0, 1", months)
+	}
+}
@@ -0,0 +1,195 @@
+// Package ratelimit limits how often something may happen: a TokenBucket
+// allows bursts and refills at a steady rate, and a SlidingWindowCounter
+// allows at most so many events in any window of time.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+// Clock tells the time and waits. Tests pass a fake one they move by hand;
+// nil means the real clock.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock is the system clock
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+func orReal(clock Clock) Clock {
+	if clock == nil {
+		return realClock{}
+	}
+	return clock
+}
+
+// ErrBurstTooSmall is returned by Wait when the bucket cannot hold a token
+var ErrBurstTooSmall = errors.New("ratelimit: burst is below 1")
+
+// TokenBucket holds up to burst tokens and gains rate tokens a second.
+// Each event takes a token, so bursts of up to burst events go through at
+// once and the long-run rate is limited to rate a second. It is safe for
+// concurrent use.
+type TokenBucket struct {
+	clock Clock
+	rate  float64
+	burst int
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time // when tokens was last brought up to date
+}
+
+// NewTokenBucket returns a full bucket. A rate of 0 never refills.
+func NewTokenBucket(rate float64, burst int, clock Clock) *TokenBucket {
+	clock = orReal(clock)
+	return &TokenBucket{
+		clock:  clock,
+		rate:   max(rate, 0),
+		burst:  burst,
+		tokens: float64(max(burst, 0)),
+		last:   clock.Now(),
+	}
+}
+
+// refill adds the tokens gained since the last call, up to burst
+func (b *TokenBucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = min(b.tokens+elapsed.Seconds()*b.rate, float64(b.burst))
+	}
+	b.last = now
+}
+
+// Allow takes a token if one is available
+func (b *TokenBucket) Allow() bool {
+	return b.AllowN(1)
+}
+
+// AllowN takes n tokens if that many are available, and none otherwise;
+// it never succeeds for more than burst tokens
+func (b *TokenBucket) AllowN(n int) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(b.clock.Now())
+	if b.tokens < float64(n) {
+		return false
+	}
+	b.tokens -= float64(n)
+	return true
+}
+
+// Tokens is the number of tokens available now
+func (b *TokenBucket) Tokens() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(b.clock.Now())
+	return b.tokens
+}
+
+// Wait blocks until a token is available and takes it, or returns the
+// context's error if ctx is done first
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	if b.burst < 1 {
+		return ErrBurstTooSmall
+	}
+	for {
+		b.mu.Lock()
+		b.refill(b.clock.Now())
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		}
+		need := 1 - b.tokens
+		b.mu.Unlock()
+
+		if b.rate == 0 {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		// Round up so the token is there when the wait is over; another
+		// caller may still take it first, in which case we wait again
+		wait := time.Duration(math.Ceil(need / b.rate * float64(time.Second)))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-b.clock.After(wait):
+		}
+	}
+}
+
+// SlidingWindowCounter allows at most limit events in any window of time.
+// It remembers the time of each allowed event, so it is exact but uses
+// memory in proportion to limit. It is safe for concurrent use.
+type SlidingWindowCounter struct {
+	clock  Clock
+	limit  int
+	window time.Duration
+
+	mu     sync.Mutex
+	events []time.Time // allowed events in the window, oldest first
+}
+
+// NewSlidingWindowCounter allows limit events per window
+func NewSlidingWindowCounter(limit int, window time.Duration, clock Clock) *SlidingWindowCounter {
+	return &SlidingWindowCounter{clock: orReal(clock), limit: limit, window: window}
+}
+
+// expire forgets events that have left the window. The window ending now
+// starts just after now-window: an event exactly one window ago has
+// expired.
+func (w *SlidingWindowCounter) expire(now time.Time) {
+	start := now.Add(-w.window)
+	i := 0
+	for i < len(w.events) && !w.events[i].After(start) {
+		i++
+	}
+	w.events = w.events[i:]
+}
+
+// Allow records an event if fewer than limit are in the window
+func (w *SlidingWindowCounter) Allow() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	now := w.clock.Now()
+	w.expire(now)
+	if len(w.events) >= w.limit {
+		return false
+	}
+	w.events = append(w.events, now)
+	return true
+}
+
+// Count is the number of events in the window ending now
+func (w *SlidingWindowCounter) Count() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.expire(w.clock.Now())
+	return len(w.events)
+}
+
+// RetryAfter is how long until Allow can succeed again: 0 if it can now,
+// and forever, as math.MaxInt64, with a limit below 1
+func (w *SlidingWindowCounter) RetryAfter() time.Duration {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.limit < 1 {
+		return math.MaxInt64
+	}
+	now := w.clock.Now()
+	w.expire(now)
+	if len(w.events) < w.limit {
+		return 0
+	}
+	// The oldest event that has to go first
+	oldest := w.events[len(w.events)-w.limit]
+	return oldest.Add(w.window).Sub(now)
+}
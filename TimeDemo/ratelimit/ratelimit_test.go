@@ -0,0 +1,259 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock only moves when Advance is called, firing the After channels
+// that are due
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []waiter
+}
+
+type waiter struct {
+	at time.Time
+	c  chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, waiter{c.now.Add(d), ch})
+	return ch
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			pending = append(pending, w)
+		} else {
+			w.c <- c.now
+		}
+	}
+	c.waiters = pending
+}
+
+// blockUntil waits for n callers to be waiting on After
+func (c *fakeClock) blockUntil(t *testing.T, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		c.mu.Lock()
+		waiting := len(c.waiters)
+		c.mu.Unlock()
+		if waiting >= n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("%d waiters never arrived", n)
+}
+
+func TestTokenBucketRefill(t *testing.T) {
+	clock := newFakeClock()
+	b := NewTokenBucket(4, 10, clock)
+
+	// Starts full, and a burst takes all of it
+	if got := b.Tokens(); got != 10 {
+		t.Errorf("new bucket has %v tokens, want 10", got)
+	}
+	if !b.AllowN(10) {
+		t.Fatal("full burst refused")
+	}
+	if b.Allow() {
+		t.Error("empty bucket allowed a request")
+	}
+
+	tests := []struct {
+		advance time.Duration
+		want    float64
+	}{
+		{250 * time.Millisecond, 1},   // 4 a second is one every 250ms
+		{125 * time.Millisecond, 1.5}, // and half a token in half the time
+		{time.Second, 5.5},
+		{time.Hour, 10}, // never above burst
+	}
+	for _, tt := range tests {
+		clock.Advance(tt.advance)
+		if got := b.Tokens(); got != tt.want {
+			t.Errorf("after another %v: %v tokens, want %v", tt.advance, got, tt.want)
+		}
+	}
+
+	// AllowN takes all or nothing
+	if b.AllowN(11) {
+		t.Error("AllowN above burst succeeded")
+	}
+	if !b.AllowN(7) || b.AllowN(4) || !b.AllowN(3) {
+		t.Error("AllowN(7), AllowN(4), AllowN(3) from 10 tokens: want true, false, true")
+	}
+	if got := b.Tokens(); got != 0 {
+		t.Errorf("%v tokens left, want 0", got)
+	}
+}
+
+func TestTokenBucketBurst(t *testing.T) {
+	clock := newFakeClock()
+	b := NewTokenBucket(5, 3, clock)
+
+	var allowed []bool
+	for range 5 {
+		allowed = append(allowed, b.Allow())
+	}
+	want := []bool{true, true, true, false, false}
+	for i := range want {
+		if allowed[i] != want[i] {
+			t.Fatalf("burst of 5 against 3: %v, want %v", allowed, want)
+		}
+	}
+
+	// One token every 200ms after that
+	clock.Advance(199 * time.Millisecond)
+	if b.Allow() {
+		t.Error("allowed before the next token")
+	}
+	clock.Advance(time.Millisecond)
+	if !b.Allow() {
+		t.Error("refused once the next token arrived")
+	}
+}
+
+func TestTokenBucketWait(t *testing.T) {
+	clock := newFakeClock()
+	b := NewTokenBucket(2, 1, clock)
+	ctx := context.Background()
+
+	// A token is there: no waiting
+	if err := b.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- b.Wait(ctx) }()
+	clock.blockUntil(t, 1)
+	clock.Advance(499 * time.Millisecond)
+	select {
+	case err := <-done:
+		t.Fatalf("Wait returned %v before the token arrived", err)
+	default:
+	}
+	clock.Advance(time.Millisecond)
+	if err := <-done; err != nil {
+		t.Errorf("Wait: %v", err)
+	}
+	if got := b.Tokens(); got != 0 {
+		t.Errorf("Wait left %v tokens, want 0", got)
+	}
+}
+
+func TestTokenBucketWaitCancel(t *testing.T) {
+	clock := newFakeClock()
+	b := NewTokenBucket(1, 1, clock)
+	b.Allow()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- b.Wait(ctx) }()
+	clock.blockUntil(t, 1)
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait = %v, want context.Canceled", err)
+	}
+
+	// The cancelled wait took no token
+	clock.Advance(time.Second)
+	if got := b.Tokens(); got != 1 {
+		t.Errorf("%v tokens after a cancelled Wait, want 1", got)
+	}
+
+	// A bucket that never refills waits for the context
+	empty := NewTokenBucket(0, 1, clock)
+	empty.Allow()
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if err := empty.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wait with no refill = %v, want context.DeadlineExceeded", err)
+	}
+	if err := NewTokenBucket(1, 0, clock).Wait(context.Background()); !errors.Is(err, ErrBurstTooSmall) {
+		t.Errorf("Wait with burst 0 = %v, want ErrBurstTooSmall", err)
+	}
+}
+
+func TestSlidingWindowCounter(t *testing.T) {
+	clock := newFakeClock()
+	w := NewSlidingWindowCounter(3, time.Second, clock)
+
+	steps := []struct {
+		advance time.Duration
+		allowed bool
+		count   int
+	}{
+		{0, true, 1},                      // t=0
+		{300 * time.Millisecond, true, 2}, // t=300ms
+		{300 * time.Millisecond, true, 3}, // t=600ms
+		{300 * time.Millisecond, false, 3},
+		// t=999ms: the first event is still in the window...
+		{99 * time.Millisecond, false, 3},
+		// ...and at t=1s, exactly one window later, it has left
+		{time.Millisecond, true, 3},
+		{299 * time.Millisecond, false, 3},
+		{time.Millisecond, true, 3}, // t=1.3s: the 300ms event has left
+		{2 * time.Second, true, 1},
+	}
+	for i, step := range steps {
+		clock.Advance(step.advance)
+		if got := w.Allow(); got != step.allowed {
+			t.Errorf("step %d: Allow = %v, want %v", i, got, step.allowed)
+		}
+		if got := w.Count(); got != step.count {
+			t.Errorf("step %d: Count = %d, want %d", i, got, step.count)
+		}
+	}
+}
+
+func TestSlidingWindowRetryAfter(t *testing.T) {
+	clock := newFakeClock()
+	w := NewSlidingWindowCounter(2, time.Minute, clock)
+	if got := w.RetryAfter(); got != 0 {
+		t.Errorf("RetryAfter on an empty window = %v", got)
+	}
+	w.Allow()
+	clock.Advance(10 * time.Second)
+	w.Allow()
+	clock.Advance(5 * time.Second)
+	if got := w.RetryAfter(); got != 45*time.Second {
+		t.Errorf("RetryAfter = %v, want 45s", got)
+	}
+	clock.Advance(45 * time.Second)
+	if got := w.RetryAfter(); got != 0 {
+		t.Errorf("RetryAfter once the oldest event left = %v, want 0", got)
+	}
+	if !w.Allow() {
+		t.Error("refused after RetryAfter")
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// WithTimeout runs fn with a context that is cancelled after d, and
+// returns fn's error, or context.DeadlineExceeded once d has passed even
+// if fn ignores its context and is still running
+func WithTimeout(fn func(ctx context.Context) error, d time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+	done := make(chan error, 1) // buffered, so a late fn can still finish
+	go func() { done <- fn(ctx) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Strategy decides how long to wait before each retry
+type Strategy interface {
+	// Backoff is the wait after the retry-th failure, from 1
+	Backoff(retry int) time.Duration
+}
+
+// ConstantBackoff waits the same time before every retry
+type ConstantBackoff time.Duration
+
+// Backoff returns b
+func (b ConstantBackoff) Backoff(int) time.Duration { return time.Duration(b) }
+
+// ExponentialBackoff waits Base, then Multiplier times longer before each
+// further retry, up to Max, with up to Jitter of the wait, as a fraction,
+// added or taken off at random so that many clients retrying together
+// spread out
+type ExponentialBackoff struct {
+	Base, Max  time.Duration // no maximum when Max is 0
+	Multiplier float64       // 2 when 0
+	Jitter     float64       // 0 to 1
+	// Rand returns a number in [0, 1), rand.Float64 when nil; tests fix it
+	Rand func() float64
+}
+
+// Backoff returns Base * Multiplier^(retry-1), capped and jittered
+func (b ExponentialBackoff) Backoff(retry int) time.Duration {
+	multiplier := b.Multiplier
+	if multiplier == 0 {
+		multiplier = 2
+	}
+	limit := float64(math.MaxInt64)
+	if b.Max > 0 {
+		limit = float64(b.Max)
+	}
+	wait := min(float64(b.Base)*math.Pow(multiplier, float64(retry-1)), limit)
+
+	if b.Jitter > 0 {
+		random := rand.Float64
+		if b.Rand != nil {
+			random = b.Rand
+		}
+		// Somewhere in wait ± Jitter*wait
+		wait += wait * b.Jitter * (2*random() - 1)
+	}
+	// float64(math.MaxInt64) rounds up past the largest Duration
+	if wait >= float64(math.MaxInt64) {
+		return math.MaxInt64
+	}
+	return time.Duration(wait)
+}
+
+// FibonacciBackoff waits Base times the Fibonacci numbers, 1, 1, 2, 3, 5,
+// 8..., up to Max; it grows more gently than doubling
+type FibonacciBackoff struct {
+	Base, Max time.Duration // no maximum when Max is 0
+}
+
+// Backoff returns Base times the retry-th Fibonacci number, capped
+func (b FibonacciBackoff) Backoff(retry int) time.Duration {
+	limit := time.Duration(math.MaxInt64)
+	if b.Max > 0 {
+		limit = b.Max
+	}
+	wait, next := min(b.Base, limit), min(b.Base, limit)
+	for range retry - 1 {
+		// Stop growing at the limit, which also keeps the sum from
+		// overflowing
+		sum := limit
+		if wait <= limit-next {
+			sum = wait + next
+		}
+		wait, next = next, sum
+	}
+	return wait
+}
+
+// Retrier runs a function until it succeeds or runs out of attempts,
+// waiting between attempts as Backoff says
+type Retrier struct {
+	Attempts int // at least one attempt is made
+	Backoff  Strategy
+	// OnRetry, when set, is called after each failure that will be
+	// retried, with the wait before the next attempt
+	OnRetry func(attempt int, err error, wait time.Duration)
+	// Clock is the clock to wait on, RealClock when nil
+	Clock TimerClock
+}
+
+// Retry runs fn up to attempts times with backoff between attempts
+func Retry(ctx context.Context, fn func(ctx context.Context) error, attempts int, backoff Strategy) error {
+	return Retrier{Attempts: attempts, Backoff: backoff}.Do(ctx, fn)
+}
+
+// Do runs fn until it returns nil, the attempts are used up or ctx is
+// done. The error wraps fn's last error, and ctx's error as well if the
+// retries were cut short.
+func (r Retrier) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	clock := r.Clock
+	if clock == nil {
+		clock = RealClock{}
+	}
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+		if attempt >= r.Attempts {
+			return fmt.Errorf("gave up after %d attempts: %w", attempt, err)
+		}
+		var wait time.Duration
+		if r.Backoff != nil {
+			wait = r.Backoff.Backoff(attempt)
+		}
+		if r.OnRetry != nil {
+			r.OnRetry(attempt, err, wait)
+		}
+		if cerr := sleep(ctx, clock, wait); cerr != nil {
+			return fmt.Errorf("stopped after %d attempts: %w (last error: %w)", attempt, cerr, err)
+		}
+	}
+}
+
+// sleep waits for d on clock, or returns ctx's error if it is done first
+func sleep(ctx context.Context, clock TimerClock, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	woke := make(chan struct{})
+	timer := clock.AfterFunc(d, func() { close(woke) })
+	select {
+	case <-woke:
+		return nil
+	case <-ctx.Done():
+		timer.Stop()
+		return ctx.Err()
+	}
+}
+
+// ErrBudgetExhausted is returned by Deadline.NextTimeout when too little
+// of the budget is left for another step
+var ErrBudgetExhausted = errors.New("time budget exhausted")
+
+// Deadline is a time budget shared by sequential steps: each step's
+// timeout comes out of what the steps before it left
+type Deadline struct {
+	clock Clock
+	end   time.Time
+}
+
+// NewDeadline starts a budget of d on clock, RealClock when nil
+func NewDeadline(d time.Duration, clock Clock) *Deadline {
+	if clock == nil {
+		clock = RealClock{}
+	}
+	return &Deadline{clock: clock, end: clock.Now().Add(d)}
+}
+
+// Remaining is the budget left, never negative
+func (d *Deadline) Remaining() time.Duration {
+	return max(d.end.Sub(d.clock.Now()), 0)
+}
+
+// Expired reports whether the budget is used up
+func (d *Deadline) Expired() bool {
+	return d.Remaining() == 0
+}
+
+// NextTimeout returns the timeout for the next step: what is left of the
+// budget, at most maxTimeout unless that is 0. It fails with
+// ErrBudgetExhausted when less than minTimeout is left, as a step is not
+// worth starting then.
+func (d *Deadline) NextTimeout(minTimeout, maxTimeout time.Duration) (time.Duration, error) {
+	remaining := d.Remaining()
+	if remaining < minTimeout || remaining == 0 {
+		return 0, fmt.Errorf("%w: %v left, next step needs %v", ErrBudgetExhausted, remaining, minTimeout)
+	}
+	if maxTimeout > 0 && remaining > maxTimeout {
+		return maxTimeout, nil
+	}
+	return remaining, nil
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestBackoffSequences(t *testing.T) {
+	ms := time.Millisecond
+	tests := []struct {
+		name     string
+		strategy Strategy
+		want     []time.Duration
+	}{
+		{"constant", ConstantBackoff(100 * ms), []time.Duration{100 * ms, 100 * ms, 100 * ms}},
+		{"exponential", ExponentialBackoff{Base: 100 * ms}, []time.Duration{100 * ms, 200 * ms, 400 * ms, 800 * ms, 1600 * ms}},
+		{"exponential capped", ExponentialBackoff{Base: 100 * ms, Max: 500 * ms}, []time.Duration{100 * ms, 200 * ms, 400 * ms, 500 * ms, 500 * ms}},
+		{"exponential x3", ExponentialBackoff{Base: 10 * ms, Multiplier: 3}, []time.Duration{10 * ms, 30 * ms, 90 * ms, 270 * ms}},
+		{"fibonacci", FibonacciBackoff{Base: 100 * ms}, []time.Duration{100 * ms, 100 * ms, 200 * ms, 300 * ms, 500 * ms, 800 * ms, 1300 * ms}},
+		{"fibonacci capped", FibonacciBackoff{Base: 100 * ms, Max: 400 * ms}, []time.Duration{100 * ms, 100 * ms, 200 * ms, 300 * ms, 400 * ms, 400 * ms}},
+	}
+	for _, tt := range tests {
+		for i, want := range tt.want {
+			if got := tt.strategy.Backoff(i + 1); got != want {
+				t.Errorf("%s: Backoff(%d) = %v, want %v", tt.name, i+1, got, want)
+			}
+		}
+	}
+
+	// Far along, the waits stop at the largest Duration rather than
+	// overflowing
+	for _, s := range []Strategy{ExponentialBackoff{Base: time.Second}, FibonacciBackoff{Base: time.Second}} {
+		if got := s.Backoff(200); got != math.MaxInt64 {
+			t.Errorf("%T: Backoff(200) = %v, want the largest Duration", s, got)
+		}
+	}
+}
+
+func TestExponentialJitter(t *testing.T) {
+	// The extremes of Rand give the bounds of the jitter
+	for _, tt := range []struct {
+		random float64
+		want   time.Duration
+	}{
+		{0, 300 * time.Millisecond},   // 400ms - 25%
+		{0.5, 400 * time.Millisecond}, // none
+		{1, 500 * time.Millisecond},   // 400ms + 25%, which Rand never quite reaches
+	} {
+		b := ExponentialBackoff{Base: 100 * time.Millisecond, Jitter: 0.25, Rand: func() float64 { return tt.random }}
+		if got := b.Backoff(3); got != tt.want {
+			t.Errorf("Rand %v: Backoff(3) = %v, want %v", tt.random, got, tt.want)
+		}
+	}
+
+	// The real random numbers stay within bounds, and vary
+	b := ExponentialBackoff{Base: 100 * time.Millisecond, Max: time.Second, Jitter: 0.5}
+	seen := map[time.Duration]bool{}
+	for range 1000 {
+		got := b.Backoff(10) // capped at 1s before the jitter
+		if got < 500*time.Millisecond || got > 1500*time.Millisecond {
+			t.Fatalf("Backoff(10) = %v, want 500ms to 1.5s", got)
+		}
+		seen[got] = true
+	}
+	if len(seen) < 100 {
+		t.Errorf("only %d different waits in 1000", len(seen))
+	}
+}
+
+// runRetrier runs r.Do in a goroutine, advancing clock through each wait
+// it starts until it returns
+func runRetrier(t *testing.T, ctx context.Context, r Retrier, clock *FakeClock, fn func(ctx context.Context) error) error {
+	t.Helper()
+	done := make(chan error, 1)
+	go func() { done <- r.Do(ctx, fn) }()
+	for {
+		select {
+		case err := <-done:
+			return err
+		case <-time.After(time.Millisecond):
+		}
+		if clock.pendingTimers() > 0 {
+			clock.Advance(time.Hour)
+		}
+	}
+}
+
+func TestRetry(t *testing.T) {
+	clock := NewFakeClock(date(2024, time.January, 1))
+	flaky := errors.New("connection reset")
+
+	var attemptsAt []time.Duration
+	var waits []time.Duration
+	r := Retrier{
+		Attempts: 5,
+		Backoff:  ExponentialBackoff{Base: 100 * time.Millisecond},
+		OnRetry:  func(_ int, _ error, wait time.Duration) { waits = append(waits, wait) },
+		Clock:    clock,
+	}
+	start := clock.Now()
+	fn := func(context.Context) error {
+		attemptsAt = append(attemptsAt, clock.Now().Sub(start))
+		if len(attemptsAt) < 3 {
+			return flaky
+		}
+		return nil
+	}
+
+	// Advance only as far as each wait, to check when the attempts ran
+	done := make(chan error, 1)
+	go func() { done <- r.Do(context.Background(), fn) }()
+	for _, wait := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond} {
+		for clock.pendingTimers() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		clock.Advance(wait - time.Nanosecond)
+		if len(attemptsAt) != len(waits) {
+			t.Fatalf("attempt ran early, %v before the end of a %v wait", time.Nanosecond, wait)
+		}
+		clock.Advance(time.Nanosecond)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	want := []time.Duration{0, 100 * time.Millisecond, 300 * time.Millisecond}
+	if len(attemptsAt) != 3 || attemptsAt[1] != want[1] || attemptsAt[2] != want[2] {
+		t.Errorf("attempts at %v, want %v", attemptsAt, want)
+	}
+	if len(waits) != 2 {
+		t.Errorf("OnRetry called %d times, want 2", len(waits))
+	}
+
+	// Running out of attempts wraps the last error
+	calls := 0
+	err := runRetrier(t, context.Background(), Retrier{Attempts: 3, Backoff: ConstantBackoff(time.Second), Clock: clock},
+		clock, func(context.Context) error { calls++; return flaky })
+	if !errors.Is(err, flaky) || calls != 3 {
+		t.Errorf("after %d calls: %v, want the last error after 3", calls, err)
+	}
+	if err.Error() != "gave up after 3 attempts: connection reset" {
+		t.Errorf("err = %q", err)
+	}
+
+	// Zero attempts still makes one
+	calls = 0
+	Retrier{Clock: clock}.Do(context.Background(), func(context.Context) error { calls++; return flaky })
+	if calls != 1 {
+		t.Errorf("Attempts 0: %d calls, want 1", calls)
+	}
+}
+
+func TestRetryCancel(t *testing.T) {
+	clock := NewFakeClock(date(2024, time.January, 1))
+	flaky := errors.New("timeout talking to the server")
+	ctx, cancel := context.WithCancel(context.Background())
+
+	calls := 0
+	done := make(chan error, 1)
+	go func() {
+		done <- Retrier{Attempts: 10, Backoff: ConstantBackoff(time.Minute), Clock: clock}.Do(ctx, func(context.Context) error {
+			calls++
+			return flaky
+		})
+	}()
+
+	// Cancel during the first wait
+	for clock.pendingTimers() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	err := <-done
+	if !errors.Is(err, context.Canceled) || !errors.Is(err, flaky) {
+		t.Errorf("err = %v, want both context.Canceled and the last error", err)
+	}
+	if calls != 1 {
+		t.Errorf("%d calls, want 1", calls)
+	}
+	if n := clock.pendingTimers(); n != 0 {
+		t.Errorf("%d timers left after cancelling", n)
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	// fn finishing in time
+	if err := WithTimeout(func(context.Context) error { return nil }, time.Second); err != nil {
+		t.Errorf("quick fn: %v", err)
+	}
+	boom := errors.New("boom")
+	if err := WithTimeout(func(context.Context) error { return boom }, time.Second); err != boom {
+		t.Errorf("failing fn: %v", err)
+	}
+
+	// fn honouring its context
+	err := WithTimeout(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, time.Millisecond)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("fn waiting on ctx: %v", err)
+	}
+
+	// fn ignoring it: WithTimeout returns anyway
+	release := make(chan struct{})
+	defer close(release)
+	err = WithTimeout(func(context.Context) error {
+		<-release
+		return nil
+	}, time.Millisecond)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("fn ignoring ctx: %v", err)
+	}
+}
+
+func TestDeadline(t *testing.T) {
+	clock := NewFakeClock(date(2024, time.January, 1))
+	d := NewDeadline(time.Second, clock)
+
+	steps := []struct {
+		took     time.Duration // time the step before used
+		min, max time.Duration
+		want     time.Duration
+	}{
+		{0, 100 * time.Millisecond, 400 * time.Millisecond, 400 * time.Millisecond}, // capped
+		{300 * time.Millisecond, 100 * time.Millisecond, 0, 700 * time.Millisecond}, // no cap
+		{550 * time.Millisecond, 100 * time.Millisecond, time.Second, 150 * time.Millisecond},
+	}
+	for i, step := range steps {
+		clock.Advance(step.took)
+		got, err := d.NextTimeout(step.min, step.max)
+		if err != nil || got != step.want {
+			t.Errorf("step %d: NextTimeout = %v, %v; want %v", i+1, got, err, step.want)
+		}
+	}
+
+	// 150ms left is less than the next step needs
+	if _, err := d.NextTimeout(200*time.Millisecond, time.Second); !errors.Is(err, ErrBudgetExhausted) {
+		t.Errorf("NextTimeout with too little left: %v", err)
+	}
+	if d.Expired() {
+		t.Error("expired with 150ms left")
+	}
+
+	clock.Advance(time.Second)
+	if d.Remaining() != 0 || !d.Expired() {
+		t.Errorf("Remaining = %v after the end, want 0", d.Remaining())
+	}
+	_, err := d.NextTimeout(0, time.Second)
+	if !errors.Is(err, ErrBudgetExhausted) {
+		t.Errorf("NextTimeout after the end: %v", err)
+	}
+	if want := "time budget exhausted: 0s left, next step needs 0s"; err.Error() != want {
+		t.Errorf("err = %q, want %q", err, want)
+	}
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency is how often an RRule repeats
+type Frequency int
+
+const (
+	FreqDaily Frequency = iota
+	FreqWeekly
+	FreqMonthly
+)
+
+func (f Frequency) String() string {
+	switch f {
+	case FreqDaily:
+		return "DAILY"
+	case FreqWeekly:
+		return "WEEKLY"
+	case FreqMonthly:
+		return "MONTHLY"
+	}
+	return fmt.Sprintf("Frequency(%d)", int(f))
+}
+
+// ByDay is a BYDAY entry: a weekday, and for monthly rules optionally
+// which one of the month, 1 to 5 from the start or -1 to -5 from the end
+type ByDay struct {
+	N       int // 0 for every one
+	Weekday time.Weekday
+}
+
+// rruleDays are the two-letter iCalendar weekday codes
+var rruleDays = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+func (d ByDay) String() string {
+	if d.N == 0 {
+		return rruleDays[d.Weekday]
+	}
+	return strconv.Itoa(d.N) + rruleDays[d.Weekday]
+}
+
+// RRule is a subset of the iCalendar (RFC 5545) recurrence rule: FREQ of
+// DAILY, WEEKLY or MONTHLY with INTERVAL, BYDAY, COUNT and UNTIL.
+// Occurrences keep Start's wall-clock time in Start's location, so a 09:00
+// meeting in New York stays at 09:00 when the clocks change. A time that
+// does not exist on a spring-forward day moves forward by the gap, as
+// RFC 5545 says.
+type RRule struct {
+	Freq     Frequency
+	Interval int // every Interval days, weeks or months; 0 means 1
+	// ByDay limits daily rules to these weekdays, gives the days of the
+	// week for weekly rules, with weeks starting on Monday, and the days of
+	// the month for monthly rules. Without it, weekly rules repeat on
+	// Start's weekday and monthly rules on Start's day of the month,
+	// skipping months that are too short.
+	ByDay []ByDay
+	Count int       // stop after Count occurrences; 0 for no limit
+	Until time.Time // stop after Until, inclusive; zero for no limit
+	Start time.Time // DTSTART, the first possible occurrence
+}
+
+// ParseRRule parses a rule such as "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU"
+// starting at start. UNTIL is written 20060102T150405Z or 20060102.
+func ParseRRule(s string, start time.Time) (RRule, error) {
+	rule := RRule{Start: start}
+	hasFreq := false
+	for _, part := range strings.Split(strings.TrimPrefix(strings.TrimSpace(s), "RRULE:"), ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return RRule{}, fmt.Errorf("rrule %q: expected KEY=VALUE, got %q", s, part)
+		}
+		var err error
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			hasFreq = true
+			switch strings.ToUpper(value) {
+			case "DAILY":
+				rule.Freq = FreqDaily
+			case "WEEKLY":
+				rule.Freq = FreqWeekly
+			case "MONTHLY":
+				rule.Freq = FreqMonthly
+			default:
+				return RRule{}, fmt.Errorf("rrule %q: unsupported FREQ %q (use DAILY, WEEKLY or MONTHLY)", s, value)
+			}
+		case "INTERVAL":
+			rule.Interval, err = strconv.Atoi(value)
+			if err == nil && rule.Interval < 1 {
+				err = fmt.Errorf("must be at least 1")
+			}
+		case "COUNT":
+			rule.Count, err = strconv.Atoi(value)
+			if err == nil && rule.Count < 1 {
+				err = fmt.Errorf("must be at least 1")
+			}
+		case "UNTIL":
+			rule.Until, err = time.Parse("20060102T150405Z", value)
+			if err != nil {
+				// A date alone is in the rule's own location
+				rule.Until, err = time.ParseInLocation("20060102", value, start.Location())
+				rule.Until = getEndOfDay(rule.Until)
+			}
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				var d ByDay
+				d, err = parseByDay(day)
+				if err != nil {
+					break
+				}
+				rule.ByDay = append(rule.ByDay, d)
+			}
+		default:
+			return RRule{}, fmt.Errorf("rrule %q: unsupported %s", s, key)
+		}
+		if err != nil {
+			return RRule{}, fmt.Errorf("rrule %q: invalid %s %q: %v", s, strings.ToUpper(key), value, err)
+		}
+	}
+	if !hasFreq {
+		return RRule{}, fmt.Errorf("rrule %q: missing FREQ", s)
+	}
+	return rule, rule.validate()
+}
+
+// parseByDay parses "TU", "2TU" or "-1FR"
+func parseByDay(s string) (ByDay, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if len(s) < 2 {
+		return ByDay{}, fmt.Errorf("unknown day %q", s)
+	}
+	code := s[len(s)-2:]
+	weekday := slices.Index(rruleDays, code)
+	if weekday < 0 {
+		return ByDay{}, fmt.Errorf("unknown day %q", code)
+	}
+	d := ByDay{Weekday: time.Weekday(weekday)}
+	if prefix := s[:len(s)-2]; prefix != "" {
+		n, err := strconv.Atoi(prefix)
+		if err != nil || n == 0 || n < -5 || n > 5 {
+			return ByDay{}, fmt.Errorf("invalid position %q (use 1 to 5 or -1 to -5)", prefix)
+		}
+		d.N = n
+	}
+	return d, nil
+}
+
+// validate checks what parsing cannot: rules built in code go through it
+// in Expand too
+func (r RRule) validate() error {
+	switch {
+	case r.Count > 0 && !r.Until.IsZero():
+		return fmt.Errorf("rrule %s: COUNT and UNTIL cannot both be set", r)
+	case r.Interval < 0, r.Count < 0:
+		return fmt.Errorf("rrule %s: INTERVAL and COUNT cannot be negative", r)
+	case r.Start.IsZero():
+		return fmt.Errorf("rrule %s: missing start", r)
+	}
+	for _, d := range r.ByDay {
+		if d.N != 0 && r.Freq != FreqMonthly {
+			return fmt.Errorf("rrule %s: BYDAY %s: positions are only allowed in MONTHLY rules", r, d)
+		}
+	}
+	return nil
+}
+
+// String formats the rule as iCalendar does, without the start
+func (r RRule) String() string {
+	parts := []string{"FREQ=" + r.Freq.String()}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, d := range r.ByDay {
+			days[i] = d.String()
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Expand returns the occurrences of rule from from up to but not including
+// to. COUNT counts from the rule's start, including occurrences before
+// from.
+func Expand(rule RRule, from, to time.Time) ([]time.Time, error) {
+	if err := rule.validate(); err != nil {
+		return nil, err
+	}
+	interval := max(rule.Interval, 1)
+
+	var out []time.Time
+	count := 0
+	for k := 0; ; k++ {
+		dates := rule.periodDates(k * interval)
+		if len(dates) == 0 && rule.periodStart(k*interval).After(to) {
+			return out, nil
+		}
+		for _, day := range dates {
+			t := wallTime(day.Year(), day.Month(), day.Day(), rule.Start.Hour(), rule.Start.Minute(), rule.Start.Location()).
+				Add(time.Duration(rule.Start.Second())*time.Second + time.Duration(rule.Start.Nanosecond()))
+			if t.Before(rule.Start) {
+				continue
+			}
+			if !rule.Until.IsZero() && t.After(rule.Until) {
+				return out, nil
+			}
+			if count++; rule.Count > 0 && count > rule.Count {
+				return out, nil
+			}
+			if !t.Before(to) {
+				return out, nil
+			}
+			if !t.Before(from) {
+				out = append(out, t)
+			}
+		}
+	}
+}
+
+// periodStart is midnight starting the n-th day, week or month after the
+// start's
+func (r RRule) periodStart(n int) time.Time {
+	start := getStartOfDay(r.Start)
+	switch r.Freq {
+	case FreqWeekly:
+		return StartOfWeek(start, time.Monday).AddDate(0, 0, 7*n)
+	case FreqMonthly:
+		return StartOfMonth(start).AddDate(0, n, 0)
+	}
+	return start.AddDate(0, 0, n)
+}
+
+// periodDates returns the dates in the n-th period that the rule picks,
+// in order, as midnight in the start's location
+func (r RRule) periodDates(n int) []time.Time {
+	first := r.periodStart(n)
+	var days []time.Time
+	switch r.Freq {
+	case FreqDaily:
+		days = []time.Time{first}
+	case FreqWeekly:
+		for i := range 7 {
+			days = append(days, first.AddDate(0, 0, i))
+		}
+		if len(r.ByDay) == 0 {
+			return slices.DeleteFunc(days, func(d time.Time) bool { return d.Weekday() != r.Start.Weekday() })
+		}
+	case FreqMonthly:
+		if len(r.ByDay) == 0 {
+			if r.Start.Day() > DaysInMonth(first.Year(), first.Month()) {
+				return nil // no 31st this month
+			}
+			return []time.Time{first.AddDate(0, 0, r.Start.Day()-1)}
+		}
+		for i := range DaysInMonth(first.Year(), first.Month()) {
+			days = append(days, first.AddDate(0, 0, i))
+		}
+	}
+	if len(r.ByDay) == 0 {
+		return days
+	}
+	return slices.DeleteFunc(days, func(d time.Time) bool { return !r.picks(d) })
+}
+
+// picks reports whether date d matches one of the BYDAY entries
+func (r RRule) picks(d time.Time) bool {
+	for _, by := range r.ByDay {
+		if d.Weekday() != by.Weekday {
+			continue
+		}
+		switch {
+		case by.N == 0:
+			return true
+		case by.N > 0 && (d.Day()-1)/7+1 == by.N:
+			return true
+		case by.N < 0 && (DaysInMonth(d.Year(), d.Month())-d.Day())/7+1 == -by.N:
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func expandStrings(t *testing.T, rule RRule, from, to time.Time, layout string) string {
+	t.Helper()
+	occurrences, err := Expand(rule, from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out []string
+	for _, o := range occurrences {
+		out = append(out, o.Format(layout))
+	}
+	return strings.Join(out, ", ")
+}
+
+func TestRRuleDST(t *testing.T) {
+	newYork := mustZone(t, "America/New_York")
+	const layout = "Jan 2 15:04 MST"
+
+	tests := []struct {
+		name  string
+		rule  string
+		start time.Time
+		want  string
+	}{
+		// Clocks go forward on March 10th, 2024: still 09:00 local
+		{"daily across spring forward", "FREQ=DAILY;COUNT=4", time.Date(2024, time.March, 8, 9, 0, 0, 0, newYork),
+			"Mar 8 09:00 EST, Mar 9 09:00 EST, Mar 10 09:00 EDT, Mar 11 09:00 EDT"},
+		// ...and back on November 3rd
+		{"every other Tuesday across fall back", "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU;COUNT=3", time.Date(2024, time.October, 22, 9, 0, 0, 0, newYork),
+			"Oct 22 09:00 EDT, Nov 5 09:00 EST, Nov 19 09:00 EST"},
+		// 02:30 does not exist on March 10th and moves forward by the gap
+		{"time in the spring-forward gap", "FREQ=DAILY;COUNT=3", time.Date(2024, time.March, 9, 2, 30, 0, 0, newYork),
+			"Mar 9 02:30 EST, Mar 10 03:30 EDT, Mar 11 02:30 EDT"},
+		// 01:30 happens twice on November 3rd; time.Date picks the first
+		{"repeated hour", "FREQ=DAILY;COUNT=3", time.Date(2024, time.November, 2, 1, 30, 0, 0, newYork),
+			"Nov 2 01:30 EDT, Nov 3 01:30 EDT, Nov 4 01:30 EST"},
+	}
+	for _, tt := range tests {
+		rule, err := ParseRRule(tt.rule, tt.start)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := expandStrings(t, rule, tt.start, tt.start.AddDate(1, 0, 0), layout); got != tt.want {
+			t.Errorf("%s:\n got %s\nwant %s", tt.name, got, tt.want)
+		}
+	}
+
+	// The same instants in UTC move by the offset change
+	rule, _ := ParseRRule("FREQ=WEEKLY;COUNT=3", time.Date(2024, time.October, 28, 9, 0, 0, 0, newYork))
+	occurrences, _ := Expand(rule, rule.Start, rule.Start.AddDate(0, 1, 0))
+	if got := occurrences[1].Sub(occurrences[0]); got != 7*24*time.Hour+time.Hour {
+		t.Errorf("week across fall back lasted %v, want 169h", got)
+	}
+}
+
+func TestRRuleExpand(t *testing.T) {
+	start := time.Date(2024, time.January, 31, 10, 0, 0, 0, time.UTC) // a Wednesday
+	const layout = "Mon Jan 2"
+
+	tests := []struct {
+		rule string
+		want string
+	}{
+		{"FREQ=DAILY;INTERVAL=3;COUNT=4", "Wed Jan 31, Sat Feb 3, Tue Feb 6, Fri Feb 9"},
+		{"FREQ=DAILY;BYDAY=MO,FR;COUNT=4", "Fri Feb 2, Mon Feb 5, Fri Feb 9, Mon Feb 12"},
+		// Weekly without BYDAY repeats on the start's weekday
+		{"FREQ=WEEKLY;COUNT=3", "Wed Jan 31, Wed Feb 7, Wed Feb 14"},
+		// Days before the start in its first week are skipped
+		{"FREQ=WEEKLY;BYDAY=MO,WE,FR;COUNT=5", "Wed Jan 31, Fri Feb 2, Mon Feb 5, Wed Feb 7, Fri Feb 9"},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=TH;COUNT=3", "Thu Feb 1, Thu Feb 15, Thu Feb 29"},
+		// Monthly on the 31st skips shorter months
+		{"FREQ=MONTHLY;COUNT=4", "Wed Jan 31, Sun Mar 31, Fri May 31, Wed Jul 31"},
+		{"FREQ=MONTHLY;BYDAY=2TU;COUNT=3", "Tue Feb 13, Tue Mar 12, Tue Apr 9"},
+		{"FREQ=MONTHLY;BYDAY=-1FR;COUNT=3", "Fri Feb 23, Fri Mar 29, Fri Apr 26"},
+		// Every other month from January, and only those with five Thursdays
+		{"FREQ=MONTHLY;INTERVAL=2;BYDAY=5TH;COUNT=3", "Thu May 30, Thu Jan 30, Thu May 29"},
+		{"FREQ=MONTHLY;BYDAY=SA;COUNT=5", "Sat Feb 3, Sat Feb 10, Sat Feb 17, Sat Feb 24, Sat Mar 2"},
+		// UNTIL is inclusive
+		{"FREQ=WEEKLY;UNTIL=20240214T100000Z", "Wed Jan 31, Wed Feb 7, Wed Feb 14"},
+		{"FREQ=WEEKLY;UNTIL=20240214T095959Z", "Wed Jan 31, Wed Feb 7"},
+		{"FREQ=WEEKLY;UNTIL=20240214", "Wed Jan 31, Wed Feb 7, Wed Feb 14"},
+		{"FREQ=WEEKLY;UNTIL=20240101", ""},
+	}
+	for _, tt := range tests {
+		rule, err := ParseRRule(tt.rule, start)
+		if err != nil {
+			t.Errorf("ParseRRule(%q): %v", tt.rule, err)
+			continue
+		}
+		if got := expandStrings(t, rule, start, start.AddDate(2, 0, 0), layout); got != tt.want {
+			t.Errorf("%s:\n got %s\nwant %s", tt.rule, got, tt.want)
+		}
+	}
+}
+
+func TestRRuleWindow(t *testing.T) {
+	start := time.Date(2024, time.January, 1, 9, 0, 0, 0, time.UTC)
+	rule, _ := ParseRRule("FREQ=DAILY;COUNT=10", start)
+
+	// COUNT counts from the start, not from the window
+	got := expandStrings(t, rule, date(2024, time.January, 8), date(2024, time.February, 1), "Jan 2")
+	if got != "Jan 8, Jan 9, Jan 10" {
+		t.Errorf("window after the start: %s", got)
+	}
+	// The window's end is exclusive
+	got = expandStrings(t, rule, start, time.Date(2024, time.January, 3, 9, 0, 0, 0, time.UTC), "Jan 2")
+	if got != "Jan 1, Jan 2" {
+		t.Errorf("window ending at an occurrence: %s", got)
+	}
+
+	// Without COUNT or UNTIL the window ends the expansion
+	forever, _ := ParseRRule("FREQ=MONTHLY;BYDAY=5FR", start)
+	got = expandStrings(t, forever, start, date(2025, time.January, 1), "Jan 2")
+	if got != "Mar 29, May 31, Aug 30, Nov 29" {
+		t.Errorf("fifth Fridays of 2024: %s", got)
+	}
+}
+
+func TestParseRRule(t *testing.T) {
+	start := time.Date(2024, time.January, 1, 9, 0, 0, 0, time.UTC)
+	rule, err := ParseRRule("RRULE:FREQ=weekly;INTERVAL=2;BYDAY=tu,TH;UNTIL=20240601T000000Z", start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := rule.String(), "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH;UNTIL=20240601T000000Z"; got != want {
+		t.Errorf("String = %s, want %s", got, want)
+	}
+
+	tests := []struct {
+		rule    string
+		wantErr string
+	}{
+		{"INTERVAL=2", "missing FREQ"},
+		{"FREQ=YEARLY", `unsupported FREQ "YEARLY"`},
+		{"FREQ=DAILY;BYMONTH=1", "unsupported BYMONTH"},
+		{"FREQ=DAILY;COUNT", `expected KEY=VALUE, got "COUNT"`},
+		{"FREQ=DAILY;INTERVAL=0", "invalid INTERVAL"},
+		{"FREQ=DAILY;COUNT=x", "invalid COUNT"},
+		{"FREQ=DAILY;UNTIL=tomorrow", "invalid UNTIL"},
+		{"FREQ=WEEKLY;BYDAY=XX", `unknown day "XX"`},
+		{"FREQ=MONTHLY;BYDAY=6MO", "invalid position"},
+		{"FREQ=WEEKLY;BYDAY=2MO", "only allowed in MONTHLY rules"},
+		{"FREQ=DAILY;COUNT=2;UNTIL=20240601", "COUNT and UNTIL cannot both be set"},
+	}
+	for _, tt := range tests {
+		_, err := ParseRRule(tt.rule, start)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("ParseRRule(%q): %v, want %q", tt.rule, err, tt.wantErr)
+		}
+	}
+
+	if _, err := Expand(RRule{Freq: FreqDaily}, start, start.AddDate(0, 0, 1)); err == nil {
+		t.Error("Expand without a start succeeded")
+	}
+}
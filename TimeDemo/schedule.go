@@ -0,0 +1,498 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule computes the occurrences of a recurring event in a location.
+// ParseSchedule understands:
+//
+//	every monday 09:00           weekdays: monday..sunday, mon..sun, lists
+//	every mon,wed,fri at 18:30   (mon,wed,fri), weekday (mon-fri), weekend
+//	every day 07:00
+//	every 2 weeks                intervals of minutes, hours, days, weeks or
+//	every month from 2024-01-31  months, counted from the "from" time or,
+//	                             without one, from the time passed to Next
+//	last day of month 17:00
+//	*/15 9-17 * * mon-fri        standard 5-field cron, and @hourly, @daily,
+//	                             @weekly, @monthly, @yearly
+//
+// Wall-clock times skipped by a daylight saving change (02:30 on the
+// spring-forward day) fire as many minutes after the jump as they were
+// into the gap, 03:30; times repeated by it fire once, at the first
+// occurrence.
+type Schedule struct {
+	expr string
+	loc  *time.Location
+
+	// Calendar schedules: bit i set means value i matches
+	minutes, hours, doms, months, dows uint64
+	domStar, dowStar                   bool
+	lastDayOfMonth                     bool
+
+	// Interval schedules
+	every int
+	unit  string // minute, hour, day, week or month
+	start time.Time
+}
+
+// maxSearchYears bounds the search for a calendar match; 8 years covers a
+// leap day skipped by a century year
+const maxSearchYears = 8
+
+// Weekly returns a Schedule firing at hour:minute on days in loc
+func Weekly(hour, minute int, loc *time.Location, days ...time.Weekday) *Schedule {
+	s := &Schedule{loc: loc, minutes: 1 << minute, hours: 1 << hour, doms: rangeBits(1, 31), months: rangeBits(1, 12), domStar: true}
+	names := make([]string, len(days))
+	for i, day := range days {
+		s.dows |= 1 << day
+		names[i] = strings.ToLower(day.String())
+	}
+	s.expr = fmt.Sprintf("every %s %02d:%02d", strings.Join(names, ","), hour, minute)
+	return s
+}
+
+// ParseSchedule parses expr (see Schedule) for occurrences in loc
+func ParseSchedule(expr string, loc *time.Location) (*Schedule, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	s, err := parseSchedule(strings.Fields(strings.ToLower(expr)), loc)
+	if err != nil {
+		return nil, fmt.Errorf("schedule %q: %w", expr, err)
+	}
+	s.expr, s.loc = strings.Join(strings.Fields(expr), " "), loc
+
+	// Catch calendar schedules like February 30th that can never fire
+	if s.unit == "" && s.Next(time.Date(2000, time.January, 1, 0, 0, 0, 0, loc)).IsZero() {
+		return nil, fmt.Errorf("schedule %q never fires", expr)
+	}
+	return s, nil
+}
+
+// String returns the expression the Schedule was parsed from
+func (s *Schedule) String() string { return s.expr }
+
+// Next returns the first occurrence after after, or the zero Time when
+// there is none within eight years
+func (s *Schedule) Next(after time.Time) time.Time {
+	if s.unit != "" {
+		anchor := s.start
+		if anchor.IsZero() {
+			anchor = after
+		}
+		return s.nextInterval(anchor, after)
+	}
+	return s.nextCalendar(after)
+}
+
+// NextN returns the next n occurrences after after, fewer if the schedule
+// runs out
+func (s *Schedule) NextN(after time.Time, n int) []time.Time {
+	var occurrences []time.Time
+	anchor := s.start
+	if s.unit != "" && anchor.IsZero() {
+		// Keep counting from the first after, not from each occurrence
+		anchor = after
+	}
+	for len(occurrences) < n {
+		var next time.Time
+		if s.unit != "" {
+			next = s.nextInterval(anchor, after)
+		} else {
+			next = s.nextCalendar(after)
+		}
+		if next.IsZero() {
+			break
+		}
+		occurrences = append(occurrences, next)
+		after = next
+	}
+	return occurrences
+}
+
+// Upcoming returns the next n occurrences after the clock's current time
+func (s *Schedule) Upcoming(clock Clock, n int) []time.Time {
+	return s.NextN(clock.Now(), n)
+}
+
+func (s *Schedule) nextCalendar(after time.Time) time.Time {
+	local := after.In(s.loc)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+	end := day.AddDate(maxSearchYears, 0, 0)
+
+	for ; day.Before(end); day = day.AddDate(0, 0, 1) {
+		if !s.matchesDay(day) {
+			continue
+		}
+		for hour := 0; hour < 24; hour++ {
+			if s.hours&(1<<hour) == 0 {
+				continue
+			}
+			for minute := 0; minute < 60; minute++ {
+				if s.minutes&(1<<minute) == 0 {
+					continue
+				}
+				if t := wallTime(day.Year(), day.Month(), day.Day(), hour, minute, s.loc); t.After(after) {
+					return t
+				}
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// matchesDay applies the day fields of a date given in UTC. As in cron,
+// when both day of month and day of week are restricted either may match.
+func (s *Schedule) matchesDay(day time.Time) bool {
+	if s.months&(1<<day.Month()) == 0 {
+		return false
+	}
+	dom := s.doms&(1<<day.Day()) != 0
+	if s.lastDayOfMonth {
+		dom = day.AddDate(0, 0, 1).Day() == 1
+	}
+	dow := s.dows&(1<<day.Weekday()) != 0
+	switch {
+	case s.domStar && s.dowStar:
+		return true
+	case s.domStar:
+		return dow
+	case s.dowStar:
+		return dom
+	}
+	return dom || dow
+}
+
+// wallTime returns hour:minute on the given date in loc, moving a time
+// skipped by a daylight saving change forward by the size of the gap
+func wallTime(year int, month time.Month, day, hour, minute int, loc *time.Location) time.Time {
+	t := time.Date(year, month, day, hour, minute, 0, 0, loc)
+	if t.Hour() == hour && t.Minute() == minute {
+		return t
+	}
+	// Read the wall clock with the offset in force before the gap
+	_, before := t.Add(-12 * time.Hour).Zone()
+	return time.Date(year, month, day, hour, minute, 0, 0, time.UTC).Add(-time.Duration(before) * time.Second).In(loc)
+}
+
+// nextInterval returns the first anchor + k intervals after after, k >= 0
+func (s *Schedule) nextInterval(anchor, after time.Time) time.Time {
+	anchor = anchor.In(s.loc)
+	occurrence := func(k int) time.Time {
+		switch s.unit {
+		case "minute":
+			return anchor.Add(time.Duration(k*s.every) * time.Minute)
+		case "hour":
+			return anchor.Add(time.Duration(k*s.every) * time.Hour)
+		case "day":
+			return anchor.AddDate(0, 0, k*s.every)
+		case "week":
+			return anchor.AddDate(0, 0, 7*k*s.every)
+		}
+		return addMonthsClamped(anchor, k*s.every)
+	}
+
+	// Estimate k, then correct it; calendar units drift from the estimate
+	// by daylight saving hours and month lengths only
+	var k int
+	if after.After(anchor) {
+		span := map[string]time.Duration{
+			"minute": time.Minute, "hour": time.Hour, "day": 24 * time.Hour,
+			"week": 7 * 24 * time.Hour, "month": 30 * 24 * time.Hour,
+		}[s.unit] * time.Duration(s.every)
+		k = int(after.Sub(anchor) / span)
+	}
+	for k > 0 && occurrence(k-1).After(after) {
+		k--
+	}
+	for !occurrence(k).After(after) {
+		k++
+	}
+	return occurrence(k)
+}
+
+// addMonthsClamped adds months to t, clamping the day to the end of
+// shorter months: Jan 31 + 1 month is Feb 29 in 2024, + 2 months Mar 31
+func addMonthsClamped(t time.Time, months int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	day := min(t.Day(), DaysInMonth(first.Year(), first.Month()))
+	return time.Date(first.Year(), first.Month(), day, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
+
+var cronMacros = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+	"@yearly":  "0 0 1 1 *",
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday,
+	"wednesday": time.Wednesday, "thursday": time.Thursday, "friday": time.Friday,
+	"saturday": time.Saturday,
+
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+func parseSchedule(fields []string, loc *time.Location) (*Schedule, error) {
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty expression")
+	}
+	if cron, ok := cronMacros[fields[0]]; ok && len(fields) == 1 {
+		return parseCron(strings.Fields(cron))
+	}
+	if strings.HasPrefix(fields[0], "@") {
+		return nil, fmt.Errorf("unknown macro %q (use @hourly, @daily, @weekly, @monthly or @yearly)", fields[0])
+	}
+
+	words := fields
+	if words[0] == "every" {
+		words = words[1:]
+	}
+	if len(words) >= 3 && words[0] == "last" && words[1] == "day" && words[2] == "of" {
+		rest := words[3:]
+		if len(rest) > 0 && rest[0] == "the" {
+			rest = rest[1:]
+		}
+		if len(rest) == 0 || rest[0] != "month" {
+			return nil, fmt.Errorf(`expected "last day of month"`)
+		}
+		s, err := atTime(rest[1:])
+		if err != nil {
+			return nil, err
+		}
+		s.lastDayOfMonth, s.doms, s.domStar = true, 0, false
+		return s, nil
+	}
+	if fields[0] == "every" {
+		return parseEvery(words, loc)
+	}
+	if len(fields) == 5 {
+		return parseCron(fields)
+	}
+	return nil, fmt.Errorf(`expected "every ...", "last day of month ..." or 5 cron fields, got %d fields`, len(fields))
+}
+
+// parseEvery parses what follows "every"
+func parseEvery(words []string, loc *time.Location) (*Schedule, error) {
+	if len(words) == 0 {
+		return nil, fmt.Errorf(`"every" needs a day, weekday or interval`)
+	}
+
+	// "every day" with a time is a daily schedule, without one an interval
+	if words[0] == "day" && len(words) > 1 && words[1] != "from" && words[1] != "starting" {
+		return atTime(words[1:])
+	}
+
+	n, unitWords := 1, words
+	if v, err := strconv.Atoi(words[0]); err == nil {
+		if v <= 0 {
+			return nil, fmt.Errorf("interval must be positive, got %d", v)
+		}
+		n, unitWords = v, words[1:]
+	}
+	if len(unitWords) > 0 {
+		if unit, ok := intervalUnit(unitWords[0]); ok {
+			return parseInterval(n, unit, unitWords[1:], loc)
+		}
+	}
+	if n != 1 || len(unitWords) != len(words) {
+		return nil, fmt.Errorf("unknown interval unit in %q (use minutes, hours, days, weeks or months)", strings.Join(words, " "))
+	}
+
+	dows, err := parseWeekdays(words[0])
+	if err != nil {
+		return nil, err
+	}
+	s, err := atTime(words[1:])
+	if err != nil {
+		return nil, err
+	}
+	s.dows, s.dowStar = dows, false
+	return s, nil
+}
+
+func intervalUnit(word string) (string, bool) {
+	unit := strings.TrimSuffix(word, "s")
+	switch unit {
+	case "minute", "hour", "day", "week", "month":
+		return unit, true
+	}
+	return "", false
+}
+
+// parseInterval parses the optional "from <time>" after an interval
+func parseInterval(n int, unit string, rest []string, loc *time.Location) (*Schedule, error) {
+	s := &Schedule{every: n, unit: unit}
+	if len(rest) == 0 {
+		return s, nil
+	}
+	if rest[0] != "from" && rest[0] != "starting" {
+		return nil, fmt.Errorf("unexpected %q after the interval (expected \"from <time>\")", strings.Join(rest, " "))
+	}
+	start, err := ParseFlexible(strings.Join(rest[1:], " "), loc)
+	if err != nil {
+		return nil, fmt.Errorf("start: %w", err)
+	}
+	s.start = start
+	return s, nil
+}
+
+func parseWeekdays(word string) (uint64, error) {
+	switch word {
+	case "weekday", "weekdays":
+		return rangeBits(1, 5), nil
+	case "weekend", "weekends":
+		return 1<<time.Saturday | 1<<time.Sunday, nil
+	}
+	var dows uint64
+	for _, name := range strings.Split(word, ",") {
+		day, ok := weekdayNames[strings.TrimSuffix(name, "s")]
+		if !ok {
+			day, ok = weekdayNames[name]
+		}
+		if !ok {
+			return 0, fmt.Errorf("unknown weekday %q", name)
+		}
+		dows |= 1 << day
+	}
+	return dows, nil
+}
+
+// atTime parses "[at] HH:MM" (midnight when empty) into a daily Schedule
+func atTime(words []string) (*Schedule, error) {
+	if len(words) > 0 && words[0] == "at" {
+		words = words[1:]
+	}
+	hour, minute := 0, 0
+	switch len(words) {
+	case 0:
+	case 1:
+		t, err := time.Parse("15:04", words[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid time %q (use HH:MM, 24-hour)", words[0])
+		}
+		hour, minute = t.Hour(), t.Minute()
+	default:
+		return nil, fmt.Errorf("unexpected %q", strings.Join(words, " "))
+	}
+	return &Schedule{
+		minutes: 1 << minute, hours: 1 << hour,
+		doms: rangeBits(1, 31), months: rangeBits(1, 12), dows: rangeBits(0, 6),
+		domStar: true, dowStar: true,
+	}, nil
+}
+
+// cronField describes one field of a cron expression
+type cronField struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var cronFields = []cronField{
+	{"minute", 0, 59, nil},
+	{"hour", 0, 23, nil},
+	{"day of month", 1, 31, nil},
+	{"month", 1, 12, map[string]int{"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12}},
+	{"day of week", 0, 7, map[string]int{"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6}},
+}
+
+func parseCron(fields []string) (*Schedule, error) {
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 cron fields, got %d", len(fields))
+	}
+	var values [5]uint64
+	for i, f := range cronFields {
+		v, err := f.parse(fields[i])
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	// 7 is Sunday too
+	if values[4]&(1<<7) != 0 {
+		values[4] = values[4]&^(1<<7) | 1
+	}
+	return &Schedule{
+		minutes: values[0], hours: values[1], doms: values[2], months: values[3], dows: values[4],
+		domStar: fields[2] == "*", dowStar: fields[4] == "*",
+	}, nil
+}
+
+// parse reads a list of *, n, a-b and /step terms
+func (f cronField) parse(field string) (uint64, error) {
+	var set uint64
+	for _, term := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(term, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepPart); err != nil || step <= 0 {
+				return 0, fmt.Errorf("%s: invalid step %q", f.name, stepPart)
+			}
+		}
+
+		lo, hi := f.min, f.max
+		if f.name == "day of week" {
+			hi = 6 // * means Sunday once, not twice
+		}
+		if rangePart != "*" {
+			from, to, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if lo, err = f.value(from); err != nil {
+				return 0, err
+			}
+			switch {
+			case isRange:
+				if hi, err = f.value(to); err != nil {
+					return 0, err
+				}
+				if hi < lo {
+					return 0, fmt.Errorf("%s: range %q is backwards", f.name, rangePart)
+				}
+			case hasStep:
+				hi = f.max // a/n runs to the end
+			default:
+				hi = lo
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			set |= 1 << v
+		}
+	}
+	if set == 0 {
+		return 0, fmt.Errorf("%s: %q matches nothing", f.name, field)
+	}
+	return set, nil
+}
+
+func (f cronField) value(s string) (int, error) {
+	if v, ok := f.names[s]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%s: invalid value %q", f.name, s)
+	}
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("%s: %d out of range %d-%d", f.name, v, f.min, f.max)
+	}
+	return v, nil
+}
+
+// rangeBits sets bits lo through hi
+func rangeBits(lo, hi int) uint64 {
+	var set uint64
+	for v := lo; v <= hi; v++ {
+		set |= 1 << v
+	}
+	return set
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// formatTimes formats times as local wall clocks with their zone
+func formatTimes(times []time.Time) string {
+	formatted := make([]string, len(times))
+	for i, t := range times {
+		formatted[i] = t.Format("Mon 2006-01-02 15:04 MST")
+	}
+	return strings.Join(formatted, ", ")
+}
+
+func TestScheduleNextN(t *testing.T) {
+	eastern := mustZone(t, "America/New_York")
+	// Wednesday
+	after := time.Date(2024, time.May, 15, 10, 0, 0, 0, eastern)
+
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"every monday 09:00", "Mon 2024-05-20 09:00 EDT, Mon 2024-05-27 09:00 EDT, Mon 2024-06-03 09:00 EDT"},
+		{"Every Mon,Fri at 18:30", "Fri 2024-05-17 18:30 EDT, Mon 2024-05-20 18:30 EDT, Fri 2024-05-24 18:30 EDT"},
+		{"every weekday 10:00", "Thu 2024-05-16 10:00 EDT, Fri 2024-05-17 10:00 EDT, Mon 2024-05-20 10:00 EDT"},
+		{"every weekend", "Sat 2024-05-18 00:00 EDT, Sun 2024-05-19 00:00 EDT, Sat 2024-05-25 00:00 EDT"},
+		{"every day 07:00", "Thu 2024-05-16 07:00 EDT, Fri 2024-05-17 07:00 EDT, Sat 2024-05-18 07:00 EDT"},
+		{"every 2 weeks", "Wed 2024-05-29 10:00 EDT, Wed 2024-06-12 10:00 EDT, Wed 2024-06-26 10:00 EDT"},
+		{"every 90 minutes", "Wed 2024-05-15 11:30 EDT, Wed 2024-05-15 13:00 EDT, Wed 2024-05-15 14:30 EDT"},
+		{"every 2 weeks from 2024-01-05 17:00", "Fri 2024-05-24 17:00 EDT, Fri 2024-06-07 17:00 EDT, Fri 2024-06-21 17:00 EDT"},
+		{"last day of month 17:00", "Fri 2024-05-31 17:00 EDT, Sun 2024-06-30 17:00 EDT, Wed 2024-07-31 17:00 EDT"},
+		{"every last day of the month", "Fri 2024-05-31 00:00 EDT, Sun 2024-06-30 00:00 EDT, Wed 2024-07-31 00:00 EDT"},
+		{"*/20 9-10 * * mon-fri", "Wed 2024-05-15 10:20 EDT, Wed 2024-05-15 10:40 EDT, Thu 2024-05-16 09:00 EDT"},
+		{"0 12 1,15 * *", "Wed 2024-05-15 12:00 EDT, Sat 2024-06-01 12:00 EDT, Sat 2024-06-15 12:00 EDT"},
+		// Day of month and day of week restricted: either matches
+		{"0 8 1 * 7", "Sun 2024-05-19 08:00 EDT, Sun 2024-05-26 08:00 EDT, Sat 2024-06-01 08:00 EDT"},
+		{"0 0 29 feb *", "Tue 2028-02-29 00:00 EST, Sun 2032-02-29 00:00 EST, Fri 2036-02-29 00:00 EST"},
+		{"@monthly", "Sat 2024-06-01 00:00 EDT, Mon 2024-07-01 00:00 EDT, Thu 2024-08-01 00:00 EDT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			s, err := ParseSchedule(tt.expr, eastern)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := formatTimes(s.NextN(after, 3)); got != tt.want {
+				t.Errorf("NextN =\n%s\nwant\n%s", got, tt.want)
+			}
+			if first := s.Next(after); formatTimes([]time.Time{first}) != strings.Split(tt.want, ", ")[0] {
+				t.Errorf("Next = %s, want the first of NextN", first)
+			}
+		})
+	}
+}
+
+func TestScheduleMonthEnd(t *testing.T) {
+	utc := time.UTC
+
+	// Each occurrence counts from Jan 31, so a short month does not pull
+	// the rest back to the 29th
+	monthly, err := ParseSchedule("every month from 2024-01-31 09:00", utc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := monthly.NextN(time.Date(2024, time.January, 1, 0, 0, 0, 0, utc), 6)
+	want := "Wed 2024-01-31 09:00 UTC, Thu 2024-02-29 09:00 UTC, Sun 2024-03-31 09:00 UTC, " +
+		"Tue 2024-04-30 09:00 UTC, Fri 2024-05-31 09:00 UTC, Sun 2024-06-30 09:00 UTC"
+	if formatTimes(got) != want {
+		t.Errorf("monthly from Jan 31 =\n%s\nwant\n%s", formatTimes(got), want)
+	}
+
+	// Picking up mid-sequence lands on the same dates
+	if next := monthly.Next(time.Date(2025, time.February, 10, 0, 0, 0, 0, utc)); !next.Equal(time.Date(2025, time.February, 28, 9, 0, 0, 0, utc)) {
+		t.Errorf("Next after 2025-02-10 = %s, want 2025-02-28 09:00", next)
+	}
+
+	// Unanchored, the first call's time is the anchor
+	everyMonth, _ := ParseSchedule("every month", utc)
+	got = everyMonth.NextN(time.Date(2023, time.January, 31, 8, 0, 0, 0, utc), 2)
+	if want := "Tue 2023-02-28 08:00 UTC, Fri 2023-03-31 08:00 UTC"; formatTimes(got) != want {
+		t.Errorf("every month from Jan 31 = %s, want %s", formatTimes(got), want)
+	}
+
+	// "last day of month" follows February through a leap year
+	last, _ := ParseSchedule("last day of month 17:00", utc)
+	got = last.NextN(time.Date(2024, time.January, 31, 18, 0, 0, 0, utc), 2)
+	if want := "Thu 2024-02-29 17:00 UTC, Sun 2024-03-31 17:00 UTC"; formatTimes(got) != want {
+		t.Errorf("last day of month = %s, want %s", formatTimes(got), want)
+	}
+}
+
+func TestScheduleDST(t *testing.T) {
+	eastern := mustZone(t, "America/New_York")
+
+	tests := []struct {
+		name  string
+		expr  string
+		after time.Time
+		want  string
+	}{
+		{"wall clock kept across spring forward", "every day 09:00",
+			time.Date(2024, time.March, 9, 10, 0, 0, 0, eastern),
+			"Sun 2024-03-10 09:00 EDT, Mon 2024-03-11 09:00 EDT"},
+		{"wall clock kept across fall back", "every sunday 09:00",
+			time.Date(2024, time.October, 28, 0, 0, 0, 0, eastern),
+			"Sun 2024-11-03 09:00 EST, Sun 2024-11-10 09:00 EST"},
+		{"skipped time fires after the jump", "every day 02:30",
+			time.Date(2024, time.March, 9, 12, 0, 0, 0, eastern),
+			"Sun 2024-03-10 03:30 EDT, Mon 2024-03-11 02:30 EDT"},
+		{"repeated time fires once", "30 1 * * *",
+			time.Date(2024, time.November, 2, 12, 0, 0, 0, eastern),
+			"Sun 2024-11-03 01:30 EDT, Mon 2024-11-04 01:30 EST"},
+		{"hourly skips the missing hour", "0 * * * *",
+			time.Date(2024, time.March, 10, 0, 30, 0, 0, eastern),
+			"Sun 2024-03-10 01:00 EST, Sun 2024-03-10 03:00 EDT, Sun 2024-03-10 04:00 EDT"},
+		{"daily interval keeps the wall clock", "every day from 2024-03-09 09:00",
+			time.Date(2024, time.March, 9, 10, 0, 0, 0, eastern),
+			"Sun 2024-03-10 09:00 EDT, Mon 2024-03-11 09:00 EDT"},
+		{"hour interval counts real hours", "every 1 hour from 2024-03-10 00:00",
+			time.Date(2024, time.March, 10, 0, 30, 0, 0, eastern),
+			"Sun 2024-03-10 01:00 EST, Sun 2024-03-10 03:00 EDT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := ParseSchedule(tt.expr, eastern)
+			if err != nil {
+				t.Fatal(err)
+			}
+			n := strings.Count(tt.want, ",") + 1
+			if got := formatTimes(s.NextN(tt.after, n)); got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr string
+	}{
+		{"", "empty expression"},
+		{"every funday 09:00", `unknown weekday "funday"`},
+		{"every monday 25:00", `invalid time "25:00"`},
+		{"every monday 9am", `invalid time "9am"`},
+		{"every 0 days", "interval must be positive"},
+		{"every 3 fortnights", "unknown interval unit"},
+		{"every 2 weeks on monday", `unexpected "on monday"`},
+		{"every week from someday", "start: cannot parse"},
+		{"last day of year", `expected "last day of month"`},
+		{"* * * *", "got 4 fields"},
+		{"61 * * * *", "minute: 61 out of range 0-59"},
+		{"0 24 * * *", "hour: 24 out of range 0-23"},
+		{"0 0 0 * *", "day of month: 0 out of range 1-31"},
+		{"0 0 * foo *", `month: invalid value "foo"`},
+		{"*/0 * * * *", `minute: invalid step "0"`},
+		{"0 17-9 * * *", `hour: range "17-9" is backwards`},
+		{"0 0 30 2 *", "never fires"},
+		{"@fortnightly", "unknown macro"},
+	}
+	for _, tt := range tests {
+		s, err := ParseSchedule(tt.expr, time.UTC)
+		if err == nil {
+			t.Errorf("ParseSchedule(%q) = %s, want an error", tt.expr, s)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.wantErr) || !strings.Contains(err.Error(), `"`+tt.expr+`"`) {
+			t.Errorf("ParseSchedule(%q): %v; want the expression and %q", tt.expr, err, tt.wantErr)
+		}
+	}
+}
+
+func TestGetNextWeekday(t *testing.T) {
+	// Friday
+	from := time.Date(2024, time.May, 17, 14, 45, 30, 0, time.UTC)
+
+	tests := []struct {
+		weekday time.Weekday
+		want    time.Time
+	}{
+		{time.Saturday, time.Date(2024, time.May, 18, 14, 45, 30, 0, time.UTC)},
+		{time.Monday, time.Date(2024, time.May, 20, 14, 45, 30, 0, time.UTC)},
+		{time.Thursday, time.Date(2024, time.May, 23, 14, 45, 30, 0, time.UTC)},
+		{time.Friday, time.Date(2024, time.May, 24, 14, 45, 30, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := getNextWeekday(from, tt.weekday); !got.Equal(tt.want) {
+			t.Errorf("getNextWeekday(%s) = %s, want %s", tt.weekday, got, tt.want)
+		}
+	}
+}
+
+func TestScheduleUpcoming(t *testing.T) {
+	eastern := mustZone(t, "America/New_York")
+	clock := NewFakeClock(time.Date(2024, time.May, 15, 10, 0, 0, 0, eastern))
+	s, err := ParseSchedule("every monday 09:00", eastern)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := formatTimes(s.Upcoming(clock, 2)), "Mon 2024-05-20 09:00 EDT, Mon 2024-05-27 09:00 EDT"; got != want {
+		t.Errorf("Upcoming = %s, want %s", got, want)
+	}
+	clock.Advance(7 * 24 * time.Hour)
+	if got, want := formatTimes(s.Upcoming(clock, 1)), "Mon 2024-05-27 09:00 EDT"; got != want {
+		t.Errorf("a week later, Upcoming = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"time"
+)
+
+// Stopwatch measures elapsed time across Start/Stop pauses and splits it
+// into named laps. The zero value is a stopped stopwatch reading zero.
+type Stopwatch struct {
+	// Now is the clock, time.Now when nil; tests substitute a fake one
+	Now func() time.Time
+
+	running bool
+	started time.Time     // when the current run began
+	elapsed time.Duration // total of the finished runs
+	lapMark time.Duration // Elapsed at the end of the last lap
+	laps    []Lap
+}
+
+// Lap is a named split of a Stopwatch
+type Lap struct {
+	Name     string
+	Duration time.Duration
+}
+
+func (s *Stopwatch) now() time.Time {
+	if s.Now == nil {
+		return time.Now()
+	}
+	return s.Now()
+}
+
+// Start starts or resumes the stopwatch; it does nothing if running
+func (s *Stopwatch) Start() {
+	if !s.running {
+		s.started, s.running = s.now(), true
+	}
+}
+
+// Stop pauses the stopwatch; time until the next Start is not counted
+func (s *Stopwatch) Stop() {
+	if s.running {
+		s.elapsed += s.now().Sub(s.started)
+		s.running = false
+	}
+}
+
+// Reset stops the stopwatch and clears the elapsed time and laps
+func (s *Stopwatch) Reset() {
+	*s = Stopwatch{Now: s.Now}
+}
+
+// Running reports whether the stopwatch is running
+func (s *Stopwatch) Running() bool {
+	return s.running
+}
+
+// Elapsed is the running time so far, excluding pauses
+func (s *Stopwatch) Elapsed() time.Duration {
+	if s.running {
+		return s.elapsed + s.now().Sub(s.started)
+	}
+	return s.elapsed
+}
+
+// Lap records and returns the running time since the previous lap, or
+// since the first Start for the first one
+func (s *Stopwatch) Lap(name string) time.Duration {
+	elapsed := s.Elapsed()
+	d := elapsed - s.lapMark
+	s.lapMark = elapsed
+	s.laps = append(s.laps, Lap{Name: name, Duration: d})
+	return d
+}
+
+// Laps returns the recorded laps in order
+func (s *Stopwatch) Laps() []Lap {
+	return slices.Clone(s.laps)
+}
+
+// Report summarizes the recorded laps
+func (s *Stopwatch) Report(name string) Report {
+	return newReport(name, s.Laps())
+}
+
+// Measure resets the stopwatch and runs fn iterations times, one lap per
+// run
+func (s *Stopwatch) Measure(name string, fn func(), iterations int) Report {
+	s.Reset()
+	for i := 0; i < iterations; i++ {
+		s.Start()
+		fn()
+		s.Stop()
+		s.Lap(fmt.Sprintf("Run %d", i+1))
+	}
+	return s.Report(name)
+}
+
+// Measure runs fn iterations times and reports the time of each run
+func Measure(name string, fn func(), iterations int) Report {
+	return (&Stopwatch{}).Measure(name, fn, iterations)
+}
+
+// Report is a summary of laps. Percentiles use the nearest-rank method:
+// P90 is the smallest lap at least as long as 90% of them.
+type Report struct {
+	Name                  string
+	Laps                  []Lap
+	Total, Min, Max, Mean time.Duration
+	P50, P90, P99         time.Duration
+
+	sorted []time.Duration
+}
+
+func newReport(name string, laps []Lap) Report {
+	r := Report{Name: name, Laps: laps}
+	if len(laps) == 0 {
+		return r
+	}
+	for _, lap := range laps {
+		r.sorted = append(r.sorted, lap.Duration)
+		r.Total += lap.Duration
+	}
+	slices.Sort(r.sorted)
+	r.Min, r.Max = r.sorted[0], r.sorted[len(r.sorted)-1]
+	r.Mean = r.Total / time.Duration(len(laps))
+	r.P50, r.P90, r.P99 = r.Percentile(50), r.Percentile(90), r.Percentile(99)
+	return r
+}
+
+// Percentile returns the nearest-rank p-th percentile of the laps, for p
+// from 0 to 100; 0 with no laps
+func (r Report) Percentile(p float64) time.Duration {
+	if len(r.sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(r.sorted))))
+	return r.sorted[min(max(rank, 1), len(r.sorted))-1]
+}
+
+// String summarizes the report on one line
+func (r Report) String() string {
+	if len(r.Laps) == 0 {
+		return r.Name + ": no laps"
+	}
+	return fmt.Sprintf("%s: %d laps, total %v, mean %v, min %v, max %v, p50 %v, p90 %v, p99 %v",
+		r.Name, len(r.Laps), r.Total, r.Mean, r.Min, r.Max, r.P50, r.P90, r.P99)
+}
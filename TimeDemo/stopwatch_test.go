@@ -0,0 +1,188 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func newFakeStopwatch() (*Stopwatch, *FakeClock) {
+	clock := NewFakeClock(time.Date(2024, time.May, 15, 12, 0, 0, 0, time.UTC))
+	return &Stopwatch{Now: clock.Now}, clock
+}
+
+func TestStopwatchPauseResume(t *testing.T) {
+	sw, clock := newFakeStopwatch()
+
+	if sw.Elapsed() != 0 || sw.Running() {
+		t.Fatal("zero Stopwatch is not stopped at zero")
+	}
+
+	sw.Start()
+	clock.Advance(3 * time.Second)
+	if got := sw.Elapsed(); got != 3*time.Second {
+		t.Errorf("running: Elapsed = %v, want 3s", got)
+	}
+
+	sw.Stop()
+	clock.Advance(10 * time.Second) // paused
+	if got := sw.Elapsed(); got != 3*time.Second {
+		t.Errorf("paused: Elapsed = %v, want 3s", got)
+	}
+
+	sw.Start()
+	clock.Advance(2 * time.Second)
+	sw.Start() // already running: no effect
+	clock.Advance(time.Second)
+	if got := sw.Elapsed(); got != 6*time.Second {
+		t.Errorf("resumed: Elapsed = %v, want 6s", got)
+	}
+
+	sw.Stop()
+	sw.Stop() // already stopped: no effect
+	clock.Advance(time.Minute)
+	if got := sw.Elapsed(); got != 6*time.Second || sw.Running() {
+		t.Errorf("stopped: Elapsed = %v, running %v; want 6s, stopped", got, sw.Running())
+	}
+
+	sw.Reset()
+	if sw.Elapsed() != 0 || sw.Running() || len(sw.Laps()) != 0 {
+		t.Error("Reset did not clear the stopwatch")
+	}
+	sw.Start()
+	clock.Advance(time.Second)
+	if got := sw.Elapsed(); got != time.Second {
+		t.Errorf("after Reset the clock is kept: Elapsed = %v, want 1s", got)
+	}
+}
+
+func TestStopwatchLaps(t *testing.T) {
+	sw, clock := newFakeStopwatch()
+
+	sw.Start()
+	clock.Advance(2 * time.Second)
+	if got := sw.Lap("load"); got != 2*time.Second {
+		t.Errorf("Lap(load) = %v, want 2s", got)
+	}
+
+	// A pause inside a lap is excluded from it
+	clock.Advance(time.Second)
+	sw.Stop()
+	clock.Advance(30 * time.Second)
+	sw.Start()
+	clock.Advance(4 * time.Second)
+	if got := sw.Lap("parse"); got != 5*time.Second {
+		t.Errorf("Lap(parse) = %v, want 5s", got)
+	}
+
+	// A lap taken while stopped ends at the Stop
+	clock.Advance(500 * time.Millisecond)
+	sw.Stop()
+	clock.Advance(time.Hour)
+	if got := sw.Lap("save"); got != 500*time.Millisecond {
+		t.Errorf("Lap(save) = %v, want 500ms", got)
+	}
+
+	want := []Lap{{"load", 2 * time.Second}, {"parse", 5 * time.Second}, {"save", 500 * time.Millisecond}}
+	laps := sw.Laps()
+	if len(laps) != len(want) {
+		t.Fatalf("Laps = %v, want %v", laps, want)
+	}
+	for i := range want {
+		if laps[i] != want[i] {
+			t.Errorf("lap %d = %v, want %v", i, laps[i], want[i])
+		}
+	}
+
+	// Laps returns a copy
+	laps[0].Name = "changed"
+	if sw.Laps()[0].Name != "load" {
+		t.Error("Laps exposed the stopwatch's own slice")
+	}
+
+	r := sw.Report("pipeline")
+	if r.Total != sw.Elapsed() || r.Total != 7500*time.Millisecond {
+		t.Errorf("Report.Total = %v, Elapsed = %v, want 7.5s", r.Total, sw.Elapsed())
+	}
+}
+
+func TestReportPercentiles(t *testing.T) {
+	laps := func(ms ...int) []Lap {
+		var laps []Lap
+		for _, m := range ms {
+			laps = append(laps, Lap{Duration: time.Duration(m) * time.Millisecond})
+		}
+		return laps
+	}
+	ms := time.Millisecond
+
+	tests := []struct {
+		name                          string
+		laps                          []Lap
+		min, max, mean, p50, p90, p99 time.Duration
+	}{
+		{"one to ten", laps(1, 2, 3, 4, 5, 6, 7, 8, 9, 10), 1 * ms, 10 * ms, 5500 * time.Microsecond, 5 * ms, 9 * ms, 10 * ms},
+		{"unsorted", laps(10, 1, 9, 2, 8, 3, 7, 4, 6, 5), 1 * ms, 10 * ms, 5500 * time.Microsecond, 5 * ms, 9 * ms, 10 * ms},
+		{"single lap", laps(7), 7 * ms, 7 * ms, 7 * ms, 7 * ms, 7 * ms, 7 * ms},
+		{"outlier", laps(10, 10, 10, 10, 10, 10, 10, 10, 10, 500), 10 * ms, 500 * ms, 59 * ms, 10 * ms, 10 * ms, 500 * ms},
+		{"odd count", laps(3, 1, 2), 1 * ms, 3 * ms, 2 * ms, 2 * ms, 3 * ms, 3 * ms},
+		{"no laps", nil, 0, 0, 0, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		r := newReport(tt.name, tt.laps)
+		got := []time.Duration{r.Min, r.Max, r.Mean, r.P50, r.P90, r.P99}
+		want := []time.Duration{tt.min, tt.max, tt.mean, tt.p50, tt.p90, tt.p99}
+		for i, field := range []string{"Min", "Max", "Mean", "P50", "P90", "P99"} {
+			if got[i] != want[i] {
+				t.Errorf("%s: %s = %v, want %v", tt.name, field, got[i], want[i])
+			}
+		}
+	}
+
+	r := newReport("bounds", laps(1, 2, 3, 4))
+	for p, want := range map[float64]time.Duration{0: 1 * ms, 25: 1 * ms, 26: 2 * ms, 75: 3 * ms, 100: 4 * ms, 150: 4 * ms, -5: 1 * ms} {
+		if got := r.Percentile(p); got != want {
+			t.Errorf("Percentile(%v) = %v, want %v", p, got, want)
+		}
+	}
+}
+
+func TestStopwatchMeasure(t *testing.T) {
+	sw, clock := newFakeStopwatch()
+	sw.Start()
+	clock.Advance(time.Hour)
+	sw.Lap("left over")
+
+	// Each run takes 1ms longer than the one before
+	run := 0
+	r := sw.Measure("work", func() {
+		run++
+		clock.Advance(time.Duration(run) * time.Millisecond)
+	}, 4)
+
+	if run != 4 {
+		t.Fatalf("fn ran %d times, want 4", run)
+	}
+	wantLaps := []Lap{{"Run 1", time.Millisecond}, {"Run 2", 2 * time.Millisecond}, {"Run 3", 3 * time.Millisecond}, {"Run 4", 4 * time.Millisecond}}
+	if len(r.Laps) != len(wantLaps) {
+		t.Fatalf("laps = %v, want %v", r.Laps, wantLaps)
+	}
+	for i := range wantLaps {
+		if r.Laps[i] != wantLaps[i] {
+			t.Errorf("lap %d = %v, want %v", i, r.Laps[i], wantLaps[i])
+		}
+	}
+	want := "work: 4 laps, total 10ms, mean 2.5ms, min 1ms, max 4ms, p50 2ms, p90 4ms, p99 4ms"
+	if r.String() != want {
+		t.Errorf("String() =\n%s\nwant\n%s", r, want)
+	}
+	if sw.Running() {
+		t.Error("stopwatch left running")
+	}
+
+	if r := Measure("nothing", func() {}, 0); len(r.Laps) != 0 || r.String() != "nothing: no laps" {
+		t.Errorf("Measure with 0 iterations = %v", r)
+	}
+	if r := Measure("real clock", func() {}, 3); len(r.Laps) != 3 || r.Min < 0 {
+		t.Errorf("Measure with the real clock = %v", r)
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	// Embed the zone database so zones resolve the same way on Windows,
+	// which has no /usr/share/zoneinfo
+	_ "time/tzdata"
+)
+
+// zoneAbbreviations maps common abbreviations to the IANA zone they are
+// usually meant as. The zone follows daylight saving time, so "EST" in
+// July gives EDT: people write EST for New York time all year round.
+// Abbreviations are matched case-insensitively.
+var zoneAbbreviations = map[string]string{
+	"UTC": "UTC", "GMT": "UTC", "Z": "UTC",
+	"ET": "America/New_York", "EST": "America/New_York", "EDT": "America/New_York",
+	"CT": "America/Chicago", "CST": "America/Chicago", "CDT": "America/Chicago",
+	"MT": "America/Denver", "MST": "America/Denver", "MDT": "America/Denver",
+	"PT": "America/Los_Angeles", "PST": "America/Los_Angeles", "PDT": "America/Los_Angeles",
+	"AKST": "America/Anchorage", "AKDT": "America/Anchorage",
+	"HST": "Pacific/Honolulu",
+	"BST": "Europe/London",
+	"CET": "Europe/Paris", "CEST": "Europe/Paris",
+	"EET": "Europe/Athens", "EEST": "Europe/Athens",
+	"IST": "Asia/Kolkata",
+	"SGT": "Asia/Singapore", "HKT": "Asia/Hong_Kong",
+	"JST": "Asia/Tokyo", "KST": "Asia/Seoul",
+	"AEST": "Australia/Sydney", "AEDT": "Australia/Sydney",
+	"NZST": "Pacific/Auckland", "NZDT": "Pacific/Auckland",
+}
+
+// commonZones are the zones offered as suggestions for a name that does
+// not resolve
+var commonZones = []string{
+	"UTC",
+	"America/New_York", "America/Chicago", "America/Denver", "America/Los_Angeles",
+	"America/Anchorage", "America/Toronto", "America/Vancouver", "America/Mexico_City",
+	"America/Sao_Paulo", "America/Buenos_Aires", "America/Bogota", "America/Halifax",
+	"Pacific/Honolulu", "Pacific/Auckland",
+	"Europe/London", "Europe/Dublin", "Europe/Lisbon", "Europe/Paris", "Europe/Berlin",
+	"Europe/Madrid", "Europe/Rome", "Europe/Amsterdam", "Europe/Stockholm",
+	"Europe/Athens", "Europe/Helsinki", "Europe/Istanbul", "Europe/Moscow",
+	"Africa/Cairo", "Africa/Lagos", "Africa/Johannesburg", "Africa/Nairobi",
+	"Asia/Dubai", "Asia/Karachi", "Asia/Kolkata", "Asia/Kathmandu", "Asia/Dhaka",
+	"Asia/Bangkok", "Asia/Jakarta", "Asia/Singapore", "Asia/Hong_Kong", "Asia/Shanghai",
+	"Asia/Taipei", "Asia/Manila", "Asia/Seoul", "Asia/Tokyo",
+	"Australia/Perth", "Australia/Adelaide", "Australia/Brisbane", "Australia/Sydney",
+	"Australia/Melbourne",
+}
+
+// UnknownZoneError is returned by ResolveZone for a name that is neither
+// an IANA zone nor a known abbreviation
+type UnknownZoneError struct {
+	Name        string
+	Suggestions []string
+}
+
+func (e *UnknownZoneError) Error() string {
+	if len(e.Suggestions) == 0 {
+		return fmt.Sprintf("unknown time zone %q", e.Name)
+	}
+	return fmt.Sprintf("unknown time zone %q (did you mean %s?)", e.Name, strings.Join(e.Suggestions, ", "))
+}
+
+// ResolveZone loads an IANA zone such as "Asia/Tokyo", "Local", or one of
+// the abbreviations in zoneAbbreviations
+func ResolveZone(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, &UnknownZoneError{Name: name}
+	}
+	// Abbreviations first: "EST" is also a fixed -05:00 zone in the
+	// database, which would silently ignore daylight saving time
+	if zone, ok := zoneAbbreviations[strings.ToUpper(name)]; ok {
+		return time.LoadLocation(zone)
+	}
+	if loc, err := time.LoadLocation(name); err == nil {
+		return loc, nil
+	}
+	return nil, &UnknownZoneError{Name: name, Suggestions: suggestZones(name)}
+}
+
+// suggestZones returns up to three zones close to name: same name in
+// another case or with spaces for underscores, a matching city, or a
+// small edit distance
+func suggestZones(name string) []string {
+	normalized := normalizeZoneName(name)
+
+	type candidate struct {
+		zone     string
+		distance int
+	}
+	var candidates []candidate
+	for _, zone := range commonZones {
+		z := normalizeZoneName(zone)
+		city := z[strings.LastIndex(z, "/")+1:]
+		switch {
+		case z == normalized, city == normalized:
+			candidates = append(candidates, candidate{zone, 0})
+		case strings.Contains(city, normalized) && len(normalized) >= 3:
+			candidates = append(candidates, candidate{zone, 1})
+		default:
+			// Compare with the whole name, or just the city when no
+			// region was given
+			d := levenshtein(normalized, z)
+			if !strings.Contains(normalized, "/") {
+				d = levenshtein(normalized, city)
+			}
+			if d <= 3 && d < len(normalized)/2+1 {
+				candidates = append(candidates, candidate{zone, d + 1})
+			}
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].distance < candidates[j].distance })
+	var suggestions []string
+	for _, c := range candidates {
+		if len(suggestions) == 3 {
+			break
+		}
+		suggestions = append(suggestions, c.zone)
+	}
+	return suggestions
+}
+
+func normalizeZoneName(name string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(name), " ", "_"))
+}
+
+// levenshtein counts the single-byte edits turning a into b
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestResolveZone(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Asia/Tokyo", "Asia/Tokyo"},
+		{"US/Eastern", "US/Eastern"},
+		{"UTC", "UTC"},
+		{"EST", "America/New_York"},
+		{"edt", "America/New_York"},
+		{"PST", "America/Los_Angeles"},
+		{"GMT", "UTC"},
+		{" JST ", "Asia/Tokyo"},
+	}
+	for _, tt := range tests {
+		loc, err := ResolveZone(tt.name)
+		if err != nil || loc.String() != tt.want {
+			t.Errorf("ResolveZone(%q) = %v, %v; want %s", tt.name, loc, err, tt.want)
+		}
+	}
+
+	// EST follows New York's daylight saving time instead of staying -05:00
+	est, _ := ResolveZone("EST")
+	if _, offset := time.Date(2024, time.July, 1, 12, 0, 0, 0, est).Zone(); offset != -4*3600 {
+		t.Errorf("EST in July: offset %d, want -4h", offset)
+	}
+}
+
+func TestResolveZoneSuggestions(t *testing.T) {
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"Asia/Tokio", []string{"Asia/Tokyo"}},
+		{"america/new_york", []string{"America/New_York"}},
+		{"New York", []string{"America/New_York"}},
+		{"Sydnee", []string{"Australia/Sydney"}},
+		{"Europe/Londn", []string{"Europe/London"}},
+		{"Mars/Olympus_Mons", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		_, err := ResolveZone(tt.name)
+		var unknown *UnknownZoneError
+		if !errors.As(err, &unknown) {
+			t.Errorf("ResolveZone(%q) err = %v, want *UnknownZoneError", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(unknown.Suggestions, tt.want) {
+			t.Errorf("ResolveZone(%q) suggestions = %q, want %q", tt.name, unknown.Suggestions, tt.want)
+		}
+	}
+
+	err := &UnknownZoneError{Name: "Asia/Tokio", Suggestions: []string{"Asia/Tokyo"}}
+	if got := err.Error(); got != `unknown time zone "Asia/Tokio" (did you mean Asia/Tokyo?)` {
+		t.Errorf("Error() = %q", got)
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"tokyo", "tokyo", 0},
+		{"tokio", "tokyo", 1},
+		{"londn", "london", 1},
+		{"", "abc", 3},
+		{"kitten", "sitting", 3},
+	}
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}